@@ -0,0 +1,27 @@
+// Package autenticacion reúne lo que el controlador y el modelo deben calcular exactamente igual
+// para autenticarse entre sí.
+package autenticacion
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Cabeceras de la solicitud firmada con la que un servicio pide su token interno
+const (
+	HeaderClienteServicio   = "X-Client-ID"
+	HeaderTimestampServicio = "X-Timestamp"
+	HeaderFirmaServicio     = "X-Service-Signature"
+)
+
+// DesafioTokenInvalido es el WWW-Authenticate con el que el modelo responde un 401 por token
+// interno ausente, inválido o vencido (RFC 6750). Sólo ante ese 401 el cliente pide otro token.
+const DesafioTokenInvalido = `Bearer error="invalid_token"`
+
+// FirmarSolicitud calcula el HMAC-SHA256 (hex) de "clientID\ntimestamp\nMETODO\nruta"
+func FirmarSolicitud(secreto, clientID, timestamp, metodo, ruta string) string {
+	mac := hmac.New(sha256.New, []byte(secreto))
+	mac.Write([]byte(clientID + "\n" + timestamp + "\n" + metodo + "\n" + ruta))
+	return hex.EncodeToString(mac.Sum(nil))
+}
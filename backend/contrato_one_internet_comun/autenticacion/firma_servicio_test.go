@@ -0,0 +1,11 @@
+package autenticacion
+
+import "testing"
+
+func TestFirmarSolicitud(t *testing.T) {
+	// Calculado con: printf 'controlador\n1700000000\nPOST\n/api/v1/internal/auth/token' | openssl dgst -sha256 -hmac secreto
+	const esperada = "1564868b6366e2c53f4dc5be164686e1dd3418b86ce14fe27722805c814836ed"
+	if firma := FirmarSolicitud("secreto", "controlador", "1700000000", "POST", "/api/v1/internal/auth/token"); firma != esperada {
+		t.Errorf("firma = %s, se esperaba %s", firma, esperada)
+	}
+}
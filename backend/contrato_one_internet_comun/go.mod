@@ -0,0 +1,3 @@
+module contrato_one_internet_comun
+
+go 1.25.0
@@ -10,3 +10,7 @@ require (
 require github.com/joho/godotenv v1.5.1
 
 require golang.org/x/text v0.31.0
+
+require contrato_one_internet_comun v0.0.0
+
+replace contrato_one_internet_comun => ../contrato_one_internet_comun
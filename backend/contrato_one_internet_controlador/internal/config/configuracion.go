@@ -3,12 +3,18 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	APIPort                  string
 	ModelURL                 string
+	ModelClientID            string // Identificador de este servicio ante el modelo
+	ModelClientSecret        string // Secreto compartido para firmar la solicitud de token interno
+	ModelCAFile              string // CA para verificar el certificado del modelo (HTTPS)
+	ModelClientCertFile      string // Certificado de cliente para mTLS (opcional)
+	ModelClientKeyFile       string
 	JWTSecret                string
 	JWTExpiration            time.Duration
 	JWTExpirationMinutes     int // Valor en minutos para logs
@@ -24,9 +30,39 @@ type Config struct {
 	EmailResetTemplatePath   string // Plantilla para recuperación de contraseña
 	EmailCredentialsTemplatePath string // Plantilla para envío de credenciales en registro asistido
 	EmailTokenFirmaTemplatePath   string // Plantilla para envío de token de firma digital
+	EmailNotificacionTemplatePath string // Plantilla para notificaciones enviadas por email
 	LogoLightPath            string
 	LogoDarkPath             string
 	FrontendPasswordResetURL string
+
+	// Entrega de notificaciones por canales externos
+	NotifIntervaloSegundos   int    // Cada cuánto el despachador busca entregas pendientes
+	NotifEmailProveedor      string // "smtp" (default) o "fake"
+	NotifSMSProveedor        string // "twilio", "fake" o vacío (deshabilitado)
+	TwilioAPIURL             string
+	TwilioAccountSID         string
+	TwilioAuthToken          string
+	TwilioFrom               string // Número E.164 o Messaging Service SID
+	NotifWhatsAppProveedor   string // "cloud" (WhatsApp Cloud API), "fake" o vacío
+	WhatsAppAPIURL           string
+	WhatsAppPhoneNumberID    string
+	WhatsAppAccessToken      string
+	NotifPushProveedor       string // "vapid", "fake" o vacío
+	VAPIDPrivateKey          string // Clave privada P-256 en base64url (32 bytes)
+	VAPIDSubject             string // mailto: de contacto para los servicios push
+
+	// Notificaciones en tiempo real (Server-Sent Events)
+	NotifStreamIntervaloSegundos int // Cada cuánto el hub consulta notificaciones nuevas al modelo
+
+	// Límite de intentos de login por IP y por minuto (0 = sin límite)
+	LoginMaxIntentosPorMinuto int
+
+	// Proxies (IPs o redes CIDR) cuyas cabeceras X-Forwarded-For y X-Real-IP se aceptan
+	ProxiesConfiables []string
+
+	// Autenticación en dos pasos
+	DosFactoresRolesObligatorios []string // Roles que sólo operan con sesiones de dos factores
+	DosFactoresDesafioMinutos    int      // Vigencia del token de desafío entre la contraseña y el código
 }
 
 func GetConfig() Config {
@@ -35,6 +71,11 @@ func GetConfig() Config {
 	return Config{
 		APIPort:              getEnv("API_PORT", "8080"),
 		ModelURL:             getEnv("MODEL_URL", "http://localhost:8081"),
+		ModelClientID:        getEnv("MODEL_CLIENT_ID", "controlador"),
+		ModelClientSecret:    getEnv("MODEL_CLIENT_SECRET", ""),
+		ModelCAFile:          getEnv("MODEL_CA_FILE", ""),
+		ModelClientCertFile:  getEnv("MODEL_CLIENT_CERT_FILE", ""),
+		ModelClientKeyFile:   getEnv("MODEL_CLIENT_KEY_FILE", ""),
 		JWTSecret:            getEnv("JWT_SECRET", "default-secret"),
 		JWTExpiration:        jwtExpiration,
 		JWTExpirationMinutes: jwtMinutes,
@@ -51,10 +92,34 @@ func GetConfig() Config {
 		EmailResetTemplatePath: getEnv("EMAIL_RESET_TEMPLATE_PATH", "assets/mail_templates/mail-recuperar.html"),
 		EmailCredentialsTemplatePath: getEnv("EMAIL_CREDENCIALES_TEMPLATE_PATH", "assets/mail_templates/mail-credenciales.html"),
 		EmailTokenFirmaTemplatePath: getEnv("EMAIL_TOKEN_FIRMA_TEMPLATE_PATH", "assets/mail_templates/mail-token-firma.html"),
+		EmailNotificacionTemplatePath: getEnv("EMAIL_NOTIFICACION_TEMPLATE_PATH", "assets/mail_templates/mail-notificacion.html"),
 		LogoLightPath:          getEnv("LOGO_LIGHT_PATH", "assets/logo-light.png"),
 		LogoDarkPath:           getEnv("LOGO_DARK_PATH", "assets/logo-dark.png"),
 		FrontendPasswordResetURL: getEnv("FRONTEND_PASSWORD_RESET_URL", ""),
 		//FrontendPasswordResetURL: passwordResetURL,
+		NotifIntervaloSegundos: getEnvInt("NOTIF_INTERVALO_SEGUNDOS", 15),
+		NotifEmailProveedor:    getEnv("NOTIF_EMAIL_PROVEEDOR", "smtp"),
+		NotifSMSProveedor:      getEnv("NOTIF_SMS_PROVEEDOR", ""),
+		TwilioAPIURL:           getEnv("TWILIO_API_URL", "https://api.twilio.com"),
+		TwilioAccountSID:       getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:        getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioFrom:             getEnv("TWILIO_FROM", ""),
+		NotifWhatsAppProveedor: getEnv("NOTIF_WHATSAPP_PROVEEDOR", ""),
+		WhatsAppAPIURL:         getEnv("WHATSAPP_API_URL", "https://graph.facebook.com/v20.0"),
+		WhatsAppPhoneNumberID:  getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
+		WhatsAppAccessToken:    getEnv("WHATSAPP_ACCESS_TOKEN", ""),
+		NotifPushProveedor:     getEnv("NOTIF_PUSH_PROVEEDOR", ""),
+		VAPIDPrivateKey:        getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:           getEnv("VAPID_SUBJECT", "mailto:soporte@oneinternet.com.ar"),
+
+		NotifStreamIntervaloSegundos: getEnvInt("NOTIF_STREAM_INTERVALO_SEGUNDOS", 2),
+
+		LoginMaxIntentosPorMinuto: getEnvInt("LOGIN_MAX_INTENTOS_POR_MINUTO", 10),
+
+		ProxiesConfiables: getEnvLista("PROXIES_CONFIABLES", ""),
+
+		DosFactoresRolesObligatorios: getEnvLista("DOS_FACTORES_ROLES_OBLIGATORIOS", "admin,verificador,atencion"),
+		DosFactoresDesafioMinutos:    getEnvInt("DOS_FACTORES_DESAFIO_MINUTOS", 5),
 	}
 }
 
@@ -65,6 +130,27 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvInt lee una variable entera; si no existe o es inválida usa el valor por defecto
+func getEnvInt(key string, fallback int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// getEnvLista lee una lista separada por comas, sin espacios ni elementos vacíos
+func getEnvLista(key, fallback string) []string {
+	var lista []string
+	for _, v := range strings.Split(getEnv(key, fallback), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			lista = append(lista, v)
+		}
+	}
+	return lista
+}
+
 // getJWTExpirationConfig lee la configuración de expiración JWT
 // Prioriza JWT_EXPIRATION_MINUTES, luego JWT_EXPIRATION_HOURS
 // Retorna (duration, minutos) para usar en logs
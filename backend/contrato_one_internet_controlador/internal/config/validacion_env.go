@@ -12,12 +12,24 @@ func ValidarConfig(cfg Config) error {
 	if cfg.ModelURL == "" {
 		return errors.New("MODEL_URL requerido")
 	}
+	if cfg.ModelClientID == "" {
+		return errors.New("MODEL_CLIENT_ID requerido")
+	}
+	if cfg.ModelClientSecret == "" {
+		return errors.New("MODEL_CLIENT_SECRET requerido")
+	}
+	if (cfg.ModelClientCertFile == "") != (cfg.ModelClientKeyFile == "") {
+		return errors.New("MODEL_CLIENT_CERT_FILE y MODEL_CLIENT_KEY_FILE deben definirse juntos")
+	}
 	if cfg.JWTSecret == "" {
 		return errors.New("JWT_SECRET requerido")
 	}
 	if cfg.JWTExpiration <= 0 {
 		return fmt.Errorf("JWT_EXPIRATION_HOURS debe ser mayor a 0 (actual: %s)", cfg.JWTExpiration)
 	}
+	if cfg.DosFactoresDesafioMinutos <= 0 {
+		return errors.New("DOS_FACTORES_DESAFIO_MINUTOS debe ser mayor a 0")
+	}
 	if cfg.SMTPUser == "" {
 		return errors.New("SMTP_USER requerido")
 	}
@@ -30,5 +42,48 @@ func ValidarConfig(cfg Config) error {
 	if cfg.SMTPPort == "" {
 		return errors.New("SMTP_PORT requerido")
 	}
+	return validarConfigNotificaciones(cfg)
+}
+
+// validarConfigNotificaciones exige las credenciales del proveedor elegido en cada canal
+func validarConfigNotificaciones(cfg Config) error {
+	if cfg.NotifIntervaloSegundos <= 0 {
+		return errors.New("NOTIF_INTERVALO_SEGUNDOS debe ser mayor a 0")
+	}
+	if cfg.NotifStreamIntervaloSegundos <= 0 {
+		return errors.New("NOTIF_STREAM_INTERVALO_SEGUNDOS debe ser mayor a 0")
+	}
+	switch cfg.NotifEmailProveedor {
+	case "smtp", "fake":
+	default:
+		return errors.New("NOTIF_EMAIL_PROVEEDOR debe ser 'smtp' o 'fake'")
+	}
+	switch cfg.NotifSMSProveedor {
+	case "", "fake":
+	case "twilio":
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioFrom == "" {
+			return errors.New("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN y TWILIO_FROM son requeridos con NOTIF_SMS_PROVEEDOR=twilio")
+		}
+	default:
+		return errors.New("NOTIF_SMS_PROVEEDOR debe ser 'twilio', 'fake' o vacío")
+	}
+	switch cfg.NotifWhatsAppProveedor {
+	case "", "fake":
+	case "cloud":
+		if cfg.WhatsAppPhoneNumberID == "" || cfg.WhatsAppAccessToken == "" {
+			return errors.New("WHATSAPP_PHONE_NUMBER_ID y WHATSAPP_ACCESS_TOKEN son requeridos con NOTIF_WHATSAPP_PROVEEDOR=cloud")
+		}
+	default:
+		return errors.New("NOTIF_WHATSAPP_PROVEEDOR debe ser 'cloud', 'fake' o vacío")
+	}
+	switch cfg.NotifPushProveedor {
+	case "", "fake":
+	case "vapid":
+		if cfg.VAPIDPrivateKey == "" {
+			return errors.New("VAPID_PRIVATE_KEY es requerido con NOTIF_PUSH_PROVEEDOR=vapid")
+		}
+	default:
+		return errors.New("NOTIF_PUSH_PROVEEDOR debe ser 'vapid', 'fake' o vacío")
+	}
 	return nil
 }
@@ -53,7 +53,7 @@ func (h *AuthHandler) setRefreshCookie(w http.ResponseWriter, refreshToken strin
 
 	http.SetCookie(w, cookie)
 
-	logger.Info.Printf("Seteada cookie refresh_token: %s", refreshToken)
+	logger.Debug.Println("Seteada cookie refresh_token")
 }
 
 // deleteRefreshCookie limpia la cookie (útil para logout)
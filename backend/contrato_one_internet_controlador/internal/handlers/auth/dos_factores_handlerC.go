@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/config"
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+)
+
+// DosFactoresHandler expone al usuario autenticado la configuración de su autenticación en
+// dos pasos y a los administradores el restablecimiento de la de otro usuario
+type DosFactoresHandler struct {
+	service *servicios.DosFactoresService
+	cfg     *config.Config
+}
+
+func NewDosFactoresHandler(service *servicios.DosFactoresService, cfg *config.Config) *DosFactoresHandler {
+	return &DosFactoresHandler{service: service, cfg: cfg}
+}
+
+// Estado maneja GET /v1/api/auth/2fa
+func (h *DosFactoresHandler) Estado(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.Estado(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "consultando autenticación en dos pasos")
+		return
+	}
+	resp.Obligatorio = utilidades.Requiere2FA(claims.Roles, h.cfg)
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// IniciarTOTP maneja POST /v1/api/auth/2fa/totp: devuelve el secreto y el QR para la app
+func (h *DosFactoresHandler) IniciarTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.IniciarTOTP(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "iniciando TOTP")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ActivarTOTP maneja POST /v1/api/auth/2fa/totp/activar. Al activarse se cierran todas las
+// sesiones, incluida la actual: el próximo refresh falla y hay que volver a iniciar sesión.
+func (h *DosFactoresHandler) ActivarTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	req, ok := decodificarCodigo(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ActivarTOTP(r.Context(), claims.IDPersona, req.Codigo)
+	if err != nil {
+		responderErrorModelo(w, err, "activando TOTP")
+		return
+	}
+	resp.Mensaje = "Autenticación en dos pasos activada. Guardá los códigos de recuperación y volvé a iniciar sesión."
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// RegenerarCodigos maneja POST /v1/api/auth/2fa/recuperacion
+func (h *DosFactoresHandler) RegenerarCodigos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	req, ok := decodificarCodigo(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.RegenerarCodigos(r.Context(), claims.IDPersona, req.Codigo)
+	if err != nil {
+		responderErrorModelo(w, err, "regenerando códigos de recuperación")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// Desactivar maneja DELETE /v1/api/auth/2fa. Los roles que exigen segundo factor no pueden
+// desactivarlo; si perdieron el acceso, un administrador lo restablece.
+func (h *DosFactoresHandler) Desactivar(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	if utilidades.Requiere2FA(claims.Roles, h.cfg) {
+		utilidades.ResponderError(w, http.StatusForbidden, "tu rol exige autenticación en dos pasos")
+		return
+	}
+	req, ok := decodificarCodigo(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Desactivar(r.Context(), claims.IDPersona, req.Codigo); err != nil {
+		responderErrorModelo(w, err, "desactivando autenticación en dos pasos")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Autenticación en dos pasos desactivada"})
+}
+
+// Restablecer maneja DELETE /v1/api/usuarios/{id}/2fa (usuarios.restablecer_2fa)
+func (h *DosFactoresHandler) Restablecer(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id de usuario inválido")
+		return
+	}
+
+	if err := h.service.Restablecer(r.Context(), id, claims.IDPersona); err != nil {
+		responderErrorModelo(w, err, "restableciendo autenticación en dos pasos")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Autenticación en dos pasos restablecida"})
+}
+
+// decodificarCodigo lee el cuerpo {"codigo": "..."}
+func decodificarCodigo(w http.ResponseWriter, r *http.Request) (modelos.CodigoDosFactoresRequest, bool) {
+	var req modelos.CodigoDosFactoresRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Codigo == "" {
+		utilidades.ResponderError(w, http.StatusBadRequest, "el código es obligatorio")
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// IntentosLoginHandler expone a los administradores los intentos de login y los bloqueos
+// de cuentas e IPs por intentos fallidos
+type IntentosLoginHandler struct {
+	service *servicios.IntentoLoginService
+}
+
+func NewIntentosLoginHandler(service *servicios.IntentoLoginService) *IntentosLoginHandler {
+	return &IntentosLoginHandler{service: service}
+}
+
+// ListarIntentos maneja GET /v1/api/login/intentos?email=&ip=&limit=
+func (h *IntentosLoginHandler) ListarIntentos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.ListarIntentos(r.Context(), r.URL.Query(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "listando intentos de login")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ListarBloqueos maneja GET /v1/api/login/bloqueos
+func (h *IntentosLoginHandler) ListarBloqueos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.ListarBloqueos(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "listando bloqueos de login")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// LevantarBloqueo maneja DELETE /v1/api/login/bloqueos/{id}
+func (h *IntentosLoginHandler) LevantarBloqueo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return
+	}
+
+	if err := h.service.LevantarBloqueo(r.Context(), id, claims.IDPersona); err != nil {
+		responderErrorModelo(w, err, "levantando bloqueo de login")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Bloqueo levantado correctamente"})
+}
+
+func responderErrorModelo(w http.ResponseWriter, err error, accion string) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	logger.Error.Printf("Error %s: %v", accion, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
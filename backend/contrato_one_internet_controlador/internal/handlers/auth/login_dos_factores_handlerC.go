@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// LoginSegundoFactor maneja POST /v1/auth/login/2fa: canjea el token de desafío y el código
+// del segundo factor por la sesión
+func (h *AuthHandler) LoginSegundoFactor(w http.ResponseWriter, r *http.Request) {
+	var req modelos.SegundoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	userAgent := r.Header.Get("User-Agent")
+	clientIP := utilidades.ObtenerIPCliente(r)
+
+	resp, err := h.authService.VerificarSegundoFactor(r.Context(), &req, clientIP, userAgent)
+	if err != nil {
+		responderErrorLogin(w, err)
+		return
+	}
+
+	h.completarLogin(w, resp, resp.Email, clientIP, userAgent)
+}
+
+// EnviarCodigoEmail maneja POST /v1/auth/login/2fa/email: manda por email un código de
+// respaldo para quien no tiene a mano la app autenticadora
+func (h *AuthHandler) EnviarCodigoEmail(w http.ResponseWriter, r *http.Request) {
+	var req modelos.CodigoEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	codigo, err := h.authService.SolicitarCodigoEmail(r.Context(), req.TokenDesafio)
+	if err != nil {
+		responderErrorLogin(w, err)
+		return
+	}
+
+	expira, err := time.Parse(time.RFC3339, codigo.ExpiresAt)
+	if err != nil {
+		expira = time.Now().Add(10 * time.Minute)
+	}
+	if err := h.correoService.EnviarCodigoDosFactores(codigo.Email, codigo.Codigo, expira); err != nil {
+		logger.Error.Printf("Error enviando código de verificación a %s: %v", codigo.Email, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "no se pudo enviar el código por email")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Te enviamos un código de verificación por email"})
+}
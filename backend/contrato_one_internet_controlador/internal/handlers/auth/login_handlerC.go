@@ -3,12 +3,13 @@ package auth
 import (
 	"encoding/json"
 	"errors"
-	"net"
 	"net/http"
-	"strings"
+	"time"
 	
 	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
 	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
 )
 
 // Login maneja la autenticación y generación de tokens.
@@ -23,52 +24,69 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Obtener metadatos user-agent e IP
 	userAgent := r.Header.Get("User-Agent")
-	clientIP := obtenerIPClienteValida(r)
+	clientIP := utilidades.ObtenerIPCliente(r)
 
 	resp, err := h.authService.Login(ctx, &req, clientIP, userAgent)
 
 	if err != nil {
-		msg := err.Error()
-		switch {
-		case errors.Is(err, utilidades.ErrCredencialesInvalidas):
-			utilidades.ResponderError(w, http.StatusUnauthorized, "email o contraseña incorrectos")
-		case errors.Is(err, utilidades.ErrEmailNoVerificado):
-			utilidades.ResponderError(w, http.StatusForbidden, "verificación de email pendiente")
-		case errors.Is(err, utilidades.ErrValidacionLogin):
-			utilidades.ResponderError(w, http.StatusBadRequest, "validación fallida")
-		default:
-			utilidades.ResponderError(w, http.StatusInternalServerError, msg)
-		}
+		responderErrorLogin(w, err)
 		return
 	}
 
-	// setRefreshCookie establece la cookie segura HttpOnly para el refresh token
-	h.setRefreshCookie(w, resp.RefreshToken, resp.RefreshExpiresAt)
+	// Cuenta con autenticación en dos pasos: todavía no hay sesión, sólo el token de desafío
+	if resp.Desafio != nil {
+		utilidades.ResponderJSON(w, http.StatusOK, resp.Desafio)
+		return
+	}
 
-	// Devolver solo el token de acceso en el cuerpo JSON
-	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"token": resp.Token})
+	h.completarLogin(w, resp, req.Email, clientIP, userAgent)
 }
 
-
-// obtenerIPClienteValida retorna la IP del cliente verificando cabeceras proxy y validando que sea una IP IPv4 o IPv6 válida.
-func obtenerIPClienteValida(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		parts := strings.Split(xff, ",")
-		if len(parts) > 0 {
-			ip := strings.TrimSpace(parts[0])
-			if net.ParseIP(ip) != nil {
-				return ip
+// completarLogin avisa del dispositivo nuevo, setea la cookie del refresh token y devuelve el JWT
+func (h *AuthHandler) completarLogin(w http.ResponseWriter, resp *modelos.LoginResponse, email, clientIP, userAgent string) {
+	// Avisar por email del login desde una IP o dispositivo que el usuario no había usado
+	if resp.DispositivoNuevo && h.correoService != nil && email != "" {
+		go func(email string) {
+			if err := h.correoService.EnviarAlertaInicioSesion(email, clientIP, userAgent, time.Now()); err != nil {
+				logger.Error.Printf("Error enviando alerta de inicio de sesión a %s: %v", email, err)
 			}
-		}
+		}(email)
 	}
-	if rip := strings.TrimSpace(r.Header.Get("X-Real-IP")); rip != "" {
-		if net.ParseIP(rip) != nil {
-			return rip
-		}
+
+	// setRefreshCookie establece la cookie segura HttpOnly para el refresh token
+	h.setRefreshCookie(w, resp.RefreshToken, resp.RefreshExpiresAt)
+
+	// Devolver solo el token de acceso en el cuerpo JSON; a los roles que lo exigen se les
+	// avisa que todavía tienen que configurar el segundo factor
+	body := map[string]interface{}{"token": resp.Token}
+	if resp.Configurar2FA {
+		body["configurar_2fa"] = true
 	}
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil && net.ParseIP(host) != nil {
-		return host
+	utilidades.ResponderJSON(w, http.StatusOK, body)
+}
+
+// responderErrorLogin traduce los errores del login (ambos pasos) a respuestas HTTP
+func responderErrorLogin(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, utilidades.ErrCredencialesInvalidas):
+		utilidades.ResponderError(w, http.StatusUnauthorized, "email o contraseña incorrectos")
+	case errors.Is(err, utilidades.ErrEmailNoVerificado):
+		utilidades.ResponderError(w, http.StatusForbidden, "verificación de email pendiente")
+	case errors.Is(err, utilidades.ErrValidacionLogin):
+		utilidades.ResponderError(w, http.StatusBadRequest, "validación fallida")
+	case errors.Is(err, utilidades.ErrDesafio2FAInvalido):
+		utilidades.ResponderError(w, http.StatusUnauthorized, "el desafío venció o no es válido: iniciá sesión de nuevo")
+	case errors.Is(err, utilidades.ErrCodigo2FAInvalido):
+		utilidades.ResponderError(w, http.StatusUnauthorized, "código de verificación incorrecto")
+	case errors.Is(err, utilidades.ErrLoginBloqueado):
+		// El modelo informa cuánto falta para poder reintentar
+		var modeloErr *servicios.ModeloError
+		if errors.As(err, &modeloErr) {
+			msg = modeloErr.Message
+		}
+		utilidades.ResponderError(w, http.StatusTooManyRequests, msg)
+	default:
+		utilidades.ResponderError(w, http.StatusInternalServerError, msg)
 	}
-	return r.RemoteAddr
-}
\ No newline at end of file
+}
@@ -18,7 +18,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	// Log seguro (solo si existe token)
 	if refreshToken != "" {
-		logger.Info.Printf("Procesando logout para refresh token: %s", refreshToken)
+		logger.Info.Println("Procesando logout con refresh token")
 		// Revocar refresh token en backend (best effort)
 		_ = h.authService.Logout(ctx, refreshToken)
 	}else {
@@ -30,7 +30,7 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Llamar al servicio (Rotación de tokens)
-	resp, err := h.authService.Refresh(r.Context(), refreshToken)
+	resp, err := h.authService.Refresh(r.Context(), refreshToken, utilidades.ObtenerIPCliente(r), r.Header.Get("User-Agent"))
 	if err != nil {
 		// Si falla el refresh, asegura de borrar cualquier cookie vieja
 		h.deleteRefreshCookie(w)
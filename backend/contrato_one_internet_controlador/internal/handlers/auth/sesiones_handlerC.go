@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+)
+
+// SesionesHandler expone al usuario sus sesiones abiertas y a los administradores el cierre
+// forzado de las sesiones de otro usuario. Cerrar una sesión revoca sus refresh tokens; los
+// JWT de acceso ya emitidos valen hasta que vencen.
+type SesionesHandler struct {
+	service *servicios.SesionService
+}
+
+func NewSesionesHandler(service *servicios.SesionService) *SesionesHandler {
+	return &SesionesHandler{service: service}
+}
+
+// Listar maneja GET /v1/api/perfil/sesiones
+func (h *SesionesHandler) Listar(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.Listar(r.Context(), claims.IDPersona, claims.IDSesion)
+	if err != nil {
+		responderErrorModelo(w, err, "listando sesiones")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// Revocar maneja DELETE /v1/api/perfil/sesiones/{id}
+func (h *SesionesHandler) Revocar(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id de sesión inválido")
+		return
+	}
+
+	if err := h.service.Revocar(r.Context(), claims.IDPersona, id); err != nil {
+		responderErrorModelo(w, err, "cerrando sesión")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Sesión cerrada"})
+}
+
+// RevocarOtras maneja DELETE /v1/api/perfil/sesiones: cierra todas las sesiones menos la actual
+func (h *SesionesHandler) RevocarOtras(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	// Un token emitido antes de las sesiones no sabe cuál es la suya: se cerrarían todas
+	if claims.IDSesion == 0 {
+		utilidades.ResponderError(w, http.StatusConflict, "volvé a iniciar sesión para poder cerrar las demás sesiones")
+		return
+	}
+
+	resp, err := h.service.RevocarOtras(r.Context(), claims.IDPersona, claims.IDSesion)
+	if err != nil {
+		responderErrorModelo(w, err, "cerrando sesiones")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// CerrarSesionesUsuario maneja DELETE /v1/api/usuarios/{id}/sesiones (usuarios.cerrar_sesiones)
+func (h *SesionesHandler) CerrarSesionesUsuario(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id de usuario inválido")
+		return
+	}
+
+	resp, err := h.service.CerrarSesionesUsuario(r.Context(), id, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "cerrando sesiones de un usuario")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
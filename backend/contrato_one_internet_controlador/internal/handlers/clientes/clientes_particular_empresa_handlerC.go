@@ -2,12 +2,17 @@ package clientes
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 
+	"contrato_one_internet_controlador/internal/middleware"
 	"contrato_one_internet_controlador/internal/modelos"
+	clientesModelos "contrato_one_internet_controlador/internal/modelos/clientes"
 	"contrato_one_internet_controlador/internal/servicios"
 	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
 	"contrato_one_internet_controlador/internal/validadores"
 )
 
@@ -57,48 +62,97 @@ func (h *ClientesHandler) CrearClienteParticularHandler(w http.ResponseWriter, r
 }
 
 // ****************************************************************************
-// CrearClienteEmpresaHandler maneja la creación de clientes empresa.
+// CrearClienteEmpresaHandler maneja el alta de clientes empresa.
 // ****************************************************************************
 func (h *ClientesHandler) CrearClienteEmpresaHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	var req struct {
-		Apoderado          modelos.Persona   `json:"apoderado"`
-		DireccionApoderado modelos.Direccion `json:"direccion_apoderado"`
-		Empresa            modelos.Empresa   `json:"empresa"`
-		DireccionEmpresa   modelos.Direccion `json:"direccion_empresa"`
+	claims, ok := middleware.GetClaimsFromContext(ctx)
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
 	}
 
+	var req clientesModelos.CrearClienteEmpresaRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido o mal formado")
 		return
 	}
 
-	// Validaciones
-	if err := validadores.ValidarDireccion(req.DireccionApoderado); err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "Datos de dirección del apoderado inválidos: "+err.Error())
+	// Sólo el personal puede dar de alta una empresa a nombre de otra persona
+	esEmpleado := claims.HasRole("admin") || claims.HasRole("atencion")
+	if req.IDPersonaContacto != nil && *req.IDPersonaContacto != claims.IDPersona && !esEmpleado {
+		utilidades.ResponderError(w, http.StatusForbidden, "No tienes permisos para registrar empresas a nombre de terceros")
 		return
 	}
-	if err := validadores.ValidarPersona(req.Apoderado); err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "Datos del apoderado inválidos: "+err.Error())
+
+	// Normalizar CUIT (se aceptan guiones y espacios)
+	req.Empresa.Cuit = utilidades.NormalizarCUIT(req.Empresa.Cuit)
+	if strings.TrimSpace(req.Empresa.NombreComercial) == "" {
+		req.Empresa.NombreComercial = req.Empresa.RazonSocial
+	}
+
+	if req.Direccion != nil {
+		dir := *req.Direccion
+		dir.Calle = utilidades.NormalizeCalle(dir.Calle)
+		dir.Numero = utilidades.NormalizeNumero(dir.Numero)
+		dir.CodigoPostal = utilidades.NormalizeCodigoPostal(dir.CodigoPostal)
+		dir.Piso = normalizarOpcional(dir.Piso)
+		dir.Depto = normalizarOpcional(dir.Depto)
+		req.Direccion = &dir
+	}
+
+	if err := validadores.ValidarClienteEmpresa(req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	if err := validadores.ValidarDireccion(req.DireccionEmpresa); err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "Datos de dirección de la empresa inválidos: "+err.Error())
+
+	resp, err := h.service.CrearClienteEmpresa(ctx, req, claims.IDUsuario)
+	if err != nil {
+		var modeloErr *servicios.ModeloError
+		if errors.As(err, &modeloErr) {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+		logger.Error.Printf("Error creando cliente empresa: %v", err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
 		return
 	}
-	if err := validadores.ValidarEmpresa(req.Empresa); err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "Datos de la empresa inválidos: "+err.Error())
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// ListarMisEmpresasHandler maneja GET /v1/api/perfil/empresas
+func (h *ClientesHandler) ListarMisEmpresasHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
 		return
 	}
 
-	// Llamar al servicio
-	resp, err := h.service.CrearClienteEmpresa(ctx, req.Apoderado, req.DireccionApoderado, req.Empresa, req.DireccionEmpresa)
+	resp, err := h.service.ListarEmpresasDePersona(r.Context(), claims.IDPersona)
 	if err != nil {
-		log.Printf("Error desde el servicio de clientes: %v", err)
-		utilidades.ResponderError(w, http.StatusConflict, err.Error())
+		var modeloErr *servicios.ModeloError
+		if errors.As(err, &modeloErr) {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+		logger.Error.Printf("Error listando empresas de persona %d: %v", claims.IDPersona, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
 		return
 	}
 
-	utilidades.ResponderJSON(w, http.StatusCreated, resp)
-}
\ No newline at end of file
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// normalizarOpcional normaliza un campo opcional de dirección; si queda vacío devuelve nil
+func normalizarOpcional(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	n := utilidades.NormalizeOptionalField(v)
+	if n == nil || *n == "" {
+		return nil
+	}
+	return n
+}
@@ -43,6 +43,33 @@ func (h *ConexionHandler) SolicitarConexionHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// 3-4. Validar permisos y datos de la solicitud
+	if !validarSolicitudConexion(w, claims, &req) {
+		return
+	}
+
+	// 5. Llamar al servicio para procesar la solicitud
+	// Pasar el ID del usuario autenticado
+	response, err := h.conexionService.SolicitarConexionParticular(r.Context(), &req, claims.IDUsuario)
+	if err != nil {
+		var modeloErr *servicios.ModeloError
+		if errors.As(err, &modeloErr) {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+
+		logger.Error.Printf("Error al procesar solicitud de conexión: %v", err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+		return
+	}
+
+	// Responder con éxito - ResponderJSON ya envuelve en {"success": true, "data": ...}
+	utilidades.ResponderJSON(w, http.StatusCreated, response)
+}
+
+// validarSolicitudConexion aplica las reglas de roles, dirección y coordenadas comunes a
+// las solicitudes de conexión. Si algo falla responde el error y devuelve false.
+func validarSolicitudConexion(w http.ResponseWriter, claims *utilidades.ClaimsJWT, req *clientesModelos.SolicitudConexionRequest) bool {
 	// 3. Lógica de Roles (Asumiendo que claims tiene campo Rol o similar)
     esEmpleado := claims.HasRole("admin") || 
 				claims.HasRole("verificador") || 
@@ -51,27 +78,27 @@ func (h *ConexionHandler) SolicitarConexionHandler(w http.ResponseWriter, r *htt
     // Validar intento de suplantación
     if req.IDPersonaCliente != nil && !esEmpleado {
         utilidades.ResponderError(w, http.StatusForbidden, "No tienes permisos para crear solicitudes a nombre de terceros")
-        return
+        return false
     }
 
     // Validar intento de auto-aprobación
     if req.FactibilidadInmediata && !esEmpleado {
         utilidades.ResponderError(w, http.StatusForbidden, "No tienes permisos para aprobar factibilidad")
-        return
+        return false
     }
 
     // 4. Validaciones Técnicas para Factibilidad Inmediata
     if req.FactibilidadInmediata {
         if req.NAP == "" || req.VLAN <= 0 {
             utilidades.ResponderError(w, http.StatusBadRequest, "Para factibilidad inmediata, NAP y VLAN son obligatorios")
-            return
+            return false
         }
     }
 
 	// Validar plan seleccionado
 	if req.IDPlan <= 0 {
 		utilidades.ResponderError(w, http.StatusBadRequest, "id_plan es requerido y debe ser mayor que 0")
-		return
+		return false
 	}
 
 	// Validar que se proporcione exactamente una opción de dirección
@@ -80,12 +107,12 @@ func (h *ConexionHandler) SolicitarConexionHandler(w http.ResponseWriter, r *htt
 
 	if tieneIDDireccion && tieneDireccion {
 		utilidades.ResponderError(w, http.StatusBadRequest, "debe proporcionar id_direccion O direccion, no ambos")
-		return
+		return false
 	}
 
 	if !tieneIDDireccion && !tieneDireccion {
 		utilidades.ResponderError(w, http.StatusBadRequest, "debe proporcionar id_direccion o direccion")
-		return
+		return false
 	}
 
 	// Si se proporciona una nueva dirección, normalizarla y validarla
@@ -131,7 +158,7 @@ func (h *ConexionHandler) SolicitarConexionHandler(w http.ResponseWriter, r *htt
 		// Validar dirección
 		if err := validadores.ValidarDireccion(normalizarDireccion); err != nil {
 			utilidades.ResponderError(w, http.StatusBadRequest, err.Error())
-			return
+			return false
 		}
 
 		// Actualizar la dirección en la solicitud con la normalizada
@@ -144,17 +171,41 @@ func (h *ConexionHandler) SolicitarConexionHandler(w http.ResponseWriter, r *htt
 	// Validar latitud y longitud
 	if req.Latitud < -90 || req.Latitud > 90 {
 		utilidades.ResponderError(w, http.StatusBadRequest, "latitud debe estar entre -90 y 90")
-		return
+		return false
 	}
 
 	if req.Longitud < -180 || req.Longitud > 180 {
 		utilidades.ResponderError(w, http.StatusBadRequest, "longitud debe estar entre -180 y 180")
+		return false
+	}
+
+	return true
+}
+
+// SolicitarConexionEmpresaHandler maneja la solicitud de conexión de una empresa cliente
+func (h *ConexionHandler) SolicitarConexionEmpresaHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
 		return
 	}
 
-	// 5. Llamar al servicio para procesar la solicitud
-	// Pasar el ID del usuario autenticado
-	response, err := h.conexionService.SolicitarConexionParticular(r.Context(), &req, claims.IDUsuario)
+	var req clientesModelos.SolicitudConexionEmpresaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	if req.IDEmpresa <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_empresa es requerido y debe ser mayor que 0")
+		return
+	}
+
+	if !validarSolicitudConexion(w, claims, &req.SolicitudConexionRequest) {
+		return
+	}
+
+	response, err := h.conexionService.SolicitarConexionEmpresa(r.Context(), &req, claims.IDUsuario)
 	if err != nil {
 		var modeloErr *servicios.ModeloError
 		if errors.As(err, &modeloErr) {
@@ -162,12 +213,11 @@ func (h *ConexionHandler) SolicitarConexionHandler(w http.ResponseWriter, r *htt
 			return
 		}
 
-		logger.Error.Printf("Error al procesar solicitud de conexión: %v", err)
+		logger.Error.Printf("Error al procesar solicitud de conexión de empresa %d: %v", req.IDEmpresa, err)
 		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
 		return
 	}
 
-	// Responder con éxito - ResponderJSON ya envuelve en {"success": true, "data": ...}
 	utilidades.ResponderJSON(w, http.StatusCreated, response)
 }
 
@@ -299,10 +349,16 @@ func (h *ConexionHandler) ConfirmarFactibilidadHandler(w http.ResponseWriter, r
 		return
 	}
 
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
 	logger.Info.Printf("Confirmando factibilidad para conexión %d", req.IDConexion)
 
 	// Llamar al servicio
-	response, err := h.conexionService.ConfirmarFactibilidad(r.Context(), &req)
+	response, err := h.conexionService.ConfirmarFactibilidad(r.Context(), &req, claims.IDPersona)
 	if err != nil {
 		var modeloErr *servicios.ModeloError
 		if errors.As(err, &modeloErr) {
@@ -337,10 +393,16 @@ func (h *ConexionHandler) RechazarFactibilidadHandler(w http.ResponseWriter, r *
 		return
 	}
 
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
 	logger.Info.Printf("Rechazando factibilidad para conexión %d", req.IDConexion)
 
 	// Llamar al servicio
-	response, err := h.conexionService.RechazarFactibilidad(r.Context(), &req)
+	response, err := h.conexionService.RechazarFactibilidad(r.Context(), &req, claims.IDPersona)
 	if err != nil {
 		var modeloErr *servicios.ModeloError
 		if errors.As(err, &modeloErr) {
@@ -356,3 +418,81 @@ func (h *ConexionHandler) RechazarFactibilidadHandler(w http.ResponseWriter, r *
 	// Responder con éxito
 	utilidades.ResponderJSON(w, http.StatusOK, response)
 }
+
+// CambiarEstadoConexionHandler maneja POST /v1/api/conexiones/{id}/estado
+func (h *ConexionHandler) CambiarEstadoConexionHandler(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, "conexiones")
+}
+
+// CambiarEstadoContratoHandler maneja POST /v1/api/contratos/{id}/estado
+func (h *ConexionHandler) CambiarEstadoContratoHandler(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, "contratos")
+}
+
+func (h *ConexionHandler) cambiarEstado(w http.ResponseWriter, r *http.Request, entidad string) {
+	defer r.Body.Close()
+
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return
+	}
+
+	var req clientesModelos.CambiarEstadoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+	if req.Estado == "" {
+		utilidades.ResponderError(w, http.StatusBadRequest, "estado es requerido")
+		return
+	}
+
+	response, err := h.conexionService.CambiarEstado(r.Context(), entidad, id, &req, claims.IDPersona)
+	if err != nil {
+		var modeloErr *servicios.ModeloError
+		if errors.As(err, &modeloErr) {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+
+		logger.Error.Printf("Error cambiando estado de %s %d: %v", entidad, id, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+// ObtenerHistorialConexionHandler maneja GET /v1/api/conexiones/{id}/historial
+func (h *ConexionHandler) ObtenerHistorialConexionHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	idConexion, err := strconv.Atoi(idStr)
+	if err != nil || idConexion <= 0 {
+		logger.Error.Printf("ID de conexión inválido: %s", idStr)
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return
+	}
+
+	historial, err := h.conexionService.ObtenerHistorialConexion(r.Context(), idConexion)
+	if err != nil {
+		var modeloErr *servicios.ModeloError
+		if errors.As(err, &modeloErr) {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+
+		logger.Error.Printf("Error obteniendo historial de conexión %d: %v", idConexion, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, historial)
+}
@@ -190,7 +190,7 @@ func (h *PersonasHandler) ObtenerPerfilDireccionHandler(w http.ResponseWriter, r
 
 // ObtenerPerfilUsuarioHandler maneja GET /v1/api/usuarios/{id}/perfil
 // Requisitos:
-// - Solo administradores autenticados pueden acceder (RequireRole("admin")).
+// - Solo usuarios con el permiso usuarios.ver pueden acceder (RequirePermission).
 // - Obtiene {id} del path (mux.Vars) y valida que sea numérico.
 // - Reutiliza el servicio para obtener el PerfilPersonaResponse y lo devuelve como JSON.
 // - Maneja códigos HTTP: 401, 403, 404, 500 según corresponda.
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"contrato_one_internet_controlador/internal/servicios"
 	"contrato_one_internet_controlador/internal/utilidades"
@@ -25,7 +26,8 @@ func NewContratoFirmaHandlerC(modeloClient *servicios.ModeloClient, correoServic
 	}
 }
 
-// SimularPago llama al modelo y envía el email con el token
+// SimularPago llama al modelo, que aprueba el pago e inicia el proceso de firma. El token
+// se envía por email recién cuando el cliente guarda su firma (ver GuardarFirma).
 func (h *ContratoFirmaHandlerC) SimularPago(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("DEBUG: SimularPago handler llamado")
 	fmt.Printf("DEBUG: Method=%s Path=%s\n", r.Method, r.URL.Path)
@@ -70,33 +72,10 @@ func (h *ContratoFirmaHandlerC) SimularPago(w http.ResponseWriter, r *http.Reque
 	fmt.Println("DEBUG: Respuesta del modelo recibida")
 	fmt.Printf("DEBUG: resp = %+v\n", resp)
 
-	// El modelo devuelve los campos directamente
-	token := utilidades.ToString(resp["token"])
-	email := utilidades.ToString(resp["email_destinatario"])
-	nombreCompleto := utilidades.ToString(resp["nombre_completo"])
-	idContratoFirma := resp["id_contrato_firma"]
-
-	// El token siempre se genera con 24 horas de validez
-	expirationHours := 24
-
-	// Enviar email con el token usando la plantilla
-	err = h.correoService.EnviarTokenFirma(email, token, nombreCompleto, expirationHours)
-	if err != nil {
-		utilidades.ResponderError(w, http.StatusInternalServerError, "Error enviando email: "+err.Error())
-		return
-	}
-
-	// Marcar token_enviado en el modelo (no bloquear el flujo si falla)
-	idCFStr := utilidades.ToString(idContratoFirma)
-	if err := h.modeloClient.MarcarTokenEnviado(r.Context(), idCFStr); err != nil {
-		fmt.Printf("WARN: no se pudo marcar token_enviado en modelo para %s: %v\n", idCFStr, err)
-	}
-
 	// Construir respuesta para el frontend
 	response := map[string]interface{}{
-		"mensaje":           "Se ha enviado un token de firma a su correo electrónico",
-		"id_contrato_firma": idContratoFirma,
-		"token":             token, // Para testing, en producción no enviar
+		"mensaje":           "Pago registrado. Al guardar su firma recibirá el token por correo electrónico",
+		"id_contrato_firma": resp["id_contrato_firma"],
 	}
 
 	utilidades.ResponderJSON(w, http.StatusOK, response)
@@ -125,7 +104,7 @@ func (h *ContratoFirmaHandlerC) GuardarFirma(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Obtener IP y User-Agent del request
-	body["ip_firma"] = r.RemoteAddr
+	body["ip_firma"] = utilidades.ObtenerIPCliente(r)
 	body["user_agent"] = r.UserAgent()
 
 	// Llamar al modelo (enviamos string)
@@ -139,6 +118,31 @@ func (h *ContratoFirmaHandlerC) GuardarFirma(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// La primera firma guardada emite el token: se envía por email y no se devuelve al frontend
+	if emitido, _ := resp["token_emitido"].(bool); emitido {
+		email := utilidades.ToString(resp["email_destinatario"])
+		nombreCompleto := utilidades.ToString(resp["nombre_completo"])
+
+		// El token siempre se genera con 24 horas de validez
+		expirationHours := 24
+
+		err = h.correoService.EnviarTokenFirma(email, utilidades.ToString(resp["token"]), nombreCompleto, expirationHours)
+		if err != nil {
+			utilidades.ResponderError(w, http.StatusInternalServerError, "Firma guardada, pero falló el envío del token. Solicite uno nuevo con 'Reenviar token': "+err.Error())
+			return
+		}
+
+		// Marcar token_enviado en el modelo (no bloquear el flujo si falla)
+		if err := h.modeloClient.MarcarTokenEnviado(r.Context(), idStr); err != nil {
+			fmt.Printf("WARN: no se pudo marcar token_enviado en modelo para %s: %v\n", idStr, err)
+		}
+
+		for _, campo := range []string{"token", "token_expira", "email_destinatario", "nombre_completo"} {
+			delete(resp, campo)
+		}
+		resp["mensaje"] = "Firma guardada. Le enviamos el token de firma a su correo electrónico"
+	}
+
 	utilidades.ResponderJSON(w, http.StatusOK, resp)
 }
 
@@ -200,6 +204,53 @@ func (h *ContratoFirmaHandlerC) ObtenerContrato(w http.ResponseWriter, r *http.R
 	utilidades.ResponderJSON(w, http.StatusOK, resp)
 }
 
+// VerificarFirma revalida la firma digital (PAdES) embebida en el PDF firmado
+func (h *ContratoFirmaHandlerC) VerificarFirma(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	// Validar que sea número
+	_, err := strconv.Atoi(idStr)
+	if err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de contrato firma inválido")
+		return
+	}
+
+	resp, err := h.modeloClient.VerificarFirmaContrato(r.Context(), idStr)
+	if err != nil {
+		if modeloErr, ok := err.(*servicios.ModeloError); ok {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		} else {
+			utilidades.ResponderError(w, http.StatusInternalServerError, "Error al verificar firma: "+err.Error())
+		}
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// VerificarPorCodigo es el endpoint público al que apunta el QR impreso en cada contrato:
+// devuelve el estado de firma, las fechas y los hashes sin exponer datos internos.
+func (h *ContratoFirmaHandlerC) VerificarPorCodigo(w http.ResponseWriter, r *http.Request) {
+	codigo := strings.TrimSpace(mux.Vars(r)["codigo"])
+	if codigo == "" || len(codigo) > 32 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Código de verificación inválido")
+		return
+	}
+
+	resp, err := h.modeloClient.VerificarContratoPorCodigo(r.Context(), codigo)
+	if err != nil {
+		if modeloErr, ok := err.(*servicios.ModeloError); ok {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		} else {
+			utilidades.ResponderError(w, http.StatusInternalServerError, "Error al verificar contrato: "+err.Error())
+		}
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
 // ServirPDF sirve el PDF del contrato (firmado o original según estado)
 func (h *ContratoFirmaHandlerC) ServirPDF(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
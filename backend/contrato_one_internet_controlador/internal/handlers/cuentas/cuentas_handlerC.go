@@ -0,0 +1,148 @@
+package cuentas
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// Handler expone a los administradores las cuentas corrientes de los clientes: deudores,
+// pagos recibidos fuera de la pasarela, notas de crédito y la revisión de deuda
+type Handler struct {
+	service *servicios.CuentaService
+}
+
+func NewHandler(service *servicios.CuentaService) *Handler {
+	return &Handler{service: service}
+}
+
+// ObtenerCuenta maneja GET /v1/api/cuentas/{id}
+func (h *Handler) ObtenerCuenta(w http.ResponseWriter, r *http.Request) {
+	claims, idCliente, ok := claimsYCliente(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ObtenerCuenta(r.Context(), idCliente, r.URL.Query(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "obteniendo cuenta corriente", idCliente)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ListarDeudores maneja GET /v1/api/cuentas/deudores
+func (h *Handler) ListarDeudores(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.ListarDeudores(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "listando deudores", 0)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// RegistrarPago maneja POST /v1/api/cuentas/{id}/pagos
+func (h *Handler) RegistrarPago(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, idCliente, ok := claimsYCliente(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.RegistrarPagoCuentaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	resp, err := h.service.RegistrarPago(r.Context(), idCliente, req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "registrando pago", idCliente)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// RegistrarNotaCredito maneja POST /v1/api/cuentas/{id}/notas-credito
+func (h *Handler) RegistrarNotaCredito(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, idCliente, ok := claimsYCliente(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.RegistrarNotaCreditoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	resp, err := h.service.RegistrarNotaCredito(r.Context(), idCliente, req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "registrando nota de crédito", idCliente)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// RevisarCuentas maneja POST /v1/api/cuentas/revisar
+func (h *Handler) RevisarCuentas(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.RevisarCuentas(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "revisando cuentas", 0)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// claimsYCliente lee los claims y el {id} de la persona titular de la cuenta
+func claimsYCliente(w http.ResponseWriter, r *http.Request) (*utilidades.ClaimsJWT, int, bool) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return nil, 0, false
+	}
+
+	idCliente, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idCliente <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return nil, 0, false
+	}
+	return claims, idCliente, true
+}
+
+func responderErrorModelo(w http.ResponseWriter, err error, accion string, idCliente int) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	logger.Error.Printf("Error %s (persona %d): %v", accion, idCliente, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
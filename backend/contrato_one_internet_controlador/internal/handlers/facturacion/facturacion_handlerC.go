@@ -0,0 +1,51 @@
+package facturacion
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// Handler expone a los administradores la emisión manual de la facturación mensual
+type Handler struct {
+	service *servicios.FacturacionService
+}
+
+func NewHandler(service *servicios.FacturacionService) *Handler {
+	return &Handler{service: service}
+}
+
+// GenerarPeriodo maneja POST /v1/api/facturacion/generar
+func (h *Handler) GenerarPeriodo(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	var req modelos.GenerarFacturacionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	resp, err := h.service.GenerarPeriodo(r.Context(), req, claims.IDPersona)
+	if err != nil {
+		if modeloErr, ok := err.(*servicios.ModeloError); ok {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+		logger.Error.Printf("Error generando facturación del período %s: %v", req.Periodo, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,195 @@
+package instalaciones
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// tamanoMaximoCompletar admite hasta 10 fotos de 5 MB en base64 más los datos relevados
+const tamanoMaximoCompletar = 72 << 20
+
+// Handler expone las órdenes de instalación a técnicos y administradores
+type Handler struct {
+	service *servicios.InstalacionService
+}
+
+func NewHandler(service *servicios.InstalacionService) *Handler {
+	return &Handler{service: service}
+}
+
+// ListarOrdenes maneja GET /v1/api/instalaciones
+func (h *Handler) ListarOrdenes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	response, err := h.service.Listar(r.Context(), r.URL.Query(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "listando órdenes de instalación")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+// ObtenerOrden maneja GET /v1/api/instalaciones/{id}
+func (h *Handler) ObtenerOrden(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Obtener(r.Context(), id, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "obteniendo orden de instalación")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+// AsignarOrden maneja POST /v1/api/instalaciones/{id}/asignar
+func (h *Handler) AsignarOrden(w http.ResponseWriter, r *http.Request) {
+	var req modelos.AsignarOrdenRequest
+	h.operar(w, r, "asignar", &req)
+}
+
+// ProgramarOrden maneja POST /v1/api/instalaciones/{id}/programar
+func (h *Handler) ProgramarOrden(w http.ResponseWriter, r *http.Request) {
+	var req modelos.ProgramarOrdenRequest
+	h.operar(w, r, "programar", &req)
+}
+
+// CompletarOrden maneja POST /v1/api/instalaciones/{id}/completar
+func (h *Handler) CompletarOrden(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, tamanoMaximoCompletar)
+	var req modelos.CompletarOrdenRequest
+	h.operar(w, r, "completar", &req)
+}
+
+// CancelarOrden maneja POST /v1/api/instalaciones/{id}/cancelar
+func (h *Handler) CancelarOrden(w http.ResponseWriter, r *http.Request) {
+	var req modelos.CancelarOrdenRequest
+	h.operar(w, r, "cancelar", &req)
+}
+
+// ObtenerAgenda maneja GET /v1/api/instalaciones/tecnicos/{id}/agenda
+func (h *Handler) ObtenerAgenda(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.ObtenerAgenda(r.Context(), id, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "obteniendo agenda del técnico")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+// ActualizarAgenda maneja PUT /v1/api/instalaciones/tecnicos/{id}/agenda
+func (h *Handler) ActualizarAgenda(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.AgendaTecnico
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	response, err := h.service.ActualizarAgenda(r.Context(), id, req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "actualizando agenda del técnico")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+// operar decodifica el cuerpo en req y reenvía la operación al modelo
+func (h *Handler) operar(w http.ResponseWriter, r *http.Request, operacion string, req interface{}) {
+	defer r.Body.Close()
+
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		var demasiadoGrande *http.MaxBytesError
+		if errors.As(err, &demasiadoGrande) {
+			utilidades.ResponderError(w, http.StatusRequestEntityTooLarge, "la solicitud supera el tamaño máximo permitido")
+			return
+		}
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	response, err := h.service.Operar(r.Context(), id, operacion, req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, operacion+" orden de instalación")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+func idDeRuta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return 0, false
+	}
+	return id, true
+}
+
+// responderErrorModelo devuelve el error del modelo tal cual o un 500 si no vino del modelo
+func responderErrorModelo(w http.ResponseWriter, err error, accion string) {
+	var modeloErr *servicios.ModeloError
+	if errors.As(err, &modeloErr) {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+
+	logger.Error.Printf("Error %s: %v", accion, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
@@ -0,0 +1,251 @@
+package notificaciones
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+)
+
+// NotificacionCanalesHandler expone preferencias de canal, suscripciones Web Push,
+// estado de entrega por canal y plantillas (notificaciones.plantillas)
+type NotificacionCanalesHandler struct {
+	ModeloClient *servicios.ModeloClient
+	ClavePush    string // applicationServerKey VAPID; vacío si el canal push no está configurado
+}
+
+func NewNotificacionCanalesHandler(mc *servicios.ModeloClient, clavePush string) *NotificacionCanalesHandler {
+	return &NotificacionCanalesHandler{ModeloClient: mc, ClavePush: clavePush}
+}
+
+// ObtenerPreferenciasHandler maneja GET /v1/api/notificaciones/preferencias
+func (h *NotificacionCanalesHandler) ObtenerPreferenciasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.ModeloClient.ObtenerPreferenciasNotificacion(r.Context(), idPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "error al obtener preferencias de notificación")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ActualizarPreferenciasHandler maneja PUT /v1/api/notificaciones/preferencias
+// Body: {"preferencias": [{"canal": "sms", "tipo": "*", "habilitado": true}, ...]}
+func (h *NotificacionCanalesHandler) ActualizarPreferenciasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Preferencias []struct {
+			Canal      string `json:"canal"`
+			Tipo       string `json:"tipo"`
+			Habilitado *bool  `json:"habilitado"`
+		} `json:"preferencias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	if len(req.Preferencias) == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Debe indicar al menos una preferencia")
+		return
+	}
+	for _, p := range req.Preferencias {
+		if p.Canal == "" || p.Habilitado == nil {
+			utilidades.ResponderError(w, http.StatusBadRequest, "Cada preferencia requiere 'canal' y 'habilitado'")
+			return
+		}
+	}
+
+	resp, err := h.ModeloClient.ActualizarPreferenciasNotificacion(r.Context(), idPersona, req)
+	if err != nil {
+		responderErrorModelo(w, err, "error al actualizar preferencias de notificación")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ClavePushHandler maneja GET /v1/api/notificaciones/push/clave-publica
+func (h *NotificacionCanalesHandler) ClavePushHandler(w http.ResponseWriter, r *http.Request) {
+	if h.ClavePush == "" {
+		utilidades.ResponderError(w, http.StatusNotFound, "Las notificaciones push no están habilitadas")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"clave_publica": h.ClavePush})
+}
+
+// RegistrarSuscripcionPushHandler maneja POST /v1/api/notificaciones/push/suscripciones
+// Body: el resultado de PushSubscription.toJSON() del navegador
+func (h *NotificacionCanalesHandler) RegistrarSuscripcionPushHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Endpoint == "" {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Se requiere la suscripción del navegador (endpoint y keys)")
+		return
+	}
+
+	sub := modelos.SuscripcionPush{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}
+	if err := h.ModeloClient.RegistrarSuscripcionPush(r.Context(), idPersona, sub); err != nil {
+		responderErrorModelo(w, err, "error al registrar la suscripción push")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusCreated, map[string]string{"mensaje": "Suscripción registrada"})
+}
+
+// EliminarSuscripcionPushHandler maneja DELETE /v1/api/notificaciones/push/suscripciones
+// Body: {"endpoint": "..."}
+func (h *NotificacionCanalesHandler) EliminarSuscripcionPushHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Endpoint == "" {
+		utilidades.ResponderError(w, http.StatusBadRequest, "El parámetro 'endpoint' es obligatorio")
+		return
+	}
+
+	if err := h.ModeloClient.EliminarSuscripcionPush(r.Context(), idPersona, req.Endpoint); err != nil {
+		responderErrorModelo(w, err, "error al eliminar la suscripción push")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Suscripción eliminada"})
+}
+
+// ListarEntregasHandler maneja GET /v1/api/notificaciones/{id}/entregas
+func (h *NotificacionCanalesHandler) ListarEntregasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	idNotificacion, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idNotificacion <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de notificación inválido")
+		return
+	}
+
+	resp, err := h.ModeloClient.ListarEntregasNotificacion(r.Context(), idPersona, idNotificacion)
+	if err != nil {
+		responderErrorModelo(w, err, "error al obtener el estado de entrega")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ListarPlantillasHandler maneja GET /v1/api/notificaciones/plantillas (notificaciones.plantillas)
+func (h *NotificacionCanalesHandler) ListarPlantillasHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.ModeloClient.ListarPlantillasNotificacion(r.Context())
+	if err != nil {
+		responderErrorModelo(w, err, "error al obtener plantillas")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// GuardarPlantillaHandler maneja PUT /v1/api/notificaciones/plantillas (notificaciones.plantillas)
+// Body: {"tipo": "FACTIBILIDAD", "canal": "sms", "asunto": "...", "cuerpo": "Hola {{.Nombre}}: {{.Mensaje}}", "activo": true}
+func (h *NotificacionCanalesHandler) GuardarPlantillaHandler(w http.ResponseWriter, r *http.Request) {
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	resp, err := h.ModeloClient.GuardarPlantillaNotificacion(r.Context(), req)
+	if err != nil {
+		responderErrorModelo(w, err, "error al guardar la plantilla")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// EliminarPlantillaHandler maneja DELETE /v1/api/notificaciones/plantillas/{id} (notificaciones.plantillas)
+func (h *NotificacionCanalesHandler) EliminarPlantillaHandler(w http.ResponseWriter, r *http.Request) {
+	idPlantilla, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idPlantilla <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de plantilla inválido")
+		return
+	}
+
+	if err := h.ModeloClient.EliminarPlantillaNotificacion(r.Context(), idPlantilla); err != nil {
+		responderErrorModelo(w, err, "error al eliminar la plantilla")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Plantilla eliminada"})
+}
+
+// ListarOutboxFallidosHandler maneja GET /v1/api/outbox/fallidos (outbox.administrar)
+func (h *NotificacionCanalesHandler) ListarOutboxFallidosHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.ModeloClient.ListarEventosOutboxFallidos(r.Context())
+	if err != nil {
+		responderErrorModelo(w, err, "error al obtener eventos fallidos")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ReintentarOutboxHandler maneja POST /v1/api/outbox/{id}/reintentar (outbox.administrar)
+func (h *NotificacionCanalesHandler) ReintentarOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	idEvento, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idEvento <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de evento inválido")
+		return
+	}
+
+	if err := h.ModeloClient.ReintentarEventoOutbox(r.Context(), idEvento); err != nil {
+		responderErrorModelo(w, err, "error al reintentar el evento")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Evento reencolado"})
+}
+
+// idPersonaDesdeToken obtiene id_persona de los claims del JWT; si falta responde 401
+func idPersonaDesdeToken(w http.ResponseWriter, r *http.Request) (int, bool) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no se pudo obtener información del token")
+		return 0, false
+	}
+	if claims.IDPersona == 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "id_persona no encontrado en el token")
+		return 0, false
+	}
+	return claims.IDPersona, true
+}
+
+// responderErrorModelo propaga el código de error del modelo o responde 500
+func responderErrorModelo(w http.ResponseWriter, err error, mensaje string) {
+	log.Printf("%s: %v", mensaje, err)
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	utilidades.ResponderError(w, http.StatusInternalServerError, mensaje)
+}
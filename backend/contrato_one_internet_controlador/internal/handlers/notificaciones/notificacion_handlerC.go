@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/gorilla/mux"
+
 	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
 	"contrato_one_internet_controlador/internal/servicios"
 	"contrato_one_internet_controlador/internal/utilidades"
 )
@@ -49,6 +52,29 @@ func (h *NotificacionHandler) ObtenerNotificacionesHandler(w http.ResponseWriter
 		leido = &leidoVal
 	}
 
+	// Filtros opcionales: tipo, id_contrato, id_conexion y archivadas (0/1)
+	filtro := modelos.FiltroNotificaciones{Leido: leido, Tipo: queryParams.Get("tipo")}
+	for _, f := range []struct {
+		nombre  string
+		destino **int
+	}{{"id_contrato", &filtro.IDContrato}, {"id_conexion", &filtro.IDConexion}} {
+		if v := queryParams.Get(f.nombre); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				utilidades.ResponderError(w, http.StatusBadRequest, f.nombre+" debe ser un entero mayor a 0")
+				return
+			}
+			*f.destino = &n
+		}
+	}
+	if v := queryParams.Get("archivadas"); v != "" {
+		if v != "0" && v != "1" {
+			utilidades.ResponderError(w, http.StatusBadRequest, "archivadas debe ser 0 o 1")
+			return
+		}
+		filtro.Archivadas = v == "1"
+	}
+
 	// Parámetro page (default: 1)
 	page := 1
 	if pageStr := queryParams.Get("page"); pageStr != "" {
@@ -99,7 +125,7 @@ func (h *NotificacionHandler) ObtenerNotificacionesHandler(w http.ResponseWriter
 	resp, err := h.ModeloClient.GetNotificaciones(
 		r.Context(),
 		idPersona,
-		leido,
+		filtro,
 		page,
 		pageSize,
 		sortBy,
@@ -169,3 +195,119 @@ func (h *NotificacionHandler) MarcarComoLeidaHandler(w http.ResponseWriter, r *h
 		"mensaje": "Notificación marcada como leída",
 	})
 }
+
+// ContarNoLeidasHandler maneja GET /v1/api/notificaciones/no-leidas
+func (h *NotificacionHandler) ContarNoLeidasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.ModeloClient.ContarNotificacionesNoLeidas(r.Context(), idPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "error al contar notificaciones no leídas")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// MarcarTodasComoLeidasHandler maneja POST /v1/api/notificaciones/marcar-todas-como-leidas
+func (h *NotificacionHandler) MarcarTodasComoLeidasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.ModeloClient.MarcarNotificacionesComoLeidas(r.Context(), idPersona, nil)
+	if err != nil {
+		responderErrorModelo(w, err, "error al marcar notificaciones como leídas")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// MarcarComoLeidasHandler maneja POST /v1/api/notificaciones/marcar-como-leidas
+// Body: {"ids": [1, 2, 3]}
+func (h *NotificacionHandler) MarcarComoLeidasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	if len(req.IDs) == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Debe indicar al menos una notificación en 'ids'")
+		return
+	}
+
+	resp, err := h.ModeloClient.MarcarNotificacionesComoLeidas(r.Context(), idPersona, req.IDs)
+	if err != nil {
+		responderErrorModelo(w, err, "error al marcar notificaciones como leídas")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ArchivarHandler maneja POST /v1/api/notificaciones/{id}/archivar
+func (h *NotificacionHandler) ArchivarHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, idNotificacion, ok := idPersonaYNotificacion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ModeloClient.ArchivarNotificacion(r.Context(), idPersona, idNotificacion, true); err != nil {
+		responderErrorModelo(w, err, "error al archivar la notificación")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Notificación archivada"})
+}
+
+// DesarchivarHandler maneja POST /v1/api/notificaciones/{id}/desarchivar
+func (h *NotificacionHandler) DesarchivarHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, idNotificacion, ok := idPersonaYNotificacion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ModeloClient.ArchivarNotificacion(r.Context(), idPersona, idNotificacion, false); err != nil {
+		responderErrorModelo(w, err, "error al desarchivar la notificación")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Notificación devuelta a la bandeja"})
+}
+
+// EliminarHandler maneja DELETE /v1/api/notificaciones/{id}
+// Sólo la quita de la bandeja del usuario: las difundidas a un rol las siguen viendo los demás.
+func (h *NotificacionHandler) EliminarHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, idNotificacion, ok := idPersonaYNotificacion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ModeloClient.EliminarNotificacion(r.Context(), idPersona, idNotificacion); err != nil {
+		responderErrorModelo(w, err, "error al eliminar la notificación")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Notificación eliminada"})
+}
+
+// idPersonaYNotificacion obtiene id_persona del token e id de la ruta; si faltan responde el error
+func idPersonaYNotificacion(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	idPersona, ok := idPersonaDesdeToken(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	idNotificacion, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idNotificacion <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de notificación inválido")
+		return 0, 0, false
+	}
+	return idPersona, idNotificacion, true
+}
@@ -0,0 +1,172 @@
+package notificaciones
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"contrato_one_internet_controlador/internal/config"
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+)
+
+// intervaloHeartbeatStream mantiene viva la conexión a través de proxies que cortan por inactividad
+const intervaloHeartbeatStream = 25 * time.Second
+
+// loteReanudacionStream es el tamaño de página al recuperar lo perdido con Last-Event-ID
+const loteReanudacionStream = 200
+
+// NotificacionStreamHandler entrega las notificaciones en tiempo real por Server-Sent Events
+type NotificacionStreamHandler struct {
+	ModeloClient *servicios.ModeloClient
+	Hub          *servicios.HubNotificaciones
+	cfg          *config.Config
+}
+
+func NewNotificacionStreamHandler(mc *servicios.ModeloClient, hub *servicios.HubNotificaciones, cfg *config.Config) *NotificacionStreamHandler {
+	return &NotificacionStreamHandler{ModeloClient: mc, Hub: hub, cfg: cfg}
+}
+
+// TicketHandler maneja POST /v1/api/notificaciones/stream/ticket
+// Devuelve un ticket de un solo uso para abrir el stream con EventSource (?ticket=), que no
+// permite enviar la cabecera Authorization. Como el ticket se acepta una sola vez, al
+// reconectar el cliente pide otro y abre un EventSource nuevo con ?last_event_id=.
+func (h *NotificacionStreamHandler) TicketHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok || claims.IDPersona == 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "id_persona no encontrado en el token")
+		return
+	}
+
+	ticket, expira, err := utilidades.GenerarTicketStream(claims, h.cfg)
+	if err != nil {
+		log.Printf("error generando ticket de streaming para persona %d: %v", claims.IDPersona, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "No se pudo generar el ticket de streaming")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{
+		"ticket": ticket,
+		"expira": expira,
+	})
+}
+
+// StreamHandler maneja GET /v1/notificaciones/stream
+// Cada evento lleva el id de la notificación; al reconectar, el navegador envía
+// Last-Event-ID (o el cliente ?last_event_id=) y se reenvía todo lo posterior.
+func (h *NotificacionStreamHandler) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok || claims.IDPersona == 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "id_persona no encontrado en el token")
+		return
+	}
+	idPersona := claims.IDPersona
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utilidades.ResponderError(w, http.StatusInternalServerError, "streaming no soportado")
+		return
+	}
+
+	// 1. Último id recibido por el cliente (si reanuda)
+	ultimoID := r.Header.Get("Last-Event-ID")
+	if ultimoID == "" {
+		ultimoID = r.URL.Query().Get("last_event_id")
+	}
+	lastID := 0
+	if ultimoID != "" {
+		n, err := strconv.Atoi(ultimoID)
+		if err != nil || n < 0 {
+			utilidades.ResponderError(w, http.StatusBadRequest, "Last-Event-ID inválido")
+			return
+		}
+		lastID = n
+	}
+
+	// 2. Suscribirse antes de recuperar lo perdido para no dejar huecos entre ambos
+	sub, cursor, ok := h.Hub.Suscribir(idPersona, claims.Roles)
+	if !ok {
+		utilidades.ResponderError(w, http.StatusServiceUnavailable, "El servicio de notificaciones en tiempo real no está disponible")
+		return
+	}
+	defer h.Hub.Desuscribir(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: no bufferear la respuesta
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	// 3. Reanudación: reenviar lo posterior a Last-Event-ID hasta alcanzar el cursor del hub.
+	// Se recuerdan los ids que el hub todavía puede entregar (los de su ventana de relectura)
+	// para no enviarlos dos veces.
+	enviadas := map[int]struct{}{}
+	if lastID > 0 {
+		lastSent := lastID
+		for {
+			feed, err := h.ModeloClient.ObtenerFeedNotificaciones(r.Context(), lastSent, loteReanudacionStream, &idPersona)
+			if err != nil {
+				log.Printf("error recuperando notificaciones perdidas de persona %d: %v", idPersona, err)
+				return
+			}
+			for _, n := range feed.Notificaciones {
+				if err := escribirEventoNotificacion(w, n); err != nil {
+					return
+				}
+				lastSent = n.IDNotificacion
+				if lastSent > cursor-servicios.VentanaRelecturaNotificaciones {
+					enviadas[lastSent] = struct{}{}
+				}
+			}
+			flusher.Flush()
+			if len(feed.Notificaciones) < loteReanudacionStream {
+				break
+			}
+		}
+	}
+
+	// 4. Tiempo real
+	heartbeat := time.NewTicker(intervaloHeartbeatStream)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case n, abierto := <-sub.C:
+			if !abierto {
+				// El hub cortó la conexión (cliente saturado); reconecta con Last-Event-ID
+				return
+			}
+			if _, ok := enviadas[n.IDNotificacion]; ok {
+				delete(enviadas, n.IDNotificacion) // ya enviada durante la reanudación
+				continue
+			}
+			if err := escribirEventoNotificacion(w, n); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// escribirEventoNotificacion serializa la notificación como evento SSE
+func escribirEventoNotificacion(w http.ResponseWriter, n modelos.Notificacion) error {
+	datos, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: notificacion\ndata: %s\n\n", n.IDNotificacion, datos)
+	return err
+}
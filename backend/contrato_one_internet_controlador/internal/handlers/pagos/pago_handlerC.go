@@ -0,0 +1,106 @@
+package pagos
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// tamanoMaximoWebhook limita el cuerpo aceptado desde el proveedor de pagos
+const tamanoMaximoWebhook = 64 * 1024
+
+// PagoHandlerC maneja el checkout de pagos y los webhooks del proveedor
+type PagoHandlerC struct {
+	modeloClient *servicios.ModeloClient
+}
+
+// NewPagoHandlerC crea un nuevo handler de pagos
+func NewPagoHandlerC(modeloClient *servicios.ModeloClient) *PagoHandlerC {
+	return &PagoHandlerC{modeloClient: modeloClient}
+}
+
+// IniciarPago crea el checkout del costo de instalación de un contrato del usuario autenticado.
+// Devuelve url_checkout para redirigir al cliente a la pasarela.
+func (h *PagoHandlerC) IniciarPago(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no se pudo obtener información del token")
+		return
+	}
+
+	idContratoStr := mux.Vars(r)["id_contrato"]
+	if _, err := strconv.Atoi(idContratoStr); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de contrato inválido")
+		return
+	}
+
+	resp, err := h.modeloClient.IniciarPagoContrato(r.Context(), claims.IDPersona, idContratoStr)
+	if err != nil {
+		responderErrorModelo(w, err, "Error al iniciar el pago")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// ObtenerPago devuelve el estado de un pago del usuario autenticado
+func (h *PagoHandlerC) ObtenerPago(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no se pudo obtener información del token")
+		return
+	}
+
+	idPagoStr := mux.Vars(r)["id"]
+	if _, err := strconv.Atoi(idPagoStr); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "ID de pago inválido")
+		return
+	}
+
+	resp, err := h.modeloClient.ObtenerPago(r.Context(), claims.IDPersona, idPagoStr)
+	if err != nil {
+		responderErrorModelo(w, err, "Error al obtener el pago")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// Webhook recibe las notificaciones del proveedor de pagos (endpoint público).
+// La firma se valida en el modelo. Con el pago aprobado el modelo inicia el proceso de firma
+// por su cuenta; el token se envía cuando el cliente guarda su firma.
+func (h *PagoHandlerC) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, tamanoMaximoWebhook))
+	if err != nil || len(body) == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Cuerpo de solicitud inválido")
+		return
+	}
+
+	resp, err := h.modeloClient.ReenviarWebhookPago(r.Context(), body, r.URL.Query(),
+		r.Header.Get("X-Signature"), r.Header.Get("X-Request-Id"))
+	if err != nil {
+		logger.Warn.Printf("Webhook de pago rechazado por el modelo: %v", err)
+		responderErrorModelo(w, err, "Error procesando notificación de pago")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{
+		"id_pago": resp["id_pago"],
+		"estado":  resp["estado"],
+	})
+}
+
+func responderErrorModelo(w http.ResponseWriter, err error, mensaje string) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	utilidades.ResponderError(w, http.StatusInternalServerError, mensaje+": "+err.Error())
+}
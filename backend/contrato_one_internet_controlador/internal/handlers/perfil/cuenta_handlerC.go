@@ -0,0 +1,40 @@
+package perfil
+
+import (
+	"net/http"
+
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// CuentaHandlerC expone al cliente su cuenta corriente
+type CuentaHandlerC struct {
+	service *servicios.CuentaService
+}
+
+// NewCuentaHandlerC crea un nuevo handler de la cuenta corriente
+func NewCuentaHandlerC(service *servicios.CuentaService) *CuentaHandlerC {
+	return &CuentaHandlerC{service: service}
+}
+
+// ObtenerMiCuenta maneja GET /v1/api/perfil/cuenta
+func (h *CuentaHandlerC) ObtenerMiCuenta(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsCliente(w, r, "cuenta corriente")
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ObtenerMiCuenta(r.Context(), r.URL.Query(), claims.IDPersona)
+	if err != nil {
+		if modeloErr, ok := err.(*servicios.ModeloError); ok {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+			return
+		}
+		logger.Error.Printf("Error obteniendo cuenta corriente de la persona %d: %v", claims.IDPersona, err)
+		utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
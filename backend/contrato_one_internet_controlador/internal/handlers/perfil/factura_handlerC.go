@@ -0,0 +1,118 @@
+package perfil
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// FacturaHandlerC expone al cliente sus facturas mensuales
+type FacturaHandlerC struct {
+	service *servicios.FacturacionService
+}
+
+// NewFacturaHandlerC crea un nuevo handler de facturas
+func NewFacturaHandlerC(service *servicios.FacturacionService) *FacturaHandlerC {
+	return &FacturaHandlerC{service: service}
+}
+
+// ListarMisFacturas maneja GET /v1/api/perfil/facturas
+func (h *FacturaHandlerC) ListarMisFacturas(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsCliente(w, r, "facturas")
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ListarMisFacturas(r.Context(), r.URL.Query(), claims.IDPersona)
+	if err != nil {
+		responderErrorFactura(w, err, "listando facturas", 0)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ObtenerMiFactura maneja GET /v1/api/perfil/facturas/{id}
+func (h *FacturaHandlerC) ObtenerMiFactura(w http.ResponseWriter, r *http.Request) {
+	claims, idFactura, ok := clienteYFactura(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ObtenerMiFactura(r.Context(), idFactura, claims.IDPersona)
+	if err != nil {
+		responderErrorFactura(w, err, "obteniendo factura", idFactura)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// DescargarMiFactura maneja GET /v1/api/perfil/facturas/{id}/pdf
+func (h *FacturaHandlerC) DescargarMiFactura(w http.ResponseWriter, r *http.Request) {
+	claims, idFactura, ok := clienteYFactura(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.DescargarMiFactura(r.Context(), idFactura, claims.IDPersona)
+	if err != nil {
+		responderErrorFactura(w, err, "descargando PDF de la factura", idFactura)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	if contentDisposition := resp.Header.Get("Content-Disposition"); contentDisposition != "" {
+		w.Header().Set("Content-Disposition", contentDisposition)
+	}
+	io.Copy(w, resp.Body)
+}
+
+// claimsCliente verifica que quien llama sea cliente
+func claimsCliente(w http.ResponseWriter, r *http.Request, recurso string) (*utilidades.ClaimsJWT, bool) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no se pudo obtener información del token")
+		return nil, false
+	}
+
+	// Verificar que el usuario tenga el rol Cliente
+	if !claims.HasRole("cliente") {
+		logger.Warn.Printf("Usuario %d intentó acceder a %s sin rol Cliente", claims.IDUsuario, recurso)
+		utilidades.ResponderError(w, http.StatusForbidden, "acceso denegado: requiere rol Cliente")
+		return nil, false
+	}
+	return claims, true
+}
+
+// clienteYFactura verifica que quien llama sea cliente y lee el {id} de la factura
+func clienteYFactura(w http.ResponseWriter, r *http.Request) (*utilidades.ClaimsJWT, int, bool) {
+	claims, ok := claimsCliente(w, r, "facturas")
+	if !ok {
+		return nil, 0, false
+	}
+
+	idFactura, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idFactura <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return nil, 0, false
+	}
+	return claims, idFactura, true
+}
+
+func responderErrorFactura(w http.ResponseWriter, err error, accion string, idFactura int) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	logger.Error.Printf("Error %s (factura %d): %v", accion, idFactura, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/gorilla/mux"
+
 	"contrato_one_internet_controlador/internal/middleware"
 	"contrato_one_internet_controlador/internal/servicios"
 	"contrato_one_internet_controlador/internal/utilidades"
@@ -200,3 +202,42 @@ func (h *PerfilHandlerC) ObtenerMisConexiones(w http.ResponseWriter, r *http.Req
 
 	utilidades.ResponderJSON(w, http.StatusOK, resp)
 }
+
+// ObtenerHistorialMiConexion obtiene la línea de tiempo de estados de una conexión del usuario autenticado
+func (h *PerfilHandlerC) ObtenerHistorialMiConexion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extraer claims del JWT
+	claims, ok := middleware.GetClaimsFromContext(ctx)
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no se pudo obtener información del token")
+		return
+	}
+
+	// Verificar que el usuario tenga el rol Cliente
+	if !claims.HasRole("cliente") {
+		logger.Warn.Printf("Usuario %d intentó acceder al historial de conexiones sin rol Cliente", claims.IDUsuario)
+		utilidades.ResponderError(w, http.StatusForbidden, "acceso denegado: requiere rol Cliente")
+		return
+	}
+
+	idConexion, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idConexion <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return
+	}
+
+	// Llamar al servicio Modelo (verifica que la conexión sea de la persona)
+	resp, err := h.ModeloClient.GetHistorialMiConexion(ctx, claims.IDPersona, idConexion)
+	if err != nil {
+		logger.Error.Printf("Error al obtener historial de la conexión %d para persona %d: %v", idConexion, claims.IDPersona, err)
+		if modeloErr, ok := err.(*servicios.ModeloError); ok {
+			utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		} else {
+			utilidades.ResponderError(w, http.StatusInternalServerError, "error al obtener historial")
+		}
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,143 @@
+package perfil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// TurnoHandlerC maneja los turnos de instalación que reserva el cliente
+type TurnoHandlerC struct {
+	service       *servicios.TurnoService
+	correoService *servicios.ServicioCorreo
+}
+
+// NewTurnoHandlerC crea un nuevo handler de turnos
+func NewTurnoHandlerC(service *servicios.TurnoService, correoService *servicios.ServicioCorreo) *TurnoHandlerC {
+	return &TurnoHandlerC{service: service, correoService: correoService}
+}
+
+// ObtenerTurnos maneja GET /v1/api/perfil/conexiones/{id}/turnos
+func (h *TurnoHandlerC) ObtenerTurnos(w http.ResponseWriter, r *http.Request) {
+	claims, idConexion, ok := clienteYConexion(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.Disponibilidad(r.Context(), idConexion, claims.IDPersona)
+	if err != nil {
+		responderErrorTurno(w, err, "obteniendo turnos", idConexion)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ReservarTurno maneja POST /v1/api/perfil/conexiones/{id}/turnos
+func (h *TurnoHandlerC) ReservarTurno(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, idConexion, ok := clienteYConexion(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.ReservarTurnoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	resp, err := h.service.Reservar(r.Context(), idConexion, req, claims.IDPersona)
+	if err != nil {
+		responderErrorTurno(w, err, "reservando turno", idConexion)
+		return
+	}
+
+	if resp.Turno != nil {
+		h.enviarCorreo(resp, "Turno de instalación confirmado", fmt.Sprintf(
+			"Tu turno para la instalación de la conexión Nº %d quedó confirmado para el %s entre las %s y las %s. "+
+				"Podés cambiarlo o cancelarlo desde tu perfil hasta 24 horas antes de la visita.",
+			resp.NroConexion, resp.Turno.Inicio.Format("02/01/2006"), resp.Turno.Inicio.Format("15:04"), resp.Turno.Fin.Format("15:04")))
+	}
+
+	resp.Correo = nil
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// CancelarTurno maneja DELETE /v1/api/perfil/conexiones/{id}/turnos
+func (h *TurnoHandlerC) CancelarTurno(w http.ResponseWriter, r *http.Request) {
+	claims, idConexion, ok := clienteYConexion(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.Cancelar(r.Context(), idConexion, claims.IDPersona)
+	if err != nil {
+		responderErrorTurno(w, err, "cancelando turno", idConexion)
+		return
+	}
+
+	if resp.Turno != nil {
+		h.enviarCorreo(resp, "Turno de instalación cancelado", fmt.Sprintf(
+			"Cancelaste el turno del %s entre las %s y las %s para la instalación de la conexión Nº %d. "+
+				"Podés reservar uno nuevo desde tu perfil.",
+			resp.Turno.Inicio.Format("02/01/2006"), resp.Turno.Inicio.Format("15:04"), resp.Turno.Fin.Format("15:04"), resp.NroConexion))
+	}
+
+	resp.Correo = nil
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// enviarCorreo envía la confirmación al cliente. El turno ya quedó registrado, así que un
+// fallo de email sólo se registra en el log.
+func (h *TurnoHandlerC) enviarCorreo(resp *modelos.TurnoResponse, asunto, mensaje string) {
+	if resp.Correo == nil || resp.Correo.Email == "" {
+		logger.Warn.Printf("Conexión %d sin email del titular: no se envía \"%s\"", resp.IDConexion, asunto)
+		return
+	}
+	if err := h.correoService.EnviarNotificacion(resp.Correo.Email, asunto, resp.Correo.Nombre, asunto, mensaje); err != nil {
+		logger.Error.Printf("Error enviando \"%s\" de la conexión %d a %s: %v", asunto, resp.IDConexion, resp.Correo.Email, err)
+	}
+}
+
+// clienteYConexion verifica que quien llama sea cliente y lee el {id} de la conexión
+func clienteYConexion(w http.ResponseWriter, r *http.Request) (*utilidades.ClaimsJWT, int, bool) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no se pudo obtener información del token")
+		return nil, 0, false
+	}
+
+	// Verificar que el usuario tenga el rol Cliente
+	if !claims.HasRole("cliente") {
+		logger.Warn.Printf("Usuario %d intentó acceder a turnos de instalación sin rol Cliente", claims.IDUsuario)
+		utilidades.ResponderError(w, http.StatusForbidden, "acceso denegado: requiere rol Cliente")
+		return nil, 0, false
+	}
+
+	idConexion, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idConexion <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return nil, 0, false
+	}
+	return claims, idConexion, true
+}
+
+func responderErrorTurno(w http.ResponseWriter, err error, accion string, idConexion int) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	logger.Error.Printf("Error %s de la conexión %d: %v", accion, idConexion, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
@@ -89,7 +89,7 @@ func (h *PlanesHandler) ObtenerPlanPorID(w http.ResponseWriter, r *http.Request)
     utilidades.ResponderJSON(w, http.StatusOK, p)
 }
 
-// CrearPlan -> POST /v1/api/planes (admin)
+// CrearPlan -> POST /v1/api/planes (planes.editar)
 func (h *PlanesHandler) CrearPlan(w http.ResponseWriter, r *http.Request) {
     ctx := r.Context()
     defer r.Body.Close()
@@ -170,7 +170,7 @@ func (h *PlanesHandler) CrearPlan(w http.ResponseWriter, r *http.Request) {
     utilidades.ResponderJSON(w, http.StatusCreated, map[string]interface{}{"mensaje": "Plan creado correctamente"})
 }
 
-// ActualizarPlan -> PATCH /v1/api/planes/{id} (admin)
+// ActualizarPlan -> PATCH /v1/api/planes/{id} (planes.editar)
 func (h *PlanesHandler) ActualizarPlan(w http.ResponseWriter, r *http.Request) {
     ctx := r.Context()
     defer r.Body.Close()
@@ -234,7 +234,7 @@ func (h *PlanesHandler) ActualizarPlan(w http.ResponseWriter, r *http.Request) {
     utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Plan actualizado correctamente"})
 }
 
-// EliminarPlan -> DELETE /v1/api/planes/{id} (admin)
+// EliminarPlan -> DELETE /v1/api/planes/{id} (planes.editar)
 func (h *PlanesHandler) EliminarPlan(w http.ResponseWriter, r *http.Request) {
     ctx := r.Context()
     vars := mux.Vars(r)
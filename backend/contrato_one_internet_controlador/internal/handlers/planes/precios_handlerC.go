@@ -0,0 +1,173 @@
+package planes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// PreciosHandler expone el historial y los ajustes de precios de los planes y las promociones
+type PreciosHandler struct {
+	service *servicios.PrecioPlanService
+}
+
+func NewPreciosHandler(service *servicios.PrecioPlanService) *PreciosHandler {
+	return &PreciosHandler{service: service}
+}
+
+// HistorialPrecios maneja GET /v1/api/planes/{id}/precios (precios.gestionar)
+func (h *PreciosHandler) HistorialPrecios(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	idPlan, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.Historial(r.Context(), idPlan, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "obteniendo historial de precios", claims.IDPersona)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// AjustarPrecios maneja POST /v1/api/planes/precios (precios.gestionar)
+func (h *PreciosHandler) AjustarPrecios(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	var req modelos.AjustePreciosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	resp, err := h.service.AjustarPrecios(r.Context(), req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "ajustando precios", claims.IDPersona)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// ListarPromocionesVigentes maneja GET /v1/promociones (público)
+func (h *PreciosHandler) ListarPromocionesVigentes(w http.ResponseWriter, r *http.Request) {
+	var idPlan *int
+	if v := r.URL.Query().Get("id_plan"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			utilidades.ResponderError(w, http.StatusBadRequest, "id_plan debe ser un número entero positivo")
+			return
+		}
+		idPlan = &id
+	}
+
+	promociones, err := h.service.ListarPromocionesVigentes(r.Context(), idPlan)
+	if err != nil {
+		responderErrorModelo(w, err, "listando promociones vigentes", 0)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{"promociones": promociones})
+}
+
+// ListarPromociones maneja GET /v1/api/promociones (precios.gestionar)
+func (h *PreciosHandler) ListarPromociones(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	promociones, err := h.service.ListarPromociones(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "listando promociones", claims.IDPersona)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{"promociones": promociones})
+}
+
+// CrearPromocion maneja POST /v1/api/promociones (precios.gestionar)
+func (h *PreciosHandler) CrearPromocion(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	var req modelos.CrearPromocionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "formato de solicitud inválido")
+		return
+	}
+
+	promo, err := h.service.CrearPromocion(r.Context(), req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "creando promoción", claims.IDPersona)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, promo)
+}
+
+// BorrarPromocion maneja DELETE /v1/api/promociones/{id} (precios.gestionar)
+func (h *PreciosHandler) BorrarPromocion(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	idPromocion, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.BorrarPromocion(r.Context(), idPromocion, claims.IDPersona); err != nil {
+		responderErrorModelo(w, err, "borrando promoción", claims.IDPersona)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Promoción dada de baja correctamente"})
+}
+
+func idDeRuta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id debe ser un número entero positivo")
+		return 0, false
+	}
+	return id, true
+}
+
+func responderErrorModelo(w http.ResponseWriter, err error, accion string, idPersona int) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	logger.Error.Printf("Error %s (persona %d): %v", accion, idPersona, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
@@ -21,7 +21,7 @@ func NewHandler(s *servicios.RolService) *RolHandler { return &RolHandler{servic
 
 func (h *RolHandler) ListarRoles(w http.ResponseWriter, r *http.Request) {
     ctx := r.Context()
-    // Only admin may access — router applies middleware.RequireRole("admin")
+    // Router applies middleware.RequirePermission(modelos.PermisoRolesEditar)
     list, err := h.service.ObtenerRoles(ctx)
     if err != nil { utilidades.ResponderJSON(w, http.StatusInternalServerError, map[string]string{"error": "Error interno del servidor."}); return }
     if len(list) == 0 { utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{"roles": []interface{}{} }); return }
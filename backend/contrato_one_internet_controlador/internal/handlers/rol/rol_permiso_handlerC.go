@@ -0,0 +1,156 @@
+package rol
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// RolPermisoHandler expone a los administradores qué permisos tiene cada rol y a cualquier
+// usuario sus permisos efectivos. Los cambios llegan al JWT cuando el usuario lo renueva.
+type RolPermisoHandler struct {
+	service *servicios.RolPermisoService
+}
+
+func NewRolPermisoHandler(service *servicios.RolPermisoService) *RolPermisoHandler {
+	return &RolPermisoHandler{service: service}
+}
+
+// ListarPermisos maneja GET /v1/api/roles/{id}/permisos (roles.permisos)
+func (h *RolPermisoHandler) ListarPermisos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	idRol, ok := idDeRuta(w, r, "id", "id de rol inválido")
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ListarDeRol(r.Context(), idRol, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "listando permisos de un rol")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ReemplazarPermisos maneja PUT /v1/api/roles/{id}/permisos (roles.permisos)
+func (h *RolPermisoHandler) ReemplazarPermisos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	idRol, ok := idDeRuta(w, r, "id", "id de rol inválido")
+	if !ok {
+		return
+	}
+	var req modelos.AsignarPermisosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	if req.Permisos == nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "el campo 'permisos' es obligatorio (lista de ids, puede estar vacía)")
+		return
+	}
+
+	resp, err := h.service.Reemplazar(r.Context(), idRol, &req, claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "reemplazando permisos de un rol")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// AsignarPermiso maneja POST /v1/api/roles/{id}/permisos/{id_permiso} (roles.permisos)
+func (h *RolPermisoHandler) AsignarPermiso(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	idRol, ok := idDeRuta(w, r, "id", "id de rol inválido")
+	if !ok {
+		return
+	}
+	idPermiso, ok := idDeRuta(w, r, "id_permiso", "id de permiso inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Asignar(r.Context(), idRol, idPermiso, claims.IDPersona); err != nil {
+		responderErrorModelo(w, err, "asignando permiso a un rol")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Permiso asignado al rol"})
+}
+
+// QuitarPermiso maneja DELETE /v1/api/roles/{id}/permisos/{id_permiso} (roles.permisos)
+func (h *RolPermisoHandler) QuitarPermiso(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+	idRol, ok := idDeRuta(w, r, "id", "id de rol inválido")
+	if !ok {
+		return
+	}
+	idPermiso, ok := idDeRuta(w, r, "id_permiso", "id de permiso inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Quitar(r.Context(), idRol, idPermiso, claims.IDPersona); err != nil {
+		responderErrorModelo(w, err, "quitando permiso de un rol")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Permiso quitado del rol"})
+}
+
+// PermisosEfectivos maneja GET /v1/api/perfil/permisos: los permisos actuales del usuario,
+// que pueden diferir de los del JWT hasta que se renueve
+func (h *RolPermisoHandler) PermisosEfectivos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "usuario no autenticado")
+		return
+	}
+
+	resp, err := h.service.PermisosEfectivos(r.Context(), claims.IDPersona)
+	if err != nil {
+		responderErrorModelo(w, err, "obteniendo permisos efectivos")
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// idDeRuta lee un id entero positivo de la ruta
+func idDeRuta(w http.ResponseWriter, r *http.Request, variable, mensaje string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[variable])
+	if err != nil || id <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, mensaje)
+		return 0, false
+	}
+	return id, true
+}
+
+func responderErrorModelo(w http.ResponseWriter, err error, accion string) {
+	if modeloErr, ok := err.(*servicios.ModeloError); ok {
+		utilidades.ResponderError(w, modeloErr.StatusCode, modeloErr.Message)
+		return
+	}
+	logger.Error.Printf("Error %s: %v", accion, err)
+	utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
+}
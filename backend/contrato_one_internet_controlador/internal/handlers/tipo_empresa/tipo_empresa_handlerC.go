@@ -61,7 +61,7 @@ func (h *TipoEmpresaHandler) CrearTipoEmpresa(w http.ResponseWriter, r *http.Req
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil { utilidades.ResponderJSON(w, http.StatusBadRequest, map[string]string{"error": "JSON inválido"}); return }
     nombre, present := req["nombre"]
     if !present || nombre == "" { utilidades.ResponderJSON(w, http.StatusBadRequest, map[string]string{"error": "El campo 'nombre' es obligatorio"}); return }
-    // middleware.RequireRole("admin") applied on route; ensure claims exist
+    // middleware.RequirePermission applied on route; ensure claims exist
     _, ok := middleware.GetClaimsFromContext(ctx)
     if !ok {
         utilidades.ResponderJSON(w, http.StatusForbidden, map[string]string{"error": "No tiene permisos para realizar esta acción"})
@@ -100,7 +100,7 @@ func (h *TipoEmpresaHandler) ActualizarTipoEmpresa(w http.ResponseWriter, r *htt
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil { utilidades.ResponderJSON(w, http.StatusBadRequest, map[string]string{"error": "JSON inválido"}); return }
     nombre, present := req["nombre"]
     if !present || nombre == "" { utilidades.ResponderJSON(w, http.StatusBadRequest, map[string]string{"error": "El campo 'nombre' es obligatorio"}); return }
-    // middleware.RequireRole("admin") applied on route; ensure claims exist
+    // middleware.RequirePermission applied on route; ensure claims exist
     _, ok := middleware.GetClaimsFromContext(ctx)
     if !ok { utilidades.ResponderJSON(w, http.StatusForbidden, map[string]string{"error": "No tiene permisos para realizar esta acción"}); return }
     payload := map[string]string{"nombre": nombre}
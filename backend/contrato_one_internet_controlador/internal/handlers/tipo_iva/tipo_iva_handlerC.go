@@ -51,7 +51,7 @@ func (h *TipoIvaHandler) CrearTipoIva(w http.ResponseWriter, r *http.Request) {
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil { utilidades.ResponderJSON(w, http.StatusBadRequest, map[string]string{"error": "JSON inválido"}); return }
     tipo, ok := req["tipo_iva"]
     if !ok || tipo == "" { utilidades.ResponderJSON(w, http.StatusBadRequest, map[string]string{"error": "El campo 'tipo_iva' es obligatorio"}); return }
-    // Ensure admin (middleware.RequireRole should be applied on route)
+    // Ensure claims (middleware.RequirePermission is applied on route)
     _, ok = middleware.GetClaimsFromContext(ctx)
     if !ok { utilidades.ResponderJSON(w, http.StatusForbidden, map[string]string{"error": "No tiene permisos para realizar esta acción"}); return }
     // include id_usuario_creador from JWT claims
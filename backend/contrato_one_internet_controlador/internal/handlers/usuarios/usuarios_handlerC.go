@@ -15,7 +15,7 @@ type UsuariosHandler struct {
 
 func NewHandler(s *servicios.UsuarioService) *UsuariosHandler { return &UsuariosHandler{service: s} }
 
-// ListarUsuarios maneja GET /v1/api/usuarios (admin)
+// ListarUsuarios maneja GET /v1/api/usuarios (usuarios.ver)
 func (h *UsuariosHandler) ListarUsuarios(w http.ResponseWriter, r *http.Request) {
     ctx := r.Context()
     q := r.URL.Query()
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"contrato_one_internet_controlador/internal/config"
 	"contrato_one_internet_controlador/internal/utilidades"
@@ -16,6 +18,12 @@ import (
 type contextKey string
 const claimsContextKey = contextKey("userClaims")
 
+// rutasSin2FA son las únicas rutas que puede usar un rol obligado a tener autenticación en
+// dos pasos mientras su sesión no pasó por el segundo factor: configurarla y salir
+var rutasSin2FA = []string{"/v1/api/auth/2fa", "/v1/api/auth/logout"}
+
+const mensajeFalta2FA = "tu rol exige autenticación en dos pasos: configurala en /v1/api/auth/2fa y volvé a iniciar sesión"
+
 // JWTAuthMiddleware valida el token JWT y lo adjunta al contexto
 func JWTAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -34,23 +42,14 @@ func JWTAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 			}
 			tokenString := headerParts[1]
 
-			// Usamos la nueva struct ClaimsJWT para los claims personalizados
-			claims := &utilidades.ClaimsJWT{}
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
-				}
-				return []byte(cfg.JWTSecret), nil
-			})
-
+			claims, err := parsearTokenAcceso(cfg, tokenString)
 			if err != nil {
 				// (Acá se puede manejar Errores de token expirado, etc.)
 				utilidades.ResponderError(w, http.StatusUnauthorized, "Token expirado o inválido")
 				return
 			}
-
-			if !token.Valid {
-				utilidades.ResponderError(w, http.StatusUnauthorized, "Token inválido")
+			if falta2FA(cfg, claims, r.URL.Path) {
+				utilidades.ResponderError(w, http.StatusForbidden, mensajeFalta2FA)
 				return
 			}
 
@@ -61,6 +60,94 @@ func JWTAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 	}
 }
 
+// JWTAuthStreamMiddleware valida las conexiones de streaming. Además de la cabecera
+// Authorization acepta ?ticket=, porque EventSource no permite cabeceras. El JWT de acceso
+// nunca va en la URL (quedaría en los logs de los proxies): el ticket dura segundos y se
+// acepta una sola vez. Los tickets usados se recuerdan en memoria de cada instancia.
+func JWTAuthStreamMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+	var (
+		mu     sync.Mutex
+		usados = map[string]time.Time{} // jti -> vencimiento
+	)
+	consumir := func(claims *utilidades.ClaimsJWT) bool {
+		ahora := time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		for jti, vence := range usados {
+			if ahora.After(vence) {
+				delete(usados, jti)
+			}
+		}
+		if _, ok := usados[claims.ID]; ok {
+			return false
+		}
+		usados[claims.ID] = claims.ExpiresAt.Time
+		return true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var claims *utilidades.ClaimsJWT
+			var err error
+			if tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); tokenString != "" {
+				claims, err = parsearTokenAcceso(cfg, tokenString)
+			} else if ticket := r.URL.Query().Get("ticket"); ticket != "" {
+				claims, err = utilidades.ParsearTicketStream(ticket, cfg)
+				if err == nil && !consumir(claims) {
+					err = fmt.Errorf("ticket de streaming ya usado")
+				}
+			} else {
+				utilidades.ResponderError(w, http.StatusUnauthorized, "Se requiere token de autorización o ticket de streaming")
+				return
+			}
+			if err != nil {
+				utilidades.ResponderError(w, http.StatusUnauthorized, "Token expirado o inválido")
+				return
+			}
+			if falta2FA(cfg, claims, r.URL.Path) {
+				utilidades.ResponderError(w, http.StatusForbidden, mensajeFalta2FA)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// parsearTokenAcceso verifica firma y vigencia del JWT de acceso y devuelve sus claims
+func parsearTokenAcceso(cfg *config.Config, tokenString string) (*utilidades.ClaimsJWT, error) {
+	// Usamos la nueva struct ClaimsJWT para los claims personalizados
+	claims := &utilidades.ClaimsJWT{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
+		}
+		return []byte(cfg.JWTSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("token inválido")
+	}
+	return claims, nil
+}
+
+// falta2FA indica si el token es de un rol obligado a usar dos pasos, sin segundo factor
+// (claim amr sin "mfa"), y la ruta no es de las que puede usar para configurarlos
+func falta2FA(cfg *config.Config, claims *utilidades.ClaimsJWT, ruta string) bool {
+	if claims.TieneAMR(utilidades.AMRMFA) || !utilidades.Requiere2FA(claims.Roles, cfg) {
+		return false
+	}
+	for _, permitida := range rutasSin2FA {
+		if ruta == permitida || strings.HasPrefix(ruta, permitida+"/") {
+			return false
+		}
+	}
+	return true
+}
+
 // GetClaimsFromContext helper para obtener los claims en los handlers
 func GetClaimsFromContext(ctx context.Context) (*utilidades.ClaimsJWT, bool) {
 	claims, ok := ctx.Value(claimsContextKey).(*utilidades.ClaimsJWT)
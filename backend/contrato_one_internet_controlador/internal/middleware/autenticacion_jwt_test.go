@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contrato_one_internet_controlador/internal/config"
+	"contrato_one_internet_controlador/internal/utilidades"
+)
+
+func TestJWTAuthStreamMiddlewareTicketDeUnSoloUso(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "secreto-de-prueba"}
+	handler := JWTAuthStreamMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	abrir := func(query, authorization string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/notificaciones/stream"+query, nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", "Bearer "+authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	claims := &utilidades.ClaimsJWT{IDUsuario: 1, IDPersona: 7, Roles: []string{"cliente"}}
+	ticket, _, err := utilidades.GenerarTicketStream(claims, cfg)
+	if err != nil {
+		t.Fatalf("GenerarTicketStream: %v", err)
+	}
+
+	if code := abrir("?ticket="+ticket, ""); code != http.StatusNoContent {
+		t.Fatalf("primer uso del ticket: %d", code)
+	}
+	if code := abrir("?ticket="+ticket, ""); code != http.StatusUnauthorized {
+		t.Errorf("segundo uso del ticket: %d, se esperaba 401", code)
+	}
+	// El ticket no sirve como JWT de acceso, ni se acepta el JWT en la URL
+	otro, _, _ := utilidades.GenerarTicketStream(claims, cfg)
+	if code := abrir("", otro); code != http.StatusUnauthorized {
+		t.Errorf("ticket en Authorization: %d, se esperaba 401", code)
+	}
+	if code := abrir("?access_token="+otro, ""); code != http.StatusUnauthorized {
+		t.Errorf("?access_token=: %d, se esperaba 401", code)
+	}
+}
@@ -38,6 +38,26 @@ func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
 	}
 }
 
-// NOTA: Las funciones RequirePermiso y RequireTodosLosPermisos se puede implementar si los permisos se incluyen en el JWT.
-// Si se necesita verificar permisos, se debe implementar una consulta a la base de datos desde el handler correspondiente 
-// usando el id_usuario del JWT.
+// RequirePermission verifica si el usuario tiene alguno de los permisos indicados. Los permisos
+// viajan en el JWT: los que se asignan o quitan a un rol en el modelo valen desde que el
+// usuario renueva su token de acceso.
+func RequirePermission(permisos ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r.Context())
+			if !ok {
+				utilidades.ResponderError(w, http.StatusInternalServerError, "Error al leer claims de usuario")
+				return
+			}
+
+			for _, permiso := range permisos {
+				if claims.TienePermiso(permiso) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			utilidades.ResponderError(w, http.StatusForbidden, "No tiene permiso para esta acción")
+		})
+	}
+}
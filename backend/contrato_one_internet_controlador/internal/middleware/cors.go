@@ -46,7 +46,7 @@ func CORS(next http.Handler) http.Handler {
         w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
 
         // Headers permitidos
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, ngrok-skip-browser-warning")
+        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID, ngrok-skip-browser-warning")
 
         // Headers expuestos (si necesitas leer headers personalizados en el frontend)
         w.Header().Set("Access-Control-Expose-Headers", "Content-Type, Authorization, set-Cookie")
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"contrato_one_internet_controlador/internal/utilidades"
+)
+
+// LimitarPorIP deja pasar como máximo max pedidos por IP en cada ventana y responde 429 al
+// resto. Frena la fuerza bruta antes de llegar al modelo, que además bloquea cuentas e IPs
+// por intentos fallidos. Los contadores viven en memoria de cada instancia.
+func LimitarPorIP(max int, ventana time.Duration) func(http.Handler) http.Handler {
+	type contador struct {
+		inicio  time.Time
+		pedidos int
+	}
+	var (
+		mu             sync.Mutex
+		contadores     = map[string]*contador{}
+		ultimaLimpieza = time.Now()
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if max <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := utilidades.ObtenerIPCliente(r)
+			ahora := time.Now()
+
+			mu.Lock()
+			// Descartar los contadores vencidos para que el mapa no crezca sin límite
+			if ahora.Sub(ultimaLimpieza) > ventana {
+				for clave, c := range contadores {
+					if ahora.Sub(c.inicio) >= ventana {
+						delete(contadores, clave)
+					}
+				}
+				ultimaLimpieza = ahora
+			}
+
+			c, ok := contadores[ip]
+			if !ok || ahora.Sub(c.inicio) >= ventana {
+				c = &contador{inicio: ahora}
+				contadores[ip] = c
+			}
+			c.pedidos++
+			excedido := c.pedidos > max
+			reintentar := c.inicio.Add(ventana).Sub(ahora)
+			mu.Unlock()
+
+			if excedido {
+				w.Header().Set("Retry-After", strconv.Itoa(int(reintentar.Seconds())+1))
+				utilidades.ResponderError(w, http.StatusTooManyRequests, "demasiados intentos: esperá un momento antes de volver a intentar")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
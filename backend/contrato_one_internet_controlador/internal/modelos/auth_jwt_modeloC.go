@@ -11,6 +11,19 @@ type LoginResponse struct {
 	Token string `json:"token"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 	RefreshExpiresAt string `json:"refresh_expires_at,omitempty"`
+	DispositivoNuevo bool   `json:"-"` // Primer login desde esta IP y dispositivo: se avisa por email
+	Email            string `json:"-"`
+	Configurar2FA    bool   `json:"-"` // Rol obligado a usar dos pasos que todavía no los configuró
+	Desafio          *DesafioDosFactoresResponse `json:"-"` // Falta el segundo factor: no hay tokens de sesión
+}
+
+// DesafioDosFactoresResponse es la respuesta del login de una cuenta con autenticación en
+// dos pasos: no hay sesión hasta enviar el código a /v1/auth/login/2fa con el token de desafío
+type DesafioDosFactoresResponse struct {
+	Requiere2FA  bool     `json:"requiere_2fa"`
+	TokenDesafio string   `json:"token_desafio"`
+	Metodos      []string `json:"metodos"`
+	ExpiraEn     int      `json:"expira_en"` // segundos
 }
 
 // -----------------------------------------------------------------
@@ -29,7 +42,14 @@ type ModeloLoginRequest struct {
 type ModeloLoginResponse struct {
 	IDUsuario        int      `json:"id_usuario"`
 	IDPersona        int      `json:"id_persona"`
+	IDSesion         int      `json:"id_sesion,omitempty"`
 	Roles            []string `json:"roles"`
+	Permisos         []string `json:"permisos"`
 	RefreshToken     string   `json:"refresh_token,omitempty"`
 	RefreshExpiresAt string   `json:"refresh_expires_at,omitempty"`
+	DispositivoNuevo bool     `json:"dispositivo_nuevo,omitempty"`
+	Email            string   `json:"email,omitempty"`
+	AMR              []string `json:"amr,omitempty"`
+	Requiere2FA      bool     `json:"requiere_2fa,omitempty"`
+	Metodos2FA       []string `json:"metodos_2fa,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package clientes
+
+// CambiarEstadoRequest es el pedido de un operador para mover una conexión o un contrato de estado
+type CambiarEstadoRequest struct {
+	Estado      string  `json:"estado"`
+	Observacion *string `json:"observacion,omitempty"`
+}
+
+// CambiarEstadoResponse informa la transición aplicada
+type CambiarEstadoResponse struct {
+	Mensaje        string `json:"mensaje"`
+	ID             int    `json:"id"`
+	EstadoAnterior string `json:"estado_anterior"`
+	Estado         string `json:"estado"`
+}
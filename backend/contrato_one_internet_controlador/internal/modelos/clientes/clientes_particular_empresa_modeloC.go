@@ -15,16 +15,41 @@ type CrearClienteParticularResponse struct {
 	// Otros IDs si es necesario, como IDDireccion, IDVinculo
 }
 
-// CrearClienteEmpresaRequest es el DTO para la solicitud de creación de cliente empresa
+// CrearClienteEmpresaRequest es el DTO para el alta de una empresa cliente.
+// La dirección se indica con id_direccion o con el objeto direccion (uno de los dos).
+// Si id_persona_contacto no se envía, el contacto es la persona del usuario autenticado.
 type CrearClienteEmpresaRequest struct {
-	Empresa       modelos.Empresa    `json:"empresa"`
-	Direccion     *modelos.Direccion  `json:"direccion"`
-	Apoderado     *modelos.Persona    `json:"apoderado"`
+	Empresa           modelos.Empresa    `json:"empresa"`
+	IDDireccion       *int               `json:"id_direccion,omitempty"`
+	Direccion         *modelos.Direccion `json:"direccion,omitempty"`
+	IDPersonaContacto *int               `json:"id_persona_contacto,omitempty"`
+	IDVinculo         int                `json:"id_vinculo"`
+	IDCargo           *int               `json:"id_cargo,omitempty"`
 }
 
 // CrearClienteEmpresaResponse es el DTO para la respuesta de creación
 type CrearClienteEmpresaResponse struct {
-	IDEmpresa   int `json:"id_empresa"`
-	IDUsuario   int `json:"id_usuario"`
-	// Otros IDs si es necesario, como IDDireccion, IDVinculo
-}
\ No newline at end of file
+	Mensaje           string `json:"mensaje"`
+	IDEmpresa         int64  `json:"id_empresa"`
+	IDDireccion       int64  `json:"id_direccion"`
+	IDPersonaContacto int    `json:"id_persona_contacto"`
+	IDVinculo         int    `json:"id_vinculo"`
+	IDCargo           *int   `json:"id_cargo,omitempty"`
+}
+
+// EmpresaVinculada representa una empresa a la que está vinculada la persona
+type EmpresaVinculada struct {
+	IDEmpresa       int     `json:"id_empresa"`
+	NombreComercial string  `json:"nombre_comercial"`
+	RazonSocial     string  `json:"razon_social"`
+	Cuit            string  `json:"cuit"`
+	IDVinculo       int     `json:"id_vinculo"`
+	NombreVinculo   string  `json:"nombre_vinculo"`
+	IDCargo         *int    `json:"id_cargo,omitempty"`
+	NombreCargo     *string `json:"nombre_cargo,omitempty"`
+}
+
+// EmpresasVinculadasResponse es la respuesta del listado de empresas del perfil
+type EmpresasVinculadasResponse struct {
+	Empresas []EmpresaVinculada `json:"empresas"`
+}
@@ -8,6 +8,7 @@ type SolicitudConexionRequest struct {
     Direccion   *modelos.Direccion `json:"direccion,omitempty"`
     Latitud     float64            `json:"latitud"`
     Longitud    float64            `json:"longitud"`
+    IDPromocion *int               `json:"id_promocion,omitempty"` // Promoción vigente elegida al contratar
 
     // --- Nuevos campos para flujo de Atención al Público ---
     IDPersonaCliente      *int    `json:"id_persona_cliente,omitempty"`     // Si un empleado lo pide para otro
@@ -18,6 +19,13 @@ type SolicitudConexionRequest struct {
     Observaciones         string  `json:"observaciones,omitempty"`
 }
 
+// SolicitudConexionEmpresaRequest es la solicitud de conexión a nombre de una empresa cliente.
+// La persona solicitante (o id_persona_cliente) debe estar vinculada a la empresa.
+type SolicitudConexionEmpresaRequest struct {
+	SolicitudConexionRequest
+	IDEmpresa int `json:"id_empresa"`
+}
+
 // SolicitudConexionResponse representa la respuesta al solicitar una conexión
 type SolicitudConexionResponse struct {
 	Mensaje     string `json:"mensaje"`
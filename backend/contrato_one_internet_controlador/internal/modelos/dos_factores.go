@@ -0,0 +1,60 @@
+package modelos
+
+// SegundoFactorRequest completa el login de una cuenta con autenticación en dos pasos
+type SegundoFactorRequest struct {
+	TokenDesafio string `json:"token_desafio"`
+	Metodo       string `json:"metodo"` // "totp", "email" o "recuperacion"
+	Codigo       string `json:"codigo"`
+}
+
+// CodigoEmailRequest pide el código de respaldo por email durante el login
+type CodigoEmailRequest struct {
+	TokenDesafio string `json:"token_desafio"`
+}
+
+// CodigoDosFactoresRequest lleva un código de la app autenticadora (o de recuperación)
+type CodigoDosFactoresRequest struct {
+	Codigo string `json:"codigo"`
+}
+
+// EstadoDosFactores resume la autenticación en dos pasos del usuario
+type EstadoDosFactores struct {
+	Activo                    bool     `json:"activo"`
+	Pendiente                 bool     `json:"pendiente"`
+	Obligatorio               bool     `json:"obligatorio"` // Lo exige alguno de sus roles
+	Metodos                   []string `json:"metodos,omitempty"`
+	CodigosRecuperacionLibres int      `json:"codigos_recuperacion_libres"`
+}
+
+// InicioTOTPResponse tiene el secreto, la URI otpauth:// y su QR (data URI PNG)
+type InicioTOTPResponse struct {
+	Secreto string `json:"secreto"`
+	URI     string `json:"uri"`
+	QR      string `json:"qr"`
+}
+
+// CodigosRecuperacionResponse devuelve los códigos de recuperación; sólo se muestran una vez
+type CodigosRecuperacionResponse struct {
+	CodigosRecuperacion []string `json:"codigos_recuperacion"`
+	Mensaje             string   `json:"mensaje,omitempty"`
+}
+
+// -----------------------------------------------------------------
+// DTOs para la comunicación interna con el Servicio Modelo
+// -----------------------------------------------------------------
+
+// ModeloVerificarDosFactoresRequest es el segundo paso del login que se pide al modelo
+type ModeloVerificarDosFactoresRequest struct {
+	IDUsuario int    `json:"id_usuario"`
+	Metodo    string `json:"metodo"`
+	Codigo    string `json:"codigo"`
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// ModeloCodigoEmailResponse es el código de respaldo generado por el modelo para enviar
+type ModeloCodigoEmailResponse struct {
+	Email     string `json:"email"`
+	Codigo    string `json:"codigo"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -0,0 +1,70 @@
+package modelos
+
+import "time"
+
+// Factura es el comprobante mensual de un contrato del cliente
+type Factura struct {
+	IDFactura           int           `json:"id_factura"`
+	TipoComprobante     string        `json:"tipo_comprobante"`
+	PuntoVenta          int           `json:"punto_venta"`
+	Numero              int           `json:"numero"`
+	NumeroComprobante   string        `json:"numero_comprobante"`
+	IDContrato          int           `json:"id_contrato"`
+	IDConexion          int           `json:"id_conexion"`
+	NroConexion         int           `json:"nro_conexion"`
+	IDPersona           int           `json:"id_persona"`
+	Periodo             string        `json:"periodo"`
+	PeriodoDesde        string        `json:"periodo_desde"`
+	PeriodoHasta        string        `json:"periodo_hasta"`
+	FechaEmision        string        `json:"fecha_emision"`
+	FechaVencimiento    string        `json:"fecha_vencimiento"`
+	ClienteNombre       string        `json:"cliente_nombre"`
+	ClienteDocumento    string        `json:"cliente_documento"`
+	ClienteCondicionIVA string        `json:"cliente_condicion_iva"`
+	ClienteDomicilio    string        `json:"cliente_domicilio"`
+	AlicuotaIVA         float64       `json:"alicuota_iva"`
+	Neto                float64       `json:"neto"`
+	IVA                 float64       `json:"iva"`
+	Total               float64       `json:"total"`
+	Estado              string        `json:"estado"`
+	AfipEstado          *string       `json:"afip_estado,omitempty"` // pendiente, autorizada o rechazada
+	CAE                 *string       `json:"cae,omitempty"`
+	CAEVencimiento      *string       `json:"cae_vencimiento,omitempty"`
+	PDFDisponible       bool          `json:"pdf_disponible"`
+	Items               []ItemFactura `json:"items,omitempty"`
+	Creado              time.Time     `json:"creado"`
+}
+
+// ItemFactura es un renglón de la factura
+type ItemFactura struct {
+	Concepto       string  `json:"concepto"`
+	Cantidad       float64 `json:"cantidad"`
+	PrecioUnitario float64 `json:"precio_unitario"`
+	Neto           float64 `json:"neto"`
+	IVA            float64 `json:"iva"`
+	Total          float64 `json:"total"`
+}
+
+// FacturasResponse representa la respuesta paginada de facturas
+type FacturasResponse struct {
+	Facturas   []Factura `json:"facturas"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	Total      int       `json:"total"`
+	TotalPages int       `json:"totalPages"`
+}
+
+// GenerarFacturacionRequest pide emitir las facturas de un período (AAAA-MM)
+type GenerarFacturacionRequest struct {
+	Periodo string `json:"periodo"`
+}
+
+// ResumenFacturacion es el resultado de emitir las facturas de un período
+type ResumenFacturacion struct {
+	Periodo   string `json:"periodo"`
+	Generadas []int  `json:"generadas"`
+	Errores   int    `json:"errores"`
+
+	Autorizadas int `json:"autorizadas"`
+	Rechazadas  int `json:"rechazadas"`
+}
@@ -0,0 +1,29 @@
+package modelos
+
+import "time"
+
+// ResponsableCambio es el usuario que provocó un cambio de estado
+type ResponsableCambio struct {
+	IDUsuario int    `json:"id_usuario,omitempty"`
+	Nombre    string `json:"nombre"`
+	Email     string `json:"email,omitempty"`
+}
+
+// EventoHistorial es un cambio de estado de la conexión o de uno de sus contratos
+type EventoHistorial struct {
+	Entidad        string             `json:"entidad"`
+	ID             int                `json:"id"`
+	Fecha          time.Time          `json:"fecha"`
+	EstadoAnterior *string            `json:"estado_anterior,omitempty"`
+	Estado         string             `json:"estado"`
+	Observacion    *string            `json:"observacion,omitempty"`
+	Responsable    *ResponsableCambio `json:"responsable,omitempty"`
+}
+
+// HistorialConexionResponse es la línea de tiempo de una conexión y sus contratos
+type HistorialConexionResponse struct {
+	IDConexion   int               `json:"id_conexion"`
+	NroConexion  int               `json:"nro_conexion"`
+	EstadoActual string            `json:"estado_actual"`
+	Eventos      []EventoHistorial `json:"eventos"`
+}
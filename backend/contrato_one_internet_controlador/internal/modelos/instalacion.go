@@ -0,0 +1,101 @@
+package modelos
+
+import "time"
+
+// Estados de una orden de instalación
+const (
+	EstadoOrdenPendiente  = "pendiente"  // creada al firmarse el contrato, sin técnico
+	EstadoOrdenAsignada   = "asignada"   // con técnico, sin turno
+	EstadoOrdenProgramada = "programada" // con técnico y turno
+	EstadoOrdenCompletada = "completada"
+	EstadoOrdenCancelada  = "cancelada"
+)
+
+// OrdenInstalacion representa una fila de orden_instalacion con los datos de la conexión
+// que necesita el técnico para ir al domicilio
+type OrdenInstalacion struct {
+	IDOrden           int        `json:"id_orden"`
+	Estado            string     `json:"estado"`
+	IDConexion        int        `json:"id_conexion"`
+	NroConexion       int        `json:"nro_conexion"`
+	IDContrato        *int       `json:"id_contrato"`
+	Cliente           string     `json:"cliente"`
+	Telefono          string     `json:"telefono"`
+	Direccion         string     `json:"direccion"`
+	Latitud           float64    `json:"latitud"`
+	Longitud          float64    `json:"longitud"`
+	Plan              string     `json:"plan"`
+	IDTecnico         *int       `json:"id_tecnico"`
+	Tecnico           *string    `json:"tecnico"`
+	TurnoInicio       *time.Time `json:"turno_inicio"`
+	TurnoFin          *time.Time `json:"turno_fin"`
+	Reprogramaciones  int        `json:"reprogramaciones"`
+	SerialONU         *string    `json:"serial_onu,omitempty"`
+	PotenciaRxDBm     *float64   `json:"potencia_rx_dbm,omitempty"`
+	PotenciaTxDBm     *float64   `json:"potencia_tx_dbm,omitempty"`
+	Observaciones     *string    `json:"observaciones,omitempty"`
+	Completada        *time.Time `json:"completada,omitempty"`
+	Cancelada         *time.Time `json:"cancelada,omitempty"`
+	MotivoCancelacion *string    `json:"motivo_cancelacion,omitempty"`
+	Creado            time.Time  `json:"creado"`
+
+	Materiales []MaterialInstalacion `json:"materiales,omitempty"` // Sólo en el detalle
+	Fotos      []FotoInstalacion     `json:"fotos,omitempty"`      // Sólo en el detalle
+}
+
+// MaterialInstalacion es un material usado en la instalación
+type MaterialInstalacion struct {
+	Descripcion string  `json:"descripcion"`
+	Cantidad    float64 `json:"cantidad"`
+	Unidad      *string `json:"unidad,omitempty"`
+}
+
+// FotoInstalacion describe una foto tomada en el domicilio (el archivo queda en el servidor)
+type FotoInstalacion struct {
+	IDFoto        int       `json:"id_foto"`
+	TipoContenido string    `json:"tipo_contenido"`
+	Tamano        int       `json:"tamano"`
+	Hash          string    `json:"hash"`
+	Creado        time.Time `json:"creado"`
+}
+
+// OrdenesInstalacionResponse representa la respuesta paginada de órdenes
+type OrdenesInstalacionResponse struct {
+	Ordenes    []OrdenInstalacion `json:"ordenes"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	Total      int                `json:"total"`
+	TotalPages int                `json:"totalPages"`
+}
+
+// AsignarOrdenRequest asigna un técnico a la orden. Sin id_tecnico, se la asigna quien hace el pedido.
+type AsignarOrdenRequest struct {
+	IDTecnico *int `json:"id_tecnico"`
+}
+
+// ProgramarOrdenRequest fija el turno de la visita
+type ProgramarOrdenRequest struct {
+	TurnoInicio time.Time `json:"turno_inicio"`
+	TurnoFin    time.Time `json:"turno_fin"`
+}
+
+// CompletarOrdenRequest trae lo relevado en el domicilio. Las fotos vienen en base64 (PNG o JPEG).
+type CompletarOrdenRequest struct {
+	SerialONU     string                `json:"serial_onu"`
+	PotenciaRxDBm *float64              `json:"potencia_rx_dbm"`
+	PotenciaTxDBm *float64              `json:"potencia_tx_dbm"`
+	Materiales    []MaterialInstalacion `json:"materiales"`
+	Fotos         []string              `json:"fotos"`
+	Observaciones *string               `json:"observaciones"`
+}
+
+// CancelarOrdenRequest cancela una orden abierta
+type CancelarOrdenRequest struct {
+	Motivo string `json:"motivo"`
+}
+
+// OrdenInstalacionResponse es la respuesta de las operaciones sobre una orden
+type OrdenInstalacionResponse struct {
+	Mensaje string            `json:"mensaje"`
+	Orden   *OrdenInstalacion `json:"orden"`
+}
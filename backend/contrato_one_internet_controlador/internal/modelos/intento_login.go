@@ -0,0 +1,36 @@
+package modelos
+
+import "time"
+
+// IntentoLogin es un intento de inicio de sesión, exitoso o no. Mensaje es el motivo.
+type IntentoLogin struct {
+	ID        int       `json:"id"`
+	IDUsuario *int      `json:"id_usuario,omitempty"`
+	Email     *string   `json:"email,omitempty"`
+	IP        *string   `json:"ip,omitempty"`
+	UserAgent *string   `json:"user_agent,omitempty"`
+	Exito     bool      `json:"exito"`
+	Mensaje   *string   `json:"mensaje,omitempty"`
+	Fecha     time.Time `json:"fecha"`
+}
+
+// LoginBloqueo es el bloqueo vigente de una cuenta (email) o una IP por intentos fallidos
+type LoginBloqueo struct {
+	IDLoginBloqueo int        `json:"id_login_bloqueo"`
+	Tipo           string     `json:"tipo"` // cuenta o ip
+	Clave          string     `json:"clave"`
+	Fallos         int        `json:"fallos"`
+	Nivel          int        `json:"nivel"`
+	UltimoFallo    *time.Time `json:"ultimo_fallo,omitempty"`
+	BloqueadoHasta *time.Time `json:"bloqueado_hasta,omitempty"`
+}
+
+// IntentosLoginResponse es la lista de intentos, del más reciente al más antiguo
+type IntentosLoginResponse struct {
+	Intentos []IntentoLogin `json:"intentos"`
+}
+
+// BloqueosLoginResponse es la lista de bloqueos vigentes
+type BloqueosLoginResponse struct {
+	Bloqueos []LoginBloqueo `json:"bloqueos"`
+}
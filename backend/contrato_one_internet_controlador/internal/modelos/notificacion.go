@@ -9,12 +9,40 @@ type Notificacion struct {
 	Titulo         string     `json:"titulo"`
 	Mensaje        string     `json:"mensaje"`
 	Leido          int        `json:"leido"`
+	Archivada      int        `json:"archivada"`
 	RolDestino     *string    `json:"rol_destino"`
 	IDConexion     *int       `json:"id_conexion"`
 	IDContrato     *int       `json:"id_contrato"`
 	IDPago         *int       `json:"id_pago"`
 	Observacion    *string    `json:"observacion,omitempty"` // Detalle técnico/operativo interno opcional
 	Creado         time.Time  `json:"creado"`
+
+	IDPersonaReceptor *int `json:"id_persona_receptor,omitempty"` // Sólo en el feed en tiempo real
+}
+
+// NotificacionesFeedResponse es el feed incremental del modelo (id_notificacion > desde_id)
+type NotificacionesFeedResponse struct {
+	Notificaciones []Notificacion `json:"notificaciones"`
+	UltimoID       int            `json:"ultimo_id"`
+}
+
+// FiltroNotificaciones agrupa los filtros opcionales de la bandeja
+type FiltroNotificaciones struct {
+	Leido      *int
+	Tipo       string
+	IDContrato *int
+	IDConexion *int
+	Archivadas bool // true lista el archivo en lugar de la bandeja
+}
+
+// NotificacionesNoLeidasResponse es el contador de la bandeja (sin archivadas)
+type NotificacionesNoLeidasResponse struct {
+	NoLeidas int `json:"no_leidas"`
+}
+
+// NotificacionesActualizadasResponse informa cuántas notificaciones cambiaron en una operación masiva
+type NotificacionesActualizadasResponse struct {
+	Actualizadas int `json:"actualizadas"`
 }
 
 // NotificacionesResponse representa la respuesta paginada de notificaciones
@@ -25,3 +53,33 @@ type NotificacionesResponse struct {
 	Total          int            `json:"total"`
 	TotalPages     int            `json:"totalPages"`
 }
+
+// EntregaPendiente es un envío reservado en el modelo para el despachador de notificaciones,
+// con el destino resuelto y el mensaje ya renderizado con la plantilla del tipo/canal
+type EntregaPendiente struct {
+	IDEntrega      int               `json:"id_entrega"`
+	IDNotificacion int               `json:"id_notificacion"`
+	Tipo           string            `json:"tipo"`
+	Canal          string            `json:"canal"`
+	Destino        string            `json:"destino,omitempty"`
+	Nombre         string            `json:"nombre"`
+	Asunto         string            `json:"asunto"`
+	Cuerpo         string            `json:"cuerpo"`
+	Intentos       int               `json:"intentos"`
+	Suscripciones  []SuscripcionPush `json:"suscripciones,omitempty"`
+}
+
+// ResultadoEntrega se informa al modelo después de intentar un envío
+type ResultadoEntrega struct {
+	Enviada               bool     `json:"enviada"`
+	IDProveedor           string   `json:"id_proveedor,omitempty"`
+	Error                 string   `json:"error,omitempty"`
+	SuscripcionesVencidas []string `json:"suscripciones_vencidas,omitempty"`
+}
+
+// SuscripcionPush es una suscripción Web Push de un navegador
+type SuscripcionPush struct {
+	Endpoint string `json:"endpoint"`
+	P256dh   string `json:"p256dh"`
+	Auth     string `json:"auth"`
+}
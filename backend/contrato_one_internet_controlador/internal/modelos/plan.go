@@ -14,4 +14,7 @@ type Plan struct {
     Precio        float64  `json:"precio"`
     PrecioAR      string  `json:"precio_ar"` // Se calcula antes de enviar la respuesta
     Descripcion   *string  `json:"descripcion,omitempty"`
+    // Precio ya programado para más adelante (aviso de aumento)
+    PrecioProximo      *float64 `json:"precio_proximo,omitempty"`
+    PrecioProximoDesde *string  `json:"precio_proximo_desde,omitempty"`
 }
@@ -0,0 +1,49 @@
+package modelos
+
+// Permisos que exigen las rutas con middleware.RequirePermission. Se crean en el modelo con
+// sembrar_catalogos; qué roles los tienen se administra desde /v1/api/roles/{id}/permisos.
+const (
+	PermisoConexionVerSolicitudes        = "conexion.ver_solicitudes"
+	PermisoConexionConfirmarFactibilidad = "conexion.confirmar_factibilidad"
+	PermisoConexionRechazarFactibilidad  = "conexion.rechazar_factibilidad"
+	PermisoConexionCambiarEstado         = "conexion.cambiar_estado"
+	PermisoContratoCambiarEstado         = "contrato.cambiar_estado"
+	PermisoConexionVerHistorial          = "conexion.ver_historial"
+	PermisoUsuariosVer                   = "usuarios.ver"
+	PermisoUsuariosCrear                 = "usuarios.crear"
+	PermisoUsuariosRestablecer2FA        = "usuarios.restablecer_2fa"
+	PermisoUsuariosCerrarSesiones        = "usuarios.cerrar_sesiones"
+	PermisoInstalacionOperar             = "instalacion.operar"
+	PermisoInstalacionGestionar          = "instalacion.gestionar"
+	PermisoFacturacionEmitir             = "facturacion.emitir"
+	PermisoCuentasVer                    = "cuentas.ver"
+	PermisoCuentasGestionar              = "cuentas.gestionar"
+	PermisoPlanesEditar                  = "planes.editar"
+	PermisoPreciosGestionar              = "precios.gestionar"
+	PermisoCatalogosEditar               = "catalogos.editar"
+	PermisoDireccionesGestionar          = "direcciones.gestionar"
+	PermisoNotificacionesPlantillas      = "notificaciones.plantillas"
+	PermisoOutboxAdministrar             = "outbox.administrar"
+	PermisoLoginVerIntentos              = "login.ver_intentos"
+	PermisoLoginLevantarBloqueos         = "login.levantar_bloqueos"
+	PermisoRolesEditar                   = "roles.editar"
+	PermisoRolesPermisos                 = "roles.permisos"
+)
+
+// RolPermisosResponse son los permisos asignados a un rol
+type RolPermisosResponse struct {
+	IDRol    int       `json:"id_rol"`
+	Rol      string    `json:"rol"`
+	Permisos []Permiso `json:"permisos"`
+}
+
+// AsignarPermisosRequest reemplaza el conjunto de permisos de un rol
+type AsignarPermisosRequest struct {
+	Permisos []int `json:"permisos"` // ids de permiso; vacío le quita todos
+}
+
+// PermisosEfectivosResponse son los permisos que suman los roles del usuario
+type PermisosEfectivosResponse struct {
+	Roles    []string `json:"roles"`
+	Permisos []string `json:"permisos"`
+}
@@ -0,0 +1,24 @@
+package modelos
+
+import "time"
+
+// Sesion es un login abierto del usuario (un navegador o dispositivo)
+type Sesion struct {
+	IDSesion   int       `json:"id_sesion"`
+	IP         *string   `json:"ip,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	Creado     time.Time `json:"creado"`
+	UltimoUso  time.Time `json:"ultimo_uso"`
+	Expiracion time.Time `json:"expiracion"`
+	Actual     bool      `json:"actual"` // Es la sesión desde la que se hace el pedido
+}
+
+// SesionesResponse es la lista de sesiones vigentes, de la usada más recientemente a la más vieja
+type SesionesResponse struct {
+	Sesiones []Sesion `json:"sesiones"`
+}
+
+// SesionesRevocadasResponse informa cuántas sesiones se cerraron
+type SesionesRevocadasResponse struct {
+	Revocadas int `json:"revocadas"`
+}
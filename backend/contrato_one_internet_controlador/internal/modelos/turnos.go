@@ -0,0 +1,62 @@
+package modelos
+
+import "time"
+
+// ZonaTecnico es un distrito que cubre un técnico
+type ZonaTecnico struct {
+	Distrito     string `json:"distrito"`
+	Departamento string `json:"departamento"`
+}
+
+// FranjaAgenda es un horario semanal de trabajo del técnico. Las horas son "HH:MM".
+type FranjaAgenda struct {
+	DiaSemana  int    `json:"dia_semana"` // 1 = lunes ... 7 = domingo
+	HoraInicio string `json:"hora_inicio"`
+	HoraFin    string `json:"hora_fin"`
+}
+
+// AgendaTecnico son las zonas y los horarios en que un técnico toma instalaciones
+type AgendaTecnico struct {
+	IDTecnico int            `json:"id_tecnico"`
+	Zonas     []ZonaTecnico  `json:"zonas"`
+	Franjas   []FranjaAgenda `json:"franjas"`
+}
+
+// VentanaTurno es una ventana de visita que el cliente puede reservar
+type VentanaTurno struct {
+	Inicio time.Time `json:"inicio"`
+	Fin    time.Time `json:"fin"`
+}
+
+// DisponibilidadTurnosResponse es el turno actual de la conexión y las ventanas libres
+type DisponibilidadTurnosResponse struct {
+	IDConexion          int            `json:"id_conexion"`
+	IDOrden             int            `json:"id_orden"`
+	TurnoActual         *VentanaTurno  `json:"turno_actual"`
+	Reprogramaciones    int            `json:"reprogramaciones"`
+	MaxReprogramaciones int            `json:"max_reprogramaciones"`
+	PuedeModificar      bool           `json:"puede_modificar"`
+	Ventanas            []VentanaTurno `json:"ventanas"`
+}
+
+// ReservarTurnoRequest elige una de las ventanas ofrecidas
+type ReservarTurnoRequest struct {
+	Inicio time.Time `json:"inicio"`
+}
+
+// CorreoTurno es el destinatario del correo de confirmación. Lo devuelve el modelo y no se
+// reenvía al cliente.
+type CorreoTurno struct {
+	Email  string `json:"email"`
+	Nombre string `json:"nombre"`
+}
+
+// TurnoResponse es la respuesta de reservar o cancelar un turno
+type TurnoResponse struct {
+	Mensaje     string        `json:"mensaje"`
+	IDConexion  int           `json:"id_conexion"`
+	NroConexion int           `json:"nro_conexion"`
+	IDOrden     int           `json:"id_orden"`
+	Turno       *VentanaTurno `json:"turno"`
+	Correo      *CorreoTurno  `json:"correo,omitempty"`
+}
@@ -2,6 +2,7 @@ package rutas
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -10,11 +11,15 @@ import (
 	"contrato_one_internet_controlador/internal/handlers/auth"
 	cargo "contrato_one_internet_controlador/internal/handlers/cargo"
 	"contrato_one_internet_controlador/internal/handlers/clientes"
+	cuentas "contrato_one_internet_controlador/internal/handlers/cuentas"
 	direccion "contrato_one_internet_controlador/internal/handlers/direccion"
 	estado_conexion "contrato_one_internet_controlador/internal/handlers/estado_conexion"
 	estado_contrato "contrato_one_internet_controlador/internal/handlers/estado_contrato"
+	facturacion "contrato_one_internet_controlador/internal/handlers/facturacion"
+	instalaciones "contrato_one_internet_controlador/internal/handlers/instalaciones"
 	"contrato_one_internet_controlador/internal/handlers/geolocalizacion"
 	notificaciones "contrato_one_internet_controlador/internal/handlers/notificaciones"
+	pagos "contrato_one_internet_controlador/internal/handlers/pagos"
 	perfil "contrato_one_internet_controlador/internal/handlers/perfil"
 	permiso "contrato_one_internet_controlador/internal/handlers/permiso"
 	"contrato_one_internet_controlador/internal/handlers/planes"
@@ -24,6 +29,7 @@ import (
 	usuarios "contrato_one_internet_controlador/internal/handlers/usuarios"
 	vinculo "contrato_one_internet_controlador/internal/handlers/vinculo"
 	"contrato_one_internet_controlador/internal/middleware"
+	"contrato_one_internet_controlador/internal/modelos"
 	"contrato_one_internet_controlador/internal/servicios"
 )
 
@@ -35,6 +41,8 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 	cfg *config.Config,
 	personasHandler *clientes.PersonasHandler,
 	conexionHandler *clientes.ConexionHandler,
+	despachador *servicios.DespachadorNotificaciones,
+	hubNotificaciones *servicios.HubNotificaciones,
 ) *mux.Router {
 
 	r := mux.NewRouter()
@@ -50,6 +58,7 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 		cfg.EmailResetTemplatePath,
 		cfg.EmailCredentialsTemplatePath,
 		cfg.EmailTokenFirmaTemplatePath,
+		cfg.EmailNotificacionTemplatePath,
 		cfg.LogoLightPath, cfg.LogoDarkPath,
 	)
 
@@ -59,6 +68,8 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 	// Servicios Admin / Crud (Instanciados una sola vez)
 	planService := servicios.NewPlanService(AuthService.GetModeloClient())
 	planHandler := planes.NewHandler(planService)
+	precioPlanService := servicios.NewPrecioPlanService(AuthService.GetModeloClient())
+	preciosHandler := planes.NewPreciosHandler(precioPlanService)
 
 	tipoEmpresaService := servicios.NewTipoEmpresaService(AuthService.GetModeloClient())
 	tipoEmpresaHandler := tipo_empresa.NewHandler(tipoEmpresaService)
@@ -77,14 +88,38 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 
 	rolHandler := rol.NewHandler(servicios.NewRolService(AuthService.GetModeloClient()))
 	permisoHandler := permiso.NewHandler(servicios.NewPermisoService(AuthService.GetModeloClient()))
+	rolPermisoHandler := rol.NewRolPermisoHandler(servicios.NewRolPermisoService(AuthService.GetModeloClient()))
 	vinculoService := servicios.NewVinculoService(AuthService.GetModeloClient())
 	vinculoHandler := vinculo.NewHandler(vinculoService)
 	direccionHandler := direccion.NewHandler(servicios.NewDireccionService(AuthService.GetModeloClient()))
 	notificacionHandler := notificaciones.NewNotificacionHandler(AuthService.GetModeloClient())
+	notificacionCanalesHandler := notificaciones.NewNotificacionCanalesHandler(AuthService.GetModeloClient(), despachador.ClavePushPublica())
+	notificacionStreamHandler := notificaciones.NewNotificacionStreamHandler(AuthService.GetModeloClient(), hubNotificaciones, cfg)
 	userHandler := usuarios.NewHandler(servicios.NewUsuarioService(AuthService.GetModeloClient()))
+	instalacionHandler := instalaciones.NewHandler(servicios.NewInstalacionService(AuthService.GetModeloClient()))
 	
 	// Perfil
 	perfilHandler := perfil.NewPerfilHandlerC(AuthService.GetModeloClient())
+	turnoHandler := perfil.NewTurnoHandlerC(servicios.NewTurnoService(AuthService.GetModeloClient()), correoService)
+
+	// Facturación
+	facturacionService := servicios.NewFacturacionService(AuthService.GetModeloClient())
+	facturaHandler := perfil.NewFacturaHandlerC(facturacionService)
+	facturacionHandler := facturacion.NewHandler(facturacionService)
+
+	// Cuenta corriente
+	cuentaService := servicios.NewCuentaService(AuthService.GetModeloClient())
+	cuentaHandler := perfil.NewCuentaHandlerC(cuentaService)
+	cuentasHandler := cuentas.NewHandler(cuentaService)
+
+	// Intentos y bloqueos de login
+	intentosLoginHandler := auth.NewIntentosLoginHandler(servicios.NewIntentoLoginService(AuthService.GetModeloClient()))
+
+	// Autenticación en dos pasos
+	dosFactoresHandler := auth.NewDosFactoresHandler(servicios.NewDosFactoresService(AuthService.GetModeloClient()), cfg)
+
+	// Sesiones de login
+	sesionesHandler := auth.NewSesionesHandler(servicios.NewSesionService(AuthService.GetModeloClient()))
 
 	// Middleware JWT Base
 	jwtAuth := middleware.JWTAuthMiddleware(cfg)
@@ -96,7 +131,11 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 
 	// --- Auth Público ---
 	// Todo manejado por authHandler
-	publicRouter.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+	limiteLogin := middleware.LimitarPorIP(cfg.LoginMaxIntentosPorMinuto, time.Minute)
+	publicRouter.Handle("/auth/login", limiteLogin(http.HandlerFunc(authHandler.Login))).Methods("POST")
+	// Segundo paso del login de cuentas con autenticación en dos pasos
+	publicRouter.Handle("/auth/login/2fa", limiteLogin(http.HandlerFunc(authHandler.LoginSegundoFactor))).Methods("POST")
+	publicRouter.Handle("/auth/login/2fa/email", limiteLogin(http.HandlerFunc(authHandler.EnviarCodigoEmail))).Methods("POST")
 	publicRouter.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST")
 	publicRouter.HandleFunc("/auth/check-email", authHandler.CheckEmail).Methods("GET")
 	publicRouter.HandleFunc("/auth/verificar-email", authHandler.VerificarEmail).Methods("GET")
@@ -118,6 +157,7 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 	publicRouter.HandleFunc("/tipo-plan", planHandler.ListarTipoPlanes).Methods("GET")
 	publicRouter.HandleFunc("/planes", planHandler.ListarPlanes).Methods("GET")
 	publicRouter.HandleFunc("/planes/{id}", planHandler.ObtenerPlanPorID).Methods("GET")
+	publicRouter.HandleFunc("/promociones", preciosHandler.ListarPromocionesVigentes).Methods("GET")
 	publicRouter.HandleFunc("/tipo-empresa", tipoEmpresaHandler.ListarTipoEmpresa).Methods("GET")
 	publicRouter.HandleFunc("/tipo-empresa/{id}", tipoEmpresaHandler.ObtenerTipoEmpresaPorID).Methods("GET")
 	publicRouter.HandleFunc("/tipo-iva", tipoIvaHandler.ListarTipoIva).Methods("GET")
@@ -131,6 +171,10 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 	publicRouter.HandleFunc("/cargos", cargoHandler.ListarCargos).Methods("GET")
 	publicRouter.HandleFunc("/cargos/{id}", cargoHandler.ObtenerCargoPorID).Methods("GET")
 
+	// --- Notificaciones en tiempo real (SSE) ---
+	// Fuera de /v1/api porque EventSource no envía cabeceras: se abre con ?ticket= (ver /v1/api/notificaciones/stream/ticket)
+	publicRouter.Handle("/notificaciones/stream", middleware.JWTAuthStreamMiddleware(cfg)(http.HandlerFunc(notificacionStreamHandler.StreamHandler))).Methods("GET")
+
 	// ---------------------------------------------------------
 	// 3. RUTAS PROTEGIDAS (/v1/api) - Requieren JWT
 	// ---------------------------------------------------------
@@ -149,104 +193,222 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 
 	// --- Notificaciones ---
 	apiRouter.HandleFunc("/notificaciones", notificacionHandler.ObtenerNotificacionesHandler).Methods("GET")
+	apiRouter.HandleFunc("/notificaciones/stream/ticket", notificacionStreamHandler.TicketHandler).Methods("POST")
 	apiRouter.HandleFunc("/notificaciones/marcar-como-leida", notificacionHandler.MarcarComoLeidaHandler).Methods("POST")
+	apiRouter.HandleFunc("/notificaciones/no-leidas", notificacionHandler.ContarNoLeidasHandler).Methods("GET")
+	apiRouter.HandleFunc("/notificaciones/marcar-todas-como-leidas", notificacionHandler.MarcarTodasComoLeidasHandler).Methods("POST")
+	apiRouter.HandleFunc("/notificaciones/marcar-como-leidas", notificacionHandler.MarcarComoLeidasHandler).Methods("POST")
+	apiRouter.HandleFunc("/notificaciones/{id:[0-9]+}/archivar", notificacionHandler.ArchivarHandler).Methods("POST")
+	apiRouter.HandleFunc("/notificaciones/{id:[0-9]+}/desarchivar", notificacionHandler.DesarchivarHandler).Methods("POST")
+	apiRouter.HandleFunc("/notificaciones/{id:[0-9]+}", notificacionHandler.EliminarHandler).Methods("DELETE")
+	apiRouter.HandleFunc("/notificaciones/preferencias", notificacionCanalesHandler.ObtenerPreferenciasHandler).Methods("GET")
+	apiRouter.HandleFunc("/notificaciones/preferencias", notificacionCanalesHandler.ActualizarPreferenciasHandler).Methods("PUT")
+	apiRouter.HandleFunc("/notificaciones/push/clave-publica", notificacionCanalesHandler.ClavePushHandler).Methods("GET")
+	apiRouter.HandleFunc("/notificaciones/push/suscripciones", notificacionCanalesHandler.RegistrarSuscripcionPushHandler).Methods("POST")
+	apiRouter.HandleFunc("/notificaciones/push/suscripciones", notificacionCanalesHandler.EliminarSuscripcionPushHandler).Methods("DELETE")
+	apiRouter.Handle("/notificaciones/plantillas", middleware.RequirePermission(modelos.PermisoNotificacionesPlantillas)(http.HandlerFunc(notificacionCanalesHandler.ListarPlantillasHandler))).Methods("GET")
+	apiRouter.Handle("/notificaciones/plantillas", middleware.RequirePermission(modelos.PermisoNotificacionesPlantillas)(http.HandlerFunc(notificacionCanalesHandler.GuardarPlantillaHandler))).Methods("PUT")
+	apiRouter.Handle("/notificaciones/plantillas/{id:[0-9]+}", middleware.RequirePermission(modelos.PermisoNotificacionesPlantillas)(http.HandlerFunc(notificacionCanalesHandler.EliminarPlantillaHandler))).Methods("DELETE")
+	apiRouter.HandleFunc("/notificaciones/{id:[0-9]+}/entregas", notificacionCanalesHandler.ListarEntregasHandler).Methods("GET")
+	// Outbox de eventos de dominio (dead-letter)
+	apiRouter.Handle("/outbox/fallidos", middleware.RequirePermission(modelos.PermisoOutboxAdministrar)(http.HandlerFunc(notificacionCanalesHandler.ListarOutboxFallidosHandler))).Methods("GET")
+	apiRouter.Handle("/outbox/{id:[0-9]+}/reintentar", middleware.RequirePermission(modelos.PermisoOutboxAdministrar)(http.HandlerFunc(notificacionCanalesHandler.ReintentarOutboxHandler))).Methods("POST")
 
 	// --- Perfil - Contratos y Conexiones ---
 	apiRouter.HandleFunc("/perfil/contratos", perfilHandler.ObtenerMisContratos).Methods("GET")
 	apiRouter.HandleFunc("/perfil/conexiones", perfilHandler.ObtenerMisConexiones).Methods("GET")
+	apiRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/historial", perfilHandler.ObtenerHistorialMiConexion).Methods("GET")
+	apiRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/turnos", turnoHandler.ObtenerTurnos).Methods("GET")
+	apiRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/turnos", turnoHandler.ReservarTurno).Methods("POST")
+	apiRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/turnos", turnoHandler.CancelarTurno).Methods("DELETE")
+
+	// --- Perfil - Facturas ---
+	apiRouter.HandleFunc("/perfil/facturas", facturaHandler.ListarMisFacturas).Methods("GET")
+	apiRouter.HandleFunc("/perfil/facturas/{id:[0-9]+}", facturaHandler.ObtenerMiFactura).Methods("GET")
+	apiRouter.HandleFunc("/perfil/facturas/{id:[0-9]+}/pdf", facturaHandler.DescargarMiFactura).Methods("GET")
+	// Emisión manual de la facturación de un período
+	apiRouter.Handle("/facturacion/generar", middleware.RequirePermission(modelos.PermisoFacturacionEmitir)(http.HandlerFunc(facturacionHandler.GenerarPeriodo))).Methods("POST")
+
+	// --- Cuenta corriente ---
+	apiRouter.HandleFunc("/perfil/cuenta", cuentaHandler.ObtenerMiCuenta).Methods("GET")
+	// Cobranzas
+	apiRouter.Handle("/cuentas/deudores", middleware.RequirePermission(modelos.PermisoCuentasVer)(http.HandlerFunc(cuentasHandler.ListarDeudores))).Methods("GET")
+	apiRouter.Handle("/cuentas/revisar", middleware.RequirePermission(modelos.PermisoCuentasGestionar)(http.HandlerFunc(cuentasHandler.RevisarCuentas))).Methods("POST")
+	apiRouter.Handle("/cuentas/{id:[0-9]+}", middleware.RequirePermission(modelos.PermisoCuentasVer)(http.HandlerFunc(cuentasHandler.ObtenerCuenta))).Methods("GET")
+	apiRouter.Handle("/cuentas/{id:[0-9]+}/pagos", middleware.RequirePermission(modelos.PermisoCuentasGestionar)(http.HandlerFunc(cuentasHandler.RegistrarPago))).Methods("POST")
+	apiRouter.Handle("/cuentas/{id:[0-9]+}/notas-credito", middleware.RequirePermission(modelos.PermisoCuentasGestionar)(http.HandlerFunc(cuentasHandler.RegistrarNotaCredito))).Methods("POST")
+
+	// --- Intentos y bloqueos de login ---
+	apiRouter.Handle("/login/intentos", middleware.RequirePermission(modelos.PermisoLoginVerIntentos)(http.HandlerFunc(intentosLoginHandler.ListarIntentos))).Methods("GET")
+	apiRouter.Handle("/login/bloqueos", middleware.RequirePermission(modelos.PermisoLoginVerIntentos)(http.HandlerFunc(intentosLoginHandler.ListarBloqueos))).Methods("GET")
+	apiRouter.Handle("/login/bloqueos/{id:[0-9]+}", middleware.RequirePermission(modelos.PermisoLoginLevantarBloqueos)(http.HandlerFunc(intentosLoginHandler.LevantarBloqueo))).Methods("DELETE")
+
+	// --- Autenticación en dos pasos ---
+	apiRouter.HandleFunc("/auth/2fa", dosFactoresHandler.Estado).Methods("GET")
+	apiRouter.HandleFunc("/auth/2fa", dosFactoresHandler.Desactivar).Methods("DELETE")
+	apiRouter.HandleFunc("/auth/2fa/totp", dosFactoresHandler.IniciarTOTP).Methods("POST")
+	apiRouter.HandleFunc("/auth/2fa/totp/activar", dosFactoresHandler.ActivarTOTP).Methods("POST")
+	apiRouter.HandleFunc("/auth/2fa/recuperacion", dosFactoresHandler.RegenerarCodigos).Methods("POST")
+	// Restablecer el segundo factor de un usuario que perdió el acceso
+	apiRouter.Handle("/usuarios/{id:[0-9]+}/2fa", middleware.RequirePermission(modelos.PermisoUsuariosRestablecer2FA)(http.HandlerFunc(dosFactoresHandler.Restablecer))).Methods("DELETE")
+
+	// --- Sesiones de login ---
+	apiRouter.HandleFunc("/perfil/sesiones", sesionesHandler.Listar).Methods("GET")
+	apiRouter.HandleFunc("/perfil/sesiones", sesionesHandler.RevocarOtras).Methods("DELETE")
+	apiRouter.HandleFunc("/perfil/sesiones/{id:[0-9]+}", sesionesHandler.Revocar).Methods("DELETE")
+	// Cierre forzado de las sesiones de un usuario
+	apiRouter.Handle("/usuarios/{id:[0-9]+}/sesiones", middleware.RequirePermission(modelos.PermisoUsuariosCerrarSesiones)(http.HandlerFunc(sesionesHandler.CerrarSesionesUsuario))).Methods("DELETE")
 
 	// --- Solicitudes de Conexión ---
 	apiRouter.HandleFunc("/cliente-particular/solicitar-conexion", conexionHandler.SolicitarConexionHandler).Methods("POST")
 
-	// --- Administración y Roles (Requieren Roles específicos) ---
+	// Clientes empresa: alta, empresas vinculadas y solicitud de conexión a nombre de la empresa
+	apiRouter.HandleFunc("/clientes/empresas", clientesHandler.CrearClienteEmpresaHandler).Methods("POST")
+	apiRouter.HandleFunc("/perfil/empresas", clientesHandler.ListarMisEmpresasHandler).Methods("GET")
+	apiRouter.HandleFunc("/cliente-empresa/solicitar-conexion", conexionHandler.SolicitarConexionEmpresaHandler).Methods("POST")
+
+	// --- Administración y Roles (Requieren Permisos específicos) ---
+	// Las rutas con RequirePermission se habilitan por rol desde /roles/{id}/permisos sin
+	// tocar el código; el modelo vuelve a verificar el mismo permiso en las que lo exigen.
 
 	// Revisación Técnica
 	apiRouter.Handle("/revisacion/solicitudes-pendientes",
-		middleware.RequireRole("admin", "verificador")(http.HandlerFunc(conexionHandler.ObtenerSolicitudesPendientesHandler)),
+		middleware.RequirePermission(modelos.PermisoConexionVerSolicitudes)(http.HandlerFunc(conexionHandler.ObtenerSolicitudesPendientesHandler)),
 	).Methods("GET")
 	apiRouter.Handle("/revisacion/solicitud/{id}",
-		middleware.RequireRole("admin", "verificador")(http.HandlerFunc(conexionHandler.ObtenerDetalleSolicitudHandler)),
+		middleware.RequirePermission(modelos.PermisoConexionVerSolicitudes)(http.HandlerFunc(conexionHandler.ObtenerDetalleSolicitudHandler)),
 	).Methods("GET")
 	apiRouter.Handle("/revisacion/confirmar-factibilidad",
-		middleware.RequireRole("admin", "verificador")(http.HandlerFunc(conexionHandler.ConfirmarFactibilidadHandler)),
+		middleware.RequirePermission(modelos.PermisoConexionConfirmarFactibilidad)(http.HandlerFunc(conexionHandler.ConfirmarFactibilidadHandler)),
 	).Methods("POST")
 	apiRouter.Handle("/revisacion/rechazar-factibilidad",
-		middleware.RequireRole("admin", "verificador")(http.HandlerFunc(conexionHandler.RechazarFactibilidadHandler)),
+		middleware.RequirePermission(modelos.PermisoConexionRechazarFactibilidad)(http.HandlerFunc(conexionHandler.RechazarFactibilidadHandler)),
+	).Methods("POST")
+
+	// Ciclo de vida: cambios de estado manuales (la máquina de estados del modelo valida la transición)
+	apiRouter.Handle("/conexiones/{id:[0-9]+}/estado",
+		middleware.RequirePermission(modelos.PermisoConexionCambiarEstado)(http.HandlerFunc(conexionHandler.CambiarEstadoConexionHandler)),
+	).Methods("POST")
+	apiRouter.Handle("/contratos/{id:[0-9]+}/estado",
+		middleware.RequirePermission(modelos.PermisoContratoCambiarEstado)(http.HandlerFunc(conexionHandler.CambiarEstadoContratoHandler)),
 	).Methods("POST")
+	apiRouter.Handle("/conexiones/{id:[0-9]+}/historial",
+		middleware.RequirePermission(modelos.PermisoConexionVerHistorial)(http.HandlerFunc(conexionHandler.ObtenerHistorialConexionHandler)),
+	).Methods("GET")
+
+	// Órdenes de instalación (sin instalacion.gestionar, el modelo limita al técnico a sus órdenes y a las sin asignar)
+	apiRouter.Handle("/instalaciones",
+		middleware.RequirePermission(modelos.PermisoInstalacionOperar, modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.ListarOrdenes)),
+	).Methods("GET")
+	apiRouter.Handle("/instalaciones/{id:[0-9]+}",
+		middleware.RequirePermission(modelos.PermisoInstalacionOperar, modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.ObtenerOrden)),
+	).Methods("GET")
+	apiRouter.Handle("/instalaciones/{id:[0-9]+}/asignar",
+		middleware.RequirePermission(modelos.PermisoInstalacionOperar, modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.AsignarOrden)),
+	).Methods("POST")
+	apiRouter.Handle("/instalaciones/{id:[0-9]+}/programar",
+		middleware.RequirePermission(modelos.PermisoInstalacionOperar, modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.ProgramarOrden)),
+	).Methods("POST")
+	apiRouter.Handle("/instalaciones/{id:[0-9]+}/completar",
+		middleware.RequirePermission(modelos.PermisoInstalacionOperar, modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.CompletarOrden)),
+	).Methods("POST")
+	apiRouter.Handle("/instalaciones/{id:[0-9]+}/cancelar",
+		middleware.RequirePermission(modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.CancelarOrden)),
+	).Methods("POST")
+	apiRouter.Handle("/instalaciones/tecnicos/{id:[0-9]+}/agenda",
+		middleware.RequirePermission(modelos.PermisoInstalacionOperar, modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.ObtenerAgenda)),
+	).Methods("GET")
+	apiRouter.Handle("/instalaciones/tecnicos/{id:[0-9]+}/agenda",
+		middleware.RequirePermission(modelos.PermisoInstalacionGestionar)(http.HandlerFunc(instalacionHandler.ActualizarAgenda)),
+	).Methods("PUT")
 
 	// Gestión de Usuarios (Admin)
 	apiRouter.Handle("/usuarios/{id}/perfil",
-		middleware.RequireRole("admin", "atencion")(http.HandlerFunc(personasHandler.ObtenerPerfilUsuarioHandler)),
+		middleware.RequirePermission(modelos.PermisoUsuariosVer)(http.HandlerFunc(personasHandler.ObtenerPerfilUsuarioHandler)),
 	).Methods("GET")
 	apiRouter.Handle("/usuarios",
-		middleware.RequireRole("admin", "atencion")(http.HandlerFunc(userHandler.ListarUsuarios)),
+		middleware.RequirePermission(modelos.PermisoUsuariosVer)(http.HandlerFunc(userHandler.ListarUsuarios)),
 	).Methods("GET")
 	apiRouter.Handle("/usuarios",
-		middleware.RequireRole("admin", "atencion")(http.HandlerFunc(personasHandler.CrearPersonaConUsuarioHandler)),
+		middleware.RequirePermission(modelos.PermisoUsuariosCrear)(http.HandlerFunc(personasHandler.CrearPersonaConUsuarioHandler)),
 	).Methods("POST")
 
 	// ABMs Administrativos (Crear, Actualizar, Eliminar)
 
 	// Planes
-	apiRouter.Handle("/planes", middleware.RequireRole("admin")(http.HandlerFunc(planHandler.CrearPlan))).Methods("POST")
-	apiRouter.Handle("/planes/{id}", middleware.RequireRole("admin")(http.HandlerFunc(planHandler.ActualizarPlan))).Methods("PATCH")
-	apiRouter.Handle("/planes/{id}", middleware.RequireRole("admin")(http.HandlerFunc(planHandler.EliminarPlan))).Methods("DELETE")
+	apiRouter.Handle("/planes", middleware.RequirePermission(modelos.PermisoPlanesEditar)(http.HandlerFunc(planHandler.CrearPlan))).Methods("POST")
+	apiRouter.Handle("/planes/{id}", middleware.RequirePermission(modelos.PermisoPlanesEditar)(http.HandlerFunc(planHandler.ActualizarPlan))).Methods("PATCH")
+	apiRouter.Handle("/planes/{id}", middleware.RequirePermission(modelos.PermisoPlanesEditar)(http.HandlerFunc(planHandler.EliminarPlan))).Methods("DELETE")
+	apiRouter.Handle("/planes/precios", middleware.RequirePermission(modelos.PermisoPreciosGestionar)(http.HandlerFunc(preciosHandler.AjustarPrecios))).Methods("POST")
+	apiRouter.Handle("/planes/{id:[0-9]+}/precios", middleware.RequirePermission(modelos.PermisoPreciosGestionar)(http.HandlerFunc(preciosHandler.HistorialPrecios))).Methods("GET")
+
+	// Promociones
+	apiRouter.Handle("/promociones", middleware.RequirePermission(modelos.PermisoPreciosGestionar)(http.HandlerFunc(preciosHandler.ListarPromociones))).Methods("GET")
+	apiRouter.Handle("/promociones", middleware.RequirePermission(modelos.PermisoPreciosGestionar)(http.HandlerFunc(preciosHandler.CrearPromocion))).Methods("POST")
+	apiRouter.Handle("/promociones/{id:[0-9]+}", middleware.RequirePermission(modelos.PermisoPreciosGestionar)(http.HandlerFunc(preciosHandler.BorrarPromocion))).Methods("DELETE")
 
 	// Tipo Empresa
-	apiRouter.Handle("/tipo-empresa", middleware.RequireRole("admin")(http.HandlerFunc(tipoEmpresaHandler.CrearTipoEmpresa))).Methods("POST")
-	apiRouter.Handle("/tipo-empresa/{id}", middleware.RequireRole("admin")(http.HandlerFunc(tipoEmpresaHandler.ActualizarTipoEmpresa))).Methods("PATCH")
-	apiRouter.Handle("/tipo-empresa/{id}", middleware.RequireRole("admin")(http.HandlerFunc(tipoEmpresaHandler.EliminarTipoEmpresa))).Methods("DELETE")
+	apiRouter.Handle("/tipo-empresa", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(tipoEmpresaHandler.CrearTipoEmpresa))).Methods("POST")
+	apiRouter.Handle("/tipo-empresa/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(tipoEmpresaHandler.ActualizarTipoEmpresa))).Methods("PATCH")
+	apiRouter.Handle("/tipo-empresa/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(tipoEmpresaHandler.EliminarTipoEmpresa))).Methods("DELETE")
 
 	// Tipo IVA
-	apiRouter.Handle("/tipo-iva", middleware.RequireRole("admin")(http.HandlerFunc(tipoIvaHandler.CrearTipoIva))).Methods("POST")
-	apiRouter.Handle("/tipo-iva/{id}", middleware.RequireRole("admin")(http.HandlerFunc(tipoIvaHandler.ActualizarTipoIva))).Methods("PATCH")
-	apiRouter.Handle("/tipo-iva/{id}", middleware.RequireRole("admin")(http.HandlerFunc(tipoIvaHandler.EliminarTipoIva))).Methods("DELETE")
+	apiRouter.Handle("/tipo-iva", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(tipoIvaHandler.CrearTipoIva))).Methods("POST")
+	apiRouter.Handle("/tipo-iva/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(tipoIvaHandler.ActualizarTipoIva))).Methods("PATCH")
+	apiRouter.Handle("/tipo-iva/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(tipoIvaHandler.EliminarTipoIva))).Methods("DELETE")
 
 	// Estados Contrato
-	apiRouter.Handle("/estados-contrato", middleware.RequireRole("admin")(http.HandlerFunc(estadoContratoHandler.CrearEstadoContrato))).Methods("POST")
-	apiRouter.Handle("/estados-contrato/{id}", middleware.RequireRole("admin")(http.HandlerFunc(estadoContratoHandler.ActualizarEstadoContrato))).Methods("PATCH")
-	apiRouter.Handle("/estados-contrato/{id}", middleware.RequireRole("admin")(http.HandlerFunc(estadoContratoHandler.EliminarEstadoContrato))).Methods("DELETE")
+	apiRouter.Handle("/estados-contrato", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(estadoContratoHandler.CrearEstadoContrato))).Methods("POST")
+	apiRouter.Handle("/estados-contrato/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(estadoContratoHandler.ActualizarEstadoContrato))).Methods("PATCH")
+	apiRouter.Handle("/estados-contrato/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(estadoContratoHandler.EliminarEstadoContrato))).Methods("DELETE")
 
 	// Estados Conexión
-	apiRouter.Handle("/estados-conexion", middleware.RequireRole("admin")(http.HandlerFunc(estadoConexionHandler.CrearEstadoConexion))).Methods("POST")
-	apiRouter.Handle("/estados-conexion/{id}", middleware.RequireRole("admin")(http.HandlerFunc(estadoConexionHandler.ActualizarEstadoConexion))).Methods("PATCH")
-	apiRouter.Handle("/estados-conexion/{id}", middleware.RequireRole("admin")(http.HandlerFunc(estadoConexionHandler.EliminarEstadoConexion))).Methods("DELETE")
+	apiRouter.Handle("/estados-conexion", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(estadoConexionHandler.CrearEstadoConexion))).Methods("POST")
+	apiRouter.Handle("/estados-conexion/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(estadoConexionHandler.ActualizarEstadoConexion))).Methods("PATCH")
+	apiRouter.Handle("/estados-conexion/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(estadoConexionHandler.EliminarEstadoConexion))).Methods("DELETE")
 
 	// Cargos
-	apiRouter.Handle("/cargos", middleware.RequireRole("admin")(http.HandlerFunc(cargoHandler.CrearCargo))).Methods("POST")
-	apiRouter.Handle("/cargos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(cargoHandler.ActualizarCargo))).Methods("PATCH")
-	apiRouter.Handle("/cargos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(cargoHandler.EliminarCargo))).Methods("DELETE")
+	apiRouter.Handle("/cargos", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(cargoHandler.CrearCargo))).Methods("POST")
+	apiRouter.Handle("/cargos/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(cargoHandler.ActualizarCargo))).Methods("PATCH")
+	apiRouter.Handle("/cargos/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(cargoHandler.EliminarCargo))).Methods("DELETE")
 
 	// Vínculos
-	apiRouter.Handle("/vinculos", middleware.RequireRole("admin")(http.HandlerFunc(vinculoHandler.CrearVinculo))).Methods("POST")
-	apiRouter.Handle("/vinculos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(vinculoHandler.ActualizarVinculo))).Methods("PATCH")
-	apiRouter.Handle("/vinculos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(vinculoHandler.EliminarVinculo))).Methods("DELETE")
+	apiRouter.Handle("/vinculos", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(vinculoHandler.CrearVinculo))).Methods("POST")
+	apiRouter.Handle("/vinculos/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(vinculoHandler.ActualizarVinculo))).Methods("PATCH")
+	apiRouter.Handle("/vinculos/{id}", middleware.RequirePermission(modelos.PermisoCatalogosEditar)(http.HandlerFunc(vinculoHandler.EliminarVinculo))).Methods("DELETE")
 
 	// Direcciones
 	direccionRouter := apiRouter.PathPrefix("/direcciones").Subrouter()
-	direccionRouter.Use(middleware.RequireRole("admin"))
+	direccionRouter.Use(middleware.RequirePermission(modelos.PermisoDireccionesGestionar))
 	direccionRouter.HandleFunc("", direccionHandler.ListarDirecciones).Methods("GET")
 	direccionRouter.HandleFunc("/{id}", direccionHandler.ObtenerDireccionPorID).Methods("GET")
 	direccionRouter.HandleFunc("", direccionHandler.CrearDireccion).Methods("POST")
 	direccionRouter.HandleFunc("/{id}", direccionHandler.ActualizarDireccion).Methods("PATCH")
 	direccionRouter.HandleFunc("/{id}", direccionHandler.EliminarDireccion).Methods("DELETE")
 	// Roles y Permisos
-	apiRouter.Handle("/roles", middleware.RequireRole("admin")(http.HandlerFunc(rolHandler.ListarRoles))).Methods("GET")
-	apiRouter.Handle("/roles/{id}", middleware.RequireRole("admin")(http.HandlerFunc(rolHandler.ObtenerRolPorID))).Methods("GET")
-	apiRouter.Handle("/roles", middleware.RequireRole("admin")(http.HandlerFunc(rolHandler.CrearRol))).Methods("POST")
-	apiRouter.Handle("/roles/{id}", middleware.RequireRole("admin")(http.HandlerFunc(rolHandler.ActualizarRol))).Methods("PATCH")
-	apiRouter.Handle("/roles/{id}", middleware.RequireRole("admin")(http.HandlerFunc(rolHandler.EliminarRol))).Methods("DELETE")
-	apiRouter.Handle("/permisos", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.ListarPermisos))).Methods("GET")
-	apiRouter.Handle("/permisos/inactivos", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.ListarPermisosInactivos))).Methods("GET")
-	apiRouter.Handle("/permisos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.ObtenerPermisoPorID))).Methods("GET")
-	apiRouter.Handle("/permisos", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.CrearPermiso))).Methods("POST")
-	apiRouter.Handle("/permisos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.ActualizarPermiso))).Methods("PATCH")
-	apiRouter.Handle("/permisos/{id}", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.EliminarPermiso))).Methods("DELETE")
-	apiRouter.Handle("/permisos/{id}/reactivar", middleware.RequireRole("admin")(http.HandlerFunc(permisoHandler.ReactivarPermiso))).Methods("PUT")
+	apiRouter.Handle("/roles", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(rolHandler.ListarRoles))).Methods("GET")
+	apiRouter.Handle("/roles/{id}", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(rolHandler.ObtenerRolPorID))).Methods("GET")
+	apiRouter.Handle("/roles", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(rolHandler.CrearRol))).Methods("POST")
+	apiRouter.Handle("/roles/{id}", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(rolHandler.ActualizarRol))).Methods("PATCH")
+	apiRouter.Handle("/roles/{id}", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(rolHandler.EliminarRol))).Methods("DELETE")
+	apiRouter.Handle("/permisos", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.ListarPermisos))).Methods("GET")
+	apiRouter.Handle("/permisos/inactivos", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.ListarPermisosInactivos))).Methods("GET")
+	apiRouter.Handle("/permisos/{id}", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.ObtenerPermisoPorID))).Methods("GET")
+	apiRouter.Handle("/permisos", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.CrearPermiso))).Methods("POST")
+	apiRouter.Handle("/permisos/{id}", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.ActualizarPermiso))).Methods("PATCH")
+	apiRouter.Handle("/permisos/{id}", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.EliminarPermiso))).Methods("DELETE")
+	apiRouter.Handle("/permisos/{id}/reactivar", middleware.RequirePermission(modelos.PermisoRolesEditar)(http.HandlerFunc(permisoHandler.ReactivarPermiso))).Methods("PUT")
+	// Permisos de cada rol (el modelo también verifica el permiso)
+	apiRouter.Handle("/roles/{id:[0-9]+}/permisos", middleware.RequirePermission(modelos.PermisoRolesPermisos)(http.HandlerFunc(rolPermisoHandler.ListarPermisos))).Methods("GET")
+	apiRouter.Handle("/roles/{id:[0-9]+}/permisos", middleware.RequirePermission(modelos.PermisoRolesPermisos)(http.HandlerFunc(rolPermisoHandler.ReemplazarPermisos))).Methods("PUT")
+	apiRouter.Handle("/roles/{id:[0-9]+}/permisos/{id_permiso:[0-9]+}", middleware.RequirePermission(modelos.PermisoRolesPermisos)(http.HandlerFunc(rolPermisoHandler.AsignarPermiso))).Methods("POST")
+	apiRouter.Handle("/roles/{id:[0-9]+}/permisos/{id_permiso:[0-9]+}", middleware.RequirePermission(modelos.PermisoRolesPermisos)(http.HandlerFunc(rolPermisoHandler.QuitarPermiso))).Methods("DELETE")
+	// Permisos efectivos del usuario autenticado
+	apiRouter.HandleFunc("/perfil/permisos", rolPermisoHandler.PermisosEfectivos).Methods("GET")
 
 	// Firma Digital de Contratos
 	contratoFirmaHandler := handlers.NewContratoFirmaHandlerC(AuthService.GetModeloClient(), correoService)
-	// Solo clientes pueden simular pago y firmar contratos
+	// Simulación de pago: solo disponible cuando el modelo usa el proveedor fake
 	apiRouter.HandleFunc("/simular-pago/{id_persona}/{id_contrato}", contratoFirmaHandler.SimularPago).Methods("POST")
 	apiRouter.HandleFunc("/contrato-firma/{id}/firma", contratoFirmaHandler.GuardarFirma).Methods("POST")
 	apiRouter.HandleFunc("/contrato-firma/{id}/validar-token", contratoFirmaHandler.ValidarToken).Methods("POST")
@@ -254,6 +416,16 @@ func SetupRutas(clientesHandler *clientes.ClientesHandler,
 	apiRouter.HandleFunc("/contrato-firma/{id}", contratoFirmaHandler.ObtenerContrato).Methods("GET")
 	apiRouter.HandleFunc("/contrato-firma/{id}/pdf", contratoFirmaHandler.ServirPDF).Methods("GET")
 	apiRouter.HandleFunc("/contrato-firma/{id}/descargar", contratoFirmaHandler.DescargarPDF).Methods("GET")
+	apiRouter.HandleFunc("/contrato-firma/{id}/verificar-firma", contratoFirmaHandler.VerificarFirma).Methods("GET")
+	// Verificación pública por el código impreso (con QR) en el contrato
+	publicRouter.HandleFunc("/verificar-contrato/{codigo}", contratoFirmaHandler.VerificarPorCodigo).Methods("GET")
+
+	// Pagos
+	pagoHandler := pagos.NewPagoHandlerC(AuthService.GetModeloClient())
+	apiRouter.HandleFunc("/pagos/contrato/{id_contrato}", pagoHandler.IniciarPago).Methods("POST")
+	apiRouter.HandleFunc("/pagos/{id}", pagoHandler.ObtenerPago).Methods("GET")
+	// Webhook del proveedor de pagos (público, la firma se valida en el modelo)
+	publicRouter.HandleFunc("/pagos/webhook", pagoHandler.Webhook).Methods("POST")
 
 	return r
 }
\ No newline at end of file
@@ -2,7 +2,9 @@ package servicios
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -39,12 +41,67 @@ func (s *AuthService) Login(ctx context.Context, req *modelos.LoginRequest, clie
 	if err != nil {
 		return nil, mapearErrorModelo(err)
 	}
-	return s.crearLoginResponse(modeloResp)
+	// Con autenticación en dos pasos la contraseña sólo da un token de desafío
+	if modeloResp.Requiere2FA {
+		return s.crearDesafio(modeloResp)
+	}
+	resp, err := s.crearLoginResponse(modeloResp)
+	if err != nil {
+		return nil, err
+	}
+	resp.DispositivoNuevo = modeloResp.DispositivoNuevo
+	resp.Configurar2FA = utilidades.Requiere2FA(modeloResp.Roles, s.cfg)
+	return resp, nil
+}
+
+// VerificarSegundoFactor completa el login con el token de desafío y el código del segundo factor.
+func (s *AuthService) VerificarSegundoFactor(ctx context.Context, req *modelos.SegundoFactorRequest, clientIP, userAgent string) (*modelos.LoginResponse, error) {
+	desafio, err := s.validarDesafio(req.TokenDesafio, req.Metodo)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Codigo) == "" {
+		return nil, fmt.Errorf("%w: el código es obligatorio", utilidades.ErrValidacionLogin)
+	}
+
+	payload := modelos.ModeloVerificarDosFactoresRequest{
+		IDUsuario: desafio.IDUsuario,
+		Metodo:    req.Metodo,
+		Codigo:    req.Codigo,
+		ClientIP:  clientIP,
+		UserAgent: userAgent,
+	}
+	var modeloResp modelos.ModeloLoginResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/auth/2fa/verificar", payload, &modeloResp, true); err != nil {
+		return nil, mapearErrorModelo(err)
+	}
+
+	resp, err := s.crearLoginResponse(&modeloResp)
+	if err != nil {
+		return nil, err
+	}
+	resp.DispositivoNuevo = modeloResp.DispositivoNuevo
+	return resp, nil
+}
+
+// SolicitarCodigoEmail genera, durante el login, el código de respaldo que se envía por email.
+func (s *AuthService) SolicitarCodigoEmail(ctx context.Context, tokenDesafio string) (*modelos.ModeloCodigoEmailResponse, error) {
+	desafio, err := s.validarDesafio(tokenDesafio, "email")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp modelos.ModeloCodigoEmailResponse
+	payload := map[string]int{"id_usuario": desafio.IDUsuario}
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/auth/2fa/codigo-email", payload, &resp, true); err != nil {
+		return nil, mapearErrorModelo(err)
+	}
+	return &resp, nil
 }
 
 // Refresh genera un nuevo JWT usando un refresh token válido.
-func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*modelos.LoginResponse, error) {
-	modeloResp, err := s.modeloClient.Refresh(ctx, refreshToken)
+func (s *AuthService) Refresh(ctx context.Context, refreshToken, clientIP, userAgent string) (*modelos.LoginResponse, error) {
+	modeloResp, err := s.modeloClient.Refresh(ctx, refreshToken, clientIP, userAgent)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +146,7 @@ func (s *AuthService) CheckEmail(ctx context.Context, email string) (bool, error
 // --- Utilidades privadas ---
 
 func (s *AuthService) crearLoginResponse(modeloResp *modelos.ModeloLoginResponse) (*modelos.LoginResponse, error) {
-	token, err := utilidades.GenerarJWT(modeloResp.IDUsuario, modeloResp.IDPersona, modeloResp.Roles, s.cfg)
+	token, err := utilidades.GenerarJWT(modeloResp.IDUsuario, modeloResp.IDPersona, modeloResp.Roles, modeloResp.Permisos, modeloResp.AMR, modeloResp.IDSesion, s.cfg)
 	if err != nil {
 		return nil, fmt.Errorf("error al generar el token: %w", err)
 	}
@@ -97,10 +154,50 @@ func (s *AuthService) crearLoginResponse(modeloResp *modelos.ModeloLoginResponse
 		Token:            token,
 		RefreshToken:     modeloResp.RefreshToken,
 		RefreshExpiresAt: modeloResp.RefreshExpiresAt,
+		Email:            modeloResp.Email,
 	}, nil
 }
 
+// crearDesafio firma el token con el que se completa el login en el segundo paso
+func (s *AuthService) crearDesafio(modeloResp *modelos.ModeloLoginResponse) (*modelos.LoginResponse, error) {
+	token, expira, err := utilidades.GenerarTokenDesafio2FA(modeloResp.IDUsuario, modeloResp.IDPersona, modeloResp.Metodos2FA, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.LoginResponse{
+		Desafio: &modelos.DesafioDosFactoresResponse{
+			Requiere2FA:  true,
+			TokenDesafio: token,
+			Metodos:      modeloResp.Metodos2FA,
+			ExpiraEn:     int(time.Until(expira).Seconds()),
+		},
+	}, nil
+}
+
+// validarDesafio verifica el token de desafío y que el método esté entre los ofrecidos
+func (s *AuthService) validarDesafio(tokenDesafio, metodo string) (*utilidades.ClaimsDesafio2FA, error) {
+	desafio, err := utilidades.ParsearTokenDesafio2FA(tokenDesafio, s.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utilidades.ErrDesafio2FAInvalido, err)
+	}
+	for _, m := range desafio.Metodos {
+		if m == metodo {
+			return desafio, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: método '%s' no disponible", utilidades.ErrValidacionLogin, metodo)
+}
+
 func mapearErrorModelo(err error) error {
+	var modeloErr *ModeloError
+	if errors.As(err, &modeloErr) && modeloErr.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", utilidades.ErrLoginBloqueado, err)
+	}
+	if errors.As(err, &modeloErr) && modeloErr.StatusCode == http.StatusUnauthorized &&
+		strings.Contains(modeloErr.Message, "código de verificación inválido") {
+		return fmt.Errorf("%w: %v", utilidades.ErrCodigo2FAInvalido, err)
+	}
+
 	errMsg := err.Error()
 	switch {
 	case strings.Contains(errMsg, "credenciales inválidas"):
@@ -3,16 +3,23 @@ package servicios
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"contrato_one_internet_controlador/internal/config"
 	"contrato_one_internet_controlador/internal/modelos"
+
+	"contrato_one_internet_comun/autenticacion"
 )
 
 // ======================================
@@ -20,10 +27,13 @@ import (
 // ======================================
 
 type ModeloClient struct {
-	baseURL    string
-	httpClient *http.Client
-	mu         sync.Mutex
-	token      string
+	baseURL      string
+	httpClient   *http.Client
+	clientID     string
+	clientSecret string
+	mu           sync.Mutex
+	token        string
+	tokenExp     time.Time
 }
 
 // ======================================
@@ -43,10 +53,23 @@ func (e *ModeloError) Error() string {
 // 🔐 Inicialización y autenticación
 // ===============================
 
-func NewModeloClient(baseURL string) (*ModeloClient, error) {
+const (
+	rutaTokenInterno = "/api/v1/internal/auth/token"
+	// Se renueva el token antes de que venza para no depender del 401
+	margenRenovacionToken = time.Minute
+)
+
+func NewModeloClient(cfg config.Config) (*ModeloClient, error) {
+	transport, err := nuevoTransporteModelo(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configuración TLS hacia el modelo inválida: %w", err)
+	}
+
 	client := &ModeloClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseURL:      cfg.ModelURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second, Transport: transport},
+		clientID:     cfg.ModelClientID,
+		clientSecret: cfg.ModelClientSecret,
 	}
 	if err := client.authenticate(); err != nil {
 		return nil, fmt.Errorf("no se pudo autenticar con el servicio de modelo: %w", err)
@@ -55,17 +78,61 @@ func NewModeloClient(baseURL string) (*ModeloClient, error) {
 	return client, nil
 }
 
-// authenticate obtiene el token interno del servicio Modelo.
+// nuevoTransporteModelo arma el transporte HTTP con la CA del modelo y, si se configuró,
+// el certificado de cliente para mTLS. Sin archivos TLS devuelve nil (transporte por defecto).
+func nuevoTransporteModelo(cfg config.Config) (http.RoundTripper, error) {
+	if cfg.ModelCAFile == "" && cfg.ModelClientCertFile == "" {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.ModelCAFile != "" {
+		pem, err := os.ReadFile(cfg.ModelCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer MODEL_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("MODEL_CA_FILE no contiene certificados PEM válidos")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.ModelClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ModelClientCertFile, cfg.ModelClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo cargar el certificado de cliente: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	return transport, nil
+}
+
+// authenticate obtiene un token interno nuevo del servicio Modelo con una solicitud firmada.
 func (c *ModeloClient) authenticate() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	url := c.baseURL + "/api/v1/internal/auth/generate-token"
-	req, err := http.NewRequest("GET", url, nil)
+	fullURL := c.baseURL + rutaTokenInterno
+	u, err := url.Parse(fullURL)
 	if err != nil {
 		return err
 	}
 
+	req, err := http.NewRequest("POST", fullURL, nil)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(autenticacion.HeaderClienteServicio, c.clientID)
+	req.Header.Set(autenticacion.HeaderTimestampServicio, timestamp)
+	req.Header.Set(autenticacion.HeaderFirmaServicio, autenticacion.FirmarSolicitud(c.clientSecret, c.clientID, timestamp, req.Method, u.Path))
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
@@ -77,17 +144,44 @@ func (c *ModeloClient) authenticate() error {
 	}
 
 	var tokenResp struct {
-		Token string `json:"token"`
+		Token     string `json:"token"`
+		ExpiresIn int    `json:"expires_in"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
 		return err
 	}
+	if tokenResp.Token == "" {
+		return fmt.Errorf("el modelo no devolvió token")
+	}
 
 	c.token = tokenResp.Token
-	log.Println("Token interno actualizado en ModeloClient")
+	c.tokenExp = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	log.Printf("Token interno actualizado en ModeloClient (vence en %ds)", tokenResp.ExpiresIn)
 	return nil
 }
 
+// obtenerToken devuelve el token vigente, renovándolo si está por vencer.
+func (c *ModeloClient) obtenerToken() (string, error) {
+	c.mu.Lock()
+	token, exp := c.token, c.tokenExp
+	c.mu.Unlock()
+
+	if token != "" && time.Until(exp) > margenRenovacionToken {
+		return token, nil
+	}
+	if err := c.authenticate(); err != nil {
+		return "", err
+	}
+	return c.GetToken(), nil
+}
+
+// tokenInternoRechazado indica si el 401 del modelo se debe al token interno: sólo ese
+// lleva el desafío invalid_token de su middleware de autenticación interna.
+func tokenInternoRechazado(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized &&
+		resp.Header.Get("WWW-Authenticate") == autenticacion.DesafioTokenInvalido
+}
+
 // GetBaseURL retorna la URL base del servicio modelo
 func (c *ModeloClient) GetBaseURL() string {
 	return c.baseURL
@@ -144,9 +238,10 @@ func (c *ModeloClient) DoRequest(
 	req.Header.Set("Content-Type", "application/json")
 
     if useInternalToken {
-        c.mu.Lock()
-        token := c.token
-        c.mu.Unlock()
+        token, err := c.obtenerToken()
+        if err != nil {
+            return fmt.Errorf("error obteniendo token interno: %w", err)
+        }
         req.Header.Set("Authorization", "Bearer "+token)
     }
 
@@ -161,8 +256,9 @@ func (c *ModeloClient) DoRequest(
     }
     defer resp.Body.Close()
 
-    // Si expiró el token => reintentar
-    if useInternalToken && resp.StatusCode == http.StatusUnauthorized {
+    // Si el modelo rechazó el token interno (vencido o inválido) => renovar y reintentar.
+    // Otros 401 (credenciales del usuario, sesión) se devuelven tal cual.
+    if useInternalToken && tokenInternoRechazado(resp) {
         if err := c.authenticate(); err != nil {
             return fmt.Errorf("error renovando token: %w", err)
         }
@@ -240,8 +336,8 @@ func (c *ModeloClient) ValidarCredenciales(ctx context.Context, email, password,
 	return &result, nil
 }
 
-func (c *ModeloClient) Refresh(ctx context.Context, refreshToken string) (*modelos.ModeloLoginResponse, error) {
-	payload := map[string]string{"refresh_token": refreshToken}
+func (c *ModeloClient) Refresh(ctx context.Context, refreshToken, clientIP, userAgent string) (*modelos.ModeloLoginResponse, error) {
+	payload := map[string]string{"refresh_token": refreshToken, "client_ip": clientIP, "user_agent": userAgent}
 	var result modelos.ModeloLoginResponse
 	if err := c.DoRequest(ctx, "POST", "/api/v1/auth/refresh", payload, &result, false); err != nil {
 		return nil, err
@@ -753,7 +849,7 @@ func (c *ModeloClient) DeletePermiso(ctx context.Context, id int) error {
 func (c *ModeloClient) GetNotificaciones(
     ctx context.Context,
     idPersona int,
-    leido *int,
+    filtro modelos.FiltroNotificaciones,
     page, pageSize int,
     sortBy, sortDirection string,
 ) (*modelos.NotificacionesResponse, error) {
@@ -763,8 +859,20 @@ func (c *ModeloClient) GetNotificaciones(
         page, pageSize,
     )
 
-    if leido != nil {
-        path += fmt.Sprintf("&leido=%d", *leido)
+    if filtro.Leido != nil {
+        path += fmt.Sprintf("&leido=%d", *filtro.Leido)
+    }
+    if filtro.Tipo != "" {
+        path += "&tipo=" + url.QueryEscape(filtro.Tipo)
+    }
+    if filtro.IDContrato != nil {
+        path += fmt.Sprintf("&id_contrato=%d", *filtro.IDContrato)
+    }
+    if filtro.IDConexion != nil {
+        path += fmt.Sprintf("&id_conexion=%d", *filtro.IDConexion)
+    }
+    if filtro.Archivadas {
+        path += "&archivadas=1"
     }
     if sortBy != "" {
         path += "&sort_by=" + url.QueryEscape(sortBy)
@@ -811,6 +919,167 @@ func (c *ModeloClient) MarcarNotificacionComoLeida(
     )
 }
 
+// ContarNotificacionesNoLeidas devuelve el contador de no leídas de la bandeja
+func (c *ModeloClient) ContarNotificacionesNoLeidas(ctx context.Context, idPersona int) (*modelos.NotificacionesNoLeidasResponse, error) {
+	var resp modelos.NotificacionesNoLeidasResponse
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	if err := c.DoRequest(ctx, "GET", "/api/v1/internal/notificaciones/no-leidas", nil, &resp, true, headers); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// MarcarNotificacionesComoLeidas marca las notificaciones indicadas; con ids vacío marca toda la bandeja
+func (c *ModeloClient) MarcarNotificacionesComoLeidas(ctx context.Context, idPersona int, ids []int) (*modelos.NotificacionesActualizadasResponse, error) {
+	path := "/api/v1/internal/notificaciones/marcar-todas-como-leidas"
+	var payload interface{}
+	if len(ids) > 0 {
+		path = "/api/v1/internal/notificaciones/marcar-como-leidas"
+		payload = map[string][]int{"ids": ids}
+	}
+
+	var resp modelos.NotificacionesActualizadasResponse
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	if err := c.DoRequest(ctx, "POST", path, payload, &resp, true, headers); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ArchivarNotificacion archiva (archivar=true) o devuelve a la bandeja una notificación de la persona
+func (c *ModeloClient) ArchivarNotificacion(ctx context.Context, idPersona, idNotificacion int, archivar bool) error {
+	accion := "desarchivar"
+	if archivar {
+		accion = "archivar"
+	}
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/%d/%s", idNotificacion, accion)
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	return c.DoRequest(ctx, "POST", path, nil, nil, true, headers)
+}
+
+// EliminarNotificacion elimina la notificación de la bandeja de la persona
+func (c *ModeloClient) EliminarNotificacion(ctx context.Context, idPersona, idNotificacion int) error {
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/%d", idNotificacion)
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	return c.DoRequest(ctx, "DELETE", path, nil, nil, true, headers)
+}
+
+// ObtenerPreferenciasNotificacion devuelve las preferencias de canal de la persona
+func (c *ModeloClient) ObtenerPreferenciasNotificacion(ctx context.Context, idPersona int) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	if err := c.DoRequest(ctx, "GET", "/api/v1/internal/notificaciones/preferencias", nil, &result, true, headers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ActualizarPreferenciasNotificacion guarda las preferencias de canal de la persona
+func (c *ModeloClient) ActualizarPreferenciasNotificacion(ctx context.Context, idPersona int, body interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	if err := c.DoRequest(ctx, "PUT", "/api/v1/internal/notificaciones/preferencias", body, &result, true, headers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RegistrarSuscripcionPush guarda la suscripción Web Push del navegador de la persona
+func (c *ModeloClient) RegistrarSuscripcionPush(ctx context.Context, idPersona int, sub modelos.SuscripcionPush) error {
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	return c.DoRequest(ctx, "POST", "/api/v1/internal/notificaciones/push/suscripciones", sub, nil, true, headers)
+}
+
+// EliminarSuscripcionPush da de baja la suscripción Web Push de un navegador
+func (c *ModeloClient) EliminarSuscripcionPush(ctx context.Context, idPersona int, endpoint string) error {
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	body := map[string]string{"endpoint": endpoint}
+	return c.DoRequest(ctx, "DELETE", "/api/v1/internal/notificaciones/push/suscripciones", body, nil, true, headers)
+}
+
+// ListarEntregasNotificacion devuelve el estado de envío por canal de una notificación
+func (c *ModeloClient) ListarEntregasNotificacion(ctx context.Context, idPersona, idNotificacion int) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/%d/entregas", idNotificacion)
+	if err := c.DoRequest(ctx, "GET", path, nil, &result, true, headers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListarPlantillasNotificacion devuelve las plantillas de notificación por tipo y canal
+func (c *ModeloClient) ListarPlantillasNotificacion(ctx context.Context) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	if err := c.DoRequest(ctx, "GET", "/api/v1/internal/notificaciones/plantillas", nil, &result, true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GuardarPlantillaNotificacion crea o reemplaza la plantilla de un tipo y canal
+func (c *ModeloClient) GuardarPlantillaNotificacion(ctx context.Context, body interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := c.DoRequest(ctx, "PUT", "/api/v1/internal/notificaciones/plantillas", body, &result, true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EliminarPlantillaNotificacion borra una plantilla de notificación
+func (c *ModeloClient) EliminarPlantillaNotificacion(ctx context.Context, idPlantilla int) error {
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/plantillas/%d", idPlantilla)
+	return c.DoRequest(ctx, "DELETE", path, nil, nil, true)
+}
+
+// ReservarEntregasNotificacion toma del modelo hasta limite entregas pendientes (despachador)
+func (c *ModeloClient) ReservarEntregasNotificacion(ctx context.Context, limite int) ([]modelos.EntregaPendiente, error) {
+	var result []modelos.EntregaPendiente
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/entregas/reservar?limite=%d", limite)
+	if err := c.DoRequest(ctx, "POST", path, nil, &result, true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RegistrarResultadoEntrega informa al modelo el resultado de un envío
+func (c *ModeloClient) RegistrarResultadoEntrega(ctx context.Context, idEntrega int, resultado modelos.ResultadoEntrega) error {
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/entregas/%d/resultado", idEntrega)
+	return c.DoRequest(ctx, "POST", path, resultado, nil, true)
+}
+
+// ObtenerFeedNotificaciones trae las notificaciones con id mayor a desdeID. Con idPersona
+// nil es el feed global (todas las personas); con limite 0 sólo informa el último id.
+func (c *ModeloClient) ObtenerFeedNotificaciones(ctx context.Context, desdeID, limite int, idPersona *int) (*modelos.NotificacionesFeedResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/notificaciones/feed?desde_id=%d&limite=%d", desdeID, limite)
+
+	var headers []map[string]string
+	if idPersona != nil {
+		headers = append(headers, map[string]string{"X-ID-Persona": fmt.Sprintf("%d", *idPersona)})
+	}
+
+	var resp modelos.NotificacionesFeedResponse
+	if err := c.DoRequest(ctx, "GET", path, nil, &resp, true, headers...); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListarEventosOutboxFallidos devuelve los eventos de dominio que agotaron los reintentos (dead-letter)
+func (c *ModeloClient) ListarEventosOutboxFallidos(ctx context.Context) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	if err := c.DoRequest(ctx, "GET", "/api/v1/internal/outbox/fallidos", nil, &result, true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReintentarEventoOutbox vuelve a encolar un evento en dead-letter
+func (c *ModeloClient) ReintentarEventoOutbox(ctx context.Context, idEvento int) error {
+	path := fmt.Sprintf("/api/v1/internal/outbox/%d/reintentar", idEvento)
+	return c.DoRequest(ctx, "POST", path, nil, nil, true)
+}
+
 // GetMisContratos obtiene los contratos del usuario autenticado
 func (c *ModeloClient) GetMisContratos(
     ctx context.Context,
@@ -875,11 +1144,34 @@ func (c *ModeloClient) GetMisConexiones(
     return &resp, nil
 }
 
+// GetHistorialMiConexion obtiene la línea de tiempo de estados de una conexión de la persona
+func (c *ModeloClient) GetHistorialMiConexion(ctx context.Context, idPersona, idConexion int) (*modelos.HistorialConexionResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/perfil/conexiones/%d/historial", idConexion)
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+
+	var resp modelos.HistorialConexionResponse
+	if err := c.DoRequest(ctx, "GET", path, nil, &resp, true, headers); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetHistorialConexion obtiene la línea de tiempo de estados de cualquier conexión (personal)
+func (c *ModeloClient) GetHistorialConexion(ctx context.Context, idConexion int) (*modelos.HistorialConexionResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/conexiones/%d/historial", idConexion)
+
+	var resp modelos.HistorialConexionResponse
+	if err := c.DoRequest(ctx, "GET", path, nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // ===============================
 // 📄 Métodos para Contrato Firma
 // ===============================
 
-// SimularPago simula el pago e inicia el proceso de firma
+// SimularPago aprueba un pago con el proveedor fake del modelo e inicia el proceso de firma
 func (c *ModeloClient) SimularPago(ctx context.Context, idPersona, idContrato string) (map[string]interface{}, error) {
 path := fmt.Sprintf("/api/v1/internal/simular-pago/%s/%s", idPersona, idContrato)
 
@@ -891,6 +1183,53 @@ return nil, err
 return result, nil
 }
 
+// ===============================
+// 💳 Métodos para Pagos
+// ===============================
+
+// IniciarPagoContrato crea (o reutiliza) el checkout del pago de instalación de un contrato
+func (c *ModeloClient) IniciarPagoContrato(ctx context.Context, idPersona int, idContrato string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/api/v1/internal/pagos/contrato/%s", idContrato)
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+
+	var result map[string]interface{}
+	if err := c.DoRequest(ctx, "POST", path, nil, &result, true, headers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ObtenerPago obtiene el estado de un pago de la persona
+func (c *ModeloClient) ObtenerPago(ctx context.Context, idPersona int, idPago string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/api/v1/internal/pagos/%s", idPago)
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+
+	var result map[string]interface{}
+	if err := c.DoRequest(ctx, "GET", path, nil, &result, true, headers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReenviarWebhookPago reenvía al modelo un webhook del proveedor de pagos sin modificarlo:
+// el cuerpo, los query params y las cabeceras de firma son necesarios para validarlo.
+func (c *ModeloClient) ReenviarWebhookPago(ctx context.Context, body []byte, query url.Values, firma, idRequest string) (map[string]interface{}, error) {
+	path := "/api/v1/internal/pagos/webhook"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	headers := map[string]string{
+		"X-Signature":  firma,
+		"X-Request-Id": idRequest,
+	}
+
+	var result map[string]interface{}
+	if err := c.DoRequest(ctx, "POST", path, json.RawMessage(body), &result, true, headers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GuardarFirma guarda la firma canvas
 func (c *ModeloClient) GuardarFirma(ctx context.Context, idContratoFirma string, body map[string]interface{}) (map[string]interface{}, error) {
 path := fmt.Sprintf("/api/v1/internal/contrato-firma/%s/firma", idContratoFirma)
@@ -927,6 +1266,30 @@ var result map[string]interface{}
 	return result, nil
 }
 
+// VerificarFirmaContrato solicita al modelo revalidar la firma digital embebida en el PDF firmado
+func (c *ModeloClient) VerificarFirmaContrato(ctx context.Context, idContratoFirma string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/api/v1/internal/contrato-firma/%s/verificar-firma", idContratoFirma)
+
+	var result map[string]interface{}
+	if err := c.DoRequest(ctx, "GET", path, nil, &result, true); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// VerificarContratoPorCodigo consulta el estado público de un contrato por su código de verificación
+func (c *ModeloClient) VerificarContratoPorCodigo(ctx context.Context, codigo string) (map[string]interface{}, error) {
+	path := "/api/v1/internal/verificar-contrato/" + url.PathEscape(codigo)
+
+	var result map[string]interface{}
+	if err := c.DoRequest(ctx, "GET", path, nil, &result, true); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // MarcarTokenEnviado notifica al modelo que el token fue enviado al destinatario
 func (c *ModeloClient) MarcarTokenEnviado(ctx context.Context, idContratoFirma string) error {
 	path := fmt.Sprintf("/api/v1/internal/contrato-firma/%s/token-enviado", idContratoFirma)
@@ -961,8 +1324,9 @@ func (c *ModeloClient) DescargarPDF(ctx context.Context, idContratoFirma string)
 	return c.doStreamRequest(ctx, "GET", path)
 }
 
-// doStreamRequest maneja requests que retornan contenido binario (PDFs, imágenes, etc)
-func (c *ModeloClient) doStreamRequest(ctx context.Context, method, path string) (*http.Response, error) {
+// doStreamRequest maneja requests que retornan contenido binario (PDFs, imágenes, etc).
+// Los headers opcionales se agregan igual que en DoRequest.
+func (c *ModeloClient) doStreamRequest(ctx context.Context, method, path string, extraHeaders ...map[string]string) (*http.Response, error) {
 	fullURL := c.baseURL + path
 	
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, nil)
@@ -971,10 +1335,16 @@ func (c *ModeloClient) doStreamRequest(ctx context.Context, method, path string)
 	}
 
 	// Agregar token interno
-	c.mu.Lock()
-	token := c.token
-	c.mu.Unlock()
+	token, err := c.obtenerToken()
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo token interno: %w", err)
+	}
 	req.Header.Set("Authorization", "Bearer "+token)
+	for _, headers := range extraHeaders {
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -998,6 +1368,11 @@ func (c *ModeloClient) doStreamRequest(ctx context.Context, method, path string)
 			return nil, fmt.Errorf("error recreando request: %w", err)
 		}
 		req.Header.Set("Authorization", "Bearer "+newToken)
+		for _, headers := range extraHeaders {
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+		}
 
 		resp, err = c.httpClient.Do(req)
 		if err != nil {
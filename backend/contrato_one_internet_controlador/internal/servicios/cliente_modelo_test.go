@@ -0,0 +1,70 @@
+package servicios
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"contrato_one_internet_comun/autenticacion"
+)
+
+// modeloFake emite tokens internos (token-1, token-2, ...) y responde /recurso sólo al
+// segundo token; al primero le devuelve 401 con el desafío indicado (o sin desafío)
+func modeloFake(t *testing.T, desafio string) (*ModeloClient, *int32) {
+	t.Helper()
+	var emitidos int32
+	mux := http.NewServeMux()
+	mux.HandleFunc(rutaTokenInterno, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&emitidos, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": fmt.Sprintf("token-%d", n), "expires_in": 3600})
+	})
+	mux.HandleFunc("/recurso", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-2" {
+			json.NewEncoder(w).Encode(map[string]string{"ok": "si"})
+			return
+		}
+		if desafio != "" {
+			w.Header().Set("WWW-Authenticate", desafio)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no autorizado"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c := &ModeloClient{baseURL: srv.URL, httpClient: srv.Client(), clientID: "controlador", clientSecret: "secreto"}
+	if err := c.authenticate(); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	return c, &emitidos
+}
+
+func TestDoRequestRenuevaTokenInternoRechazado(t *testing.T) {
+	c, emitidos := modeloFake(t, autenticacion.DesafioTokenInvalido)
+
+	var resp map[string]string
+	if err := c.DoRequest(context.Background(), "GET", "/recurso", nil, &resp, true); err != nil {
+		t.Fatalf("DoRequest: %v", err)
+	}
+	if resp["ok"] != "si" || atomic.LoadInt32(emitidos) != 2 {
+		t.Errorf("resp = %v, tokens emitidos = %d; se esperaba reintento con un token nuevo", resp, *emitidos)
+	}
+}
+
+func TestDoRequestNoRenuevaTokenPorOtro401(t *testing.T) {
+	c, emitidos := modeloFake(t, "")
+
+	err := c.DoRequest(context.Background(), "GET", "/recurso", nil, nil, true)
+	var modeloErr *ModeloError
+	if !errors.As(err, &modeloErr) || modeloErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, se esperaba el 401 del modelo", err)
+	}
+	if n := atomic.LoadInt32(emitidos); n != 1 {
+		t.Errorf("tokens emitidos = %d, no se debía pedir otro", n)
+	}
+}
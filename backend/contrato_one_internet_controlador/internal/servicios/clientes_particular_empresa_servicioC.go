@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"contrato_one_internet_controlador/internal/modelos"
+	clientesModelos "contrato_one_internet_controlador/internal/modelos/clientes"
 )
 
 // ClientesService contiene la lógica de negocio para clientes.
@@ -33,20 +34,33 @@ func (s *ClientesService) CrearClienteParticular(ctx context.Context, persona mo
 	return result, nil
 }
 
-// CrearClienteEmpresa envía la solicitud al servicio Modelo para registrar un cliente empresa.
-func (s *ClientesService) CrearClienteEmpresa(ctx context.Context, apoderado modelos.Persona, dirApoderado modelos.Direccion, empresa modelos.Empresa, dirEmpresa modelos.Direccion) (map[string]interface{}, error) {
-	reqPayload := map[string]interface{}{
-		"apoderado":           apoderado,
-		"direccion_apoderado": dirApoderado,
-		"empresa":             empresa,
-		"direccion_empresa":   dirEmpresa,
+// CrearClienteEmpresa envía el alta de una empresa cliente al servicio Modelo.
+// El Modelo valida CUIT, crea la dirección y vincula a la persona de contacto.
+func (s *ClientesService) CrearClienteEmpresa(ctx context.Context, req clientesModelos.CrearClienteEmpresaRequest, idUsuario int) (*clientesModelos.CrearClienteEmpresaResponse, error) {
+	reqPayload := struct {
+		clientesModelos.CrearClienteEmpresaRequest
+		IDUsuario int `json:"id_usuario"`
+	}{
+		CrearClienteEmpresaRequest: req,
+		IDUsuario:                  idUsuario,
 	}
 
-	var result map[string]interface{}
+	var result clientesModelos.CrearClienteEmpresaResponse
+	err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/clientes/empresas", reqPayload, &result, true)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListarEmpresasDePersona obtiene las empresas a las que está vinculada la persona autenticada.
+func (s *ClientesService) ListarEmpresasDePersona(ctx context.Context, idPersona int) (*clientesModelos.EmpresasVinculadasResponse, error) {
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
 
-	err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/clientes/empresas", reqPayload, &result, true)
+	var result clientesModelos.EmpresasVinculadasResponse
+	err := s.modeloClient.DoRequest(ctx, "GET", "/api/v1/internal/perfil/empresas", nil, &result, true, headers)
 	if err != nil {
-		return nil, fmt.Errorf("error creando cliente empresa en Modelo: %w", err)
+		return nil, err
 	}
-	return result, nil	
+	return &result, nil
 }
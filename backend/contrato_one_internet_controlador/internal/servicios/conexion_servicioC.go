@@ -28,6 +28,7 @@ type SolicitudConexionInternaRequest struct {
 	Direccion   		*modelos.Direccion	`json:"direccion,omitempty"`
 	Latitud     		float64	`json:"latitud"`
 	Longitud     		float64	`json:"longitud"`
+	IDPromocion 		*int   	`json:"id_promocion,omitempty"`
 
 	// Datos Factibilidad Inmediata
     FactibilidadInmediata bool    `json:"factibilidad_inmediata"`
@@ -37,6 +38,12 @@ type SolicitudConexionInternaRequest struct {
     Observaciones         string  `json:"observaciones,omitempty"`
 }
 
+// SolicitudConexionEmpresaInternaRequest agrega la empresa cliente a la solicitud interna
+type SolicitudConexionEmpresaInternaRequest struct {
+	SolicitudConexionInternaRequest
+	IDEmpresa int `json:"id_empresa"`
+}
+
 // SolicitarConexionParticular envía la solicitud de conexión al servicio modelo
 func (s *ConexionService) SolicitarConexionParticular(
 	ctx context.Context,
@@ -53,6 +60,7 @@ func (s *ConexionService) SolicitarConexionParticular(
 		Direccion:   req.Direccion,
 		Latitud:     req.Latitud,
 		Longitud:    req.Longitud,
+		IDPromocion: req.IDPromocion,
 
 		FactibilidadInmediata: req.FactibilidadInmediata,
 		NAP:                   req.NAP,
@@ -122,16 +130,18 @@ func (s *ConexionService) ObtenerDetalleSolicitud(
 	return &response, nil
 }
 
-// ConfirmarFactibilidad confirma la factibilidad de una conexión
+// ConfirmarFactibilidad confirma la factibilidad de una conexión en nombre del verificador idPersona
 func (s *ConexionService) ConfirmarFactibilidad(
 	ctx context.Context,
 	req *clientesModelos.ConfirmarFactibilidadRequest,
+	idPersona int,
 ) (*clientesModelos.ConfirmarFactibilidadResponse, error) {
 	var response clientesModelos.ConfirmarFactibilidadResponse
 	
 	url := "/api/v1/internal/revisacion/confirmar-factibilidad"
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
 	
-	err := s.modeloClient.DoRequest(ctx, "POST", url, req, &response, true)
+	err := s.modeloClient.DoRequest(ctx, "POST", url, req, &response, true, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -139,16 +149,91 @@ func (s *ConexionService) ConfirmarFactibilidad(
 	return &response, nil
 }
 
-// RechazarFactibilidad rechaza la factibilidad de una conexión
+// RechazarFactibilidad rechaza la factibilidad de una conexión en nombre del verificador idPersona
 func (s *ConexionService) RechazarFactibilidad(
 	ctx context.Context,
 	req *clientesModelos.RechazarFactibilidadRequest,
+	idPersona int,
 ) (*clientesModelos.RechazarFactibilidadResponse, error) {
 	var response clientesModelos.RechazarFactibilidadResponse
 	
 	url := "/api/v1/internal/revisacion/rechazar-factibilidad"
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+	
+	err := s.modeloClient.DoRequest(ctx, "POST", url, req, &response, true, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// CambiarEstado aplica un cambio de estado manual a una conexión o a un contrato
+// (entidad "conexiones" o "contratos") en nombre del operador idPersona
+func (s *ConexionService) CambiarEstado(
+	ctx context.Context,
+	entidad string,
+	id int,
+	req *clientesModelos.CambiarEstadoRequest,
+	idPersona int,
+) (*clientesModelos.CambiarEstadoResponse, error) {
+	var response clientesModelos.CambiarEstadoResponse
+	
+	url := fmt.Sprintf("/api/v1/internal/%s/%d/estado", entidad, id)
+	headers := map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
 	
-	err := s.modeloClient.DoRequest(ctx, "POST", url, req, &response, true)
+	err := s.modeloClient.DoRequest(ctx, "POST", url, req, &response, true, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ObtenerHistorialConexion obtiene la línea de tiempo de estados de una conexión y sus contratos
+func (s *ConexionService) ObtenerHistorialConexion(
+	ctx context.Context,
+	idConexion int,
+) (*modelos.HistorialConexionResponse, error) {
+	return s.modeloClient.GetHistorialConexion(ctx, idConexion)
+}
+
+// SolicitarConexionEmpresa envía la solicitud de conexión de una empresa cliente al servicio modelo
+func (s *ConexionService) SolicitarConexionEmpresa(
+	ctx context.Context,
+	req *clientesModelos.SolicitudConexionEmpresaRequest,
+	idUsuario int,
+) (*clientesModelos.SolicitudConexionResponse, error) {
+	internalReq := SolicitudConexionEmpresaInternaRequest{
+		SolicitudConexionInternaRequest: SolicitudConexionInternaRequest{
+			IDUsuario:        idUsuario,
+			IDPersonaCliente: req.IDPersonaCliente,
+
+			IDPlan:      req.IDPlan,
+			IDDireccion: req.IDDireccion,
+			Direccion:   req.Direccion,
+			Latitud:     req.Latitud,
+			Longitud:    req.Longitud,
+			IDPromocion: req.IDPromocion,
+
+			FactibilidadInmediata: req.FactibilidadInmediata,
+			NAP:                   req.NAP,
+			VLAN:                  req.VLAN,
+			Puerto:                req.Puerto,
+			Observaciones:         req.Observaciones,
+		},
+		IDEmpresa: req.IDEmpresa,
+	}
+
+	var response clientesModelos.SolicitudConexionResponse
+	err := s.modeloClient.DoRequest(
+		ctx,
+		"POST",
+		"/api/v1/internal/solicitar-conexion-empresa",
+		internalReq,
+		&response,
+		true,
+	)
 	if err != nil {
 		return nil, err
 	}
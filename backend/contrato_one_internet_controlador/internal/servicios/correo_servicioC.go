@@ -25,6 +25,7 @@ type ServicioCorreo struct {
 	ResetTemplatePath  string // Plantilla para recuperación de contraseña
 	CredentialsPath    string // Plantilla para envío de credenciales en registro asistido
 	TokenTemplatePath  string // Plantilla para token de firma digital
+	NotificacionTemplatePath string // Plantilla genérica para notificaciones (canal email)
 	LogoLightPath      string
 	LogoDarkPath       string
 }
@@ -39,9 +40,11 @@ type EmailData struct {
 	ExpirationHours  int
 	Year             int
 	Password        string // Nueva contraseña para registro asistido
+	Titulo           string // Título de la notificación
+	Mensaje          string // Cuerpo de la notificación (texto plano)
 }
 
-func NewServicioCorreo(host, port, usuario, password, fromEmail, fromName, templatePath, resetTemplatePath, credentialsPath, tokenTemplatePath, logoLightPath, logoDarkPath string) *ServicioCorreo {
+func NewServicioCorreo(host, port, usuario, password, fromEmail, fromName, templatePath, resetTemplatePath, credentialsPath, tokenTemplatePath, notificacionTemplatePath, logoLightPath, logoDarkPath string) *ServicioCorreo {
 	return &ServicioCorreo{
 		Host:              host,
 		Port:              port,
@@ -53,6 +56,7 @@ func NewServicioCorreo(host, port, usuario, password, fromEmail, fromName, templ
 		ResetTemplatePath: resetTemplatePath,
 		CredentialsPath:   credentialsPath,
 		TokenTemplatePath: tokenTemplatePath,
+		NotificacionTemplatePath: notificacionTemplatePath,
 		LogoLightPath:     logoLightPath,
 		LogoDarkPath:      logoDarkPath,
 	}
@@ -131,6 +135,45 @@ func (s *ServicioCorreo) EnviarTokenFirma(destinatario, token, nombreCompleto st
 	return s.enviarEmailHTMLConPlantilla(destinatario, "Token de Firma Digital - ONE Internet", data, s.TokenTemplatePath)
 }
 
+// EnviarNotificacion envía una notificación del sistema usando la plantilla mail-notificacion.html
+func (s *ServicioCorreo) EnviarNotificacion(destinatario, asunto, nombreCompleto, titulo, mensaje string) error {
+	userName := nombreCompleto
+	if userName == "" {
+		userName = extractNameFromEmail(destinatario)
+	}
+
+	data := EmailData{
+		UserName: userName,
+		Titulo:   titulo,
+		Mensaje:  mensaje,
+		Year:     time.Now().Year(),
+	}
+
+	return s.enviarEmailHTMLConPlantilla(destinatario, asunto+" - ONE Internet", data, s.NotificacionTemplatePath)
+}
+
+// EnviarAlertaInicioSesion avisa de un inicio de sesión desde una IP o dispositivo que el usuario no había usado
+func (s *ServicioCorreo) EnviarAlertaInicioSesion(destinatario, ip, userAgent string, fecha time.Time) error {
+	if userAgent == "" {
+		userAgent = "desconocido"
+	}
+	mensaje := fmt.Sprintf("Se inició sesión en tu cuenta el %s desde la IP %s (dispositivo: %s).\n\n"+
+		"Si fuiste vos, no tenés que hacer nada. Si no reconocés este acceso, cambiá tu contraseña "+
+		"de inmediato y comunicate con nosotros.",
+		fecha.Format("02/01/2006 15:04"), ip, userAgent)
+
+	return s.EnviarNotificacion(destinatario, "Nuevo inicio de sesión", "", "Nuevo inicio de sesión en tu cuenta", mensaje)
+}
+
+// EnviarCodigoDosFactores envía el código de respaldo de la autenticación en dos pasos
+func (s *ServicioCorreo) EnviarCodigoDosFactores(destinatario, codigo string, expira time.Time) error {
+	mensaje := fmt.Sprintf("Tu código de verificación es %s. Vence a las %s.\n\n"+
+		"Si no estás iniciando sesión, alguien conoce tu contraseña: cambiala de inmediato.",
+		codigo, expira.Local().Format("15:04"))
+
+	return s.EnviarNotificacion(destinatario, "Código de verificación", "", "Código de verificación", mensaje)
+}
+
 func (s *ServicioCorreo) enviarEmailHTML(destinatario, asunto string, data EmailData) error {
 	return s.enviarEmailHTMLConPlantilla(destinatario, asunto, data, s.TemplatePath)
 }
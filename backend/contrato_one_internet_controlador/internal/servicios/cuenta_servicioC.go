@@ -0,0 +1,93 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// CuentaService reenvía al modelo las consultas de la cuenta corriente y la gestión de
+// cobranzas. El modelo verifica el rol admin en las operaciones sobre cuentas ajenas.
+type CuentaService struct {
+	modeloClient *ModeloClient
+}
+
+func NewCuentaService(modeloClient *ModeloClient) *CuentaService {
+	return &CuentaService{modeloClient: modeloClient}
+}
+
+// ObtenerMiCuenta obtiene la cuenta de la persona con sus movimientos paginados
+func (s *CuentaService) ObtenerMiCuenta(ctx context.Context, query url.Values, idPersona int) (*modelos.CuentaResponse, error) {
+	var response modelos.CuentaResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", conPaginacion("/api/v1/internal/perfil/cuenta", query), nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ObtenerCuenta obtiene la cuenta de un cliente (sólo admin)
+func (s *CuentaService) ObtenerCuenta(ctx context.Context, idCliente int, query url.Values, idPersona int) (*modelos.CuentaResponse, error) {
+	path := conPaginacion(fmt.Sprintf("/api/v1/internal/cuentas/%d", idCliente), query)
+
+	var response modelos.CuentaResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListarDeudores obtiene los clientes con deuda y su antigüedad (sólo admin)
+func (s *CuentaService) ListarDeudores(ctx context.Context, idPersona int) (*modelos.DeudoresResponse, error) {
+	var response modelos.DeudoresResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", "/api/v1/internal/cuentas/deudores", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RegistrarPago acredita un pago recibido fuera de la pasarela (sólo admin)
+func (s *CuentaService) RegistrarPago(ctx context.Context, idCliente int, req modelos.RegistrarPagoCuentaRequest, idPersona int) (*modelos.MovimientoRegistradoResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/cuentas/%d/pagos", idCliente)
+
+	var response modelos.MovimientoRegistradoResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", path, req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RegistrarNotaCredito acredita una nota de crédito en la cuenta (sólo admin)
+func (s *CuentaService) RegistrarNotaCredito(ctx context.Context, idCliente int, req modelos.RegistrarNotaCreditoRequest, idPersona int) (*modelos.MovimientoRegistradoResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/cuentas/%d/notas-credito", idCliente)
+
+	var response modelos.MovimientoRegistradoResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", path, req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RevisarCuentas corre la revisión de deuda sin esperar al próximo ciclo (sólo admin)
+func (s *CuentaService) RevisarCuentas(ctx context.Context, idPersona int) (*modelos.ResumenRevisionCuentas, error) {
+	var response modelos.ResumenRevisionCuentas
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/cuentas/revisar", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// conPaginacion agrega al path los parámetros page y limit del pedido original
+func conPaginacion(path string, query url.Values) string {
+	q := url.Values{}
+	for _, p := range []string{"page", "limit"} {
+		if v := query.Get(p); v != "" {
+			q.Set(p, v)
+		}
+	}
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	return path
+}
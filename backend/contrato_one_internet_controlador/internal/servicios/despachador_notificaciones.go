@@ -0,0 +1,159 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_controlador/internal/config"
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// DespachadorNotificaciones reserva en el modelo las entregas pendientes, las envía por
+// el canal correspondiente e informa el resultado de cada una. Los reintentos con espera
+// creciente los decide el modelo al registrar el resultado.
+type DespachadorNotificaciones struct {
+	modelo    *ModeloClient
+	canales   map[string]CanalNotificacion
+	intervalo time.Duration
+	lote      int
+}
+
+func NewDespachadorNotificaciones(modelo *ModeloClient, intervalo time.Duration, lote int, canales ...CanalNotificacion) *DespachadorNotificaciones {
+	d := &DespachadorNotificaciones{
+		modelo:    modelo,
+		canales:   make(map[string]CanalNotificacion, len(canales)),
+		intervalo: intervalo,
+		lote:      lote,
+	}
+	for _, c := range canales {
+		d.canales[c.Nombre()] = c
+	}
+	return d
+}
+
+// LoteDespachoNotificaciones es la cantidad de entregas que se reservan por vuelta
+const LoteDespachoNotificaciones = 20
+
+// NewDespachadorDesdeConfig arma el despachador con los proveedores elegidos en la
+// configuración. Los canales sin proveedor quedan sin registrar y sus entregas fallan
+// hasta agotar los reintentos.
+func NewDespachadorDesdeConfig(cfg *config.Config, modelo *ModeloClient, correo *ServicioCorreo) (*DespachadorNotificaciones, error) {
+	var canales []CanalNotificacion
+
+	// 1. Email
+	if cfg.NotifEmailProveedor == "fake" {
+		canales = append(canales, NewCanalFake(CanalEmail))
+	} else {
+		canales = append(canales, NewCanalEmailSMTP(correo))
+	}
+
+	// 2. SMS
+	switch cfg.NotifSMSProveedor {
+	case "twilio":
+		canales = append(canales, NewCanalSMSTwilio(cfg.TwilioAPIURL, cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFrom))
+	case "fake":
+		canales = append(canales, NewCanalFake(CanalSMS))
+	}
+
+	// 3. WhatsApp
+	switch cfg.NotifWhatsAppProveedor {
+	case "cloud":
+		canales = append(canales, NewCanalWhatsAppCloud(cfg.WhatsAppAPIURL, cfg.WhatsAppPhoneNumberID, cfg.WhatsAppAccessToken))
+	case "fake":
+		canales = append(canales, NewCanalFake(CanalWhatsApp))
+	}
+
+	// 4. Web Push
+	switch cfg.NotifPushProveedor {
+	case "vapid":
+		push, err := NewCanalWebPush(cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+		if err != nil {
+			return nil, err
+		}
+		canales = append(canales, push)
+	case "fake":
+		canales = append(canales, NewCanalFake(CanalPush))
+	}
+
+	intervalo := time.Duration(cfg.NotifIntervaloSegundos) * time.Second
+	return NewDespachadorNotificaciones(modelo, intervalo, LoteDespachoNotificaciones, canales...), nil
+}
+
+// ClavePushPublica devuelve la applicationServerKey VAPID, o vacío si no hay canal Web Push real
+func (d *DespachadorNotificaciones) ClavePushPublica() string {
+	if push, ok := d.canales[CanalPush].(*CanalWebPush); ok {
+		return push.ClavePublica()
+	}
+	return ""
+}
+
+// Canal devuelve el canal configurado con ese nombre (nil si no hay)
+func (d *DespachadorNotificaciones) Canal(nombre string) CanalNotificacion {
+	return d.canales[nombre]
+}
+
+// Iniciar procesa lotes cada intervalo hasta que se cancele el contexto.
+// Se ejecuta en su propia goroutine.
+func (d *DespachadorNotificaciones) Iniciar(ctx context.Context) {
+	logger.Info.Printf("Despachador de notificaciones iniciado (intervalo %s, canales %d)", d.intervalo, len(d.canales))
+	ticker := time.NewTicker(d.intervalo)
+	defer ticker.Stop()
+
+	for {
+		// Vaciar lo pendiente antes de esperar el siguiente tick
+		for {
+			n, err := d.ProcesarLote(ctx)
+			if err != nil {
+				logger.Error.Printf("Error procesando notificaciones pendientes: %v", err)
+				break
+			}
+			if n < d.lote {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info.Println("Despachador de notificaciones detenido")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcesarLote envía un lote de entregas y devuelve cuántas se reservaron
+func (d *DespachadorNotificaciones) ProcesarLote(ctx context.Context) (int, error) {
+	entregas, err := d.modelo.ReservarEntregasNotificacion(ctx, d.lote)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range entregas {
+		resultado := d.enviar(ctx, e)
+		if err := d.modelo.RegistrarResultadoEntrega(ctx, e.IDEntrega, resultado); err != nil {
+			logger.Error.Printf("Error registrando resultado de la entrega %d: %v", e.IDEntrega, err)
+		}
+	}
+	return len(entregas), nil
+}
+
+func (d *DespachadorNotificaciones) enviar(ctx context.Context, e modelos.EntregaPendiente) modelos.ResultadoEntrega {
+	canal, ok := d.canales[e.Canal]
+	if !ok {
+		return modelos.ResultadoEntrega{Error: fmt.Sprintf("canal %s no configurado en el controlador", e.Canal)}
+	}
+
+	envio, err := canal.Enviar(ctx, e)
+	resultado := modelos.ResultadoEntrega{Enviada: err == nil}
+	if envio != nil {
+		resultado.IDProveedor = envio.IDProveedor
+		resultado.SuscripcionesVencidas = envio.SuscripcionesVencidas
+	}
+	if err != nil {
+		logger.Warn.Printf("Entrega %d por %s falló (intento %d): %v", e.IDEntrega, e.Canal, e.Intentos+1, err)
+		resultado.Error = err.Error()
+	}
+	return resultado
+}
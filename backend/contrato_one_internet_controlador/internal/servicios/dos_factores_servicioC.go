@@ -0,0 +1,69 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// DosFactoresService reenvía al modelo la configuración de la autenticación en dos pasos
+// del usuario autenticado. El modelo guarda el secreto cifrado y valida los códigos.
+type DosFactoresService struct {
+	modeloClient *ModeloClient
+}
+
+func NewDosFactoresService(modeloClient *ModeloClient) *DosFactoresService {
+	return &DosFactoresService{modeloClient: modeloClient}
+}
+
+// Estado informa si la cuenta tiene el segundo factor activo y cuántos códigos de recuperación le quedan
+func (s *DosFactoresService) Estado(ctx context.Context, idPersona int) (*modelos.EstadoDosFactores, error) {
+	var response modelos.EstadoDosFactores
+	if err := s.modeloClient.DoRequest(ctx, "GET", "/api/v1/internal/auth/2fa", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// IniciarTOTP genera un secreto nuevo, pendiente hasta que se confirme con un código
+func (s *DosFactoresService) IniciarTOTP(ctx context.Context, idPersona int) (*modelos.InicioTOTPResponse, error) {
+	var response modelos.InicioTOTPResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/auth/2fa/totp", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ActivarTOTP confirma el secreto con un código de la app y devuelve los códigos de recuperación.
+// El modelo revoca las sesiones abiertas.
+func (s *DosFactoresService) ActivarTOTP(ctx context.Context, idPersona int, codigo string) (*modelos.CodigosRecuperacionResponse, error) {
+	var response modelos.CodigosRecuperacionResponse
+	payload := modelos.CodigoDosFactoresRequest{Codigo: codigo}
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/auth/2fa/totp/activar", payload, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RegenerarCodigos reemplaza los códigos de recuperación, previa verificación de un código TOTP
+func (s *DosFactoresService) RegenerarCodigos(ctx context.Context, idPersona int, codigo string) (*modelos.CodigosRecuperacionResponse, error) {
+	var response modelos.CodigosRecuperacionResponse
+	payload := modelos.CodigoDosFactoresRequest{Codigo: codigo}
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/auth/2fa/recuperacion", payload, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Desactivar quita el segundo factor, previa verificación de un código TOTP o de recuperación
+func (s *DosFactoresService) Desactivar(ctx context.Context, idPersona int, codigo string) error {
+	payload := modelos.CodigoDosFactoresRequest{Codigo: codigo}
+	return s.modeloClient.DoRequest(ctx, "DELETE", "/api/v1/internal/auth/2fa", payload, nil, true, headersPersona(idPersona))
+}
+
+// Restablecer borra el segundo factor de otro usuario que perdió el acceso (admin)
+func (s *DosFactoresService) Restablecer(ctx context.Context, idUsuario int, idPersona int) error {
+	path := fmt.Sprintf("/api/v1/internal/usuarios/%d/2fa", idUsuario)
+	return s.modeloClient.DoRequest(ctx, "DELETE", path, nil, nil, true, headersPersona(idPersona))
+}
@@ -0,0 +1,66 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// FacturacionService reenvía al modelo las consultas de facturas del cliente y la emisión
+// manual de un período. El modelo verifica que cada factura sea de la persona del header.
+type FacturacionService struct {
+	modeloClient *ModeloClient
+}
+
+func NewFacturacionService(modeloClient *ModeloClient) *FacturacionService {
+	return &FacturacionService{modeloClient: modeloClient}
+}
+
+// ListarMisFacturas obtiene las facturas de la persona paginadas
+func (s *FacturacionService) ListarMisFacturas(ctx context.Context, query url.Values, idPersona int) (*modelos.FacturasResponse, error) {
+	q := url.Values{}
+	for _, p := range []string{"page", "limit"} {
+		if v := query.Get(p); v != "" {
+			q.Set(p, v)
+		}
+	}
+	path := "/api/v1/internal/perfil/facturas"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var response modelos.FacturasResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ObtenerMiFactura obtiene la factura con sus ítems
+func (s *FacturacionService) ObtenerMiFactura(ctx context.Context, idFactura, idPersona int) (*modelos.Factura, error) {
+	path := fmt.Sprintf("/api/v1/internal/perfil/facturas/%d", idFactura)
+
+	var response modelos.Factura
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DescargarMiFactura obtiene el PDF de la factura (el caller debe cerrar el Body)
+func (s *FacturacionService) DescargarMiFactura(ctx context.Context, idFactura, idPersona int) (*http.Response, error) {
+	path := fmt.Sprintf("/api/v1/internal/perfil/facturas/%d/pdf", idFactura)
+	return s.modeloClient.doStreamRequest(ctx, "GET", path, headersPersona(idPersona))
+}
+
+// GenerarPeriodo emite las facturas que falten del período (sólo admin)
+func (s *FacturacionService) GenerarPeriodo(ctx context.Context, req modelos.GenerarFacturacionRequest, idPersona int) (*modelos.ResumenFacturacion, error) {
+	var response modelos.ResumenFacturacion
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/facturacion/generar", req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,101 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// parametrosListadoInstalaciones son los query params que se reenvían al modelo
+var parametrosListadoInstalaciones = []string{"estado", "id_tecnico", "desde", "hasta", "page", "limit"}
+
+// InstalacionService reenvía al modelo las operaciones de los técnicos sobre las órdenes de
+// instalación. El modelo decide qué puede hacer cada uno según la persona del header.
+type InstalacionService struct {
+	modeloClient *ModeloClient
+}
+
+func NewInstalacionService(modeloClient *ModeloClient) *InstalacionService {
+	return &InstalacionService{modeloClient: modeloClient}
+}
+
+func headersPersona(idPersona int) map[string]string {
+	return map[string]string{"X-ID-Persona": fmt.Sprintf("%d", idPersona)}
+}
+
+// Listar obtiene las órdenes visibles para la persona
+func (s *InstalacionService) Listar(ctx context.Context, query url.Values, idPersona int) (*modelos.OrdenesInstalacionResponse, error) {
+	q := url.Values{}
+	for _, p := range parametrosListadoInstalaciones {
+		if v := query.Get(p); v != "" {
+			q.Set(p, v)
+		}
+	}
+	path := "/api/v1/internal/instalaciones"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var response modelos.OrdenesInstalacionResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Obtener obtiene el detalle de una orden con sus materiales y fotos
+func (s *InstalacionService) Obtener(ctx context.Context, idOrden, idPersona int) (*modelos.OrdenInstalacion, error) {
+	path := fmt.Sprintf("/api/v1/internal/instalaciones/%d", idOrden)
+
+	var response modelos.OrdenInstalacion
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Operar aplica una operación (asignar, programar, completar o cancelar) sobre la orden
+func (s *InstalacionService) Operar(
+	ctx context.Context,
+	idOrden int,
+	operacion string,
+	req interface{},
+	idPersona int,
+) (*modelos.OrdenInstalacionResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/instalaciones/%d/%s", idOrden, operacion)
+
+	var response modelos.OrdenInstalacionResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", path, req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ObtenerAgenda obtiene las zonas y los horarios de trabajo del técnico
+func (s *InstalacionService) ObtenerAgenda(ctx context.Context, idTecnico, idPersona int) (*modelos.AgendaTecnico, error) {
+	path := fmt.Sprintf("/api/v1/internal/instalaciones/tecnicos/%d/agenda", idTecnico)
+
+	var response modelos.AgendaTecnico
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ActualizarAgenda reemplaza las zonas y los horarios de trabajo del técnico
+func (s *InstalacionService) ActualizarAgenda(
+	ctx context.Context,
+	idTecnico int,
+	agenda modelos.AgendaTecnico,
+	idPersona int,
+) (*modelos.AgendaTecnico, error) {
+	path := fmt.Sprintf("/api/v1/internal/instalaciones/tecnicos/%d/agenda", idTecnico)
+
+	var response modelos.AgendaTecnico
+	if err := s.modeloClient.DoRequest(ctx, "PUT", path, agenda, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,54 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// IntentoLoginService reenvía al modelo las consultas de intentos de login y la gestión de
+// bloqueos por fuerza bruta. El modelo verifica el rol admin.
+type IntentoLoginService struct {
+	modeloClient *ModeloClient
+}
+
+func NewIntentoLoginService(modeloClient *ModeloClient) *IntentoLoginService {
+	return &IntentoLoginService{modeloClient: modeloClient}
+}
+
+// ListarIntentos obtiene los últimos intentos de login, filtrados por email, ip y limit
+func (s *IntentoLoginService) ListarIntentos(ctx context.Context, query url.Values, idPersona int) (*modelos.IntentosLoginResponse, error) {
+	q := url.Values{}
+	for _, p := range []string{"email", "ip", "limit"} {
+		if v := query.Get(p); v != "" {
+			q.Set(p, v)
+		}
+	}
+	path := "/api/v1/internal/login/intentos"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var response modelos.IntentosLoginResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListarBloqueos obtiene las cuentas e IPs bloqueadas en este momento
+func (s *IntentoLoginService) ListarBloqueos(ctx context.Context, idPersona int) (*modelos.BloqueosLoginResponse, error) {
+	var response modelos.BloqueosLoginResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", "/api/v1/internal/login/bloqueos", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// LevantarBloqueo quita el bloqueo y los fallos de una cuenta o IP
+func (s *IntentoLoginService) LevantarBloqueo(ctx context.Context, idLoginBloqueo int, idPersona int) error {
+	path := fmt.Sprintf("/api/v1/internal/login/bloqueos/%d", idLoginBloqueo)
+	return s.modeloClient.DoRequest(ctx, "DELETE", path, nil, nil, true, headersPersona(idPersona))
+}
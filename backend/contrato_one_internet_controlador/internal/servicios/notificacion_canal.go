@@ -0,0 +1,114 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// Canales de entrega (deben coincidir con los del modelo)
+const (
+	CanalEmail    = "email"
+	CanalSMS      = "sms"
+	CanalWhatsApp = "whatsapp"
+	CanalPush     = "push"
+)
+
+// CanalNotificacion envía una notificación por un medio externo (email, SMS, WhatsApp, Web Push).
+type CanalNotificacion interface {
+	// Nombre identifica el canal ("email", "sms", "whatsapp", "push")
+	Nombre() string
+	// Enviar entrega el mensaje ya renderizado. Puede devolver un envío junto con un error
+	// (p. ej. suscripciones push vencidas aunque ninguna haya recibido el mensaje).
+	Enviar(ctx context.Context, e modelos.EntregaPendiente) (*EnvioRealizado, error)
+}
+
+// EnvioRealizado es lo que devuelve un canal al enviar
+type EnvioRealizado struct {
+	IDProveedor           string   // ID del mensaje en el proveedor, si lo informa
+	SuscripcionesVencidas []string // endpoints push que respondieron 404/410
+}
+
+// CanalEmailSMTP envía por correo usando el ServicioCorreo existente
+type CanalEmailSMTP struct {
+	correo *ServicioCorreo
+}
+
+func NewCanalEmailSMTP(correo *ServicioCorreo) *CanalEmailSMTP {
+	return &CanalEmailSMTP{correo: correo}
+}
+
+func (c *CanalEmailSMTP) Nombre() string { return CanalEmail }
+
+func (c *CanalEmailSMTP) Enviar(ctx context.Context, e modelos.EntregaPendiente) (*EnvioRealizado, error) {
+	if err := c.correo.EnviarNotificacion(e.Destino, e.Asunto, e.Nombre, e.Asunto, e.Cuerpo); err != nil {
+		return nil, err
+	}
+	return &EnvioRealizado{}, nil
+}
+
+// CanalFake registra los mensajes en memoria y en el log en lugar de enviarlos.
+// Sirve para desarrollo local y pruebas de cualquier canal.
+type CanalFake struct {
+	nombre string
+
+	mu       sync.Mutex
+	enviados []modelos.EntregaPendiente
+}
+
+func NewCanalFake(nombre string) *CanalFake {
+	return &CanalFake{nombre: nombre}
+}
+
+func (c *CanalFake) Nombre() string { return c.nombre }
+
+func (c *CanalFake) Enviar(ctx context.Context, e modelos.EntregaPendiente) (*EnvioRealizado, error) {
+	c.mu.Lock()
+	c.enviados = append(c.enviados, e)
+	n := len(c.enviados)
+	c.mu.Unlock()
+
+	destino := e.Destino
+	if e.Canal == CanalPush {
+		destino = fmt.Sprintf("%d suscripciones", len(e.Suscripciones))
+	}
+	logger.Info.Printf("[%s fake] → %s: %s", c.nombre, destino, e.Asunto)
+	return &EnvioRealizado{IDProveedor: fmt.Sprintf("fake-%s-%d", c.nombre, n)}, nil
+}
+
+// Enviados devuelve una copia de los mensajes registrados
+func (c *CanalFake) Enviados() []modelos.EntregaPendiente {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]modelos.EntregaPendiente(nil), c.enviados...)
+}
+
+// normalizarTelefonoAR lleva un teléfono argentino al formato E.164 (+549...) que
+// esperan los proveedores de SMS y WhatsApp. Los números que ya empiezan con + se respetan.
+func normalizarTelefonoAR(telefono string) string {
+	limpio := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '+' {
+			return r
+		}
+		return -1
+	}, telefono)
+
+	switch {
+	case strings.HasPrefix(limpio, "+"):
+		return limpio
+	case strings.HasPrefix(limpio, "549"):
+		return "+" + limpio
+	case strings.HasPrefix(limpio, "54"):
+		return "+549" + strings.TrimPrefix(limpio, "54")
+	}
+	limpio = strings.TrimPrefix(limpio, "0")
+	// Prefijo "15" de celulares locales: 2634 15 445162 → 2634 445162
+	if i := strings.Index(limpio, "15"); i >= 2 && i <= 4 && len(limpio) == 12 {
+		limpio = limpio[:i] + limpio[i+2:]
+	}
+	return "+549" + limpio
+}
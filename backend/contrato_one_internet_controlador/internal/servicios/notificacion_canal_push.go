@@ -0,0 +1,238 @@
+package servicios
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// CanalWebPush envía notificaciones Web Push a los navegadores suscriptos:
+// autenticación VAPID (RFC 8292) y cifrado aes128gcm del contenido (RFC 8291).
+type CanalWebPush struct {
+	clave      *ecdsa.PrivateKey
+	publica    string // clave pública VAPID (base64url, sin comprimir): applicationServerKey del frontend
+	sujeto     string // mailto: o https: de contacto para el servicio push
+	ttl        time.Duration
+	httpClient *http.Client
+}
+
+// NewCanalWebPush crea el canal a partir de la clave privada VAPID en base64url (32 bytes),
+// el formato que generan las librerías web-push habituales.
+func NewCanalWebPush(clavePrivadaVAPID, sujeto string) (*CanalWebPush, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(clavePrivadaVAPID)
+	if err != nil {
+		return nil, fmt.Errorf("clave VAPID inválida: %w", err)
+	}
+	clave, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("clave VAPID inválida: %w", err)
+	}
+	publica, err := clave.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &CanalWebPush{
+		clave:      clave,
+		publica:    base64.RawURLEncoding.EncodeToString(publica),
+		sujeto:     sujeto,
+		ttl:        24 * time.Hour,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (c *CanalWebPush) Nombre() string { return CanalPush }
+
+// ClavePublica devuelve la applicationServerKey que el frontend usa para suscribirse
+func (c *CanalWebPush) ClavePublica() string { return c.publica }
+
+// Enviar manda el mensaje a todas las suscripciones de la persona. Alcanza con que una lo
+// reciba; las que responden 404/410 se informan como vencidas para darlas de baja.
+func (c *CanalWebPush) Enviar(ctx context.Context, e modelos.EntregaPendiente) (*EnvioRealizado, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"id_notificacion": e.IDNotificacion,
+		"tipo":            e.Tipo,
+		"titulo":          e.Asunto,
+		"mensaje":         e.Cuerpo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	envio := &EnvioRealizado{}
+	enviadas := 0
+	var ultimoErr error
+	for _, sub := range e.Suscripciones {
+		status, err := c.enviarASuscripcion(ctx, sub, payload)
+		switch {
+		case err != nil:
+			ultimoErr = err
+		case status == http.StatusNotFound || status == http.StatusGone:
+			envio.SuscripcionesVencidas = append(envio.SuscripcionesVencidas, sub.Endpoint)
+		case status >= 300:
+			ultimoErr = fmt.Errorf("el servicio push respondió %d", status)
+		default:
+			enviadas++
+		}
+	}
+
+	if enviadas > 0 {
+		return envio, nil
+	}
+	if ultimoErr == nil {
+		ultimoErr = errors.New("todas las suscripciones push están vencidas")
+	}
+	return envio, ultimoErr
+}
+
+func (c *CanalWebPush) enviarASuscripcion(ctx context.Context, sub modelos.SuscripcionPush, payload []byte) (int, error) {
+	cuerpo, err := cifrarWebPush(sub, payload)
+	if err != nil {
+		return 0, err
+	}
+	autorizacion, err := c.autorizacionVAPID(sub.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(cuerpo))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", autorizacion)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(c.ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error enviando push: %w", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// autorizacionVAPID arma la cabecera "vapid t=<JWT ES256>, k=<clave pública>" para el
+// origen del endpoint (RFC 8292)
+func (c *CanalWebPush) autorizacionVAPID(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("endpoint push inválido: %s", endpoint)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": c.sujeto,
+	})
+	firmado, err := token.SignedString(c.clave)
+	if err != nil {
+		return "", fmt.Errorf("error firmando JWT VAPID: %w", err)
+	}
+	return "vapid t=" + firmado + ", k=" + c.publica, nil
+}
+
+// cifrarWebPush cifra el payload para la suscripción con aes128gcm (RFC 8188 / RFC 8291),
+// en un único registro
+func cifrarWebPush(sub modelos.SuscripcionPush, payload []byte) ([]byte, error) {
+	// 1. Claves del navegador
+	uaPublicaRaw, err := decodificarBase64URL(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh inválido: %w", err)
+	}
+	authSecret, err := decodificarBase64URL(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("auth inválido: %w", err)
+	}
+	uaPublica, err := ecdh.P256().NewPublicKey(uaPublicaRaw)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh inválido: %w", err)
+	}
+
+	// 2. Clave efímera del servidor y secreto compartido
+	asPrivada, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublica := asPrivada.PublicKey().Bytes()
+	secreto, err := asPrivada.ECDH(uaPublica)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Derivación de claves (RFC 8291 §3.4)
+	prkKey, err := hkdf.Extract(sha256.New, secreto, authSecret)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := "WebPush: info\x00" + string(uaPublicaRaw) + string(asPublica)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Cifrado: payload + delimitador de último registro (0x02)
+	bloque, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(bloque)
+	if err != nil {
+		return nil, err
+	}
+	cifrado := gcm.Seal(nil, nonce, append(append([]byte{}, payload...), 0x02), nil)
+
+	// 5. Cabecera: salt(16) | rs(4) | idlen(1) | keyid(clave pública efímera)
+	const tamRegistro = 4096
+	var out bytes.Buffer
+	out.Write(salt)
+	binary.Write(&out, binary.BigEndian, uint32(tamRegistro))
+	out.WriteByte(byte(len(asPublica)))
+	out.Write(asPublica)
+	out.Write(cifrado)
+	return out.Bytes(), nil
+}
+
+// decodificarBase64URL acepta base64url con o sin relleno (los navegadores lo omiten)
+func decodificarBase64URL(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
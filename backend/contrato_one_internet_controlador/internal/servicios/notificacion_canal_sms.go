@@ -0,0 +1,83 @@
+package servicios
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// CanalSMSTwilio envía SMS con la API REST de Twilio (Messages.json)
+type CanalSMSTwilio struct {
+	apiURL     string
+	accountSID string
+	authToken  string
+	remitente  string // número E.164 o Messaging Service SID (MG...)
+	httpClient *http.Client
+}
+
+func NewCanalSMSTwilio(apiURL, accountSID, authToken, remitente string) *CanalSMSTwilio {
+	if apiURL == "" {
+		apiURL = "https://api.twilio.com"
+	}
+	return &CanalSMSTwilio{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		accountSID: accountSID,
+		authToken:  authToken,
+		remitente:  remitente,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *CanalSMSTwilio) Nombre() string { return CanalSMS }
+
+func (c *CanalSMSTwilio) Enviar(ctx context.Context, e modelos.EntregaPendiente) (*EnvioRealizado, error) {
+	form := url.Values{}
+	form.Set("To", normalizarTelefonoAR(e.Destino))
+	if strings.HasPrefix(c.remitente, "MG") {
+		form.Set("MessagingServiceSid", c.remitente)
+	} else {
+		form.Set("From", c.remitente)
+	}
+	form.Set("Body", textoMensajeCorto(e))
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", c.apiURL, url.PathEscape(c.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error enviando SMS: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+
+	var respuesta struct {
+		SID     string `json:"sid"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &respuesta)
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("twilio respondió %d: %s", resp.StatusCode, respuesta.Message)
+	}
+	return &EnvioRealizado{IDProveedor: respuesta.SID}, nil
+}
+
+// textoMensajeCorto arma el texto para SMS/WhatsApp: asunto y cuerpo, sin repetir
+// el asunto si la plantilla ya lo incluye en el cuerpo
+func textoMensajeCorto(e modelos.EntregaPendiente) string {
+	if e.Asunto == "" || strings.Contains(e.Cuerpo, e.Asunto) {
+		return e.Cuerpo
+	}
+	return "ONE Internet - " + e.Asunto + ": " + e.Cuerpo
+}
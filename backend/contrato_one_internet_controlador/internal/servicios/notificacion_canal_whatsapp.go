@@ -0,0 +1,84 @@
+package servicios
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// CanalWhatsAppCloud envía mensajes con la WhatsApp Cloud API de Meta (/{phone-number-id}/messages).
+// Los mensajes de texto libre sólo llegan dentro de la ventana de 24 h de atención; fuera de
+// ella Meta exige plantillas aprobadas, por eso el error del proveedor queda registrado en la entrega.
+type CanalWhatsAppCloud struct {
+	apiURL        string
+	phoneNumberID string
+	accessToken   string
+	httpClient    *http.Client
+}
+
+func NewCanalWhatsAppCloud(apiURL, phoneNumberID, accessToken string) *CanalWhatsAppCloud {
+	if apiURL == "" {
+		apiURL = "https://graph.facebook.com/v20.0"
+	}
+	return &CanalWhatsAppCloud{
+		apiURL:        strings.TrimRight(apiURL, "/"),
+		phoneNumberID: phoneNumberID,
+		accessToken:   accessToken,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *CanalWhatsAppCloud) Nombre() string { return CanalWhatsApp }
+
+func (c *CanalWhatsAppCloud) Enviar(ctx context.Context, e modelos.EntregaPendiente) (*EnvioRealizado, error) {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(normalizarTelefonoAR(e.Destino), "+"),
+		"type":              "text",
+		"text":              map[string]string{"body": textoMensajeCorto(e)},
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/"+c.phoneNumberID+"/messages", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error enviando WhatsApp: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+
+	var respuesta struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &respuesta)
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("whatsapp respondió %d: %s", resp.StatusCode, respuesta.Error.Message)
+	}
+
+	envio := &EnvioRealizado{}
+	if len(respuesta.Messages) > 0 {
+		envio.IDProveedor = respuesta.Messages[0].ID
+	}
+	return envio, nil
+}
@@ -0,0 +1,223 @@
+package servicios
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"contrato_one_internet_controlador/internal/modelos"
+	"contrato_one_internet_controlador/internal/utilidades/logger"
+)
+
+// loteFeedNotificaciones es la cantidad máxima de notificaciones que se piden al modelo por consulta
+const loteFeedNotificaciones = 200
+
+// VentanaRelecturaNotificaciones es cuántos ids por debajo del cursor se vuelven a leer en
+// cada consulta. Los ids se asignan al insertar pero se ven recién al confirmar la transacción,
+// así que una notificación puede aparecer después de otras con id mayor; la relectura la
+// recupera y los ids ya entregados se descartan.
+const VentanaRelecturaNotificaciones = 200
+
+// bufferSuscriptor es cuántas notificaciones se encolan por conexión antes de cortarla.
+// Un cliente lento se desconecta y, al reconectarse con Last-Event-ID, recupera lo perdido.
+const bufferSuscriptor = 64
+
+// SuscriptorNotificaciones es una conexión abierta de streaming
+type SuscriptorNotificaciones struct {
+	IDPersona int
+	Roles     []string
+	C         chan modelos.Notificacion
+}
+
+// recibe indica si la notificación es para la persona o para alguno de sus roles
+func (s *SuscriptorNotificaciones) recibe(n modelos.Notificacion) bool {
+	if n.IDPersonaReceptor != nil {
+		return *n.IDPersonaReceptor == s.IDPersona
+	}
+	if n.RolDestino == nil {
+		return false
+	}
+	for _, rol := range s.Roles {
+		if strings.EqualFold(rol, *n.RolDestino) {
+			return true
+		}
+	}
+	return false
+}
+
+// HubNotificaciones consulta el feed global del modelo con un único cursor (una consulta
+// por intervalo sin importar cuántas conexiones haya) y reparte cada notificación nueva
+// entre las conexiones abiertas.
+type HubNotificaciones struct {
+	modelo    *ModeloClient
+	intervalo time.Duration
+
+	mu           sync.Mutex
+	suscriptores map[*SuscriptorNotificaciones]struct{}
+	cursor       int
+	iniciado     bool             // el cursor se posicionó en el último id del modelo
+	entregados   map[int]struct{} // ids ya repartidos dentro de la ventana de relectura
+}
+
+func NewHubNotificaciones(modelo *ModeloClient, intervalo time.Duration) *HubNotificaciones {
+	return &HubNotificaciones{
+		modelo:       modelo,
+		intervalo:    intervalo,
+		suscriptores: make(map[*SuscriptorNotificaciones]struct{}),
+		entregados:   make(map[int]struct{}),
+	}
+}
+
+// Suscribir registra una conexión y devuelve el cursor en ese momento: el hub le entregará
+// toda notificación con id mayor y las que se confirmen tarde con id dentro de
+// VentanaRelecturaNotificaciones por debajo del cursor. El canal se cierra al desuscribir o si el cliente no da abasto.
+// Devuelve ok=false mientras el hub no haya podido leer el feed del modelo.
+func (h *HubNotificaciones) Suscribir(idPersona int, roles []string) (s *SuscriptorNotificaciones, cursor int, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.iniciado {
+		return nil, 0, false
+	}
+
+	s = &SuscriptorNotificaciones{
+		IDPersona: idPersona,
+		Roles:     roles,
+		C:         make(chan modelos.Notificacion, bufferSuscriptor),
+	}
+	h.suscriptores[s] = struct{}{}
+	return s, h.cursor, true
+}
+
+// Desuscribir quita la conexión (idempotente)
+func (h *HubNotificaciones) Desuscribir(s *SuscriptorNotificaciones) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.suscriptores[s]; ok {
+		delete(h.suscriptores, s)
+		close(s.C)
+	}
+}
+
+// Iniciar consulta el feed cada intervalo hasta que se cancele el contexto.
+// Se ejecuta en su propia goroutine.
+func (h *HubNotificaciones) Iniciar(ctx context.Context) {
+	logger.Info.Printf("Hub de notificaciones en tiempo real iniciado (intervalo %s)", h.intervalo)
+	ticker := time.NewTicker(h.intervalo)
+	defer ticker.Stop()
+
+	for {
+		if err := h.consultar(ctx); err != nil {
+			logger.Warn.Printf("Error consultando feed de notificaciones: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info.Println("Hub de notificaciones detenido")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// consultar trae las notificaciones nuevas y las reparte
+func (h *HubNotificaciones) consultar(ctx context.Context) error {
+	h.mu.Lock()
+	iniciado, cursor := h.iniciado, h.cursor
+	h.mu.Unlock()
+
+	// 1. Al arrancar, posicionar el cursor en el último id y dar por entregado lo que ya está
+	// en la ventana: lo anterior lo recupera cada conexión al reanudar con Last-Event-ID
+	if !iniciado {
+		feed, err := h.modelo.ObtenerFeedNotificaciones(ctx, 0, 0, nil)
+		if err != nil {
+			return err
+		}
+		previas, err := h.leerDesde(ctx, desdeVentana(feed.UltimoID))
+		if err != nil {
+			return err
+		}
+		h.mu.Lock()
+		h.cursor = feed.UltimoID
+		h.registrarEntregadas(previas)
+		h.iniciado = true
+		h.mu.Unlock()
+		return nil
+	}
+
+	// 2. Releer desde el inicio de la ventana y repartir lo que no se entregó todavía
+	notificaciones, err := h.leerDesde(ctx, desdeVentana(cursor))
+	if err != nil {
+		return err
+	}
+	h.repartir(notificaciones)
+	return nil
+}
+
+// desdeVentana es el id desde el que se relee para un cursor dado
+func desdeVentana(cursor int) int {
+	return max(0, cursor-VentanaRelecturaNotificaciones)
+}
+
+// leerDesde trae en lotes todas las notificaciones con id mayor a desde
+func (h *HubNotificaciones) leerDesde(ctx context.Context, desde int) ([]modelos.Notificacion, error) {
+	var todas []modelos.Notificacion
+	for {
+		feed, err := h.modelo.ObtenerFeedNotificaciones(ctx, desde, loteFeedNotificaciones, nil)
+		if err != nil {
+			return nil, err
+		}
+		todas = append(todas, feed.Notificaciones...)
+		desde = feed.UltimoID
+
+		if len(feed.Notificaciones) < loteFeedNotificaciones {
+			return todas, nil
+		}
+	}
+}
+
+// registrarEntregadas marca los ids como entregados, avanza el cursor y olvida los que
+// quedaron fuera de la ventana. Se llama con h.mu tomado.
+func (h *HubNotificaciones) registrarEntregadas(notificaciones []modelos.Notificacion) {
+	for _, n := range notificaciones {
+		h.entregados[n.IDNotificacion] = struct{}{}
+		h.cursor = max(h.cursor, n.IDNotificacion)
+	}
+	limite := desdeVentana(h.cursor)
+	for id := range h.entregados {
+		if id <= limite {
+			delete(h.entregados, id)
+		}
+	}
+}
+
+// repartir entrega las notificaciones no entregadas y avanza el cursor bajo el mismo lock,
+// para que una conexión nueva reciba todo lo posterior al cursor que obtuvo al suscribirse
+func (h *HubNotificaciones) repartir(notificaciones []modelos.Notificacion) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var nuevas []modelos.Notificacion
+	for _, n := range notificaciones {
+		if _, ok := h.entregados[n.IDNotificacion]; !ok {
+			nuevas = append(nuevas, n)
+		}
+	}
+	h.registrarEntregadas(nuevas)
+
+	for _, n := range nuevas {
+		for s := range h.suscriptores {
+			if !s.recibe(n) {
+				continue
+			}
+			select {
+			case s.C <- n:
+			default:
+				// Cliente saturado: se corta y reanuda desde su último id al reconectar
+				logger.Warn.Printf("Conexión de streaming de persona %d saturada, se cierra", s.IDPersona)
+				delete(h.suscriptores, s)
+				close(s.C)
+			}
+		}
+	}
+}
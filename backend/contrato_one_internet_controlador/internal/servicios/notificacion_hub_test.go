@@ -0,0 +1,155 @@
+package servicios
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// feedFake simula el feed global del modelo con las notificaciones ya confirmadas
+type feedFake struct {
+	mu        sync.Mutex
+	visibles  []int
+	idPersona int
+}
+
+// confirmar hace visibles los ids, en el orden en que se confirman sus transacciones
+func (f *feedFake) confirmar(ids ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.visibles = append(f.visibles, ids...)
+	sort.Ints(f.visibles)
+}
+
+func (f *feedFake) servir(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	desde, _ := strconv.Atoi(r.URL.Query().Get("desde_id"))
+	limite, _ := strconv.Atoi(r.URL.Query().Get("limite"))
+
+	resp := modelos.NotificacionesFeedResponse{Notificaciones: []modelos.Notificacion{}, UltimoID: desde}
+	if limite == 0 {
+		if n := len(f.visibles); n > 0 {
+			resp.UltimoID = f.visibles[n-1]
+		}
+	}
+	for _, id := range f.visibles {
+		if limite == 0 || len(resp.Notificaciones) == limite {
+			break
+		}
+		if id > desde {
+			resp.Notificaciones = append(resp.Notificaciones, modelos.Notificacion{IDNotificacion: id, IDPersonaReceptor: &f.idPersona})
+			resp.UltimoID = id
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func nuevoHubPrueba(t *testing.T, feed *feedFake) *HubNotificaciones {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(rutaTokenInterno, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": "token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/api/v1/internal/notificaciones/feed", feed.servir)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c := &ModeloClient{baseURL: srv.URL, httpClient: srv.Client(), clientID: "controlador", clientSecret: "secreto"}
+	if err := c.authenticate(); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	return NewHubNotificaciones(c, 0)
+}
+
+// recibidas vacía el canal del suscriptor
+func recibidas(s *SuscriptorNotificaciones) []int {
+	var ids []int
+	for {
+		select {
+		case n := <-s.C:
+			ids = append(ids, n.IDNotificacion)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHubEntregaNotificacionesConfirmadasTarde(t *testing.T) {
+	feed := &feedFake{idPersona: 7}
+	feed.confirmar(1, 2, 3)
+	hub := nuevoHubPrueba(t, feed)
+	ctx := context.Background()
+
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatalf("consultar inicial: %v", err)
+	}
+	s, cursor, ok := hub.Suscribir(7, nil)
+	if !ok || cursor != 3 {
+		t.Fatalf("Suscribir = (cursor %d, ok %v), se esperaba cursor 3", cursor, ok)
+	}
+
+	// La 5 se confirma antes que la 4, que tenía id asignado pero su transacción seguía abierta
+	feed.confirmar(5)
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recibidas(s); len(got) != 1 || got[0] != 5 {
+		t.Fatalf("primera consulta entregó %v, se esperaba [5]", got)
+	}
+
+	feed.confirmar(4)
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recibidas(s); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("segunda consulta entregó %v, se esperaba [4]", got)
+	}
+
+	// La relectura de la ventana no vuelve a entregar nada
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recibidas(s); len(got) != 0 {
+		t.Fatalf("tercera consulta entregó %v, no se esperaba nada", got)
+	}
+}
+
+func TestHubNoReentregaLoPrevioAlArranque(t *testing.T) {
+	feed := &feedFake{idPersona: 7}
+	feed.confirmar(1, 2, 3)
+	hub := nuevoHubPrueba(t, feed)
+	ctx := context.Background()
+
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s, _, _ := hub.Suscribir(7, nil)
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recibidas(s); len(got) != 0 {
+		t.Fatalf("se reentregaron %v, anteriores al arranque del hub", got)
+	}
+
+	// Fuera de la ventana ya no se relee: el mapa de entregados no crece sin límite
+	hub.Desuscribir(s)
+	ids := make([]int, 0, VentanaRelecturaNotificaciones+10)
+	for id := 4; id < 4+VentanaRelecturaNotificaciones+10; id++ {
+		ids = append(ids, id)
+	}
+	feed.confirmar(ids...)
+	if err := hub.consultar(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(hub.entregados); n > VentanaRelecturaNotificaciones {
+		t.Errorf("entregados tiene %d ids, más que la ventana (%d)", n, VentanaRelecturaNotificaciones)
+	}
+}
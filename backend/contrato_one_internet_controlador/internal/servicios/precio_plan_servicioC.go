@@ -0,0 +1,78 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// PrecioPlanService reenvía al modelo el historial y los ajustes de precios de los planes y
+// la gestión de promociones. El modelo verifica el rol admin y la anticipación del aviso.
+type PrecioPlanService struct {
+	modeloClient *ModeloClient
+}
+
+func NewPrecioPlanService(modeloClient *ModeloClient) *PrecioPlanService {
+	return &PrecioPlanService{modeloClient: modeloClient}
+}
+
+// Historial obtiene los precios de un plan, del más reciente al más antiguo (sólo admin)
+func (s *PrecioPlanService) Historial(ctx context.Context, idPlan int, idPersona int) (*modelos.HistorialPreciosResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/planes/%d/precios", idPlan)
+
+	var response modelos.HistorialPreciosResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AjustarPrecios programa precios nuevos y avisa a los clientes alcanzados (sólo admin)
+func (s *PrecioPlanService) AjustarPrecios(ctx context.Context, req modelos.AjustePreciosRequest, idPersona int) (*modelos.AjustePreciosResponse, error) {
+	var response modelos.AjustePreciosResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/planes/precios", req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListarPromocionesVigentes obtiene las promociones que se pueden contratar hoy (público)
+func (s *PrecioPlanService) ListarPromocionesVigentes(ctx context.Context, idPlan *int) ([]modelos.Promocion, error) {
+	path := "/api/v1/promociones"
+	if idPlan != nil {
+		path += "?" + url.Values{"id_plan": {strconv.Itoa(*idPlan)}}.Encode()
+	}
+
+	var response modelos.PromocionesResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, false); err != nil {
+		return nil, err
+	}
+	return response.Promociones, nil
+}
+
+// ListarPromociones obtiene todas las promociones no borradas (sólo admin)
+func (s *PrecioPlanService) ListarPromociones(ctx context.Context, idPersona int) ([]modelos.Promocion, error) {
+	var response modelos.PromocionesResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", "/api/v1/internal/promociones", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return response.Promociones, nil
+}
+
+// CrearPromocion da de alta una promoción (sólo admin)
+func (s *PrecioPlanService) CrearPromocion(ctx context.Context, req modelos.CrearPromocionRequest, idPersona int) (*modelos.Promocion, error) {
+	var response modelos.Promocion
+	if err := s.modeloClient.DoRequest(ctx, "POST", "/api/v1/internal/promociones", req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// BorrarPromocion da de baja una promoción; los contratos que ya la tienen la conservan (sólo admin)
+func (s *PrecioPlanService) BorrarPromocion(ctx context.Context, idPromocion int, idPersona int) error {
+	path := fmt.Sprintf("/api/v1/internal/promociones/%d", idPromocion)
+	return s.modeloClient.DoRequest(ctx, "DELETE", path, nil, nil, true, headersPersona(idPersona))
+}
@@ -0,0 +1,59 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// RolPermisoService reenvía al modelo la asignación de permisos a roles y la consulta de los
+// permisos efectivos
+type RolPermisoService struct {
+	modeloClient *ModeloClient
+}
+
+func NewRolPermisoService(modeloClient *ModeloClient) *RolPermisoService {
+	return &RolPermisoService{modeloClient: modeloClient}
+}
+
+// ListarDeRol obtiene los permisos asignados al rol
+func (s *RolPermisoService) ListarDeRol(ctx context.Context, idRol, idPersona int) (*modelos.RolPermisosResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/roles/%d/permisos", idRol)
+	var response modelos.RolPermisosResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Reemplazar deja al rol con exactamente los permisos indicados
+func (s *RolPermisoService) Reemplazar(ctx context.Context, idRol int, req *modelos.AsignarPermisosRequest, idPersona int) (*modelos.RolPermisosResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/roles/%d/permisos", idRol)
+	var response modelos.RolPermisosResponse
+	if err := s.modeloClient.DoRequest(ctx, "PUT", path, req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Asignar le da un permiso al rol
+func (s *RolPermisoService) Asignar(ctx context.Context, idRol, idPermiso, idPersona int) error {
+	path := fmt.Sprintf("/api/v1/internal/roles/%d/permisos/%d", idRol, idPermiso)
+	return s.modeloClient.DoRequest(ctx, "POST", path, nil, nil, true, headersPersona(idPersona))
+}
+
+// Quitar le saca un permiso al rol
+func (s *RolPermisoService) Quitar(ctx context.Context, idRol, idPermiso, idPersona int) error {
+	path := fmt.Sprintf("/api/v1/internal/roles/%d/permisos/%d", idRol, idPermiso)
+	return s.modeloClient.DoRequest(ctx, "DELETE", path, nil, nil, true, headersPersona(idPersona))
+}
+
+// PermisosEfectivos obtiene los roles y permisos actuales de la persona
+func (s *RolPermisoService) PermisosEfectivos(ctx context.Context, idPersona int) (*modelos.PermisosEfectivosResponse, error) {
+	var response modelos.PermisosEfectivosResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", "/api/v1/internal/perfil/permisos", nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
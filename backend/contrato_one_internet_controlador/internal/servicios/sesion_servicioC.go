@@ -0,0 +1,53 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// SesionService reenvía al modelo la consulta y el cierre de sesiones de login
+type SesionService struct {
+	modeloClient *ModeloClient
+}
+
+func NewSesionService(modeloClient *ModeloClient) *SesionService {
+	return &SesionService{modeloClient: modeloClient}
+}
+
+// Listar obtiene las sesiones vigentes de la persona; idSesionActual marca la del pedido
+func (s *SesionService) Listar(ctx context.Context, idPersona, idSesionActual int) (*modelos.SesionesResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/perfil/sesiones?actual=%d", idSesionActual)
+	var response modelos.SesionesResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Revocar cierra una sesión de la persona
+func (s *SesionService) Revocar(ctx context.Context, idPersona, idSesion int) error {
+	path := fmt.Sprintf("/api/v1/internal/perfil/sesiones/%d", idSesion)
+	return s.modeloClient.DoRequest(ctx, "DELETE", path, nil, nil, true, headersPersona(idPersona))
+}
+
+// RevocarOtras cierra todas las sesiones de la persona salvo la indicada
+func (s *SesionService) RevocarOtras(ctx context.Context, idPersona, excepto int) (*modelos.SesionesRevocadasResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/perfil/sesiones?excepto=%d", excepto)
+	var response modelos.SesionesRevocadasResponse
+	if err := s.modeloClient.DoRequest(ctx, "DELETE", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CerrarSesionesUsuario cierra todas las sesiones de otro usuario (admin)
+func (s *SesionService) CerrarSesionesUsuario(ctx context.Context, idUsuario, idPersona int) (*modelos.SesionesRevocadasResponse, error) {
+	path := fmt.Sprintf("/api/v1/internal/usuarios/%d/sesiones", idUsuario)
+	var response modelos.SesionesRevocadasResponse
+	if err := s.modeloClient.DoRequest(ctx, "DELETE", path, nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,54 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+
+	"contrato_one_internet_controlador/internal/modelos"
+)
+
+// TurnoService reenvía al modelo las reservas de turnos de instalación del cliente. El modelo
+// verifica que la conexión sea de la persona del header.
+type TurnoService struct {
+	modeloClient *ModeloClient
+}
+
+func NewTurnoService(modeloClient *ModeloClient) *TurnoService {
+	return &TurnoService{modeloClient: modeloClient}
+}
+
+func pathTurnos(idConexion int) string {
+	return fmt.Sprintf("/api/v1/internal/perfil/conexiones/%d/turnos", idConexion)
+}
+
+// Disponibilidad obtiene el turno actual y las ventanas libres de la conexión
+func (s *TurnoService) Disponibilidad(ctx context.Context, idConexion, idPersona int) (*modelos.DisponibilidadTurnosResponse, error) {
+	var response modelos.DisponibilidadTurnosResponse
+	if err := s.modeloClient.DoRequest(ctx, "GET", pathTurnos(idConexion), nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Reservar reserva (o cambia) el turno de la conexión
+func (s *TurnoService) Reservar(
+	ctx context.Context,
+	idConexion int,
+	req modelos.ReservarTurnoRequest,
+	idPersona int,
+) (*modelos.TurnoResponse, error) {
+	var response modelos.TurnoResponse
+	if err := s.modeloClient.DoRequest(ctx, "POST", pathTurnos(idConexion), req, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Cancelar libera el turno reservado de la conexión
+func (s *TurnoService) Cancelar(ctx context.Context, idConexion, idPersona int) (*modelos.TurnoResponse, error) {
+	var response modelos.TurnoResponse
+	if err := s.modeloClient.DoRequest(ctx, "DELETE", pathTurnos(idConexion), nil, &response, true, headersPersona(idPersona)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
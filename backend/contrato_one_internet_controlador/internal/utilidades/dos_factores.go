@@ -0,0 +1,88 @@
+package utilidades
+
+import (
+	"fmt"
+	"time"
+
+	"contrato_one_internet_controlador/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Valores del claim amr que emite el modelo
+const (
+	AMRPassword = "pwd"
+	AMRMFA      = "mfa" // la sesión pasó por el segundo factor
+)
+
+// audienciaDesafio2FA distingue el token de desafío de un JWT de acceso
+const audienciaDesafio2FA = "desafio-2fa"
+
+// ClaimsDesafio2FA es el token de desafío que recibe quien validó la contraseña de una
+// cuenta con autenticación en dos pasos. Sólo sirve para pedir el código por email y
+// completar el login en /v1/auth/login/2fa.
+type ClaimsDesafio2FA struct {
+	IDUsuario int      `json:"id_usuario"`
+	IDPersona int      `json:"id_persona"`
+	Metodos   []string `json:"metodos"`
+	jwt.RegisteredClaims
+}
+
+// claveDesafio2FA deriva la clave de firma del desafío, distinta de la de los JWT de acceso:
+// aunque alguien presente un desafío como token de acceso, la firma no valida
+func claveDesafio2FA(cfg *config.Config) []byte {
+	return []byte(cfg.JWTSecret + "|" + audienciaDesafio2FA)
+}
+
+// GenerarTokenDesafio2FA firma el desafío con vigencia DOS_FACTORES_DESAFIO_MINUTOS
+func GenerarTokenDesafio2FA(idUsuario, idPersona int, metodos []string, cfg *config.Config) (string, time.Time, error) {
+	now := time.Now()
+	expira := now.Add(time.Duration(cfg.DosFactoresDesafioMinutos) * time.Minute)
+
+	claims := &ClaimsDesafio2FA{
+		IDUsuario: idUsuario,
+		IDPersona: idPersona,
+		Metodos:   metodos,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{audienciaDesafio2FA},
+			ExpiresAt: jwt.NewNumericDate(expira),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   fmt.Sprintf("%d", idUsuario),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(claveDesafio2FA(cfg))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error al firmar el token de desafío: %w", err)
+	}
+	return token, expira, nil
+}
+
+// ParsearTokenDesafio2FA verifica firma, audiencia y vigencia del desafío
+func ParsearTokenDesafio2FA(tokenString string, cfg *config.Config) (*ClaimsDesafio2FA, error) {
+	claims := &ClaimsDesafio2FA{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
+		}
+		return claveDesafio2FA(cfg), nil
+	}, jwt.WithAudience(audienciaDesafio2FA))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.IDUsuario <= 0 {
+		return nil, fmt.Errorf("token de desafío inválido")
+	}
+	return claims, nil
+}
+
+// Requiere2FA indica si alguno de los roles está obligado a operar con segundo factor
+func Requiere2FA(roles []string, cfg *config.Config) bool {
+	for _, r := range roles {
+		for _, obligatorio := range cfg.DosFactoresRolesObligatorios {
+			if r == obligatorio {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -11,4 +11,7 @@ var (
 	ErrCredencialesInvalidas = errors.New("credenciales inválidas")
 	ErrEmailNoVerificado     = errors.New("verificación de email pendiente")
 	ErrValidacionLogin       = errors.New("validación login fallida")
+	ErrLoginBloqueado        = errors.New("demasiados intentos fallidos")
+	ErrDesafio2FAInvalido    = errors.New("token de desafío inválido o vencido")
+	ErrCodigo2FAInvalido     = errors.New("código de verificación inválido")
 )
@@ -0,0 +1,98 @@
+package utilidades
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	muProxies         sync.RWMutex
+	proxiesConfiables []*net.IPNet
+)
+
+// ConfigurarProxiesConfiables fija los proxies (IPs o redes CIDR) cuyas cabeceras
+// X-Forwarded-For y X-Real-IP se aceptan. Sin proxies configurados sólo cuenta RemoteAddr.
+func ConfigurarProxiesConfiables(lista []string) error {
+	redes := make([]*net.IPNet, 0, len(lista))
+	for _, p := range lista {
+		p = strings.TrimSpace(p)
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return fmt.Errorf("proxy confiable inválido: %q", p)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			p = fmt.Sprintf("%s/%d", p, bits)
+		}
+		_, red, err := net.ParseCIDR(p)
+		if err != nil {
+			return fmt.Errorf("proxy confiable inválido: %q", p)
+		}
+		redes = append(redes, red)
+	}
+
+	muProxies.Lock()
+	defer muProxies.Unlock()
+	proxiesConfiables = redes
+	return nil
+}
+
+// esProxyConfiable indica si la IP pertenece a alguno de los proxies configurados
+func esProxyConfiable(ip net.IP) bool {
+	muProxies.RLock()
+	defer muProxies.RUnlock()
+	for _, red := range proxiesConfiables {
+		if red.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ObtenerIPCliente retorna la IP del cliente. Las cabeceras de proxy las puede escribir
+// cualquiera, así que sólo se leen si la conexión viene de un proxy confiable: en ese caso
+// X-Forwarded-For se recorre de derecha a izquierda salteando los proxies confiables, y la
+// primera IP que no lo es es la del cliente.
+func ObtenerIPCliente(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remota := net.ParseIP(host)
+	if remota == nil || !esProxyConfiable(remota) {
+		return host
+	}
+
+	// 1. X-Forwarded-For: cada proxy agrega a la derecha la IP de quien le habló
+	var saltos []string
+	for _, valor := range r.Header.Values("X-Forwarded-For") {
+		saltos = append(saltos, strings.Split(valor, ",")...)
+	}
+	cliente := host
+	for i := len(saltos) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(saltos[i]))
+		if ip == nil {
+			// Lo que sigue a la izquierda no lo escribió un proxy confiable
+			return cliente
+		}
+		cliente = ip.String()
+		if !esProxyConfiable(ip) {
+			return cliente
+		}
+	}
+	if len(saltos) > 0 {
+		return cliente
+	}
+
+	// 2. X-Real-IP, que algunos proxies usan en lugar de X-Forwarded-For
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return host
+}
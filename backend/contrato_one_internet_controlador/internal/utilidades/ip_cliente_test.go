@@ -0,0 +1,71 @@
+package utilidades
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestObtenerIPCliente(t *testing.T) {
+	if err := ConfigurarProxiesConfiables([]string{"10.0.0.0/8", "192.168.1.5", "fd00::/8"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ConfigurarProxiesConfiables(nil) })
+
+	casos := []struct {
+		nombre   string
+		remota   string
+		xff      []string
+		realIP   string
+		esperada string
+	}{
+		{"sin proxy", "203.0.113.7:51000", nil, "", "203.0.113.7"},
+		{"XFF falsificado sin proxy", "203.0.113.7:51000", []string{"1.2.3.4"}, "", "203.0.113.7"},
+		{"X-Real-IP falsificado sin proxy", "203.0.113.7:51000", nil, "1.2.3.4", "203.0.113.7"},
+		{"detrás de un proxy", "10.0.0.2:443", []string{"203.0.113.7"}, "", "203.0.113.7"},
+		{"XFF falsificado detrás de un proxy", "10.0.0.2:443", []string{"1.2.3.4, 203.0.113.7"}, "", "203.0.113.7"},
+		{"dos proxies confiables", "10.0.0.2:443", []string{"1.2.3.4, 203.0.113.7, 192.168.1.5"}, "", "203.0.113.7"},
+		{"XFF en varias cabeceras", "10.0.0.2:443", []string{"1.2.3.4", "203.0.113.7, 10.9.9.9"}, "", "203.0.113.7"},
+		{"basura a la izquierda", "10.0.0.2:443", []string{"no-es-ip, 203.0.113.7"}, "", "203.0.113.7"},
+		{"basura del último salto", "10.0.0.2:443", []string{"203.0.113.7, no-es-ip"}, "", "10.0.0.2"},
+		{"sólo proxies", "10.0.0.2:443", []string{"10.1.1.1, 10.2.2.2"}, "", "10.1.1.1"},
+		{"X-Real-IP de un proxy", "10.0.0.2:443", nil, "203.0.113.7", "203.0.113.7"},
+		{"XFF tiene prioridad sobre X-Real-IP", "10.0.0.2:443", []string{"203.0.113.7"}, "1.2.3.4", "203.0.113.7"},
+		{"IPv6 detrás de un proxy IPv6", "[fd00::1]:443", []string{"2001:db8::7"}, "", "2001:db8::7"},
+		{"IPv6 sin proxy", "[2001:db8::9]:51000", []string{"1.2.3.4"}, "", "2001:db8::9"},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/auth/login", nil)
+			r.RemoteAddr = c.remota
+			for _, v := range c.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if c.realIP != "" {
+				r.Header.Set("X-Real-IP", c.realIP)
+			}
+			if ip := ObtenerIPCliente(r); ip != c.esperada {
+				t.Errorf("ObtenerIPCliente = %q, se esperaba %q", ip, c.esperada)
+			}
+		})
+	}
+}
+
+func TestObtenerIPClienteSinProxies(t *testing.T) {
+	ConfigurarProxiesConfiables(nil)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "127.0.0.1:40000"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	r.Header.Set("X-Real-IP", "5.6.7.8")
+	if ip := ObtenerIPCliente(r); ip != "127.0.0.1" {
+		t.Errorf("ObtenerIPCliente = %q: sin proxies configurados sólo cuenta la conexión", ip)
+	}
+}
+
+func TestConfigurarProxiesConfiablesInvalido(t *testing.T) {
+	for _, p := range []string{"10.0.0.0/33", "proxy.local", "10.0.0"} {
+		if err := ConfigurarProxiesConfiables([]string{p}); err == nil {
+			t.Errorf("%q debería ser inválido", p)
+		}
+	}
+	ConfigurarProxiesConfiables(nil)
+}
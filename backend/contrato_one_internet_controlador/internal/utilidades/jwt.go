@@ -14,11 +14,15 @@ type ClaimsJWT struct {
 	IDUsuario int      `json:"id_usuario"`
 	IDPersona int      `json:"id_persona"`
 	Roles     []string `json:"roles"`
+	Permisos  []string `json:"permisos,omitempty"` // Los que suman los roles; se actualizan al renovar el token
+	AMR       []string `json:"amr,omitempty"`      // Métodos de autenticación (RFC 8176): "pwd", y "otp" + "mfa" con segundo factor
+	IDSesion  int      `json:"sid,omitempty"`      // Sesión de login del modelo a la que pertenece el token
 	jwt.RegisteredClaims
 }
 
-// GenerarJWT crea un nuevo token JWT para un usuario con id_persona y roles
-func GenerarJWT(idUsuario int, idPersona int, roles []string, cfg *config.Config) (string, error) {
+// GenerarJWT crea un nuevo token JWT para un usuario con id_persona, roles, sus permisos
+// efectivos, los métodos con los que se autenticó la sesión y el id de esa sesión
+func GenerarJWT(idUsuario int, idPersona int, roles []string, permisos []string, amr []string, idSesion int, cfg *config.Config) (string, error) {
 	now := time.Now()
 	expirationTime := now.Add(cfg.JWTExpiration)
 
@@ -26,6 +30,9 @@ func GenerarJWT(idUsuario int, idPersona int, roles []string, cfg *config.Config
 		IDUsuario: idUsuario,
 		IDPersona: idPersona,
 		Roles:     roles,
+		Permisos:  permisos,
+		AMR:       amr,
+		IDSesion:  idSesion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -56,4 +63,24 @@ func (c *ClaimsJWT) HasRole(role string) bool {
         }
     }
     return false
-}
\ No newline at end of file
+}
+
+// TienePermiso indica si alguno de los roles del usuario tiene el permiso
+func (c *ClaimsJWT) TienePermiso(permiso string) bool {
+	for _, p := range c.Permisos {
+		if p == permiso {
+			return true
+		}
+	}
+	return false
+}
+
+// TieneAMR indica si la sesión se autenticó con el método dado
+func (c *ClaimsJWT) TieneAMR(metodo string) bool {
+	for _, m := range c.AMR {
+		if m == metodo {
+			return true
+		}
+	}
+	return false
+}
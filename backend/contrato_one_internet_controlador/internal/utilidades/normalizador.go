@@ -109,3 +109,8 @@ func NormalizeOptionalField(s *string) *string {
 	val = removeAccents(val)
 	return &val
 }
+
+// NormalizarCUIT quita guiones, espacios y puntos de un CUIT/CUIL
+func NormalizarCUIT(cuit string) string {
+	return strings.NewReplacer("-", "", " ", "", ".", "").Replace(strings.TrimSpace(cuit))
+}
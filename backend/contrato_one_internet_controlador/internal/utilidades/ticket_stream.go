@@ -0,0 +1,76 @@
+package utilidades
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_controlador/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// audienciaTicketStream distingue el ticket de streaming de un JWT de acceso
+const audienciaTicketStream = "stream-notificaciones"
+
+// VigenciaTicketStream es lo que dura un ticket: sólo tiene que alcanzar para abrir la conexión
+const VigenciaTicketStream = 30 * time.Second
+
+// claveTicketStream deriva la clave de firma del ticket, distinta de la de los JWT de acceso:
+// un ticket que quede en un log no sirve como token de acceso
+func claveTicketStream(cfg *config.Config) []byte {
+	return []byte(cfg.JWTSecret + "|" + audienciaTicketStream)
+}
+
+// GenerarTicketStream emite el ticket con el que EventSource, que no permite cabeceras, abre
+// la conexión de notificaciones en ?ticket=. Copia los claims del JWT de acceso y lleva un
+// id propio (jti) para poder usarse una sola vez.
+func GenerarTicketStream(acceso *ClaimsJWT, cfg *config.Config) (string, time.Time, error) {
+	aleatorio := make([]byte, 16)
+	if _, err := rand.Read(aleatorio); err != nil {
+		return "", time.Time{}, fmt.Errorf("error generando el id del ticket: %w", err)
+	}
+	now := time.Now()
+	expira := now.Add(VigenciaTicketStream)
+
+	claims := &ClaimsJWT{
+		IDUsuario: acceso.IDUsuario,
+		IDPersona: acceso.IDPersona,
+		Roles:     acceso.Roles,
+		Permisos:  acceso.Permisos,
+		AMR:       acceso.AMR,
+		IDSesion:  acceso.IDSesion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(aleatorio),
+			Audience:  jwt.ClaimStrings{audienciaTicketStream},
+			ExpiresAt: jwt.NewNumericDate(expira),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   acceso.Subject,
+		},
+	}
+
+	ticket, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(claveTicketStream(cfg))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error al firmar el ticket de streaming: %w", err)
+	}
+	return ticket, expira, nil
+}
+
+// ParsearTicketStream verifica firma, audiencia y vigencia del ticket. Que no se haya usado
+// antes lo controla quien lo consume.
+func ParsearTicketStream(ticket string, cfg *config.Config) (*ClaimsJWT, error) {
+	claims := &ClaimsJWT{}
+	token, err := jwt.ParseWithClaims(ticket, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
+		}
+		return claveTicketStream(cfg), nil
+	}, jwt.WithAudience(audienciaTicketStream))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.ID == "" || claims.ExpiresAt == nil || claims.IDPersona <= 0 {
+		return nil, fmt.Errorf("ticket de streaming inválido")
+	}
+	return claims, nil
+}
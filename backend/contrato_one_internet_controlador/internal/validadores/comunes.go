@@ -29,6 +29,31 @@ func ValidarTelefono(telefono string) error {
 	return nil
 }
 
+// ValidarCUIT verifica el formato (11 dígitos sin guiones), el prefijo y el dígito verificador de un CUIT.
+func ValidarCUIT(cuit string) error {
+	if !cuitRegex.MatchString(cuit) {
+		return errors.New("debe contener 11 dígitos sin guiones")
+	}
+	switch cuit[:2] {
+	case "20", "23", "24", "27", "30", "33", "34":
+	default:
+		return errors.New("prefijo de CUIT inválido")
+	}
+
+	pesos := [10]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}
+	suma := 0
+	for i, p := range pesos {
+		suma += int(cuit[i]-'0') * p
+	}
+	verificador := 11 - suma%11
+	if verificador == 11 {
+		verificador = 0
+	}
+	if verificador == 10 || int(cuit[10]-'0') != verificador {
+		return errors.New("dígito verificador de CUIT inválido")
+	}
+	return nil
+}
 
 var (
 	reMayuscula = regexp.MustCompile(`[A-Z]`)
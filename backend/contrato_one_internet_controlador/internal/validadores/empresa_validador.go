@@ -28,16 +28,17 @@ func ValidarEmpresa(e modelos.Empresa) error {
 
 	if strings.TrimSpace(e.Cuit) == "" {
 		errores = append(errores, "el campo 'cuit' es requerido")
-	} else if !cuitRegex.MatchString(e.Cuit) {
-		errores = append(errores, "el campo 'cuit' debe contener 11 dígitos sin guiones")
+	} else if err := ValidarCUIT(e.Cuit); err != nil {
+		errores = append(errores, fmt.Sprintf("el campo 'cuit' es inválido: %v", err))
 	}
 
 	if e.IDTipoEmpresa <= 0 {
 		errores = append(errores, "el campo 'id_tipo_empresa' es requerido y debe ser mayor que 0")
 	}
 
-	if e.IDDireccion <= 0 {
-		errores = append(errores, "el campo 'id_direccion' es requerido y debe ser mayor que 0")
+	// La dirección puede venir aparte (id_direccion u objeto direccion en el alta)
+	if e.IDDireccion < 0 {
+		errores = append(errores, "el campo 'id_direccion' debe ser mayor que 0 si se proporciona")
 	}
 
 	if strings.TrimSpace(e.Telefono) == "" {
@@ -1,73 +1,43 @@
 package validadores
-/*
+
 import (
-	"contrato_one_internet_controlador/internal/modelos"
-	"contrato_one_internet_controlador/internal/utilidades"
 	"fmt"
-)
 
-func ValidarDireccion1(direccion *modelos.Direccion) error {
-	if direccion == nil {
-		return fmt.Errorf("dirección requerida: %w", utilidades.ErrValidacion)
-	}
-	if direccion.Calle == "" || len(direccion.Calle) > 100 {
-		return fmt.Errorf("calle inválida: %w", utilidades.ErrValidacion)
-	}
-	if direccion.Numero == "" || len(direccion.Numero) > 10 {
-		return fmt.Errorf("número inválido: %w", utilidades.ErrValidacion)
-	}
-	if direccion.CodigoPostal == "" || len(direccion.CodigoPostal) > 10 {
-		return fmt.Errorf("código postal inválido: %w", utilidades.ErrValidacion)
-	}
-	if direccion.IDDistrito <= 0 {
-		return fmt.Errorf("distrito requerido: %w", utilidades.ErrValidacion)
-	}
-	if direccion.Piso != nil && len(*direccion.Piso) > 10 {
-		return fmt.Errorf("piso demasiado largo: %w", utilidades.ErrValidacion)
-	}
-	if direccion.Depto != nil && len(*direccion.Depto) > 10 {
-		return fmt.Errorf("depto demasiado largo: %w", utilidades.ErrValidacion)
-	}
-	return nil
-}
+	clientesModelos "contrato_one_internet_controlador/internal/modelos/clientes"
+	"contrato_one_internet_controlador/internal/utilidades"
+)
 
-func ValidarClienteParticular(req modelos.CrearClienteParticularRequest) error {
-	if err := ValidarPersona(req.Persona); err != nil {
-		return err
-	}
-	if err := ValidarDireccion(req.DireccionReal); err != nil {
+// ValidarClienteEmpresa valida la solicitud de alta de una empresa cliente
+func ValidarClienteEmpresa(req clientesModelos.CrearClienteEmpresaRequest) error {
+	if err := ValidarEmpresa(req.Empresa); err != nil {
 		return err
 	}
-	if req.DireccionLegal != nil {
-		if err := ValidarDireccion(req.DireccionLegal); err != nil {
-			return err
-		}
-	}
-	return nil
-}
 
-func ValidarClienteEmpresa(req modelos.CrearClienteEmpresaRequest) error {
-	if err := ValidarPersona(req.Apoderado); err != nil {
-		return err
+	// Exactamente una opción de dirección
+	if req.IDDireccion != nil && req.Direccion != nil {
+		return fmt.Errorf("debe proporcionar id_direccion O direccion, no ambos: %w", utilidades.ErrValidacion)
 	}
-	if err := ValidarDireccion(req.DireccionApoderadoReal); err != nil {
-		return err
+	if req.IDDireccion == nil && req.Direccion == nil {
+		return fmt.Errorf("debe proporcionar id_direccion o direccion: %w", utilidades.ErrValidacion)
+	}
+	if req.IDDireccion != nil && *req.IDDireccion <= 0 {
+		return fmt.Errorf("el campo 'id_direccion' debe ser mayor que 0: %w", utilidades.ErrValidacion)
 	}
-	if req.DireccionApoderadoLegal != nil {
-		if err := ValidarDireccion(req.DireccionApoderadoLegal); err != nil {
-			return err
+	if req.Direccion != nil {
+		if err := ValidarDireccion(*req.Direccion); err != nil {
+			return fmt.Errorf("dirección de la empresa inválida: %v: %w", err, utilidades.ErrValidacion)
 		}
 	}
-	if err := ValidarEmpresa(req.Empresa); err != nil {
-		return err
+
+	if req.IDVinculo <= 0 {
+		return fmt.Errorf("el campo 'id_vinculo' es requerido y debe ser mayor que 0: %w", utilidades.ErrValidacion)
 	}
-	if err := ValidarDireccion(req.DireccionEmpresaReal); err != nil {
-		return err
+	if req.IDCargo != nil && *req.IDCargo <= 0 {
+		return fmt.Errorf("el campo 'id_cargo' debe ser mayor que 0 si se proporciona: %w", utilidades.ErrValidacion)
 	}
-	if req.DireccionEmpresaLegal != nil {
-		if err := ValidarDireccion(req.DireccionEmpresaLegal); err != nil {
-			return err
-		}
+	if req.IDPersonaContacto != nil && *req.IDPersonaContacto <= 0 {
+		return fmt.Errorf("el campo 'id_persona_contacto' debe ser mayor que 0 si se proporciona: %w", utilidades.ErrValidacion)
 	}
+
 	return nil
-}*/
\ No newline at end of file
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"contrato_one_internet_controlador/internal/config"
 	"contrato_one_internet_controlador/internal/handlers/clientes"
@@ -11,6 +13,7 @@ import (
 	"contrato_one_internet_controlador/internal/middleware"
 	"contrato_one_internet_controlador/internal/rutas"
 	"contrato_one_internet_controlador/internal/servicios"
+	"contrato_one_internet_controlador/internal/utilidades"
 	"contrato_one_internet_controlador/internal/utilidades/logger"
 
 	"github.com/joho/godotenv"
@@ -38,9 +41,12 @@ func main() {
 	if err := config.ValidarConfig(cfg); err != nil {
 		logger.Error.Fatalf("Configuración inválida: %v", err)
 	}
+	if err := utilidades.ConfigurarProxiesConfiables(cfg.ProxiesConfiables); err != nil {
+		logger.Error.Fatalf("PROXIES_CONFIABLES inválido: %v", err)
+	}
 
 	// Crear el cliente para el servicio Modelo (se autentica al crearse)
-	modeloClient, err := servicios.NewModeloClient(cfg.ModelURL)
+	modeloClient, err := servicios.NewModeloClient(cfg)
 	if err != nil {
 		logger.Error.Fatalf("No se pudo inicializar el cliente del servicio modelo: %v", err)
 	}
@@ -71,16 +77,28 @@ func main() {
 		cfg.EmailCredentialsTemplatePath,
 		cfg.EmailTokenFirmaTemplatePath,
 		//"mail-token-firma.html",
+		cfg.EmailNotificacionTemplatePath,
 		cfg.LogoLightPath,
 		cfg.LogoDarkPath,
 	) // Servicios
 	personaService := servicios.NewPersonaService(modeloClient, correoService)
 
+	// Despachador de notificaciones por email/SMS/WhatsApp/push
+	despachador, err := servicios.NewDespachadorDesdeConfig(&cfg, modeloClient, correoService)
+	if err != nil {
+		logger.Error.Fatalf("No se pudo inicializar el despachador de notificaciones: %v", err)
+	}
+	go despachador.Iniciar(context.Background())
+
+	// Hub de notificaciones en tiempo real (SSE)
+	hubNotificaciones := servicios.NewHubNotificaciones(modeloClient, time.Duration(cfg.NotifStreamIntervaloSegundos)*time.Second)
+	go hubNotificaciones.Iniciar(context.Background())
+
 	// Handlers
 	personasHandler := clientes.NewPersonasHandler(personaService)
 
 	// Configurar rutas y servidor HTTP, pasando todos los argumentos que espera SetupRutas
-	r := rutas.SetupRutas(clientesHandler, geografiaHandler, authService, &cfg, personasHandler, conexionHandler)
+	r := rutas.SetupRutas(clientesHandler, geografiaHandler, authService, &cfg, personasHandler, conexionHandler, despachador, hubNotificaciones)
 
 	// Aplicar el middleware CORS
 	handlerConCORS := middleware.CORS(r)
@@ -10,4 +10,7 @@ require (
 	golang.org/x/crypto v0.43.0
 )
 
-require filippo.io/edwards25519 v1.1.0 // indirect
\ No newline at end of file
+require filippo.io/edwards25519 v1.1.0 // indirect
+require contrato_one_internet_comun v0.0.0
+
+replace contrato_one_internet_comun => ../contrato_one_internet_comun
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"contrato_one_internet_modelo/internal/utilidades/afip"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// Servidor SOAP fake de AFIP (WSAA + WSFEv1) para probar la facturación electrónica sin
+// salir a homologación. Con AFIP_MODO=fake, apuntar AFIP_WSAA_URL y AFIP_WSFE_URL a
+// http://localhost:<puerto>/ws/services/LoginCms y http://localhost:<puerto>/wsfev1/service.asmx.
+// La numeración se pierde al reiniciarlo.
+func main() {
+	logger.Init("desarrollo")
+
+	puerto := os.Getenv("AFIP_FAKE_PUERTO")
+	if puerto == "" {
+		puerto = "8099"
+	}
+
+	logger.Info.Printf("🚀 Servidor fake de AFIP escuchando en :%s", puerto)
+	if err := http.ListenAndServe(":"+puerto, afip.NuevoServidorFake()); err != nil {
+		logger.Error.Fatalf("No se pudo iniciar el servidor fake de AFIP: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"contrato_one_internet_modelo/internal/config"
+	"contrato_one_internet_modelo/internal/database"
+	"contrato_one_internet_modelo/internal/database/migraciones"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+
+	"github.com/joho/godotenv"
+)
+
+const uso = `Uso: go run . <comando>
+
+Comandos:
+  up                  aplica todas las migraciones pendientes
+  down [n]            revierte las últimas n migraciones (por defecto 1)
+  status              muestra qué migraciones están aplicadas
+  baseline <version>  registra como aplicadas, sin ejecutarlas, las migraciones hasta <version>
+                      (para adoptar las migraciones en una base creada a partir de un dump)
+  accept <version>    registra el checksum actual de una migración aplicada cuyo archivo cambió
+                      (sólo si el cambio no altera el esquema: comentarios, formato)`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, uso)
+		os.Exit(2)
+	}
+
+	// Mismo modo que la importación: no exige la configuración del servidor HTTP
+	os.Setenv("APP_ENV", "import")
+
+	// Cargar el archivo .env manualmente
+	// siempre desde la raíz del proyecto
+	if err := godotenv.Overload("../../../.env"); err != nil {
+		panic("❌ No se pudo cargar el archivo .env: " + err.Error())
+	}
+
+	// Inicializar logger
+	logger.Init("desarrollo")
+
+	// Cargar configuración usando .env
+	appCfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Error.Fatalf("Error al cargar config: %v", err)
+	}
+
+	// Conectar a DB sin verificar el esquema
+	db, err := database.Abrir(appCfg.DBConfig)
+	if err != nil {
+		logger.Error.Fatalf("Error conectando a la base de datos: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "up":
+		// Con la clave de tokens, los que estaban en claro se convierten y siguen valiendo;
+		// sin ella la migración 0019 los invalida
+		pasos := map[int]migraciones.PasoGo{}
+		if appCfg.TokensClaveHash != "" {
+			pasos[migraciones.VersionTokensHash] = migraciones.PasoTokensHash(appCfg.TokensClaveHash)
+		} else {
+			logger.Warn.Println("TOKENS_CLAVE_HASH no está configurada: los tokens guardados en claro se invalidarán")
+		}
+		aplicadas, err := migraciones.Subir(ctx, db, pasos)
+		for _, m := range aplicadas {
+			logger.Info.Printf("✔ Aplicada %04d_%s", m.Version, m.Nombre)
+		}
+		if err != nil {
+			logger.Error.Fatalf("Error aplicando migraciones: %v", err)
+		}
+		if len(aplicadas) == 0 {
+			logger.Info.Println("El esquema ya está al día.")
+		}
+
+	case "down":
+		pasos := 1
+		if len(os.Args) > 2 {
+			if pasos, err = strconv.Atoi(os.Args[2]); err != nil {
+				logger.Error.Fatalf("Cantidad inválida %q", os.Args[2])
+			}
+		}
+		revertidas, err := migraciones.Bajar(ctx, db, pasos)
+		for _, m := range revertidas {
+			logger.Info.Printf("✔ Revertida %04d_%s", m.Version, m.Nombre)
+		}
+		if err != nil {
+			logger.Error.Fatalf("Error revirtiendo migraciones: %v", err)
+		}
+		if len(revertidas) == 0 {
+			logger.Info.Println("No hay migraciones aplicadas.")
+		}
+
+	case "status":
+		estado, err := migraciones.Estado(ctx, db)
+		if err != nil {
+			logger.Error.Fatalf("Error leyendo el estado de las migraciones: %v", err)
+		}
+		for _, e := range estado {
+			switch {
+			case e.Aplicada == nil:
+				fmt.Printf("%04d_%-40s pendiente\n", e.Version, e.Nombre)
+			case e.Modificada:
+				fmt.Printf("%04d_%-40s aplicada %s (¡el archivo cambió después de aplicarse!)\n",
+					e.Version, e.Nombre, e.Aplicada.Format("2006-01-02 15:04:05"))
+			default:
+				fmt.Printf("%04d_%-40s aplicada %s\n", e.Version, e.Nombre, e.Aplicada.Format("2006-01-02 15:04:05"))
+			}
+		}
+
+	case "accept":
+		if len(os.Args) < 3 {
+			logger.Error.Fatalf("accept requiere la versión cuyo checksum registrar")
+		}
+		version, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			logger.Error.Fatalf("Versión inválida %q", os.Args[2])
+		}
+		m, err := migraciones.Aceptar(ctx, db, version)
+		if err != nil {
+			logger.Error.Fatalf("Error aceptando el checksum: %v", err)
+		}
+		logger.Info.Printf("✔ Checksum actualizado para %04d_%s", m.Version, m.Nombre)
+
+	case "baseline":
+		if len(os.Args) < 3 {
+			logger.Error.Fatalf("baseline requiere la versión hasta la que marcar")
+		}
+		hasta, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			logger.Error.Fatalf("Versión inválida %q", os.Args[2])
+		}
+		marcadas, err := migraciones.Marcar(ctx, db, hasta)
+		for _, m := range marcadas {
+			logger.Info.Printf("✔ Marcada %04d_%s", m.Version, m.Nombre)
+		}
+		if err != nil {
+			logger.Error.Fatalf("Error marcando migraciones: %v", err)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, uso)
+		os.Exit(2)
+	}
+}
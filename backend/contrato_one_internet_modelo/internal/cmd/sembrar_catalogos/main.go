@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"contrato_one_internet_modelo/internal/config"
+	"contrato_one_internet_modelo/internal/database"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+
+	// Mismo modo que la importación: no exige la configuración del servidor HTTP
+	os.Setenv("APP_ENV", "import")
+
+	// Cargar el archivo .env manualmente
+	// siempre desde la raíz del proyecto
+	if err := godotenv.Overload("../../../.env"); err != nil {
+		panic("❌ No se pudo cargar el archivo .env: " + err.Error())
+	}
+
+	// Inicializar logger
+	logger.Init("desarrollo")
+
+	// Cargar configuración usando .env
+	appCfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Error.Fatalf("Error al cargar config: %v", err)
+	}
+
+	// Conectar a DB (exige el esquema al día)
+	db := database.ConnectDB(appCfg.DBConfig)
+
+	// Crear las filas de catálogo requeridas que falten
+	cambios, err := servicios.NewCatalogoService(db).Sembrar(context.Background())
+	if err != nil {
+		logger.Error.Fatalf("Error sembrando catálogos: %v", err)
+	}
+
+	for _, c := range cambios {
+		logger.Info.Printf("✔ %s", c)
+	}
+	if len(cambios) == 0 {
+		logger.Info.Println("✔ Los catálogos requeridos ya estaban completos.")
+		return
+	}
+	logger.Info.Printf("✔ Siembra completada: %d cambio(s).", len(cambios))
+}
@@ -14,6 +14,110 @@ type AppConfig struct {
 	InternalJWTSecret string
 	AppEnv            string
 	RefreshTokenDuration  time.Duration 
+	Pagos             PagosConfig
+	ServicioAuth      ServicioAuthConfig
+	TLS               TLSConfig
+	PDF               PDFConfig
+	FirmaDigital      FirmaDigitalConfig
+	Instalaciones     InstalacionesConfig
+	Facturacion       FacturacionConfig
+	Afip              AfipConfig
+	Cuentas           CuentasConfig
+	Planes            PlanesConfig
+	Login             LoginConfig
+	DosFactores       DosFactoresConfig
+	TokensClaveHash   string // Clave del HMAC con el que se guardan los tokens opacos
+}
+
+// FacturacionConfig contiene la configuración de la facturación mensual.
+type FacturacionConfig struct {
+	PuntoVenta      int    // Punto de venta con el que se numeran los comprobantes
+	FacturasPath    string // Directorio base de los PDF de las facturas
+	DiasVencimiento int    // Días entre la emisión y el vencimiento de cada factura
+	CondicionIVAEmisor string // Condición frente al IVA de la empresa: define la letra A/B o C
+}
+
+// CuentasConfig contiene la configuración de la cuenta corriente de los clientes.
+type CuentasConfig struct {
+	DiasGraciaSuspension int // Días de atraso tolerados antes de suspender las conexiones por deuda
+}
+
+// PlanesConfig contiene la configuración de los precios de los planes.
+type PlanesConfig struct {
+	DiasAvisoPrecio int // Anticipación mínima con la que se avisa a los clientes un cambio de precio
+}
+
+// LoginConfig contiene los umbrales del bloqueo de login por intentos fallidos.
+type LoginConfig struct {
+	MaxFallosCuenta int           // Fallos de una cuenta (email) dentro de la ventana que la bloquean
+	MaxFallosIP     int           // Fallos desde una IP, sumando todas las cuentas, que la bloquean
+	VentanaFallos   time.Duration // Los fallos más viejos que esto dejan de contar
+	BloqueoInicial  time.Duration // Duración del primer bloqueo; cada bloqueo siguiente dura el doble
+	BloqueoMaximo   time.Duration // Tope de la duración de un bloqueo
+}
+
+// DosFactoresConfig contiene la configuración de la autenticación en dos pasos.
+type DosFactoresConfig struct {
+	ClaveCifrado        string        // Cifra los secretos TOTP y firma los códigos guardados
+	Emisor              string        // Nombre que muestran las apps autenticadoras
+	VigenciaCodigoEmail time.Duration // Validez del código de respaldo enviado por email
+	MaxIntentosCodigo   int           // Intentos fallidos que invalidan un código por email
+}
+
+// AfipConfig contiene la configuración de la factura electrónica (WSAA + WSFEv1).
+type AfipConfig struct {
+	Modo           string // "deshabilitado", "fake", "homologacion" o "produccion"
+	CUIT           string // CUIT emisor; vacío = el de la empresa operadora
+	PKCS12Path     string // Certificado que AFIP asoció al CUIT (WSAA)
+	PKCS12Password string
+	WSAAURL        string // Vacío: la URL oficial del modo (con "fake", el servidor fake en proceso)
+	WSFEURL        string
+}
+
+// InstalacionesConfig contiene la configuración de las órdenes de instalación.
+type InstalacionesConfig struct {
+	FotosPath string // Directorio base de las fotos tomadas en el domicilio
+}
+
+// PDFConfig contiene la configuración de generación de contratos en PDF.
+type PDFConfig struct {
+	Renderizador  string // "nativo" (Go puro) o "wkhtmltopdf"
+	PlantillaPath string // Plantilla HTML (sólo wkhtmltopdf)
+	ContratosPath string // Directorio base de los PDF original/firmado
+	VerificacionURL string // URL pública de verificación impresa (con QR) en cada contrato
+}
+
+// FirmaDigitalConfig contiene el certificado con el que la empresa firma digitalmente
+// los contratos (PAdES). Sin PKCS12Path los PDF firmados no llevan firma digital.
+type FirmaDigitalConfig struct {
+	PKCS12Path     string // Archivo .p12/.pfx con clave privada y certificado
+	PKCS12Password string
+	Razon          string // /Reason del diccionario de firma
+	Ubicacion      string // /Location del diccionario de firma
+}
+
+// ServicioAuthConfig contiene las credenciales con las que el controlador obtiene su token interno.
+type ServicioAuthConfig struct {
+	ClientID     string
+	ClientSecret string        // Secreto compartido para firmar (HMAC) la solicitud de token
+	TokenTTL     time.Duration // Vigencia de cada token interno emitido
+}
+
+// TLSConfig habilita HTTPS y, opcionalmente, mTLS (certificado de cliente obligatorio).
+type TLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string // Si se define, sólo se aceptan clientes con certificado firmado por esta CA
+}
+
+// PagosConfig contiene la configuración de la pasarela de pagos.
+type PagosConfig struct {
+	Proveedor       string // "mercadopago" o "fake"
+	WebhookSecret   string // Secreto para validar la firma x-signature de los webhooks
+	AccessToken     string // Access token de Mercado Pago
+	APIURL          string
+	NotificacionURL string // URL pública del webhook (expuesta por el controlador)
+	RetornoURL      string // URL del frontend a la que vuelve el cliente tras pagar
 }
 
 // DBConfig contiene los parámetros de conexión para la base de datos.
@@ -39,6 +143,73 @@ func LoadConfig() (AppConfig, error) {
 		InternalJWTSecret: getEnv("INTERNAL_JWT_SECRET", ""), // Dejar vacío por defecto para forzar su configuración
 		AppEnv:            getEnv("APP_ENV", "desarrollo"),
 		RefreshTokenDuration: time.Duration(getEnvInt("REFRESH_TOKEN_DAYS", 30)) * 24 * time.Hour, 
+		Pagos: PagosConfig{
+			Proveedor:       getEnv("PAGO_PROVEEDOR", "fake"),
+			WebhookSecret:   getEnv("PAGO_WEBHOOK_SECRET", ""),
+			AccessToken:     getEnv("MP_ACCESS_TOKEN", ""),
+			APIURL:          getEnv("MP_API_URL", "https://api.mercadopago.com"),
+			NotificacionURL: getEnv("PAGO_NOTIFICACION_URL", ""),
+			RetornoURL:      getEnv("PAGO_RETORNO_URL", ""),
+		},
+		ServicioAuth: ServicioAuthConfig{
+			ClientID:     getEnv("INTERNAL_CLIENT_ID", "controlador"),
+			ClientSecret: getEnv("INTERNAL_CLIENT_SECRET", ""),
+			TokenTTL:     time.Duration(getEnvInt("INTERNAL_TOKEN_TTL_MINUTES", 15)) * time.Minute,
+		},
+		PDF: PDFConfig{
+			Renderizador:  getEnv("PDF_RENDERIZADOR", "nativo"),
+			PlantillaPath: getEnv("PDF_PLANTILLA_PATH", "/var/www/html/contratos/backend/contrato_one_internet_controlador/contratos.html"),
+			ContratosPath: getEnv("CONTRATOS_PATH", "/var/www/contracts"),
+			VerificacionURL: getEnv("CONTRATO_VERIFICACION_URL", "http://localhost:8080/v1/verificar-contrato"),
+		},
+		FirmaDigital: FirmaDigitalConfig{
+			PKCS12Path:     getEnv("FIRMA_PKCS12_PATH", ""),
+			PKCS12Password: getEnv("FIRMA_PKCS12_PASSWORD", ""),
+			Razon:          getEnv("FIRMA_RAZON", "Solicitud de servicio firmada por el cliente"),
+			Ubicacion:      getEnv("FIRMA_UBICACION", "Rivadavia, Mendoza, Argentina"),
+		},
+		Instalaciones: InstalacionesConfig{
+			FotosPath: getEnv("INSTALACION_FOTOS_PATH", "/var/www/instalaciones"),
+		},
+		Facturacion: FacturacionConfig{
+			PuntoVenta:      getEnvInt("FACTURACION_PUNTO_VENTA", 1),
+			FacturasPath:    getEnv("FACTURAS_PATH", "/var/www/facturas"),
+			DiasVencimiento: getEnvInt("FACTURACION_DIAS_VENCIMIENTO", 10),
+			CondicionIVAEmisor: getEnv("FACTURACION_CONDICION_IVA_EMISOR", "Responsable Inscripto"),
+		},
+		Afip: AfipConfig{
+			Modo:           getEnv("AFIP_MODO", "deshabilitado"),
+			CUIT:           getEnv("AFIP_CUIT", ""),
+			PKCS12Path:     getEnv("AFIP_PKCS12_PATH", ""),
+			PKCS12Password: getEnv("AFIP_PKCS12_PASSWORD", ""),
+			WSAAURL:        getEnv("AFIP_WSAA_URL", ""),
+			WSFEURL:        getEnv("AFIP_WSFE_URL", ""),
+		},
+		Cuentas: CuentasConfig{
+			DiasGraciaSuspension: getEnvInt("CUENTA_DIAS_GRACIA_SUSPENSION", 15),
+		},
+		Planes: PlanesConfig{
+			DiasAvisoPrecio: getEnvInt("PLANES_DIAS_AVISO_PRECIO", 30),
+		},
+		Login: LoginConfig{
+			MaxFallosCuenta: getEnvInt("LOGIN_MAX_FALLOS_CUENTA", 5),
+			MaxFallosIP:     getEnvInt("LOGIN_MAX_FALLOS_IP", 20),
+			VentanaFallos:   time.Duration(getEnvInt("LOGIN_VENTANA_FALLOS_MINUTOS", 15)) * time.Minute,
+			BloqueoInicial:  time.Duration(getEnvInt("LOGIN_BLOQUEO_MINUTOS", 5)) * time.Minute,
+			BloqueoMaximo:   time.Duration(getEnvInt("LOGIN_BLOQUEO_MAXIMO_MINUTOS", 1440)) * time.Minute,
+		},
+		TokensClaveHash: getEnv("TOKENS_CLAVE_HASH", ""),
+		DosFactores: DosFactoresConfig{
+			ClaveCifrado:        getEnv("DOS_FACTORES_CLAVE", ""),
+			Emisor:              getEnv("DOS_FACTORES_EMISOR", "ONE Internet"),
+			VigenciaCodigoEmail: time.Duration(getEnvInt("DOS_FACTORES_CODIGO_EMAIL_MINUTOS", 10)) * time.Minute,
+			MaxIntentosCodigo:   getEnvInt("DOS_FACTORES_MAX_INTENTOS_CODIGO", 5),
+		},
+		TLS: TLSConfig{
+			CertFile:     getEnv("TLS_CERT_FILE", ""),
+			KeyFile:      getEnv("TLS_KEY_FILE", ""),
+			ClientCAFile: getEnv("TLS_CLIENT_CA_FILE", ""),
+		},
 	}
 
 	// Validar campos obligatorios
@@ -58,6 +229,121 @@ func LoadConfig() (AppConfig, error) {
 	if cfg.AppEnv != "import" && cfg.InternalJWTSecret == "" {
 		return cfg, errors.New("INTERNAL_JWT_SECRET no puede estar vacío")
 	}
+	if cfg.AppEnv != "import" {
+		if cfg.ServicioAuth.ClientID == "" {
+			return cfg, errors.New("INTERNAL_CLIENT_ID no puede estar vacío")
+		}
+		if len(cfg.ServicioAuth.ClientSecret) < 32 {
+			return cfg, errors.New("INTERNAL_CLIENT_SECRET debe tener al menos 32 caracteres")
+		}
+		if cfg.ServicioAuth.ClientSecret == cfg.InternalJWTSecret {
+			return cfg, errors.New("INTERNAL_CLIENT_SECRET debe ser distinto de INTERNAL_JWT_SECRET")
+		}
+		if cfg.ServicioAuth.TokenTTL <= 0 {
+			return cfg, errors.New("INTERNAL_TOKEN_TTL_MINUTES debe ser mayor a 0")
+		}
+		if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+			return cfg, errors.New("TLS_CERT_FILE y TLS_KEY_FILE deben definirse juntos")
+		}
+		if cfg.TLS.ClientCAFile != "" && cfg.TLS.CertFile == "" {
+			return cfg, errors.New("TLS_CLIENT_CA_FILE requiere TLS_CERT_FILE y TLS_KEY_FILE")
+		}
+
+		if cfg.PDF.Renderizador != "nativo" && cfg.PDF.Renderizador != "wkhtmltopdf" {
+			return cfg, errors.New("PDF_RENDERIZADOR debe ser 'nativo' o 'wkhtmltopdf'")
+		}
+		if cfg.Facturacion.PuntoVenta < 1 || cfg.Facturacion.PuntoVenta > 9999 {
+			return cfg, errors.New("FACTURACION_PUNTO_VENTA debe estar entre 1 y 9999")
+		}
+		if cfg.Facturacion.DiasVencimiento < 0 {
+			return cfg, errors.New("FACTURACION_DIAS_VENCIMIENTO no puede ser negativo")
+		}
+		switch cfg.Facturacion.CondicionIVAEmisor {
+		case "Responsable Inscripto", "Monotributista", "Exento":
+		default:
+			return cfg, errors.New("FACTURACION_CONDICION_IVA_EMISOR debe ser 'Responsable Inscripto', 'Monotributista' o 'Exento'")
+		}
+		if cfg.Cuentas.DiasGraciaSuspension < 0 {
+			return cfg, errors.New("CUENTA_DIAS_GRACIA_SUSPENSION no puede ser negativo")
+		}
+		if cfg.Planes.DiasAvisoPrecio < 0 {
+			return cfg, errors.New("PLANES_DIAS_AVISO_PRECIO no puede ser negativo")
+		}
+		if cfg.Login.MaxFallosCuenta < 1 || cfg.Login.MaxFallosIP < 1 {
+			return cfg, errors.New("LOGIN_MAX_FALLOS_CUENTA y LOGIN_MAX_FALLOS_IP deben ser al menos 1")
+		}
+		if cfg.Login.VentanaFallos <= 0 || cfg.Login.BloqueoInicial <= 0 {
+			return cfg, errors.New("LOGIN_VENTANA_FALLOS_MINUTOS y LOGIN_BLOQUEO_MINUTOS deben ser mayores a 0")
+		}
+		if cfg.Login.BloqueoMaximo < cfg.Login.BloqueoInicial {
+			return cfg, errors.New("LOGIN_BLOQUEO_MAXIMO_MINUTOS no puede ser menor que LOGIN_BLOQUEO_MINUTOS")
+		}
+		if cfg.DosFactores.ClaveCifrado == "" {
+			if cfg.AppEnv != "desarrollo" {
+				return cfg, errors.New("DOS_FACTORES_CLAVE es obligatoria fuera de desarrollo")
+			}
+			// Sólo en desarrollo se deriva del secreto interno para no exigir otra variable:
+			// rotar INTERNAL_JWT_SECRET deja ilegibles los secretos TOTP guardados
+			cfg.DosFactores.ClaveCifrado = cfg.InternalJWTSecret + "|dos-factores"
+		} else if len(cfg.DosFactores.ClaveCifrado) < 32 {
+			return cfg, errors.New("DOS_FACTORES_CLAVE debe tener al menos 32 caracteres")
+		}
+		if cfg.TokensClaveHash == "" {
+			if cfg.AppEnv != "desarrollo" {
+				return cfg, errors.New("TOKENS_CLAVE_HASH es obligatoria fuera de desarrollo")
+			}
+			// Igual que DOS_FACTORES_CLAVE: rotar INTERNAL_JWT_SECRET invalida los tokens guardados
+			cfg.TokensClaveHash = cfg.InternalJWTSecret + "|tokens"
+		} else if len(cfg.TokensClaveHash) < 32 {
+			return cfg, errors.New("TOKENS_CLAVE_HASH debe tener al menos 32 caracteres")
+		}
+		if cfg.DosFactores.VigenciaCodigoEmail <= 0 || cfg.DosFactores.MaxIntentosCodigo < 1 {
+			return cfg, errors.New("DOS_FACTORES_CODIGO_EMAIL_MINUTOS y DOS_FACTORES_MAX_INTENTOS_CODIGO deben ser mayores a 0")
+		}
+		switch cfg.Afip.Modo {
+		case "deshabilitado":
+		case "fake":
+			if cfg.AppEnv == "produccion" {
+				return cfg, errors.New("AFIP_MODO=fake no está permitido en produccion")
+			}
+			if (cfg.Afip.WSAAURL == "") != (cfg.Afip.WSFEURL == "") {
+				return cfg, errors.New("AFIP_WSAA_URL y AFIP_WSFE_URL deben definirse juntas")
+			}
+		case "homologacion", "produccion":
+			if cfg.Afip.PKCS12Path == "" {
+				return cfg, errors.New("AFIP_PKCS12_PATH no puede estar vacío con AFIP_MODO=" + cfg.Afip.Modo)
+			}
+		default:
+			return cfg, errors.New("AFIP_MODO debe ser 'deshabilitado', 'fake', 'homologacion' o 'produccion'")
+		}
+		if cfg.AppEnv == "produccion" && cfg.FirmaDigital.PKCS12Path == "" {
+			return cfg, errors.New("FIRMA_PKCS12_PATH es obligatorio en produccion")
+		}
+
+		switch cfg.Pagos.Proveedor {
+		case "mercadopago":
+			if cfg.Pagos.AccessToken == "" {
+				return cfg, errors.New("MP_ACCESS_TOKEN no puede estar vacío con PAGO_PROVEEDOR=mercadopago")
+			}
+			if cfg.Pagos.WebhookSecret == "" {
+				return cfg, errors.New("PAGO_WEBHOOK_SECRET no puede estar vacío con PAGO_PROVEEDOR=mercadopago")
+			}
+		case "fake":
+			if cfg.AppEnv == "produccion" {
+				return cfg, errors.New("PAGO_PROVEEDOR=fake no está permitido en produccion")
+			}
+			if cfg.Pagos.WebhookSecret == "" {
+				if cfg.AppEnv != "desarrollo" {
+					return cfg, errors.New("PAGO_WEBHOOK_SECRET es obligatorio fuera de desarrollo")
+				}
+				// En desarrollo el proveedor fake firma sus propios webhooks con una clave
+				// derivada (nunca con el secreto interno en sí)
+				cfg.Pagos.WebhookSecret = cfg.InternalJWTSecret + "|webhook-pago"
+			}
+		default:
+			return cfg, errors.New("PAGO_PROVEEDOR debe ser 'mercadopago' o 'fake'")
+		}
+	}
 
 	return cfg, nil
 }
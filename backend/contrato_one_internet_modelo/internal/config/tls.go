@@ -0,0 +1,34 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// HabilitadoTLS indica si el servidor debe escuchar por HTTPS
+func (t TLSConfig) HabilitadoTLS() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// ConfigServidorTLS arma la configuración TLS del servidor. Con ClientCAFile definido
+// exige y verifica el certificado del cliente (mTLS).
+func (t TLSConfig) ConfigServidorTLS() (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if t.ClientCAFile != "" {
+		pem, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer TLS_CLIENT_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS_CLIENT_CA_FILE no contiene certificados PEM válidos")
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsCfg, nil
+}
@@ -1,13 +1,16 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"contrato_one_internet_modelo/internal/config"
+	"contrato_one_internet_modelo/internal/database/migraciones"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -19,20 +22,21 @@ var (
 
 // ConnectDB inicializa y devuelve una instancia singleton de la conexión a la base de datos.
 // Utiliza sync.Once para asegurar que la inicialización ocurra solo una vez.
+// Se niega a continuar si el esquema tiene migraciones pendientes.
 func ConnectDB(cfg config.DBConfig) *sql.DB {
 	once.Do(func() {
-		// DSN (Data Source Name) con configuración de zona horaria para Argentina.
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=America%%2FArgentina%%2FBuenos_Aires",
-			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
-
-		db, err := sql.Open("mysql", dsn)
+		db, err := Abrir(cfg)
 		if err != nil {
 			log.Fatalf("Error fatal al abrir la conexión a la base de datos: %v", err)
 		}
 
-		// Verificar que la conexión es válida antes de continuar.
-		if err := db.Ping(); err != nil {
-			log.Fatalf("No se pudo hacer ping a la base de datos: %v", err)
+		// Verificar que el esquema esté al día con las migraciones embebidas.
+		if err := migraciones.VerificarAlDia(context.Background(), db); err != nil {
+			var modificadas migraciones.ErrMigracionesModificadas
+			if errors.As(err, &modificadas) {
+				log.Fatalf("%v. Restaure los archivos o, si el cambio no altera el esquema, registre el checksum con: cd internal/cmd/migraciones && go run . accept <version>", err)
+			}
+			log.Fatalf("%v. Ejecute las migraciones con: cd internal/cmd/migraciones && go run . up", err)
 		}
 
 		dbInstance = db
@@ -42,6 +46,26 @@ func ConnectDB(cfg config.DBConfig) *sql.DB {
 	return dbInstance
 }
 
+// Abrir crea una conexión nueva (no singleton) sin verificar el esquema.
+// La usa el comando de migraciones, que debe poder operar sobre una base desactualizada.
+func Abrir(cfg config.DBConfig) (*sql.DB, error) {
+	// DSN (Data Source Name) con configuración de zona horaria para Argentina.
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=America%%2FArgentina%%2FBuenos_Aires",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar que la conexión es válida antes de continuar.
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("no se pudo hacer ping a la base de datos: %w", err)
+	}
+	return db, nil
+}
+
 // Cargar la localización de Argentina una sola vez.
 var argLocation, _ = time.LoadLocation("America/Argentina/Buenos_Aires")
 
@@ -0,0 +1,510 @@
+// Package migraciones versiona el esquema de la base de datos del modelo.
+//
+// Cada migración es un par de archivos SQL embebidos en el binario:
+//
+//	sql/NNNN_nombre.up.sql    aplica el cambio
+//	sql/NNNN_nombre.down.sql  lo revierte
+//
+// Las versiones aplicadas se registran en la tabla schema_migrations. Las sentencias
+// se ejecutan de a una (el DSN no habilita multiStatements), por lo que los triggers
+// deben escribirse sin BEGIN ... END.
+package migraciones
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//go:embed sql/*.sql
+var archivosSQL embed.FS
+
+// nombreLock es el lock de MySQL (GET_LOCK) que impide que dos procesos migren a la vez
+const nombreLock = "schema_migrations"
+
+// esperaLockSegundos es cuánto se espera a que otro proceso libere el lock
+const esperaLockSegundos = 60
+
+// errTablaInexistente es el código de MySQL para "Table doesn't exist"
+const errTablaInexistente = 1146
+
+var patronArchivo = regexp.MustCompile(`^(\d{4})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+const crearTablaVersiones = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version  INT UNSIGNED NOT NULL,
+		nombre   VARCHAR(150) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		aplicada DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (version)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+`
+
+// Migracion es un cambio de esquema embebido en el binario
+type Migracion struct {
+	Version  int
+	Nombre   string
+	Subida   string
+	Bajada   string
+	Checksum string // sha256 del archivo .up.sql
+}
+
+// PasoGo completa una migración con lo que no se puede hacer en SQL (por ejemplo, calcular
+// un HMAC con una clave que no está en la base). Corre en la conexión de la migración,
+// después de su .up.sql y antes de registrar la versión, y debe poder repetirse.
+type PasoGo func(ctx context.Context, conn *sql.Conn) error
+
+// EstadoMigracion indica si una migración está aplicada en la base
+type EstadoMigracion struct {
+	Migracion
+	Aplicada   *time.Time
+	Modificada bool // el .up.sql cambió después de aplicarse
+}
+
+// ErrEsquemaDesactualizado indica que la base tiene migraciones pendientes
+type ErrEsquemaDesactualizado struct {
+	Pendientes []Migracion
+}
+
+func (e ErrEsquemaDesactualizado) Error() string {
+	versiones := make([]string, len(e.Pendientes))
+	for i, m := range e.Pendientes {
+		versiones[i] = fmt.Sprintf("%04d_%s", m.Version, m.Nombre)
+	}
+	return fmt.Sprintf("el esquema de la base de datos está desactualizado: %d migración(es) pendiente(s): %s",
+		len(e.Pendientes), strings.Join(versiones, ", "))
+}
+
+// ErrMigracionesModificadas indica migraciones aplicadas cuyo .up.sql ya no coincide con
+// el checksum registrado: el esquema de la base puede no ser el que describen los archivos
+type ErrMigracionesModificadas struct {
+	Modificadas []Migracion
+}
+
+func (e ErrMigracionesModificadas) Error() string {
+	versiones := make([]string, len(e.Modificadas))
+	for i, m := range e.Modificadas {
+		versiones[i] = fmt.Sprintf("%04d_%s", m.Version, m.Nombre)
+	}
+	return fmt.Sprintf("%d migración(es) aplicada(s) cambiaron después de aplicarse: %s",
+		len(e.Modificadas), strings.Join(versiones, ", "))
+}
+
+// versionAplicada es una fila de schema_migrations
+type versionAplicada struct {
+	nombre   string
+	checksum string
+	aplicada time.Time
+}
+
+// Cargar lee las migraciones embebidas ordenadas por versión
+func Cargar() ([]Migracion, error) {
+	entradas, err := fs.ReadDir(archivosSQL, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo migraciones embebidas: %w", err)
+	}
+
+	porVersion := make(map[int]*Migracion)
+	for _, entrada := range entradas {
+		partes := patronArchivo.FindStringSubmatch(entrada.Name())
+		if partes == nil {
+			return nil, fmt.Errorf("nombre de migración inválido %q (se espera NNNN_nombre.up.sql o .down.sql)", entrada.Name())
+		}
+		version, _ := strconv.Atoi(partes[1])
+		contenido, err := archivosSQL.ReadFile(path.Join("sql", entrada.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo %s: %w", entrada.Name(), err)
+		}
+
+		m, ok := porVersion[version]
+		if !ok {
+			m = &Migracion{Version: version, Nombre: partes[2]}
+			porVersion[version] = m
+		} else if m.Nombre != partes[2] {
+			return nil, fmt.Errorf("la versión %04d tiene dos nombres: %s y %s", version, m.Nombre, partes[2])
+		}
+
+		if partes[3] == "up" {
+			suma := sha256.Sum256(contenido)
+			m.Subida, m.Checksum = string(contenido), hex.EncodeToString(suma[:])
+		} else {
+			m.Bajada = string(contenido)
+		}
+	}
+
+	migraciones := make([]Migracion, 0, len(porVersion))
+	for _, m := range porVersion {
+		if m.Checksum == "" || m.Bajada == "" {
+			return nil, fmt.Errorf("la migración %04d_%s debe tener .up.sql y .down.sql", m.Version, m.Nombre)
+		}
+		migraciones = append(migraciones, *m)
+	}
+	sort.Slice(migraciones, func(i, j int) bool { return migraciones[i].Version < migraciones[j].Version })
+	return migraciones, nil
+}
+
+// Subir aplica en orden todas las migraciones pendientes y devuelve las aplicadas. pasos
+// asocia a una versión el PasoGo que la completa.
+func Subir(ctx context.Context, db *sql.DB, pasos map[int]PasoGo) ([]Migracion, error) {
+	migraciones, err := Cargar()
+	if err != nil {
+		return nil, err
+	}
+
+	var aplicadas []Migracion
+	err = conLock(ctx, db, func(conn *sql.Conn) error {
+		versiones, err := leerVersiones(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migraciones {
+			if _, ok := versiones[m.Version]; ok {
+				continue
+			}
+			// 1. Ejecutar el .up.sql
+			if err := ejecutar(ctx, conn, m, m.Subida); err != nil {
+				return err
+			}
+			// 2. Completarla en Go si hace falta
+			if paso, ok := pasos[m.Version]; ok {
+				if err := paso(ctx, conn); err != nil {
+					return fmt.Errorf("migración %04d_%s, paso en Go: %w", m.Version, m.Nombre, err)
+				}
+			}
+			// 3. Registrar la versión
+			if _, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, nombre, checksum) VALUES (?, ?, ?)`,
+				m.Version, m.Nombre, m.Checksum); err != nil {
+				return fmt.Errorf("error registrando migración %04d: %w", m.Version, err)
+			}
+			aplicadas = append(aplicadas, m)
+		}
+		return nil
+	})
+	return aplicadas, err
+}
+
+// Bajar revierte las últimas pasos migraciones aplicadas y devuelve las revertidas
+func Bajar(ctx context.Context, db *sql.DB, pasos int) ([]Migracion, error) {
+	if pasos <= 0 {
+		return nil, errors.New("la cantidad de migraciones a revertir debe ser mayor a 0")
+	}
+	migraciones, err := Cargar()
+	if err != nil {
+		return nil, err
+	}
+	porVersion := make(map[int]Migracion, len(migraciones))
+	for _, m := range migraciones {
+		porVersion[m.Version] = m
+	}
+
+	var revertidas []Migracion
+	err = conLock(ctx, db, func(conn *sql.Conn) error {
+		versiones, err := leerVersiones(ctx, conn)
+		if err != nil {
+			return err
+		}
+		aplicadas := make([]int, 0, len(versiones))
+		for v := range versiones {
+			aplicadas = append(aplicadas, v)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(aplicadas)))
+
+		for i := 0; i < pasos && i < len(aplicadas); i++ {
+			m, ok := porVersion[aplicadas[i]]
+			if !ok {
+				return fmt.Errorf("la versión %04d (%s) está aplicada pero este binario no la conoce",
+					aplicadas[i], versiones[aplicadas[i]].nombre)
+			}
+			// 1. Ejecutar el .down.sql
+			if err := ejecutar(ctx, conn, m, m.Bajada); err != nil {
+				return err
+			}
+			// 2. Quitar la versión
+			if _, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+				return fmt.Errorf("error quitando migración %04d: %w", m.Version, err)
+			}
+			revertidas = append(revertidas, m)
+		}
+		return nil
+	})
+	return revertidas, err
+}
+
+// Marcar registra como aplicadas, sin ejecutarlas, las migraciones hasta la versión indicada.
+// Sirve para adoptar las migraciones en una base creada a mano a partir de un dump.
+func Marcar(ctx context.Context, db *sql.DB, hasta int) ([]Migracion, error) {
+	migraciones, err := Cargar()
+	if err != nil {
+		return nil, err
+	}
+
+	var marcadas []Migracion
+	err = conLock(ctx, db, func(conn *sql.Conn) error {
+		versiones, err := leerVersiones(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migraciones {
+			if m.Version > hasta {
+				break
+			}
+			if _, ok := versiones[m.Version]; ok {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, nombre, checksum) VALUES (?, ?, ?)`,
+				m.Version, m.Nombre, m.Checksum); err != nil {
+				return fmt.Errorf("error registrando migración %04d: %w", m.Version, err)
+			}
+			marcadas = append(marcadas, m)
+		}
+		return nil
+	})
+	return marcadas, err
+}
+
+// Estado devuelve cada migración embebida indicando si está aplicada
+func Estado(ctx context.Context, db *sql.DB) ([]EstadoMigracion, error) {
+	migraciones, err := Cargar()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	versiones, err := leerVersiones(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	estado := make([]EstadoMigracion, len(migraciones))
+	for i, m := range migraciones {
+		estado[i].Migracion = m
+		if v, ok := versiones[m.Version]; ok {
+			aplicada := v.aplicada
+			estado[i].Aplicada = &aplicada
+			estado[i].Modificada = v.checksum != m.Checksum
+		}
+	}
+	return estado, nil
+}
+
+// Aceptar registra el checksum actual del .up.sql de una migración aplicada. Es para cuando
+// el cambio del archivo fue deliberado y no altera el esquema (comentarios, formato).
+func Aceptar(ctx context.Context, db *sql.DB, version int) (*Migracion, error) {
+	migraciones, err := Cargar()
+	if err != nil {
+		return nil, err
+	}
+	var m *Migracion
+	for i := range migraciones {
+		if migraciones[i].Version == version {
+			m = &migraciones[i]
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("no existe la migración %04d", version)
+	}
+
+	err = conLock(ctx, db, func(conn *sql.Conn) error {
+		versiones, err := leerVersiones(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if _, ok := versiones[m.Version]; !ok {
+			return fmt.Errorf("la migración %04d_%s no está aplicada", m.Version, m.Nombre)
+		}
+		if _, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET checksum = ? WHERE version = ?`, m.Checksum, m.Version); err != nil {
+			return fmt.Errorf("error actualizando checksum de %04d: %w", m.Version, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VerificarAlDia devuelve ErrEsquemaDesactualizado si queda alguna migración sin aplicar y
+// ErrMigracionesModificadas si alguna aplicada cambió después de aplicarse
+func VerificarAlDia(ctx context.Context, db *sql.DB) error {
+	estado, err := Estado(ctx, db)
+	if err != nil {
+		return fmt.Errorf("error leyendo schema_migrations: %w", err)
+	}
+	return verificarEstado(estado)
+}
+
+// verificarEstado informa primero los archivos modificados: aplicar las pendientes sobre un
+// esquema que no coincide con los archivos no lo arregla
+func verificarEstado(estado []EstadoMigracion) error {
+	var pendientes, modificadas []Migracion
+	for _, e := range estado {
+		switch {
+		case e.Aplicada == nil:
+			pendientes = append(pendientes, e.Migracion)
+		case e.Modificada:
+			modificadas = append(modificadas, e.Migracion)
+		}
+	}
+	if len(modificadas) > 0 {
+		return ErrMigracionesModificadas{Modificadas: modificadas}
+	}
+	if len(pendientes) > 0 {
+		return ErrEsquemaDesactualizado{Pendientes: pendientes}
+	}
+	return nil
+}
+
+// leerVersiones devuelve las versiones registradas en schema_migrations.
+// Si la tabla todavía no existe, no hay ninguna aplicada.
+func leerVersiones(ctx context.Context, conn *sql.Conn) (map[int]versionAplicada, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, nombre, checksum, aplicada FROM schema_migrations`)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errTablaInexistente {
+			return map[int]versionAplicada{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	versiones := make(map[int]versionAplicada)
+	for rows.Next() {
+		var version int
+		var v versionAplicada
+		if err := rows.Scan(&version, &v.nombre, &v.checksum, &v.aplicada); err != nil {
+			return nil, err
+		}
+		versiones[version] = v
+	}
+	return versiones, rows.Err()
+}
+
+// conLock ejecuta fn en una conexión dedicada que retiene el lock de migraciones.
+// GET_LOCK pertenece a la sesión, por eso todo debe correr sobre la misma conexión.
+func conLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var obtenido sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, nombreLock, esperaLockSegundos).Scan(&obtenido); err != nil {
+		return fmt.Errorf("error obteniendo lock de migraciones: %w", err)
+	}
+	if obtenido.Int64 != 1 {
+		return errors.New("otro proceso está aplicando migraciones; reintente más tarde")
+	}
+	defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, nombreLock)
+
+	if _, err := conn.ExecContext(ctx, crearTablaVersiones); err != nil {
+		return fmt.Errorf("error creando schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// ejecutar corre las sentencias del archivo una por una.
+// MySQL confirma implícitamente cada DDL, así que ante un error la migración puede
+// quedar aplicada a medias: se informa la sentencia que falló para corregirla a mano.
+func ejecutar(ctx context.Context, conn *sql.Conn, m Migracion, contenido string) error {
+	for i, sentencia := range separarSentencias(contenido) {
+		if _, err := conn.ExecContext(ctx, sentencia); err != nil {
+			return fmt.Errorf("migración %04d_%s, sentencia %d: %w\n%s", m.Version, m.Nombre, i+1, err, sentencia)
+		}
+	}
+	return nil
+}
+
+// esInicioComentario indica si s empieza con "--" seguido de un espacio o fin de línea,
+// que es como MySQL reconoce un comentario de línea
+func esInicioComentario(s string) bool {
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return len(s) == 2 || strings.ContainsRune(" \t\r\n", rune(s[2]))
+}
+
+// separarSentencias divide el archivo en sentencias por ';', ignorando los ';' dentro
+// de cadenas, identificadores entre backticks y comentarios. Descarta los comentarios.
+func separarSentencias(contenido string) []string {
+	var sentencias []string
+	var actual strings.Builder
+
+	agregar := func() {
+		if s := strings.TrimSpace(actual.String()); s != "" {
+			sentencias = append(sentencias, s)
+		}
+		actual.Reset()
+	}
+
+	for i := 0; i < len(contenido); i++ {
+		c := contenido[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Copiar hasta la comilla de cierre (respetando escapes y comillas dobladas)
+			j := i + 1
+			for j < len(contenido) {
+				if contenido[j] == '\\' && c != '`' {
+					j += 2
+					continue
+				}
+				if contenido[j] == c {
+					if j+1 < len(contenido) && contenido[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(contenido) {
+				j = len(contenido) - 1
+			}
+			actual.WriteString(contenido[i : j+1])
+			i = j
+		case c == '-' && esInicioComentario(contenido[i:]), c == '#':
+			// Comentario de línea
+			fin := strings.IndexByte(contenido[i:], '\n')
+			if fin < 0 {
+				i = len(contenido)
+			} else {
+				i += fin
+				actual.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(contenido[i:], "/*"):
+			// Comentario de bloque
+			fin := strings.Index(contenido[i+2:], "*/")
+			if fin < 0 {
+				i = len(contenido)
+			} else {
+				i += fin + 3
+				actual.WriteByte(' ')
+			}
+		case c == ';':
+			agregar()
+		default:
+			actual.WriteByte(c)
+		}
+	}
+	agregar()
+	return sentencias
+}
@@ -0,0 +1,117 @@
+package migraciones
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSepararSentencias(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		contenido string
+		esperadas []string
+	}{
+		{
+			nombre:    "varias sentencias",
+			contenido: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n\nDROP TABLE c",
+			esperadas: []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)", "DROP TABLE c"},
+		},
+		{
+			nombre:    "sentencias vacías",
+			contenido: ";;\n  ;\nSELECT 1;;",
+			esperadas: []string{"SELECT 1"},
+		},
+		{
+			nombre:    "punto y coma entre comillas simples",
+			contenido: "INSERT INTO t VALUES ('a;b');SELECT 2;",
+			esperadas: []string{"INSERT INTO t VALUES ('a;b')", "SELECT 2"},
+		},
+		{
+			nombre:    "punto y coma entre comillas dobles y backticks",
+			contenido: "INSERT INTO `x;y` VALUES (\"c;d\");SELECT 3",
+			esperadas: []string{"INSERT INTO `x;y` VALUES (\"c;d\")", "SELECT 3"},
+		},
+		{
+			nombre:    "comillas escapadas y dobladas",
+			contenido: `INSERT INTO t VALUES ('it\'s;', 'o''k;');SELECT 4`,
+			esperadas: []string{`INSERT INTO t VALUES ('it\'s;', 'o''k;')`, "SELECT 4"},
+		},
+		{
+			nombre:    "comentarios de línea",
+			contenido: "-- crea la tabla; con punto y coma\nCREATE TABLE a (id INT); # otro; comentario\nSELECT 5;\n-- fin",
+			esperadas: []string{"CREATE TABLE a (id INT)", "SELECT 5"},
+		},
+		{
+			nombre:    "doble guión sin espacio no es comentario",
+			contenido: "SELECT 6--1;SELECT 7",
+			esperadas: []string{"SELECT 6--1", "SELECT 7"},
+		},
+		{
+			nombre:    "comentario de bloque",
+			contenido: "/* cabecera; varias\nlíneas */CREATE TABLE a (id INT /* la clave; */);SELECT 8",
+			esperadas: []string{"CREATE TABLE a (id INT  )", "SELECT 8"},
+		},
+		{
+			nombre:    "comentario dentro de una cadena",
+			contenido: "INSERT INTO t VALUES ('-- no; es comentario', '/* tampoco; */');",
+			esperadas: []string{"INSERT INTO t VALUES ('-- no; es comentario', '/* tampoco; */')"},
+		},
+		{
+			nombre:    "trigger de una sola sentencia",
+			contenido: "CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.codigo = CONCAT('A;', NEW.id);\nDROP TRIGGER IF EXISTS tr2;",
+			esperadas: []string{"CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.codigo = CONCAT('A;', NEW.id)", "DROP TRIGGER IF EXISTS tr2"},
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := separarSentencias(c.contenido); !reflect.DeepEqual(got, c.esperadas) {
+				t.Errorf("separarSentencias(%q)\n obtuvo   %q\n esperaba %q", c.contenido, got, c.esperadas)
+			}
+		})
+	}
+}
+
+func TestVerificarEstado(t *testing.T) {
+	aplicada := time.Now()
+	m := func(version int) Migracion { return Migracion{Version: version, Nombre: "prueba"} }
+
+	al := EstadoMigracion{Migracion: m(1), Aplicada: &aplicada}
+	pendiente := EstadoMigracion{Migracion: m(2)}
+	modificada := EstadoMigracion{Migracion: m(3), Aplicada: &aplicada, Modificada: true}
+
+	if err := verificarEstado([]EstadoMigracion{al}); err != nil {
+		t.Errorf("esquema al día: %v", err)
+	}
+
+	var desactualizado ErrEsquemaDesactualizado
+	if err := verificarEstado([]EstadoMigracion{al, pendiente}); !errors.As(err, &desactualizado) || len(desactualizado.Pendientes) != 1 {
+		t.Errorf("con una pendiente: %v", err)
+	}
+
+	var modificadas ErrMigracionesModificadas
+	if err := verificarEstado([]EstadoMigracion{al, modificada}); !errors.As(err, &modificadas) || modificadas.Modificadas[0].Version != 3 {
+		t.Errorf("con una modificada: %v", err)
+	}
+	// El cambio de un archivo aplicado tiene prioridad sobre las pendientes
+	if err := verificarEstado([]EstadoMigracion{al, modificada, pendiente}); !errors.As(err, &modificadas) {
+		t.Errorf("con modificada y pendiente: %v", err)
+	}
+}
+
+// Las migraciones embebidas deben cargarse y separarse sin sentencias vacías
+func TestCargarMigracionesEmbebidas(t *testing.T) {
+	migraciones, err := Cargar()
+	if err != nil {
+		t.Fatalf("Cargar: %v", err)
+	}
+	for i, m := range migraciones {
+		if i > 0 && m.Version <= migraciones[i-1].Version {
+			t.Errorf("versiones desordenadas: %d después de %d", m.Version, migraciones[i-1].Version)
+		}
+		if len(separarSentencias(m.Subida)) == 0 || len(separarSentencias(m.Bajada)) == 0 {
+			t.Errorf("%04d_%s: sin sentencias", m.Version, m.Nombre)
+		}
+	}
+}
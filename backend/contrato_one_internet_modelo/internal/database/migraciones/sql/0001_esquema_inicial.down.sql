@@ -0,0 +1,35 @@
+DROP TRIGGER IF EXISTS trg_contrato_creador;
+DROP TRIGGER IF EXISTS trg_conexion_creador;
+DROP TRIGGER IF EXISTS trg_empresa_creador;
+DROP TRIGGER IF EXISTS trg_usuario_creador;
+DROP TRIGGER IF EXISTS trg_persona_creador;
+DROP TRIGGER IF EXISTS trg_direccion_creador;
+
+DROP TABLE IF EXISTS notificacion;
+DROP TABLE IF EXISTS contrato_firma;
+DROP TABLE IF EXISTS contrato;
+DROP TABLE IF EXISTS conexion;
+DROP TABLE IF EXISTS plan;
+DROP TABLE IF EXISTS persona_vinculo_empresa;
+DROP TABLE IF EXISTS empresa;
+DROP TABLE IF EXISTS reset_password_token;
+DROP TABLE IF EXISTS email_verificacion_token;
+DROP TABLE IF EXISTS refresh_token;
+DROP TABLE IF EXISTS rol_permiso;
+DROP TABLE IF EXISTS usuario_rol;
+DROP TABLE IF EXISTS permiso;
+DROP TABLE IF EXISTS rol;
+DROP TABLE IF EXISTS usuario_login_log;
+DROP TABLE IF EXISTS usuario;
+DROP TABLE IF EXISTS persona;
+DROP TABLE IF EXISTS estado_contrato;
+DROP TABLE IF EXISTS estado_conexion;
+DROP TABLE IF EXISTS tipo_plan;
+DROP TABLE IF EXISTS vinculo;
+DROP TABLE IF EXISTS cargo;
+DROP TABLE IF EXISTS tipo_empresa;
+DROP TABLE IF EXISTS tipo_iva;
+DROP TABLE IF EXISTS direccion;
+DROP TABLE IF EXISTS distrito;
+DROP TABLE IF EXISTS departamento;
+DROP TABLE IF EXISTS provincia;
@@ -0,0 +1,487 @@
+-- Esquema base del modelo.
+-- Usa CREATE TABLE IF NOT EXISTS para que una base creada a partir de un dump
+-- anterior a las migraciones pueda adoptarlas sin recrear sus tablas.
+
+-- ----------------------------------------------------------------------------
+-- Geografía
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS provincia (
+    id_provincia  INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre        VARCHAR(100) NOT NULL,
+    creado        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado       DATETIME NULL,
+    PRIMARY KEY (id_provincia),
+    UNIQUE KEY uq_provincia_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS departamento (
+    id_departamento INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_provincia    INT UNSIGNED NOT NULL,
+    nombre          VARCHAR(100) NOT NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado         DATETIME NULL,
+    PRIMARY KEY (id_departamento),
+    UNIQUE KEY uq_departamento_provincia_nombre (id_provincia, nombre),
+    CONSTRAINT fk_departamento_provincia FOREIGN KEY (id_provincia) REFERENCES provincia (id_provincia)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS distrito (
+    id_distrito     INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_departamento INT UNSIGNED NOT NULL,
+    nombre          VARCHAR(150) NOT NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado         DATETIME NULL,
+    PRIMARY KEY (id_distrito),
+    UNIQUE KEY uq_distrito_departamento_nombre (id_departamento, nombre),
+    CONSTRAINT fk_distrito_departamento FOREIGN KEY (id_departamento) REFERENCES departamento (id_departamento)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS direccion (
+    id_direccion       INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    calle              VARCHAR(150) NOT NULL,
+    numero             VARCHAR(20) NOT NULL,
+    codigo_postal      VARCHAR(10) NOT NULL,
+    piso               VARCHAR(10) NULL,
+    depto              VARCHAR(10) NULL,
+    id_distrito        INT UNSIGNED NOT NULL,
+    id_usuario_creador INT UNSIGNED NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado            DATETIME NULL,
+    PRIMARY KEY (id_direccion),
+    KEY idx_direccion_distrito (id_distrito),
+    CONSTRAINT fk_direccion_distrito FOREIGN KEY (id_distrito) REFERENCES distrito (id_distrito)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------------------------------------------------------
+-- Catálogos
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS tipo_iva (
+    id_tipo_iva        INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tipo_iva           VARCHAR(100) NOT NULL,
+    id_usuario_creador INT UNSIGNED NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado            DATETIME NULL,
+    PRIMARY KEY (id_tipo_iva),
+    UNIQUE KEY uq_tipo_iva_tipo_iva (tipo_iva)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS tipo_empresa (
+    id_tipo_empresa INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre          VARCHAR(100) NOT NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado         DATETIME NULL,
+    PRIMARY KEY (id_tipo_empresa),
+    UNIQUE KEY uq_tipo_empresa_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS cargo (
+    id_cargo      INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre        VARCHAR(100) NOT NULL,
+    creado        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado       DATETIME NULL,
+    PRIMARY KEY (id_cargo),
+    UNIQUE KEY uq_cargo_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS vinculo (
+    id_vinculo     INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre_vinculo VARCHAR(100) NOT NULL,
+    descripcion    VARCHAR(255) NULL,
+    creado         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado        DATETIME NULL,
+    PRIMARY KEY (id_vinculo),
+    UNIQUE KEY uq_vinculo_nombre_vinculo (nombre_vinculo)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS tipo_plan (
+    id_tipo_plan  INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre        VARCHAR(100) NOT NULL,
+    descripcion   VARCHAR(255) NULL,
+    creado        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado       DATETIME NULL,
+    PRIMARY KEY (id_tipo_plan),
+    UNIQUE KEY uq_tipo_plan_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS estado_conexion (
+    id_estado_conexion INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre             VARCHAR(100) NOT NULL,
+    descripcion        VARCHAR(255) NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado            DATETIME NULL,
+    PRIMARY KEY (id_estado_conexion),
+    UNIQUE KEY uq_estado_conexion_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS estado_contrato (
+    id_estado_contrato INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre             VARCHAR(100) NOT NULL,
+    descripcion        VARCHAR(255) NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado            DATETIME NULL,
+    PRIMARY KEY (id_estado_contrato),
+    UNIQUE KEY uq_estado_contrato_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------------------------------------------------------
+-- Personas, usuarios y seguridad
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS persona (
+    id_persona           INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre               VARCHAR(100) NOT NULL,
+    apellido             VARCHAR(100) NOT NULL,
+    sexo                 VARCHAR(20) NOT NULL,
+    dni                  VARCHAR(15) NOT NULL,
+    cuil                 VARCHAR(15) NULL,
+    fecha_nacimiento     DATE NULL,
+    id_direccion         INT UNSIGNED NULL,
+    distrito_nombre      VARCHAR(150) NULL,
+    departamento_nombre  VARCHAR(100) NULL,
+    provincia_nombre     VARCHAR(100) NULL,
+    telefono             VARCHAR(30) NOT NULL,
+    telefono_alternativo VARCHAR(30) NULL,
+    email                VARCHAR(150) NOT NULL,
+    id_tipo_iva          INT UNSIGNED NULL,
+    id_usuario_creador   INT UNSIGNED NULL,
+    creado               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado              DATETIME NULL,
+    PRIMARY KEY (id_persona),
+    UNIQUE KEY uq_persona_dni (dni),
+    UNIQUE KEY uq_persona_cuil (cuil),
+    UNIQUE KEY uq_persona_email (email),
+    KEY idx_persona_direccion (id_direccion),
+    CONSTRAINT fk_persona_direccion FOREIGN KEY (id_direccion) REFERENCES direccion (id_direccion),
+    CONSTRAINT fk_persona_tipo_iva FOREIGN KEY (id_tipo_iva) REFERENCES tipo_iva (id_tipo_iva)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS usuario (
+    id_usuario            INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    email                 VARCHAR(150) NOT NULL,
+    password_hash         VARCHAR(255) NOT NULL,
+    id_persona            INT UNSIGNED NOT NULL,
+    email_verificado      TINYINT(1) NOT NULL DEFAULT 0,
+    requiere_verificacion TINYINT(1) NOT NULL DEFAULT 1,
+    ultimo_login          DATETIME NULL,
+    ultimo_ip             VARCHAR(45) NULL,
+    ultimo_user_agent     VARCHAR(255) NULL,
+    id_usuario_creador    INT UNSIGNED NULL,
+    creado                DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado               DATETIME NULL,
+    PRIMARY KEY (id_usuario),
+    UNIQUE KEY uq_usuario_email (email),
+    UNIQUE KEY uq_usuario_persona (id_persona),
+    CONSTRAINT fk_usuario_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS usuario_login_log (
+    id         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario INT UNSIGNED NOT NULL,
+    ip         VARCHAR(45) NULL,
+    user_agent VARCHAR(255) NULL,
+    exito      TINYINT(1) NOT NULL,
+    mensaje    VARCHAR(255) NULL,
+    fecha      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_usuario_login_log_usuario_fecha (id_usuario, fecha),
+    CONSTRAINT fk_usuario_login_log_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS rol (
+    id_rol        INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre        VARCHAR(50) NOT NULL,
+    descripcion   VARCHAR(255) NULL,
+    creado        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado       DATETIME NULL,
+    PRIMARY KEY (id_rol),
+    UNIQUE KEY uq_rol_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS permiso (
+    id_permiso    INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre        VARCHAR(100) NOT NULL,
+    descripcion   VARCHAR(255) NULL,
+    creado        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado       DATETIME NULL,
+    PRIMARY KEY (id_permiso),
+    UNIQUE KEY uq_permiso_nombre (nombre)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS usuario_rol (
+    id_usuario INT UNSIGNED NOT NULL,
+    id_rol     INT UNSIGNED NOT NULL,
+    creado     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_usuario, id_rol),
+    KEY idx_usuario_rol_rol (id_rol),
+    CONSTRAINT fk_usuario_rol_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario),
+    CONSTRAINT fk_usuario_rol_rol FOREIGN KEY (id_rol) REFERENCES rol (id_rol)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS rol_permiso (
+    id_rol     INT UNSIGNED NOT NULL,
+    id_permiso INT UNSIGNED NOT NULL,
+    creado     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    borrado    DATETIME NULL,
+    PRIMARY KEY (id_rol, id_permiso),
+    KEY idx_rol_permiso_permiso (id_permiso),
+    CONSTRAINT fk_rol_permiso_rol FOREIGN KEY (id_rol) REFERENCES rol (id_rol),
+    CONSTRAINT fk_rol_permiso_permiso FOREIGN KEY (id_permiso) REFERENCES permiso (id_permiso)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS refresh_token (
+    id         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario INT UNSIGNED NOT NULL,
+    token      VARCHAR(255) NOT NULL,
+    expiracion DATETIME NOT NULL,
+    creado     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revocado   TINYINT(1) NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_refresh_token_token (token),
+    KEY idx_refresh_token_usuario (id_usuario),
+    CONSTRAINT fk_refresh_token_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS email_verificacion_token (
+    id         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario INT UNSIGNED NOT NULL,
+    token      VARCHAR(255) NOT NULL,
+    expiracion DATETIME NOT NULL,
+    creado     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    usado      TINYINT(1) NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_email_verificacion_token_token (token),
+    KEY idx_email_verificacion_token_usuario (id_usuario),
+    CONSTRAINT fk_email_verificacion_token_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS reset_password_token (
+    id         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario INT UNSIGNED NOT NULL,
+    token      VARCHAR(255) NOT NULL,
+    expiracion DATETIME NOT NULL,
+    creado     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    usado      TINYINT(1) NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_reset_password_token_token (token),
+    KEY idx_reset_password_token_usuario (id_usuario),
+    CONSTRAINT fk_reset_password_token_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------------------------------------------------------
+-- Empresas
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS empresa (
+    id_empresa           INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre_comercial     VARCHAR(150) NOT NULL,
+    razon_social         VARCHAR(150) NOT NULL,
+    cuit                 VARCHAR(15) NOT NULL,
+    id_tipo_empresa      INT UNSIGNED NOT NULL,
+    id_direccion         INT UNSIGNED NULL,
+    distrito_nombre      VARCHAR(150) NULL,
+    departamento_nombre  VARCHAR(100) NULL,
+    provincia_nombre     VARCHAR(100) NULL,
+    telefono             VARCHAR(30) NULL,
+    telefono_alternativo VARCHAR(30) NULL,
+    email                VARCHAR(150) NULL,
+    id_tipo_iva          INT UNSIGNED NULL,
+    id_usuario_creador   INT UNSIGNED NULL,
+    creado               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado              DATETIME NULL,
+    PRIMARY KEY (id_empresa),
+    UNIQUE KEY uq_empresa_cuit (cuit),
+    CONSTRAINT fk_empresa_tipo_empresa FOREIGN KEY (id_tipo_empresa) REFERENCES tipo_empresa (id_tipo_empresa),
+    CONSTRAINT fk_empresa_direccion FOREIGN KEY (id_direccion) REFERENCES direccion (id_direccion),
+    CONSTRAINT fk_empresa_tipo_iva FOREIGN KEY (id_tipo_iva) REFERENCES tipo_iva (id_tipo_iva)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS persona_vinculo_empresa (
+    id_persona    INT UNSIGNED NOT NULL,
+    id_vinculo    INT UNSIGNED NOT NULL,
+    id_empresa    INT UNSIGNED NOT NULL,
+    id_cargo      INT UNSIGNED NULL,
+    creado        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado       DATETIME NULL,
+    PRIMARY KEY (id_persona, id_vinculo, id_empresa),
+    KEY idx_persona_vinculo_empresa_empresa (id_empresa),
+    CONSTRAINT fk_pve_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona),
+    CONSTRAINT fk_pve_vinculo FOREIGN KEY (id_vinculo) REFERENCES vinculo (id_vinculo),
+    CONSTRAINT fk_pve_empresa FOREIGN KEY (id_empresa) REFERENCES empresa (id_empresa),
+    CONSTRAINT fk_pve_cargo FOREIGN KEY (id_cargo) REFERENCES cargo (id_cargo)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------------------------------------------------------
+-- Planes, conexiones y contratos
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS plan (
+    id_plan            INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_tipo_plan       INT UNSIGNED NOT NULL,
+    nombre             VARCHAR(100) NOT NULL,
+    velocidad_mbps     INT UNSIGNED NOT NULL,
+    precio             DECIMAL(12,2) NOT NULL,
+    descripcion        VARCHAR(255) NULL,
+    fecha_inicio       DATE NULL,
+    fecha_fin          DATE NULL,
+    id_usuario_creador INT UNSIGNED NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado            DATETIME NULL,
+    PRIMARY KEY (id_plan),
+    CONSTRAINT fk_plan_tipo_plan FOREIGN KEY (id_tipo_plan) REFERENCES tipo_plan (id_tipo_plan)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS conexion (
+    id_conexion         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nro_conexion        INT UNSIGNED NOT NULL,
+    id_persona          INT UNSIGNED NOT NULL,
+    id_instalador       INT UNSIGNED NULL,
+    id_plan             INT UNSIGNED NOT NULL,
+    id_direccion        INT UNSIGNED NULL,
+    distrito_nombre     VARCHAR(150) NULL,
+    departamento_nombre VARCHAR(100) NULL,
+    provincia_nombre    VARCHAR(100) NULL,
+    latitud             DECIMAL(10,7) NULL,
+    longitud            DECIMAL(10,7) NULL,
+    tipo_conexion       VARCHAR(50) NOT NULL,
+    id_estado_conexion  INT UNSIGNED NOT NULL,
+    fecha_instalacion   DATE NULL,
+    fecha_baja          DATE NULL,
+    observaciones       TEXT NULL,
+    id_usuario_creador  INT UNSIGNED NULL,
+    vlanA               INT NULL,
+    detalleNodo         VARCHAR(100) NULL,
+    puertoOLT           INT NULL,
+    creado              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado             DATETIME NULL,
+    PRIMARY KEY (id_conexion),
+    UNIQUE KEY uq_conexion_nro_conexion (nro_conexion),
+    KEY idx_conexion_persona (id_persona),
+    KEY idx_conexion_estado (id_estado_conexion),
+    CONSTRAINT fk_conexion_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona),
+    CONSTRAINT fk_conexion_plan FOREIGN KEY (id_plan) REFERENCES plan (id_plan),
+    CONSTRAINT fk_conexion_direccion FOREIGN KEY (id_direccion) REFERENCES direccion (id_direccion),
+    CONSTRAINT fk_conexion_estado FOREIGN KEY (id_estado_conexion) REFERENCES estado_conexion (id_estado_conexion)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS contrato (
+    id_contrato        INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_persona         INT UNSIGNED NOT NULL,
+    id_vinculo         INT UNSIGNED NULL,
+    id_empresa         INT UNSIGNED NULL,
+    id_conexion        INT UNSIGNED NOT NULL,
+    id_plan            INT UNSIGNED NOT NULL,
+    costo_instalacion  DECIMAL(12,2) NULL,
+    fecha_inicio       DATE NULL,
+    fecha_fin          DATE NULL,
+    id_estado_contrato INT UNSIGNED NOT NULL,
+    id_usuario_creador INT UNSIGNED NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado            DATETIME NULL,
+    PRIMARY KEY (id_contrato),
+    KEY idx_contrato_persona (id_persona),
+    KEY idx_contrato_conexion (id_conexion),
+    CONSTRAINT fk_contrato_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona),
+    CONSTRAINT fk_contrato_vinculo FOREIGN KEY (id_vinculo) REFERENCES vinculo (id_vinculo),
+    CONSTRAINT fk_contrato_empresa FOREIGN KEY (id_empresa) REFERENCES empresa (id_empresa),
+    CONSTRAINT fk_contrato_conexion FOREIGN KEY (id_conexion) REFERENCES conexion (id_conexion),
+    CONSTRAINT fk_contrato_plan FOREIGN KEY (id_plan) REFERENCES plan (id_plan),
+    CONSTRAINT fk_contrato_estado FOREIGN KEY (id_estado_contrato) REFERENCES estado_contrato (id_estado_contrato)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS contrato_firma (
+    id_contrato_firma INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_contrato       INT UNSIGNED NOT NULL,
+    pdf_original_path VARCHAR(255) NOT NULL,
+    pdf_firmado_path  VARCHAR(255) NULL,
+    firma_path        VARCHAR(255) NULL,
+    hash_original     CHAR(64) NOT NULL,
+    hash_firmado      CHAR(64) NULL,
+    metodo_firma      VARCHAR(30) NOT NULL,
+    token_firma       VARCHAR(255) NULL,
+    token_expira      DATETIME NULL,
+    intentos_token    INT UNSIGNED NOT NULL DEFAULT 0,
+    pdf_generado      DATETIME NULL,
+    token_enviado     DATETIME NULL,
+    firmado           TINYINT(1) NOT NULL DEFAULT 0,
+    fecha_firma       DATETIME NULL,
+    ip_firma          VARCHAR(45) NULL,
+    user_agent        VARCHAR(255) NULL,
+    creado            DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_contrato_firma),
+    KEY idx_contrato_firma_contrato (id_contrato),
+    CONSTRAINT fk_contrato_firma_contrato FOREIGN KEY (id_contrato) REFERENCES contrato (id_contrato)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------------------------------------------------------
+-- Notificaciones
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS notificacion (
+    id_notificacion     INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_persona_receptor INT UNSIGNED NOT NULL,
+    tipo                VARCHAR(50) NOT NULL,
+    titulo              VARCHAR(150) NOT NULL,
+    mensaje             TEXT NOT NULL,
+    leido               TINYINT(1) NOT NULL DEFAULT 0,
+    rol_destino         VARCHAR(50) NULL,
+    id_conexion         INT UNSIGNED NULL,
+    id_contrato         INT UNSIGNED NULL,
+    id_pago             INT UNSIGNED NULL,
+    observacion         TEXT NULL,
+    creado              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_notificacion),
+    KEY idx_notificacion_receptor_leido (id_persona_receptor, leido),
+    CONSTRAINT fk_notificacion_persona FOREIGN KEY (id_persona_receptor) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------------------------------------------------------
+-- Auditoría: los servicios fijan @session_user_id antes de insertar y estos
+-- triggers completan id_usuario_creador cuando el INSERT no lo informa.
+-- ----------------------------------------------------------------------------
+
+DROP TRIGGER IF EXISTS trg_direccion_creador;
+CREATE TRIGGER trg_direccion_creador BEFORE INSERT ON direccion FOR EACH ROW
+    SET NEW.id_usuario_creador = COALESCE(NEW.id_usuario_creador, @session_user_id);
+
+DROP TRIGGER IF EXISTS trg_persona_creador;
+CREATE TRIGGER trg_persona_creador BEFORE INSERT ON persona FOR EACH ROW
+    SET NEW.id_usuario_creador = COALESCE(NEW.id_usuario_creador, @session_user_id);
+
+DROP TRIGGER IF EXISTS trg_usuario_creador;
+CREATE TRIGGER trg_usuario_creador BEFORE INSERT ON usuario FOR EACH ROW
+    SET NEW.id_usuario_creador = COALESCE(NEW.id_usuario_creador, @session_user_id);
+
+DROP TRIGGER IF EXISTS trg_empresa_creador;
+CREATE TRIGGER trg_empresa_creador BEFORE INSERT ON empresa FOR EACH ROW
+    SET NEW.id_usuario_creador = COALESCE(NEW.id_usuario_creador, @session_user_id);
+
+DROP TRIGGER IF EXISTS trg_conexion_creador;
+CREATE TRIGGER trg_conexion_creador BEFORE INSERT ON conexion FOR EACH ROW
+    SET NEW.id_usuario_creador = COALESCE(NEW.id_usuario_creador, @session_user_id);
+
+DROP TRIGGER IF EXISTS trg_contrato_creador;
+CREATE TRIGGER trg_contrato_creador BEFORE INSERT ON contrato FOR EACH ROW
+    SET NEW.id_usuario_creador = COALESCE(NEW.id_usuario_creador, @session_user_id);
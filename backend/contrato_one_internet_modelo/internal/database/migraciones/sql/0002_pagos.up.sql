@@ -0,0 +1,24 @@
+-- Pagos de contratos a través de la pasarela (Mercado Pago o proveedor fake en desarrollo)
+
+CREATE TABLE pago (
+    id_pago          INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_contrato      INT UNSIGNED NOT NULL,
+    id_persona       INT UNSIGNED NOT NULL,
+    monto            DECIMAL(12,2) NOT NULL,
+    moneda           CHAR(3) NOT NULL DEFAULT 'ARS',
+    concepto         VARCHAR(100) NOT NULL,
+    proveedor        VARCHAR(30) NOT NULL,
+    id_preferencia   VARCHAR(100) NULL,
+    id_transaccion   VARCHAR(100) NULL,
+    url_checkout     VARCHAR(500) NULL,
+    estado           VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    detalle_estado   VARCHAR(255) NULL,
+    fecha_aprobacion DATETIME NULL,
+    creado           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_pago),
+    KEY idx_pago_contrato (id_contrato),
+    KEY idx_pago_persona (id_persona),
+    CONSTRAINT fk_pago_contrato FOREIGN KEY (id_contrato) REFERENCES contrato (id_contrato),
+    CONSTRAINT fk_pago_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
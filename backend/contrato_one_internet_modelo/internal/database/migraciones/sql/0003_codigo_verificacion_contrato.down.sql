@@ -0,0 +1,3 @@
+ALTER TABLE contrato_firma
+    DROP INDEX uq_contrato_firma_codigo_verificacion,
+    DROP COLUMN codigo_verificacion;
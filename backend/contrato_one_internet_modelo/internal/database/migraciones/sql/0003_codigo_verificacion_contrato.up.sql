@@ -0,0 +1,5 @@
+-- Código público impreso (con QR) en cada PDF para verificar el contrato sin iniciar sesión
+
+ALTER TABLE contrato_firma
+    ADD COLUMN codigo_verificacion VARCHAR(20) NULL AFTER user_agent,
+    ADD UNIQUE KEY uq_contrato_firma_codigo_verificacion (codigo_verificacion);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS push_suscripcion;
+DROP TABLE IF EXISTS notificacion_plantilla;
+DROP TABLE IF EXISTS notificacion_preferencia;
+DROP TABLE IF EXISTS notificacion_entrega;
@@ -0,0 +1,59 @@
+-- Entrega de notificaciones por email, SMS, WhatsApp y push con preferencias y plantillas
+
+CREATE TABLE notificacion_entrega (
+    id_entrega      INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_notificacion INT UNSIGNED NOT NULL,
+    id_persona      INT UNSIGNED NOT NULL,
+    canal           VARCHAR(20) NOT NULL,
+    estado          VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    intentos        INT UNSIGNED NOT NULL DEFAULT 0,
+    proximo_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    id_proveedor    VARCHAR(150) NULL,
+    ultimo_error    VARCHAR(500) NULL,
+    enviado         DATETIME NULL,
+    reserva         CHAR(32) NULL,
+    reservado_hasta DATETIME NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_entrega),
+    KEY idx_notificacion_entrega_pendientes (estado, proximo_intento),
+    KEY idx_notificacion_entrega_reserva (reserva),
+    KEY idx_notificacion_entrega_notificacion (id_notificacion, id_persona),
+    CONSTRAINT fk_notificacion_entrega_notificacion FOREIGN KEY (id_notificacion) REFERENCES notificacion (id_notificacion) ON DELETE CASCADE,
+    CONSTRAINT fk_notificacion_entrega_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE notificacion_preferencia (
+    id_persona    INT UNSIGNED NOT NULL,
+    canal         VARCHAR(20) NOT NULL,
+    tipo          VARCHAR(50) NOT NULL,
+    habilitado    TINYINT(1) NOT NULL DEFAULT 1,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_persona, canal, tipo),
+    CONSTRAINT fk_notificacion_preferencia_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE notificacion_plantilla (
+    id_plantilla  INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tipo          VARCHAR(50) NOT NULL,
+    canal         VARCHAR(20) NOT NULL,
+    asunto        VARCHAR(200) NOT NULL,
+    cuerpo        TEXT NOT NULL,
+    activo        TINYINT(1) NOT NULL DEFAULT 1,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_plantilla),
+    UNIQUE KEY uq_notificacion_plantilla_tipo_canal (tipo, canal)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE push_suscripcion (
+    id_suscripcion INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_persona     INT UNSIGNED NOT NULL,
+    endpoint       VARCHAR(700) NOT NULL,
+    p256dh         VARCHAR(200) NOT NULL,
+    auth           VARCHAR(100) NOT NULL,
+    creado         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_suscripcion),
+    UNIQUE KEY uq_push_suscripcion_endpoint (endpoint),
+    KEY idx_push_suscripcion_persona (id_persona),
+    CONSTRAINT fk_push_suscripcion_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
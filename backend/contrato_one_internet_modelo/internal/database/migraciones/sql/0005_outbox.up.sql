@@ -0,0 +1,19 @@
+-- Outbox transaccional: los eventos de dominio se registran junto al cambio de estado
+-- y un despachador con reintentos los convierte en notificaciones
+
+CREATE TABLE outbox_evento (
+    id_evento       INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tipo            VARCHAR(50) NOT NULL,
+    payload         JSON NOT NULL,
+    estado          VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    intentos        INT UNSIGNED NOT NULL DEFAULT 0,
+    proximo_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_error    VARCHAR(500) NULL,
+    reserva         CHAR(32) NULL,
+    reservado_hasta DATETIME NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    procesado       DATETIME NULL,
+    PRIMARY KEY (id_evento),
+    KEY idx_outbox_evento_pendientes (estado, proximo_intento),
+    KEY idx_outbox_evento_reserva (reserva)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,8 @@
+-- Los avisos a un rol no tienen receptor: no se pueden conservar con la columna NOT NULL
+DELETE FROM notificacion WHERE id_persona_receptor IS NULL;
+
+DROP TABLE IF EXISTS notificacion_persona;
+
+ALTER TABLE notificacion
+    DROP KEY idx_notificacion_rol_destino,
+    MODIFY id_persona_receptor INT UNSIGNED NOT NULL;
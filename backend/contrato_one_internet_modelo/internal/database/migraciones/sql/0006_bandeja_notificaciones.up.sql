@@ -0,0 +1,18 @@
+-- Bandeja de notificaciones: los avisos a un rol se guardan una sola vez (sin receptor)
+-- y el estado leído/archivado/borrado de cada destinatario vive en notificacion_persona
+
+ALTER TABLE notificacion
+    MODIFY id_persona_receptor INT UNSIGNED NULL,
+    ADD KEY idx_notificacion_rol_destino (rol_destino);
+
+CREATE TABLE notificacion_persona (
+    id_notificacion INT UNSIGNED NOT NULL,
+    id_persona      INT UNSIGNED NOT NULL,
+    leido           DATETIME NULL,
+    archivado       DATETIME NULL,
+    borrado         DATETIME NULL,
+    PRIMARY KEY (id_notificacion, id_persona),
+    KEY idx_notificacion_persona_persona (id_persona),
+    CONSTRAINT fk_notificacion_persona_notificacion FOREIGN KEY (id_notificacion) REFERENCES notificacion (id_notificacion) ON DELETE CASCADE,
+    CONSTRAINT fk_notificacion_persona_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
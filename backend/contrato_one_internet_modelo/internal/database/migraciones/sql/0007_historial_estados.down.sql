@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS contrato_estado_historial;
+DROP TABLE IF EXISTS conexion_estado_historial;
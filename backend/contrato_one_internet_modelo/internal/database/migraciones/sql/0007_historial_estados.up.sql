@@ -0,0 +1,43 @@
+-- Historial de estados de conexiones y contratos: cada transición de la máquina de
+-- estados deja una fila con el estado anterior, el nuevo y el usuario que la provocó
+
+CREATE TABLE conexion_estado_historial (
+    id_historial       INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_conexion        INT UNSIGNED NOT NULL,
+    id_estado_anterior INT UNSIGNED NULL,
+    id_estado_nuevo    INT UNSIGNED NOT NULL,
+    id_usuario         INT UNSIGNED NULL,
+    observacion        TEXT NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_historial),
+    KEY idx_conexion_estado_historial_conexion (id_conexion, creado),
+    CONSTRAINT fk_conexion_estado_historial_conexion FOREIGN KEY (id_conexion) REFERENCES conexion (id_conexion),
+    CONSTRAINT fk_conexion_estado_historial_anterior FOREIGN KEY (id_estado_anterior) REFERENCES estado_conexion (id_estado_conexion),
+    CONSTRAINT fk_conexion_estado_historial_nuevo FOREIGN KEY (id_estado_nuevo) REFERENCES estado_conexion (id_estado_conexion),
+    CONSTRAINT fk_conexion_estado_historial_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE contrato_estado_historial (
+    id_historial       INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_contrato        INT UNSIGNED NOT NULL,
+    id_estado_anterior INT UNSIGNED NULL,
+    id_estado_nuevo    INT UNSIGNED NOT NULL,
+    id_usuario         INT UNSIGNED NULL,
+    observacion        TEXT NULL,
+    creado             DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_historial),
+    KEY idx_contrato_estado_historial_contrato (id_contrato, creado),
+    CONSTRAINT fk_contrato_estado_historial_contrato FOREIGN KEY (id_contrato) REFERENCES contrato (id_contrato),
+    CONSTRAINT fk_contrato_estado_historial_anterior FOREIGN KEY (id_estado_anterior) REFERENCES estado_contrato (id_estado_contrato),
+    CONSTRAINT fk_contrato_estado_historial_nuevo FOREIGN KEY (id_estado_nuevo) REFERENCES estado_contrato (id_estado_contrato),
+    CONSTRAINT fk_contrato_estado_historial_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Punto de partida: el estado actual de lo existente, sin estado anterior conocido
+INSERT INTO conexion_estado_historial (id_conexion, id_estado_nuevo, id_usuario, observacion, creado)
+SELECT id_conexion, id_estado_conexion, NULL, 'Estado al incorporar el historial', ultimo_cambio
+FROM conexion;
+
+INSERT INTO contrato_estado_historial (id_contrato, id_estado_nuevo, id_usuario, observacion, creado)
+SELECT id_contrato, id_estado_contrato, NULL, 'Estado al incorporar el historial', ultimo_cambio
+FROM contrato;
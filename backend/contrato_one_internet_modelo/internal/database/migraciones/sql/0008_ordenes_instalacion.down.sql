@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS orden_instalacion_foto;
+DROP TABLE IF EXISTS orden_instalacion_material;
+DROP TABLE IF EXISTS orden_instalacion;
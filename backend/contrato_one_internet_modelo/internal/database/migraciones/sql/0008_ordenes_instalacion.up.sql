@@ -0,0 +1,62 @@
+-- Órdenes de instalación: se crean cuando la conexión pasa a "Por configurar" (contrato
+-- firmado), un técnico las toma o se le asignan, se programan en un turno y se cierran con
+-- los datos relevados en el domicilio
+
+CREATE TABLE orden_instalacion (
+    id_orden            INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_conexion         INT UNSIGNED NOT NULL,
+    id_contrato         INT UNSIGNED NULL,
+    estado              VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    id_tecnico          INT UNSIGNED NULL,
+    turno_inicio        DATETIME NULL,
+    turno_fin           DATETIME NULL,
+    serial_onu          VARCHAR(64) NULL,
+    potencia_rx_dbm     DECIMAL(5,2) NULL,
+    potencia_tx_dbm     DECIMAL(5,2) NULL,
+    observaciones       TEXT NULL,
+    completada          DATETIME NULL,
+    cancelada           DATETIME NULL,
+    motivo_cancelacion  VARCHAR(255) NULL,
+    creado              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_orden),
+    KEY idx_orden_instalacion_conexion (id_conexion),
+    KEY idx_orden_instalacion_tecnico_turno (id_tecnico, turno_inicio),
+    KEY idx_orden_instalacion_estado (estado),
+    CONSTRAINT fk_orden_instalacion_conexion FOREIGN KEY (id_conexion) REFERENCES conexion (id_conexion),
+    CONSTRAINT fk_orden_instalacion_contrato FOREIGN KEY (id_contrato) REFERENCES contrato (id_contrato),
+    CONSTRAINT fk_orden_instalacion_tecnico FOREIGN KEY (id_tecnico) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE orden_instalacion_material (
+    id_material  INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_orden     INT UNSIGNED NOT NULL,
+    descripcion  VARCHAR(150) NOT NULL,
+    cantidad     DECIMAL(10,2) NOT NULL,
+    unidad       VARCHAR(20) NULL,
+    PRIMARY KEY (id_material),
+    KEY idx_orden_instalacion_material_orden (id_orden),
+    CONSTRAINT fk_orden_instalacion_material_orden FOREIGN KEY (id_orden) REFERENCES orden_instalacion (id_orden) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE orden_instalacion_foto (
+    id_foto         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_orden        INT UNSIGNED NOT NULL,
+    path            VARCHAR(255) NOT NULL,
+    tipo_contenido  VARCHAR(50) NOT NULL,
+    tamano          INT UNSIGNED NOT NULL,
+    hash            CHAR(64) NOT NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_foto),
+    KEY idx_orden_instalacion_foto_orden (id_orden),
+    CONSTRAINT fk_orden_instalacion_foto_orden FOREIGN KEY (id_orden) REFERENCES orden_instalacion (id_orden) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Las conexiones que ya esperaban configuración reciben su orden pendiente
+INSERT INTO orden_instalacion (id_conexion, id_contrato, estado)
+SELECT c.id_conexion,
+       (SELECT MAX(ct.id_contrato) FROM contrato ct WHERE ct.id_conexion = c.id_conexion AND ct.borrado IS NULL),
+       'pendiente'
+FROM conexion c
+JOIN estado_conexion ec ON ec.id_estado_conexion = c.id_estado_conexion
+WHERE ec.nombre = 'Por configurar' AND c.borrado IS NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE orden_instalacion
+    DROP KEY idx_orden_instalacion_turno,
+    DROP COLUMN recordatorio_enviado,
+    DROP COLUMN reprogramaciones;
+
+DROP TABLE IF EXISTS tecnico_agenda;
+DROP TABLE IF EXISTS tecnico_zona;
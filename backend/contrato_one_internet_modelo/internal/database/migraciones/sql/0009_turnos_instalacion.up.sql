@@ -0,0 +1,31 @@
+-- Agenda de los técnicos para que los clientes reserven el turno de instalación:
+-- zonas (distritos) que cubre cada técnico y franjas semanales en las que atiende
+
+CREATE TABLE tecnico_zona (
+    id_tecnico_zona     INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_tecnico          INT UNSIGNED NOT NULL,
+    distrito_nombre     VARCHAR(150) NOT NULL,
+    departamento_nombre VARCHAR(100) NOT NULL,
+    creado              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_tecnico_zona),
+    UNIQUE KEY uq_tecnico_zona (id_tecnico, distrito_nombre, departamento_nombre),
+    KEY idx_tecnico_zona_distrito (distrito_nombre, departamento_nombre),
+    CONSTRAINT fk_tecnico_zona_tecnico FOREIGN KEY (id_tecnico) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE tecnico_agenda (
+    id_agenda    INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_tecnico   INT UNSIGNED NOT NULL,
+    dia_semana   TINYINT UNSIGNED NOT NULL, -- 1 = lunes ... 7 = domingo
+    hora_inicio  TIME NOT NULL,
+    hora_fin     TIME NOT NULL,
+    PRIMARY KEY (id_agenda),
+    KEY idx_tecnico_agenda_tecnico (id_tecnico, dia_semana),
+    CONSTRAINT fk_tecnico_agenda_tecnico FOREIGN KEY (id_tecnico) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Cambios de turno hechos por el cliente y recordatorio del día anterior
+ALTER TABLE orden_instalacion
+    ADD COLUMN reprogramaciones     TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER turno_fin,
+    ADD COLUMN recordatorio_enviado DATETIME NULL AFTER reprogramaciones,
+    ADD KEY idx_orden_instalacion_turno (estado, turno_inicio);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS factura_item;
+DROP TABLE IF EXISTS factura;
+DROP TABLE IF EXISTS factura_numeracion;
@@ -0,0 +1,59 @@
+-- Facturación mensual: una factura por contrato vigente y período (AAAA-MM), con la
+-- numeración correlativa por tipo de comprobante y punto de venta
+
+CREATE TABLE factura_numeracion (
+    tipo_comprobante CHAR(1) NOT NULL,
+    punto_venta      SMALLINT UNSIGNED NOT NULL,
+    ultimo_numero    INT UNSIGNED NOT NULL DEFAULT 0,
+    PRIMARY KEY (tipo_comprobante, punto_venta)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE factura (
+    id_factura            INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tipo_comprobante      CHAR(1) NOT NULL, -- 'A' (Responsable Inscripto) o 'B'
+    punto_venta           SMALLINT UNSIGNED NOT NULL,
+    numero                INT UNSIGNED NOT NULL,
+    id_contrato           INT UNSIGNED NOT NULL,
+    id_conexion           INT UNSIGNED NOT NULL,
+    id_persona            INT UNSIGNED NOT NULL,
+    periodo               CHAR(7) NOT NULL, -- AAAA-MM
+    periodo_desde         DATE NOT NULL,
+    periodo_hasta         DATE NOT NULL,
+    fecha_emision         DATE NOT NULL,
+    fecha_vencimiento     DATE NOT NULL,
+    -- Datos del cliente al momento de emitir
+    cliente_nombre        VARCHAR(150) NOT NULL,
+    cliente_documento     VARCHAR(20) NOT NULL,
+    cliente_condicion_iva VARCHAR(100) NOT NULL,
+    cliente_domicilio     VARCHAR(255) NOT NULL,
+    alicuota_iva          DECIMAL(5,2) NOT NULL,
+    neto                  DECIMAL(12,2) NOT NULL,
+    iva                   DECIMAL(12,2) NOT NULL,
+    total                 DECIMAL(12,2) NOT NULL,
+    estado                VARCHAR(20) NOT NULL DEFAULT 'emitida',
+    pdf_path              VARCHAR(255) NULL,
+    pdf_hash              CHAR(64) NULL,
+    creado                DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_factura),
+    UNIQUE KEY uq_factura_numero (tipo_comprobante, punto_venta, numero),
+    UNIQUE KEY uq_factura_contrato_periodo (id_contrato, periodo),
+    KEY idx_factura_persona (id_persona, fecha_emision),
+    CONSTRAINT fk_factura_contrato FOREIGN KEY (id_contrato) REFERENCES contrato (id_contrato),
+    CONSTRAINT fk_factura_conexion FOREIGN KEY (id_conexion) REFERENCES conexion (id_conexion),
+    CONSTRAINT fk_factura_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE factura_item (
+    id_item         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_factura      INT UNSIGNED NOT NULL,
+    concepto        VARCHAR(255) NOT NULL,
+    cantidad        DECIMAL(8,4) NOT NULL, -- fracción del mes en el abono prorrateado
+    precio_unitario DECIMAL(12,2) NOT NULL,
+    neto            DECIMAL(12,2) NOT NULL,
+    iva             DECIMAL(12,2) NOT NULL,
+    total           DECIMAL(12,2) NOT NULL,
+    PRIMARY KEY (id_item),
+    KEY idx_factura_item_factura (id_factura),
+    CONSTRAINT fk_factura_item_factura FOREIGN KEY (id_factura) REFERENCES factura (id_factura)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
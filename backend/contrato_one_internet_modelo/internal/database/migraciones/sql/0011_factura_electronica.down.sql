@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS afip_ticket_acceso;
+
+-- Falla si quedan facturas pendientes de autorización (sin número)
+ALTER TABLE factura
+    DROP INDEX idx_factura_afip_estado,
+    DROP COLUMN autorizada,
+    DROP COLUMN afip_intentos,
+    DROP COLUMN afip_observaciones,
+    DROP COLUMN cae_vencimiento,
+    DROP COLUMN cae,
+    DROP COLUMN afip_estado,
+    MODIFY numero INT UNSIGNED NOT NULL;
@@ -0,0 +1,25 @@
+-- Factura electrónica (AFIP WSAA + WSFEv1): CAE de cada factura y tickets de acceso.
+-- Con la factura electrónica habilitada el número lo asigna la autorización de AFIP, así que
+-- la factura queda sin número mientras está pendiente.
+
+ALTER TABLE factura
+    MODIFY numero INT UNSIGNED NULL,
+    ADD COLUMN afip_estado VARCHAR(20) NULL AFTER estado, -- NULL: emitida sin factura electrónica
+    ADD COLUMN cae CHAR(14) NULL AFTER afip_estado,
+    ADD COLUMN cae_vencimiento DATE NULL AFTER cae,
+    ADD COLUMN afip_observaciones VARCHAR(1000) NULL AFTER cae_vencimiento,
+    ADD COLUMN afip_intentos SMALLINT UNSIGNED NOT NULL DEFAULT 0 AFTER afip_observaciones,
+    ADD COLUMN autorizada DATETIME NULL AFTER afip_intentos,
+    ADD KEY idx_factura_afip_estado (afip_estado, id_factura);
+
+-- AFIP rechaza un nuevo login mientras el ticket anterior siga vigente: se guarda para
+-- reutilizarlo entre reinicios y procesos
+CREATE TABLE afip_ticket_acceso (
+    modo          VARCHAR(20) NOT NULL, -- 'fake', 'homologacion' o 'produccion'
+    servicio      VARCHAR(30) NOT NULL, -- 'wsfe'
+    token         TEXT NOT NULL,
+    sign          TEXT NOT NULL,
+    vence         DATETIME NOT NULL,
+    ultimo_cambio DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (modo, servicio)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+ALTER TABLE conexion DROP COLUMN suspendida_por_deuda;
+DROP TABLE IF EXISTS cuenta_movimiento;
@@ -0,0 +1,44 @@
+-- Cuenta corriente del cliente: cargos (facturas emitidas), pagos y notas de crédito. El
+-- saldo es la suma de los cargos menos la de los pagos y notas de crédito.
+-- Las conexiones que suspende la revisión de deuda quedan marcadas para restablecerlas
+-- cuando el cliente se pone al día.
+
+CREATE TABLE cuenta_movimiento (
+    id_movimiento     INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_persona        INT UNSIGNED NOT NULL,
+    tipo              VARCHAR(20) NOT NULL, -- 'cargo', 'pago' o 'nota_credito'
+    concepto          VARCHAR(255) NOT NULL,
+    importe           DECIMAL(12,2) NOT NULL, -- siempre positivo: el signo lo da el tipo
+    fecha             DATE NOT NULL,
+    fecha_vencimiento DATE NULL, -- sólo cargos
+    id_factura        INT UNSIGNED NULL,
+    id_pago           INT UNSIGNED NULL, -- pagos acreditados por la pasarela
+    medio_pago        VARCHAR(30) NULL, -- pagos registrados por el personal
+    referencia        VARCHAR(100) NULL,
+    id_usuario        INT UNSIGNED NULL, -- NULL: lo registró el sistema
+    creado            DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_movimiento),
+    UNIQUE KEY uq_cuenta_movimiento_pago (id_pago),
+    KEY idx_cuenta_movimiento_persona (id_persona, fecha),
+    KEY idx_cuenta_movimiento_factura (id_factura),
+    CONSTRAINT fk_cuenta_movimiento_persona FOREIGN KEY (id_persona) REFERENCES persona (id_persona),
+    CONSTRAINT fk_cuenta_movimiento_factura FOREIGN KEY (id_factura) REFERENCES factura (id_factura),
+    CONSTRAINT fk_cuenta_movimiento_pago FOREIGN KEY (id_pago) REFERENCES pago (id_pago),
+    CONSTRAINT fk_cuenta_movimiento_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE conexion
+    ADD COLUMN suspendida_por_deuda DATETIME NULL AFTER fecha_baja;
+
+-- Saldo inicial: facturas ya emitidas (con CAE, si son electrónicas) y pagos aprobados
+INSERT INTO cuenta_movimiento (id_persona, tipo, concepto, importe, fecha, fecha_vencimiento, id_factura)
+SELECT id_persona, 'cargo',
+       CONCAT('Factura ', tipo_comprobante, ' ', LPAD(punto_venta, 4, '0'), '-', LPAD(numero, 8, '0'), ' - período ', periodo),
+       total, fecha_emision, fecha_vencimiento, id_factura
+FROM factura
+WHERE estado = 'emitida' AND numero IS NOT NULL AND (afip_estado IS NULL OR afip_estado = 'autorizada');
+
+INSERT INTO cuenta_movimiento (id_persona, tipo, concepto, importe, fecha, id_pago, medio_pago)
+SELECT id_persona, 'pago', concepto, monto, DATE(COALESCE(fecha_aprobacion, ultimo_cambio)), id_pago, proveedor
+FROM pago
+WHERE estado = 'aprobado';
@@ -0,0 +1,8 @@
+ALTER TABLE contrato
+    DROP FOREIGN KEY fk_contrato_promocion,
+    DROP COLUMN promocion_meses,
+    DROP COLUMN promocion_descuento,
+    DROP COLUMN id_promocion,
+    DROP COLUMN precio_plan;
+DROP TABLE IF EXISTS promocion;
+DROP TABLE IF EXISTS plan_precio;
@@ -0,0 +1,54 @@
+-- Precios versionados de los planes y promociones. plan_precio guarda cada precio con su
+-- vigencia; plan.precio queda como precio de lista inicial. El contrato guarda el precio y la
+-- promoción con los que se firmó: un precio nuevo del plan sólo le llega si empieza a regir
+-- después de la firma (y se le avisó con la anticipación que pide la ley).
+
+CREATE TABLE plan_precio (
+    id_plan_precio INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_plan        INT UNSIGNED NOT NULL,
+    precio         DECIMAL(12,2) NOT NULL, -- neto de IVA, como plan.precio
+    fecha_inicio   DATE NOT NULL,
+    fecha_fin      DATE NULL, -- NULL: vigente hasta el próximo precio
+    motivo         VARCHAR(255) NULL,
+    id_usuario     INT UNSIGNED NULL,
+    creado         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_plan_precio),
+    UNIQUE KEY uq_plan_precio_inicio (id_plan, fecha_inicio),
+    CONSTRAINT fk_plan_precio_plan FOREIGN KEY (id_plan) REFERENCES plan (id_plan),
+    CONSTRAINT fk_plan_precio_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE promocion (
+    id_promocion         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nombre               VARCHAR(100) NOT NULL,
+    descripcion          VARCHAR(255) NULL,
+    descuento_porcentaje DECIMAL(5,2) NOT NULL,
+    duracion_meses       INT UNSIGNED NOT NULL, -- meses de servicio bonificados desde la instalación
+    id_plan              INT UNSIGNED NULL, -- NULL: vale para todos los planes
+    fecha_inicio         DATE NOT NULL, -- período en que se puede contratar
+    fecha_fin            DATE NULL,
+    id_usuario_creador   INT UNSIGNED NULL,
+    creado               DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    borrado              DATETIME NULL,
+    PRIMARY KEY (id_promocion),
+    KEY idx_promocion_plan (id_plan),
+    CONSTRAINT fk_promocion_plan FOREIGN KEY (id_plan) REFERENCES plan (id_plan)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE contrato
+    ADD COLUMN precio_plan DECIMAL(12,2) NULL AFTER costo_instalacion,
+    ADD COLUMN id_promocion INT UNSIGNED NULL AFTER precio_plan,
+    ADD COLUMN promocion_descuento DECIMAL(5,2) NULL AFTER id_promocion,
+    ADD COLUMN promocion_meses INT UNSIGNED NULL AFTER promocion_descuento,
+    ADD CONSTRAINT fk_contrato_promocion FOREIGN KEY (id_promocion) REFERENCES promocion (id_promocion);
+
+-- Precio inicial de cada plan, desde su fecha de inicio
+INSERT INTO plan_precio (id_plan, precio, fecha_inicio, fecha_fin)
+SELECT id_plan, precio, COALESCE(fecha_inicio, DATE(creado)), NULL
+FROM plan;
+
+-- Los contratos existentes quedan con el precio actual de su plan
+UPDATE contrato c
+JOIN plan pl ON pl.id_plan = c.id_plan
+SET c.precio_plan = pl.precio;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS login_bloqueo;
+DELETE FROM usuario_login_log WHERE id_usuario IS NULL;
+ALTER TABLE usuario_login_log
+    DROP KEY idx_usuario_login_log_ip_fecha,
+    DROP KEY idx_usuario_login_log_email_fecha,
+    DROP COLUMN email,
+    MODIFY id_usuario INT UNSIGNED NOT NULL;
@@ -0,0 +1,25 @@
+-- Intentos de login y bloqueos por fuerza bruta.
+-- usuario_login_log pasa a registrar todos los intentos, también los de emails que no
+-- existen (sin id_usuario). login_bloqueo lleva los fallos recientes por cuenta (email) y
+-- por IP: al llegar al umbral se bloquea por un tiempo que crece con cada bloqueo.
+
+ALTER TABLE usuario_login_log
+    MODIFY id_usuario INT UNSIGNED NULL,
+    ADD COLUMN email VARCHAR(150) NULL AFTER id_usuario,
+    ADD KEY idx_usuario_login_log_email_fecha (email, fecha),
+    ADD KEY idx_usuario_login_log_ip_fecha (ip, fecha);
+
+CREATE TABLE login_bloqueo (
+    id_login_bloqueo INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tipo             VARCHAR(10) NOT NULL, -- 'cuenta' o 'ip'
+    clave            VARCHAR(150) NOT NULL, -- email en minúsculas o IP
+    fallos           INT UNSIGNED NOT NULL DEFAULT 0, -- fallos dentro de la ventana
+    nivel            INT UNSIGNED NOT NULL DEFAULT 0, -- bloqueos sufridos: define la duración del próximo
+    ultimo_fallo     DATETIME NULL,
+    bloqueado_hasta  DATETIME NULL,
+    creado           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_login_bloqueo),
+    UNIQUE KEY uq_login_bloqueo_tipo_clave (tipo, clave),
+    KEY idx_login_bloqueo_hasta (bloqueado_hasta)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
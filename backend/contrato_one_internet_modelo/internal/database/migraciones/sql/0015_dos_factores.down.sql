@@ -0,0 +1,5 @@
+ALTER TABLE refresh_token DROP COLUMN amr;
+
+DROP TABLE IF EXISTS usuario_codigo_email;
+DROP TABLE IF EXISTS usuario_codigo_recuperacion;
+DROP TABLE IF EXISTS usuario_dos_factores;
@@ -0,0 +1,44 @@
+-- Autenticación en dos pasos (TOTP con códigos de recuperación y código por email de respaldo).
+-- El secreto TOTP se guarda cifrado (AES-GCM con DOS_FACTORES_CLAVE) porque hay que poder
+-- recalcular los códigos; los códigos de un solo uso se guardan como HMAC-SHA256.
+-- refresh_token.amr recuerda con qué métodos se autenticó la sesión, para que los JWT que
+-- se emiten al refrescarla mantengan el claim amr.
+
+CREATE TABLE usuario_dos_factores (
+    id_usuario       INT UNSIGNED NOT NULL,
+    secreto_totp     VARCHAR(255) NOT NULL, -- cifrado, base64
+    activo           TINYINT(1) NOT NULL DEFAULT 0, -- 0 mientras no se confirma el primer código
+    ultimo_paso_totp BIGINT NOT NULL DEFAULT 0, -- último intervalo usado: evita reusar un código
+    activado         DATETIME NULL,
+    creado           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_cambio    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_usuario),
+    CONSTRAINT fk_usuario_dos_factores_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE usuario_codigo_recuperacion (
+    id_codigo_recuperacion INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario             INT UNSIGNED NOT NULL,
+    codigo_hash            CHAR(64) NOT NULL,
+    usado                  DATETIME NULL,
+    creado                 DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_codigo_recuperacion),
+    UNIQUE KEY uq_usuario_codigo_recuperacion (id_usuario, codigo_hash),
+    CONSTRAINT fk_usuario_codigo_recuperacion_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE usuario_codigo_email (
+    id_codigo_email INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario      INT UNSIGNED NOT NULL,
+    codigo_hash     CHAR(64) NOT NULL,
+    expiracion      DATETIME NOT NULL,
+    intentos        INT UNSIGNED NOT NULL DEFAULT 0,
+    usado           DATETIME NULL,
+    creado          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id_codigo_email),
+    KEY idx_usuario_codigo_email_usuario (id_usuario, creado),
+    CONSTRAINT fk_usuario_codigo_email_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE refresh_token
+    ADD COLUMN amr VARCHAR(50) NOT NULL DEFAULT 'pwd' AFTER token;
@@ -0,0 +1,7 @@
+ALTER TABLE refresh_token
+    DROP FOREIGN KEY fk_refresh_token_sesion,
+    DROP KEY idx_refresh_token_sesion,
+    DROP COLUMN rotado,
+    DROP COLUMN id_sesion;
+
+DROP TABLE IF EXISTS sesion;
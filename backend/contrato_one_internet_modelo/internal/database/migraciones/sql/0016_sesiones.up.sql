@@ -0,0 +1,32 @@
+-- Sesiones de login. Cada login abre una sesión y los refresh tokens que se emiten al
+-- rotarla forman su familia: presentar un token ya rotado revoca la sesión entera.
+-- Las sesiones vigentes de antes de esta migración se crean con el mismo id que su token.
+
+CREATE TABLE sesion (
+    id_sesion         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    id_usuario        INT UNSIGNED NOT NULL,
+    ip                VARCHAR(45) NULL,
+    user_agent        VARCHAR(255) NULL,
+    creado            DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_uso        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expiracion        DATETIME NOT NULL, -- la del último refresh token emitido
+    revocada          DATETIME NULL,
+    motivo_revocacion VARCHAR(50) NULL,
+    PRIMARY KEY (id_sesion),
+    KEY idx_sesion_usuario (id_usuario, revocada),
+    CONSTRAINT fk_sesion_usuario FOREIGN KEY (id_usuario) REFERENCES usuario (id_usuario)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE refresh_token
+    ADD COLUMN id_sesion INT UNSIGNED NULL AFTER id_usuario,
+    ADD COLUMN rotado DATETIME NULL AFTER revocado, -- se reemplazó por uno nuevo (no es un logout)
+    ADD KEY idx_refresh_token_sesion (id_sesion),
+    ADD CONSTRAINT fk_refresh_token_sesion FOREIGN KEY (id_sesion) REFERENCES sesion (id_sesion);
+
+INSERT INTO sesion (id_sesion, id_usuario, creado, ultimo_uso, expiracion)
+SELECT id, id_usuario, creado, creado, expiracion
+FROM refresh_token
+WHERE revocado = 0 AND expiracion > NOW();
+
+UPDATE refresh_token SET id_sesion = id
+WHERE revocado = 0 AND expiracion > NOW();
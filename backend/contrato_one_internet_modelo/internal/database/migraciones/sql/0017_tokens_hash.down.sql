@@ -0,0 +1,4 @@
+ALTER TABLE contrato_firma DROP COLUMN token_firma_hash;
+ALTER TABLE reset_password_token DROP COLUMN token_hash;
+ALTER TABLE email_verificacion_token DROP COLUMN token_hash;
+ALTER TABLE refresh_token DROP COLUMN token_hash;
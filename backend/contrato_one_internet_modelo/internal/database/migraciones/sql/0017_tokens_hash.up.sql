@@ -0,0 +1,10 @@
+-- Los tokens opacos (refresh, verificación de email, reseteo de contraseña y firma de
+-- contrato) se guardan como HMAC-SHA256 con TOKENS_CLAVE_HASH en lugar de en claro.
+-- La clave no está en la base: después de estas sentencias el comando de migraciones
+-- calcula el hash de los tokens existentes (migraciones.PasoTokensHash) y las sesiones
+-- siguen abiertas. La migración 0019 borra las columnas en claro.
+
+ALTER TABLE refresh_token ADD COLUMN token_hash CHAR(64) NULL AFTER token;
+ALTER TABLE email_verificacion_token ADD COLUMN token_hash CHAR(64) NULL AFTER token;
+ALTER TABLE reset_password_token ADD COLUMN token_hash CHAR(64) NULL AFTER token;
+ALTER TABLE contrato_firma ADD COLUMN token_firma_hash CHAR(64) NULL AFTER token_firma;
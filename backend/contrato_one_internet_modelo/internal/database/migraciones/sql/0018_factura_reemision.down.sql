@@ -0,0 +1,6 @@
+-- Falla si algún contrato tiene una factura reemitida junto a la rechazada del mismo período
+ALTER TABLE factura ADD UNIQUE KEY uq_factura_contrato_periodo (id_contrato, periodo);
+
+ALTER TABLE factura
+    DROP KEY uq_factura_contrato_periodo_vigente,
+    DROP COLUMN periodo_vigente;
@@ -0,0 +1,10 @@
+-- Una factura rechazada por AFIP no tiene número ni cargo: el admin puede reemitir la del
+-- período con los datos corregidos. La unicidad por contrato y período pasa a contar sólo las
+-- facturas no rechazadas (periodo_vigente es NULL en las rechazadas, y los NULL no chocan).
+
+ALTER TABLE factura
+    ADD COLUMN periodo_vigente CHAR(7)
+        AS (IF(afip_estado = 'rechazada', NULL, periodo)) STORED AFTER periodo,
+    ADD UNIQUE KEY uq_factura_contrato_periodo_vigente (id_contrato, periodo_vigente);
+
+ALTER TABLE factura DROP KEY uq_factura_contrato_periodo;
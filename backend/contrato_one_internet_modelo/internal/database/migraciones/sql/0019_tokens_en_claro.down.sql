@@ -0,0 +1,32 @@
+-- Los tokens en claro no se pueden recuperar: las columnas vuelven con el hash como valor,
+-- que no coincide con ningún token emitido, así que todos quedan invalidados.
+
+ALTER TABLE contrato_firma ADD COLUMN token_firma VARCHAR(255) NULL AFTER metodo_firma;
+UPDATE contrato_firma SET token_firma = token_firma_hash, token_expira = LEAST(token_expira, NOW())
+WHERE token_firma_hash IS NOT NULL;
+
+ALTER TABLE reset_password_token ADD COLUMN token VARCHAR(255) NULL AFTER id_usuario;
+UPDATE reset_password_token SET token = token_hash, usado = 1;
+ALTER TABLE reset_password_token
+    DROP KEY uq_reset_password_token_token_hash,
+    MODIFY token VARCHAR(255) NOT NULL,
+    MODIFY token_hash CHAR(64) NULL,
+    ADD UNIQUE KEY uq_reset_password_token_token (token);
+
+ALTER TABLE email_verificacion_token ADD COLUMN token VARCHAR(255) NULL AFTER id_usuario;
+UPDATE email_verificacion_token SET token = token_hash, usado = 1;
+ALTER TABLE email_verificacion_token
+    DROP KEY uq_email_verificacion_token_token_hash,
+    MODIFY token VARCHAR(255) NOT NULL,
+    MODIFY token_hash CHAR(64) NULL,
+    ADD UNIQUE KEY uq_email_verificacion_token_token (token);
+
+ALTER TABLE refresh_token ADD COLUMN token VARCHAR(255) NULL AFTER id_sesion;
+UPDATE refresh_token SET token = token_hash, revocado = 1;
+UPDATE sesion SET revocada = NOW(), motivo_revocacion = 'migración a tokens hasheados'
+WHERE revocada IS NULL;
+ALTER TABLE refresh_token
+    DROP KEY uq_refresh_token_token_hash,
+    MODIFY token VARCHAR(255) NOT NULL,
+    MODIFY token_hash CHAR(64) NULL,
+    ADD UNIQUE KEY uq_refresh_token_token (token);
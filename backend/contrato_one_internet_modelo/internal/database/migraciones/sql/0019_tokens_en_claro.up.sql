@@ -0,0 +1,41 @@
+-- Cierra la migración a tokens hasheados (0017). Los tokens que quedaron sin hash (todos, si
+-- el comando de migraciones corrió sin TOKENS_CLAVE_HASH) no se pueden convertir: reciben un
+-- hash que ningún token puede reproducir y se dan por usados. Después se borran las columnas
+-- en claro.
+
+-- Refresh tokens: los que no se convirtieron se revocan junto con las sesiones que se quedan
+-- sin ningún token vigente
+UPDATE refresh_token SET token_hash = SHA2(CONCAT('en-claro|', token), 256), revocado = 1
+WHERE token_hash IS NULL;
+UPDATE sesion s SET revocada = NOW(), motivo_revocacion = 'migración a tokens hasheados'
+WHERE s.revocada IS NULL
+  AND NOT EXISTS (SELECT 1 FROM refresh_token t
+                  WHERE t.id_sesion = s.id_sesion AND t.revocado = 0 AND t.expiracion > NOW());
+ALTER TABLE refresh_token
+    DROP KEY uq_refresh_token_token,
+    DROP COLUMN token,
+    MODIFY token_hash CHAR(64) NOT NULL,
+    ADD UNIQUE KEY uq_refresh_token_token_hash (token_hash);
+
+-- Verificación de email
+UPDATE email_verificacion_token SET token_hash = SHA2(CONCAT('en-claro|', token), 256), usado = 1
+WHERE token_hash IS NULL;
+ALTER TABLE email_verificacion_token
+    DROP KEY uq_email_verificacion_token_token,
+    DROP COLUMN token,
+    MODIFY token_hash CHAR(64) NOT NULL,
+    ADD UNIQUE KEY uq_email_verificacion_token_token_hash (token_hash);
+
+-- Reseteo de contraseña
+UPDATE reset_password_token SET token_hash = SHA2(CONCAT('en-claro|', token), 256), usado = 1
+WHERE token_hash IS NULL;
+ALTER TABLE reset_password_token
+    DROP KEY uq_reset_password_token_token,
+    DROP COLUMN token,
+    MODIFY token_hash CHAR(64) NOT NULL,
+    ADD UNIQUE KEY uq_reset_password_token_token_hash (token_hash);
+
+-- Firma de contratos: un token pendiente sin hash vence ya para que se pueda reenviar
+UPDATE contrato_firma SET token_expira = NOW()
+WHERE token_firma_hash IS NULL AND firmado = 0 AND token_firma IS NOT NULL AND token_expira > NOW();
+ALTER TABLE contrato_firma DROP COLUMN token_firma;
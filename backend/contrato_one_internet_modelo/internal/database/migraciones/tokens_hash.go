@@ -0,0 +1,88 @@
+package migraciones
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// VersionTokensHash es la migración que agrega las columnas de hash de los tokens opacos
+const VersionTokensHash = 17
+
+// loteTokensHash es cuántas filas se leen por vez al convertir los tokens
+const loteTokensHash = 500
+
+// tablaTokenEnClaro describe una tabla cuyos tokens se guardaban en claro
+type tablaTokenEnClaro struct {
+	tabla, id, token, hash string
+}
+
+var tablasTokensEnClaro = []tablaTokenEnClaro{
+	{"refresh_token", "id", "token", "token_hash"},
+	{"email_verificacion_token", "id", "token", "token_hash"},
+	{"reset_password_token", "id", "token", "token_hash"},
+	{"contrato_firma", "id_contrato_firma", "token_firma", "token_firma_hash"},
+}
+
+// PasoTokensHash guarda el HMAC con clave (TOKENS_CLAVE_HASH) de los tokens que la migración
+// 0017 encuentra en claro, así los tokens ya emitidos siguen valiendo y nadie pierde la
+// sesión. Los tokens usados, revocados o vencidos también se convierten y conservan su
+// estado: un refresh token ya rotado sigue detectándose como reutilizado. Lo que quede sin
+// hash lo invalida la migración 0019.
+func PasoTokensHash(clave string) PasoGo {
+	return func(ctx context.Context, conn *sql.Conn) error {
+		for _, t := range tablasTokensEnClaro {
+			if err := convertirTokens(ctx, conn, t, clave); err != nil {
+				return fmt.Errorf("error convirtiendo tokens de %s: %w", t.tabla, err)
+			}
+		}
+		return nil
+	}
+}
+
+// convertirTokens recorre la tabla por lotes de id. Sólo toca filas sin hash, así que se
+// puede repetir si una ejecución anterior se cortó.
+func convertirTokens(ctx context.Context, conn *sql.Conn, t tablaTokenEnClaro, clave string) error {
+	consulta := fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s > ? AND %s IS NULL AND %s IS NOT NULL ORDER BY %s LIMIT ?`,
+		t.id, t.token, t.tabla, t.id, t.hash, t.token, t.id)
+	actualizar := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s = ?`, t.tabla, t.hash, t.id)
+
+	ultimo := 0
+	for {
+		// 1. Leer el lote completo antes de actualizar (la conexión es una sola)
+		rows, err := conn.QueryContext(ctx, consulta, ultimo, loteTokensHash)
+		if err != nil {
+			return err
+		}
+		type fila struct {
+			id    int
+			token string
+		}
+		var lote []fila
+		for rows.Next() {
+			var f fila
+			if err := rows.Scan(&f.id, &f.token); err != nil {
+				rows.Close()
+				return err
+			}
+			lote = append(lote, f)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(lote) == 0 {
+			return nil
+		}
+
+		// 2. Guardar el hash de cada token
+		for _, f := range lote {
+			if _, err := conn.ExecContext(ctx, actualizar, utilidades.HashCodigo(clave, f.token), f.id); err != nil {
+				return err
+			}
+			ultimo = f.id
+		}
+	}
+}
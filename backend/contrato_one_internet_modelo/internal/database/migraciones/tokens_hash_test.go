@@ -0,0 +1,135 @@
+package migraciones
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// tokenFake es una fila con token en claro y, una vez convertida, su hash
+type tokenFake struct {
+	token string
+	hash  string
+}
+
+// bdTokensFake simula las tablas de tokens: responde la consulta por lotes de
+// convertirTokens y aplica sus UPDATE
+type bdTokensFake struct {
+	tablas map[string]map[int]*tokenFake
+}
+
+func (bd *bdTokensFake) Connect(context.Context) (driver.Conn, error) { return conexionTokensFake{bd}, nil }
+func (bd *bdTokensFake) Driver() driver.Driver                        { return nil }
+
+type conexionTokensFake struct{ bd *bdTokensFake }
+
+func (c conexionTokensFake) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c conexionTokensFake) Close() error                        { return nil }
+func (c conexionTokensFake) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c conexionTokensFake) tabla(query string) map[int]*tokenFake {
+	for nombre, filas := range c.bd.tablas {
+		if strings.Contains(query, " "+nombre+" ") {
+			return filas
+		}
+	}
+	return nil
+}
+
+func (c conexionTokensFake) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	filas := c.tabla(query)
+	desde, limite := args[0].Value.(int64), args[1].Value.(int64)
+	var ids []int
+	for id, f := range filas {
+		if int64(id) > desde && f.hash == "" && f.token != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if int64(len(ids)) > limite {
+		ids = ids[:limite]
+	}
+	resultado := &filasTokensFake{}
+	for _, id := range ids {
+		resultado.filas = append(resultado.filas, []driver.Value{int64(id), filas[id].token})
+	}
+	return resultado, nil
+}
+
+func (c conexionTokensFake) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	filas := c.tabla(query)
+	filas[int(args[1].Value.(int64))].hash = args[0].Value.(string)
+	return driver.RowsAffected(1), nil
+}
+
+type filasTokensFake struct {
+	filas [][]driver.Value
+	i     int
+}
+
+func (f *filasTokensFake) Columns() []string { return []string{"id", "token"} }
+func (f *filasTokensFake) Close() error      { return nil }
+func (f *filasTokensFake) Next(dest []driver.Value) error {
+	if f.i >= len(f.filas) {
+		return io.EOF
+	}
+	copy(dest, f.filas[f.i])
+	f.i++
+	return nil
+}
+
+// TestPasoTokensHash verifica que los tokens en claro quedan con el mismo HMAC que calcula
+// utilidades.HashToken al usarlos, de modo que siguen valiendo después de la migración
+func TestPasoTokensHash(t *testing.T) {
+	const clave = "clave-de-tokens-de-prueba-de-32-caracteres"
+	bd := &bdTokensFake{tablas: map[string]map[int]*tokenFake{
+		"refresh_token":            {1: {token: "refresh-vigente"}, 2: {token: "refresh-rotado"}},
+		"email_verificacion_token": {5: {token: "verificacion"}},
+		"reset_password_token":     {},
+		"contrato_firma":           {3: {token: "123456"}, 4: {}},
+	}}
+	db := sql.OpenDB(bd)
+	defer db.Close()
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	paso := PasoTokensHash(clave)
+	if err := paso(ctx, conn); err != nil {
+		t.Fatalf("PasoTokensHash: %v", err)
+	}
+
+	utilidades.ConfigurarClaveTokens(clave)
+	for nombre, filas := range bd.tablas {
+		for id, f := range filas {
+			if f.token == "" {
+				if f.hash != "" {
+					t.Errorf("%s %d: se guardó hash de una fila sin token", nombre, id)
+				}
+				continue
+			}
+			if !utilidades.TokenCoincide(f.token, f.hash) {
+				t.Errorf("%s %d: el hash guardado no coincide con HashToken", nombre, id)
+			}
+		}
+	}
+
+	// Repetir el paso (una ejecución anterior cortada) no cambia nada
+	antes := bd.tablas["refresh_token"][1].hash
+	if err := paso(ctx, conn); err != nil {
+		t.Fatalf("segunda ejecución: %v", err)
+	}
+	if bd.tablas["refresh_token"][1].hash != antes {
+		t.Error("la segunda ejecución volvió a calcular el hash")
+	}
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// DosFactoresHandler expone la configuración de la autenticación en dos pasos y el segundo
+// paso del login
+type DosFactoresHandler struct {
+	service      *servicios.DosFactoresService
+	loginService *servicios.LoginService
+}
+
+func NewDosFactoresHandler(s *servicios.DosFactoresService, ls *servicios.LoginService) *DosFactoresHandler {
+	return &DosFactoresHandler{service: s, loginService: ls}
+}
+
+// EstadoHandler maneja GET /api/v1/internal/auth/2fa
+func (h *DosFactoresHandler) EstadoHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Estado(r.Context(), idPersonaDelPedido(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// IniciarTOTPHandler maneja POST /api/v1/internal/auth/2fa/totp
+func (h *DosFactoresHandler) IniciarTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.IniciarTOTP(r.Context(), idPersonaDelPedido(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ActivarTOTPHandler maneja POST /api/v1/internal/auth/2fa/totp/activar
+func (h *DosFactoresHandler) ActivarTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodificarCodigo(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.service.ActivarTOTP(r.Context(), idPersonaDelPedido(r), req.Codigo)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// RegenerarCodigosHandler maneja POST /api/v1/internal/auth/2fa/recuperacion
+func (h *DosFactoresHandler) RegenerarCodigosHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodificarCodigo(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.service.RegenerarCodigosRecuperacion(r.Context(), idPersonaDelPedido(r), req.Codigo)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// DesactivarHandler maneja DELETE /api/v1/internal/auth/2fa
+func (h *DosFactoresHandler) DesactivarHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodificarCodigo(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.Desactivar(r.Context(), idPersonaDelPedido(r), req.Codigo); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Autenticación en dos pasos desactivada"})
+}
+
+// RestablecerHandler maneja DELETE /api/v1/internal/usuarios/{id}/2fa (usuarios.restablecer_2fa)
+func (h *DosFactoresHandler) RestablecerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id", Mensaje: "debe ser un número entero positivo"})
+		return
+	}
+	if err := h.service.Restablecer(r.Context(), id, idPersonaDelPedido(r)); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Autenticación en dos pasos restablecida"})
+}
+
+// VerificarHandler maneja POST /api/v1/internal/auth/2fa/verificar: el segundo paso del login
+func (h *DosFactoresHandler) VerificarHandler(w http.ResponseWriter, r *http.Request) {
+	var req modelos.VerificarDosFactoresRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IDUsuario <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resp, err := h.loginService.VerificarSegundoFactor(r.Context(), req)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// CodigoEmailHandler maneja POST /api/v1/internal/auth/2fa/codigo-email: genera el código de
+// respaldo que el controlador envía por email
+func (h *DosFactoresHandler) CodigoEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDUsuario int `json:"id_usuario"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IDUsuario <= 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resp, err := h.service.GenerarCodigoEmail(r.Context(), req.IDUsuario)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// decodificarCodigo lee el cuerpo {"codigo": "..."}
+func decodificarCodigo(w http.ResponseWriter, r *http.Request) (modelos.CodigoDosFactoresRequest, bool) {
+	var req modelos.CodigoDosFactoresRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// IntentosLoginHandler expone al admin los intentos de login y los bloqueos por fuerza bruta
+type IntentosLoginHandler struct {
+	service *servicios.IntentoLoginService
+}
+
+func NewIntentosLoginHandler(s *servicios.IntentoLoginService) *IntentosLoginHandler {
+	return &IntentosLoginHandler{service: s}
+}
+
+// ListarIntentosHandler maneja GET /api/v1/internal/login/intentos?email=&ip=&limit=
+func (h *IntentosLoginHandler) ListarIntentosHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limite := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "limit", Mensaje: "debe ser un número entero"})
+			return
+		}
+		limite = n
+	}
+
+	resp, err := h.service.ListarIntentos(r.Context(), q.Get("email"), q.Get("ip"), limite, idPersonaDelPedido(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ListarBloqueosHandler maneja GET /api/v1/internal/login/bloqueos
+func (h *IntentosLoginHandler) ListarBloqueosHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListarBloqueos(r.Context(), idPersonaDelPedido(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// LevantarBloqueoHandler maneja DELETE /api/v1/internal/login/bloqueos/{id}
+func (h *IntentosLoginHandler) LevantarBloqueoHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id", Mensaje: "debe ser un número entero positivo"})
+		return
+	}
+
+	if err := h.service.LevantarBloqueo(r.Context(), id, idPersonaDelPedido(r)); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Bloqueo levantado correctamente"})
+}
+
+// idPersonaDelPedido devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersonaDelPedido(r *http.Request) int {
+	id, _ := r.Context().Value("id_persona").(int)
+	return id
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"contrato_one_internet_modelo/internal/servicios"
@@ -39,6 +40,11 @@ func (h *LoginHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	result, err := h.LoginService.ValidarCredenciales(ctx, req.Email, req.Password, req.ClientIP, req.UserAgent)
 	if err != nil {
+		var bloqueo utilidades.ErrLoginBloqueado
+		if errors.As(err, &bloqueo) {
+			utilidades.ManejarErrorHTTP(w, bloqueo)
+			return
+		}
 		utilidades.ResponderError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
@@ -21,6 +21,8 @@ func (h *RefreshHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Requ
     ctx := r.Context()
     var req struct{ 
         RefreshToken string `json:"refresh_token"` 
+        ClientIP     string `json:"client_ip"`  // Para registrar desde dónde se usa la sesión
+        UserAgent    string `json:"user_agent"`
     }
     
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
@@ -28,7 +30,7 @@ func (h *RefreshHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Requ
         return
     }
 
-    resp, err := h.LoginService.RefreshConToken(ctx, req.RefreshToken)
+    resp, err := h.LoginService.RefreshConToken(ctx, req.RefreshToken, req.ClientIP, req.UserAgent)
     if err != nil {
         logger.Error.Printf("Error en RefreshConToken: %v", err)
         utilidades.ResponderError(w, http.StatusUnauthorized, "refresh token inválido")
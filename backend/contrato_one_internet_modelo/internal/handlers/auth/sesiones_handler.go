@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// SesionesHandler expone las sesiones de login del usuario y el cierre forzado por un admin
+type SesionesHandler struct {
+	service *servicios.SesionService
+}
+
+func NewSesionesHandler(s *servicios.SesionService) *SesionesHandler {
+	return &SesionesHandler{service: s}
+}
+
+// ListarHandler maneja GET /api/v1/internal/perfil/sesiones?actual={id_sesion}
+func (h *SesionesHandler) ListarHandler(w http.ResponseWriter, r *http.Request) {
+	actual, ok := idSesionOpcional(w, r, "actual")
+	if !ok {
+		return
+	}
+	resp, err := h.service.Listar(r.Context(), idPersonaDelPedido(r), actual)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// RevocarHandler maneja DELETE /api/v1/internal/perfil/sesiones/{id}
+func (h *SesionesHandler) RevocarHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id", Mensaje: "debe ser un número entero positivo"})
+		return
+	}
+	if err := h.service.Revocar(r.Context(), idPersonaDelPedido(r), id); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Sesión cerrada"})
+}
+
+// RevocarOtrasHandler maneja DELETE /api/v1/internal/perfil/sesiones?excepto={id_sesion}
+func (h *SesionesHandler) RevocarOtrasHandler(w http.ResponseWriter, r *http.Request) {
+	excepto, ok := idSesionOpcional(w, r, "excepto")
+	if !ok {
+		return
+	}
+	resp, err := h.service.RevocarOtras(r.Context(), idPersonaDelPedido(r), excepto)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// CerrarSesionesUsuarioHandler maneja DELETE /api/v1/internal/usuarios/{id}/sesiones (usuarios.cerrar_sesiones)
+func (h *SesionesHandler) CerrarSesionesUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id", Mensaje: "debe ser un número entero positivo"})
+		return
+	}
+	resp, err := h.service.CerrarSesionesUsuario(r.Context(), id, idPersonaDelPedido(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// idSesionOpcional lee un id de sesión de la query; 0 si no vino
+func idSesionOpcional(w http.ResponseWriter, r *http.Request, parametro string) (int, bool) {
+	v := r.URL.Query().Get(parametro)
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: parametro, Mensaje: "debe ser un número entero positivo"})
+		return 0, false
+	}
+	return id, true
+}
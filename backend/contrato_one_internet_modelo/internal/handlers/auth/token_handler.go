@@ -1,9 +1,9 @@
 package auth
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+	"time"
+
 	"contrato_one_internet_modelo/internal/utilidades"
 )
 
@@ -17,14 +17,25 @@ func NewAuthHandler(tokenManager *TokenManager) *AuthHandler {
 	}
 }
 
-func (h *AuthHandler) GenerateTokenHandler(w http.ResponseWriter, r *http.Request) {
-	token, err := h.TokenManager.GetToken()
+// EmitirTokenHandler maneja POST /api/v1/internal/auth/token.
+// Requiere las cabeceras X-Client-ID, X-Timestamp y X-Service-Signature.
+func (h *AuthHandler) EmitirTokenHandler(w http.ResponseWriter, r *http.Request) {
+	token, exp, err := h.TokenManager.EmitirToken(
+		r.Header.Get(utilidades.HeaderClienteServicio),
+		r.Header.Get(utilidades.HeaderTimestampServicio),
+		r.Method,
+		r.URL.Path,
+		r.Header.Get(utilidades.HeaderFirmaServicio),
+	)
 	if err != nil {
-		log.Printf("Error al obtener token: %v", err)
-		utilidades.ResponderError(w, http.StatusInternalServerError, "No se pudo obtener el token")
+		utilidades.ManejarErrorHTTP(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
-}
\ No newline at end of file
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_in": int(time.Until(exp).Seconds()),
+		"expira":     exp,
+	})
+}
@@ -1,45 +1,48 @@
 package auth
 
 import (
-	"sync"
 	"time"
+
 	"contrato_one_internet_modelo/internal/utilidades"
-	"log"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
 )
 
+// TokenManager emite tokens internos a servicios que presentan una solicitud
+// firmada con su secreto compartido (ver utilidades.FirmarSolicitudServicio).
 type TokenManager struct {
 	jwtSecret []byte
 
-	mu    sync.Mutex
-	token string
-	exp   time.Time
+	clientID     string
+	clientSecret string
+	vigencia     time.Duration
 }
 
-func NewTokenManager(secret string) *TokenManager {
+func NewTokenManager(secret, clientID, clientSecret string, vigencia time.Duration) *TokenManager {
 	return &TokenManager{
-		jwtSecret: []byte(secret),
+		jwtSecret:    []byte(secret),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		vigencia:     vigencia,
 	}
 }
 
-func (tm *TokenManager) GetToken() (string, error) {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+// EmitirToken valida las credenciales firmadas y devuelve un token nuevo con su expiración
+func (tm *TokenManager) EmitirToken(clientID, timestamp, metodo, ruta, firma string) (string, time.Time, error) {
+	if clientID == "" || clientID != tm.clientID {
+		logger.Warn.Printf("Solicitud de token interno con client id desconocido: %q", clientID)
+		return "", time.Time{}, utilidades.ErrCredencialesServicio
+	}
 
-	// Retorna el token si está vigente por más de 5 minutos
-	if tm.token != "" && time.Until(tm.exp) > 5*time.Minute {
-		return tm.token, nil
+	if err := utilidades.ValidarFirmaServicio(tm.clientSecret, clientID, timestamp, metodo, ruta, firma, time.Now()); err != nil {
+		logger.Warn.Printf("Firma de servicio rechazada para %q: %v", clientID, err)
+		return "", time.Time{}, err
 	}
 
-	// Genera un token nuevo
-	token, err := utilidades.GenerarTokenInterno(tm.jwtSecret)
+	token, exp, err := utilidades.GenerarTokenInterno(tm.jwtSecret, clientID, tm.vigencia)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	tm.token = token
-	tm.exp = time.Now().Add(24 * time.Hour)
-
-	log.Printf("Token renovado en TokenManager")
-
-	return token, nil
-}
\ No newline at end of file
+	logger.Info.Printf("Token interno emitido para %q (vence %s)", clientID, exp.Format(time.RFC3339))
+	return token, exp, nil
+}
@@ -1,22 +0,0 @@
-package clientes
-/*
-import (
-	"database/sql"
-)
-
-// Constantes comunes
-const (
-	VinculoCliente    = "Cliente"
-	VinculoApoderado  = "Apoderado"
-	CuitOneInternet   = "30-71646883-2" // CUIT real de ONE Internet
-)
-
-// ClientesHandler contiene la dependencia de la base de datos.
-type ClientesHandler struct {
-	DB *sql.DB
-}
-
-// NewClientesHandler crea una nueva instancia de ClientesHandler.
-func NewClientesHandler(db *sql.DB) *ClientesHandler {
-	return &ClientesHandler{DB: db}
-}*/
\ No newline at end of file
@@ -1,88 +1,92 @@
 package clientes
-/*
+
 import (
-	"context"
 	"encoding/json"
 	"net/http"
-	"time"
+	"strconv"
+
+	"github.com/gorilla/mux"
 
-	"contrato_one_internet_modelo/internal/modelos"
 	"contrato_one_internet_modelo/internal/servicios"
 	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
 )
 
-type CrearParticularRequest struct {
-	Persona   modelos.Persona   `json:"persona"`
-	Direccion modelos.Direccion `json:"direccion"`
-}
-
-type CrearEmpresaRequest struct {
-	Apoderado          modelos.Persona   `json:"apoderado"`
-	DireccionApoderado modelos.Direccion `json:"direccion_apoderado"`
-	Empresa            modelos.Empresa   `json:"empresa"`
-	DireccionEmpresa   modelos.Direccion `json:"direccion_empresa"`
-}
-
-// ✅ Solo una definición de ClientesHandler
+// ClientesHandler maneja el alta y consulta de clientes empresa
 type ClientesHandler struct {
-	servicio *servicios.ClientesService
+	service *servicios.ClientesService
 }
 
+// NewClientesHandler crea una nueva instancia
 func NewClientesHandler(s *servicios.ClientesService) *ClientesHandler {
-	return &ClientesHandler{servicio: s}
+	return &ClientesHandler{service: s}
 }
 
-// 🧾 Crear Cliente Particular
-func (h *ClientesHandler) CrearClienteParticular(w http.ResponseWriter, r *http.Request) {
-	var req CrearParticularRequest
+// CrearClienteEmpresaHandler maneja POST /api/v1/internal/clientes/empresas
+func (h *ClientesHandler) CrearClienteEmpresaHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req struct {
+		servicios.CrearClienteEmpresaRequest
+		IDUsuario int `json:"id_usuario"`
+	}
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "Request body inválido")
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
+	if req.IDUsuario <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id_usuario",
+			Mensaje: "es requerido y debe ser mayor a cero",
+		})
+		return
+	}
 
-	idPersona, err := h.servicio.CrearClienteParticular(ctx, servicios.CrearParticularRequest{
-		Persona:   req.Persona,
-		Direccion: req.Direccion,
-	})
+	resp, err := h.service.CrearClienteEmpresa(r.Context(), req.CrearClienteEmpresaRequest, req.IDUsuario)
 	if err != nil {
-		utilidades.ResponderError(w, http.StatusInternalServerError, err.Error())
+		utilidades.ManejarErrorHTTP(w, err)
 		return
 	}
 
-	utilidades.ResponderJSON(w, http.StatusCreated, map[string]interface{}{
-		"mensaje":    "Cliente particular creado exitosamente",
-		"id_persona": idPersona,
-	})
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
 }
 
-// 🧾 Crear Cliente Empresa
-func (h *ClientesHandler) CrearClienteEmpresa(w http.ResponseWriter, r *http.Request) {
-	var req CrearEmpresaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "Request body inválido")
+// ObtenerEmpresaHandler maneja GET /api/v1/internal/clientes/empresas/{id}
+func (h *ClientesHandler) ObtenerEmpresaHandler(w http.ResponseWriter, r *http.Request) {
+	idEmpresa, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idEmpresa <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_empresa", Mensaje: "debe ser un entero mayor a cero"})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	empresa, err := h.service.ObtenerEmpresa(r.Context(), idEmpresa)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
 
-	resultado, err := h.servicio.CrearClienteEmpresa(ctx, servicios.CrearEmpresaRequest{
-		Apoderado:          req.Apoderado,
-		DireccionApoderado: req.DireccionApoderado,
-		Empresa:            req.Empresa,
-		DireccionEmpresa:   req.DireccionEmpresa,
-	})
+	utilidades.ResponderJSON(w, http.StatusOK, empresa)
+}
+
+// ListarMisEmpresasHandler maneja GET /api/v1/internal/perfil/empresas
+func (h *ClientesHandler) ListarMisEmpresasHandler(w http.ResponseWriter, r *http.Request) {
+	// Obtener id_persona desde el contexto (autenticación interna)
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	empresas, err := h.service.ListarEmpresasDePersona(r.Context(), idPersona)
 	if err != nil {
-		utilidades.ResponderErrorDB(w, err)
+		utilidades.ManejarErrorHTTP(w, err)
 		return
 	}
 
-	utilidades.ResponderJSON(w, http.StatusCreated, map[string]interface{}{
-		"mensaje":      "Cliente empresa creado exitosamente",
-		"id_empresa":   resultado.IDEmpresa,
-		"id_apoderado": resultado.IDApoderado,
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{
+		"empresas": empresas,
 	})
-}*/
\ No newline at end of file
+}
@@ -71,6 +71,46 @@ func (h *ConexionHandler) SolicitarConexionParticularHandler(w http.ResponseWrit
 	utilidades.ResponderJSON(w, http.StatusCreated, resp)
 }
 
+// SolicitarConexionEmpresaHandler maneja POST /api/v1/internal/solicitar-conexion-empresa
+func (h *ConexionHandler) SolicitarConexionEmpresaHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req struct {
+		servicios.SolicitudConexionEmpresaRequest
+		IDUsuario int `json:"id_usuario"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	if req.IDUsuario <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id_usuario",
+			Mensaje: "es requerido y debe ser mayor a cero",
+		})
+		return
+	}
+
+	if req.IDPlan <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id_plan",
+			Mensaje: "es requerido y debe ser mayor a cero",
+		})
+		return
+	}
+
+	resp, err := h.service.SolicitarConexionEmpresa(r.Context(), req.SolicitudConexionEmpresaRequest, req.IDUsuario)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
 // ObtenerSolicitudesPendientesHandler maneja GET /api/v1/internal/revisacion/solicitudes-pendientes
 func (h *ConexionHandler) ObtenerSolicitudesPendientesHandler(w http.ResponseWriter, r *http.Request) {
 	// Parsear query parameters
@@ -174,7 +214,7 @@ func (h *ConexionHandler) ConfirmarFactibilidadHandler(w http.ResponseWriter, r
 	}
 
 	// Llamar al servicio
-	response, err := h.service.ConfirmarFactibilidad(r.Context(), req)
+	response, err := h.service.ConfirmarFactibilidad(r.Context(), req, idPersonaResponsable(r))
 	if err != nil {
 		utilidades.ManejarErrorHTTP(w, err)
 		return
@@ -196,7 +236,7 @@ func (h *ConexionHandler) RechazarFactibilidadHandler(w http.ResponseWriter, r *
 	}
 
 	// Llamar al servicio
-	response, err := h.service.RechazarFactibilidad(r.Context(), req)
+	response, err := h.service.RechazarFactibilidad(r.Context(), req, idPersonaResponsable(r))
 	if err != nil {
 		utilidades.ManejarErrorHTTP(w, err)
 		return
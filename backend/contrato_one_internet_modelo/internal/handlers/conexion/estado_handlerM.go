@@ -0,0 +1,118 @@
+package conexion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// EstadoHandler maneja los cambios de estado manuales de conexiones y contratos
+type EstadoHandler struct {
+	service *servicios.CicloVidaService
+}
+
+// NewEstadoHandler crea una nueva instancia
+func NewEstadoHandler(s *servicios.CicloVidaService) *EstadoHandler {
+	return &EstadoHandler{service: s}
+}
+
+// CambiarEstadoConexionHandler maneja POST /api/v1/internal/conexiones/{id}/estado
+func (h *EstadoHandler) CambiarEstadoConexionHandler(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, h.service.CambiarEstadoConexion)
+}
+
+// CambiarEstadoContratoHandler maneja POST /api/v1/internal/contratos/{id}/estado
+func (h *EstadoHandler) CambiarEstadoContratoHandler(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, h.service.CambiarEstadoContrato)
+}
+
+// ObtenerHistorialConexionHandler maneja GET /api/v1/internal/conexiones/{id}/historial
+func (h *EstadoHandler) ObtenerHistorialConexionHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	historial, err := h.service.HistorialConexion(r.Context(), id)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, historial)
+}
+
+// ObtenerMiHistorialConexionHandler maneja GET /api/v1/internal/perfil/conexiones/{id}/historial
+func (h *EstadoHandler) ObtenerMiHistorialConexionHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona := idPersonaResponsable(r)
+	if idPersona <= 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return
+	}
+
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	historial, err := h.service.HistorialConexionDeCliente(r.Context(), id, idPersona)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, historial)
+}
+
+type cambioEstadoFunc func(ctx context.Context, id int, req modelos.CambiarEstadoRequest, idPersona int) (*modelos.CambiarEstadoResponse, error)
+
+func (h *EstadoHandler) cambiarEstado(w http.ResponseWriter, r *http.Request, cambiar cambioEstadoFunc) {
+	defer r.Body.Close()
+
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.CambiarEstadoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	response, err := cambiar(r.Context(), id, req, idPersonaResponsable(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
+}
+
+// idDeRuta lee el {id} de la ruta; si no es válido responde 400
+func idDeRuta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id",
+			Mensaje: "debe ser un número entero positivo",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// idPersonaResponsable devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersonaResponsable(r *http.Request) int {
+	idPersona, _ := r.Context().Value("id_persona").(int)
+	return idPersona
+}
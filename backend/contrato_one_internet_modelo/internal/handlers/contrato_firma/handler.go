@@ -1,6 +1,7 @@
 package contrato_firma
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -29,88 +30,6 @@ func NewHandler(db *sql.DB, firmaDigitalService *servicios.FirmaDigitalService)
 	}
 }
 
-// SimularPago simula el pago de instalación e inicia el proceso de firma
-func (h *Handler) SimularPago(w http.ResponseWriter, r *http.Request) {
-	logger.Info.Printf("DEBUG MODELO: SimularPago llamado - Method=%s Path=%s", r.Method, r.URL.Path)
-	ctx := r.Context()
-	vars := mux.Vars(r)
-	logger.Info.Printf("DEBUG MODELO: mux.Vars = %+v", vars)
-
-	// 1. Obtener parámetros
-	idPersonaStr := vars["id_persona"]
-	idContratoStr := vars["id_contrato"]
-
-	idPersona, err := strconv.Atoi(idPersonaStr)
-	if err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona inválido")
-		return
-	}
-
-	idContrato, err := strconv.Atoi(idContratoStr)
-	if err != nil {
-		utilidades.ResponderError(w, http.StatusBadRequest, "id_contrato inválido")
-		return
-	}
-
-	// 2. Validar que persona existe y obtener email y nombre
-	var email, nombre, apellido string
-	err = h.db.QueryRowContext(ctx, "SELECT email, nombre, apellido FROM persona WHERE id_persona = ?", idPersona).Scan(&email, &nombre, &apellido)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			utilidades.ResponderError(w, http.StatusNotFound, "persona no encontrada")
-			return
-		}
-		logger.Error.Printf("Error consultando persona: %v", err)
-		utilidades.ResponderError(w, http.StatusInternalServerError, utilidades.ErrInterno.Error())
-		return
-	}
-
-	nombreCompleto := nombre + " " + apellido
-
-	// 3. Validar que contrato existe y pertenece a la persona
-	var contratoPersonaID int
-	err = h.db.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ? AND borrado IS NULL", idContrato).Scan(&contratoPersonaID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			utilidades.ResponderError(w, http.StatusNotFound, "contrato no encontrado")
-			return
-		}
-		logger.Error.Printf("Error consultando contrato: %v", err)
-		utilidades.ResponderError(w, http.StatusInternalServerError, utilidades.ErrInterno.Error())
-		return
-	}
-
-	if contratoPersonaID != idPersona {
-		utilidades.ResponderError(w, http.StatusForbidden, "el contrato no pertenece a esta persona")
-		return
-	}
-
-	// 4. Iniciar proceso de firma (genera PDF, token)
-	contratoFirma, err := h.firmaDigitalService.IniciarProcesoFirma(ctx, idContrato)
-	if err != nil {
-		if errors.Is(err, utilidades.ErrDuplicado) {
-			utilidades.ResponderError(w, http.StatusConflict, "ya existe un proceso de firma activo para este contrato")
-			return
-		}
-		logger.Error.Printf("Error iniciando proceso de firma: %v", err)
-		utilidades.ResponderError(w, http.StatusInternalServerError, "error generando contrato")
-		return
-	}
-
-	// 5. Preparar respuesta
-	response := map[string]interface{}{
-		"id_contrato_firma": contratoFirma.IDContratoFirma,
-		"token":             contratoFirma.TokenFirma, // Para que controlador envíe por email
-		"token_expira":      contratoFirma.TokenExpira.Format("2006-01-02 15:04:05"),
-		"email_destinatario": email,
-		"nombre_completo":    nombreCompleto,
-		"pdf_generado":      contratoFirma.PdfGenerado != nil,
-	}
-
-	logger.Info.Printf("Proceso de firma iniciado para contrato %d (persona %d)", idContrato, idPersona)
-	utilidades.ResponderJSON(w, http.StatusOK, response)
-}
-
 // GuardarFirma guarda la firma en canvas
 func (h *Handler) GuardarFirma(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -150,8 +69,9 @@ func (h *Handler) GuardarFirma(w http.ResponseWriter, r *http.Request) {
 		userAgent = r.UserAgent()
 	}
 
-	// Guardar firma
-	if err := h.firmaDigitalService.GuardarFirmaCanvas(ctx, idContratoFirma, req.FirmaBase64, ip, userAgent); err != nil {
+	// Guardar firma (la primera vez también se emite el token)
+	cf, err := h.firmaDigitalService.GuardarFirmaCanvas(ctx, idContratoFirma, req.FirmaBase64, ip, userAgent)
+	if err != nil {
 		if errors.Is(err, utilidades.ErrNoEncontrado) {
 			utilidades.ResponderError(w, http.StatusNotFound, "contrato_firma no encontrado")
 			return
@@ -161,9 +81,26 @@ func (h *Handler) GuardarFirma(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{
-		"mensaje": "firma guardada correctamente",
-	})
+	response := map[string]interface{}{
+		"mensaje":       "firma guardada correctamente",
+		"token_emitido": cf != nil,
+	}
+	if cf != nil {
+		// Token recién emitido: el controlador lo envía por email
+		email, nombreCompleto, err := h.destinatarioToken(ctx, idContratoFirma)
+		if err != nil {
+			logger.Error.Printf("Error obteniendo datos de persona para contrato_firma %d: %v", idContratoFirma, err)
+			utilidades.ResponderError(w, http.StatusInternalServerError, "error obteniendo datos del destinatario")
+			return
+		}
+		response["id_contrato_firma"] = cf.IDContratoFirma
+		response["token"] = cf.TokenFirma
+		response["token_expira"] = cf.TokenExpira.Format("2006-01-02 15:04:05")
+		response["email_destinatario"] = email
+		response["nombre_completo"] = nombreCompleto
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, response)
 }
 
 // ValidarToken valida el token y genera el PDF firmado
@@ -289,23 +226,13 @@ func (h *Handler) ReenviarToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Obtener email y nombre de la persona
-	var email, nombre, apellido string
-	query := `
-		SELECT p.email, p.nombre, p.apellido
-		FROM contrato_firma cf
-		INNER JOIN contrato c ON cf.id_contrato = c.id_contrato
-		INNER JOIN persona p ON c.id_persona = p.id_persona
-		WHERE cf.id_contrato_firma = ?
-	`
-	err = h.db.QueryRowContext(ctx, query, id).Scan(&email, &nombre, &apellido)
+	email, nombreCompleto, err := h.destinatarioToken(ctx, id)
 	if err != nil {
 		logger.Error.Printf("Error obteniendo datos de persona para contrato_firma %d: %v", id, err)
 		utilidades.ResponderError(w, http.StatusInternalServerError, "error obteniendo datos del destinatario")
 		return
 	}
 
-	nombreCompleto := nombre + " " + apellido
-
 	// Preparar respuesta para el controlador
 	response := map[string]interface{}{
 		"id_contrato_firma":  cf.IDContratoFirma,
@@ -319,6 +246,22 @@ func (h *Handler) ReenviarToken(w http.ResponseWriter, r *http.Request) {
 	utilidades.ResponderJSON(w, http.StatusOK, response)
 }
 
+// destinatarioToken obtiene email y nombre completo del titular de un contrato_firma
+func (h *Handler) destinatarioToken(ctx context.Context, idContratoFirma int) (string, string, error) {
+	var email, nombre, apellido string
+	query := `
+		SELECT p.email, p.nombre, p.apellido
+		FROM contrato_firma cf
+		INNER JOIN contrato c ON cf.id_contrato = c.id_contrato
+		INNER JOIN persona p ON c.id_persona = p.id_persona
+		WHERE cf.id_contrato_firma = ?
+	`
+	if err := h.db.QueryRowContext(ctx, query, idContratoFirma).Scan(&email, &nombre, &apellido); err != nil {
+		return "", "", err
+	}
+	return email, nombre + " " + apellido, nil
+}
+
 // ServirPDF sirve el PDF apropiado (firmado si existe, original si no)
 func (h *Handler) ServirPDF(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -417,3 +360,35 @@ func (h *Handler) DescargarPDF(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
 	http.ServeFile(w, r, pdfPath)
 }
+
+// VerificarFirma revalida la firma digital embebida en el PDF firmado
+func (h *Handler) VerificarFirma(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_contrato_firma inválido")
+		return
+	}
+
+	verificacion, err := h.firmaDigitalService.VerificarFirma(r.Context(), id)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	if !verificacion.Valida {
+		logger.Warn.Printf("Firma digital inválida en contrato_firma %d: %s", id, verificacion.Motivo)
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, verificacion)
+}
+
+// VerificarPorCodigo devuelve el estado público de un contrato a partir del código de
+// verificación impreso (con QR) en el PDF.
+func (h *Handler) VerificarPorCodigo(w http.ResponseWriter, r *http.Request) {
+	verificacion, err := h.firmaDigitalService.VerificarPorCodigo(r.Context(), mux.Vars(r)["codigo"])
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, verificacion)
+}
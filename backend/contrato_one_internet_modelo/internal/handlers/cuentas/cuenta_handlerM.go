@@ -0,0 +1,176 @@
+package cuentas
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// CuentaHandler maneja la cuenta corriente: la vista del cliente y la gestión de cobranzas
+type CuentaHandler struct {
+	service *servicios.CuentaService
+}
+
+// NewCuentaHandler crea una nueva instancia
+func NewCuentaHandler(s *servicios.CuentaService) *CuentaHandler {
+	return &CuentaHandler{service: s}
+}
+
+// ObtenerMiCuentaHandler maneja GET /api/v1/internal/perfil/cuenta
+func (h *CuentaHandler) ObtenerMiCuentaHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente := idPersona(r)
+	if idCliente <= 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return
+	}
+	page, limit, ok := paginacion(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.MiCuenta(r.Context(), idCliente, page, limit)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ObtenerCuentaHandler maneja GET /api/v1/internal/cuentas/{id}
+func (h *CuentaHandler) ObtenerCuentaHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+	page, limit, ok := paginacion(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.CuentaDeCliente(r.Context(), idCliente, page, limit, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ListarDeudoresHandler maneja GET /api/v1/internal/cuentas/deudores
+func (h *CuentaHandler) ListarDeudoresHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Deudores(r.Context(), idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// RegistrarPagoHandler maneja POST /api/v1/internal/cuentas/{id}/pagos
+func (h *CuentaHandler) RegistrarPagoHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	idCliente, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.RegistrarPagoCuentaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resp, err := h.service.RegistrarPago(r.Context(), idCliente, req, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// RegistrarNotaCreditoHandler maneja POST /api/v1/internal/cuentas/{id}/notas-credito
+func (h *CuentaHandler) RegistrarNotaCreditoHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	idCliente, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.RegistrarNotaCreditoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resp, err := h.service.RegistrarNotaCredito(r.Context(), idCliente, req, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// RevisarCuentasHandler maneja POST /api/v1/internal/cuentas/revisar
+func (h *CuentaHandler) RevisarCuentasHandler(w http.ResponseWriter, r *http.Request) {
+	resumen, err := h.service.RevisarCuentasManual(r.Context(), idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resumen)
+}
+
+// paginacion lee page y limit del query string
+func paginacion(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	q := r.URL.Query()
+	page, limit := 1, 20
+	for _, p := range []struct {
+		nombre  string
+		destino *int
+	}{{"page", &page}, {"limit", &limit}} {
+		if v := q.Get(p.nombre); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				utilidades.ResponderError(w, http.StatusBadRequest, p.nombre+" debe ser un entero mayor a 0")
+				return 0, 0, false
+			}
+			*p.destino = n
+		}
+	}
+	return page, limit, true
+}
+
+// idDeRuta lee el {id} de la persona titular de la cuenta
+func idDeRuta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id",
+			Mensaje: "debe ser un número entero positivo",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// idPersona devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersona(r *http.Request) int {
+	id, _ := r.Context().Value("id_persona").(int)
+	return id
+}
@@ -0,0 +1,135 @@
+package facturacion
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// FacturaHandler maneja las facturas del cliente y la generación manual de la facturación
+type FacturaHandler struct {
+	service *servicios.FacturacionService
+}
+
+// NewFacturaHandler crea una nueva instancia
+func NewFacturaHandler(s *servicios.FacturacionService) *FacturaHandler {
+	return &FacturaHandler{service: s}
+}
+
+// ListarMisFacturasHandler maneja GET /api/v1/internal/perfil/facturas
+func (h *FacturaHandler) ListarMisFacturasHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente := idPersona(r)
+	if idCliente <= 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return
+	}
+
+	q := r.URL.Query()
+	page, limit := 1, 20
+	for _, p := range []struct {
+		nombre  string
+		destino *int
+	}{{"page", &page}, {"limit", &limit}} {
+		if v := q.Get(p.nombre); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				utilidades.ResponderError(w, http.StatusBadRequest, p.nombre+" debe ser un entero mayor a 0")
+				return
+			}
+			*p.destino = n
+		}
+	}
+
+	resp, err := h.service.ListarDeCliente(r.Context(), idCliente, page, limit)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ObtenerMiFacturaHandler maneja GET /api/v1/internal/perfil/facturas/{id}
+func (h *FacturaHandler) ObtenerMiFacturaHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente, id, ok := clienteYFactura(w, r)
+	if !ok {
+		return
+	}
+
+	factura, err := h.service.DetalleDeCliente(r.Context(), id, idCliente)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, factura)
+}
+
+// DescargarMiFacturaHandler maneja GET /api/v1/internal/perfil/facturas/{id}/pdf
+func (h *FacturaHandler) DescargarMiFacturaHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente, id, ok := clienteYFactura(w, r)
+	if !ok {
+		return
+	}
+
+	pdfPath, nombre, err := h.service.PDFDeCliente(r.Context(), id, idCliente)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+nombre)
+	http.ServeFile(w, r, pdfPath)
+}
+
+// GenerarPeriodoHandler maneja POST /api/v1/internal/facturacion/generar
+func (h *FacturaHandler) GenerarPeriodoHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req modelos.GenerarFacturacionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resumen, err := h.service.GenerarPeriodoManual(r.Context(), req, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resumen)
+}
+
+// clienteYFactura lee la persona del header y el {id} de la factura
+func clienteYFactura(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	idCliente := idPersona(r)
+	if idCliente <= 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id",
+			Mensaje: "debe ser un número entero positivo",
+		})
+		return 0, 0, false
+	}
+	return idCliente, id, true
+}
+
+// idPersona devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersona(r *http.Request) int {
+	id, _ := r.Context().Value("id_persona").(int)
+	return id
+}
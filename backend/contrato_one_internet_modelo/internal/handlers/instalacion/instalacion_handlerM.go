@@ -0,0 +1,221 @@
+package instalacion
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// InstalacionHandler maneja las órdenes de instalación de los técnicos
+type InstalacionHandler struct {
+	service *servicios.InstalacionService
+}
+
+// NewInstalacionHandler crea una nueva instancia
+func NewInstalacionHandler(s *servicios.InstalacionService) *InstalacionHandler {
+	return &InstalacionHandler{service: s}
+}
+
+// ListarOrdenesHandler maneja GET /api/v1/internal/instalaciones
+// Query: estado, id_tecnico, desde, hasta (RFC 3339 o AAAA-MM-DD), page, limit
+func (h *InstalacionHandler) ListarOrdenesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filtro := modelos.FiltroOrdenesInstalacion{Estado: q.Get("estado")}
+
+	if v := q.Get("id_tecnico"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			utilidades.ResponderError(w, http.StatusBadRequest, "id_tecnico debe ser un entero mayor a 0")
+			return
+		}
+		filtro.IDTecnico = &id
+	}
+	for _, f := range []struct {
+		nombre  string
+		destino **time.Time
+	}{{"desde", &filtro.Desde}, {"hasta", &filtro.Hasta}} {
+		if v := q.Get(f.nombre); v != "" {
+			t, err := parsearFecha(v)
+			if err != nil {
+				utilidades.ResponderError(w, http.StatusBadRequest, f.nombre+" debe tener formato AAAA-MM-DD o RFC 3339")
+				return
+			}
+			*f.destino = &t
+		}
+	}
+
+	page, limit := 1, 20
+	for _, p := range []struct {
+		nombre  string
+		destino *int
+	}{{"page", &page}, {"limit", &limit}} {
+		if v := q.Get(p.nombre); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				utilidades.ResponderError(w, http.StatusBadRequest, p.nombre+" debe ser un entero mayor a 0")
+				return
+			}
+			*p.destino = n
+		}
+	}
+
+	resp, err := h.service.Listar(r.Context(), filtro, page, limit, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ObtenerOrdenHandler maneja GET /api/v1/internal/instalaciones/{id}
+func (h *InstalacionHandler) ObtenerOrdenHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	orden, err := h.service.Detalle(r.Context(), id, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, orden)
+}
+
+// AsignarOrdenHandler maneja POST /api/v1/internal/instalaciones/{id}/asignar
+func (h *InstalacionHandler) AsignarOrdenHandler(w http.ResponseWriter, r *http.Request) {
+	var req modelos.AsignarOrdenRequest
+	id, ok := leerOperacion(w, r, &req)
+	if !ok {
+		return
+	}
+	resp, err := h.service.Asignar(r.Context(), id, req, idPersona(r))
+	responder(w, resp, err)
+}
+
+// ProgramarOrdenHandler maneja POST /api/v1/internal/instalaciones/{id}/programar
+func (h *InstalacionHandler) ProgramarOrdenHandler(w http.ResponseWriter, r *http.Request) {
+	var req modelos.ProgramarOrdenRequest
+	id, ok := leerOperacion(w, r, &req)
+	if !ok {
+		return
+	}
+	resp, err := h.service.Programar(r.Context(), id, req, idPersona(r))
+	responder(w, resp, err)
+}
+
+// CompletarOrdenHandler maneja POST /api/v1/internal/instalaciones/{id}/completar
+func (h *InstalacionHandler) CompletarOrdenHandler(w http.ResponseWriter, r *http.Request) {
+	var req modelos.CompletarOrdenRequest
+	id, ok := leerOperacion(w, r, &req)
+	if !ok {
+		return
+	}
+	resp, err := h.service.Completar(r.Context(), id, req, idPersona(r))
+	responder(w, resp, err)
+}
+
+// CancelarOrdenHandler maneja POST /api/v1/internal/instalaciones/{id}/cancelar
+func (h *InstalacionHandler) CancelarOrdenHandler(w http.ResponseWriter, r *http.Request) {
+	var req modelos.CancelarOrdenRequest
+	id, ok := leerOperacion(w, r, &req)
+	if !ok {
+		return
+	}
+	resp, err := h.service.Cancelar(r.Context(), id, req, idPersona(r))
+	responder(w, resp, err)
+}
+
+// ObtenerAgendaHandler maneja GET /api/v1/internal/instalaciones/tecnicos/{id}/agenda
+func (h *InstalacionHandler) ObtenerAgendaHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	agenda, err := h.service.ObtenerAgenda(r.Context(), id, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, agenda)
+}
+
+// ActualizarAgendaHandler maneja PUT /api/v1/internal/instalaciones/tecnicos/{id}/agenda
+func (h *InstalacionHandler) ActualizarAgendaHandler(w http.ResponseWriter, r *http.Request) {
+	var req modelos.AgendaTecnico
+	id, ok := leerOperacion(w, r, &req)
+	if !ok {
+		return
+	}
+
+	agenda, err := h.service.ActualizarAgenda(r.Context(), id, req, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, agenda)
+}
+
+// leerOperacion lee el {id} de la ruta y decodifica el cuerpo en req; si falla responde 400
+func leerOperacion(w http.ResponseWriter, r *http.Request, req interface{}) (int, bool) {
+	defer r.Body.Close()
+
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return 0, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return 0, false
+	}
+	return id, true
+}
+
+func responder(w http.ResponseWriter, resp *modelos.OrdenInstalacionResponse, err error) {
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// idDeRuta lee el {id} de la ruta; si no es válido responde 400
+func idDeRuta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id",
+			Mensaje: "debe ser un número entero positivo",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// idPersona devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersona(r *http.Request) int {
+	id, _ := r.Context().Value("id_persona").(int)
+	return id
+}
+
+// parsearFecha acepta una fecha (AAAA-MM-DD, en la zona horaria local) o un instante RFC 3339
+func parsearFecha(v string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
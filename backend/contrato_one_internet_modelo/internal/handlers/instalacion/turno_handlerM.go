@@ -0,0 +1,92 @@
+package instalacion
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// TurnoHandler maneja los turnos de instalación que reserva el cliente
+type TurnoHandler struct {
+	service *servicios.TurnoService
+}
+
+// NewTurnoHandler crea una nueva instancia
+func NewTurnoHandler(s *servicios.TurnoService) *TurnoHandler {
+	return &TurnoHandler{service: s}
+}
+
+// DisponibilidadHandler maneja GET /api/v1/internal/perfil/conexiones/{id}/turnos
+func (h *TurnoHandler) DisponibilidadHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente, id, ok := clienteYConexion(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.Disponibilidad(r.Context(), id, idCliente)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ReservarHandler maneja POST /api/v1/internal/perfil/conexiones/{id}/turnos
+func (h *TurnoHandler) ReservarHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	idCliente, id, ok := clienteYConexion(w, r)
+	if !ok {
+		return
+	}
+
+	var req modelos.ReservarTurnoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resp, err := h.service.Reservar(r.Context(), id, req, idCliente)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// CancelarHandler maneja DELETE /api/v1/internal/perfil/conexiones/{id}/turnos
+func (h *TurnoHandler) CancelarHandler(w http.ResponseWriter, r *http.Request) {
+	idCliente, id, ok := clienteYConexion(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.Cancelar(r.Context(), id, idCliente)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// clienteYConexion lee la persona del header y el {id} de la conexión
+func clienteYConexion(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	idCliente := idPersona(r)
+	if idCliente <= 0 {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return 0, 0, false
+	}
+	id, ok := idDeRuta(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	return idCliente, id, true
+}
@@ -0,0 +1,226 @@
+package notificaciones
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// NotificacionEntregaHandler maneja preferencias de canal, suscripciones push, plantillas
+// y el intercambio de entregas con el despachador del controlador
+type NotificacionEntregaHandler struct {
+	service *servicios.NotificacionEntregaService
+}
+
+func NewNotificacionEntregaHandler(s *servicios.NotificacionEntregaService) *NotificacionEntregaHandler {
+	return &NotificacionEntregaHandler{service: s}
+}
+
+// ObtenerPreferenciasHandler maneja GET /api/v1/internal/notificaciones/preferencias
+func (h *NotificacionEntregaHandler) ObtenerPreferenciasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	prefs, err := h.service.ObtenerPreferencias(r.Context(), idPersona)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, prefs)
+}
+
+// ActualizarPreferenciasHandler maneja PUT /api/v1/internal/notificaciones/preferencias
+func (h *NotificacionEntregaHandler) ActualizarPreferenciasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	var req struct {
+		Preferencias []modelos.PreferenciaNotificacion `json:"preferencias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	prefs, err := h.service.ActualizarPreferencias(r.Context(), idPersona, req.Preferencias)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, prefs)
+}
+
+// RegistrarSuscripcionPushHandler maneja POST /api/v1/internal/notificaciones/push/suscripciones
+func (h *NotificacionEntregaHandler) RegistrarSuscripcionPushHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	sub, err := decodificarSuscripcionPush(r)
+	if err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	if err := h.service.RegistrarSuscripcionPush(r.Context(), idPersona, sub); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusCreated, map[string]string{"mensaje": "Suscripción registrada"})
+}
+
+// EliminarSuscripcionPushHandler maneja DELETE /api/v1/internal/notificaciones/push/suscripciones
+func (h *NotificacionEntregaHandler) EliminarSuscripcionPushHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	sub, err := decodificarSuscripcionPush(r)
+	if err != nil || sub.Endpoint == "" {
+		utilidades.ResponderError(w, http.StatusBadRequest, "endpoint es obligatorio")
+		return
+	}
+
+	if err := h.service.EliminarSuscripcionPush(r.Context(), idPersona, sub.Endpoint); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Suscripción eliminada"})
+}
+
+// ListarEntregasHandler maneja GET /api/v1/internal/notificaciones/{id}/entregas
+func (h *NotificacionEntregaHandler) ListarEntregasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	idNotificacion, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idNotificacion <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_notificacion", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	entregas, err := h.service.ListarEntregas(r.Context(), idNotificacion, idPersona)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, entregas)
+}
+
+// ReservarEntregasHandler maneja POST /api/v1/internal/notificaciones/entregas/reservar?limite=N
+// Lo usa el despachador del controlador para obtener los mensajes a enviar.
+func (h *NotificacionEntregaHandler) ReservarEntregasHandler(w http.ResponseWriter, r *http.Request) {
+	limite, _ := strconv.Atoi(r.URL.Query().Get("limite"))
+
+	entregas, err := h.service.ReservarEntregas(r.Context(), limite)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, entregas)
+}
+
+// RegistrarResultadoHandler maneja POST /api/v1/internal/notificaciones/entregas/{id}/resultado
+func (h *NotificacionEntregaHandler) RegistrarResultadoHandler(w http.ResponseWriter, r *http.Request) {
+	idEntrega, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idEntrega <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_entrega", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	var res modelos.ResultadoEntrega
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	if err := h.service.RegistrarResultado(r.Context(), idEntrega, res); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListarPlantillasHandler maneja GET /api/v1/internal/notificaciones/plantillas
+func (h *NotificacionEntregaHandler) ListarPlantillasHandler(w http.ResponseWriter, r *http.Request) {
+	plantillas, err := h.service.ListarPlantillas(r.Context())
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, plantillas)
+}
+
+// GuardarPlantillaHandler maneja PUT /api/v1/internal/notificaciones/plantillas
+func (h *NotificacionEntregaHandler) GuardarPlantillaHandler(w http.ResponseWriter, r *http.Request) {
+	var p modelos.PlantillaNotificacion
+	p.Activo = true
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	plantilla, err := h.service.GuardarPlantilla(r.Context(), &p)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, plantilla)
+}
+
+// EliminarPlantillaHandler maneja DELETE /api/v1/internal/notificaciones/plantillas/{id}
+func (h *NotificacionEntregaHandler) EliminarPlantillaHandler(w http.ResponseWriter, r *http.Request) {
+	idPlantilla, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idPlantilla <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_plantilla", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	if err := h.service.EliminarPlantilla(r.Context(), idPlantilla); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodificarSuscripcionPush acepta el formato de PushSubscription.toJSON()
+// ({"endpoint", "keys": {"p256dh", "auth"}}) o los campos planos
+func decodificarSuscripcionPush(r *http.Request) (modelos.SuscripcionPush, error) {
+	var body struct {
+		modelos.SuscripcionPush
+		Keys struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return modelos.SuscripcionPush{}, err
+	}
+	sub := body.SuscripcionPush
+	if sub.P256dh == "" {
+		sub.P256dh = body.Keys.P256dh
+	}
+	if sub.Auth == "" {
+		sub.Auth = body.Keys.Auth
+	}
+	return sub, nil
+}
@@ -7,6 +7,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
 	"contrato_one_internet_modelo/internal/servicios"
 	"contrato_one_internet_modelo/internal/utilidades"
 )
@@ -42,6 +45,29 @@ func (h *NotificacionHandler) ObtenerNotificacionesHandler(w http.ResponseWriter
 		leido = &leidoVal
 	}
 
+	// Filtros opcionales: tipo, id_contrato, id_conexion y archivadas (0/1)
+	filtro := modelos.FiltroNotificaciones{Leido: leido, Tipo: queryParams.Get("tipo")}
+	for _, f := range []struct {
+		nombre  string
+		destino **int
+	}{{"id_contrato", &filtro.IDContrato}, {"id_conexion", &filtro.IDConexion}} {
+		if v := queryParams.Get(f.nombre); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				utilidades.ResponderError(w, http.StatusBadRequest, f.nombre+" debe ser un entero mayor a 0")
+				return
+			}
+			*f.destino = &n
+		}
+	}
+	if v := queryParams.Get("archivadas"); v != "" {
+		if v != "0" && v != "1" {
+			utilidades.ResponderError(w, http.StatusBadRequest, "archivadas debe ser 0 o 1")
+			return
+		}
+		filtro.Archivadas = v == "1"
+	}
+
 	// Parámetro page (default: 1)
 	page := 1
 	if pageStr := queryParams.Get("page"); pageStr != "" {
@@ -88,7 +114,7 @@ func (h *NotificacionHandler) ObtenerNotificacionesHandler(w http.ResponseWriter
 	resp, err := h.NotificacionService.ObtenerNotificaciones(
 		r.Context(),
 		idPersona,
-		leido,
+		filtro,
 		page,
 		pageSize,
 		sortBy,
@@ -148,3 +174,141 @@ func (h *NotificacionHandler) MarcarComoLeidaHandler(w http.ResponseWriter, r *h
 		"mensaje": "Notificación marcada como leída",
 	})
 }
+
+// ObtenerFeedHandler maneja GET /api/v1/internal/notificaciones/feed?desde_id=&limite=
+// Con X-ID-Persona devuelve sólo las de esa persona (reanudación del stream); sin él,
+// las de todos los receptores (feed global que consume el controlador).
+func (h *NotificacionHandler) ObtenerFeedHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	desdeID := 0
+	if v := queryParams.Get("desde_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "desde_id", Mensaje: "debe ser un entero"})
+			return
+		}
+		desdeID = n
+	}
+
+	limite := 100
+	if v := queryParams.Get("limite"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "limite", Mensaje: "debe ser un entero"})
+			return
+		}
+		limite = n
+	}
+
+	var idPersona *int
+	if id, ok := r.Context().Value("id_persona").(int); ok && id > 0 {
+		idPersona = &id
+	}
+
+	resp, err := h.NotificacionService.ObtenerFeed(r.Context(), desdeID, idPersona, limite)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ContarNoLeidasHandler maneja GET /api/v1/internal/notificaciones/no-leidas
+func (h *NotificacionHandler) ContarNoLeidasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	resp, err := h.NotificacionService.ContarNoLeidas(r.Context(), idPersona)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// MarcarTodasComoLeidasHandler maneja POST /api/v1/internal/notificaciones/marcar-todas-como-leidas
+func (h *NotificacionHandler) MarcarTodasComoLeidasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	resp, err := h.NotificacionService.MarcarComoLeidas(r.Context(), idPersona, nil)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// MarcarComoLeidasHandler maneja POST /api/v1/internal/notificaciones/marcar-como-leidas
+// Body: {"ids": [1, 2, 3]}
+func (h *NotificacionHandler) MarcarComoLeidasHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.IDs) == 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "ids", Mensaje: "debe indicar al menos una notificación"})
+		return
+	}
+
+	resp, err := h.NotificacionService.MarcarComoLeidas(r.Context(), idPersona, req.IDs)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ArchivarHandler maneja POST /api/v1/internal/notificaciones/{id}/archivar
+func (h *NotificacionHandler) ArchivarHandler(w http.ResponseWriter, r *http.Request) {
+	h.actualizarEstado(w, r, "Notificación archivada", func(idNotificacion, idPersona int) error {
+		return h.NotificacionService.Archivar(r.Context(), idNotificacion, idPersona, true)
+	})
+}
+
+// DesarchivarHandler maneja POST /api/v1/internal/notificaciones/{id}/desarchivar
+func (h *NotificacionHandler) DesarchivarHandler(w http.ResponseWriter, r *http.Request) {
+	h.actualizarEstado(w, r, "Notificación devuelta a la bandeja", func(idNotificacion, idPersona int) error {
+		return h.NotificacionService.Archivar(r.Context(), idNotificacion, idPersona, false)
+	})
+}
+
+// EliminarHandler maneja DELETE /api/v1/internal/notificaciones/{id}
+func (h *NotificacionHandler) EliminarHandler(w http.ResponseWriter, r *http.Request) {
+	h.actualizarEstado(w, r, "Notificación eliminada", func(idNotificacion, idPersona int) error {
+		return h.NotificacionService.Eliminar(r.Context(), idNotificacion, idPersona)
+	})
+}
+
+// actualizarEstado resuelve persona e id de la ruta y aplica la operación
+func (h *NotificacionHandler) actualizarEstado(w http.ResponseWriter, r *http.Request, mensaje string, operacion func(idNotificacion, idPersona int) error) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok || idPersona == 0 {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona no proporcionado")
+		return
+	}
+
+	idNotificacion, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idNotificacion <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_notificacion", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	if err := operacion(idNotificacion, idPersona); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": mensaje})
+}
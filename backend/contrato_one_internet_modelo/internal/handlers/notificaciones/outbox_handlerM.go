@@ -0,0 +1,45 @@
+package notificaciones
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// OutboxHandler expone los eventos en dead-letter del outbox para su revisión y reintento
+type OutboxHandler struct {
+	service *servicios.OutboxService
+}
+
+func NewOutboxHandler(s *servicios.OutboxService) *OutboxHandler {
+	return &OutboxHandler{service: s}
+}
+
+// ListarFallidosHandler maneja GET /api/v1/internal/outbox/fallidos
+func (h *OutboxHandler) ListarFallidosHandler(w http.ResponseWriter, r *http.Request) {
+	eventos, err := h.service.ListarFallidos(r.Context())
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, eventos)
+}
+
+// ReintentarHandler maneja POST /api/v1/internal/outbox/{id}/reintentar
+func (h *OutboxHandler) ReintentarHandler(w http.ResponseWriter, r *http.Request) {
+	idEvento, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idEvento <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_evento", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	if err := h.service.Reintentar(r.Context(), idEvento); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
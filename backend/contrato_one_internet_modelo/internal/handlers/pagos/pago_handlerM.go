@@ -0,0 +1,162 @@
+package pagos
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// PagoHandler maneja las solicitudes HTTP de pagos
+type PagoHandler struct {
+	service *servicios.PagoService
+}
+
+// NewPagoHandler crea una nueva instancia
+func NewPagoHandler(s *servicios.PagoService) *PagoHandler {
+	return &PagoHandler{service: s}
+}
+
+// IniciarPagoHandler maneja POST /api/v1/internal/pagos/contrato/{id_contrato}
+// Requiere cabecera X-ID-Persona (el contrato debe pertenecer a esa persona).
+func (h *PagoHandler) IniciarPagoHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return
+	}
+
+	idContrato, err := strconv.Atoi(mux.Vars(r)["id_contrato"])
+	if err != nil || idContrato <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_contrato", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	pago, err := h.service.IniciarPagoContrato(r.Context(), idPersona, idContrato)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, pago)
+}
+
+// ObtenerPagoHandler maneja GET /api/v1/internal/pagos/{id}
+func (h *PagoHandler) ObtenerPagoHandler(w http.ResponseWriter, r *http.Request) {
+	idPersona, ok := r.Context().Value("id_persona").(int)
+	if !ok {
+		utilidades.ResponderError(w, http.StatusUnauthorized, "no autorizado")
+		return
+	}
+
+	idPago, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idPago <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_pago", Mensaje: "debe ser un entero mayor a cero"})
+		return
+	}
+
+	pago, err := h.service.ObtenerPago(r.Context(), idPago, idPersona)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, pago)
+}
+
+// WebhookHandler maneja POST /api/v1/internal/pagos/webhook
+// El controlador reenvía el cuerpo y las cabeceras x-signature / x-request-id
+// tal como las recibió del proveedor.
+func (h *PagoHandler) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body struct {
+		Type   string `json:"type"`
+		Action string `json:"action"`
+		Data   struct {
+			ID json.Number `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	// Mercado Pago envía data.id también como query param, que es el que se firma
+	n := servicios.NotificacionPago{
+		Tipo:      body.Type,
+		IDRecurso: r.URL.Query().Get("data.id"),
+		Firma:     r.Header.Get("X-Signature"),
+		IDRequest: r.Header.Get("X-Request-Id"),
+	}
+	if n.IDRecurso == "" {
+		n.IDRecurso = body.Data.ID.String()
+	}
+	if n.Tipo == "" {
+		n.Tipo = r.URL.Query().Get("type")
+	}
+
+	resultado, err := h.service.ProcesarNotificacion(r.Context(), n)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	if resultado == nil {
+		utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{"procesado": false})
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, respuestaResultado(resultado))
+}
+
+// SimularPagoHandler maneja POST /api/v1/internal/simular-pago/{id_persona}/{id_contrato}
+// Solo funciona con el proveedor fake: crea el pago y lo aprueba mediante un webhook simulado.
+func (h *PagoHandler) SimularPagoHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	idPersona, err := strconv.Atoi(vars["id_persona"])
+	if err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_persona inválido")
+		return
+	}
+	idContrato, err := strconv.Atoi(vars["id_contrato"])
+	if err != nil {
+		utilidades.ResponderError(w, http.StatusBadRequest, "id_contrato inválido")
+		return
+	}
+
+	resultado, err := h.service.SimularPagoAprobado(r.Context(), idPersona, idContrato)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	if resultado.ContratoFirma == nil {
+		utilidades.ResponderError(w, http.StatusConflict, "ya existe un proceso de firma activo para este contrato")
+		return
+	}
+
+	logger.Info.Printf("Pago simulado para contrato %d (persona %d)", idContrato, idPersona)
+	utilidades.ResponderJSON(w, http.StatusOK, respuestaResultado(resultado))
+}
+
+// respuestaResultado arma la respuesta para el controlador. El token de firma no viaja acá:
+// se emite cuando el cliente guarda su firma.
+func respuestaResultado(res *servicios.ResultadoPago) map[string]interface{} {
+	resp := map[string]interface{}{
+		"procesado":      true,
+		"id_pago":        res.Pago.IDPago,
+		"id_contrato":    res.Pago.IDContrato,
+		"estado":         res.Pago.Estado,
+		"firma_iniciada": res.ContratoFirma != nil,
+	}
+	if cf := res.ContratoFirma; cf != nil {
+		resp["id_contrato_firma"] = cf.IDContratoFirma
+		resp["pdf_generado"] = cf.PdfGenerado != nil
+	}
+	return resp
+}
@@ -2,6 +2,7 @@ package planes
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
     "strconv"
     "strings"
@@ -33,6 +34,7 @@ func (h *PlanesWriteHandler) CrearPlanHandler(w http.ResponseWriter, r *http.Req
         Precio     float64 `json:"precio"`
         Descripcion *string `json:"descripcion,omitempty"`
         IDUsuarioCreador *int `json:"id_usuario_creador,omitempty"`
+        FechaInicio *string `json:"fecha_inicio,omitempty"` // YYYY-MM-DD, vacía = hoy
         FechaFin   *string `json:"fecha_fin,omitempty"` // YYYY-MM-DD
     }
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -56,6 +58,16 @@ func (h *PlanesWriteHandler) CrearPlanHandler(w http.ResponseWriter, r *http.Req
         utilidades.ResponderError(w, http.StatusBadRequest, "El precio no puede ser negativo")
         return
     }
+    if req.FechaInicio != nil {
+        if _, err := time.Parse("2006-01-02", *req.FechaInicio); err != nil {
+            utilidades.ResponderError(w, http.StatusBadRequest, "fecha_inicio inválida, usar YYYY-MM-DD")
+            return
+        }
+        if req.FechaFin != nil && *req.FechaFin < *req.FechaInicio {
+            utilidades.ResponderError(w, http.StatusBadRequest, "fecha_fin no puede ser anterior a fecha_inicio")
+            return
+        }
+    }
     if req.FechaFin != nil {
         // validar que fecha_fin >= hoy
         t, err := time.Parse("2006-01-02", *req.FechaFin)
@@ -77,6 +89,8 @@ func (h *PlanesWriteHandler) CrearPlanHandler(w http.ResponseWriter, r *http.Req
         Precio: req.Precio,
         Descripcion: req.Descripcion,
         IDUsuarioCreador: req.IDUsuarioCreador,
+        FechaInicio: req.FechaInicio,
+        FechaFin: req.FechaFin,
     }
 
     id, err := h.service.CrearPlan(ctx, plan)
@@ -157,6 +171,11 @@ func (h *PlanesWriteHandler) ActualizarPlanHandler(w http.ResponseWriter, r *htt
             utilidades.ResponderError(w, http.StatusBadRequest, "No se puede modificar un plan borrado")
             return
         }
+        var errValidacion utilidades.ErrValidation
+        if errors.As(err, &errValidacion) {
+            utilidades.ManejarErrorHTTP(w, err)
+            return
+        }
         logger.Error.Printf("Error actualizando plan %d: %v", id, err)
         utilidades.ResponderError(w, http.StatusInternalServerError, "error interno del servidor")
         return
@@ -0,0 +1,146 @@
+package planes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// PreciosHandler maneja el historial y los ajustes de precios de los planes y las promociones
+type PreciosHandler struct {
+	service *servicios.PrecioPlanService
+}
+
+// NewPreciosHandler crea una nueva instancia
+func NewPreciosHandler(s *servicios.PrecioPlanService) *PreciosHandler {
+	return &PreciosHandler{service: s}
+}
+
+// HistorialPreciosHandler maneja GET /api/v1/internal/planes/{id}/precios
+func (h *PreciosHandler) HistorialPreciosHandler(w http.ResponseWriter, r *http.Request) {
+	idPlan, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.Historial(r.Context(), idPlan, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// AjustarPreciosHandler maneja POST /api/v1/internal/planes/precios
+func (h *PreciosHandler) AjustarPreciosHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req modelos.AjustePreciosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	resp, err := h.service.AjustarPrecios(r.Context(), req, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, resp)
+}
+
+// ListarPromocionesVigentesHandler maneja GET /api/v1/promociones (público)
+func (h *PreciosHandler) ListarPromocionesVigentesHandler(w http.ResponseWriter, r *http.Request) {
+	var idPlan *int
+	if v := r.URL.Query().Get("id_plan"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "id_plan", Mensaje: "debe ser un número entero positivo"})
+			return
+		}
+		idPlan = &id
+	}
+
+	promociones, err := h.service.ListarPromocionesVigentes(r.Context(), idPlan)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{"promociones": promociones})
+}
+
+// ListarPromocionesHandler maneja GET /api/v1/internal/promociones
+func (h *PreciosHandler) ListarPromocionesHandler(w http.ResponseWriter, r *http.Request) {
+	promociones, err := h.service.ListarPromociones(r.Context(), idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]interface{}{"promociones": promociones})
+}
+
+// CrearPromocionHandler maneja POST /api/v1/internal/promociones
+func (h *PreciosHandler) CrearPromocionHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req modelos.CrearPromocionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error.Printf("Error decodificando request: %v", err)
+		utilidades.ResponderError(w, http.StatusBadRequest, "Estructura de datos inválida")
+		return
+	}
+
+	promo, err := h.service.CrearPromocion(r.Context(), req, idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusCreated, promo)
+}
+
+// BorrarPromocionHandler maneja DELETE /api/v1/internal/promociones/{id}
+func (h *PreciosHandler) BorrarPromocionHandler(w http.ResponseWriter, r *http.Request) {
+	idPromocion, ok := idDeRuta(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.BorrarPromocion(r.Context(), idPromocion, idPersona(r)); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Promoción dada de baja correctamente"})
+}
+
+// idDeRuta lee el {id} de la ruta
+func idDeRuta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{
+			Campo:   "id",
+			Mensaje: "debe ser un número entero positivo",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// idPersona devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersona(r *http.Request) int {
+	id, _ := r.Context().Value("id_persona").(int)
+	return id
+}
@@ -0,0 +1,121 @@
+package rol
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// RolPermisoHandler expone la asignación de permisos a roles (roles.permisos) y los permisos
+// efectivos de quien hace el pedido
+type RolPermisoHandler struct {
+	service *servicios.RolPermisoService
+}
+
+func NewRolPermisoHandler(s *servicios.RolPermisoService) *RolPermisoHandler {
+	return &RolPermisoHandler{service: s}
+}
+
+// ListarPermisosHandler maneja GET /api/v1/internal/roles/{id}/permisos
+func (h *RolPermisoHandler) ListarPermisosHandler(w http.ResponseWriter, r *http.Request) {
+	idRol, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+	resp, err := h.service.ListarDeRol(r.Context(), idPersona(r), idRol)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// ReemplazarPermisosHandler maneja PUT /api/v1/internal/roles/{id}/permisos
+func (h *RolPermisoHandler) ReemplazarPermisosHandler(w http.ResponseWriter, r *http.Request) {
+	idRol, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+	var req modelos.AsignarPermisosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "body", Mensaje: "JSON inválido"})
+		return
+	}
+	if req.Permisos == nil {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: "permisos", Mensaje: "es obligatorio (lista de ids, puede estar vacía)"})
+		return
+	}
+
+	resp, err := h.service.Reemplazar(r.Context(), idPersona(r), idRol, req.Permisos)
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// AsignarPermisoHandler maneja POST /api/v1/internal/roles/{id}/permisos/{id_permiso}
+func (h *RolPermisoHandler) AsignarPermisoHandler(w http.ResponseWriter, r *http.Request) {
+	idRol, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+	idPermiso, ok := idDeRuta(w, r, "id_permiso")
+	if !ok {
+		return
+	}
+	if err := h.service.Asignar(r.Context(), idPersona(r), idRol, idPermiso); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Permiso asignado al rol"})
+}
+
+// QuitarPermisoHandler maneja DELETE /api/v1/internal/roles/{id}/permisos/{id_permiso}
+func (h *RolPermisoHandler) QuitarPermisoHandler(w http.ResponseWriter, r *http.Request) {
+	idRol, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+	idPermiso, ok := idDeRuta(w, r, "id_permiso")
+	if !ok {
+		return
+	}
+	if err := h.service.Quitar(r.Context(), idPersona(r), idRol, idPermiso); err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, map[string]string{"mensaje": "Permiso quitado del rol"})
+}
+
+// PermisosEfectivosHandler maneja GET /api/v1/internal/perfil/permisos
+func (h *RolPermisoHandler) PermisosEfectivosHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.PermisosEfectivos(r.Context(), idPersona(r))
+	if err != nil {
+		utilidades.ManejarErrorHTTP(w, err)
+		return
+	}
+	utilidades.ResponderJSON(w, http.StatusOK, resp)
+}
+
+// idDeRuta lee un id entero positivo de la ruta
+func idDeRuta(w http.ResponseWriter, r *http.Request, variable string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[variable])
+	if err != nil || id <= 0 {
+		utilidades.ManejarErrorHTTP(w, utilidades.ErrValidation{Campo: variable, Mensaje: "debe ser un número entero positivo"})
+		return 0, false
+	}
+	return id, true
+}
+
+// idPersona devuelve la persona que hace el pedido (X-ID-Persona), o 0 si no viene
+func idPersona(r *http.Request) int {
+	id, _ := r.Context().Value("id_persona").(int)
+	return id
+}
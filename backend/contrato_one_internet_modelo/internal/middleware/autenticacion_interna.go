@@ -7,30 +7,31 @@ import (
 	"strconv"
 	"strings"
 	"log"
+
+	"contrato_one_internet_comun/autenticacion"
 )
 
+// AutenticacionInterna exige el token interno del controlador. Los 401 de este middleware
+// llevan el desafío invalid_token, que es lo único que hace pedir un token nuevo al cliente.
 func AutenticacionInterna(jwtSecret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				utilidades.ResponderError(w, http.StatusUnauthorized, "Cabecera de autorización requerida")
+				rechazarTokenInterno(w, "Cabecera de autorización requerida")
 				return
 			}
 
-			log.Printf("Authorization header: %q", authHeader)
-
 			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenStr == authHeader {
-				utilidades.ResponderError(w, http.StatusUnauthorized, "Formato de token inválido")
+				rechazarTokenInterno(w, "Formato de token inválido")
 				return
 			}
 
-			log.Printf("Token recibido para validación: %s", tokenStr)
-
-			err := utilidades.ValidarTokenInterno(tokenStr, []byte(jwtSecret))
+			_, err := utilidades.ValidarTokenInterno(tokenStr, []byte(jwtSecret))
 			if err != nil {
-				utilidades.ResponderError(w, http.StatusUnauthorized, "Token inválido o expirado")
+				log.Printf("Token interno rechazado para %s %s: %v", r.Method, r.URL.Path, err)
+				rechazarTokenInterno(w, "Token inválido o expirado")
 				return
 			}
 
@@ -45,4 +46,10 @@ func AutenticacionInterna(jwtSecret string) func(http.Handler) http.Handler {
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
-}
\ No newline at end of file
+}
+
+// rechazarTokenInterno responde 401 con el desafío que identifica al token interno como causa
+func rechazarTokenInterno(w http.ResponseWriter, mensaje string) {
+	w.Header().Set("WWW-Authenticate", autenticacion.DesafioTokenInvalido)
+	utilidades.ResponderError(w, http.StatusUnauthorized, mensaje)
+}
@@ -0,0 +1,86 @@
+package modelos
+
+// Filas de catálogo de las que depende el código. Los servicios las resuelven por nombre;
+// el comando sembrar_catalogos las crea y el modelo verifica al iniciar que existan.
+
+// Estados de conexión
+const (
+	EstadoConexionEnVerificacion               = "En verificacion"
+	EstadoConexionPendienteVerificacionTecnica = "Pendiente verificación técnica"
+	EstadoConexionFactible                     = "Factible"
+	EstadoConexionNoFactible                   = "No factible"
+	EstadoConexionPorConfigurar                = "Por configurar"
+	EstadoConexionActiva                       = "Activa"
+	EstadoConexionSuspendida                   = "Suspendida"
+	EstadoConexionBaja                         = "Baja"
+)
+
+// Estados de contrato
+const (
+	EstadoContratoEnVerificacion = "En verificacion"
+	EstadoContratoPendientePago  = "Pendiente de pago"
+	EstadoContratoNoFactible     = "No factible"
+	EstadoContratoVigente        = "Vigente"
+	EstadoContratoSuspendido     = "Suspendido"
+	EstadoContratoBaja           = "Baja"
+)
+
+// Roles
+const (
+	RolAdmin       = "admin"
+	RolVerificador = "verificador"
+	RolAtencion    = "atencion"
+	RolTecnico     = "tecnico"
+	RolCliente     = "cliente"
+)
+
+// Permisos que el controlador exige por endpoint. Qué roles los tienen se administra en
+// rol_permiso; sembrar_catalogos les da la asignación inicial al crearlos.
+const (
+	PermisoConexionVerSolicitudes        = "conexion.ver_solicitudes"
+	PermisoConexionConfirmarFactibilidad = "conexion.confirmar_factibilidad"
+	PermisoConexionRechazarFactibilidad  = "conexion.rechazar_factibilidad"
+	PermisoConexionCambiarEstado         = "conexion.cambiar_estado"
+	PermisoContratoCambiarEstado         = "contrato.cambiar_estado"
+	PermisoConexionVerHistorial          = "conexion.ver_historial"
+	PermisoUsuariosVer                   = "usuarios.ver"
+	PermisoUsuariosCrear                 = "usuarios.crear"
+	PermisoUsuariosRestablecer2FA        = "usuarios.restablecer_2fa"
+	PermisoUsuariosCerrarSesiones        = "usuarios.cerrar_sesiones"
+	PermisoInstalacionOperar             = "instalacion.operar"
+	PermisoInstalacionGestionar          = "instalacion.gestionar"
+	PermisoFacturacionEmitir             = "facturacion.emitir"
+	PermisoCuentasVer                    = "cuentas.ver"
+	PermisoCuentasGestionar              = "cuentas.gestionar"
+	PermisoPlanesEditar                  = "planes.editar"
+	PermisoPreciosGestionar              = "precios.gestionar"
+	PermisoCatalogosEditar               = "catalogos.editar"
+	PermisoDireccionesGestionar          = "direcciones.gestionar"
+	PermisoNotificacionesPlantillas      = "notificaciones.plantillas"
+	PermisoOutboxAdministrar             = "outbox.administrar"
+	PermisoLoginVerIntentos              = "login.ver_intentos"
+	PermisoLoginLevantarBloqueos         = "login.levantar_bloqueos"
+	PermisoRolesEditar                   = "roles.editar"
+	PermisoRolesPermisos                 = "roles.permisos"
+)
+
+// Vínculo persona-empresa de los clientes particulares
+const VinculoCliente = "cliente"
+
+// Empresa operadora: los contratos de particulares se celebran con ella
+const (
+	EmpresaOperadoraNombre      = "ONE Internet"
+	EmpresaOperadoraRazonSocial = "CREA Servicios Tecnológicos S.A.P.E.M."
+	EmpresaOperadoraCUIT        = "30716468832"
+)
+
+// Condiciones frente al IVA
+const (
+	TipoIVAResponsableInscripto = "Responsable Inscripto"
+	TipoIVAMonotributista       = "Monotributista"
+	TipoIVAExento               = "Exento"
+	TipoIVAConsumidorFinal      = "Consumidor Final"
+)
+
+// Tipo de empresa de la operadora
+const TipoEmpresaSociedadAnonima = "Sociedad Anónima"
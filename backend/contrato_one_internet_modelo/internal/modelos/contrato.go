@@ -11,6 +11,10 @@ type Contrato struct {
 	IDConexion         int        `json:"id_conexion"`
 	IDPlan             int        `json:"id_plan"`
 	CostoInstalacion   *float64   `json:"costo_instalacion,omitempty"`
+	PrecioPlan         *float64   `json:"precio_plan,omitempty"`         // abono mensual con el que se firmó
+	IDPromocion        *int       `json:"id_promocion,omitempty"`
+	PromocionDescuento *float64   `json:"promocion_descuento,omitempty"` // % sobre el abono
+	PromocionMeses     *int       `json:"promocion_meses,omitempty"`
 	FechaInicio        time.Time  `json:"fecha_inicio"`
 	FechaFin           *time.Time `json:"fecha_fin,omitempty"`
 	IDEstadoContrato   int        `json:"id_estado_contrato"`
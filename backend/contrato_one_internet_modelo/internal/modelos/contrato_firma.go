@@ -12,7 +12,8 @@ type ContratoFirma struct {
 	HashOriginal     string     `json:"hash_original"`
 	HashFirmado      *string    `json:"hash_firmado,omitempty"`
 	MetodoFirma      string     `json:"metodo_firma"`
-	TokenFirma       string     `json:"-"` // No exponemos el token en JSON
+	TokenFirma       string     `json:"-"` // En claro sólo al generarlo, para enviarlo por email
+	TokenFirmaHash   string     `json:"-"` // Lo que guarda la base (HMAC-SHA256)
 	TokenExpira      time.Time  `json:"token_expira"`
 	IntentosToken    int        `json:"intentos_token"`
 	PdfGenerado      *time.Time `json:"pdf_generado,omitempty"`
@@ -21,4 +22,5 @@ type ContratoFirma struct {
 	FechaFirma       *time.Time `json:"fecha_firma,omitempty"`
 	IpFirma          *string    `json:"ip_firma,omitempty"`
 	UserAgent        *string    `json:"user_agent,omitempty"`
+	CodigoVerificacion *string  `json:"codigo_verificacion,omitempty"` // Código público impreso (con QR) en el PDF
 }
@@ -0,0 +1,117 @@
+package modelos
+
+import "time"
+
+// Tipos de movimiento de la cuenta corriente. Los cargos suman al saldo; los pagos y las
+// notas de crédito lo restan.
+const (
+	MovimientoCargo       = "cargo"
+	MovimientoPago        = "pago"
+	MovimientoNotaCredito = "nota_credito"
+)
+
+// Medios de pago que el personal puede registrar a mano
+const (
+	MedioPagoEfectivo      = "efectivo"
+	MedioPagoTransferencia = "transferencia"
+	MedioPagoDebito        = "debito"
+	MedioPagoCheque        = "cheque"
+)
+
+// MovimientoCuenta es una fila de cuenta_movimiento. Importe es siempre positivo; Saldo es
+// el saldo de la cuenta después del movimiento.
+type MovimientoCuenta struct {
+	IDMovimiento     int       `json:"id_movimiento"`
+	IDPersona        int       `json:"-"`
+	Tipo             string    `json:"tipo"`
+	Concepto         string    `json:"concepto"`
+	Importe          float64   `json:"importe"`
+	Fecha            string    `json:"fecha"`
+	FechaVencimiento *string   `json:"fecha_vencimiento,omitempty"`
+	IDFactura        *int      `json:"id_factura,omitempty"`
+	IDPago           *int      `json:"id_pago,omitempty"`
+	MedioPago        *string   `json:"medio_pago,omitempty"`
+	Referencia       *string   `json:"referencia,omitempty"`
+	Saldo            float64   `json:"saldo"`
+	Creado           time.Time `json:"creado"`
+
+	IDUsuario *int `json:"-"`
+}
+
+// AntiguedadDeuda reparte lo adeudado según los días de atraso de cada cargo
+type AntiguedadDeuda struct {
+	PorVencer float64 `json:"por_vencer"`
+	Dias1a30  float64 `json:"dias_1_30"`
+	Dias31a60 float64 `json:"dias_31_60"`
+	Dias61a90 float64 `json:"dias_61_90"`
+	MasDe90   float64 `json:"mas_de_90"`
+}
+
+// ResumenCuenta es el estado de la cuenta de un cliente. Un saldo negativo es a favor del
+// cliente.
+type ResumenCuenta struct {
+	Saldo                 float64         `json:"saldo"`
+	SaldoVencido          float64         `json:"saldo_vencido"`
+	VencimientoMasAntiguo *string         `json:"vencimiento_mas_antiguo,omitempty"` // del cargo impago más viejo
+	DiasAtraso            int             `json:"dias_atraso"`
+	Antiguedad            AntiguedadDeuda `json:"antiguedad"`
+	ConexionesSuspendidas []int           `json:"conexiones_suspendidas"` // suspendidas por deuda
+}
+
+// CuentaResponse es la cuenta del cliente con sus movimientos paginados, del más reciente al
+// más antiguo
+type CuentaResponse struct {
+	IDPersona   int                `json:"id_persona"`
+	Resumen     ResumenCuenta      `json:"resumen"`
+	Movimientos []MovimientoCuenta `json:"movimientos"`
+	Page        int                `json:"page"`
+	Limit       int                `json:"limit"`
+	Total       int                `json:"total"`
+	TotalPages  int                `json:"totalPages"`
+}
+
+// DeudorCuenta es un renglón del listado de clientes con deuda
+type DeudorCuenta struct {
+	IDPersona int    `json:"id_persona"`
+	Nombre    string `json:"nombre"`
+	Documento string `json:"documento"`
+	ResumenCuenta
+}
+
+// DeudoresResponse es el listado de clientes con deuda y los totales por antigüedad
+type DeudoresResponse struct {
+	Deudores     []DeudorCuenta  `json:"deudores"`
+	Total        float64         `json:"total"`
+	TotalVencido float64         `json:"total_vencido"`
+	Antiguedad   AntiguedadDeuda `json:"antiguedad"`
+	DiasGracia   int             `json:"dias_gracia"`
+}
+
+// RegistrarPagoCuentaRequest registra un pago recibido fuera de la pasarela
+type RegistrarPagoCuentaRequest struct {
+	Importe    float64 `json:"importe"`
+	Fecha      string  `json:"fecha,omitempty"` // AAAA-MM-DD; vacío = hoy
+	MedioPago  string  `json:"medio_pago"`
+	Referencia *string `json:"referencia,omitempty"`
+}
+
+// RegistrarNotaCreditoRequest acredita un importe en la cuenta, opcionalmente sobre una factura
+type RegistrarNotaCreditoRequest struct {
+	Importe   float64 `json:"importe"`
+	Concepto  string  `json:"concepto"`
+	IDFactura *int    `json:"id_factura,omitempty"`
+}
+
+// MovimientoRegistradoResponse devuelve el movimiento registrado y la cuenta actualizada
+type MovimientoRegistradoResponse struct {
+	Movimiento    MovimientoCuenta `json:"movimiento"`
+	Resumen       ResumenCuenta    `json:"resumen"`
+	Restablecidas []int            `json:"restablecidas"` // conexiones reactivadas por el pago
+}
+
+// ResumenRevisionCuentas es el resultado de una corrida de la revisión de deuda
+type ResumenRevisionCuentas struct {
+	Suspendidas   []int `json:"suspendidas"`
+	Restablecidas []int `json:"restablecidas"`
+	Errores       int   `json:"errores"`
+}
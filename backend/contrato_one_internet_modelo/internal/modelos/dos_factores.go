@@ -0,0 +1,66 @@
+package modelos
+
+// Valores del claim amr (RFC 8176) con los que se autenticó una sesión
+const (
+	AMRPassword = "pwd" // contraseña
+	AMROTP      = "otp" // código de un solo uso (TOTP, email o recuperación)
+	AMRMFA      = "mfa" // más de un factor
+)
+
+// Métodos aceptados como segundo factor
+const (
+	MetodoDosFactoresTOTP         = "totp"
+	MetodoDosFactoresEmail        = "email"
+	MetodoDosFactoresRecuperacion = "recuperacion"
+)
+
+// DosFactoresUsuario es la configuración TOTP guardada de un usuario
+type DosFactoresUsuario struct {
+	IDUsuario      int
+	SecretoCifrado string
+	Activo         bool
+	UltimoPasoTOTP int64
+}
+
+// EstadoDosFactores resume la autenticación en dos pasos de un usuario
+type EstadoDosFactores struct {
+	Activo                    bool     `json:"activo"`
+	Pendiente                 bool     `json:"pendiente"` // TOTP iniciado pero sin confirmar
+	Metodos                   []string `json:"metodos,omitempty"`
+	CodigosRecuperacionLibres int      `json:"codigos_recuperacion_libres"`
+}
+
+// InicioTOTPResponse tiene lo necesario para cargar el secreto en la app autenticadora
+type InicioTOTPResponse struct {
+	Secreto string `json:"secreto"`
+	URI     string `json:"uri"` // otpauth://totp/...
+	QR      string `json:"qr"`  // PNG de la URI como data URI
+}
+
+// CodigoDosFactoresRequest lleva un código de la app autenticadora (o de recuperación)
+type CodigoDosFactoresRequest struct {
+	Codigo string `json:"codigo"`
+}
+
+// CodigosRecuperacionResponse devuelve los códigos de recuperación en claro: sólo se
+// muestran una vez
+type CodigosRecuperacionResponse struct {
+	CodigosRecuperacion []string `json:"codigos_recuperacion"`
+}
+
+// VerificarDosFactoresRequest es el segundo paso del login, pedido por el controlador una vez
+// validado su token de desafío
+type VerificarDosFactoresRequest struct {
+	IDUsuario int    `json:"id_usuario"`
+	Metodo    string `json:"metodo"`
+	Codigo    string `json:"codigo"`
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// CodigoEmailResponse es el código de respaldo que el controlador envía por email
+type CodigoEmailResponse struct {
+	Email     string `json:"email"`
+	Codigo    string `json:"codigo"`
+	ExpiresAt string `json:"expires_at"`
+}
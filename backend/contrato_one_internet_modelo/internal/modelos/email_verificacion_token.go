@@ -6,7 +6,7 @@ import "time"
 type EmailVerificacionToken struct {
 	ID         int       `json:"id"`
 	IDUsuario  int       `json:"id_usuario"`
-	Token      string    `json:"token"`
+	Token      string    `json:"token"` // En claro sólo al emitirlo: la tabla guarda token_hash
 	Creado     time.Time `json:"creado"`
 	Expiracion time.Time `json:"expiracion"`
 	Usado      bool      `json:"usado"`
@@ -21,4 +21,15 @@ type Empresa struct {
 	Creado            time.Time  `json:"creado"`
 	UltimoCambio      time.Time  `json:"ultimo_cambio"`
 	Borrado           *time.Time `json:"borrado,omitempty"`
-}
\ No newline at end of file
+}
+// EmpresaVinculada representa una empresa junto al vínculo y cargo de una persona en ella.
+type EmpresaVinculada struct {
+	IDEmpresa       int     `json:"id_empresa"`
+	NombreComercial string  `json:"nombre_comercial"`
+	RazonSocial     string  `json:"razon_social"`
+	Cuit            string  `json:"cuit"`
+	IDVinculo       int     `json:"id_vinculo"`
+	NombreVinculo   string  `json:"nombre_vinculo"`
+	IDCargo         *int    `json:"id_cargo,omitempty"`
+	NombreCargo     *string `json:"nombre_cargo,omitempty"`
+}
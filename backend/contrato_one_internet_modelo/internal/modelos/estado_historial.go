@@ -0,0 +1,54 @@
+package modelos
+
+import "time"
+
+// CambioEstado es una fila del historial de estados de una conexión o de un contrato
+type CambioEstado struct {
+	IDHistorial      int       `json:"id_historial"`
+	IDEntidad        int       `json:"-"`
+	IDEstadoAnterior *int      `json:"id_estado_anterior,omitempty"`
+	IDEstadoNuevo    int       `json:"id_estado_nuevo"`
+	IDUsuario        *int      `json:"id_usuario,omitempty"`
+	Observacion      *string   `json:"observacion,omitempty"`
+	Creado           time.Time `json:"creado"`
+}
+
+// CambiarEstadoRequest es el pedido de un operador para mover una conexión o un contrato de estado
+type CambiarEstadoRequest struct {
+	Estado      string  `json:"estado"`
+	Observacion *string `json:"observacion,omitempty"`
+}
+
+// CambiarEstadoResponse informa la transición aplicada
+type CambiarEstadoResponse struct {
+	Mensaje        string `json:"mensaje"`
+	ID             int    `json:"id"`
+	EstadoAnterior string `json:"estado_anterior"`
+	Estado         string `json:"estado"`
+}
+
+// ResponsableCambio es el usuario que provocó un cambio de estado
+type ResponsableCambio struct {
+	IDUsuario int    `json:"id_usuario,omitempty"`
+	Nombre    string `json:"nombre"`
+	Email     string `json:"email,omitempty"`
+}
+
+// EventoHistorial es un cambio de estado de la conexión o de uno de sus contratos
+type EventoHistorial struct {
+	Entidad        string             `json:"entidad"` // conexion, contrato
+	ID             int                `json:"id"`
+	Fecha          time.Time          `json:"fecha"`
+	EstadoAnterior *string            `json:"estado_anterior,omitempty"`
+	Estado         string             `json:"estado"`
+	Observacion    *string            `json:"observacion,omitempty"`
+	Responsable    *ResponsableCambio `json:"responsable,omitempty"` // nil: cambio del sistema
+}
+
+// HistorialConexionResponse es la línea de tiempo de una conexión y sus contratos
+type HistorialConexionResponse struct {
+	IDConexion   int               `json:"id_conexion"`
+	NroConexion  int               `json:"nro_conexion"`
+	EstadoActual string            `json:"estado_actual"`
+	Eventos      []EventoHistorial `json:"eventos"`
+}
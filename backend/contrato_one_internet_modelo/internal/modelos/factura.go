@@ -0,0 +1,132 @@
+package modelos
+
+import "time"
+
+// Estados de una factura
+const (
+	EstadoFacturaEmitida = "emitida"
+	EstadoFacturaAnulada = "anulada"
+)
+
+// Tipos de comprobante: un emisor Responsable Inscripto emite A a Responsables Inscriptos y
+// Monotributistas y B al resto; un emisor Monotributista o Exento emite C
+const (
+	ComprobanteFacturaA = "A"
+	ComprobanteFacturaB = "B"
+	ComprobanteFacturaC = "C"
+)
+
+// Estados de la autorización de AFIP (CAE). Sin factura electrónica, AfipEstado es nil.
+const (
+	EstadoAfipPendiente  = "pendiente"
+	EstadoAfipAutorizada = "autorizada"
+	EstadoAfipRechazada  = "rechazada"
+)
+
+// Factura es el comprobante mensual de un contrato. Los datos del cliente se guardan al
+// emitirla para que un cambio posterior del perfil no altere comprobantes ya emitidos.
+type Factura struct {
+	IDFactura           int           `json:"id_factura"`
+	TipoComprobante     string        `json:"tipo_comprobante"`
+	PuntoVenta          int           `json:"punto_venta"`
+	Numero              int           `json:"numero"`             // 0 mientras espera el CAE
+	NumeroComprobante   string        `json:"numero_comprobante"` // 0001-00000123
+	IDContrato          int           `json:"id_contrato"`
+	IDConexion          int           `json:"id_conexion"`
+	NroConexion         int           `json:"nro_conexion"`
+	IDPersona           int           `json:"id_persona"`
+	Periodo             string        `json:"periodo"` // AAAA-MM
+	PeriodoDesde        string        `json:"periodo_desde"`
+	PeriodoHasta        string        `json:"periodo_hasta"`
+	FechaEmision        string        `json:"fecha_emision"`
+	FechaVencimiento    string        `json:"fecha_vencimiento"`
+	ClienteNombre       string        `json:"cliente_nombre"`
+	ClienteDocumento    string        `json:"cliente_documento"`
+	ClienteCondicionIVA string        `json:"cliente_condicion_iva"`
+	ClienteDomicilio    string        `json:"cliente_domicilio"`
+	AlicuotaIVA         float64       `json:"alicuota_iva"`
+	Neto                float64       `json:"neto"`
+	IVA                 float64       `json:"iva"`
+	Total               float64       `json:"total"`
+	Estado              string        `json:"estado"`
+	AfipEstado          *string       `json:"afip_estado,omitempty"`
+	CAE                 *string       `json:"cae,omitempty"`
+	CAEVencimiento      *string       `json:"cae_vencimiento,omitempty"`
+	PDFDisponible       bool          `json:"pdf_disponible"`
+	Items               []ItemFactura `json:"items,omitempty"`
+	Creado              time.Time     `json:"creado"`
+
+	PDFPath           *string `json:"-"`
+	PDFHash           *string `json:"-"`
+	AfipObservaciones *string `json:"-"`
+}
+
+// ItemFactura es un renglón de la factura. Cantidad es la fracción del mes en el abono
+// prorrateado y 1 en el resto.
+type ItemFactura struct {
+	Concepto       string  `json:"concepto"`
+	Cantidad       float64 `json:"cantidad"`
+	PrecioUnitario float64 `json:"precio_unitario"`
+	Neto           float64 `json:"neto"`
+	IVA            float64 `json:"iva"`
+	Total          float64 `json:"total"`
+}
+
+// DatosFacturacionContrato son los datos del contrato con los que se arma su factura
+type DatosFacturacionContrato struct {
+	IDContrato       int
+	IDConexion       int
+	NroConexion      int
+	IDPersona        int
+	PlanNombre       string
+	PlanVelocidad    int
+	PlanPrecio       float64
+	CostoInstalacion float64
+	FechaInstalacion time.Time
+	FechaFin         *time.Time
+	TieneFacturas    bool
+
+	// Promoción con la que se firmó el contrato: descuento sobre el abono de los primeros
+	// PromocionMeses meses de servicio (0 = sin promoción)
+	PromocionNombre    string
+	PromocionDescuento float64
+	PromocionMeses     int
+
+	ClienteNombre       string
+	ClienteDocumento    string
+	ClienteCondicionIVA string
+	ClienteDomicilio    string
+}
+
+// NumeracionFactura es el último número usado de un tipo de comprobante y punto de venta
+type NumeracionFactura struct {
+	TipoComprobante string
+	PuntoVenta      int
+	UltimoNumero    int
+}
+
+// FacturasResponse representa la respuesta paginada de facturas
+type FacturasResponse struct {
+	Facturas   []Factura `json:"facturas"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	Total      int       `json:"total"`
+	TotalPages int       `json:"totalPages"`
+}
+
+// GenerarFacturacionRequest pide generar las facturas de un período (AAAA-MM)
+type GenerarFacturacionRequest struct {
+	Periodo string `json:"periodo"`
+}
+
+// ResumenFacturacion es el resultado de generar las facturas de un período
+type ResumenFacturacion struct {
+	Periodo   string `json:"periodo"`
+	Generadas []int  `json:"generadas"` // id_factura emitidas en esta corrida
+	Errores   int    `json:"errores"`
+
+	// Factura electrónica: facturas (de esta corrida o anteriores) que obtuvieron el CAE o
+	// que AFIP rechazó
+	Autorizadas int `json:"autorizadas"`
+	Rechazadas  int `json:"rechazadas"`
+}
@@ -3,7 +3,14 @@ package modelos
 type ModeloLoginResponse struct {
 	IDUsuario        int      `json:"id_usuario"`
 	IDPersona        int      `json:"id_persona"`
+	IDSesion         int      `json:"id_sesion,omitempty"` // Sesión a la que pertenece el refresh token
 	Roles            []string `json:"roles"`
+	Permisos         []string `json:"permisos"` // Los que suman los roles, para el JWT
 	RefreshToken     string   `json:"refresh_token,omitempty"`
 	RefreshExpiresAt string   `json:"refresh_expires_at,omitempty"`
+	DispositivoNuevo bool     `json:"dispositivo_nuevo,omitempty"` // Primer login exitoso desde esta IP y user agent
+	Email            string   `json:"email,omitempty"`             // Para que el controlador avise del login por email
+	AMR              []string `json:"amr,omitempty"`               // Métodos con los que se autenticó la sesión
+	Requiere2FA      bool     `json:"requiere_2fa,omitempty"`      // Falta el segundo factor: no hay refresh token todavía
+	Metodos2FA       []string `json:"metodos_2fa,omitempty"`
 }
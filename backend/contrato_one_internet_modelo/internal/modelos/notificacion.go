@@ -9,12 +9,24 @@ type Notificacion struct {
 	Titulo         string     `json:"titulo"`
 	Mensaje        string     `json:"mensaje"`
 	Leido          int        `json:"leido"`
+	Archivada      int        `json:"archivada"`
 	RolDestino     *string    `json:"rol_destino"`
 	IDConexion     *int       `json:"id_conexion"`
 	IDContrato     *int       `json:"id_contrato"`
 	IDPago         *int       `json:"id_pago"`
 	Observacion    *string    `json:"observacion,omitempty"` // Detalle técnico/operativo interno opcional
 	Creado         time.Time  `json:"creado"`
+
+	IDPersonaReceptor *int `json:"id_persona_receptor,omitempty"` // Sólo en el feed en tiempo real
+}
+
+// FiltroNotificaciones agrupa los filtros opcionales de la bandeja de una persona
+type FiltroNotificaciones struct {
+	Leido      *int
+	Tipo       string
+	IDContrato *int
+	IDConexion *int
+	Archivadas bool // true lista el archivo en lugar de la bandeja
 }
 
 // NotificacionesResponse representa la respuesta paginada de notificaciones
@@ -25,3 +37,20 @@ type NotificacionesResponse struct {
 	Total          int            `json:"total"`
 	TotalPages     int            `json:"totalPages"`
 }
+
+// NotificacionesFeedResponse es la respuesta del feed incremental (id_notificacion > desde_id)
+// que usa el controlador para el streaming en tiempo real
+type NotificacionesFeedResponse struct {
+	Notificaciones []Notificacion `json:"notificaciones"`
+	UltimoID       int            `json:"ultimo_id"`
+}
+
+// NotificacionesNoLeidasResponse es el contador de la bandeja (sin archivadas)
+type NotificacionesNoLeidasResponse struct {
+	NoLeidas int `json:"no_leidas"`
+}
+
+// NotificacionesActualizadasResponse informa cuántas notificaciones cambiaron en una operación masiva
+type NotificacionesActualizadasResponse struct {
+	Actualizadas int `json:"actualizadas"`
+}
@@ -0,0 +1,98 @@
+package modelos
+
+import "time"
+
+// Canales por los que se entrega una notificación además de la bandeja in-app
+const (
+	CanalEmail    = "email"
+	CanalSMS      = "sms"
+	CanalWhatsApp = "whatsapp"
+	CanalPush     = "push"
+)
+
+// CanalesNotificacion lista los canales soportados, en el orden en que se muestran
+var CanalesNotificacion = []string{CanalEmail, CanalSMS, CanalWhatsApp, CanalPush}
+
+// Estados de una entrega por canal
+const (
+	EstadoEntregaPendiente = "pendiente"
+	EstadoEntregaEnviada   = "enviada"
+	EstadoEntregaFallida   = "fallida"
+	EstadoEntregaOmitida   = "omitida" // sin destino (p. ej. sin teléfono o sin suscripción push)
+)
+
+// TipoNotificacionTodos se usa en preferencias y plantillas para "cualquier tipo"
+const TipoNotificacionTodos = "*"
+
+// EntregaNotificacion es el estado de envío de una notificación por un canal
+type EntregaNotificacion struct {
+	IDEntrega      int        `json:"id_entrega"`
+	IDNotificacion int        `json:"id_notificacion"`
+	IDPersona      int        `json:"id_persona"`
+	Canal          string     `json:"canal"`
+	Estado         string     `json:"estado"`
+	Intentos       int        `json:"intentos"`
+	ProximoIntento *time.Time `json:"proximo_intento,omitempty"`
+	IDProveedor    *string    `json:"id_proveedor,omitempty"` // ID del mensaje en el proveedor (SMS, WhatsApp, etc.)
+	UltimoError    *string    `json:"ultimo_error,omitempty"`
+	Enviado        *time.Time `json:"enviado,omitempty"`
+	Creado         time.Time  `json:"creado"`
+	UltimoCambio   time.Time  `json:"ultimo_cambio"`
+}
+
+// EntregaPendiente es una entrega reservada para el despachador, con el mensaje ya
+// renderizado con la plantilla del tipo/canal y el destino resuelto.
+type EntregaPendiente struct {
+	IDEntrega      int               `json:"id_entrega"`
+	IDNotificacion int               `json:"id_notificacion"`
+	Tipo           string            `json:"tipo"`
+	Canal          string            `json:"canal"`
+	Destino        string            `json:"destino,omitempty"` // email o teléfono
+	Nombre         string            `json:"nombre"`
+	Asunto         string            `json:"asunto"`
+	Cuerpo         string            `json:"cuerpo"`
+	Intentos       int               `json:"intentos"`
+	Suscripciones  []SuscripcionPush `json:"suscripciones,omitempty"` // sólo canal push
+}
+
+// ResultadoEntrega es lo que informa el despachador después de intentar un envío
+type ResultadoEntrega struct {
+	Enviada               bool     `json:"enviada"`
+	IDProveedor           string   `json:"id_proveedor,omitempty"`
+	Error                 string   `json:"error,omitempty"`
+	SuscripcionesVencidas []string `json:"suscripciones_vencidas,omitempty"` // endpoints push que respondieron 404/410
+}
+
+// PreferenciaNotificacion habilita o deshabilita un canal para un tipo de notificación
+// (o para todos con Tipo = "*"). La preferencia de un tipo concreto pisa a la general.
+type PreferenciaNotificacion struct {
+	Canal      string `json:"canal"`
+	Tipo       string `json:"tipo"`
+	Habilitado bool   `json:"habilitado"`
+}
+
+// PreferenciasNotificacionResponse agrupa las preferencias guardadas y el resultado efectivo por canal
+type PreferenciasNotificacionResponse struct {
+	Preferencias []PreferenciaNotificacion `json:"preferencias"`
+	Canales      map[string]bool           `json:"canales"` // estado general (tipo "*") de cada canal
+}
+
+// PlantillaNotificacion define asunto y cuerpo (text/template) de un tipo de notificación en un canal.
+// Campos disponibles: .Nombre, .Titulo, .Mensaje, .Tipo
+type PlantillaNotificacion struct {
+	IDPlantilla  int       `json:"id_plantilla"`
+	Tipo         string    `json:"tipo"`
+	Canal        string    `json:"canal"`
+	Asunto       string    `json:"asunto"`
+	Cuerpo       string    `json:"cuerpo"`
+	Activo       bool      `json:"activo"`
+	UltimoCambio time.Time `json:"ultimo_cambio"`
+}
+
+// SuscripcionPush es una suscripción Web Push de un navegador (PushSubscription.toJSON())
+type SuscripcionPush struct {
+	IDSuscripcion int    `json:"id_suscripcion,omitempty"`
+	Endpoint      string `json:"endpoint"`
+	P256dh        string `json:"p256dh"`
+	Auth          string `json:"auth"`
+}
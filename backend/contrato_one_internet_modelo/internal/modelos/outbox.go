@@ -0,0 +1,113 @@
+package modelos
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Estados de un evento del outbox
+const (
+	EstadoOutboxPendiente = "pendiente"
+	EstadoOutboxProcesado = "procesado"
+	EstadoOutboxFallido   = "fallido" // dead-letter: agotó los reintentos, requiere intervención
+)
+
+// Tipos de evento de dominio. Se registran en la misma transacción que el cambio de estado
+// y el despachador del outbox los convierte en notificaciones.
+const (
+	EventoConexionSolicitada   = "conexion.solicitada"
+	EventoConexionFactible     = "conexion.factible"
+	EventoConexionNoFactible   = "conexion.no_factible"
+	EventoPagoAprobado         = "pago.aprobado"
+	EventoPagoRechazado        = "pago.rechazado"
+	EventoFirmaIniciada        = "firma.iniciada"
+	EventoFirmaGuardada        = "firma.guardada"
+	EventoFirmaTokenRegenerado = "firma.token_regenerado"
+	EventoContratoFirmado      = "contrato.firmado"
+
+	EventoInstalacionAsignada   = "instalacion.asignada"
+	EventoInstalacionProgramada = "instalacion.programada"
+	EventoInstalacionCompletada = "instalacion.completada"
+
+	EventoTurnoCancelado    = "turno.cancelado"
+	EventoTurnoRecordatorio = "turno.recordatorio"
+
+	EventoFacturaEmitida = "factura.emitida"
+
+	EventoCuentaConexionSuspendida   = "cuenta.conexion_suspendida"
+	EventoCuentaConexionRestablecida = "cuenta.conexion_restablecida"
+
+	EventoPlanPrecioProgramado = "plan.precio_programado"
+)
+
+// EventoOutbox representa una fila de outbox_evento
+type EventoOutbox struct {
+	IDEvento       int             `json:"id_evento"`
+	Tipo           string          `json:"tipo"`
+	Payload        json.RawMessage `json:"payload"`
+	Estado         string          `json:"estado"`
+	Intentos       int             `json:"intentos"`
+	ProximoIntento time.Time       `json:"proximo_intento"`
+	UltimoError    *string         `json:"ultimo_error,omitempty"`
+	Creado         time.Time       `json:"creado"`
+	Procesado      *time.Time      `json:"procesado,omitempty"`
+}
+
+// PayloadConexionSolicitada acompaña a EventoConexionSolicitada
+type PayloadConexionSolicitada struct {
+	IDPersona             int    `json:"id_persona"`
+	IDConexion            int    `json:"id_conexion"`
+	IDContrato            int    `json:"id_contrato"`
+	IDPlan                int    `json:"id_plan"`
+	NroConexion           int    `json:"nro_conexion"`
+	FactibilidadInmediata bool   `json:"factibilidad_inmediata"`
+	Distrito              string `json:"distrito"`
+	Departamento          string `json:"departamento"`
+	Provincia             string `json:"provincia"`
+	Observaciones         string `json:"observaciones,omitempty"`
+	Fecha                 string `json:"fecha"`
+}
+
+// PayloadConexion acompaña a los eventos de factibilidad
+type PayloadConexion struct {
+	IDConexion int `json:"id_conexion"`
+}
+
+// PayloadPago acompaña a los eventos de pago
+type PayloadPago struct {
+	IDPago  int    `json:"id_pago"`
+	Detalle string `json:"detalle,omitempty"`
+}
+
+// PayloadContrato acompaña a los eventos de firma
+type PayloadContrato struct {
+	IDContrato int `json:"id_contrato"`
+}
+
+// PayloadOrdenInstalacion acompaña a los eventos de instalación
+type PayloadOrdenInstalacion struct {
+	IDOrden int `json:"id_orden"`
+}
+
+// PayloadTurnoCancelado acompaña a EventoTurnoCancelado con el turno que tenía la orden
+type PayloadTurnoCancelado struct {
+	IDOrden int       `json:"id_orden"`
+	Inicio  time.Time `json:"inicio"`
+	Fin     time.Time `json:"fin"`
+}
+
+// PayloadFactura acompaña a los eventos de facturación
+type PayloadFactura struct {
+	IDFactura int `json:"id_factura"`
+}
+
+// PayloadConexionCuenta acompaña a la suspensión y al restablecimiento por deuda
+type PayloadConexionCuenta struct {
+	IDConexion   int     `json:"id_conexion"`
+	SaldoVencido float64 `json:"saldo_vencido,omitempty"`
+}
+
+// PayloadPlanPrecio acompaña al aviso de un precio nuevo de un plan
+type PayloadPlanPrecio struct {
+	IDPlanPrecio int `json:"id_plan_precio"`
+}
@@ -0,0 +1,31 @@
+package modelos
+
+import "time"
+
+// Estados posibles de un pago
+const (
+	EstadoPagoPendiente = "pendiente"
+	EstadoPagoAprobado  = "aprobado"
+	EstadoPagoRechazado = "rechazado"
+	EstadoPagoCancelado = "cancelado"
+)
+
+// Pago representa la tabla pago en la base de datos.
+// Cada pago está asociado a un contrato (por ahora, el costo de instalación).
+type Pago struct {
+	IDPago          int        `json:"id_pago"`
+	IDContrato      int        `json:"id_contrato"`
+	IDPersona       int        `json:"id_persona"`
+	Monto           float64    `json:"monto"`
+	Moneda          string     `json:"moneda"`
+	Concepto        string     `json:"concepto"`
+	Proveedor       string     `json:"proveedor"`
+	IDPreferencia   *string    `json:"id_preferencia,omitempty"` // ID del checkout en el proveedor
+	IDTransaccion   *string    `json:"id_transaccion,omitempty"` // ID del pago en el proveedor
+	URLCheckout     *string    `json:"url_checkout,omitempty"`
+	Estado          string     `json:"estado"`
+	DetalleEstado   *string    `json:"detalle_estado,omitempty"`
+	FechaAprobacion *time.Time `json:"fecha_aprobacion,omitempty"`
+	Creado          time.Time  `json:"creado"`
+	UltimoCambio    time.Time  `json:"ultimo_cambio"`
+}
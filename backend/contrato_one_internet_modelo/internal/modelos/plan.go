@@ -13,7 +13,9 @@ type Plan struct {
     IDTipoPlan    int      `json:"id_tipo_plan"`
     Nombre        string   `json:"nombre"`
     VelocidadMbps int      `json:"velocidad_mbps"`
-    Precio        float64  `json:"precio"`
+    Precio        float64  `json:"precio"` // precio que rige hoy
+    PrecioProximo      *float64 `json:"precio_proximo,omitempty"`       // precio ya anunciado que rige desde PrecioProximoDesde
+    PrecioProximoDesde *string  `json:"precio_proximo_desde,omitempty"`
     Descripcion   *string  `json:"descripcion,omitempty"`
     FechaInicio   *string  `json:"fecha_inicio,omitempty"`
     FechaFin      *string  `json:"fecha_fin,omitempty"`
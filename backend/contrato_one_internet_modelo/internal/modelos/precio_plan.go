@@ -0,0 +1,90 @@
+package modelos
+
+import "time"
+
+// PrecioPlan es una versión del precio mensual de un plan (neto de IVA) con su vigencia
+type PrecioPlan struct {
+	IDPlanPrecio int       `json:"id_plan_precio"`
+	IDPlan       int       `json:"id_plan"`
+	Precio       float64   `json:"precio"`
+	FechaInicio  string    `json:"fecha_inicio"`
+	FechaFin     *string   `json:"fecha_fin,omitempty"`
+	Motivo       *string   `json:"motivo,omitempty"`
+	IDUsuario    *int      `json:"-"`
+	Creado       time.Time `json:"creado"`
+}
+
+// HistorialPreciosResponse es la lista de precios de un plan, del más reciente al más antiguo
+type HistorialPreciosResponse struct {
+	IDPlan  int          `json:"id_plan"`
+	Nombre  string       `json:"nombre"`
+	Precios []PrecioPlan `json:"precios"`
+}
+
+// PrecioPlanNuevo es el precio que pasa a tener un plan en un ajuste
+type PrecioPlanNuevo struct {
+	IDPlan int     `json:"id_plan"`
+	Precio float64 `json:"precio"`
+}
+
+// AjustePreciosRequest programa precios nuevos desde una fecha: por porcentaje sobre el
+// precio vigente (de los planes indicados o de todos) o con el precio de cada plan
+type AjustePreciosRequest struct {
+	VigenteDesde string            `json:"vigente_desde"` // AAAA-MM-DD
+	Porcentaje   *float64          `json:"porcentaje,omitempty"`
+	IDPlanes     []int             `json:"id_planes,omitempty"` // con porcentaje; vacío = todos los planes vigentes
+	Precios      []PrecioPlanNuevo `json:"precios,omitempty"`
+	Motivo       *string           `json:"motivo,omitempty"`
+}
+
+// PrecioAjustado es el resultado del ajuste de un plan
+type PrecioAjustado struct {
+	IDPlan            int     `json:"id_plan"`
+	Nombre            string  `json:"nombre"`
+	IDPlanPrecio      int     `json:"id_plan_precio"`
+	PrecioAnterior    float64 `json:"precio_anterior"`
+	PrecioNuevo       float64 `json:"precio_nuevo"`
+	ContratosAvisados int     `json:"contratos_avisados"`
+}
+
+// AjustePreciosResponse resume el ajuste programado
+type AjustePreciosResponse struct {
+	VigenteDesde      string           `json:"vigente_desde"`
+	Planes            []PrecioAjustado `json:"planes"`
+	ContratosAvisados int              `json:"contratos_avisados"`
+}
+
+// ContratoAvisoPrecio es un contrato alcanzado por un precio nuevo de su plan
+type ContratoAvisoPrecio struct {
+	IDContrato   int
+	IDPersona    int
+	IDConexion   int
+	NroConexion  int
+	PlanNombre   string
+	PrecioActual float64
+}
+
+// Promocion es un descuento porcentual sobre el abono durante los primeros meses de servicio.
+// Se puede contratar entre FechaInicio y FechaFin; sin IDPlan vale para todos los planes.
+type Promocion struct {
+	IDPromocion         int     `json:"id_promocion"`
+	Nombre              string  `json:"nombre"`
+	Descripcion         *string `json:"descripcion,omitempty"`
+	DescuentoPorcentaje float64 `json:"descuento_porcentaje"`
+	DuracionMeses       int     `json:"duracion_meses"`
+	IDPlan              *int    `json:"id_plan,omitempty"`
+	FechaInicio         string  `json:"fecha_inicio"`
+	FechaFin            *string `json:"fecha_fin,omitempty"`
+	IDUsuarioCreador    *int    `json:"-"`
+}
+
+// CrearPromocionRequest es el alta de una promoción
+type CrearPromocionRequest struct {
+	Nombre              string  `json:"nombre"`
+	Descripcion         *string `json:"descripcion,omitempty"`
+	DescuentoPorcentaje float64 `json:"descuento_porcentaje"`
+	DuracionMeses       int     `json:"duracion_meses"`
+	IDPlan              *int    `json:"id_plan,omitempty"`
+	FechaInicio         string  `json:"fecha_inicio,omitempty"` // vacía = hoy
+	FechaFin            *string `json:"fecha_fin,omitempty"`
+}
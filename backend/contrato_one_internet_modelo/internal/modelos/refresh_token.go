@@ -6,8 +6,12 @@ import "time"
 type RefreshToken struct {
     ID         int       `json:"id"`
     IDUsuario  int       `json:"id_usuario"`
-    Token      string    `json:"token"`
+    IDSesion   *int      `json:"id_sesion,omitempty"` // Sesión (familia de tokens); nil en tokens previos a las sesiones
+    Token      string    `json:"-"` // En claro sólo al emitirlo; en la base queda TokenHash
+    TokenHash  string    `json:"-"`
+    AMR        string    `json:"amr"` // Métodos de autenticación de la sesión, separados por espacio
     Creado     time.Time `json:"creado"`
     Expiracion time.Time `json:"expiracion"`
     Revocado   bool      `json:"revocado"`
+    Rotado     *time.Time `json:"rotado,omitempty"` // Cuándo se reemplazó por otro al refrescar
 }
@@ -0,0 +1,19 @@
+package modelos
+
+// RolPermisosResponse son los permisos asignados a un rol
+type RolPermisosResponse struct {
+	IDRol    int       `json:"id_rol"`
+	Rol      string    `json:"rol"`
+	Permisos []Permiso `json:"permisos"`
+}
+
+// AsignarPermisosRequest reemplaza el conjunto de permisos de un rol
+type AsignarPermisosRequest struct {
+	Permisos []int `json:"permisos"` // ids de permiso; vacío le quita todos
+}
+
+// PermisosEfectivosResponse son los permisos que suman los roles del usuario
+type PermisosEfectivosResponse struct {
+	Roles    []string `json:"roles"`
+	Permisos []string `json:"permisos"`
+}
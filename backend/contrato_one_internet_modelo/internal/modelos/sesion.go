@@ -0,0 +1,34 @@
+package modelos
+
+import "time"
+
+// Motivos con los que se revoca una sesión
+const (
+	MotivoSesionLogout      = "logout"
+	MotivoSesionUsuario     = "cerrada por el usuario"
+	MotivoSesionAdmin       = "cierre forzado por un administrador"
+	MotivoSesionReuso       = "reuso de refresh token"
+	MotivoSesionDosFactores = "autenticación en dos pasos"
+)
+
+// Sesion es un login del usuario con los refresh tokens que se emitieron al rotarlo
+type Sesion struct {
+	IDSesion   int       `json:"id_sesion"`
+	IDUsuario  int       `json:"-"`
+	IP         *string   `json:"ip,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	Creado     time.Time `json:"creado"`
+	UltimoUso  time.Time `json:"ultimo_uso"`
+	Expiracion time.Time `json:"expiracion"`
+	Actual     bool      `json:"actual"` // Es la sesión desde la que se hace el pedido
+}
+
+// SesionesResponse es la lista de sesiones vigentes, de la usada más recientemente a la más vieja
+type SesionesResponse struct {
+	Sesiones []Sesion `json:"sesiones"`
+}
+
+// SesionesRevocadasResponse informa cuántas sesiones se cerraron
+type SesionesRevocadasResponse struct {
+	Revocadas int `json:"revocadas"`
+}
@@ -0,0 +1,85 @@
+package modelos
+
+import "time"
+
+// ZonaTecnico es un distrito que cubre un técnico
+type ZonaTecnico struct {
+	Distrito     string `json:"distrito"`
+	Departamento string `json:"departamento"`
+}
+
+// FranjaAgenda es un horario semanal de trabajo del técnico. Las horas son "HH:MM".
+type FranjaAgenda struct {
+	DiaSemana  int    `json:"dia_semana"` // 1 = lunes ... 7 = domingo
+	HoraInicio string `json:"hora_inicio"`
+	HoraFin    string `json:"hora_fin"`
+}
+
+// FranjaTecnico es una franja de agenda con el técnico al que pertenece
+type FranjaTecnico struct {
+	IDTecnico int
+	FranjaAgenda
+}
+
+// AgendaTecnico son las zonas y los horarios en que un técnico toma instalaciones
+type AgendaTecnico struct {
+	IDTecnico int            `json:"id_tecnico"`
+	Zonas     []ZonaTecnico  `json:"zonas"`
+	Franjas   []FranjaAgenda `json:"franjas"`
+}
+
+// TurnoOcupado es el turno de una orden abierta de un técnico
+type TurnoOcupado struct {
+	IDTecnico int
+	Inicio    time.Time
+	Fin       time.Time
+}
+
+// DatosConexionTurno son los datos de la conexión que se usan para ofrecer y reservar turnos
+type DatosConexionTurno struct {
+	IDConexion   int
+	NroConexion  int
+	IDPersona    int
+	Distrito     string
+	Departamento string
+	Email        string
+	Nombre       string
+}
+
+// VentanaTurno es una ventana de visita que el cliente puede reservar
+type VentanaTurno struct {
+	Inicio time.Time `json:"inicio"`
+	Fin    time.Time `json:"fin"`
+}
+
+// DisponibilidadTurnosResponse es la respuesta de GET /perfil/conexiones/{id}/turnos
+type DisponibilidadTurnosResponse struct {
+	IDConexion          int            `json:"id_conexion"`
+	IDOrden             int            `json:"id_orden"`
+	TurnoActual         *VentanaTurno  `json:"turno_actual"`
+	Reprogramaciones    int            `json:"reprogramaciones"`
+	MaxReprogramaciones int            `json:"max_reprogramaciones"`
+	PuedeModificar      bool           `json:"puede_modificar"`
+	Ventanas            []VentanaTurno `json:"ventanas"`
+}
+
+// ReservarTurnoRequest elige una de las ventanas ofrecidas
+type ReservarTurnoRequest struct {
+	Inicio time.Time `json:"inicio"`
+}
+
+// CorreoTurno son los datos para que el controlador envíe el correo de confirmación
+type CorreoTurno struct {
+	Email  string `json:"email"`
+	Nombre string `json:"nombre"`
+}
+
+// TurnoResponse es la respuesta de reservar o cancelar un turno
+type TurnoResponse struct {
+	Mensaje     string        `json:"mensaje"`
+	IDConexion  int           `json:"id_conexion"`
+	NroConexion int           `json:"nro_conexion"`
+	IDOrden     int           `json:"id_orden"`
+	Turno       *VentanaTurno `json:"turno"`
+	Correo      *CorreoTurno  `json:"correo,omitempty"`
+}
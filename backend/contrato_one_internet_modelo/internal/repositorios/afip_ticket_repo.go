@@ -0,0 +1,58 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/afip"
+)
+
+// AfipTicketRepo guarda los tickets de acceso del WSAA de un modo (fake, homologación o
+// producción). Implementa afip.CacheTickets.
+type AfipTicketRepo struct {
+	db   Execer
+	modo string
+}
+
+// NewAfipTicketRepo crea una nueva instancia de AfipTicketRepo
+func NewAfipTicketRepo(db Execer, modo string) *AfipTicketRepo {
+	return &AfipTicketRepo{db: db, modo: modo}
+}
+
+// Obtener devuelve el último ticket del servicio, o nil si no hay ninguno
+func (r *AfipTicketRepo) Obtener(ctx context.Context, servicio string) (*afip.Ticket, error) {
+	var t afip.Ticket
+	err := r.db.QueryRowContext(ctx,
+		`SELECT token, sign, vence FROM afip_ticket_acceso WHERE modo = ? AND servicio = ?`,
+		r.modo, servicio).Scan(&t.Token, &t.Sign, &t.Vence)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo ticket de acceso %s/%s: %w", r.modo, servicio, err)
+	}
+	return &t, nil
+}
+
+// Guardar reemplaza el ticket del servicio
+func (r *AfipTicketRepo) Guardar(ctx context.Context, servicio string, t *afip.Ticket) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO afip_ticket_acceso (modo, servicio, token, sign, vence) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE token = VALUES(token), sign = VALUES(sign), vence = VALUES(vence)`,
+		r.modo, servicio, t.Token, t.Sign, t.Vence)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
+
+// Borrar descarta el ticket del servicio
+func (r *AfipTicketRepo) Borrar(ctx context.Context, servicio string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM afip_ticket_acceso WHERE modo = ? AND servicio = ?`, r.modo, servicio)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// AgendaTecnicoRepo maneja las zonas y los horarios de trabajo de los técnicos
+type AgendaTecnicoRepo struct {
+	db Execer
+}
+
+func NewAgendaTecnicoRepo(db Execer) *AgendaTecnicoRepo {
+	return &AgendaTecnicoRepo{db: db}
+}
+
+// ListarZonas devuelve los distritos que cubre el técnico
+func (r *AgendaTecnicoRepo) ListarZonas(ctx context.Context, idTecnico int) ([]modelos.ZonaTecnico, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT distrito_nombre, departamento_nombre FROM tecnico_zona
+		WHERE id_tecnico = ? ORDER BY departamento_nombre, distrito_nombre
+	`, idTecnico)
+	if err != nil {
+		return nil, fmt.Errorf("error listando zonas del técnico %d: %w", idTecnico, err)
+	}
+	defer rows.Close()
+
+	zonas := []modelos.ZonaTecnico{}
+	for rows.Next() {
+		var z modelos.ZonaTecnico
+		if err := rows.Scan(&z.Distrito, &z.Departamento); err != nil {
+			return nil, fmt.Errorf("error leyendo zona: %w", err)
+		}
+		zonas = append(zonas, z)
+	}
+	return zonas, rows.Err()
+}
+
+// ListarFranjas devuelve los horarios semanales del técnico
+func (r *AgendaTecnicoRepo) ListarFranjas(ctx context.Context, idTecnico int) ([]modelos.FranjaAgenda, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT dia_semana, TIME_FORMAT(hora_inicio, '%H:%i'), TIME_FORMAT(hora_fin, '%H:%i')
+		FROM tecnico_agenda WHERE id_tecnico = ? ORDER BY dia_semana, hora_inicio
+	`, idTecnico)
+	if err != nil {
+		return nil, fmt.Errorf("error listando agenda del técnico %d: %w", idTecnico, err)
+	}
+	defer rows.Close()
+
+	franjas := []modelos.FranjaAgenda{}
+	for rows.Next() {
+		var f modelos.FranjaAgenda
+		if err := rows.Scan(&f.DiaSemana, &f.HoraInicio, &f.HoraFin); err != nil {
+			return nil, fmt.Errorf("error leyendo franja: %w", err)
+		}
+		franjas = append(franjas, f)
+	}
+	return franjas, rows.Err()
+}
+
+// ReemplazarAgenda borra las zonas y franjas del técnico y carga las nuevas. Debe usarse
+// dentro de una transacción.
+func (r *AgendaTecnicoRepo) ReemplazarAgenda(ctx context.Context, agenda *modelos.AgendaTecnico) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM tecnico_zona WHERE id_tecnico = ?`, agenda.IDTecnico); err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM tecnico_agenda WHERE id_tecnico = ?`, agenda.IDTecnico); err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+
+	for _, z := range agenda.Zonas {
+		if _, err := r.db.ExecContext(ctx,
+			`INSERT INTO tecnico_zona (id_tecnico, distrito_nombre, departamento_nombre) VALUES (?, ?, ?)`,
+			agenda.IDTecnico, z.Distrito, z.Departamento); err != nil {
+			return utilidades.TraducirErrorBD(err)
+		}
+	}
+	for _, f := range agenda.Franjas {
+		if _, err := r.db.ExecContext(ctx,
+			`INSERT INTO tecnico_agenda (id_tecnico, dia_semana, hora_inicio, hora_fin) VALUES (?, ?, ?, ?)`,
+			agenda.IDTecnico, f.DiaSemana, f.HoraInicio, f.HoraFin); err != nil {
+			return utilidades.TraducirErrorBD(err)
+		}
+	}
+	return nil
+}
+
+// ListarFranjasPorZona devuelve las franjas de los técnicos activos que cubren el distrito
+func (r *AgendaTecnicoRepo) ListarFranjasPorZona(ctx context.Context, distrito, departamento string) ([]modelos.FranjaTecnico, error) {
+	query := `
+		SELECT a.id_tecnico, a.dia_semana, TIME_FORMAT(a.hora_inicio, '%H:%i'), TIME_FORMAT(a.hora_fin, '%H:%i')
+		FROM tecnico_agenda a
+		JOIN tecnico_zona z ON z.id_tecnico = a.id_tecnico
+		JOIN usuario u ON u.id_usuario = a.id_tecnico
+		JOIN usuario_rol ur ON ur.id_usuario = u.id_usuario
+		JOIN rol ro ON ro.id_rol = ur.id_rol
+		WHERE z.distrito_nombre = ? AND z.departamento_nombre = ?
+		  AND ro.nombre = ? AND u.borrado IS NULL
+		ORDER BY a.id_tecnico, a.dia_semana, a.hora_inicio
+	`
+	rows, err := r.db.QueryContext(ctx, query, distrito, departamento, modelos.RolTecnico)
+	if err != nil {
+		return nil, fmt.Errorf("error listando agenda de la zona: %w", err)
+	}
+	defer rows.Close()
+
+	var franjas []modelos.FranjaTecnico
+	for rows.Next() {
+		var f modelos.FranjaTecnico
+		if err := rows.Scan(&f.IDTecnico, &f.DiaSemana, &f.HoraInicio, &f.HoraFin); err != nil {
+			return nil, fmt.Errorf("error leyendo franja: %w", err)
+		}
+		franjas = append(franjas, f)
+	}
+	return franjas, rows.Err()
+}
+
+// ObtenerDatosConexion devuelve la zona y el titular de la conexión
+func (r *AgendaTecnicoRepo) ObtenerDatosConexion(ctx context.Context, idConexion int) (*modelos.DatosConexionTurno, error) {
+	query := `
+		SELECT c.id_conexion, c.nro_conexion, c.id_persona,
+		       COALESCE(c.distrito_nombre, ''), COALESCE(c.departamento_nombre, ''),
+		       COALESCE(p.email, ''), CONCAT(p.nombre, ' ', p.apellido)
+		FROM conexion c
+		JOIN persona p ON p.id_persona = c.id_persona
+		WHERE c.id_conexion = ? AND c.borrado IS NULL
+	`
+	var d modelos.DatosConexionTurno
+	err := r.db.QueryRowContext(ctx, query, idConexion).Scan(&d.IDConexion, &d.NroConexion, &d.IDPersona,
+		&d.Distrito, &d.Departamento, &d.Email, &d.Nombre)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "conexion", Campo: "id_conexion", Valor: fmt.Sprintf("%d", idConexion)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo conexión %d: %w", idConexion, err)
+	}
+	return &d, nil
+}
@@ -298,20 +298,19 @@ func (r *ConexionRepo) VerificarEstadoConexionMultiple(ctx context.Context, idCo
 	}
 }
 
-// ActualizarFactibilidadConexion actualiza los datos técnicos y el estado de una conexión
-func (r *ConexionRepo) ActualizarFactibilidadConexion(
+// ActualizarDatosTecnicos guarda los datos de red de una conexión verificada.
+// El cambio de estado lo hace la máquina de estados, que ya comprobó que la conexión existe.
+func (r *ConexionRepo) ActualizarDatosTecnicos(
 	ctx context.Context,
 	idConexion int,
 	nap string,
 	vlan int,
 	puerto *int,
 	observaciones *string,
-	idEstadoFactible int,
 ) error {
 	query := `
 		UPDATE conexion
-		SET id_estado_conexion = ?,
-		    detalleNodo = ?,
+		SET detalleNodo = ?,
 		    vlanA = ?,
 		    puertoOLT = ?,
 		    observaciones = CASE 
@@ -323,8 +322,7 @@ func (r *ConexionRepo) ActualizarFactibilidadConexion(
 		  AND borrado IS NULL
 	`
 	
-	result, err := r.db.ExecContext(ctx, query,
-		idEstadoFactible,
+	_, err := r.db.ExecContext(ctx, query,
 		nap,
 		vlan,
 		puerto,
@@ -336,64 +334,27 @@ func (r *ConexionRepo) ActualizarFactibilidadConexion(
 		return utilidades.TraducirErrorBD(err)
 	}
 	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error verificando filas afectadas: %w", err)
-	}
-	
-	if rowsAffected == 0 {
-		return utilidades.ErrNotFound{
-			Entity: "conexion",
-			Campo:  "id_conexion",
-			Valor:  fmt.Sprintf("%d", idConexion),
-		}
-	}
-	
 	return nil
 }
 
-// RechazarFactibilidadConexion actualiza el estado de una conexión a "No factible" y registra el motivo
-func (r *ConexionRepo) RechazarFactibilidadConexion(
+// RegistrarMotivoRechazo guarda en observaciones el motivo por el que la conexión no es factible
+func (r *ConexionRepo) RegistrarMotivoRechazo(
 	ctx context.Context,
 	idConexion int,
-	motivo *string,
-	idEstadoNoFactible int,
+	motivo string,
 ) error {
 	query := `
 		UPDATE conexion
-		SET id_estado_conexion = ?,
-		    observaciones = CASE 
-		        WHEN ? IS NOT NULL THEN ?
-		        ELSE observaciones
-		    END,
+		SET observaciones = ?,
 		    ultimo_cambio = CURRENT_TIMESTAMP
 		WHERE id_conexion = ?
 		  AND borrado IS NULL
 	`
 	
-	result, err := r.db.ExecContext(ctx, query,
-		idEstadoNoFactible,
-		motivo,
-		motivo,
-		idConexion,
-	)
-	if err != nil {
+	if _, err := r.db.ExecContext(ctx, query, motivo, idConexion); err != nil {
 		return utilidades.TraducirErrorBD(err)
 	}
 	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error verificando filas afectadas: %w", err)
-	}
-	
-	if rowsAffected == 0 {
-		return utilidades.ErrNotFound{
-			Entity: "conexion",
-			Campo:  "id_conexion",
-			Valor:  fmt.Sprintf("%d", idConexion),
-		}
-	}
-	
 	return nil
 }
 
@@ -680,33 +641,19 @@ func (r *ConexionRepo) ContarConexionesPorPersona(ctx context.Context, idPersona
 	return total, nil
 }
 
-// ActualizarEstado actualiza el estado de una conexión
-func (r *ConexionRepo) ActualizarEstado(ctx context.Context, idConexion, idNuevoEstado int) error {
+// AsignarInstalador registra el usuario técnico que instaló la conexión
+func (r *ConexionRepo) AsignarInstalador(ctx context.Context, idConexion, idUsuario int) error {
 	query := `
 		UPDATE conexion
-		SET id_estado_conexion = ?,
+		SET id_instalador = ?,
 		    ultimo_cambio = CURRENT_TIMESTAMP
 		WHERE id_conexion = ?
 		  AND borrado IS NULL
 	`
-	
-	result, err := r.db.ExecContext(ctx, query, idNuevoEstado, idConexion)
-	if err != nil {
+
+	if _, err := r.db.ExecContext(ctx, query, idUsuario, idConexion); err != nil {
 		return utilidades.TraducirErrorBD(err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error verificando filas afectadas: %w", err)
-	}
-	
-	if rowsAffected == 0 {
-		return utilidades.ErrNotFound{
-			Entity: "conexion",
-			Campo:  "id_conexion",
-			Valor:  fmt.Sprintf("%d", idConexion),
-		}
-	}
-	
+
 	return nil
 }
\ No newline at end of file
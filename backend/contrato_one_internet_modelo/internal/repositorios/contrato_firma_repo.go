@@ -6,23 +6,32 @@ import (
 	"time"
 
 	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
 )
 
 type ContratoFirmaRepo struct {
-	db *sql.DB
+	db Execer
 }
 
-func NewContratoFirmaRepo(db *sql.DB) *ContratoFirmaRepo {
+func NewContratoFirmaRepo(db Execer) *ContratoFirmaRepo {
 	return &ContratoFirmaRepo{db: db}
 }
 
-// Crear crea un nuevo registro de contrato_firma
+// Crear crea un nuevo registro de contrato_firma. Del token de firma se guarda sólo el hash;
+// sin token (todavía no emitido) la columna queda en NULL.
 func (r *ContratoFirmaRepo) Crear(ctx context.Context, cf *modelos.ContratoFirma) (int64, error) {
+	var tokenHash *string
+	if cf.TokenFirma != "" {
+		h := utilidades.HashToken(cf.TokenFirma)
+		tokenHash = &h
+	}
+
 	query := `
 		INSERT INTO contrato_firma (
 			id_contrato, pdf_original_path, hash_original, metodo_firma,
-			token_firma, token_expira, pdf_generado, token_enviado
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			token_firma_hash, token_expira, pdf_generado, token_enviado,
+			codigo_verificacion
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
@@ -30,10 +39,11 @@ func (r *ContratoFirmaRepo) Crear(ctx context.Context, cf *modelos.ContratoFirma
 		cf.PdfOriginalPath,
 		cf.HashOriginal,
 		cf.MetodoFirma,
-		cf.TokenFirma,
+		tokenHash,
 		cf.TokenExpira,
 		cf.PdfGenerado,
 		cf.TokenEnviado,
+		cf.CodigoVerificacion,
 	)
 	if err != nil {
 		return 0, err
@@ -42,99 +52,51 @@ func (r *ContratoFirmaRepo) Crear(ctx context.Context, cf *modelos.ContratoFirma
 	return result.LastInsertId()
 }
 
+// columnasContratoFirma son las columnas leídas por escanearContratoFirma
+const columnasContratoFirma = `
+	id_contrato_firma, id_contrato, pdf_original_path, pdf_firmado_path,
+	firma_path, hash_original, hash_firmado, metodo_firma, token_firma_hash,
+	token_expira, intentos_token, pdf_generado, token_enviado, firmado,
+	fecha_firma, ip_firma, user_agent, codigo_verificacion`
+
 // ObtenerPorID obtiene un contrato_firma por su ID
 func (r *ContratoFirmaRepo) ObtenerPorID(ctx context.Context, id int) (*modelos.ContratoFirma, error) {
-	query := `
-		SELECT 
-			id_contrato_firma, id_contrato, pdf_original_path, pdf_firmado_path,
-			firma_path, hash_original, hash_firmado, metodo_firma, token_firma,
-			token_expira, intentos_token, pdf_generado, token_enviado, firmado,
-			fecha_firma, ip_firma, user_agent
+	query := `SELECT ` + columnasContratoFirma + `
 		FROM contrato_firma
 		WHERE id_contrato_firma = ?
 	`
-
-	cf := &modelos.ContratoFirma{}
-	var pdfFirmado, firmaPath, hashFirmado sql.NullString
-	var pdfGenerado, tokenEnviado, fechaFirma sql.NullTime
-	var ipFirma, userAgent sql.NullString
-	var firmadoInt int
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&cf.IDContratoFirma,
-		&cf.IDContrato,
-		&cf.PdfOriginalPath,
-		&pdfFirmado,
-		&firmaPath,
-		&cf.HashOriginal,
-		&hashFirmado,
-		&cf.MetodoFirma,
-		&cf.TokenFirma,
-		&cf.TokenExpira,
-		&cf.IntentosToken,
-		&pdfGenerado,
-		&tokenEnviado,
-		&firmadoInt,
-		&fechaFirma,
-		&ipFirma,
-		&userAgent,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	// Convertir tipos nullable
-	if pdfFirmado.Valid {
-		cf.PdfFirmadoPath = &pdfFirmado.String
-	}
-	if firmaPath.Valid {
-		cf.FirmaPath = &firmaPath.String
-	}
-	if hashFirmado.Valid {
-		cf.HashFirmado = &hashFirmado.String
-	}
-	if pdfGenerado.Valid {
-		cf.PdfGenerado = &pdfGenerado.Time
-	}
-	if tokenEnviado.Valid {
-		cf.TokenEnviado = &tokenEnviado.Time
-	}
-	if fechaFirma.Valid {
-		cf.FechaFirma = &fechaFirma.Time
-	}
-	if ipFirma.Valid {
-		cf.IpFirma = &ipFirma.String
-	}
-	if userAgent.Valid {
-		cf.UserAgent = &userAgent.String
-	}
-	cf.Firmado = firmadoInt == 1
-
-	return cf, nil
+	return escanearContratoFirma(r.db.QueryRowContext(ctx, query, id))
 }
 
 // ObtenerPorIDContrato obtiene el contrato_firma activo de un contrato (no firmado o reciente)
 func (r *ContratoFirmaRepo) ObtenerPorIDContrato(ctx context.Context, idContrato int) (*modelos.ContratoFirma, error) {
-	query := `
-		SELECT 
-			id_contrato_firma, id_contrato, pdf_original_path, pdf_firmado_path,
-			firma_path, hash_original, hash_firmado, metodo_firma, token_firma,
-			token_expira, intentos_token, pdf_generado, token_enviado, firmado,
-			fecha_firma, ip_firma, user_agent
+	query := `SELECT ` + columnasContratoFirma + `
 		FROM contrato_firma
 		WHERE id_contrato = ?
 		ORDER BY id_contrato_firma DESC
 		LIMIT 1
 	`
+	return escanearContratoFirma(r.db.QueryRowContext(ctx, query, idContrato))
+}
+
+// ObtenerPorCodigoVerificacion obtiene el contrato_firma con el código público impreso en el PDF
+func (r *ContratoFirmaRepo) ObtenerPorCodigoVerificacion(ctx context.Context, codigo string) (*modelos.ContratoFirma, error) {
+	query := `SELECT ` + columnasContratoFirma + `
+		FROM contrato_firma
+		WHERE codigo_verificacion = ?
+	`
+	return escanearContratoFirma(r.db.QueryRowContext(ctx, query, codigo))
+}
 
+// escanearContratoFirma convierte una fila con columnasContratoFirma en el modelo
+func escanearContratoFirma(row *sql.Row) (*modelos.ContratoFirma, error) {
 	cf := &modelos.ContratoFirma{}
 	var pdfFirmado, firmaPath, hashFirmado sql.NullString
 	var pdfGenerado, tokenEnviado, fechaFirma sql.NullTime
-	var ipFirma, userAgent sql.NullString
+	var ipFirma, userAgent, codigoVerificacion, tokenFirmaHash sql.NullString
 	var firmadoInt int
 
-	err := r.db.QueryRowContext(ctx, query, idContrato).Scan(
+	err := row.Scan(
 		&cf.IDContratoFirma,
 		&cf.IDContrato,
 		&cf.PdfOriginalPath,
@@ -143,7 +105,7 @@ func (r *ContratoFirmaRepo) ObtenerPorIDContrato(ctx context.Context, idContrato
 		&cf.HashOriginal,
 		&hashFirmado,
 		&cf.MetodoFirma,
-		&cf.TokenFirma,
+		&tokenFirmaHash,
 		&cf.TokenExpira,
 		&cf.IntentosToken,
 		&pdfGenerado,
@@ -152,6 +114,7 @@ func (r *ContratoFirmaRepo) ObtenerPorIDContrato(ctx context.Context, idContrato
 		&fechaFirma,
 		&ipFirma,
 		&userAgent,
+		&codigoVerificacion,
 	)
 
 	if err != nil {
@@ -183,6 +146,12 @@ func (r *ContratoFirmaRepo) ObtenerPorIDContrato(ctx context.Context, idContrato
 	if userAgent.Valid {
 		cf.UserAgent = &userAgent.String
 	}
+	if tokenFirmaHash.Valid {
+		cf.TokenFirmaHash = tokenFirmaHash.String
+	}
+	if codigoVerificacion.Valid {
+		cf.CodigoVerificacion = &codigoVerificacion.String
+	}
 	cf.Firmado = firmadoInt == 1
 
 	return cf, nil
@@ -43,15 +43,15 @@ func (r *ContratoRepo) CrearContrato(ctx context.Context, c *modelos.Contrato) (
 	query := `
 		INSERT INTO contrato (
 			id_persona, id_vinculo, id_empresa, id_conexion, id_plan,
-			costo_instalacion, fecha_inicio, fecha_fin, id_estado_contrato,
-			id_usuario_creador
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			costo_instalacion, precio_plan, id_promocion, promocion_descuento, promocion_meses,
+			fecha_inicio, fecha_fin, id_estado_contrato, id_usuario_creador
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	res, err := r.db.ExecContext(ctx, query,
 		c.IDPersona, c.IDVinculo, c.IDEmpresa, c.IDConexion, c.IDPlan,
-		c.CostoInstalacion, c.FechaInicio, c.FechaFin, c.IDEstadoContrato,
-		c.IDUsuarioCreador,
+		c.CostoInstalacion, c.PrecioPlan, c.IDPromocion, c.PromocionDescuento, c.PromocionMeses,
+		c.FechaInicio, c.FechaFin, c.IDEstadoContrato, c.IDUsuarioCreador,
 	)
 	if err != nil {
 		return 0, utilidades.TraducirErrorBD(err)
@@ -64,41 +64,6 @@ func (r *ContratoRepo) CrearContrato(ctx context.Context, c *modelos.Contrato) (
 	return id, nil
 }
 
-// ActualizarEstadoContratoPorConexion actualiza el estado de un contrato asociado a una conexión
-func (r *ContratoRepo) ActualizarEstadoContratoPorConexion(
-	ctx context.Context,
-	idConexion int,
-	idNuevoEstado int,
-) error {
-	query := `
-		UPDATE contrato
-		SET id_estado_contrato = ?,
-		    ultimo_cambio = CURRENT_TIMESTAMP
-		WHERE id_conexion = ?
-		  AND borrado IS NULL
-	`
-	
-	result, err := r.db.ExecContext(ctx, query, idNuevoEstado, idConexion)
-	if err != nil {
-		return utilidades.TraducirErrorBD(err)
-	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error verificando filas afectadas: %w", err)
-	}
-	
-	if rowsAffected == 0 {
-		return utilidades.ErrNotFound{
-			Entity: "contrato",
-			Campo:  "id_conexion",
-			Valor:  fmt.Sprintf("%d", idConexion),
-		}
-	}
-	
-	return nil
-}
-
 // ListarContratosPorPersona obtiene los contratos de una persona con paginación y ordenamiento
 func (r *ContratoRepo) ListarContratosPorPersona(
 	ctx context.Context,
@@ -206,37 +171,6 @@ func (r *ContratoRepo) ContarContratosPorPersona(ctx context.Context, idPersona
 	return total, nil
 }
 
-// ActualizarEstado actualiza el estado de un contrato
-func (r *ContratoRepo) ActualizarEstado(ctx context.Context, idContrato, idNuevoEstado int) error {
-	query := `
-		UPDATE contrato
-		SET id_estado_contrato = ?,
-		    ultimo_cambio = CURRENT_TIMESTAMP
-		WHERE id_contrato = ?
-		  AND borrado IS NULL
-	`
-	
-	result, err := r.db.ExecContext(ctx, query, idNuevoEstado, idContrato)
-	if err != nil {
-		return utilidades.TraducirErrorBD(err)
-	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error verificando filas afectadas: %w", err)
-	}
-	
-	if rowsAffected == 0 {
-		return utilidades.ErrNotFound{
-			Entity: "contrato",
-			Campo:  "id_contrato",
-			Valor:  fmt.Sprintf("%d", idContrato),
-		}
-	}
-	
-	return nil
-}
-
 // ObtenerIDConexionPorContrato obtiene el id_conexion asociado a un contrato
 func (r *ContratoRepo) ObtenerIDConexionPorContrato(ctx context.Context, idContrato int) (int, error) {
 	var idConexion int
@@ -254,3 +188,21 @@ func (r *ContratoRepo) ObtenerIDConexionPorContrato(ctx context.Context, idContr
 	}
 	return idConexion, nil
 }
+
+// ObtenerIDPersonaPorContrato obtiene el titular de un contrato
+func (r *ContratoRepo) ObtenerIDPersonaPorContrato(ctx context.Context, idContrato int) (int, error) {
+	var idPersona int
+	query := `SELECT id_persona FROM contrato WHERE id_contrato = ? AND borrado IS NULL`
+	err := r.db.QueryRowContext(ctx, query, idContrato).Scan(&idPersona)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, utilidades.ErrNotFound{
+				Entity: "contrato",
+				Campo:  "id_contrato",
+				Valor:  fmt.Sprintf("%d", idContrato),
+			}
+		}
+		return 0, fmt.Errorf("error obteniendo id_persona: %w", err)
+	}
+	return idPersona, nil
+}
@@ -0,0 +1,197 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// CuentaRepo maneja los movimientos de la cuenta corriente de los clientes y las marcas de
+// suspensión por deuda de sus conexiones
+type CuentaRepo struct {
+	db Execer
+}
+
+// NewCuentaRepo crea una nueva instancia de CuentaRepo
+func NewCuentaRepo(db Execer) *CuentaRepo {
+	return &CuentaRepo{db: db}
+}
+
+// importeConSigno suma los cargos y resta pagos y notas de crédito
+const importeConSigno = `CASE WHEN m.tipo = 'cargo' THEN m.importe ELSE -m.importe END`
+
+// RegistrarCargoFactura agrega a la cuenta del titular el cargo de una factura emitida. Es
+// idempotente: una factura se carga una sola vez.
+func (r *CuentaRepo) RegistrarCargoFactura(ctx context.Context, idFactura int) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO cuenta_movimiento (id_persona, tipo, concepto, importe, fecha, fecha_vencimiento, id_factura)
+		SELECT f.id_persona, 'cargo',
+		       CONCAT('Factura ', f.tipo_comprobante, ' ', LPAD(f.punto_venta, 4, '0'), '-', LPAD(f.numero, 8, '0'), ' - período ', f.periodo),
+		       f.total, f.fecha_emision, f.fecha_vencimiento, f.id_factura
+		FROM factura f
+		WHERE f.id_factura = ? AND f.numero IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM cuenta_movimiento m WHERE m.id_factura = f.id_factura AND m.tipo = 'cargo')`,
+		idFactura)
+	if err != nil {
+		return fmt.Errorf("error registrando el cargo de la factura %d: %w", idFactura, utilidades.TraducirErrorBD(err))
+	}
+	return nil
+}
+
+// RegistrarPagoAprobado acredita en la cuenta un pago aprobado de la pasarela. Es idempotente.
+func (r *CuentaRepo) RegistrarPagoAprobado(ctx context.Context, idPago int) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO cuenta_movimiento (id_persona, tipo, concepto, importe, fecha, id_pago, medio_pago)
+		SELECT p.id_persona, 'pago', p.concepto, p.monto, DATE(COALESCE(p.fecha_aprobacion, NOW())), p.id_pago, p.proveedor
+		FROM pago p
+		WHERE p.id_pago = ? AND p.estado = 'aprobado'
+		  AND NOT EXISTS (SELECT 1 FROM cuenta_movimiento m WHERE m.id_pago = p.id_pago)`,
+		idPago)
+	if err != nil {
+		return fmt.Errorf("error acreditando el pago %d: %w", idPago, utilidades.TraducirErrorBD(err))
+	}
+	return nil
+}
+
+// Registrar guarda un movimiento cargado por el personal (pago o nota de crédito)
+func (r *CuentaRepo) Registrar(ctx context.Context, m *modelos.MovimientoCuenta) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO cuenta_movimiento (id_persona, tipo, concepto, importe, fecha, id_factura, medio_pago, referencia, id_usuario)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.IDPersona, m.Tipo, m.Concepto, m.Importe, m.Fecha, m.IDFactura, m.MedioPago, m.Referencia, m.IDUsuario)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// ListarMovimientos devuelve los movimientos de la cuenta en orden cronológico. Saldo queda
+// en cero: lo calcula el servicio.
+func (r *CuentaRepo) ListarMovimientos(ctx context.Context, idPersona int) ([]modelos.MovimientoCuenta, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.id_movimiento, m.id_persona, m.tipo, m.concepto, m.importe,
+		       DATE_FORMAT(m.fecha, '%Y-%m-%d'), DATE_FORMAT(m.fecha_vencimiento, '%Y-%m-%d'),
+		       m.id_factura, m.id_pago, m.medio_pago, m.referencia, m.id_usuario, m.creado
+		FROM cuenta_movimiento m
+		WHERE m.id_persona = ?
+		ORDER BY m.fecha, m.id_movimiento`, idPersona)
+	if err != nil {
+		return nil, fmt.Errorf("error listando movimientos de la cuenta de la persona %d: %w", idPersona, err)
+	}
+	defer rows.Close()
+
+	movimientos := []modelos.MovimientoCuenta{}
+	for rows.Next() {
+		var m modelos.MovimientoCuenta
+		var idFactura, idPago, idUsuario sql.NullInt64
+		if err := rows.Scan(&m.IDMovimiento, &m.IDPersona, &m.Tipo, &m.Concepto, &m.Importe,
+			&m.Fecha, &m.FechaVencimiento, &idFactura, &idPago, &m.MedioPago, &m.Referencia, &idUsuario, &m.Creado); err != nil {
+			return nil, fmt.Errorf("error escaneando movimiento de cuenta: %w", err)
+		}
+		m.IDFactura = nullIntAPuntero(idFactura)
+		m.IDPago = nullIntAPuntero(idPago)
+		m.IDUsuario = nullIntAPuntero(idUsuario)
+		movimientos = append(movimientos, m)
+	}
+	return movimientos, rows.Err()
+}
+
+// ListarDeudores devuelve los clientes cuyo saldo es a favor de la empresa, con el saldo
+// cargado; el resto del resumen lo completa el servicio
+func (r *CuentaRepo) ListarDeudores(ctx context.Context) ([]modelos.DeudorCuenta, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id_persona, CONCAT(p.nombre, ' ', p.apellido), COALESCE(p.cuil, p.dni), SUM(`+importeConSigno+`) AS saldo
+		FROM cuenta_movimiento m
+		JOIN persona p ON p.id_persona = m.id_persona
+		GROUP BY p.id_persona, p.nombre, p.apellido, p.cuil, p.dni
+		HAVING saldo > 0.009
+		ORDER BY saldo DESC, p.id_persona`)
+	if err != nil {
+		return nil, fmt.Errorf("error listando deudores: %w", err)
+	}
+	defer rows.Close()
+
+	deudores := []modelos.DeudorCuenta{}
+	for rows.Next() {
+		var d modelos.DeudorCuenta
+		if err := rows.Scan(&d.IDPersona, &d.Nombre, &d.Documento, &d.Saldo); err != nil {
+			return nil, fmt.Errorf("error escaneando deudor: %w", err)
+		}
+		deudores = append(deudores, d)
+	}
+	return deudores, rows.Err()
+}
+
+// ExistePersona indica si la persona existe y no está borrada
+func (r *CuentaRepo) ExistePersona(ctx context.Context, idPersona int) (bool, error) {
+	var existe bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM persona WHERE id_persona = ? AND borrado IS NULL)`, idPersona).Scan(&existe)
+	if err != nil {
+		return false, fmt.Errorf("error verificando persona %d: %w", idPersona, err)
+	}
+	return existe, nil
+}
+
+// ListarConexionesEnEstado devuelve las conexiones de la persona que están en el estado dado
+func (r *CuentaRepo) ListarConexionesEnEstado(ctx context.Context, idPersona int, estado string) ([]int, error) {
+	return r.listarIDs(ctx, `
+		SELECT c.id_conexion
+		FROM conexion c
+		JOIN estado_conexion ec ON ec.id_estado_conexion = c.id_estado_conexion
+		WHERE c.id_persona = ? AND c.borrado IS NULL AND ec.nombre = ?
+		ORDER BY c.id_conexion`, idPersona, estado)
+}
+
+// ListarSuspendidasPorDeuda devuelve las conexiones de la persona que siguen suspendidas por
+// la revisión de deuda
+func (r *CuentaRepo) ListarSuspendidasPorDeuda(ctx context.Context, idPersona int) ([]int, error) {
+	return r.listarIDs(ctx, `
+		SELECT c.id_conexion
+		FROM conexion c
+		JOIN estado_conexion ec ON ec.id_estado_conexion = c.id_estado_conexion
+		WHERE c.id_persona = ? AND c.suspendida_por_deuda IS NOT NULL AND ec.nombre = ?
+		ORDER BY c.id_conexion`, idPersona, modelos.EstadoConexionSuspendida)
+}
+
+// ListarPersonasConSuspendidasPorDeuda devuelve los titulares de conexiones suspendidas por deuda
+func (r *CuentaRepo) ListarPersonasConSuspendidasPorDeuda(ctx context.Context) ([]int, error) {
+	return r.listarIDs(ctx, `
+		SELECT DISTINCT c.id_persona
+		FROM conexion c
+		JOIN estado_conexion ec ON ec.id_estado_conexion = c.id_estado_conexion
+		WHERE c.suspendida_por_deuda IS NOT NULL AND ec.nombre = ?
+		ORDER BY c.id_persona`, modelos.EstadoConexionSuspendida)
+}
+
+// MarcarSuspendidaPorDeuda deja constancia de que la conexión la suspendió la revisión de deuda
+func (r *CuentaRepo) MarcarSuspendidaPorDeuda(ctx context.Context, idConexion int, fecha time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE conexion SET suspendida_por_deuda = ? WHERE id_conexion = ?`, fecha, idConexion)
+	return utilidades.TraducirErrorBD(err)
+}
+
+func (r *CuentaRepo) listarIDs(ctx context.Context, query string, args ...interface{}) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando conexiones de la cuenta: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error escaneando id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,198 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// DosFactoresRepo guarda el secreto TOTP, los códigos de recuperación y los códigos por
+// email de la autenticación en dos pasos
+type DosFactoresRepo struct {
+	db Execer
+}
+
+// NewDosFactoresRepo crea una nueva instancia de DosFactoresRepo
+func NewDosFactoresRepo(db Execer) *DosFactoresRepo {
+	return &DosFactoresRepo{db: db}
+}
+
+// Obtener devuelve la configuración TOTP del usuario (ErrNotFound si nunca la inició)
+func (r *DosFactoresRepo) Obtener(ctx context.Context, idUsuario int) (*modelos.DosFactoresUsuario, error) {
+	d := modelos.DosFactoresUsuario{IDUsuario: idUsuario}
+	err := r.db.QueryRowContext(ctx,
+		"SELECT secreto_totp, activo, ultimo_paso_totp FROM usuario_dos_factores WHERE id_usuario = ?", idUsuario).
+		Scan(&d.SecretoCifrado, &d.Activo, &d.UltimoPasoTOTP)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "autenticación en dos pasos", Campo: "id_usuario", Valor: fmt.Sprintf("%d", idUsuario)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo autenticación en dos pasos del usuario %d: %w", idUsuario, err)
+	}
+	return &d, nil
+}
+
+// GuardarPendiente guarda un secreto nuevo sin activar, reemplazando uno pendiente anterior
+func (r *DosFactoresRepo) GuardarPendiente(ctx context.Context, idUsuario int, secretoCifrado string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO usuario_dos_factores (id_usuario, secreto_totp, activo, ultimo_paso_totp)
+		VALUES (?, ?, 0, 0)
+		ON DUPLICATE KEY UPDATE secreto_totp = VALUES(secreto_totp), activo = 0, ultimo_paso_totp = 0, activado = NULL`,
+		idUsuario, secretoCifrado)
+	if err != nil {
+		return fmt.Errorf("error guardando secreto TOTP del usuario %d: %w", idUsuario, err)
+	}
+	return nil
+}
+
+// Activar marca el TOTP como confirmado con el código del paso indicado
+func (r *DosFactoresRepo) Activar(ctx context.Context, idUsuario int, paso int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE usuario_dos_factores SET activo = 1, activado = NOW(), ultimo_paso_totp = ?
+		WHERE id_usuario = ?`, paso, idUsuario)
+	if err != nil {
+		return fmt.Errorf("error activando TOTP del usuario %d: %w", idUsuario, err)
+	}
+	return nil
+}
+
+// UsarPasoTOTP registra el paso de un código aceptado. Devuelve false si otro pedido ya usó
+// ese paso o uno posterior, así un mismo código no sirve dos veces aunque lleguen a la par.
+func (r *DosFactoresRepo) UsarPasoTOTP(ctx context.Context, idUsuario int, paso int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE usuario_dos_factores SET ultimo_paso_totp = ?
+		WHERE id_usuario = ? AND ultimo_paso_totp < ?`, paso, idUsuario, paso)
+	if err != nil {
+		return false, fmt.Errorf("error registrando código TOTP del usuario %d: %w", idUsuario, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Eliminar borra el TOTP y los códigos del usuario
+func (r *DosFactoresRepo) Eliminar(ctx context.Context, idUsuario int) error {
+	for _, tabla := range []string{"usuario_codigo_email", "usuario_codigo_recuperacion", "usuario_dos_factores"} {
+		if _, err := r.db.ExecContext(ctx, "DELETE FROM "+tabla+" WHERE id_usuario = ?", idUsuario); err != nil {
+			return fmt.Errorf("error borrando %s del usuario %d: %w", tabla, idUsuario, err)
+		}
+	}
+	return nil
+}
+
+// ReemplazarCodigosRecuperacion descarta los códigos anteriores y guarda los nuevos (hashes)
+func (r *DosFactoresRepo) ReemplazarCodigosRecuperacion(ctx context.Context, idUsuario int, hashes []string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM usuario_codigo_recuperacion WHERE id_usuario = ?", idUsuario); err != nil {
+		return fmt.Errorf("error borrando códigos de recuperación del usuario %d: %w", idUsuario, err)
+	}
+	for _, h := range hashes {
+		_, err := r.db.ExecContext(ctx,
+			"INSERT INTO usuario_codigo_recuperacion (id_usuario, codigo_hash) VALUES (?, ?)", idUsuario, h)
+		if err != nil {
+			return fmt.Errorf("error guardando código de recuperación del usuario %d: %w", idUsuario, utilidades.TraducirErrorBD(err))
+		}
+	}
+	return nil
+}
+
+// UsarCodigoRecuperacion marca como usado el código si existe y estaba libre
+func (r *DosFactoresRepo) UsarCodigoRecuperacion(ctx context.Context, idUsuario int, hash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE usuario_codigo_recuperacion SET usado = NOW()
+		WHERE id_usuario = ? AND codigo_hash = ? AND usado IS NULL`, idUsuario, hash)
+	if err != nil {
+		return false, fmt.Errorf("error usando código de recuperación del usuario %d: %w", idUsuario, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ContarCodigosRecuperacionLibres devuelve cuántos códigos de recuperación quedan sin usar
+func (r *DosFactoresRepo) ContarCodigosRecuperacionLibres(ctx context.Context, idUsuario int) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM usuario_codigo_recuperacion WHERE id_usuario = ? AND usado IS NULL", idUsuario).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("error contando códigos de recuperación del usuario %d: %w", idUsuario, err)
+	}
+	return n, nil
+}
+
+// CrearCodigoEmail invalida los códigos por email anteriores del usuario y guarda uno nuevo
+func (r *DosFactoresRepo) CrearCodigoEmail(ctx context.Context, idUsuario int, hash string, vigencia time.Duration) (time.Time, error) {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE usuario_codigo_email SET usado = NOW() WHERE id_usuario = ? AND usado IS NULL", idUsuario)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error invalidando códigos por email del usuario %d: %w", idUsuario, err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO usuario_codigo_email (id_usuario, codigo_hash, expiracion)
+		VALUES (?, ?, NOW() + INTERVAL ? SECOND)`, idUsuario, hash, int(vigencia.Seconds()))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error guardando código por email del usuario %d: %w", idUsuario, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var expiracion time.Time
+	err = r.db.QueryRowContext(ctx,
+		"SELECT expiracion FROM usuario_codigo_email WHERE id_codigo_email = ?", id).Scan(&expiracion)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error leyendo código por email %d: %w", id, err)
+	}
+	return expiracion, nil
+}
+
+// CodigoEmailVigente devuelve el último código por email sin usar ni vencer (ErrNotFound si no hay)
+func (r *DosFactoresRepo) CodigoEmailVigente(ctx context.Context, idUsuario int) (id int, hash string, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT id_codigo_email, codigo_hash FROM usuario_codigo_email
+		WHERE id_usuario = ? AND usado IS NULL AND expiracion > NOW()
+		ORDER BY id_codigo_email DESC LIMIT 1`, idUsuario).Scan(&id, &hash)
+	if err == sql.ErrNoRows {
+		return 0, "", utilidades.ErrNotFound{Entity: "código por email", Campo: "id_usuario", Valor: fmt.Sprintf("%d", idUsuario)}
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("error obteniendo código por email del usuario %d: %w", idUsuario, err)
+	}
+	return id, hash, nil
+}
+
+// UsarCodigoEmail marca el código como usado; false si otro pedido lo usó antes
+func (r *DosFactoresRepo) UsarCodigoEmail(ctx context.Context, idCodigo int) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE usuario_codigo_email SET usado = NOW() WHERE id_codigo_email = ? AND usado IS NULL", idCodigo)
+	if err != nil {
+		return false, fmt.Errorf("error usando código por email %d: %w", idCodigo, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// SumarIntentoCodigoEmail cuenta un intento fallido y descarta el código al llegar al máximo
+func (r *DosFactoresRepo) SumarIntentoCodigoEmail(ctx context.Context, idCodigo, maxIntentos int) error {
+	// MySQL asigna en orden: el IF ya ve los intentos sumados
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE usuario_codigo_email
+		SET intentos = intentos + 1, usado = IF(intentos >= ?, NOW(), usado)
+		WHERE id_codigo_email = ?`, maxIntentos, idCodigo)
+	if err != nil {
+		return fmt.Errorf("error contando intento del código por email %d: %w", idCodigo, err)
+	}
+	return nil
+}
@@ -2,7 +2,11 @@ package repositorios
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+
 	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
 )
 
 type EmpresaRepo struct{}
@@ -32,4 +36,77 @@ func (r *EmpresaRepo) ObtenerIDPorCUIT(ctx context.Context, db Execer, cuit stri
 	query := "SELECT id_empresa FROM empresa WHERE cuit = ? AND borrado IS NULL"
 	err := db.QueryRowContext(ctx, query, cuit).Scan(&id)
 	return id, err
-}
\ No newline at end of file
+}
+
+// ObtenerPorID obtiene una empresa activa por su ID
+func (r *EmpresaRepo) ObtenerPorID(ctx context.Context, db Execer, idEmpresa int) (*modelos.Empresa, error) {
+	query := `SELECT id_empresa, nombre_comercial, razon_social, cuit, id_tipo_empresa, id_direccion,
+		distrito_nombre, departamento_nombre, provincia_nombre,
+		telefono, telefono_alternativo, email, id_tipo_iva, id_usuario_creador, creado, ultimo_cambio
+		FROM empresa WHERE id_empresa = ? AND borrado IS NULL`
+
+	e := &modelos.Empresa{}
+	var telAlt sql.NullString
+	var idTipoIva, idCreador sql.NullInt64
+	err := db.QueryRowContext(ctx, query, idEmpresa).Scan(
+		&e.IDEmpresa, &e.NombreComercial, &e.RazonSocial, &e.Cuit, &e.IDTipoEmpresa, &e.IDDireccion,
+		&e.DistritoNombre, &e.DepartamentoNombre, &e.ProvinciaNombre,
+		&e.Telefono, &telAlt, &e.Email, &idTipoIva, &idCreador, &e.Creado, &e.UltimoCambio,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utilidades.ErrNotFound{Entity: "empresa", Campo: "id_empresa", Valor: fmt.Sprintf("%d", idEmpresa)}
+		}
+		return nil, fmt.Errorf("error obteniendo empresa: %w", err)
+	}
+	if telAlt.Valid {
+		e.TelefonoAlternativo = &telAlt.String
+	}
+	if idTipoIva.Valid {
+		v := int(idTipoIva.Int64)
+		e.IDTipoIva = &v
+	}
+	if idCreador.Valid {
+		v := int(idCreador.Int64)
+		e.IDUsuarioCreador = &v
+	}
+	return e, nil
+}
+
+// ListarPorPersona lista las empresas con las que una persona tiene un vínculo activo
+func (r *EmpresaRepo) ListarPorPersona(ctx context.Context, db Execer, idPersona int) ([]modelos.EmpresaVinculada, error) {
+	query := `SELECT e.id_empresa, e.nombre_comercial, e.razon_social, e.cuit,
+		v.id_vinculo, v.nombre_vinculo, c.id_cargo, c.nombre
+		FROM persona_vinculo_empresa pve
+		INNER JOIN empresa e ON e.id_empresa = pve.id_empresa AND e.borrado IS NULL
+		INNER JOIN vinculo v ON v.id_vinculo = pve.id_vinculo
+		LEFT JOIN cargo c ON c.id_cargo = pve.id_cargo
+		WHERE pve.id_persona = ? AND pve.borrado IS NULL
+		ORDER BY e.nombre_comercial`
+
+	rows, err := db.QueryContext(ctx, query, idPersona)
+	if err != nil {
+		return nil, fmt.Errorf("error listando empresas de persona: %w", err)
+	}
+	defer rows.Close()
+
+	empresas := []modelos.EmpresaVinculada{}
+	for rows.Next() {
+		var ev modelos.EmpresaVinculada
+		var idCargo sql.NullInt64
+		var nombreCargo sql.NullString
+		if err := rows.Scan(&ev.IDEmpresa, &ev.NombreComercial, &ev.RazonSocial, &ev.Cuit,
+			&ev.IDVinculo, &ev.NombreVinculo, &idCargo, &nombreCargo); err != nil {
+			return nil, fmt.Errorf("error escaneando empresa vinculada: %w", err)
+		}
+		if idCargo.Valid {
+			v := int(idCargo.Int64)
+			ev.IDCargo = &v
+		}
+		if nombreCargo.Valid {
+			ev.NombreCargo = &nombreCargo.String
+		}
+		empresas = append(empresas, ev)
+	}
+	return empresas, rows.Err()
+}
@@ -0,0 +1,271 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// EntidadConEstado describe las tablas de una entidad con ciclo de vida
+type EntidadConEstado struct {
+	Nombre         string // nombre para mensajes y errores
+	Tabla          string
+	ColumnaID      string
+	TablaEstado    string
+	ColumnaEstado  string // id del estado, igual en la entidad y en el catálogo
+	TablaHistorial string
+}
+
+var (
+	EntidadConexion = EntidadConEstado{
+		Nombre:         "conexion",
+		Tabla:          "conexion",
+		ColumnaID:      "id_conexion",
+		TablaEstado:    "estado_conexion",
+		ColumnaEstado:  "id_estado_conexion",
+		TablaHistorial: "conexion_estado_historial",
+	}
+	EntidadContrato = EntidadConEstado{
+		Nombre:         "contrato",
+		Tabla:          "contrato",
+		ColumnaID:      "id_contrato",
+		TablaEstado:    "estado_contrato",
+		ColumnaEstado:  "id_estado_contrato",
+		TablaHistorial: "contrato_estado_historial",
+	}
+)
+
+// EstadoHistorialRepo lee y cambia el estado de conexiones y contratos y registra cada cambio
+type EstadoHistorialRepo struct {
+	db Execer
+}
+
+// NewEstadoHistorialRepo crea una nueva instancia de EstadoHistorialRepo. Para que el
+// historial sea atómico con el cambio de estado debe recibir la misma transacción.
+func NewEstadoHistorialRepo(db Execer) *EstadoHistorialRepo {
+	return &EstadoHistorialRepo{db: db}
+}
+
+// ObtenerEstadoParaActualizar devuelve el estado actual y bloquea la fila hasta el fin de la transacción
+func (r *EstadoHistorialRepo) ObtenerEstadoParaActualizar(ctx context.Context, e EntidadConEstado, id int) (int, string, error) {
+	var idEstado int
+	var nombre string
+	query := `
+		SELECT t.` + e.ColumnaEstado + `, es.nombre
+		FROM ` + e.Tabla + ` t
+		JOIN ` + e.TablaEstado + ` es ON es.` + e.ColumnaEstado + ` = t.` + e.ColumnaEstado + `
+		WHERE t.` + e.ColumnaID + ` = ? AND t.borrado IS NULL
+		FOR UPDATE
+	`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&idEstado, &nombre)
+	if err == sql.ErrNoRows {
+		return 0, "", utilidades.ErrNotFound{Entity: e.Nombre, Campo: e.ColumnaID, Valor: fmt.Sprintf("%d", id)}
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("error obteniendo estado de %s: %w", e.Nombre, err)
+	}
+	return idEstado, nombre, nil
+}
+
+// ObtenerIDEstado busca un estado del catálogo de la entidad por nombre
+func (r *EstadoHistorialRepo) ObtenerIDEstado(ctx context.Context, e EntidadConEstado, nombre string) (int, error) {
+	var idEstado int
+	query := `SELECT ` + e.ColumnaEstado + ` FROM ` + e.TablaEstado + ` WHERE nombre = ? AND borrado IS NULL LIMIT 1`
+	err := r.db.QueryRowContext(ctx, query, nombre).Scan(&idEstado)
+	if err == sql.ErrNoRows {
+		return 0, utilidades.ErrNotFound{Entity: e.TablaEstado, Campo: "nombre", Valor: nombre}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error buscando %s: %w", e.TablaEstado, err)
+	}
+	return idEstado, nil
+}
+
+// ActualizarEstado cambia el estado de la entidad
+func (r *EstadoHistorialRepo) ActualizarEstado(ctx context.Context, e EntidadConEstado, id, idEstado int) error {
+	query := `UPDATE ` + e.Tabla + ` SET ` + e.ColumnaEstado + ` = ?, ultimo_cambio = CURRENT_TIMESTAMP WHERE ` + e.ColumnaID + ` = ?`
+	if _, err := r.db.ExecContext(ctx, query, idEstado, id); err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
+
+// Registrar agrega una fila al historial de la entidad
+func (r *EstadoHistorialRepo) Registrar(ctx context.Context, e EntidadConEstado, c *modelos.CambioEstado) error {
+	query := `
+		INSERT INTO ` + e.TablaHistorial + ` (` + e.ColumnaID + `, id_estado_anterior, id_estado_nuevo, id_usuario, observacion)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	res, err := r.db.ExecContext(ctx, query, c.IDEntidad, c.IDEstadoAnterior, c.IDEstadoNuevo, c.IDUsuario, c.Observacion)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	c.IDHistorial = int(id)
+	return nil
+}
+
+// ObtenerIDsContratosPorConexion devuelve los contratos no borrados de una conexión
+func (r *EstadoHistorialRepo) ObtenerIDsContratosPorConexion(ctx context.Context, idConexion int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id_contrato FROM contrato WHERE id_conexion = ? AND borrado IS NULL ORDER BY id_contrato`, idConexion)
+	if err != nil {
+		return nil, fmt.Errorf("error listando contratos de la conexión %d: %w", idConexion, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ContratoFirmado indica si el cliente completó la firma del contrato
+func (r *EstadoHistorialRepo) ContratoFirmado(ctx context.Context, idContrato int) (bool, error) {
+	var cnt int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(1) FROM contrato_firma WHERE id_contrato = ? AND firmado = 1`, idContrato).Scan(&cnt)
+	if err != nil {
+		return false, fmt.Errorf("error verificando firma del contrato %d: %w", idContrato, err)
+	}
+	return cnt > 0, nil
+}
+
+// ConexionConContratoEnEstado indica si la conexión tiene algún contrato no borrado en el estado dado
+func (r *EstadoHistorialRepo) ConexionConContratoEnEstado(ctx context.Context, idConexion int, estado string) (bool, error) {
+	var cnt int
+	query := `
+		SELECT COUNT(1)
+		FROM contrato c
+		JOIN estado_contrato ec ON ec.id_estado_contrato = c.id_estado_contrato
+		WHERE c.id_conexion = ? AND c.borrado IS NULL AND ec.nombre = ?
+	`
+	if err := r.db.QueryRowContext(ctx, query, idConexion, estado).Scan(&cnt); err != nil {
+		return false, fmt.Errorf("error verificando contratos de la conexión %d: %w", idConexion, err)
+	}
+	return cnt > 0, nil
+}
+
+// ConexionConDatosTecnicos indica si la verificación cargó NAP y VLAN
+func (r *EstadoHistorialRepo) ConexionConDatosTecnicos(ctx context.Context, idConexion int) (bool, error) {
+	var cnt int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(1) FROM conexion WHERE id_conexion = ? AND detalleNodo IS NOT NULL AND vlanA IS NOT NULL`,
+		idConexion).Scan(&cnt)
+	if err != nil {
+		return false, fmt.Errorf("error verificando datos técnicos de la conexión %d: %w", idConexion, err)
+	}
+	return cnt > 0, nil
+}
+
+// MarcarInstalada completa la fecha de instalación si todavía no la tiene
+func (r *EstadoHistorialRepo) MarcarInstalada(ctx context.Context, idConexion int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE conexion SET fecha_instalacion = COALESCE(fecha_instalacion, CURDATE()) WHERE id_conexion = ?`, idConexion)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// MarcarBajaConexion registra la fecha de baja de la conexión
+func (r *EstadoHistorialRepo) MarcarBajaConexion(ctx context.Context, idConexion int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE conexion SET fecha_baja = CURDATE() WHERE id_conexion = ?`, idConexion)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// LimpiarSuspensionPorDeuda quita la marca de suspensión por deuda al reactivar la conexión
+func (r *EstadoHistorialRepo) LimpiarSuspensionPorDeuda(ctx context.Context, idConexion int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE conexion SET suspendida_por_deuda = NULL WHERE id_conexion = ?`, idConexion)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// MarcarFinContrato registra la fecha de fin del contrato
+func (r *EstadoHistorialRepo) MarcarFinContrato(ctx context.Context, idContrato int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE contrato SET fecha_fin = CURDATE() WHERE id_contrato = ?`, idContrato)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// ObtenerCabeceraConexion devuelve número, titular y estado actual de una conexión
+func (r *EstadoHistorialRepo) ObtenerCabeceraConexion(ctx context.Context, idConexion int) (*modelos.HistorialConexionResponse, int, error) {
+	h := &modelos.HistorialConexionResponse{IDConexion: idConexion}
+	var idPersona int
+	query := `
+		SELECT c.nro_conexion, c.id_persona, ec.nombre
+		FROM conexion c
+		JOIN estado_conexion ec ON ec.id_estado_conexion = c.id_estado_conexion
+		WHERE c.id_conexion = ? AND c.borrado IS NULL
+	`
+	err := r.db.QueryRowContext(ctx, query, idConexion).Scan(&h.NroConexion, &idPersona, &h.EstadoActual)
+	if err == sql.ErrNoRows {
+		return nil, 0, utilidades.ErrNotFound{Entity: "conexion", Campo: "id_conexion", Valor: fmt.Sprintf("%d", idConexion)}
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error obteniendo conexión %d: %w", idConexion, err)
+	}
+	return h, idPersona, nil
+}
+
+// ListarHistorialConexion devuelve, en orden cronológico, los cambios de estado de la
+// conexión y de sus contratos
+func (r *EstadoHistorialRepo) ListarHistorialConexion(ctx context.Context, idConexion int) ([]modelos.EventoHistorial, error) {
+	query := `
+		SELECT 'conexion' AS entidad, h.id_conexion AS id, h.id_historial, h.creado AS fecha,
+		       ea.nombre, en.nombre, h.observacion, u.id_usuario, p.nombre, p.apellido, u.email
+		FROM conexion_estado_historial h
+		JOIN estado_conexion en ON en.id_estado_conexion = h.id_estado_nuevo
+		LEFT JOIN estado_conexion ea ON ea.id_estado_conexion = h.id_estado_anterior
+		LEFT JOIN usuario u ON u.id_usuario = h.id_usuario
+		LEFT JOIN persona p ON p.id_persona = u.id_persona
+		WHERE h.id_conexion = ?
+		UNION ALL
+		SELECT 'contrato', h.id_contrato, h.id_historial, h.creado,
+		       ea.nombre, en.nombre, h.observacion, u.id_usuario, p.nombre, p.apellido, u.email
+		FROM contrato_estado_historial h
+		JOIN contrato c ON c.id_contrato = h.id_contrato
+		JOIN estado_contrato en ON en.id_estado_contrato = h.id_estado_nuevo
+		LEFT JOIN estado_contrato ea ON ea.id_estado_contrato = h.id_estado_anterior
+		LEFT JOIN usuario u ON u.id_usuario = h.id_usuario
+		LEFT JOIN persona p ON p.id_persona = u.id_persona
+		WHERE c.id_conexion = ?
+		ORDER BY fecha, id_historial
+	`
+	rows, err := r.db.QueryContext(ctx, query, idConexion, idConexion)
+	if err != nil {
+		return nil, fmt.Errorf("error listando historial de la conexión %d: %w", idConexion, err)
+	}
+	defer rows.Close()
+
+	eventos := []modelos.EventoHistorial{}
+	for rows.Next() {
+		var (
+			e                       modelos.EventoHistorial
+			idHistorial             int
+			idUsuario               sql.NullInt64
+			nombre, apellido, email sql.NullString
+		)
+		err := rows.Scan(&e.Entidad, &e.ID, &idHistorial, &e.Fecha,
+			&e.EstadoAnterior, &e.Estado, &e.Observacion, &idUsuario, &nombre, &apellido, &email)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo historial de la conexión %d: %w", idConexion, err)
+		}
+		if idUsuario.Valid {
+			e.Responsable = &modelos.ResponsableCambio{
+				IDUsuario: int(idUsuario.Int64),
+				Nombre:    strings.TrimSpace(nombre.String + " " + apellido.String),
+				Email:     email.String,
+			}
+		}
+		eventos = append(eventos, e)
+	}
+	return eventos, rows.Err()
+}
@@ -0,0 +1,377 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// FacturaRepo maneja las facturas, sus ítems y la numeración de comprobantes
+type FacturaRepo struct {
+	db Execer
+}
+
+// NewFacturaRepo crea una nueva instancia de FacturaRepo
+func NewFacturaRepo(db Execer) *FacturaRepo {
+	return &FacturaRepo{db: db}
+}
+
+const selectFactura = `
+	SELECT f.id_factura, f.tipo_comprobante, f.punto_venta, f.numero, f.id_contrato,
+	       f.id_conexion, cn.nro_conexion, f.id_persona, f.periodo,
+	       DATE_FORMAT(f.periodo_desde, '%Y-%m-%d'), DATE_FORMAT(f.periodo_hasta, '%Y-%m-%d'),
+	       DATE_FORMAT(f.fecha_emision, '%Y-%m-%d'), DATE_FORMAT(f.fecha_vencimiento, '%Y-%m-%d'),
+	       f.cliente_nombre, f.cliente_documento, f.cliente_condicion_iva, f.cliente_domicilio,
+	       f.alicuota_iva, f.neto, f.iva, f.total, f.estado, f.pdf_path, f.pdf_hash, f.creado,
+	       f.afip_estado, f.cae, DATE_FORMAT(f.cae_vencimiento, '%Y-%m-%d'), f.afip_observaciones
+	FROM factura f
+	JOIN conexion cn ON cn.id_conexion = f.id_conexion
+`
+
+func escanearFactura(s interface{ Scan(...interface{}) error }) (*modelos.Factura, error) {
+	var f modelos.Factura
+	var numero sql.NullInt64
+	err := s.Scan(&f.IDFactura, &f.TipoComprobante, &f.PuntoVenta, &numero, &f.IDContrato,
+		&f.IDConexion, &f.NroConexion, &f.IDPersona, &f.Periodo,
+		&f.PeriodoDesde, &f.PeriodoHasta, &f.FechaEmision, &f.FechaVencimiento,
+		&f.ClienteNombre, &f.ClienteDocumento, &f.ClienteCondicionIVA, &f.ClienteDomicilio,
+		&f.AlicuotaIVA, &f.Neto, &f.IVA, &f.Total, &f.Estado, &f.PDFPath, &f.PDFHash, &f.Creado,
+		&f.AfipEstado, &f.CAE, &f.CAEVencimiento, &f.AfipObservaciones)
+	if err != nil {
+		return nil, err
+	}
+	// Pendiente de CAE la factura todavía no tiene número
+	if numero.Valid {
+		f.Numero = int(numero.Int64)
+		f.NumeroComprobante = fmt.Sprintf("%04d-%08d", f.PuntoVenta, f.Numero)
+	}
+	f.PDFDisponible = f.PDFPath != nil && *f.PDFPath != ""
+	return &f, nil
+}
+
+// ListarContratosAFacturar devuelve los contratos vigentes, con la conexión instalada dentro
+// del período, que todavía no tienen factura del período. Con reemitirRechazadas, una factura
+// rechazada por AFIP no cuenta y el contrato vuelve a facturarse.
+func (r *FacturaRepo) ListarContratosAFacturar(ctx context.Context, periodo string, desde, hasta time.Time, reemitirRechazadas bool) ([]int, error) {
+	// Estado de AFIP con el que una factura del período no cuenta; vacío, cuentan todas
+	ignorada := ""
+	if reemitirRechazadas {
+		ignorada = modelos.EstadoAfipRechazada
+	}
+	query := `
+		SELECT c.id_contrato
+		FROM contrato c
+		JOIN estado_contrato ec ON ec.id_estado_contrato = c.id_estado_contrato
+		JOIN conexion cn ON cn.id_conexion = c.id_conexion
+		WHERE c.borrado IS NULL
+		  AND ec.nombre = ?
+		  AND cn.fecha_instalacion IS NOT NULL AND cn.fecha_instalacion <= ?
+		  AND (c.fecha_fin IS NULL OR c.fecha_fin >= ?)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM factura f
+		      WHERE f.id_contrato = c.id_contrato AND f.periodo = ?
+		        AND (f.afip_estado IS NULL OR f.afip_estado <> ?))
+		ORDER BY c.id_contrato
+	`
+	rows, err := r.db.QueryContext(ctx, query, modelos.EstadoContratoVigente, hasta, desde, periodo, ignorada)
+	if err != nil {
+		return nil, fmt.Errorf("error listando contratos a facturar del período %s: %w", periodo, err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error escaneando contrato a facturar: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ObtenerDatosFacturacion devuelve el plan, el abono que rige en la fecha, la promoción, la
+// instalación y el cliente del contrato. Si el contrato se celebró con una empresa distinta de
+// la operadora, el cliente es la empresa; si no, la persona titular. Sin condición frente al
+// IVA se toma Consumidor Final.
+func (r *FacturaRepo) ObtenerDatosFacturacion(ctx context.Context, idContrato int, fecha time.Time) (*modelos.DatosFacturacionContrato, error) {
+	query := `
+		SELECT c.id_contrato, c.id_conexion, cn.nro_conexion, c.id_persona,
+		       pl.nombre, pl.velocidad_mbps, ` + sqlPrecioContrato + `, COALESCE(c.costo_instalacion, 0),
+		       COALESCE(pr.nombre, ''), COALESCE(c.promocion_descuento, 0), COALESCE(c.promocion_meses, 0),
+		       cn.fecha_instalacion, c.fecha_fin,
+		       EXISTS (SELECT 1 FROM factura f WHERE f.id_contrato = c.id_contrato
+		               AND (f.afip_estado IS NULL OR f.afip_estado <> ?)),
+		       COALESCE(e.razon_social, CONCAT(p.nombre, ' ', p.apellido)),
+		       COALESCE(e.cuit, p.cuil, p.dni),
+		       COALESCE(IF(e.id_empresa IS NULL, tp.tipo_iva, te.tipo_iva), ?),
+		       CONCAT_WS(', ', NULLIF(CONCAT_WS(' ', d.calle, d.numero), ''),
+		                 cn.distrito_nombre, cn.departamento_nombre, cn.provincia_nombre)
+		FROM contrato c
+		JOIN conexion cn ON cn.id_conexion = c.id_conexion
+		JOIN persona p ON p.id_persona = c.id_persona
+		JOIN plan pl ON pl.id_plan = c.id_plan
+		LEFT JOIN promocion pr ON pr.id_promocion = c.id_promocion
+		LEFT JOIN empresa e ON e.id_empresa = c.id_empresa AND e.cuit <> ?
+		LEFT JOIN tipo_iva tp ON tp.id_tipo_iva = p.id_tipo_iva
+		LEFT JOIN tipo_iva te ON te.id_tipo_iva = e.id_tipo_iva
+		LEFT JOIN direccion d ON d.id_direccion = cn.id_direccion
+		WHERE c.id_contrato = ? AND c.borrado IS NULL
+	`
+	var d modelos.DatosFacturacionContrato
+	var fechaInstalacion sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, fecha.Format("2006-01-02"), modelos.EstadoAfipRechazada, modelos.TipoIVAConsumidorFinal, modelos.EmpresaOperadoraCUIT, idContrato).Scan(
+		&d.IDContrato, &d.IDConexion, &d.NroConexion, &d.IDPersona,
+		&d.PlanNombre, &d.PlanVelocidad, &d.PlanPrecio, &d.CostoInstalacion,
+		&d.PromocionNombre, &d.PromocionDescuento, &d.PromocionMeses,
+		&fechaInstalacion, &d.FechaFin, &d.TieneFacturas,
+		&d.ClienteNombre, &d.ClienteDocumento, &d.ClienteCondicionIVA, &d.ClienteDomicilio,
+	)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "contrato", Campo: "id_contrato", Valor: fmt.Sprintf("%d", idContrato)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo datos de facturación del contrato %d: %w", idContrato, err)
+	}
+	if !fechaInstalacion.Valid {
+		return nil, fmt.Errorf("la conexión %d del contrato %d no tiene fecha de instalación", d.IDConexion, idContrato)
+	}
+	d.FechaInstalacion = fechaInstalacion.Time
+	return &d, nil
+}
+
+// SiguienteNumero reserva el próximo número del tipo de comprobante y punto de venta. La fila
+// de numeración queda bloqueada hasta el fin de la transacción, así que debe llamarse dentro
+// de la misma transacción que inserta la factura.
+func (r *FacturaRepo) SiguienteNumero(ctx context.Context, tipoComprobante string, puntoVenta int) (int, error) {
+	ultimo, err := r.BloquearNumeracion(ctx, tipoComprobante, puntoVenta)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.ActualizarNumeracion(ctx, tipoComprobante, puntoVenta, ultimo+1); err != nil {
+		return 0, err
+	}
+	return ultimo + 1, nil
+}
+
+// BloquearNumeracion devuelve el último número usado del tipo de comprobante y punto de venta
+// y bloquea su fila hasta el fin de la transacción
+func (r *FacturaRepo) BloquearNumeracion(ctx context.Context, tipoComprobante string, puntoVenta int) (int, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO factura_numeracion (tipo_comprobante, punto_venta, ultimo_numero) VALUES (?, ?, 0)
+		ON DUPLICATE KEY UPDATE ultimo_numero = ultimo_numero`, tipoComprobante, puntoVenta)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+
+	var ultimo int
+	err = r.db.QueryRowContext(ctx,
+		`SELECT ultimo_numero FROM factura_numeracion WHERE tipo_comprobante = ? AND punto_venta = ? FOR UPDATE`,
+		tipoComprobante, puntoVenta).Scan(&ultimo)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo numeración %s-%04d: %w", tipoComprobante, puntoVenta, err)
+	}
+	return ultimo, nil
+}
+
+// ActualizarNumeracion fija el último número usado del tipo de comprobante y punto de venta
+func (r *FacturaRepo) ActualizarNumeracion(ctx context.Context, tipoComprobante string, puntoVenta, numero int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE factura_numeracion SET ultimo_numero = ? WHERE tipo_comprobante = ? AND punto_venta = ?`,
+		numero, tipoComprobante, puntoVenta)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
+
+// Numeraciones devuelve el último número usado de cada tipo de comprobante y punto de venta
+func (r *FacturaRepo) Numeraciones(ctx context.Context) ([]modelos.NumeracionFactura, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT tipo_comprobante, punto_venta, ultimo_numero FROM factura_numeracion`)
+	if err != nil {
+		return nil, fmt.Errorf("error listando numeración de facturas: %w", err)
+	}
+	defer rows.Close()
+
+	numeraciones := []modelos.NumeracionFactura{}
+	for rows.Next() {
+		var n modelos.NumeracionFactura
+		if err := rows.Scan(&n.TipoComprobante, &n.PuntoVenta, &n.UltimoNumero); err != nil {
+			return nil, fmt.Errorf("error escaneando numeración de facturas: %w", err)
+		}
+		numeraciones = append(numeraciones, n)
+	}
+	return numeraciones, rows.Err()
+}
+
+// Crear inserta la factura con sus ítems y devuelve su id
+func (r *FacturaRepo) Crear(ctx context.Context, f *modelos.Factura) (int, error) {
+	query := `
+		INSERT INTO factura (tipo_comprobante, punto_venta, numero, id_contrato, id_conexion, id_persona,
+			periodo, periodo_desde, periodo_hasta, fecha_emision, fecha_vencimiento,
+			cliente_nombre, cliente_documento, cliente_condicion_iva, cliente_domicilio,
+			alicuota_iva, neto, iva, total, estado, afip_estado)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	var numero interface{}
+	if f.Numero > 0 {
+		numero = f.Numero
+	}
+	res, err := r.db.ExecContext(ctx, query,
+		f.TipoComprobante, f.PuntoVenta, numero, f.IDContrato, f.IDConexion, f.IDPersona,
+		f.Periodo, f.PeriodoDesde, f.PeriodoHasta, f.FechaEmision, f.FechaVencimiento,
+		f.ClienteNombre, f.ClienteDocumento, f.ClienteCondicionIVA, f.ClienteDomicilio,
+		f.AlicuotaIVA, f.Neto, f.IVA, f.Total, modelos.EstadoFacturaEmitida, f.AfipEstado)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, it := range f.Items {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO factura_item (id_factura, concepto, cantidad, precio_unitario, neto, iva, total)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, it.Concepto, it.Cantidad, it.PrecioUnitario, it.Neto, it.IVA, it.Total)
+		if err != nil {
+			return 0, utilidades.TraducirErrorBD(err)
+		}
+	}
+	return int(id), nil
+}
+
+// ObtenerPorID devuelve la factura con sus ítems
+func (r *FacturaRepo) ObtenerPorID(ctx context.Context, idFactura int) (*modelos.Factura, error) {
+	f, err := escanearFactura(r.db.QueryRowContext(ctx, selectFactura+` WHERE f.id_factura = ?`, idFactura))
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "factura", Campo: "id_factura", Valor: fmt.Sprintf("%d", idFactura)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo factura %d: %w", idFactura, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT concepto, cantidad, precio_unitario, neto, iva, total
+		FROM factura_item WHERE id_factura = ? ORDER BY id_item`, idFactura)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo ítems de la factura %d: %w", idFactura, err)
+	}
+	defer rows.Close()
+
+	f.Items = []modelos.ItemFactura{}
+	for rows.Next() {
+		var it modelos.ItemFactura
+		if err := rows.Scan(&it.Concepto, &it.Cantidad, &it.PrecioUnitario, &it.Neto, &it.IVA, &it.Total); err != nil {
+			return nil, fmt.Errorf("error escaneando ítem de factura: %w", err)
+		}
+		f.Items = append(f.Items, it)
+	}
+	return f, rows.Err()
+}
+
+// ListarPorPersona devuelve las facturas del titular, de la más reciente a la más antigua
+func (r *FacturaRepo) ListarPorPersona(ctx context.Context, idPersona, limit, offset int) ([]modelos.Factura, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM factura WHERE id_persona = ?`, idPersona).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error contando facturas de la persona %d: %w", idPersona, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		selectFactura+` WHERE f.id_persona = ? ORDER BY f.fecha_emision DESC, f.id_factura DESC LIMIT ? OFFSET ?`,
+		idPersona, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listando facturas de la persona %d: %w", idPersona, err)
+	}
+	defer rows.Close()
+
+	facturas := []modelos.Factura{}
+	for rows.Next() {
+		f, err := escanearFactura(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error escaneando factura: %w", err)
+		}
+		facturas = append(facturas, *f)
+	}
+	return facturas, total, rows.Err()
+}
+
+// ActualizarPDF guarda la ruta y el hash SHA-256 del PDF generado
+func (r *FacturaRepo) ActualizarPDF(ctx context.Context, idFactura int, path, hash string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE factura SET pdf_path = ?, pdf_hash = ? WHERE id_factura = ?`, path, hash, idFactura)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
+
+// ListarPendientesAFIP devuelve, en orden de emisión, las facturas que esperan el CAE
+func (r *FacturaRepo) ListarPendientesAFIP(ctx context.Context, limite int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id_factura FROM factura WHERE afip_estado = ? ORDER BY id_factura LIMIT ?`,
+		modelos.EstadoAfipPendiente, limite)
+	if err != nil {
+		return nil, fmt.Errorf("error listando facturas pendientes de CAE: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error escaneando factura pendiente de CAE: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// BloquearEstadoAFIP devuelve el estado de autorización de la factura y bloquea su fila hasta
+// el fin de la transacción
+func (r *FacturaRepo) BloquearEstadoAFIP(ctx context.Context, idFactura int) (string, error) {
+	var estado sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT afip_estado FROM factura WHERE id_factura = ? FOR UPDATE`, idFactura).Scan(&estado)
+	if err == sql.ErrNoRows {
+		return "", utilidades.ErrNotFound{Entity: "factura", Campo: "id_factura", Valor: fmt.Sprintf("%d", idFactura)}
+	}
+	if err != nil {
+		return "", fmt.Errorf("error bloqueando factura %d: %w", idFactura, err)
+	}
+	return estado.String, nil
+}
+
+// RegistrarCAE guarda el número y el CAE con los que AFIP autorizó la factura
+func (r *FacturaRepo) RegistrarCAE(ctx context.Context, idFactura, numero int, cae string, vencimiento time.Time, observaciones *string, autorizada time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE factura
+		SET numero = ?, cae = ?, cae_vencimiento = ?, afip_estado = ?, afip_observaciones = ?,
+		    afip_intentos = afip_intentos + 1, autorizada = ?
+		WHERE id_factura = ?`,
+		numero, cae, vencimiento.Format("2006-01-02"), modelos.EstadoAfipAutorizada, observaciones, autorizada, idFactura)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
+
+// RegistrarResultadoAFIP cuenta un intento de autorización fallido con su motivo. Con
+// rechazada la factura deja de reintentarse.
+func (r *FacturaRepo) RegistrarResultadoAFIP(ctx context.Context, idFactura int, rechazada bool, observaciones string) error {
+	estado := modelos.EstadoAfipPendiente
+	if rechazada {
+		estado = modelos.EstadoAfipRechazada
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE factura SET afip_estado = ?, afip_observaciones = ?, afip_intentos = afip_intentos + 1
+		WHERE id_factura = ?`,
+		estado, observaciones, idFactura)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// IntentoLoginRepo consulta el registro de intentos de login y lleva los contadores de
+// fallos y los bloqueos por cuenta y por IP. Las fechas se calculan con NOW() de la base
+// para que no dependan del reloj de cada instancia.
+type IntentoLoginRepo struct {
+	db Execer
+}
+
+// NewIntentoLoginRepo crea una nueva instancia de IntentoLoginRepo
+func NewIntentoLoginRepo(db Execer) *IntentoLoginRepo {
+	return &IntentoLoginRepo{db: db}
+}
+
+// SegundosBloqueo devuelve cuánto le falta al bloqueo vigente de una cuenta o IP (0 si no tiene)
+func (r *IntentoLoginRepo) SegundosBloqueo(ctx context.Context, tipo, clave string) (int, error) {
+	var segundos int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT GREATEST(TIMESTAMPDIFF(SECOND, NOW(), bloqueado_hasta), 1)
+		FROM login_bloqueo
+		WHERE tipo = ? AND clave = ? AND bloqueado_hasta > NOW()`,
+		tipo, clave).Scan(&segundos)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error consultando bloqueo de login (%s %s): %w", tipo, clave, err)
+	}
+	return segundos, nil
+}
+
+// SumarFallo cuenta un intento fallido y devuelve los fallos dentro de la ventana y el nivel
+// (bloqueos ya sufridos). El nivel vuelve a cero después de un día sin fallos ni bloqueos.
+func (r *IntentoLoginRepo) SumarFallo(ctx context.Context, tipo, clave string, ventana time.Duration) (fallos, nivel int, err error) {
+	// MySQL evalúa las asignaciones en orden: nivel y fallos usan el ultimo_fallo anterior
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO login_bloqueo (tipo, clave, fallos, ultimo_fallo)
+		VALUES (?, ?, 1, NOW())
+		ON DUPLICATE KEY UPDATE
+		    nivel = IF(GREATEST(COALESCE(bloqueado_hasta, ultimo_fallo), ultimo_fallo) > NOW() - INTERVAL 1 DAY, nivel, 0),
+		    fallos = IF(ultimo_fallo > NOW() - INTERVAL ? SECOND, fallos + 1, 1),
+		    ultimo_fallo = NOW()`,
+		tipo, clave, int(ventana.Seconds()))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error sumando fallo de login (%s %s): %w", tipo, clave, utilidades.TraducirErrorBD(err))
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		"SELECT fallos, nivel FROM login_bloqueo WHERE tipo = ? AND clave = ?", tipo, clave).Scan(&fallos, &nivel)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error leyendo fallos de login (%s %s): %w", tipo, clave, err)
+	}
+	return fallos, nivel, nil
+}
+
+// Bloquear bloquea la cuenta o IP por la duración indicada y sube su nivel
+func (r *IntentoLoginRepo) Bloquear(ctx context.Context, tipo, clave string, duracion time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_bloqueo
+		SET fallos = 0, nivel = nivel + 1, bloqueado_hasta = NOW() + INTERVAL ? SECOND
+		WHERE tipo = ? AND clave = ?`,
+		int(duracion.Seconds()), tipo, clave)
+	if err != nil {
+		return fmt.Errorf("error bloqueando login (%s %s): %w", tipo, clave, err)
+	}
+	return nil
+}
+
+// Reiniciar borra los fallos, el nivel y el bloqueo de una cuenta o IP
+func (r *IntentoLoginRepo) Reiniciar(ctx context.Context, tipo, clave string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_bloqueo SET fallos = 0, nivel = 0, bloqueado_hasta = NULL
+		WHERE tipo = ? AND clave = ? AND (fallos > 0 OR nivel > 0 OR bloqueado_hasta IS NOT NULL)`,
+		tipo, clave)
+	if err != nil {
+		return fmt.Errorf("error reiniciando fallos de login (%s %s): %w", tipo, clave, err)
+	}
+	return nil
+}
+
+// ListarBloqueosVigentes devuelve las cuentas e IPs bloqueadas en este momento
+func (r *IntentoLoginRepo) ListarBloqueosVigentes(ctx context.Context) ([]modelos.LoginBloqueo, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id_login_bloqueo, tipo, clave, fallos, nivel, ultimo_fallo, bloqueado_hasta
+		FROM login_bloqueo
+		WHERE bloqueado_hasta > NOW()
+		ORDER BY bloqueado_hasta DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error listando bloqueos de login: %w", err)
+	}
+	defer rows.Close()
+
+	bloqueos := []modelos.LoginBloqueo{}
+	for rows.Next() {
+		var b modelos.LoginBloqueo
+		var ultimoFallo, hasta sql.NullTime
+		if err := rows.Scan(&b.IDLoginBloqueo, &b.Tipo, &b.Clave, &b.Fallos, &b.Nivel, &ultimoFallo, &hasta); err != nil {
+			return nil, fmt.Errorf("error leyendo bloqueo de login: %w", err)
+		}
+		if ultimoFallo.Valid {
+			b.UltimoFallo = &ultimoFallo.Time
+		}
+		if hasta.Valid {
+			b.BloqueadoHasta = &hasta.Time
+		}
+		bloqueos = append(bloqueos, b)
+	}
+	return bloqueos, rows.Err()
+}
+
+// Levantar quita el bloqueo y los fallos de una cuenta o IP (ErrNotFound si no existe)
+func (r *IntentoLoginRepo) Levantar(ctx context.Context, idLoginBloqueo int) (*modelos.LoginBloqueo, error) {
+	var b modelos.LoginBloqueo
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id_login_bloqueo, tipo, clave FROM login_bloqueo WHERE id_login_bloqueo = ?", idLoginBloqueo).
+		Scan(&b.IDLoginBloqueo, &b.Tipo, &b.Clave)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "bloqueo de login", Campo: "id", Valor: fmt.Sprintf("%d", idLoginBloqueo)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo bloqueo de login %d: %w", idLoginBloqueo, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"UPDATE login_bloqueo SET fallos = 0, nivel = 0, bloqueado_hasta = NULL WHERE id_login_bloqueo = ?", idLoginBloqueo)
+	if err != nil {
+		return nil, fmt.Errorf("error levantando bloqueo de login %d: %w", idLoginBloqueo, err)
+	}
+	return &b, nil
+}
+
+// ListarIntentos devuelve los últimos intentos de login, opcionalmente de un email o una IP
+func (r *IntentoLoginRepo) ListarIntentos(ctx context.Context, email, ip string, limite int) ([]modelos.IntentoLogin, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, id_usuario, email, ip, user_agent, exito, mensaje, fecha
+		FROM usuario_login_log
+		WHERE (? = '' OR email = ?) AND (? = '' OR ip = ?)
+		ORDER BY fecha DESC, id DESC
+		LIMIT ?`,
+		email, email, ip, ip, limite)
+	if err != nil {
+		return nil, fmt.Errorf("error listando intentos de login: %w", err)
+	}
+	defer rows.Close()
+
+	intentos := []modelos.IntentoLogin{}
+	for rows.Next() {
+		var i modelos.IntentoLogin
+		var idUsuario sql.NullInt64
+		var email, ip, userAgent, mensaje sql.NullString
+		if err := rows.Scan(&i.ID, &idUsuario, &email, &ip, &userAgent, &i.Exito, &mensaje, &i.Fecha); err != nil {
+			return nil, fmt.Errorf("error leyendo intento de login: %w", err)
+		}
+		if idUsuario.Valid {
+			id := int(idUsuario.Int64)
+			i.IDUsuario = &id
+		}
+		if email.Valid {
+			i.Email = &email.String
+		}
+		if ip.Valid {
+			i.IP = &ip.String
+		}
+		if userAgent.Valid {
+			i.UserAgent = &userAgent.String
+		}
+		if mensaje.Valid {
+			i.Mensaje = &mensaje.String
+		}
+		intentos = append(intentos, i)
+	}
+	return intentos, rows.Err()
+}
+
+// ContarLoginsExitosos devuelve cuántos logins exitosos tiene el usuario y cuántos de ellos
+// fueron desde esa IP con ese user agent
+func (r *IntentoLoginRepo) ContarLoginsExitosos(ctx context.Context, idUsuario int, ip, userAgent string) (total, desdeDispositivo int, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(ip <=> ? AND user_agent <=> ?), 0)
+		FROM usuario_login_log
+		WHERE id_usuario = ? AND exito = 1`,
+		ip, userAgent, idUsuario).Scan(&total, &desdeDispositivo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error contando logins del usuario %d: %w", idUsuario, err)
+	}
+	return total, desdeDispositivo, nil
+}
@@ -0,0 +1,153 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// NotificacionEntregaRepo maneja la tabla notificacion_entrega (estado de envío por canal)
+type NotificacionEntregaRepo struct {
+	db Execer
+}
+
+// NewNotificacionEntregaRepo crea una nueva instancia de NotificacionEntregaRepo
+func NewNotificacionEntregaRepo(db Execer) *NotificacionEntregaRepo {
+	return &NotificacionEntregaRepo{db: db}
+}
+
+const columnasEntrega = `
+	id_entrega, id_notificacion, id_persona, canal, estado, intentos, proximo_intento,
+	id_proveedor, ultimo_error, enviado, creado, ultimo_cambio`
+
+// Encolar crea una entrega pendiente por cada canal indicado
+func (r *NotificacionEntregaRepo) Encolar(ctx context.Context, idNotificacion, idPersona int, canales []string) error {
+	query := `
+		INSERT INTO notificacion_entrega (id_notificacion, id_persona, canal, estado, intentos, proximo_intento)
+		VALUES (?, ?, ?, ?, 0, NOW())
+	`
+	for _, canal := range canales {
+		if _, err := r.db.ExecContext(ctx, query, idNotificacion, idPersona, canal, modelos.EstadoEntregaPendiente); err != nil {
+			return utilidades.TraducirErrorBD(err)
+		}
+	}
+	return nil
+}
+
+// Reservar marca hasta limite entregas pendientes con la reserva indicada, de modo que dos
+// despachadores no tomen la misma. La reserva vence sola si el despachador no informa el resultado.
+// El vencimiento se calcula con el reloj de MySQL, el mismo con el que se compara.
+func (r *NotificacionEntregaRepo) Reservar(ctx context.Context, reserva string, limite int, duracion time.Duration) ([]modelos.EntregaNotificacion, error) {
+	query := `
+		UPDATE notificacion_entrega
+		SET reserva = ?, reservado_hasta = NOW() + INTERVAL ? SECOND
+		WHERE estado = ?
+		  AND proximo_intento <= NOW()
+		  AND (reservado_hasta IS NULL OR reservado_hasta < NOW())
+		ORDER BY id_entrega
+		LIMIT ?
+	`
+	if _, err := r.db.ExecContext(ctx, query, reserva, int(duracion.Seconds()), modelos.EstadoEntregaPendiente, limite); err != nil {
+		return nil, utilidades.TraducirErrorBD(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+columnasEntrega+` FROM notificacion_entrega WHERE reserva = ? ORDER BY id_entrega`, reserva)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return escanearEntregas(rows)
+}
+
+// ObtenerPorID obtiene una entrega
+func (r *NotificacionEntregaRepo) ObtenerPorID(ctx context.Context, idEntrega int) (*modelos.EntregaNotificacion, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+columnasEntrega+` FROM notificacion_entrega WHERE id_entrega = ?`, idEntrega)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entregas, err := escanearEntregas(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entregas) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &entregas[0], nil
+}
+
+// ListarPorNotificacion devuelve las entregas de una notificación de la persona
+func (r *NotificacionEntregaRepo) ListarPorNotificacion(ctx context.Context, idNotificacion, idPersona int) ([]modelos.EntregaNotificacion, error) {
+	query := `SELECT ` + columnasEntrega + `
+		FROM notificacion_entrega
+		WHERE id_notificacion = ? AND id_persona = ?
+		ORDER BY id_entrega
+	`
+	rows, err := r.db.QueryContext(ctx, query, idNotificacion, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return escanearEntregas(rows)
+}
+
+// MarcarEnviada registra el envío exitoso y libera la reserva
+func (r *NotificacionEntregaRepo) MarcarEnviada(ctx context.Context, idEntrega int, idProveedor *string) error {
+	query := `
+		UPDATE notificacion_entrega
+		SET estado = ?, intentos = intentos + 1, id_proveedor = ?, ultimo_error = NULL,
+		    enviado = NOW(), reserva = NULL, reservado_hasta = NULL, ultimo_cambio = CURRENT_TIMESTAMP
+		WHERE id_entrega = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, modelos.EstadoEntregaEnviada, idProveedor, idEntrega)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// MarcarIntentoFallido suma un intento y deja la entrega en el estado indicado: pendiente
+// (reintentando después de espera) o fallida/omitida (espera nil) si no se reintenta más.
+func (r *NotificacionEntregaRepo) MarcarIntentoFallido(ctx context.Context, idEntrega int, estado, motivo string, espera *time.Duration) error {
+	query := `
+		UPDATE notificacion_entrega
+		SET estado = ?, intentos = intentos + 1, ultimo_error = ?,
+		    proximo_intento = COALESCE(NOW() + INTERVAL ? SECOND, proximo_intento),
+		    reserva = NULL, reservado_hasta = NULL, ultimo_cambio = CURRENT_TIMESTAMP
+		WHERE id_entrega = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, estado, motivo, segundosEspera(espera), idEntrega)
+	return utilidades.TraducirErrorBD(err)
+}
+
+func escanearEntregas(rows *sql.Rows) ([]modelos.EntregaNotificacion, error) {
+	entregas := []modelos.EntregaNotificacion{}
+	for rows.Next() {
+		var e modelos.EntregaNotificacion
+		var proximo, enviado sql.NullTime
+		var idProveedor, ultimoError sql.NullString
+
+		if err := rows.Scan(
+			&e.IDEntrega, &e.IDNotificacion, &e.IDPersona, &e.Canal, &e.Estado, &e.Intentos, &proximo,
+			&idProveedor, &ultimoError, &enviado, &e.Creado, &e.UltimoCambio,
+		); err != nil {
+			return nil, fmt.Errorf("error escaneando entrega: %w", err)
+		}
+		if proximo.Valid {
+			e.ProximoIntento = &proximo.Time
+		}
+		if enviado.Valid {
+			e.Enviado = &enviado.Time
+		}
+		if idProveedor.Valid {
+			e.IDProveedor = &idProveedor.String
+		}
+		if ultimoError.Valid {
+			e.UltimoError = &ultimoError.String
+		}
+		entregas = append(entregas, e)
+	}
+	return entregas, rows.Err()
+}
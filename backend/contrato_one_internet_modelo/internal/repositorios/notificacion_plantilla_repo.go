@@ -0,0 +1,93 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// NotificacionPlantillaRepo maneja las plantillas por tipo de notificación y canal
+type NotificacionPlantillaRepo struct {
+	db Execer
+}
+
+// NewNotificacionPlantillaRepo crea una nueva instancia de NotificacionPlantillaRepo
+func NewNotificacionPlantillaRepo(db Execer) *NotificacionPlantillaRepo {
+	return &NotificacionPlantillaRepo{db: db}
+}
+
+const columnasPlantilla = `id_plantilla, tipo, canal, asunto, cuerpo, activo, ultimo_cambio`
+
+// Obtener devuelve la plantilla activa del tipo y canal; si no existe usa la del tipo "*".
+// Devuelve sql.ErrNoRows si no hay ninguna.
+func (r *NotificacionPlantillaRepo) Obtener(ctx context.Context, tipo, canal string) (*modelos.PlantillaNotificacion, error) {
+	query := `SELECT ` + columnasPlantilla + `
+		FROM notificacion_plantilla
+		WHERE canal = ? AND tipo IN (?, ?) AND activo = 1
+		ORDER BY tipo = ? DESC
+		LIMIT 1
+	`
+	var p modelos.PlantillaNotificacion
+	err := r.db.QueryRowContext(ctx, query, canal, tipo, modelos.TipoNotificacionTodos, tipo).Scan(
+		&p.IDPlantilla, &p.Tipo, &p.Canal, &p.Asunto, &p.Cuerpo, &p.Activo, &p.UltimoCambio,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Listar devuelve todas las plantillas
+func (r *NotificacionPlantillaRepo) Listar(ctx context.Context) ([]modelos.PlantillaNotificacion, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+columnasPlantilla+` FROM notificacion_plantilla ORDER BY tipo, canal`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plantillas := []modelos.PlantillaNotificacion{}
+	for rows.Next() {
+		var p modelos.PlantillaNotificacion
+		if err := rows.Scan(&p.IDPlantilla, &p.Tipo, &p.Canal, &p.Asunto, &p.Cuerpo, &p.Activo, &p.UltimoCambio); err != nil {
+			return nil, fmt.Errorf("error escaneando plantilla: %w", err)
+		}
+		plantillas = append(plantillas, p)
+	}
+	return plantillas, rows.Err()
+}
+
+// Guardar crea o reemplaza la plantilla (tipo, canal) y devuelve su ID
+func (r *NotificacionPlantillaRepo) Guardar(ctx context.Context, p *modelos.PlantillaNotificacion) (int, error) {
+	query := `
+		INSERT INTO notificacion_plantilla (tipo, canal, asunto, cuerpo, activo)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id_plantilla = LAST_INSERT_ID(id_plantilla),
+			asunto = VALUES(asunto), cuerpo = VALUES(cuerpo), activo = VALUES(activo),
+			ultimo_cambio = CURRENT_TIMESTAMP
+	`
+	res, err := r.db.ExecContext(ctx, query, p.Tipo, p.Canal, p.Asunto, p.Cuerpo, p.Activo)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error obteniendo ID de plantilla: %w", err)
+	}
+	return int(id), nil
+}
+
+// Eliminar borra una plantilla por ID
+func (r *NotificacionPlantillaRepo) Eliminar(ctx context.Context, idPlantilla int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM notificacion_plantilla WHERE id_plantilla = ?`, idPlantilla)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// NotificacionPreferenciaRepo maneja las preferencias de canal por persona y las
+// suscripciones Web Push de sus navegadores
+type NotificacionPreferenciaRepo struct {
+	db Execer
+}
+
+// NewNotificacionPreferenciaRepo crea una nueva instancia de NotificacionPreferenciaRepo
+func NewNotificacionPreferenciaRepo(db Execer) *NotificacionPreferenciaRepo {
+	return &NotificacionPreferenciaRepo{db: db}
+}
+
+// Listar devuelve las preferencias guardadas de la persona
+func (r *NotificacionPreferenciaRepo) Listar(ctx context.Context, idPersona int) ([]modelos.PreferenciaNotificacion, error) {
+	query := `
+		SELECT canal, tipo, habilitado
+		FROM notificacion_preferencia
+		WHERE id_persona = ?
+		ORDER BY canal, tipo
+	`
+	rows, err := r.db.QueryContext(ctx, query, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := []modelos.PreferenciaNotificacion{}
+	for rows.Next() {
+		var p modelos.PreferenciaNotificacion
+		if err := rows.Scan(&p.Canal, &p.Tipo, &p.Habilitado); err != nil {
+			return nil, fmt.Errorf("error escaneando preferencia: %w", err)
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+// CanalesHabilitados devuelve los canales por los que hay que entregar una notificación
+// del tipo indicado a la persona, según sus preferencias
+func (r *NotificacionPreferenciaRepo) CanalesHabilitados(ctx context.Context, idPersona int, tipo string) ([]string, error) {
+	prefs, err := r.Listar(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	var canales []string
+	for _, canal := range modelos.CanalesNotificacion {
+		if CanalHabilitado(prefs, canal, tipo) {
+			canales = append(canales, canal)
+		}
+	}
+	return canales, nil
+}
+
+// CanalHabilitado resuelve una preferencia: primero la del tipo concreto, luego la general
+// ("*") y, si no hay ninguna, el valor por defecto (sólo email habilitado).
+func CanalHabilitado(prefs []modelos.PreferenciaNotificacion, canal, tipo string) bool {
+	general := canal == modelos.CanalEmail
+	for _, p := range prefs {
+		if p.Canal != canal {
+			continue
+		}
+		if p.Tipo == tipo {
+			return p.Habilitado
+		}
+		if p.Tipo == modelos.TipoNotificacionTodos {
+			general = p.Habilitado
+		}
+	}
+	return general
+}
+
+// Guardar crea o actualiza la preferencia (id_persona, canal, tipo)
+func (r *NotificacionPreferenciaRepo) Guardar(ctx context.Context, idPersona int, p modelos.PreferenciaNotificacion) error {
+	query := `
+		INSERT INTO notificacion_preferencia (id_persona, canal, tipo, habilitado)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE habilitado = VALUES(habilitado), ultimo_cambio = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.ExecContext(ctx, query, idPersona, p.Canal, p.Tipo, p.Habilitado)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// GuardarSuscripcionPush registra (o reasigna) la suscripción push de un navegador
+func (r *NotificacionPreferenciaRepo) GuardarSuscripcionPush(ctx context.Context, idPersona int, s modelos.SuscripcionPush) error {
+	query := `
+		INSERT INTO push_suscripcion (id_persona, endpoint, p256dh, auth)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id_persona = VALUES(id_persona), p256dh = VALUES(p256dh), auth = VALUES(auth)
+	`
+	_, err := r.db.ExecContext(ctx, query, idPersona, s.Endpoint, s.P256dh, s.Auth)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// EliminarSuscripcionPush borra la suscripción de la persona con ese endpoint
+func (r *NotificacionPreferenciaRepo) EliminarSuscripcionPush(ctx context.Context, idPersona int, endpoint string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM push_suscripcion WHERE id_persona = ? AND endpoint = ?`, idPersona, endpoint)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EliminarSuscripcionesPushVencidas borra las suscripciones que el servicio push dio de baja
+func (r *NotificacionPreferenciaRepo) EliminarSuscripcionesPushVencidas(ctx context.Context, endpoints []string) error {
+	for _, endpoint := range endpoints {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM push_suscripcion WHERE endpoint = ?`, endpoint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListarSuscripcionesPush devuelve las suscripciones push activas de la persona
+func (r *NotificacionPreferenciaRepo) ListarSuscripcionesPush(ctx context.Context, idPersona int) ([]modelos.SuscripcionPush, error) {
+	query := `
+		SELECT id_suscripcion, endpoint, p256dh, auth
+		FROM push_suscripcion
+		WHERE id_persona = ?
+		ORDER BY id_suscripcion
+	`
+	rows, err := r.db.QueryContext(ctx, query, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []modelos.SuscripcionPush
+	for rows.Next() {
+		var s modelos.SuscripcionPush
+		if err := rows.Scan(&s.IDSuscripcion, &s.Endpoint, &s.P256dh, &s.Auth); err != nil {
+			return nil, fmt.Errorf("error escaneando suscripción push: %w", err)
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
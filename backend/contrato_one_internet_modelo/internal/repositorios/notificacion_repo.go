@@ -17,39 +17,88 @@ func NewNotificacionRepo(db Execer) *NotificacionRepo {
 	return &NotificacionRepo{db: db}
 }
 
+// subconsultaRolesPersona devuelve los nombres de rol de la persona (un parámetro: id_persona)
+const subconsultaRolesPersona = `
+	SELECT r.nombre
+	FROM usuario u
+	INNER JOIN usuario_rol ur ON u.id_usuario = ur.id_usuario
+	INNER JOIN rol r ON ur.id_rol = r.id_rol
+	WHERE u.id_persona = ? AND u.borrado IS NULL`
+
+// desdeBandeja arma el FROM/WHERE de la bandeja de una persona: sus notificaciones personales
+// más las difundidas a alguno de sus roles (una sola fila con id_persona_receptor NULL).
+// El estado por persona de las difundidas (leída, archivada, eliminada) vive en
+// notificacion_persona; las personales usan notificacion.leido y también notificacion_persona
+// para archivar/eliminar.
+func desdeBandeja(idPersona int) (string, []interface{}) {
+	from := `
+		FROM notificacion n
+		LEFT JOIN notificacion_persona np
+		       ON np.id_notificacion = n.id_notificacion AND np.id_persona = ?
+		WHERE (n.id_persona_receptor = ?
+		       OR (n.id_persona_receptor IS NULL AND n.rol_destino IN (` + subconsultaRolesPersona + `)))
+		  AND np.borrado IS NULL
+	`
+	return from, []interface{}{idPersona, idPersona, idPersona}
+}
+
+// leidoBandeja es el estado de lectura efectivo para la persona
+const leidoBandeja = `IF(n.leido = 1 OR np.leido IS NOT NULL, 1, 0)`
+
+// aplicarFiltroBandeja agrega los filtros opcionales a la consulta
+func aplicarFiltroBandeja(query string, args []interface{}, filtro modelos.FiltroNotificaciones) (string, []interface{}) {
+	if filtro.Leido != nil {
+		query += " AND " + leidoBandeja + " = ?"
+		args = append(args, *filtro.Leido)
+	}
+	if filtro.Tipo != "" {
+		query += " AND n.tipo = ?"
+		args = append(args, filtro.Tipo)
+	}
+	if filtro.IDContrato != nil {
+		query += " AND n.id_contrato = ?"
+		args = append(args, *filtro.IDContrato)
+	}
+	if filtro.IDConexion != nil {
+		query += " AND n.id_conexion = ?"
+		args = append(args, *filtro.IDConexion)
+	}
+	if filtro.Archivadas {
+		query += " AND np.archivado IS NOT NULL"
+	} else {
+		query += " AND np.archivado IS NULL"
+	}
+	return query, args
+}
+
 // ObtenerNotificaciones obtiene las notificaciones de un usuario con filtros y paginación
 func (r *NotificacionRepo) ObtenerNotificaciones(
 	ctx context.Context,
 	idPersona int,
-	leido *int,
+	filtro modelos.FiltroNotificaciones,
 	page, limit int,
 	sortBy, sortDirection string,
 ) ([]modelos.Notificacion, error) {
 	// Construir query con filtros
+	from, args := desdeBandeja(idPersona)
 	query := `
 		SELECT 
-			id_notificacion,
-			tipo,
-			titulo,
-			mensaje,
-			leido,
-			rol_destino,
-			id_conexion,
-			id_contrato,
-			id_pago,
-			observacion,
-			creado
-		FROM notificacion
-		WHERE id_persona_receptor = ?
-	`
-
-	args := []interface{}{idPersona}
-
-	// Filtro por leído
-	if leido != nil {
-		query += " AND leido = ?"
-		args = append(args, *leido)
-	}
+			n.id_notificacion,
+			n.tipo,
+			n.titulo,
+			n.mensaje,
+			` + leidoBandeja + `,
+			IF(np.archivado IS NULL, 0, 1),
+			n.rol_destino,
+			n.id_conexion,
+			n.id_contrato,
+			n.id_pago,
+			n.observacion,
+			n.creado
+	` + from
+
+	// Filtros opcionales (leído, tipo, contrato, conexión, archivadas)
+	query, args = aplicarFiltroBandeja(query, args, filtro)
 
 	// Ordenamiento
 	allowedSortBy := map[string]bool{"creado": true}
@@ -62,7 +111,7 @@ func (r *NotificacionRepo) ObtenerNotificaciones(
 		sortDirection = "DESC"
 	}
 
-	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, strings.ToUpper(sortDirection))
+	query += fmt.Sprintf(" ORDER BY n.%s %s", sortBy, strings.ToUpper(sortDirection))
 
 	// Paginación
 	offset := (page - 1) * limit
@@ -87,6 +136,7 @@ func (r *NotificacionRepo) ObtenerNotificaciones(
 			&n.Titulo,
 			&n.Mensaje,
 			&n.Leido,
+			&n.Archivada,
 			&rolDestino,
 			&idConexion,
 			&idContrato,
@@ -134,15 +184,10 @@ func (r *NotificacionRepo) ObtenerNotificaciones(
 func (r *NotificacionRepo) ContarNotificaciones(
 	ctx context.Context,
 	idPersona int,
-	leido *int,
+	filtro modelos.FiltroNotificaciones,
 ) (int, error) {
-	query := "SELECT COUNT(*) FROM notificacion WHERE id_persona_receptor = ?"
-	args := []interface{}{idPersona}
-
-	if leido != nil {
-		query += " AND leido = ?"
-		args = append(args, *leido)
-	}
+	from, args := desdeBandeja(idPersona)
+	query, args := aplicarFiltroBandeja("SELECT COUNT(*) "+from, args, filtro)
 
 	var total int
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(&total)
@@ -153,64 +198,204 @@ func (r *NotificacionRepo) ContarNotificaciones(
 	return total, nil
 }
 
+// ExisteEnBandeja indica si la notificación es visible para la persona (personal o difundida
+// a uno de sus roles) y no fue eliminada por ella. Devuelve sql.ErrNoRows si no lo es.
+func (r *NotificacionRepo) ExisteEnBandeja(
+	ctx context.Context,
+	idNotificacion int,
+	idPersona int,
+) error {
+	from, args := desdeBandeja(idPersona)
+	query := "SELECT 1 " + from + " AND n.id_notificacion = ?"
+	args = append(args, idNotificacion)
+
+	var existe int
+	return r.db.QueryRowContext(ctx, query, args...).Scan(&existe)
+}
+
 // MarcarComoLeida actualiza una notificación como leída solo si pertenece al usuario
 func (r *NotificacionRepo) MarcarComoLeida(
 	ctx context.Context,
 	idNotificacion int,
 	idPersona int,
 ) error {
+	// Verificar que la notificación pertenezca al usuario (o a uno de sus roles)
+	if err := r.ExisteEnBandeja(ctx, idNotificacion, idPersona); err != nil {
+		return err
+	}
+
+	_, err := r.MarcarComoLeidas(ctx, idPersona, []int{idNotificacion})
+	return err
+}
+
+// MarcarComoLeidas marca como leídas las notificaciones indicadas de la persona; con ids vacío
+// marca toda su bandeja. Devuelve cuántas pasaron de no leída a leída.
+func (r *NotificacionRepo) MarcarComoLeidas(
+	ctx context.Context,
+	idPersona int,
+	ids []int,
+) (int, error) {
+	filtroIDs, argsIDs := "", []interface{}{}
+	if len(ids) > 0 {
+		filtroIDs = " AND n.id_notificacion IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		for _, id := range ids {
+			argsIDs = append(argsIDs, id)
+		}
+	}
+
+	// 1. Personales: el estado vive en la propia fila
 	query := `
-		UPDATE notificacion 
-		SET leido = 1 
-		WHERE id_notificacion = ? 
-		  AND id_persona_receptor = ?
-	`
+		UPDATE notificacion n
+		SET n.leido = 1
+		WHERE n.id_persona_receptor = ? AND n.leido = 0
+	` + filtroIDs
+	res, err := r.db.ExecContext(ctx, query, append([]interface{}{idPersona}, argsIDs...)...)
+	if err != nil {
+		return 0, err
+	}
+	personales, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
 
-	result, err := r.db.ExecContext(ctx, query, idNotificacion, idPersona)
+	// 2. Difundidas con estado ya creado para la persona (p. ej. archivadas sin leer)
+	query = `
+		UPDATE notificacion_persona np
+		INNER JOIN notificacion n ON n.id_notificacion = np.id_notificacion
+		SET np.leido = NOW()
+		WHERE np.id_persona = ? AND np.leido IS NULL AND np.borrado IS NULL
+		  AND n.id_persona_receptor IS NULL
+	` + filtroIDs
+	res, err = r.db.ExecContext(ctx, query, append([]interface{}{idPersona}, argsIDs...)...)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	existentes, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
 
-	// Verificar si se actualizó alguna fila
-	rowsAffected, err := result.RowsAffected()
+	// 3. Difundidas a sus roles que todavía no tienen estado para la persona
+	query = `
+		INSERT INTO notificacion_persona (id_notificacion, id_persona, leido)
+		SELECT n.id_notificacion, ?, NOW()
+		FROM notificacion n
+		WHERE n.id_persona_receptor IS NULL
+		  AND n.rol_destino IN (` + subconsultaRolesPersona + `)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM notificacion_persona np
+		      WHERE np.id_notificacion = n.id_notificacion AND np.id_persona = ?
+		  )
+	` + filtroIDs
+	res, err = r.db.ExecContext(ctx, query, append([]interface{}{idPersona, idPersona, idPersona}, argsIDs...)...)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	nuevas, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(personales + existentes + nuevas), nil
+}
+
+// ContarNoLeidas cuenta las notificaciones sin leer de la bandeja (no incluye archivadas)
+func (r *NotificacionRepo) ContarNoLeidas(ctx context.Context, idPersona int) (int, error) {
+	noLeido := 0
+	return r.ContarNotificaciones(ctx, idPersona, modelos.FiltroNotificaciones{Leido: &noLeido})
+}
 
-	if rowsAffected == 0 {
-		// La notificación no existe o no pertenece al usuario
-		return sql.ErrNoRows
+// ActualizarEstadoPersona archiva, desarchiva o elimina (sólo para esta persona) una
+// notificación. campo es "archivado" o "borrado"; marcar=false limpia la marca.
+func (r *NotificacionRepo) ActualizarEstadoPersona(
+	ctx context.Context,
+	idNotificacion int,
+	idPersona int,
+	campo string,
+	marcar bool,
+) error {
+	if campo != "archivado" && campo != "borrado" {
+		return fmt.Errorf("campo de estado inválido: %s", campo)
 	}
 
-	return nil
+	query := fmt.Sprintf(`
+		INSERT INTO notificacion_persona (id_notificacion, id_persona, %[1]s)
+		VALUES (?, ?, IF(?, NOW(), NULL))
+		ON DUPLICATE KEY UPDATE %[1]s = VALUES(%[1]s)
+	`, campo)
+	_, err := r.db.ExecContext(ctx, query, idNotificacion, idPersona, marcar)
+	return err
 }
 
-// CrearNotificacion inserta una nueva notificación en la base de datos
-func (r *NotificacionRepo) CrearNotificacion(
+// insertarNotificacion inserta la fila y devuelve su id. idPersonaReceptor nil indica
+// una notificación difundida a rolDestino.
+func (r *NotificacionRepo) insertarNotificacion(
 	ctx context.Context,
-	idPersonaReceptor int,
+	idPersonaReceptor *int,
 	tipo, titulo, mensaje string,
 	rolDestino *string,
 	idConexion, idContrato, idPago *int,
 	observacion *string,
-) error {
+) (int, error) {
 	query := `
 		INSERT INTO notificacion 
 		(id_persona_receptor, tipo, titulo, mensaje, rol_destino, id_conexion, id_contrato, id_pago, observacion, leido, creado)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0, NOW())
 	`
 
-	_, err := r.db.ExecContext(ctx, query, idPersonaReceptor, tipo, titulo, mensaje, rolDestino, idConexion, idContrato, idPago, observacion)
-	return err
+	res, err := r.db.ExecContext(ctx, query, idPersonaReceptor, tipo, titulo, mensaje, rolDestino, idConexion, idContrato, idPago, observacion)
+	if err != nil {
+		return 0, err
+	}
+
+	idNotificacion, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(idNotificacion), nil
+}
+
+// encolarEntregas encola la entrega por los canales que la persona tiene habilitados
+// (email, SMS, etc.). Se usa el mismo Execer para que, dentro de una transacción, todo se
+// confirme junto.
+func (r *NotificacionRepo) encolarEntregas(ctx context.Context, idNotificacion, idPersona int, tipo string) error {
+	canales, err := NewNotificacionPreferenciaRepo(r.db).CanalesHabilitados(ctx, idPersona, tipo)
+	if err != nil {
+		return err
+	}
+	return NewNotificacionEntregaRepo(r.db).Encolar(ctx, idNotificacion, idPersona, canales)
+}
+
+// CrearNotificacion inserta una nueva notificación en la base de datos
+func (r *NotificacionRepo) CrearNotificacion(
+	ctx context.Context,
+	idPersonaReceptor int,
+	tipo, titulo, mensaje string,
+	rolDestino *string,
+	idConexion, idContrato, idPago *int,
+	observacion *string,
+) error {
+	idNotificacion, err := r.insertarNotificacion(ctx, &idPersonaReceptor, tipo, titulo, mensaje, rolDestino, idConexion, idContrato, idPago, observacion)
+	if err != nil {
+		return err
+	}
+	return r.encolarEntregas(ctx, idNotificacion, idPersonaReceptor, tipo)
 }
 
-// CrearNotificacionParaRol crea notificaciones para todos los usuarios con un rol específico
+// CrearNotificacionParaRol difunde una notificación a todos los usuarios con un rol específico.
+// Se guarda una sola fila (el estado de lectura se lleva por persona en notificacion_persona);
+// la entrega por canales externos sí se encola para cada persona con el rol.
 func (r *NotificacionRepo) CrearNotificacionParaRol(
 	ctx context.Context,
 	rol, tipo, titulo, mensaje string,
 	idConexion, idContrato, idPago *int,
 	observacion *string,
 ) error {
+	idNotificacion, err := r.insertarNotificacion(ctx, nil, tipo, titulo, mensaje, &rol, idConexion, idContrato, idPago, observacion)
+	if err != nil {
+		return err
+	}
+
 	// Obtener todos los usuarios con el rol especificado
 	query := `
 		SELECT DISTINCT u.id_persona
@@ -239,12 +424,80 @@ func (r *NotificacionRepo) CrearNotificacionParaRol(
 		return err
 	}
 
-	// Crear notificación para cada persona con ese rol
+	// Encolar la entrega para cada persona con ese rol
 	for _, idPersona := range idPersonas {
-		if err := r.CrearNotificacion(ctx, idPersona, tipo, titulo, mensaje, &rol, idConexion, idContrato, idPago, observacion); err != nil {
+		if err := r.encolarEntregas(ctx, idNotificacion, idPersona, tipo); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// ObtenerFeed devuelve, en orden ascendente, hasta limite notificaciones con id mayor a desdeID.
+// Si idPersona es nil devuelve las de todos los receptores (feed global del controlador); si no,
+// las personales y las difundidas a sus roles.
+func (r *NotificacionRepo) ObtenerFeed(ctx context.Context, desdeID int, idPersona *int, limite int) ([]modelos.Notificacion, error) {
+	query := `
+		SELECT id_notificacion, id_persona_receptor, tipo, titulo, mensaje, leido, rol_destino,
+		       id_conexion, id_contrato, id_pago, observacion, creado
+		FROM notificacion
+		WHERE id_notificacion > ?
+	`
+	args := []interface{}{desdeID}
+	if idPersona != nil {
+		query += " AND (id_persona_receptor = ? OR (id_persona_receptor IS NULL AND rol_destino IN (" + subconsultaRolesPersona + ")))"
+		args = append(args, *idPersona, *idPersona)
+	}
+	query += " ORDER BY id_notificacion ASC LIMIT ?"
+	args = append(args, limite)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notificaciones := []modelos.Notificacion{}
+	for rows.Next() {
+		var n modelos.Notificacion
+		var idReceptor, idConexion, idContrato, idPago sql.NullInt64
+		var rolDestino, observacion sql.NullString
+
+		if err := rows.Scan(
+			&n.IDNotificacion, &idReceptor, &n.Tipo, &n.Titulo, &n.Mensaje, &n.Leido, &rolDestino,
+			&idConexion, &idContrato, &idPago, &observacion, &n.Creado,
+		); err != nil {
+			return nil, err
+		}
+		n.IDPersonaReceptor = nullIntAPuntero(idReceptor)
+		n.IDConexion = nullIntAPuntero(idConexion)
+		n.IDContrato = nullIntAPuntero(idContrato)
+		n.IDPago = nullIntAPuntero(idPago)
+		if rolDestino.Valid {
+			n.RolDestino = &rolDestino.String
+		}
+		if observacion.Valid {
+			n.Observacion = &observacion.String
+		}
+		notificaciones = append(notificaciones, n)
+	}
+	return notificaciones, rows.Err()
+}
+
+// UltimoID devuelve el mayor id_notificacion existente (0 si no hay)
+func (r *NotificacionRepo) UltimoID(ctx context.Context) (int, error) {
+	var ultimo sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, "SELECT MAX(id_notificacion) FROM notificacion").Scan(&ultimo); err != nil {
+		return 0, err
+	}
+	return int(ultimo.Int64), nil
+}
+
+func nullIntAPuntero(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	val := int(v.Int64)
+	return &val
+}
@@ -0,0 +1,431 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// OrdenInstalacionRepo maneja las órdenes de instalación, sus materiales y sus fotos
+type OrdenInstalacionRepo struct {
+	db Execer
+}
+
+// NewOrdenInstalacionRepo crea una nueva instancia de OrdenInstalacionRepo
+func NewOrdenInstalacionRepo(db Execer) *OrdenInstalacionRepo {
+	return &OrdenInstalacionRepo{db: db}
+}
+
+// estadosOrdenAbierta son los estados en los que la orden todavía puede trabajarse
+var estadosOrdenAbierta = []interface{}{modelos.EstadoOrdenPendiente, modelos.EstadoOrdenAsignada, modelos.EstadoOrdenProgramada}
+
+const condicionOrdenAbierta = `o.estado IN (?, ?, ?)`
+
+const selectOrdenInstalacion = `
+	SELECT o.id_orden, o.estado, o.id_conexion, c.nro_conexion, o.id_contrato,
+	       CONCAT(p.nombre, ' ', p.apellido), p.telefono,
+	       CONCAT_WS(', ', NULLIF(CONCAT_WS(' ', d.calle, d.numero), ''),
+	                 c.distrito_nombre, c.departamento_nombre, c.provincia_nombre),
+	       COALESCE(c.latitud, 0), COALESCE(c.longitud, 0),
+	       CONCAT(pl.nombre, ' ', pl.velocidad_mbps, ' Mbps'),
+	       o.id_tecnico, CONCAT(pt.nombre, ' ', pt.apellido),
+	       o.turno_inicio, o.turno_fin, o.reprogramaciones, o.serial_onu, o.potencia_rx_dbm, o.potencia_tx_dbm,
+	       o.observaciones, o.completada, o.cancelada, o.motivo_cancelacion, o.creado
+	FROM orden_instalacion o
+	JOIN conexion c ON c.id_conexion = o.id_conexion
+	JOIN persona p ON p.id_persona = c.id_persona
+	JOIN plan pl ON pl.id_plan = c.id_plan
+	LEFT JOIN direccion d ON d.id_direccion = c.id_direccion
+	LEFT JOIN usuario u ON u.id_usuario = o.id_tecnico
+	LEFT JOIN persona pt ON pt.id_persona = u.id_persona
+`
+
+func escanearOrdenInstalacion(s interface{ Scan(...interface{}) error }) (*modelos.OrdenInstalacion, error) {
+	var o modelos.OrdenInstalacion
+	err := s.Scan(&o.IDOrden, &o.Estado, &o.IDConexion, &o.NroConexion, &o.IDContrato,
+		&o.Cliente, &o.Telefono, &o.Direccion, &o.Latitud, &o.Longitud, &o.Plan,
+		&o.IDTecnico, &o.Tecnico, &o.TurnoInicio, &o.TurnoFin, &o.Reprogramaciones, &o.SerialONU, &o.PotenciaRxDBm, &o.PotenciaTxDBm,
+		&o.Observaciones, &o.Completada, &o.Cancelada, &o.MotivoCancelacion, &o.Creado)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// CrearPendiente crea la orden de la conexión si no tiene una abierta. Devuelve el id de la
+// orden abierta (nueva o existente) y si la creó.
+func (r *OrdenInstalacionRepo) CrearPendiente(ctx context.Context, idConexion int) (int, bool, error) {
+	var idOrden int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT o.id_orden FROM orden_instalacion o WHERE o.id_conexion = ? AND `+condicionOrdenAbierta+` LIMIT 1`,
+		append([]interface{}{idConexion}, estadosOrdenAbierta...)...).Scan(&idOrden)
+	if err == nil {
+		return idOrden, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, fmt.Errorf("error buscando orden abierta de la conexión %d: %w", idConexion, err)
+	}
+
+	query := `
+		INSERT INTO orden_instalacion (id_conexion, id_contrato, estado)
+		SELECT ?, (SELECT MAX(ct.id_contrato) FROM contrato ct WHERE ct.id_conexion = ? AND ct.borrado IS NULL), ?
+	`
+	res, err := r.db.ExecContext(ctx, query, idConexion, idConexion, modelos.EstadoOrdenPendiente)
+	if err != nil {
+		return 0, false, utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, false, err
+	}
+	return int(id), true, nil
+}
+
+// CancelarAbiertas cancela las órdenes abiertas de la conexión y devuelve cuántas canceló
+func (r *OrdenInstalacionRepo) CancelarAbiertas(ctx context.Context, idConexion int, motivo string) (int64, error) {
+	query := `
+		UPDATE orden_instalacion o
+		SET o.estado = ?, o.cancelada = NOW(), o.motivo_cancelacion = ?
+		WHERE o.id_conexion = ? AND ` + condicionOrdenAbierta
+	args := append([]interface{}{modelos.EstadoOrdenCancelada, motivo, idConexion}, estadosOrdenAbierta...)
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	return res.RowsAffected()
+}
+
+// ObtenerPorID devuelve la orden con los datos de la conexión y del técnico
+func (r *OrdenInstalacionRepo) ObtenerPorID(ctx context.Context, idOrden int) (*modelos.OrdenInstalacion, error) {
+	o, err := escanearOrdenInstalacion(r.db.QueryRowContext(ctx, selectOrdenInstalacion+` WHERE o.id_orden = ?`, idOrden))
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "orden_instalacion", Campo: "id_orden", Valor: fmt.Sprintf("%d", idOrden)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo orden %d: %w", idOrden, err)
+	}
+	return o, nil
+}
+
+// ObtenerParaActualizar devuelve estado, conexión y técnico de la orden y bloquea la fila
+// hasta el fin de la transacción
+func (r *OrdenInstalacionRepo) ObtenerParaActualizar(ctx context.Context, idOrden int) (*modelos.OrdenInstalacion, error) {
+	var o modelos.OrdenInstalacion
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id_orden, estado, id_conexion, id_contrato, id_tecnico, turno_inicio, turno_fin, reprogramaciones
+		 FROM orden_instalacion WHERE id_orden = ? FOR UPDATE`, idOrden).
+		Scan(&o.IDOrden, &o.Estado, &o.IDConexion, &o.IDContrato, &o.IDTecnico, &o.TurnoInicio, &o.TurnoFin, &o.Reprogramaciones)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "orden_instalacion", Campo: "id_orden", Valor: fmt.Sprintf("%d", idOrden)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo orden %d: %w", idOrden, err)
+	}
+	return &o, nil
+}
+
+// Listar devuelve una página de órdenes (turnos más próximos primero) y el total
+func (r *OrdenInstalacionRepo) Listar(
+	ctx context.Context,
+	filtro modelos.FiltroOrdenesInstalacion,
+	limit, offset int,
+) ([]modelos.OrdenInstalacion, int, error) {
+	condiciones := []string{"1 = 1"}
+	args := []interface{}{}
+
+	if filtro.Estado != "" {
+		condiciones = append(condiciones, "o.estado = ?")
+		args = append(args, filtro.Estado)
+	}
+	if filtro.IDTecnico != nil {
+		condiciones = append(condiciones, "o.id_tecnico = ?")
+		args = append(args, *filtro.IDTecnico)
+	}
+	if filtro.Desde != nil {
+		condiciones = append(condiciones, "o.turno_inicio >= ?")
+		args = append(args, *filtro.Desde)
+	}
+	if filtro.Hasta != nil {
+		condiciones = append(condiciones, "o.turno_inicio < ?")
+		args = append(args, *filtro.Hasta)
+	}
+	if filtro.ParaTecnico != nil {
+		condiciones = append(condiciones, "(o.id_tecnico = ? OR (o.id_tecnico IS NULL AND o.estado = ?))")
+		args = append(args, *filtro.ParaTecnico, modelos.EstadoOrdenPendiente)
+	}
+	where := " WHERE " + strings.Join(condiciones, " AND ")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orden_instalacion o`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error contando órdenes de instalación: %w", err)
+	}
+
+	query := selectOrdenInstalacion + where + `
+		ORDER BY o.turno_inicio IS NULL, o.turno_inicio, o.creado
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listando órdenes de instalación: %w", err)
+	}
+	defer rows.Close()
+
+	ordenes := []modelos.OrdenInstalacion{}
+	for rows.Next() {
+		o, err := escanearOrdenInstalacion(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error leyendo orden de instalación: %w", err)
+		}
+		ordenes = append(ordenes, *o)
+	}
+	return ordenes, total, rows.Err()
+}
+
+// Asignar fija el técnico de la orden. Si cambia el técnico se descarta el turno.
+// (MySQL evalúa las asignaciones en orden: estado ya ve el turno actualizado)
+func (r *OrdenInstalacionRepo) Asignar(ctx context.Context, idOrden, idTecnico int) error {
+	query := `
+		UPDATE orden_instalacion
+		SET turno_inicio = IF(id_tecnico <=> ?, turno_inicio, NULL),
+		    turno_fin    = IF(id_tecnico <=> ?, turno_fin, NULL),
+		    estado       = IF(turno_inicio IS NOT NULL, ?, ?),
+		    id_tecnico   = ?
+		WHERE id_orden = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, idTecnico, idTecnico,
+		modelos.EstadoOrdenProgramada, modelos.EstadoOrdenAsignada, idTecnico, idOrden)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// BloquearTecnico bloquea la fila del usuario técnico para que dos turnos simultáneos no
+// pasen a la vez la verificación de superposición
+func (r *OrdenInstalacionRepo) BloquearTecnico(ctx context.Context, idTecnico int) error {
+	var id int
+	err := r.db.QueryRowContext(ctx, `SELECT id_usuario FROM usuario WHERE id_usuario = ? FOR UPDATE`, idTecnico).Scan(&id)
+	if err == sql.ErrNoRows {
+		return utilidades.ErrNotFound{Entity: "usuario", Campo: "id_usuario", Valor: fmt.Sprintf("%d", idTecnico)}
+	}
+	return err
+}
+
+// BuscarTurnoSuperpuesto devuelve el id de otra orden abierta del técnico cuyo turno se
+// superpone con [inicio, fin), o 0 si no hay
+func (r *OrdenInstalacionRepo) BuscarTurnoSuperpuesto(ctx context.Context, idTecnico, idOrden int, inicio, fin time.Time) (int, error) {
+	query := `
+		SELECT o.id_orden FROM orden_instalacion o
+		WHERE o.id_tecnico = ? AND o.id_orden <> ? AND ` + condicionOrdenAbierta + `
+		  AND o.turno_inicio < ? AND o.turno_fin > ?
+		LIMIT 1
+	`
+	args := append([]interface{}{idTecnico, idOrden}, estadosOrdenAbierta...)
+	var id int
+	err := r.db.QueryRowContext(ctx, query, append(args, fin, inicio)...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error verificando turnos del técnico %d: %w", idTecnico, err)
+	}
+	return id, nil
+}
+
+// Programar fija el turno de la orden. El recordatorio se vuelve a enviar para el turno nuevo.
+func (r *OrdenInstalacionRepo) Programar(ctx context.Context, idOrden int, inicio, fin time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE orden_instalacion SET turno_inicio = ?, turno_fin = ?, estado = ?, recordatorio_enviado = NULL WHERE id_orden = ?`,
+		inicio, fin, modelos.EstadoOrdenProgramada, idOrden)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// QuitarTurno deja la orden sin turno (asignada si tiene técnico, pendiente si no)
+func (r *OrdenInstalacionRepo) QuitarTurno(ctx context.Context, idOrden int) error {
+	query := `
+		UPDATE orden_instalacion
+		SET turno_inicio = NULL, turno_fin = NULL, recordatorio_enviado = NULL,
+		    estado = IF(id_tecnico IS NULL, ?, ?)
+		WHERE id_orden = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, modelos.EstadoOrdenPendiente, modelos.EstadoOrdenAsignada, idOrden)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// SumarReprogramacion cuenta un cambio de turno hecho por el cliente
+func (r *OrdenInstalacionRepo) SumarReprogramacion(ctx context.Context, idOrden int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE orden_instalacion SET reprogramaciones = reprogramaciones + 1 WHERE id_orden = ?`, idOrden)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// ObtenerAbiertaPorConexion devuelve la orden abierta de la conexión y la bloquea hasta el
+// fin de la transacción
+func (r *OrdenInstalacionRepo) ObtenerAbiertaPorConexion(ctx context.Context, idConexion int) (*modelos.OrdenInstalacion, error) {
+	var idOrden int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT o.id_orden FROM orden_instalacion o WHERE o.id_conexion = ? AND `+condicionOrdenAbierta+` LIMIT 1 FOR UPDATE`,
+		append([]interface{}{idConexion}, estadosOrdenAbierta...)...).Scan(&idOrden)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "orden_instalacion", Campo: "id_conexion", Valor: fmt.Sprintf("%d", idConexion)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error buscando orden abierta de la conexión %d: %w", idConexion, err)
+	}
+	return r.ObtenerParaActualizar(ctx, idOrden)
+}
+
+// ListarTurnosOcupados devuelve los turnos de órdenes abiertas de los técnicos que empiezan
+// antes de hasta y terminan después de desde, salvo los de la orden excluida
+func (r *OrdenInstalacionRepo) ListarTurnosOcupados(
+	ctx context.Context,
+	idsTecnicos []int,
+	desde, hasta time.Time,
+	excluirOrden int,
+) ([]modelos.TurnoOcupado, error) {
+	if len(idsTecnicos) == 0 {
+		return nil, nil
+	}
+	marcas := strings.TrimSuffix(strings.Repeat("?, ", len(idsTecnicos)), ", ")
+	query := `
+		SELECT o.id_tecnico, o.turno_inicio, o.turno_fin
+		FROM orden_instalacion o
+		WHERE o.id_tecnico IN (` + marcas + `) AND ` + condicionOrdenAbierta + `
+		  AND o.turno_inicio < ? AND o.turno_fin > ? AND o.id_orden <> ?
+	`
+	args := []interface{}{}
+	for _, id := range idsTecnicos {
+		args = append(args, id)
+	}
+	args = append(args, estadosOrdenAbierta...)
+	args = append(args, hasta, desde, excluirOrden)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando turnos ocupados: %w", err)
+	}
+	defer rows.Close()
+
+	var turnos []modelos.TurnoOcupado
+	for rows.Next() {
+		var t modelos.TurnoOcupado
+		if err := rows.Scan(&t.IDTecnico, &t.Inicio, &t.Fin); err != nil {
+			return nil, fmt.Errorf("error leyendo turno ocupado: %w", err)
+		}
+		turnos = append(turnos, t)
+	}
+	return turnos, rows.Err()
+}
+
+// ListarSinRecordatorio devuelve las órdenes programadas con turno en [desde, hasta) cuyo
+// recordatorio todavía no se envió
+func (r *OrdenInstalacionRepo) ListarSinRecordatorio(ctx context.Context, desde, hasta time.Time) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id_orden FROM orden_instalacion
+		WHERE estado = ? AND turno_inicio >= ? AND turno_inicio < ? AND recordatorio_enviado IS NULL
+	`, modelos.EstadoOrdenProgramada, desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error listando recordatorios pendientes: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarcarRecordatorio marca el recordatorio como enviado. Devuelve false si otro proceso ya
+// lo marcó, para que cada recordatorio se registre una sola vez.
+func (r *OrdenInstalacionRepo) MarcarRecordatorio(ctx context.Context, idOrden int) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE orden_instalacion SET recordatorio_enviado = NOW() WHERE id_orden = ? AND recordatorio_enviado IS NULL`, idOrden)
+	if err != nil {
+		return false, utilidades.TraducirErrorBD(err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Completar registra lo relevado en el domicilio y cierra la orden
+func (r *OrdenInstalacionRepo) Completar(ctx context.Context, idOrden int, req modelos.CompletarOrdenRequest) error {
+	query := `
+		UPDATE orden_instalacion
+		SET estado = ?, serial_onu = ?, potencia_rx_dbm = ?, potencia_tx_dbm = ?, observaciones = ?, completada = NOW()
+		WHERE id_orden = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, modelos.EstadoOrdenCompletada,
+		req.SerialONU, req.PotenciaRxDBm, req.PotenciaTxDBm, req.Observaciones, idOrden)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// Cancelar cierra la orden sin instalación
+func (r *OrdenInstalacionRepo) Cancelar(ctx context.Context, idOrden int, motivo string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE orden_instalacion SET estado = ?, cancelada = NOW(), motivo_cancelacion = ? WHERE id_orden = ?`,
+		modelos.EstadoOrdenCancelada, motivo, idOrden)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// AgregarMaterial registra un material usado en la instalación
+func (r *OrdenInstalacionRepo) AgregarMaterial(ctx context.Context, idOrden int, m modelos.MaterialInstalacion) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO orden_instalacion_material (id_orden, descripcion, cantidad, unidad) VALUES (?, ?, ?, ?)`,
+		idOrden, m.Descripcion, m.Cantidad, m.Unidad)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// AgregarFoto registra una foto ya guardada en disco
+func (r *OrdenInstalacionRepo) AgregarFoto(ctx context.Context, idOrden int, path, tipoContenido string, tamano int, hash string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO orden_instalacion_foto (id_orden, path, tipo_contenido, tamano, hash) VALUES (?, ?, ?, ?, ?)`,
+		idOrden, path, tipoContenido, tamano, hash)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// ListarMateriales devuelve los materiales usados en la orden
+func (r *OrdenInstalacionRepo) ListarMateriales(ctx context.Context, idOrden int) ([]modelos.MaterialInstalacion, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT descripcion, cantidad, unidad FROM orden_instalacion_material WHERE id_orden = ? ORDER BY id_material`, idOrden)
+	if err != nil {
+		return nil, fmt.Errorf("error listando materiales de la orden %d: %w", idOrden, err)
+	}
+	defer rows.Close()
+
+	var materiales []modelos.MaterialInstalacion
+	for rows.Next() {
+		var m modelos.MaterialInstalacion
+		if err := rows.Scan(&m.Descripcion, &m.Cantidad, &m.Unidad); err != nil {
+			return nil, fmt.Errorf("error leyendo material de la orden %d: %w", idOrden, err)
+		}
+		materiales = append(materiales, m)
+	}
+	return materiales, rows.Err()
+}
+
+// ListarFotos devuelve los datos de las fotos de la orden (sin el archivo)
+func (r *OrdenInstalacionRepo) ListarFotos(ctx context.Context, idOrden int) ([]modelos.FotoInstalacion, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id_foto, tipo_contenido, tamano, hash, creado FROM orden_instalacion_foto WHERE id_orden = ? ORDER BY id_foto`, idOrden)
+	if err != nil {
+		return nil, fmt.Errorf("error listando fotos de la orden %d: %w", idOrden, err)
+	}
+	defer rows.Close()
+
+	var fotos []modelos.FotoInstalacion
+	for rows.Next() {
+		var f modelos.FotoInstalacion
+		if err := rows.Scan(&f.IDFoto, &f.TipoContenido, &f.Tamano, &f.Hash, &f.Creado); err != nil {
+			return nil, fmt.Errorf("error leyendo foto de la orden %d: %w", idOrden, err)
+		}
+		fotos = append(fotos, f)
+	}
+	return fotos, rows.Err()
+}
@@ -0,0 +1,168 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// OutboxRepo maneja la tabla outbox_evento (eventos de dominio pendientes de despacho)
+type OutboxRepo struct {
+	db Execer
+}
+
+// NewOutboxRepo crea una nueva instancia de OutboxRepo. Para que el evento sea atómico con
+// el cambio de estado debe recibir la misma transacción.
+func NewOutboxRepo(db Execer) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+const columnasOutbox = `
+	id_evento, tipo, payload, estado, intentos, proximo_intento, ultimo_error, creado, procesado`
+
+// Registrar agrega un evento pendiente con el payload serializado a JSON
+func (r *OutboxRepo) Registrar(ctx context.Context, tipo string, payload interface{}) (int64, error) {
+	datos, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("error serializando evento %s: %w", tipo, err)
+	}
+
+	query := `
+		INSERT INTO outbox_evento (tipo, payload, estado, intentos, proximo_intento)
+		VALUES (?, ?, ?, 0, NOW())
+	`
+	res, err := r.db.ExecContext(ctx, query, tipo, datos, modelos.EstadoOutboxPendiente)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	return res.LastInsertId()
+}
+
+// Reservar marca hasta limite eventos pendientes con la reserva indicada para que dos
+// despachadores no procesen el mismo. La reserva vence sola si el proceso se cae. El
+// vencimiento se calcula con el reloj de MySQL, el mismo con el que se compara.
+func (r *OutboxRepo) Reservar(ctx context.Context, reserva string, limite int, duracion time.Duration) ([]modelos.EventoOutbox, error) {
+	query := `
+		UPDATE outbox_evento
+		SET reserva = ?, reservado_hasta = NOW() + INTERVAL ? SECOND
+		WHERE estado = ?
+		  AND proximo_intento <= NOW()
+		  AND (reservado_hasta IS NULL OR reservado_hasta < NOW())
+		ORDER BY id_evento
+		LIMIT ?
+	`
+	if _, err := r.db.ExecContext(ctx, query, reserva, int(duracion.Seconds()), modelos.EstadoOutboxPendiente, limite); err != nil {
+		return nil, utilidades.TraducirErrorBD(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+columnasOutbox+` FROM outbox_evento WHERE reserva = ? ORDER BY id_evento`, reserva)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return escanearEventosOutbox(rows)
+}
+
+// MarcarProcesado cierra el evento. Se ejecuta en la misma transacción que sus efectos.
+func (r *OutboxRepo) MarcarProcesado(ctx context.Context, idEvento int) error {
+	query := `
+		UPDATE outbox_evento
+		SET estado = ?, intentos = intentos + 1, ultimo_error = NULL, procesado = NOW(),
+		    reserva = NULL, reservado_hasta = NULL
+		WHERE id_evento = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, modelos.EstadoOutboxProcesado, idEvento)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// MarcarIntentoFallido suma un intento y deja el evento pendiente (reintentando después de
+// espera) o fallido (dead-letter, espera nil) si no se reintenta más
+func (r *OutboxRepo) MarcarIntentoFallido(ctx context.Context, idEvento int, estado, motivo string, espera *time.Duration) error {
+	query := `
+		UPDATE outbox_evento
+		SET estado = ?, intentos = intentos + 1, ultimo_error = ?,
+		    proximo_intento = COALESCE(NOW() + INTERVAL ? SECOND, proximo_intento),
+		    reserva = NULL, reservado_hasta = NULL
+		WHERE id_evento = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, estado, motivo, segundosEspera(espera), idEvento)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// ListarFallidos devuelve los eventos en dead-letter, más recientes primero
+func (r *OutboxRepo) ListarFallidos(ctx context.Context, limite int) ([]modelos.EventoOutbox, error) {
+	query := `SELECT ` + columnasOutbox + `
+		FROM outbox_evento
+		WHERE estado = ?
+		ORDER BY id_evento DESC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, modelos.EstadoOutboxFallido, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return escanearEventosOutbox(rows)
+}
+
+// Reencolar devuelve un evento fallido a pendiente con los intentos en cero
+func (r *OutboxRepo) Reencolar(ctx context.Context, idEvento int) error {
+	query := `
+		UPDATE outbox_evento
+		SET estado = ?, intentos = 0, proximo_intento = NOW(), reserva = NULL, reservado_hasta = NULL
+		WHERE id_evento = ? AND estado = ?
+	`
+	res, err := r.db.ExecContext(ctx, query, modelos.EstadoOutboxPendiente, idEvento, modelos.EstadoOutboxFallido)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return utilidades.ErrNotFound{Entity: "evento fallido", Campo: "id_evento", Valor: strconv.Itoa(idEvento)}
+	}
+	return nil
+}
+
+func escanearEventosOutbox(rows *sql.Rows) ([]modelos.EventoOutbox, error) {
+	eventos := []modelos.EventoOutbox{}
+	for rows.Next() {
+		var e modelos.EventoOutbox
+		var payload []byte
+		var ultimoError sql.NullString
+		var procesado sql.NullTime
+
+		if err := rows.Scan(
+			&e.IDEvento, &e.Tipo, &payload, &e.Estado, &e.Intentos, &e.ProximoIntento,
+			&ultimoError, &e.Creado, &procesado,
+		); err != nil {
+			return nil, fmt.Errorf("error escaneando evento outbox: %w", err)
+		}
+		e.Payload = json.RawMessage(payload)
+		if ultimoError.Valid {
+			e.UltimoError = &ultimoError.String
+		}
+		if procesado.Valid {
+			e.Procesado = &procesado.Time
+		}
+		eventos = append(eventos, e)
+	}
+	return eventos, rows.Err()
+}
+
+// segundosEspera convierte una espera opcional en el argumento de INTERVAL ? SECOND (NULL
+// deja la fecha sin cambios con COALESCE)
+func segundosEspera(espera *time.Duration) interface{} {
+	if espera == nil {
+		return nil
+	}
+	return int(espera.Seconds())
+}
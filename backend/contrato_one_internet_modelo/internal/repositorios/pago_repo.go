@@ -0,0 +1,185 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// PagoRepo maneja operaciones de la tabla pago
+type PagoRepo struct {
+	db Execer
+}
+
+// NewPagoRepo crea una nueva instancia de PagoRepo
+func NewPagoRepo(db Execer) *PagoRepo {
+	return &PagoRepo{db: db}
+}
+
+const columnasPago = `
+	id_pago, id_contrato, id_persona, monto, moneda, concepto, proveedor,
+	id_preferencia, id_transaccion, url_checkout, estado, detalle_estado,
+	fecha_aprobacion, creado, ultimo_cambio`
+
+// Crear inserta un pago en estado pendiente y devuelve su ID
+func (r *PagoRepo) Crear(ctx context.Context, p *modelos.Pago) (int64, error) {
+	query := `
+		INSERT INTO pago (id_contrato, id_persona, monto, moneda, concepto, proveedor, estado)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		p.IDContrato, p.IDPersona, p.Monto, p.Moneda, p.Concepto, p.Proveedor, p.Estado,
+	)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error obteniendo ID de pago insertado: %w", err)
+	}
+	return id, nil
+}
+
+// ActualizarCheckout guarda los datos del checkout creado en el proveedor
+func (r *PagoRepo) ActualizarCheckout(ctx context.Context, idPago int, idPreferencia, urlCheckout string) error {
+	query := `
+		UPDATE pago
+		SET id_preferencia = ?, url_checkout = ?, ultimo_cambio = CURRENT_TIMESTAMP
+		WHERE id_pago = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, idPreferencia, urlCheckout, idPago)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	return nil
+}
+
+// ActualizarEstado cambia el estado del pago y registra la transacción del proveedor.
+// Si el nuevo estado es aprobado, también se registra la fecha de aprobación.
+func (r *PagoRepo) ActualizarEstado(ctx context.Context, idPago int, estado, idTransaccion string, detalle *string) error {
+	var fechaAprobacion *time.Time
+	if estado == modelos.EstadoPagoAprobado {
+		ahora := time.Now()
+		fechaAprobacion = &ahora
+	}
+
+	query := `
+		UPDATE pago
+		SET estado = ?,
+		    id_transaccion = ?,
+		    detalle_estado = ?,
+		    fecha_aprobacion = COALESCE(?, fecha_aprobacion),
+		    ultimo_cambio = CURRENT_TIMESTAMP
+		WHERE id_pago = ?
+	`
+	res, err := r.db.ExecContext(ctx, query, estado, idTransaccion, detalle, fechaAprobacion, idPago)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error verificando filas afectadas: %w", err)
+	}
+	if rows == 0 {
+		return utilidades.ErrNotFound{Entity: "pago", Campo: "id_pago", Valor: strconv.Itoa(idPago)}
+	}
+	return nil
+}
+
+// ObtenerPorID obtiene un pago por su ID
+func (r *PagoRepo) ObtenerPorID(ctx context.Context, idPago int) (*modelos.Pago, error) {
+	query := `SELECT ` + columnasPago + ` FROM pago WHERE id_pago = ?`
+	p, err := escanearPago(r.db.QueryRowContext(ctx, query, idPago))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utilidades.ErrNotFound{Entity: "pago", Campo: "id_pago", Valor: strconv.Itoa(idPago)}
+		}
+		return nil, fmt.Errorf("error obteniendo pago: %w", err)
+	}
+	return p, nil
+}
+
+// ObtenerPorIDParaActualizar obtiene un pago bloqueando la fila (SELECT ... FOR UPDATE).
+// Debe usarse dentro de una transacción.
+func (r *PagoRepo) ObtenerPorIDParaActualizar(ctx context.Context, idPago int) (*modelos.Pago, error) {
+	query := `SELECT ` + columnasPago + ` FROM pago WHERE id_pago = ? FOR UPDATE`
+	p, err := escanearPago(r.db.QueryRowContext(ctx, query, idPago))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utilidades.ErrNotFound{Entity: "pago", Campo: "id_pago", Valor: strconv.Itoa(idPago)}
+		}
+		return nil, fmt.Errorf("error obteniendo pago: %w", err)
+	}
+	return p, nil
+}
+
+// ObtenerUltimoPorContrato obtiene el pago más reciente de un contrato.
+// Devuelve sql.ErrNoRows si el contrato no tiene pagos.
+func (r *PagoRepo) ObtenerUltimoPorContrato(ctx context.Context, idContrato int) (*modelos.Pago, error) {
+	query := `SELECT ` + columnasPago + ` FROM pago WHERE id_contrato = ? ORDER BY id_pago DESC LIMIT 1`
+	return escanearPago(r.db.QueryRowContext(ctx, query, idContrato))
+}
+
+// ListarPorContrato devuelve todos los pagos de un contrato, del más reciente al más antiguo
+func (r *PagoRepo) ListarPorContrato(ctx context.Context, idContrato int) ([]modelos.Pago, error) {
+	query := `SELECT ` + columnasPago + ` FROM pago WHERE id_contrato = ? ORDER BY id_pago DESC`
+	rows, err := r.db.QueryContext(ctx, query, idContrato)
+	if err != nil {
+		return nil, fmt.Errorf("error listando pagos: %w", err)
+	}
+	defer rows.Close()
+
+	pagos := []modelos.Pago{}
+	for rows.Next() {
+		p, err := escanearPago(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error escaneando pago: %w", err)
+		}
+		pagos = append(pagos, *p)
+	}
+	return pagos, rows.Err()
+}
+
+// filaEscaneable abstrae *sql.Row y *sql.Rows
+type filaEscaneable interface {
+	Scan(dest ...interface{}) error
+}
+
+func escanearPago(row filaEscaneable) (*modelos.Pago, error) {
+	p := &modelos.Pago{}
+	var idPreferencia, idTransaccion, urlCheckout, detalle sql.NullString
+	var fechaAprobacion sql.NullTime
+
+	err := row.Scan(
+		&p.IDPago, &p.IDContrato, &p.IDPersona, &p.Monto, &p.Moneda, &p.Concepto, &p.Proveedor,
+		&idPreferencia, &idTransaccion, &urlCheckout, &p.Estado, &detalle,
+		&fechaAprobacion, &p.Creado, &p.UltimoCambio,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if idPreferencia.Valid {
+		p.IDPreferencia = &idPreferencia.String
+	}
+	if idTransaccion.Valid {
+		p.IDTransaccion = &idTransaccion.String
+	}
+	if urlCheckout.Valid {
+		p.URLCheckout = &urlCheckout.String
+	}
+	if detalle.Valid {
+		p.DetalleEstado = &detalle.String
+	}
+	if fechaAprobacion.Valid {
+		p.FechaAprobacion = &fechaAprobacion.Time
+	}
+	return p, nil
+}
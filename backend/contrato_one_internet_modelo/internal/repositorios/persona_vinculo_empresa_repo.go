@@ -59,6 +59,12 @@ func (r *PersonaVinculoEmpresaRepo) ObtenerIDVinculoPorNombre(ctx context.Contex
 // CrearOActualizarVinculo crea o actualiza un vínculo persona-empresa
 // Si existe y está borrado, lo reactiva
 func (r *PersonaVinculoEmpresaRepo) CrearOActualizarVinculo(ctx context.Context, idPersona, idVinculo, idEmpresa int) error {
+	return r.CrearOActualizarVinculoConCargo(ctx, idPersona, idVinculo, idEmpresa, nil)
+}
+
+// CrearOActualizarVinculoConCargo crea o actualiza un vínculo persona-empresa indicando el cargo.
+// Si existe y está borrado, lo reactiva; si se indica cargo, lo actualiza.
+func (r *PersonaVinculoEmpresaRepo) CrearOActualizarVinculoConCargo(ctx context.Context, idPersona, idVinculo, idEmpresa int, idCargo *int) error {
 	// Verificar si ya existe
 	var exists int
 	var borrado sql.NullTime
@@ -70,8 +76,8 @@ func (r *PersonaVinculoEmpresaRepo) CrearOActualizarVinculo(ctx context.Context,
 		// No existe, insertar
 		insertQuery := `INSERT INTO persona_vinculo_empresa 
 		                (id_persona, id_vinculo, id_empresa, id_cargo) 
-		                VALUES (?, ?, ?, NULL)`
-		_, err = r.db.ExecContext(ctx, insertQuery, idPersona, idVinculo, idEmpresa)
+		                VALUES (?, ?, ?, ?)`
+		_, err = r.db.ExecContext(ctx, insertQuery, idPersona, idVinculo, idEmpresa, idCargo)
 		if err != nil {
 			return utilidades.TraducirErrorBD(err)
 		}
@@ -90,6 +96,37 @@ func (r *PersonaVinculoEmpresaRepo) CrearOActualizarVinculo(ctx context.Context,
 			return utilidades.TraducirErrorBD(err)
 		}
 	}
-	// Si no está borrado, no hacemos nada (ya existe activo)
+	// Si se indicó cargo, actualizarlo (sin cargo no tocamos el existente)
+	if idCargo != nil {
+		updateQuery := `UPDATE persona_vinculo_empresa 
+		                SET id_cargo = ? 
+		                WHERE id_persona = ? AND id_vinculo = ? AND id_empresa = ?`
+		_, err = r.db.ExecContext(ctx, updateQuery, *idCargo, idPersona, idVinculo, idEmpresa)
+		if err != nil {
+			return utilidades.TraducirErrorBD(err)
+		}
+	}
 	return nil
 }
+
+// ObtenerIDVinculoActivo devuelve el vínculo activo de una persona con una empresa.
+// Si la persona tiene más de un vínculo, devuelve el más antiguo.
+func (r *PersonaVinculoEmpresaRepo) ObtenerIDVinculoActivo(ctx context.Context, idPersona, idEmpresa int) (int, error) {
+	var idVinculo int
+	query := `SELECT pve.id_vinculo FROM persona_vinculo_empresa pve
+	          INNER JOIN empresa e ON e.id_empresa = pve.id_empresa AND e.borrado IS NULL
+	          WHERE pve.id_persona = ? AND pve.id_empresa = ? AND pve.borrado IS NULL
+	          ORDER BY pve.id_vinculo LIMIT 1`
+	err := r.db.QueryRowContext(ctx, query, idPersona, idEmpresa).Scan(&idVinculo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, utilidades.ErrNotFound{
+				Entity: "vínculo persona-empresa",
+				Campo:  "id_empresa",
+				Valor:  fmt.Sprintf("%d", idEmpresa),
+			}
+		}
+		return 0, fmt.Errorf("error buscando vínculo persona-empresa: %w", err)
+	}
+	return idVinculo, nil
+}
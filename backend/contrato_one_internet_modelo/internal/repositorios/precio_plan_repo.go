@@ -0,0 +1,314 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// PrecioPlanRepo maneja las versiones de precio de los planes y las promociones
+type PrecioPlanRepo struct {
+	db Execer
+}
+
+// NewPrecioPlanRepo crea una nueva instancia de PrecioPlanRepo
+func NewPrecioPlanRepo(db Execer) *PrecioPlanRepo {
+	return &PrecioPlanRepo{db: db}
+}
+
+// sqlPrecioContrato es el abono mensual del contrato c (plan pl) en la fecha del parámetro:
+// el último precio del plan que empezó a regir después de la firma o, si no hubo cambios, el
+// precio con el que se firmó
+const sqlPrecioContrato = `COALESCE(
+	(SELECT pp.precio FROM plan_precio pp
+	 WHERE pp.id_plan = c.id_plan
+	   AND pp.fecha_inicio > COALESCE(c.fecha_inicio, DATE(c.creado))
+	   AND pp.fecha_inicio <= ?
+	 ORDER BY pp.fecha_inicio DESC LIMIT 1),
+	c.precio_plan, pl.precio)`
+
+const selectPrecioPlan = `
+	SELECT id_plan_precio, id_plan, precio, DATE_FORMAT(fecha_inicio, '%Y-%m-%d'),
+	       DATE_FORMAT(fecha_fin, '%Y-%m-%d'), motivo, id_usuario, creado
+	FROM plan_precio
+`
+
+func escanearPrecioPlan(s interface{ Scan(...interface{}) error }) (*modelos.PrecioPlan, error) {
+	var p modelos.PrecioPlan
+	var idUsuario sql.NullInt64
+	if err := s.Scan(&p.IDPlanPrecio, &p.IDPlan, &p.Precio, &p.FechaInicio, &p.FechaFin, &p.Motivo, &idUsuario, &p.Creado); err != nil {
+		return nil, err
+	}
+	p.IDUsuario = nullIntAPuntero(idUsuario)
+	return &p, nil
+}
+
+// ObtenerPlan devuelve nombre y vigencia de un plan no borrado
+func (r *PrecioPlanRepo) ObtenerPlan(ctx context.Context, idPlan int) (*modelos.Plan, error) {
+	var p modelos.Plan
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id_plan, nombre, DATE_FORMAT(fecha_inicio, '%Y-%m-%d'), DATE_FORMAT(fecha_fin, '%Y-%m-%d')
+		FROM plan WHERE id_plan = ? AND borrado IS NULL`, idPlan).Scan(&p.IDPlan, &p.Nombre, &p.FechaInicio, &p.FechaFin)
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "plan", Campo: "id_plan", Valor: fmt.Sprintf("%d", idPlan)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo plan %d: %w", idPlan, err)
+	}
+	return &p, nil
+}
+
+// ListarPlanesVigentes devuelve los planes no borrados que siguen vigentes en la fecha
+func (r *PrecioPlanRepo) ListarPlanesVigentes(ctx context.Context, fecha string) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id_plan FROM plan
+		WHERE borrado IS NULL AND (fecha_fin IS NULL OR fecha_fin >= ?)
+		ORDER BY id_plan`, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error listando planes vigentes: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error escaneando plan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PrecioVigente devuelve el precio del plan que rige en la fecha
+func (r *PrecioPlanRepo) PrecioVigente(ctx context.Context, idPlan int, fecha string) (*modelos.PrecioPlan, error) {
+	p, err := escanearPrecioPlan(r.db.QueryRowContext(ctx, selectPrecioPlan+`
+		WHERE id_plan = ? AND fecha_inicio <= ?
+		ORDER BY fecha_inicio DESC LIMIT 1`, idPlan, fecha))
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "precio del plan", Campo: "id_plan", Valor: fmt.Sprintf("%d", idPlan)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo precio vigente del plan %d: %w", idPlan, err)
+	}
+	return p, nil
+}
+
+// ProximoPrecio devuelve el primer precio del plan que empieza a regir en la fecha o después,
+// o nil si no hay ninguno programado
+func (r *PrecioPlanRepo) ProximoPrecio(ctx context.Context, idPlan int, fecha string) (*modelos.PrecioPlan, error) {
+	p, err := escanearPrecioPlan(r.db.QueryRowContext(ctx, selectPrecioPlan+`
+		WHERE id_plan = ? AND fecha_inicio >= ?
+		ORDER BY fecha_inicio LIMIT 1`, idPlan, fecha))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo próximo precio del plan %d: %w", idPlan, err)
+	}
+	return p, nil
+}
+
+// ObtenerPorID devuelve una versión de precio
+func (r *PrecioPlanRepo) ObtenerPorID(ctx context.Context, idPlanPrecio int) (*modelos.PrecioPlan, error) {
+	p, err := escanearPrecioPlan(r.db.QueryRowContext(ctx, selectPrecioPlan+` WHERE id_plan_precio = ?`, idPlanPrecio))
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "precio del plan", Campo: "id_plan_precio", Valor: fmt.Sprintf("%d", idPlanPrecio)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo precio %d: %w", idPlanPrecio, err)
+	}
+	return p, nil
+}
+
+// Historial devuelve los precios del plan, del más reciente al más antiguo
+func (r *PrecioPlanRepo) Historial(ctx context.Context, idPlan int) ([]modelos.PrecioPlan, error) {
+	rows, err := r.db.QueryContext(ctx, selectPrecioPlan+` WHERE id_plan = ? ORDER BY fecha_inicio DESC`, idPlan)
+	if err != nil {
+		return nil, fmt.Errorf("error listando precios del plan %d: %w", idPlan, err)
+	}
+	defer rows.Close()
+
+	precios := []modelos.PrecioPlan{}
+	for rows.Next() {
+		p, err := escanearPrecioPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error escaneando precio del plan: %w", err)
+		}
+		precios = append(precios, *p)
+	}
+	return precios, rows.Err()
+}
+
+// CerrarVigencia pone fin al precio abierto del plan que empezó a regir hasta la fecha dada
+func (r *PrecioPlanRepo) CerrarVigencia(ctx context.Context, idPlan int, fechaFin string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE plan_precio SET fecha_fin = ?
+		WHERE id_plan = ? AND fecha_fin IS NULL AND fecha_inicio <= ?`, fechaFin, idPlan, fechaFin)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// Crear guarda una versión de precio
+func (r *PrecioPlanRepo) Crear(ctx context.Context, p *modelos.PrecioPlan) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO plan_precio (id_plan, precio, fecha_inicio, fecha_fin, motivo, id_usuario)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		p.IDPlan, p.Precio, p.FechaInicio, p.FechaFin, p.Motivo, p.IDUsuario)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// ReemplazarPrecioInicial cambia el precio de un plan que nunca se contrató: no hay precio
+// firmado que respetar, así que se corrige el precio que rige en la fecha (o el primero, si el
+// plan todavía no empezó) y el precio de lista
+func (r *PrecioPlanRepo) ReemplazarPrecioInicial(ctx context.Context, idPlan int, precio float64, fecha string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE plan_precio SET precio = ?
+		WHERE id_plan = ? AND (fecha_fin IS NULL OR fecha_fin >= ?)
+		ORDER BY fecha_inicio LIMIT 1`,
+		precio, idPlan, fecha); err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE plan SET precio = ? WHERE id_plan = ?`, precio, idPlan)
+	return utilidades.TraducirErrorBD(err)
+}
+
+// TieneContratos indica si el plan tiene algún contrato
+func (r *PrecioPlanRepo) TieneContratos(ctx context.Context, idPlan int) (bool, error) {
+	var existe bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM contrato WHERE id_plan = ?)`, idPlan).Scan(&existe)
+	if err != nil {
+		return false, fmt.Errorf("error verificando contratos del plan %d: %w", idPlan, err)
+	}
+	return existe, nil
+}
+
+// ListarContratosAlcanzados devuelve los contratos en curso del plan firmados antes de que
+// rija el precio nuevo, con el abono que pagan hasta entonces
+func (r *PrecioPlanRepo) ListarContratosAlcanzados(ctx context.Context, idPlan int, vigenteDesde, diaAnterior string) ([]modelos.ContratoAvisoPrecio, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id_contrato, c.id_persona, cn.id_conexion, cn.nro_conexion, pl.nombre, `+sqlPrecioContrato+`
+		FROM contrato c
+		JOIN estado_contrato ec ON ec.id_estado_contrato = c.id_estado_contrato
+		JOIN conexion cn ON cn.id_conexion = c.id_conexion
+		JOIN plan pl ON pl.id_plan = c.id_plan
+		WHERE c.id_plan = ? AND c.borrado IS NULL
+		  AND ec.nombre NOT IN (?, ?)
+		  AND (c.fecha_fin IS NULL OR c.fecha_fin >= ?)
+		  AND COALESCE(c.fecha_inicio, DATE(c.creado)) < ?
+		ORDER BY c.id_contrato`,
+		diaAnterior, idPlan, modelos.EstadoContratoNoFactible, modelos.EstadoContratoBaja, vigenteDesde, vigenteDesde)
+	if err != nil {
+		return nil, fmt.Errorf("error listando contratos del plan %d: %w", idPlan, err)
+	}
+	defer rows.Close()
+
+	contratos := []modelos.ContratoAvisoPrecio{}
+	for rows.Next() {
+		var c modelos.ContratoAvisoPrecio
+		if err := rows.Scan(&c.IDContrato, &c.IDPersona, &c.IDConexion, &c.NroConexion, &c.PlanNombre, &c.PrecioActual); err != nil {
+			return nil, fmt.Errorf("error escaneando contrato alcanzado: %w", err)
+		}
+		contratos = append(contratos, c)
+	}
+	return contratos, rows.Err()
+}
+
+const selectPromocion = `
+	SELECT id_promocion, nombre, descripcion, descuento_porcentaje, duracion_meses, id_plan,
+	       DATE_FORMAT(fecha_inicio, '%Y-%m-%d'), DATE_FORMAT(fecha_fin, '%Y-%m-%d'), id_usuario_creador
+	FROM promocion
+`
+
+func escanearPromocion(s interface{ Scan(...interface{}) error }) (*modelos.Promocion, error) {
+	var p modelos.Promocion
+	var idPlan, idUsuario sql.NullInt64
+	if err := s.Scan(&p.IDPromocion, &p.Nombre, &p.Descripcion, &p.DescuentoPorcentaje, &p.DuracionMeses, &idPlan,
+		&p.FechaInicio, &p.FechaFin, &idUsuario); err != nil {
+		return nil, err
+	}
+	p.IDPlan = nullIntAPuntero(idPlan)
+	p.IDUsuarioCreador = nullIntAPuntero(idUsuario)
+	return &p, nil
+}
+
+// CrearPromocion guarda una promoción
+func (r *PrecioPlanRepo) CrearPromocion(ctx context.Context, p *modelos.Promocion) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO promocion (nombre, descripcion, descuento_porcentaje, duracion_meses, id_plan, fecha_inicio, fecha_fin, id_usuario_creador)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Nombre, p.Descripcion, p.DescuentoPorcentaje, p.DuracionMeses, p.IDPlan, p.FechaInicio, p.FechaFin, p.IDUsuarioCreador)
+	if err != nil {
+		return 0, utilidades.TraducirErrorBD(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// ListarPromociones devuelve las promociones no borradas. Con fecha, sólo las que se pueden
+// contratar ese día; con idPlan, sólo las que valen para ese plan.
+func (r *PrecioPlanRepo) ListarPromociones(ctx context.Context, fecha *string, idPlan *int) ([]modelos.Promocion, error) {
+	q := selectPromocion + ` WHERE borrado IS NULL`
+	args := []interface{}{}
+	if fecha != nil {
+		q += ` AND fecha_inicio <= ? AND (fecha_fin IS NULL OR fecha_fin >= ?)`
+		args = append(args, *fecha, *fecha)
+	}
+	if idPlan != nil {
+		q += ` AND (id_plan IS NULL OR id_plan = ?)`
+		args = append(args, *idPlan)
+	}
+	q += ` ORDER BY fecha_inicio DESC, id_promocion DESC`
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando promociones: %w", err)
+	}
+	defer rows.Close()
+
+	promociones := []modelos.Promocion{}
+	for rows.Next() {
+		p, err := escanearPromocion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error escaneando promoción: %w", err)
+		}
+		promociones = append(promociones, *p)
+	}
+	return promociones, rows.Err()
+}
+
+// ObtenerPromocion devuelve una promoción no borrada
+func (r *PrecioPlanRepo) ObtenerPromocion(ctx context.Context, idPromocion int) (*modelos.Promocion, error) {
+	p, err := escanearPromocion(r.db.QueryRowContext(ctx, selectPromocion+` WHERE id_promocion = ? AND borrado IS NULL`, idPromocion))
+	if err == sql.ErrNoRows {
+		return nil, utilidades.ErrNotFound{Entity: "promocion", Campo: "id_promocion", Valor: fmt.Sprintf("%d", idPromocion)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo promoción %d: %w", idPromocion, err)
+	}
+	return p, nil
+}
+
+// BorrarPromocion da de baja una promoción. Los contratos que ya la tienen la conservan.
+func (r *PrecioPlanRepo) BorrarPromocion(ctx context.Context, idPromocion int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE promocion SET borrado = NOW() WHERE id_promocion = ? AND borrado IS NULL`, idPromocion)
+	if err != nil {
+		return utilidades.TraducirErrorBD(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return utilidades.ErrNotFound{Entity: "promocion", Campo: "id_promocion", Valor: fmt.Sprintf("%d", idPromocion)}
+	}
+	return nil
+}
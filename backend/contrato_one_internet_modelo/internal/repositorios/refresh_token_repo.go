@@ -2,7 +2,7 @@ package repositorios
 
 import (
     "context"
-    "time"
+    "database/sql"
 
     "contrato_one_internet_modelo/internal/modelos"
     "contrato_one_internet_modelo/internal/utilidades"
@@ -16,30 +16,59 @@ func NewRefreshTokenRepo(db Execer) *RefreshTokenRepo {
     return &RefreshTokenRepo{db: db}
 }
 
-// CrearRefreshToken inserta un nuevo refresh token para un usuario.
+// CrearRefreshToken inserta un nuevo refresh token para un usuario. Se guarda sólo su hash.
 func (r *RefreshTokenRepo) CrearRefreshToken(ctx context.Context, t *modelos.RefreshToken) error {
-    query := `INSERT INTO refresh_token (id_usuario, token, expiracion, creado, revocado) VALUES (?, ?, ?, ?, 0)`
-    _, err := r.db.ExecContext(ctx, query, t.IDUsuario, t.Token, t.Expiracion, t.Creado)
+    if t.AMR == "" {
+        t.AMR = modelos.AMRPassword
+    }
+    t.TokenHash = utilidades.HashToken(t.Token)
+    query := `INSERT INTO refresh_token (id_usuario, id_sesion, token_hash, amr, expiracion, creado, revocado) VALUES (?, ?, ?, ?, ?, ?, 0)`
+    _, err := r.db.ExecContext(ctx, query, t.IDUsuario, t.IDSesion, t.TokenHash, t.AMR, t.Expiracion, t.Creado)
     return err
 }
 
-// ValidarRefreshToken retorna el id_usuario asociado si el token existe y está válido (no revocado ni expirado)
-func (r *RefreshTokenRepo) ValidarRefreshToken(ctx context.Context, token string) (int, error) {
-    var idUsuario int
-    var expiracion time.Time
-    var revocado bool
-    err := r.db.QueryRowContext(ctx, `SELECT id_usuario, expiracion, revocado FROM refresh_token WHERE token = ? LIMIT 1`, token).Scan(&idUsuario, &expiracion, &revocado)
+// ObtenerRefreshToken busca el token aunque esté revocado o vencido, para que quien lo use
+// pueda distinguir un token rotado (posible robo) de uno inexistente
+func (r *RefreshTokenRepo) ObtenerRefreshToken(ctx context.Context, token string) (*modelos.RefreshToken, error) {
+    var t modelos.RefreshToken
+    err := r.db.QueryRowContext(ctx, `
+        SELECT id, id_usuario, id_sesion, token_hash, amr, creado, expiracion, revocado, rotado
+        FROM refresh_token WHERE token_hash = ? LIMIT 1`, utilidades.HashToken(token)).
+        Scan(&t.ID, &t.IDUsuario, &t.IDSesion, &t.TokenHash, &t.AMR, &t.Creado, &t.Expiracion, &t.Revocado, &t.Rotado)
+    if err == sql.ErrNoRows {
+        return nil, utilidades.ErrTokenInvalido
+    }
     if err != nil {
-        return 0, err
+        return nil, err
+    }
+    if !utilidades.TokenCoincide(token, t.TokenHash) {
+        return nil, utilidades.ErrTokenInvalido
     }
-    if revocado || time.Now().After(expiracion) {
-        return 0, utilidades.ErrTokenInvalido
+    return &t, nil
+}
+
+// RotarRefreshToken revoca el token marcándolo como reemplazado. Devuelve false si ya estaba
+// revocado, es decir si otro pedido lo usó antes.
+func (r *RefreshTokenRepo) RotarRefreshToken(ctx context.Context, id int) (bool, error) {
+    res, err := r.db.ExecContext(ctx, `UPDATE refresh_token SET revocado = 1, rotado = NOW() WHERE id = ? AND revocado = 0`, id)
+    if err != nil {
+        return false, err
     }
-    return idUsuario, nil
+    n, err := res.RowsAffected()
+    if err != nil {
+        return false, err
+    }
+    return n == 1, nil
+}
+
+// AsignarSesion asocia a una sesión un token emitido antes de que existieran las sesiones
+func (r *RefreshTokenRepo) AsignarSesion(ctx context.Context, id, idSesion int) error {
+    _, err := r.db.ExecContext(ctx, `UPDATE refresh_token SET id_sesion = ? WHERE id = ?`, idSesion, id)
+    return err
 }
 
 // RevocarRefreshToken marca como revocado un token determinado
 func (r *RefreshTokenRepo) RevocarRefreshToken(ctx context.Context, token string) error {
-    _, err := r.db.ExecContext(ctx, `UPDATE refresh_token SET revocado = 1 WHERE token = ?`, token)
+    _, err := r.db.ExecContext(ctx, `UPDATE refresh_token SET revocado = 1 WHERE token_hash = ?`, utilidades.HashToken(token))
     return err
 }
@@ -0,0 +1,132 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+// RolPermisoRepo guarda qué permisos tiene cada rol. Quitar un permiso es un borrado lógico
+// de la asignación; volver a asignarlo la reactiva.
+type RolPermisoRepo struct {
+	db Execer
+}
+
+// NewRolPermisoRepo crea una nueva instancia de RolPermisoRepo
+func NewRolPermisoRepo(db Execer) *RolPermisoRepo {
+	return &RolPermisoRepo{db: db}
+}
+
+// ListarPorRol devuelve los permisos activos asignados al rol
+func (r *RolPermisoRepo) ListarPorRol(ctx context.Context, idRol int) ([]modelos.Permiso, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id_permiso, p.nombre, p.descripcion
+		FROM rol_permiso rp
+		JOIN permiso p ON p.id_permiso = rp.id_permiso AND p.borrado IS NULL
+		WHERE rp.id_rol = ? AND rp.borrado IS NULL
+		ORDER BY p.nombre`, idRol)
+	if err != nil {
+		return nil, fmt.Errorf("error listando permisos del rol %d: %w", idRol, err)
+	}
+	defer rows.Close()
+
+	permisos := []modelos.Permiso{}
+	for rows.Next() {
+		var p modelos.Permiso
+		var desc sql.NullString
+		if err := rows.Scan(&p.IDPermiso, &p.Nombre, &desc); err != nil {
+			return nil, fmt.Errorf("error leyendo permiso: %w", err)
+		}
+		if desc.Valid {
+			s := desc.String
+			p.Descripcion = &s
+		}
+		permisos = append(permisos, p)
+	}
+	return permisos, rows.Err()
+}
+
+// Asignar da el permiso al rol. Devuelve false si ya lo tenía.
+func (r *RolPermisoRepo) Asignar(ctx context.Context, idRol, idPermiso int) (bool, error) {
+	// MySQL evalúa las asignaciones en orden: creado se renueva sólo si estaba quitado
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO rol_permiso (id_rol, id_permiso) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE creado = IF(borrado IS NULL, creado, NOW()), borrado = NULL`, idRol, idPermiso)
+	if err != nil {
+		return false, fmt.Errorf("error asignando permiso %d al rol %d: %w", idPermiso, idRol, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// AsignarPorNombre da el permiso a los roles indicados que existan. Lo usa la siembra de
+// catálogos para la asignación inicial.
+func (r *RolPermisoRepo) AsignarPorNombre(ctx context.Context, permiso string, roles []string) error {
+	for _, rol := range roles {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT IGNORE INTO rol_permiso (id_rol, id_permiso)
+			SELECT r.id_rol, p.id_permiso
+			FROM rol r, permiso p
+			WHERE r.nombre = ? AND r.borrado IS NULL AND p.nombre = ? AND p.borrado IS NULL`, rol, permiso)
+		if err != nil {
+			return fmt.Errorf("error asignando permiso %s al rol %s: %w", permiso, rol, err)
+		}
+	}
+	return nil
+}
+
+// Quitar le saca el permiso al rol. Devuelve false si no lo tenía.
+func (r *RolPermisoRepo) Quitar(ctx context.Context, idRol, idPermiso int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE rol_permiso SET borrado = NOW()
+		WHERE id_rol = ? AND id_permiso = ? AND borrado IS NULL`, idRol, idPermiso)
+	if err != nil {
+		return false, fmt.Errorf("error quitando permiso %d del rol %d: %w", idPermiso, idRol, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// QuitarTodos le saca al rol todos sus permisos
+func (r *RolPermisoRepo) QuitarTodos(ctx context.Context, idRol int) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE rol_permiso SET borrado = NOW() WHERE id_rol = ? AND borrado IS NULL", idRol)
+	if err != nil {
+		return fmt.Errorf("error quitando permisos del rol %d: %w", idRol, err)
+	}
+	return nil
+}
+
+// PermisosDeUsuario devuelve los nombres de los permisos que suman los roles activos del usuario
+func (r *RolPermisoRepo) PermisosDeUsuario(ctx context.Context, idUsuario int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT p.nombre
+		FROM usuario_rol ur
+		JOIN rol r ON r.id_rol = ur.id_rol AND r.borrado IS NULL
+		JOIN rol_permiso rp ON rp.id_rol = ur.id_rol AND rp.borrado IS NULL
+		JOIN permiso p ON p.id_permiso = rp.id_permiso AND p.borrado IS NULL
+		WHERE ur.id_usuario = ?
+		ORDER BY p.nombre`, idUsuario)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo permisos del usuario %d: %w", idUsuario, err)
+	}
+	defer rows.Close()
+
+	permisos := []string{}
+	for rows.Next() {
+		var nombre string
+		if err := rows.Scan(&nombre); err != nil {
+			return nil, fmt.Errorf("error leyendo permiso: %w", err)
+		}
+		permisos = append(permisos, nombre)
+	}
+	return permisos, rows.Err()
+}
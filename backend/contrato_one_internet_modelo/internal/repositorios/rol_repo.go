@@ -66,7 +66,10 @@ func (r *RolRepo) Actualizar(ctx context.Context, id int, nombre string, descrip
 
 func (r *RolRepo) EstaEnUso(ctx context.Context, id int) (bool, error) {
     var cnt int
-    query := `SELECT COUNT(1) FROM usuario WHERE id_rol = ? AND borrado IS NULL`
+    query := `SELECT COUNT(1)
+        FROM usuario_rol ur
+        INNER JOIN usuario u ON u.id_usuario = ur.id_usuario
+        WHERE ur.id_rol = ? AND u.borrado IS NULL`
     if err := r.db.QueryRowContext(ctx, query, id).Scan(&cnt); err != nil { return false, err }
     return cnt > 0, nil
 }
@@ -0,0 +1,119 @@
+package repositorios
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+// SesionRepo guarda las sesiones de login; cada una agrupa la familia de refresh tokens
+// que se emitieron al rotarla
+type SesionRepo struct {
+	db Execer
+}
+
+// NewSesionRepo crea una nueva instancia de SesionRepo
+func NewSesionRepo(db Execer) *SesionRepo {
+	return &SesionRepo{db: db}
+}
+
+// Crear abre una sesión y devuelve su id
+func (r *SesionRepo) Crear(ctx context.Context, idUsuario int, ip, userAgent string, expiracion time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO sesion (id_usuario, ip, user_agent, expiracion)
+		VALUES (?, NULLIF(?, ''), NULLIF(?, ''), ?)`, idUsuario, ip, userAgent, expiracion)
+	if err != nil {
+		return 0, fmt.Errorf("error creando sesión del usuario %d: %w", idUsuario, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// RegistrarUso actualiza el último uso y la expiración al rotar el refresh token. La IP y el
+// user agent se actualizan sólo si se informan.
+func (r *SesionRepo) RegistrarUso(ctx context.Context, idSesion int, ip, userAgent string, expiracion time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sesion
+		SET ultimo_uso = NOW(), expiracion = ?,
+		    ip = COALESCE(NULLIF(?, ''), ip), user_agent = COALESCE(NULLIF(?, ''), user_agent)
+		WHERE id_sesion = ?`, expiracion, ip, userAgent, idSesion)
+	if err != nil {
+		return fmt.Errorf("error registrando uso de la sesión %d: %w", idSesion, err)
+	}
+	return nil
+}
+
+// ListarVigentes devuelve las sesiones sin revocar ni vencer del usuario
+func (r *SesionRepo) ListarVigentes(ctx context.Context, idUsuario int) ([]modelos.Sesion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id_sesion, id_usuario, ip, user_agent, creado, ultimo_uso, expiracion
+		FROM sesion
+		WHERE id_usuario = ? AND revocada IS NULL AND expiracion > NOW()
+		ORDER BY ultimo_uso DESC, id_sesion DESC`, idUsuario)
+	if err != nil {
+		return nil, fmt.Errorf("error listando sesiones del usuario %d: %w", idUsuario, err)
+	}
+	defer rows.Close()
+
+	sesiones := []modelos.Sesion{}
+	for rows.Next() {
+		var s modelos.Sesion
+		if err := rows.Scan(&s.IDSesion, &s.IDUsuario, &s.IP, &s.UserAgent, &s.Creado, &s.UltimoUso, &s.Expiracion); err != nil {
+			return nil, fmt.Errorf("error leyendo sesión: %w", err)
+		}
+		sesiones = append(sesiones, s)
+	}
+	return sesiones, rows.Err()
+}
+
+// Revocar cierra una sesión del usuario y revoca sus refresh tokens. Devuelve false si la
+// sesión no es del usuario o ya estaba cerrada.
+func (r *SesionRepo) Revocar(ctx context.Context, idUsuario, idSesion int, motivo string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE sesion SET revocada = NOW(), motivo_revocacion = ?
+		WHERE id_sesion = ? AND id_usuario = ? AND revocada IS NULL`, motivo, idSesion, idUsuario)
+	if err != nil {
+		return false, fmt.Errorf("error revocando sesión %d: %w", idSesion, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Los tokens se revocan aunque la sesión ya estuviera cerrada, por si quedó alguno
+	_, err = r.db.ExecContext(ctx,
+		"UPDATE refresh_token SET revocado = 1 WHERE id_sesion = ? AND revocado = 0", idSesion)
+	if err != nil {
+		return false, fmt.Errorf("error revocando tokens de la sesión %d: %w", idSesion, err)
+	}
+	return n == 1, nil
+}
+
+// RevocarTodas cierra las sesiones vigentes del usuario salvo la indicada (0 para cerrarlas
+// todas) y revoca sus refresh tokens, incluidos los que no tienen sesión. Devuelve cuántas
+// sesiones cerró.
+func (r *SesionRepo) RevocarTodas(ctx context.Context, idUsuario, excepto int, motivo string) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE sesion SET revocada = NOW(), motivo_revocacion = ?
+		WHERE id_usuario = ? AND id_sesion <> ? AND revocada IS NULL`, motivo, idUsuario, excepto)
+	if err != nil {
+		return 0, fmt.Errorf("error revocando sesiones del usuario %d: %w", idUsuario, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE refresh_token SET revocado = 1
+		WHERE id_usuario = ? AND revocado = 0 AND (id_sesion IS NULL OR id_sesion <> ?)`, idUsuario, excepto)
+	if err != nil {
+		return 0, fmt.Errorf("error revocando tokens del usuario %d: %w", idUsuario, err)
+	}
+	return int(n), nil
+}
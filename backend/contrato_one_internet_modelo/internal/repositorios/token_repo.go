@@ -16,12 +16,12 @@ func NewTokenRepo(db Execer) *TokenRepo {
 	return &TokenRepo{db: db}
 }
 
-// CrearEmailVerificacionToken inserta un nuevo token de verificación.
+// CrearEmailVerificacionToken inserta un nuevo token de verificación. Se guarda sólo su hash.
 func (r *TokenRepo) CrearEmailVerificacionToken(ctx context.Context, t *modelos.EmailVerificacionToken) error {
 	query := `
-        INSERT INTO email_verificacion_token (id_usuario, token, expiracion, creado)
+        INSERT INTO email_verificacion_token (id_usuario, token_hash, expiracion, creado)
         VALUES (?, ?, ?, ?)`
-	_, err := r.db.ExecContext(ctx, query, t.IDUsuario, t.Token, t.Expiracion, t.Creado)
+	_, err := r.db.ExecContext(ctx, query, t.IDUsuario, utilidades.HashToken(t.Token), t.Expiracion, t.Creado)
 	return utilidades.TraducirErrorBD(err)
 }
 
@@ -76,6 +76,34 @@ func (r *UsuarioRepo) ObtenerIDPersona(ctx context.Context, idUsuario int) (int,
 	return idPersona, nil
 }
 
+// ObtenerIDUsuarioPorPersona recupera el usuario activo de una persona (ErrNotFound si no tiene)
+func (r *UsuarioRepo) ObtenerIDUsuarioPorPersona(ctx context.Context, idPersona int) (int, error) {
+	var idUsuario int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id_usuario FROM usuario WHERE id_persona = ? AND borrado IS NULL LIMIT 1", idPersona).Scan(&idUsuario)
+	if err == sql.ErrNoRows {
+		return 0, utilidades.ErrNotFound{Entity: "usuario", Campo: "id_persona", Valor: fmt.Sprintf("%d", idPersona)}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return idUsuario, nil
+}
+
+// ObtenerEmail devuelve el email de un usuario activo (ErrNotFound si no existe o fue borrado)
+func (r *UsuarioRepo) ObtenerEmail(ctx context.Context, idUsuario int) (string, error) {
+	var email string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT email FROM usuario WHERE id_usuario = ? AND borrado IS NULL", idUsuario).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", utilidades.ErrNotFound{Entity: "usuario", Campo: "id_usuario", Valor: fmt.Sprintf("%d", idUsuario)}
+	}
+	if err != nil {
+		return "", err
+	}
+	return email, nil
+}
+
 // ActualizarAuditoriaLogin actualiza los datos de rastreo del último acceso.
 func (r *UsuarioRepo) ActualizarAuditoriaLogin(ctx context.Context, idUsuario int, ip, userAgent string) error {
 	query := `UPDATE usuario SET ultimo_ip = ?, ultimo_user_agent = ?, ultimo_login = NOW() WHERE id_usuario = ?`
@@ -84,9 +112,10 @@ func (r *UsuarioRepo) ActualizarAuditoriaLogin(ctx context.Context, idUsuario in
 }
 
 // RegistrarLogLogin inserta un registro histórico del intento de login.
-func (r *UsuarioRepo) RegistrarLogLogin(ctx context.Context, idUsuario int, ip, userAgent string, exito bool, mensaje string) error {
-	query := `INSERT INTO usuario_login_log (id_usuario, ip, user_agent, exito, mensaje, fecha) VALUES (?, ?, ?, ?, ?, NOW())`
-	_, err := r.db.ExecContext(ctx, query, idUsuario, ip, userAgent, exito, mensaje)
+// idUsuario es nil cuando el email no corresponde a ningún usuario.
+func (r *UsuarioRepo) RegistrarLogLogin(ctx context.Context, idUsuario *int, email, ip, userAgent string, exito bool, mensaje string) error {
+	query := `INSERT INTO usuario_login_log (id_usuario, email, ip, user_agent, exito, mensaje, fecha) VALUES (?, ?, ?, ?, ?, ?, NOW())`
+	_, err := r.db.ExecContext(ctx, query, idUsuario, email, ip, userAgent, exito, mensaje)
 	return err
 }
 
@@ -7,13 +7,18 @@ import (
 	personas "contrato_one_internet_modelo/internal/handlers"
 	"contrato_one_internet_modelo/internal/handlers/auth"
 	cargo "contrato_one_internet_modelo/internal/handlers/cargo"
+	clientes "contrato_one_internet_modelo/internal/handlers/clientes"
 	conexion "contrato_one_internet_modelo/internal/handlers/conexion"
+	cuentas "contrato_one_internet_modelo/internal/handlers/cuentas"
 	contrato_firma "contrato_one_internet_modelo/internal/handlers/contrato_firma"
 	direccion "contrato_one_internet_modelo/internal/handlers/direccion"
 	estado_conexion "contrato_one_internet_modelo/internal/handlers/estado_conexion"
 	estado_contrato "contrato_one_internet_modelo/internal/handlers/estado_contrato"
+	facturacion "contrato_one_internet_modelo/internal/handlers/facturacion"
+	instalacion "contrato_one_internet_modelo/internal/handlers/instalacion"
 	"contrato_one_internet_modelo/internal/handlers/geografia" 
 	notificaciones "contrato_one_internet_modelo/internal/handlers/notificaciones"
+	pagos "contrato_one_internet_modelo/internal/handlers/pagos"
 	perfil "contrato_one_internet_modelo/internal/handlers/perfil"
 	permiso "contrato_one_internet_modelo/internal/handlers/permiso"
 	planes "contrato_one_internet_modelo/internal/handlers/planes"
@@ -24,11 +29,13 @@ import (
 	"contrato_one_internet_modelo/internal/middleware"
 	"contrato_one_internet_modelo/internal/repositorios"
 	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+	"contrato_one_internet_modelo/internal/utilidades/pdf"
 
 	"github.com/gorilla/mux"
 )
 
-func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
+func SetupRutas(db *sql.DB, cfg config.AppConfig, facturacionService *servicios.FacturacionService, cuentaService *servicios.CuentaService, firmaDigitalService *servicios.FirmaDigitalService) *mux.Router {
 	r := mux.NewRouter()
 	apiV1 := r.PathPrefix("/api/v1").Subrouter()
 
@@ -36,10 +43,12 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	geografiaRepo := repositorios.NewGeografiaRepository(db)
 
 	// TokenManager para manejar el token con concurrencia segura
-	tokenManager := auth.NewTokenManager(cfg.InternalJWTSecret)
+	tokenManager := auth.NewTokenManager(cfg.InternalJWTSecret, cfg.ServicioAuth.ClientID,
+		cfg.ServicioAuth.ClientSecret, cfg.ServicioAuth.TokenTTL)
 	authHandler := auth.NewAuthHandler(tokenManager)
 
-	//clientesHandler := clientes.NewClientesHandler(clientesService)
+	clientesService := servicios.NewClientesService(db)
+	clientesHandler := clientes.NewClientesHandler(clientesService)
 	geografiaHandler := geografia.NewHandler(geografiaRepo)
 	// Nueva inyección para el flujo de Personas
 	usuarioService := servicios.NewUsuarioService(db) // Nuevo servicio
@@ -48,6 +57,7 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 
 	// Planes
 	planService := servicios.NewPlanService(db)
+	precioPlanService := servicios.NewPrecioPlanService(db, cfg.Planes.DiasAvisoPrecio)
 	planesHandler := planes.NewHandler(planService)
 
 	// Tipos de IVA
@@ -94,10 +104,23 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	// Conexiones
 	conexionService := servicios.NewConexionService(db)
 	conexionHandler := conexion.NewConexionHandler(conexionService)
+	estadoHandler := conexion.NewEstadoHandler(servicios.NewCicloVidaService(db))
+
+	// Órdenes de instalación
+	instalacionHandler := instalacion.NewInstalacionHandler(servicios.NewInstalacionService(db, cfg.Instalaciones.FotosPath))
+	turnoHandler := instalacion.NewTurnoHandler(servicios.NewTurnoService(db))
+
+	// Facturación (el servicio se comparte con el proceso de facturación mensual)
+	facturaHandler := facturacion.NewFacturaHandler(facturacionService)
+
+	// Cuenta corriente (el servicio se comparte con la revisión periódica de deuda)
+	cuentaHandler := cuentas.NewCuentaHandler(cuentaService)
 
 	// Notificaciones
 	notificacionService := servicios.NewNotificacionService(db)
 	notificacionHandler := notificaciones.NewNotificacionHandler(notificacionService)
+	notificacionEntregaHandler := notificaciones.NewNotificacionEntregaHandler(servicios.NewNotificacionEntregaService(db))
+	outboxHandler := notificaciones.NewOutboxHandler(servicios.NewOutboxService(db, firmaDigitalService))
 
 	// Perfil - Contratos y Conexiones
 	contratoRepo := repositorios.NewContratoRepo(db)
@@ -105,15 +128,22 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	perfilContratoHandler := perfil.NewContratoHandlerM(contratoRepo)
 	perfilConexionHandler := perfil.NewConexionHandlerM(conexionRepo)
 
-	// Firma Digital de Contratos
-	templatePath := "/var/www/html/contratos/backend/contrato_one_internet_controlador/contratos.html"
-	contractBasePath := "/var/www/contracts"
-	pdfService := servicios.NewPDFService(db, templatePath, contractBasePath)
-	firmaDigitalService := servicios.NewFirmaDigitalService(db, pdfService, contractBasePath)
+	// Firma Digital de Contratos (el servicio se comparte con el despachador del outbox)
 	contratoFirmaHandler := contrato_firma.NewHandler(db, firmaDigitalService)
 
-	// Rutas públicas
-	apiV1.HandleFunc("/internal/auth/generate-token", authHandler.GenerateTokenHandler).Methods("GET")
+	// Pagos: el proceso de firma se inicia recién cuando el proveedor confirma el pago
+	var proveedorPago servicios.ProveedorPago
+	if cfg.Pagos.Proveedor == "mercadopago" {
+		proveedorPago = servicios.NewMercadoPagoProveedor(cfg.Pagos.AccessToken, cfg.Pagos.WebhookSecret,
+			cfg.Pagos.APIURL, cfg.Pagos.NotificacionURL, cfg.Pagos.RetornoURL)
+	} else {
+		proveedorPago = servicios.NewProveedorPagoFake(cfg.Pagos.WebhookSecret, cfg.Pagos.RetornoURL)
+	}
+	pagoService := servicios.NewPagoService(db, proveedorPago, firmaDigitalService, cuentaService)
+	pagoHandler := pagos.NewPagoHandler(pagoService)
+
+	// Emisión de token interno: pública pero exige solicitud firmada con el secreto del servicio
+	apiV1.HandleFunc("/internal/auth/token", authHandler.EmitirTokenHandler).Methods("POST")
 
 	// Rutas protegidas con middleware usando el secreto para validar token
 	protectedRouter := apiV1.PathPrefix("/internal").Subrouter()
@@ -139,7 +169,9 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	usuarioRepo := repositorios.NewUsuarioRepo(db)
 	usuarioRolRepo := repositorios.NewUsuarioRolRepo(db)
 	refreshRepo := repositorios.NewRefreshTokenRepo(db)
-	loginService := servicios.NewLoginService(usuarioRepo, usuarioRolRepo, refreshRepo, &cfg)      // servicio para login
+	intentoLoginService := servicios.NewIntentoLoginService(db, cfg.Login)
+	dosFactoresService := servicios.NewDosFactoresService(db, cfg.DosFactores)
+	loginService := servicios.NewLoginService(usuarioRepo, usuarioRolRepo, repositorios.NewRolPermisoRepo(db), refreshRepo, repositorios.NewSesionRepo(db), intentoLoginService, dosFactoresService, &cfg)      // servicio para login
 	loginHandler := auth.NewLoginHandler(loginService) // handler del login
 	// Rutas públicas (no requieren token)
 	apiV1.HandleFunc("/auth/login", loginHandler.LoginHandler).Methods("POST")
@@ -161,12 +193,45 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	// Registrar endpoint interno para chequear disponibilidad de email (protegido)
 	protectedRouter.HandleFunc("/auth/check-email", emailHandler.CheckEmailHandler).Methods("GET")
 
+	// Endpoints internos de intentos y bloqueos de login (el servicio verifica el rol admin)
+	intentosLoginHandler := auth.NewIntentosLoginHandler(intentoLoginService)
+	protectedRouter.HandleFunc("/login/intentos", intentosLoginHandler.ListarIntentosHandler).Methods("GET")
+	protectedRouter.HandleFunc("/login/bloqueos", intentosLoginHandler.ListarBloqueosHandler).Methods("GET")
+	protectedRouter.HandleFunc("/login/bloqueos/{id}", intentosLoginHandler.LevantarBloqueoHandler).Methods("DELETE")
+
+	// Autenticación en dos pasos: configuración propia, segundo paso del login (lo pide el
+	// controlador tras validar su token de desafío) y restablecimiento por un admin
+	dosFactoresHandler := auth.NewDosFactoresHandler(dosFactoresService, loginService)
+	protectedRouter.HandleFunc("/auth/2fa", dosFactoresHandler.EstadoHandler).Methods("GET")
+	protectedRouter.HandleFunc("/auth/2fa", dosFactoresHandler.DesactivarHandler).Methods("DELETE")
+	protectedRouter.HandleFunc("/auth/2fa/totp", dosFactoresHandler.IniciarTOTPHandler).Methods("POST")
+	protectedRouter.HandleFunc("/auth/2fa/totp/activar", dosFactoresHandler.ActivarTOTPHandler).Methods("POST")
+	protectedRouter.HandleFunc("/auth/2fa/recuperacion", dosFactoresHandler.RegenerarCodigosHandler).Methods("POST")
+	protectedRouter.HandleFunc("/auth/2fa/verificar", dosFactoresHandler.VerificarHandler).Methods("POST")
+	protectedRouter.HandleFunc("/auth/2fa/codigo-email", dosFactoresHandler.CodigoEmailHandler).Methods("POST")
+	protectedRouter.HandleFunc("/usuarios/{id}/2fa", dosFactoresHandler.RestablecerHandler).Methods("DELETE")
+
+	// Sesiones de login: las propias y el cierre forzado de las de otro usuario (admin)
+	sesionesHandler := auth.NewSesionesHandler(servicios.NewSesionService(db))
+	protectedRouter.HandleFunc("/perfil/sesiones", sesionesHandler.ListarHandler).Methods("GET")
+	protectedRouter.HandleFunc("/perfil/sesiones", sesionesHandler.RevocarOtrasHandler).Methods("DELETE")
+	protectedRouter.HandleFunc("/perfil/sesiones/{id}", sesionesHandler.RevocarHandler).Methods("DELETE")
+	protectedRouter.HandleFunc("/usuarios/{id}/sesiones", sesionesHandler.CerrarSesionesUsuarioHandler).Methods("DELETE")
+
 	// Endpoints internos para gestionar planes (protegidos)
 	planesWriteHandler := planes.NewWriteHandler(planService)
 	protectedRouter.HandleFunc("/planes", planesWriteHandler.CrearPlanHandler).Methods("POST")
 	protectedRouter.HandleFunc("/planes/{id}", planesWriteHandler.ActualizarPlanHandler).Methods("PATCH")
 	protectedRouter.HandleFunc("/planes/{id}", planesWriteHandler.EliminarPlanHandler).Methods("DELETE")
 
+	// Endpoints internos de precios de planes y promociones (el servicio verifica el rol admin)
+	preciosHandler := planes.NewPreciosHandler(precioPlanService)
+	protectedRouter.HandleFunc("/planes/precios", preciosHandler.AjustarPreciosHandler).Methods("POST")
+	protectedRouter.HandleFunc("/planes/{id}/precios", preciosHandler.HistorialPreciosHandler).Methods("GET")
+	protectedRouter.HandleFunc("/promociones", preciosHandler.ListarPromocionesHandler).Methods("GET")
+	protectedRouter.HandleFunc("/promociones", preciosHandler.CrearPromocionHandler).Methods("POST")
+	protectedRouter.HandleFunc("/promociones/{id}", preciosHandler.BorrarPromocionHandler).Methods("DELETE")
+
 	// Endpoints internos para gestionar tipos de IVA (protegidos)
 	protectedRouter.HandleFunc("/tipo-iva", tipoIvaWriteHandler.CrearTipoIvaHandler).Methods("POST")
 	protectedRouter.HandleFunc("/tipo-iva/{id}", tipoIvaWriteHandler.ActualizarTipoIvaHandler).Methods("PATCH")
@@ -201,6 +266,15 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	protectedRouter.HandleFunc("/roles/{id}", rolWriteHandler.ActualizarRolHandler).Methods("PATCH")
 	protectedRouter.HandleFunc("/roles/{id}", rolWriteHandler.EliminarRolHandler).Methods("DELETE")
 
+	// Endpoints internos de permisos por rol (el servicio verifica el rol admin) y permisos
+	// efectivos de la persona del pedido
+	rolPermisoHandler := rol.NewRolPermisoHandler(servicios.NewRolPermisoService(db))
+	protectedRouter.HandleFunc("/roles/{id}/permisos", rolPermisoHandler.ListarPermisosHandler).Methods("GET")
+	protectedRouter.HandleFunc("/roles/{id}/permisos", rolPermisoHandler.ReemplazarPermisosHandler).Methods("PUT")
+	protectedRouter.HandleFunc("/roles/{id}/permisos/{id_permiso}", rolPermisoHandler.AsignarPermisoHandler).Methods("POST")
+	protectedRouter.HandleFunc("/roles/{id}/permisos/{id_permiso}", rolPermisoHandler.QuitarPermisoHandler).Methods("DELETE")
+	protectedRouter.HandleFunc("/perfil/permisos", rolPermisoHandler.PermisosEfectivosHandler).Methods("GET")
+
 	// Endpoints internos para gestionar permisos (protegidos)
 	permisoRepo := repositorios.NewPermisoRepo(db)
 	permisoService := servicios.NewPermisoService(permisoRepo)
@@ -225,6 +299,12 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	protectedRouter.HandleFunc("/direcciones/{id}", direccionHandler.EliminarDireccionHandler).Methods("DELETE")
 	// Endpoint interno para solicitar conexión (protegido)
 	protectedRouter.HandleFunc("/solicitar-conexion-particular", conexionHandler.SolicitarConexionParticularHandler).Methods("POST")
+	protectedRouter.HandleFunc("/solicitar-conexion-empresa", conexionHandler.SolicitarConexionEmpresaHandler).Methods("POST")
+
+	// Endpoints internos para clientes empresa (protegidos)
+	protectedRouter.HandleFunc("/clientes/empresas", clientesHandler.CrearClienteEmpresaHandler).Methods("POST")
+	protectedRouter.HandleFunc("/clientes/empresas/{id}", clientesHandler.ObtenerEmpresaHandler).Methods("GET")
+	protectedRouter.HandleFunc("/perfil/empresas", clientesHandler.ListarMisEmpresasHandler).Methods("GET")
 
 	// Endpoint interno para obtener solicitudes pendientes (protegido)
 	protectedRouter.HandleFunc("/revisacion/solicitudes-pendientes", conexionHandler.ObtenerSolicitudesPendientesHandler).Methods("GET")
@@ -238,12 +318,79 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	// Endpoint interno para rechazar factibilidad de una solicitud (protegido)
 	protectedRouter.HandleFunc("/revisacion/rechazar-factibilidad", conexionHandler.RechazarFactibilidadHandler).Methods("POST")
 
+	// Endpoints internos para cambiar manualmente el estado de conexiones y contratos (protegidos)
+	protectedRouter.HandleFunc("/conexiones/{id:[0-9]+}/estado", estadoHandler.CambiarEstadoConexionHandler).Methods("POST")
+	protectedRouter.HandleFunc("/contratos/{id:[0-9]+}/estado", estadoHandler.CambiarEstadoContratoHandler).Methods("POST")
+
+	// Endpoints internos para consultar la línea de tiempo de estados de una conexión (protegidos)
+	protectedRouter.HandleFunc("/conexiones/{id:[0-9]+}/historial", estadoHandler.ObtenerHistorialConexionHandler).Methods("GET")
+	protectedRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/historial", estadoHandler.ObtenerMiHistorialConexionHandler).Methods("GET")
+
+	// Endpoints internos de órdenes de instalación (protegidos; el servicio exige rol técnico o admin)
+	protectedRouter.HandleFunc("/instalaciones", instalacionHandler.ListarOrdenesHandler).Methods("GET")
+	protectedRouter.HandleFunc("/instalaciones/{id:[0-9]+}", instalacionHandler.ObtenerOrdenHandler).Methods("GET")
+	protectedRouter.HandleFunc("/instalaciones/{id:[0-9]+}/asignar", instalacionHandler.AsignarOrdenHandler).Methods("POST")
+	protectedRouter.HandleFunc("/instalaciones/{id:[0-9]+}/programar", instalacionHandler.ProgramarOrdenHandler).Methods("POST")
+	protectedRouter.HandleFunc("/instalaciones/{id:[0-9]+}/completar", instalacionHandler.CompletarOrdenHandler).Methods("POST")
+	protectedRouter.HandleFunc("/instalaciones/{id:[0-9]+}/cancelar", instalacionHandler.CancelarOrdenHandler).Methods("POST")
+	protectedRouter.HandleFunc("/instalaciones/tecnicos/{id:[0-9]+}/agenda", instalacionHandler.ObtenerAgendaHandler).Methods("GET")
+	protectedRouter.HandleFunc("/instalaciones/tecnicos/{id:[0-9]+}/agenda", instalacionHandler.ActualizarAgendaHandler).Methods("PUT")
+
+	// Endpoints internos de turnos de instalación del cliente (protegidos; sólo conexiones propias)
+	protectedRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/turnos", turnoHandler.DisponibilidadHandler).Methods("GET")
+	protectedRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/turnos", turnoHandler.ReservarHandler).Methods("POST")
+	protectedRouter.HandleFunc("/perfil/conexiones/{id:[0-9]+}/turnos", turnoHandler.CancelarHandler).Methods("DELETE")
+
+	// Endpoints internos de facturas del cliente (protegidos; sólo facturas propias)
+	protectedRouter.HandleFunc("/perfil/facturas", facturaHandler.ListarMisFacturasHandler).Methods("GET")
+	protectedRouter.HandleFunc("/perfil/facturas/{id:[0-9]+}", facturaHandler.ObtenerMiFacturaHandler).Methods("GET")
+	protectedRouter.HandleFunc("/perfil/facturas/{id:[0-9]+}/pdf", facturaHandler.DescargarMiFacturaHandler).Methods("GET")
+	// Endpoint interno para emitir a demanda las facturas de un período (el servicio exige rol admin)
+	protectedRouter.HandleFunc("/facturacion/generar", facturaHandler.GenerarPeriodoHandler).Methods("POST")
+
+	// Endpoint interno de la cuenta corriente del cliente (protegido; sólo la cuenta propia)
+	protectedRouter.HandleFunc("/perfil/cuenta", cuentaHandler.ObtenerMiCuentaHandler).Methods("GET")
+	// Endpoints internos de cobranzas (el servicio exige rol admin)
+	protectedRouter.HandleFunc("/cuentas/deudores", cuentaHandler.ListarDeudoresHandler).Methods("GET")
+	protectedRouter.HandleFunc("/cuentas/revisar", cuentaHandler.RevisarCuentasHandler).Methods("POST")
+	protectedRouter.HandleFunc("/cuentas/{id:[0-9]+}", cuentaHandler.ObtenerCuentaHandler).Methods("GET")
+	protectedRouter.HandleFunc("/cuentas/{id:[0-9]+}/pagos", cuentaHandler.RegistrarPagoHandler).Methods("POST")
+	protectedRouter.HandleFunc("/cuentas/{id:[0-9]+}/notas-credito", cuentaHandler.RegistrarNotaCreditoHandler).Methods("POST")
+
 	// Endpoint interno para obtener notificaciones del usuario (protegido)
 	protectedRouter.HandleFunc("/notificaciones", notificacionHandler.ObtenerNotificacionesHandler).Methods("GET")
 
 	// Endpoint interno para marcar notificación como leída (protegido)
 	protectedRouter.HandleFunc("/notificaciones/marcar-como-leida", notificacionHandler.MarcarComoLeidaHandler).Methods("POST")
 
+	// Bandeja: contador, marcado masivo, archivo y eliminación (por persona)
+	protectedRouter.HandleFunc("/notificaciones/no-leidas", notificacionHandler.ContarNoLeidasHandler).Methods("GET")
+	protectedRouter.HandleFunc("/notificaciones/marcar-todas-como-leidas", notificacionHandler.MarcarTodasComoLeidasHandler).Methods("POST")
+	protectedRouter.HandleFunc("/notificaciones/marcar-como-leidas", notificacionHandler.MarcarComoLeidasHandler).Methods("POST")
+	protectedRouter.HandleFunc("/notificaciones/{id:[0-9]+}/archivar", notificacionHandler.ArchivarHandler).Methods("POST")
+	protectedRouter.HandleFunc("/notificaciones/{id:[0-9]+}/desarchivar", notificacionHandler.DesarchivarHandler).Methods("POST")
+	protectedRouter.HandleFunc("/notificaciones/{id:[0-9]+}", notificacionHandler.EliminarHandler).Methods("DELETE")
+
+	// Feed incremental para el streaming en tiempo real del controlador (global o por persona)
+	protectedRouter.HandleFunc("/notificaciones/feed", notificacionHandler.ObtenerFeedHandler).Methods("GET")
+
+	// Entrega multicanal: preferencias, suscripciones push, plantillas y estado por canal
+	protectedRouter.HandleFunc("/notificaciones/preferencias", notificacionEntregaHandler.ObtenerPreferenciasHandler).Methods("GET")
+	protectedRouter.HandleFunc("/notificaciones/preferencias", notificacionEntregaHandler.ActualizarPreferenciasHandler).Methods("PUT")
+	protectedRouter.HandleFunc("/notificaciones/push/suscripciones", notificacionEntregaHandler.RegistrarSuscripcionPushHandler).Methods("POST")
+	protectedRouter.HandleFunc("/notificaciones/push/suscripciones", notificacionEntregaHandler.EliminarSuscripcionPushHandler).Methods("DELETE")
+	protectedRouter.HandleFunc("/notificaciones/plantillas", notificacionEntregaHandler.ListarPlantillasHandler).Methods("GET")
+	protectedRouter.HandleFunc("/notificaciones/plantillas", notificacionEntregaHandler.GuardarPlantillaHandler).Methods("PUT")
+	protectedRouter.HandleFunc("/notificaciones/plantillas/{id}", notificacionEntregaHandler.EliminarPlantillaHandler).Methods("DELETE")
+	protectedRouter.HandleFunc("/notificaciones/{id}/entregas", notificacionEntregaHandler.ListarEntregasHandler).Methods("GET")
+	// Despachador del controlador: reserva entregas pendientes e informa el resultado de cada envío
+	protectedRouter.HandleFunc("/notificaciones/entregas/reservar", notificacionEntregaHandler.ReservarEntregasHandler).Methods("POST")
+	protectedRouter.HandleFunc("/notificaciones/entregas/{id}/resultado", notificacionEntregaHandler.RegistrarResultadoHandler).Methods("POST")
+
+	// Outbox de eventos de dominio: dead-letter y reintento manual
+	protectedRouter.HandleFunc("/outbox/fallidos", outboxHandler.ListarFallidosHandler).Methods("GET")
+	protectedRouter.HandleFunc("/outbox/{id}/reintentar", outboxHandler.ReintentarHandler).Methods("POST")
+
 	// Endpoint interno para listar usuarios (persona + dirección)
 	protectedRouter.HandleFunc("/usuarios", personasHandler.ListarUsuariosHandler).Methods("GET")
 
@@ -263,6 +410,7 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	apiV1.HandleFunc("/tipo-plan", planesHandler.ListarTipoPlanes).Methods("GET")
 	apiV1.HandleFunc("/planes", planesHandler.ListarPlanes).Methods("GET")
 	apiV1.HandleFunc("/planes/{id}", planesHandler.ObtenerPlanPorID).Methods("GET")
+	apiV1.HandleFunc("/promociones", preciosHandler.ListarPromocionesVigentesHandler).Methods("GET")
 	// Rutas publicas de tipos de IVA
 	apiV1.HandleFunc("/tipo-iva", tipoIvaHandler.ListarTipoIva).Methods("GET")
 	apiV1.HandleFunc("/tipo-iva/{id}", tipoIvaHandler.ObtenerTipoIvaPorID).Methods("GET")
@@ -290,7 +438,7 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	apiV1.HandleFunc("/cargos/{id}", cargoHandler.ObtenerCargoPorID).Methods("GET")
 
 	// Endpoints internos para firma digital de contratos (protegidos)
-	protectedRouter.HandleFunc("/simular-pago/{id_persona}/{id_contrato}", contratoFirmaHandler.SimularPago).Methods("POST")
+	protectedRouter.HandleFunc("/simular-pago/{id_persona}/{id_contrato}", pagoHandler.SimularPagoHandler).Methods("POST")
 	protectedRouter.HandleFunc("/contrato-firma/{id}/firma", contratoFirmaHandler.GuardarFirma).Methods("POST")
 	protectedRouter.HandleFunc("/contrato-firma/{id}/validar-token", contratoFirmaHandler.ValidarToken).Methods("POST")
 	protectedRouter.HandleFunc("/contrato-firma/{id}", contratoFirmaHandler.ObtenerContrato).Methods("GET")
@@ -298,6 +446,48 @@ func SetupRutas(db *sql.DB, cfg config.AppConfig) *mux.Router {
 	protectedRouter.HandleFunc("/contrato-firma/{id}/reenvio-token", contratoFirmaHandler.ReenviarToken).Methods("POST")
 	protectedRouter.HandleFunc("/contrato-firma/{id}/pdf", contratoFirmaHandler.ServirPDF).Methods("GET")
 	protectedRouter.HandleFunc("/contrato-firma/{id}/descargar", contratoFirmaHandler.DescargarPDF).Methods("GET")
+	protectedRouter.HandleFunc("/contrato-firma/{id}/verificar-firma", contratoFirmaHandler.VerificarFirma).Methods("GET")
+	protectedRouter.HandleFunc("/verificar-contrato/{codigo}", contratoFirmaHandler.VerificarPorCodigo).Methods("GET")
+
+
+	// Endpoints internos de pagos (protegidos)
+	protectedRouter.HandleFunc("/pagos/contrato/{id_contrato}", pagoHandler.IniciarPagoHandler).Methods("POST")
+	protectedRouter.HandleFunc("/pagos/webhook", pagoHandler.WebhookHandler).Methods("POST")
+	protectedRouter.HandleFunc("/pagos/{id}", pagoHandler.ObtenerPagoHandler).Methods("GET")
 
 	return r
-}
\ No newline at end of file
+}
+
+// ConfigurarFirmaDigital arma el servicio de firma de contratos: renderizador del PDF y,
+// si hay certificado configurado, firma digital de la empresa
+func ConfigurarFirmaDigital(db *sql.DB, cfg config.AppConfig) *servicios.FirmaDigitalService {
+	contractBasePath := cfg.PDF.ContratosPath
+	var renderizadorPDF servicios.RenderizadorPDF = servicios.NewRenderizadorNativo()
+	if cfg.PDF.Renderizador == "wkhtmltopdf" {
+		wk, err := servicios.NewRenderizadorWkhtmltopdf(cfg.PDF.PlantillaPath)
+		if err != nil {
+			logger.Error.Fatalf("No se pudo inicializar el renderizador wkhtmltopdf: %v", err)
+		}
+		renderizadorPDF = wk
+	}
+	logger.Info.Printf("Renderizador de contratos PDF: %s", renderizadorPDF.Nombre())
+	var firmaEmpresa *servicios.FirmaEmpresa
+	if cfg.FirmaDigital.PKCS12Path != "" {
+		firmante, err := pdf.CargarFirmantePKCS12(cfg.FirmaDigital.PKCS12Path, cfg.FirmaDigital.PKCS12Password)
+		if err != nil {
+			logger.Error.Fatalf("No se pudo cargar el certificado de firma digital: %v", err)
+		}
+		cert := firmante.Certificado()
+		logger.Info.Printf("Firma digital de contratos con certificado %q (serie %X, vence %s)",
+			cert.Subject.CommonName, cert.SerialNumber, cert.NotAfter.Format("02/01/2006"))
+		firmaEmpresa = &servicios.FirmaEmpresa{
+			Firmante:  firmante,
+			Razon:     cfg.FirmaDigital.Razon,
+			Ubicacion: cfg.FirmaDigital.Ubicacion,
+		}
+	} else {
+		logger.Warn.Printf("FIRMA_PKCS12_PATH no configurado: los contratos firmados no llevarán firma digital")
+	}
+	pdfService := servicios.NewPDFService(servicios.NewFuenteDatosContratoBD(db), renderizadorPDF, firmaEmpresa, contractBasePath, cfg.PDF.VerificacionURL)
+	return servicios.NewFirmaDigitalService(db, pdfService, contractBasePath)
+}
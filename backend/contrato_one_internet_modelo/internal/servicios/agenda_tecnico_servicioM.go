@@ -0,0 +1,115 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// ObtenerAgenda devuelve las zonas y los horarios del técnico. El técnico sólo ve la suya.
+func (s *InstalacionService) ObtenerAgenda(ctx context.Context, idTecnico, idPersona int) (*modelos.AgendaTecnico, error) {
+	op, err := s.operador(ctx, s.db, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	if !op.gestiona && op.idUsuario != idTecnico {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+
+	repo := repositorios.NewAgendaTecnicoRepo(s.db)
+	agenda := &modelos.AgendaTecnico{IDTecnico: idTecnico}
+	if agenda.Zonas, err = repo.ListarZonas(ctx, idTecnico); err != nil {
+		return nil, err
+	}
+	if agenda.Franjas, err = repo.ListarFranjas(ctx, idTecnico); err != nil {
+		return nil, err
+	}
+	return agenda, nil
+}
+
+// validarAgenda normaliza y valida las zonas y franjas enviadas
+func validarAgenda(agenda *modelos.AgendaTecnico) error {
+	for i := range agenda.Zonas {
+		z := &agenda.Zonas[i]
+		z.Distrito = strings.TrimSpace(z.Distrito)
+		z.Departamento = strings.TrimSpace(z.Departamento)
+		if z.Distrito == "" || len(z.Distrito) > 150 {
+			return utilidades.ErrValidation{Campo: fmt.Sprintf("zonas[%d].distrito", i), Mensaje: "es requerido y no puede superar 150 caracteres"}
+		}
+		if z.Departamento == "" || len(z.Departamento) > 100 {
+			return utilidades.ErrValidation{Campo: fmt.Sprintf("zonas[%d].departamento", i), Mensaje: "es requerido y no puede superar 100 caracteres"}
+		}
+	}
+
+	for i, f := range agenda.Franjas {
+		if f.DiaSemana < 1 || f.DiaSemana > 7 {
+			return utilidades.ErrValidation{Campo: fmt.Sprintf("franjas[%d].dia_semana", i), Mensaje: "debe estar entre 1 (lunes) y 7 (domingo)"}
+		}
+		inicio, err := time.Parse("15:04", f.HoraInicio)
+		if err != nil {
+			return utilidades.ErrValidation{Campo: fmt.Sprintf("franjas[%d].hora_inicio", i), Mensaje: "debe tener formato HH:MM"}
+		}
+		fin, err := time.Parse("15:04", f.HoraFin)
+		if err != nil {
+			return utilidades.ErrValidation{Campo: fmt.Sprintf("franjas[%d].hora_fin", i), Mensaje: "debe tener formato HH:MM"}
+		}
+		if fin.Sub(inicio) < DuracionVentanaTurno {
+			return utilidades.ErrValidation{Campo: fmt.Sprintf("franjas[%d].hora_fin", i), Mensaje: fmt.Sprintf("la franja debe durar al menos %s", DuracionVentanaTurno)}
+		}
+	}
+	return nil
+}
+
+// ActualizarAgenda reemplaza las zonas y los horarios del técnico (instalacion.gestionar). Los turnos
+// ya reservados no se modifican.
+func (s *InstalacionService) ActualizarAgenda(
+	ctx context.Context,
+	idTecnico int,
+	agenda modelos.AgendaTecnico,
+	idPersona int,
+) (*modelos.AgendaTecnico, error) {
+	agenda.IDTecnico = idTecnico
+	if err := validarAgenda(&agenda); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Sólo quien gestiona las órdenes carga agendas, y sólo de usuarios con rol técnico
+	op, err := s.operador(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	if !op.gestiona {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+	roles, err := repositorios.NewUsuarioRolRepo(tx).ObtenerRolesPorUsuario(ctx, idTecnico)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo roles del usuario %d: %w", idTecnico, err)
+	}
+	if !tieneRol(roles, modelos.RolTecnico) {
+		return nil, utilidades.ErrValidation{Campo: "id_tecnico", Mensaje: "no corresponde a un usuario con rol técnico"}
+	}
+
+	// 2. Reemplazo completo
+	if err := repositorios.NewAgendaTecnicoRepo(tx).ReemplazarAgenda(ctx, &agenda); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Agenda del técnico %d actualizada: %d zona(s), %d franja(s)", idTecnico, len(agenda.Zonas), len(agenda.Franjas))
+	return s.ObtenerAgenda(ctx, idTecnico, idPersona)
+}
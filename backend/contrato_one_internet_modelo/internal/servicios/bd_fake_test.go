@@ -0,0 +1,172 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("test")
+	os.Exit(m.Run())
+}
+
+// respuestaFake es la respuesta de la base fake a las sentencias que contienen un fragmento
+type respuestaFake struct {
+	contiene string
+	columnas []string
+	filas    [][]driver.Value
+	err      error
+}
+
+// bdFake es una base de datos guionada para los tests de servicios: las consultas devuelven
+// la primera respuesta cuyo fragmento contienen (o ninguna fila), y las sentencias de
+// escritura se registran para verificarlas.
+type bdFake struct {
+	mu         sync.Mutex
+	respuestas []respuestaFake
+	ejecutadas []string
+	argumentos [][]driver.NamedValue
+	ultimoID   int64
+	commits    int
+	rollbacks  int
+}
+
+// nuevaBDFake abre un *sql.DB sobre la base fake
+func nuevaBDFake(t *testing.T, respuestas ...respuestaFake) (*sql.DB, *bdFake) {
+	t.Helper()
+	bd := &bdFake{respuestas: respuestas}
+	db := sql.OpenDB(bd)
+	t.Cleanup(func() { db.Close() })
+	return db, bd
+}
+
+// responder agrega una respuesta con prioridad sobre las anteriores
+func (bd *bdFake) responder(r respuestaFake) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	bd.respuestas = append([]respuestaFake{r}, bd.respuestas...)
+}
+
+// contarEjecutadas devuelve cuántas sentencias de escritura contienen el fragmento
+func (bd *bdFake) contarEjecutadas(fragmento string) int {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	n := 0
+	for _, q := range bd.ejecutadas {
+		if strings.Contains(q, fragmento) {
+			n++
+		}
+	}
+	return n
+}
+
+// argumentosDe devuelve los argumentos de la última sentencia de escritura que contiene el
+// fragmento, o nil si no hubo ninguna
+func (bd *bdFake) argumentosDe(fragmento string) []driver.Value {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	for i := len(bd.ejecutadas) - 1; i >= 0; i-- {
+		if strings.Contains(bd.ejecutadas[i], fragmento) {
+			valores := make([]driver.Value, len(bd.argumentos[i]))
+			for j, a := range bd.argumentos[i] {
+				valores[j] = a.Value
+			}
+			return valores
+		}
+	}
+	return nil
+}
+
+func (bd *bdFake) buscar(query string) (respuestaFake, bool) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	for _, r := range bd.respuestas {
+		if strings.Contains(query, r.contiene) {
+			return r, true
+		}
+	}
+	return respuestaFake{}, false
+}
+
+func (bd *bdFake) Connect(context.Context) (driver.Conn, error) { return &conexionFake{bd: bd}, nil }
+func (bd *bdFake) Driver() driver.Driver                        { return driverFake{} }
+
+type driverFake struct{}
+
+func (driverFake) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type conexionFake struct{ bd *bdFake }
+
+func (c *conexionFake) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *conexionFake) Close() error                        { return nil }
+func (c *conexionFake) Begin() (driver.Tx, error)           { return txFake{bd: c.bd}, nil }
+
+func (c *conexionFake) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if r, ok := c.bd.buscar(query); ok && r.err != nil {
+		return nil, r.err
+	}
+	c.bd.mu.Lock()
+	defer c.bd.mu.Unlock()
+	c.bd.ejecutadas = append(c.bd.ejecutadas, query)
+	c.bd.argumentos = append(c.bd.argumentos, args)
+	c.bd.ultimoID++
+	return resultadoFake(c.bd.ultimoID), nil
+}
+
+func (c *conexionFake) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	r, ok := c.bd.buscar(query)
+	if !ok {
+		return &filasFake{}, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &filasFake{columnas: r.columnas, filas: r.filas}, nil
+}
+
+type txFake struct{ bd *bdFake }
+
+func (t txFake) Commit() error {
+	t.bd.mu.Lock()
+	defer t.bd.mu.Unlock()
+	t.bd.commits++
+	return nil
+}
+
+func (t txFake) Rollback() error {
+	t.bd.mu.Lock()
+	defer t.bd.mu.Unlock()
+	t.bd.rollbacks++
+	return nil
+}
+
+type resultadoFake int64
+
+func (r resultadoFake) LastInsertId() (int64, error) { return int64(r), nil }
+func (r resultadoFake) RowsAffected() (int64, error) { return 1, nil }
+
+type filasFake struct {
+	columnas []string
+	filas    [][]driver.Value
+	i        int
+}
+
+func (f *filasFake) Columns() []string { return f.columnas }
+func (f *filasFake) Close() error      { return nil }
+
+func (f *filasFake) Next(dest []driver.Value) error {
+	if f.i >= len(f.filas) {
+		return io.EOF
+	}
+	copy(dest, f.filas[f.i])
+	f.i++
+	return nil
+}
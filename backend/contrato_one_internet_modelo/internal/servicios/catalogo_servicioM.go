@@ -0,0 +1,257 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// filaCatalogo es una fila de catálogo que el código busca por nombre
+type filaCatalogo struct {
+	tabla       string
+	columnaID   string
+	columna     string // columna con el nombre
+	nombre      string
+	descripcion string // vacío si la tabla no tiene descripción
+}
+
+func (f filaCatalogo) String() string {
+	return fmt.Sprintf("%s '%s'", f.tabla, f.nombre)
+}
+
+// catalogosRequeridos son las filas sin las cuales algún flujo falla a mitad de la solicitud
+var catalogosRequeridos = []filaCatalogo{
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionEnVerificacion, "Solicitud del cliente a la espera de verificación"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionPendienteVerificacionTecnica, "Derivada por atención al verificador técnico"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionFactible, "Verificada: es posible realizar la instalación"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionNoFactible, "Verificada: no es posible realizar la instalación"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionPorConfigurar, "Contrato firmado, pendiente de configuración técnica"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionActiva, "Instalada y prestando servicio"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionSuspendida, "Servicio interrumpido temporalmente"},
+	{"estado_conexion", "id_estado_conexion", "nombre", modelos.EstadoConexionBaja, "Servicio dado de baja definitivamente"},
+
+	{"estado_contrato", "id_estado_contrato", "nombre", modelos.EstadoContratoEnVerificacion, "La conexión asociada está en verificación"},
+	{"estado_contrato", "id_estado_contrato", "nombre", modelos.EstadoContratoPendientePago, "Conexión factible, a la espera del pago de instalación"},
+	{"estado_contrato", "id_estado_contrato", "nombre", modelos.EstadoContratoNoFactible, "La conexión asociada no es factible"},
+	{"estado_contrato", "id_estado_contrato", "nombre", modelos.EstadoContratoVigente, "Contrato firmado por el cliente"},
+	{"estado_contrato", "id_estado_contrato", "nombre", modelos.EstadoContratoSuspendido, "Contrato suspendido temporalmente"},
+	{"estado_contrato", "id_estado_contrato", "nombre", modelos.EstadoContratoBaja, "Contrato rescindido"},
+
+	{"rol", "id_rol", "nombre", modelos.RolAdmin, "Administrador del sistema"},
+	{"rol", "id_rol", "nombre", modelos.RolVerificador, "Verifica la factibilidad técnica de las solicitudes"},
+	{"rol", "id_rol", "nombre", modelos.RolAtencion, "Atención al cliente: carga solicitudes en nombre de clientes"},
+	{"rol", "id_rol", "nombre", modelos.RolTecnico, "Configura e instala las conexiones"},
+	{"rol", "id_rol", "nombre", modelos.RolCliente, "Cliente registrado desde la web"},
+
+	{"vinculo", "id_vinculo", "nombre_vinculo", modelos.VinculoCliente, "Cliente particular de la empresa operadora"},
+
+	{"tipo_iva", "id_tipo_iva", "tipo_iva", modelos.TipoIVAResponsableInscripto, ""},
+	{"tipo_iva", "id_tipo_iva", "tipo_iva", modelos.TipoIVAMonotributista, ""},
+	{"tipo_iva", "id_tipo_iva", "tipo_iva", modelos.TipoIVAExento, ""},
+	{"tipo_iva", "id_tipo_iva", "tipo_iva", modelos.TipoIVAConsumidorFinal, ""},
+
+	{"tipo_empresa", "id_tipo_empresa", "nombre", modelos.TipoEmpresaSociedadAnonima, ""},
+}
+
+// permisoRequerido es un permiso que exige el controlador, con los roles que lo reciben al
+// crearlo. Después la asignación se administra desde /roles/{id}/permisos y la siembra no la
+// vuelve a tocar.
+type permisoRequerido struct {
+	nombre      string
+	descripcion string
+	roles       []string
+}
+
+func (p permisoRequerido) fila() filaCatalogo {
+	return filaCatalogo{"permiso", "id_permiso", "nombre", p.nombre, p.descripcion}
+}
+
+// permisosRequeridos arrancan con los roles que tenían fijos las rutas del controlador
+var permisosRequeridos = []permisoRequerido{
+	{modelos.PermisoConexionVerSolicitudes, "Ver las solicitudes de conexión pendientes y su detalle", []string{modelos.RolAdmin, modelos.RolVerificador}},
+	{modelos.PermisoConexionConfirmarFactibilidad, "Confirmar la factibilidad técnica de una solicitud", []string{modelos.RolAdmin, modelos.RolVerificador}},
+	{modelos.PermisoConexionRechazarFactibilidad, "Rechazar una solicitud por no factible", []string{modelos.RolAdmin, modelos.RolVerificador}},
+	{modelos.PermisoConexionCambiarEstado, "Cambiar a mano el estado de una conexión", []string{modelos.RolAdmin}},
+	{modelos.PermisoContratoCambiarEstado, "Cambiar a mano el estado de un contrato", []string{modelos.RolAdmin}},
+	{modelos.PermisoConexionVerHistorial, "Ver el historial de estados de una conexión", []string{modelos.RolAdmin, modelos.RolVerificador, modelos.RolAtencion, modelos.RolTecnico}},
+	{modelos.PermisoUsuariosVer, "Listar usuarios y ver su perfil", []string{modelos.RolAdmin, modelos.RolAtencion}},
+	{modelos.PermisoUsuariosCrear, "Dar de alta personas con usuario en nombre de clientes", []string{modelos.RolAdmin, modelos.RolAtencion}},
+	{modelos.PermisoUsuariosRestablecer2FA, "Quitar la autenticación en dos pasos de otro usuario", []string{modelos.RolAdmin}},
+	{modelos.PermisoUsuariosCerrarSesiones, "Cerrar todas las sesiones de otro usuario", []string{modelos.RolAdmin}},
+	{modelos.PermisoInstalacionOperar, "Ver, tomar, programar y completar órdenes de instalación propias o sin asignar, y ver la agenda propia", []string{modelos.RolAdmin, modelos.RolTecnico}},
+	{modelos.PermisoInstalacionGestionar, "Operar cualquier orden de instalación, cancelarlas y cargar la agenda de los técnicos", []string{modelos.RolAdmin}},
+	{modelos.PermisoFacturacionEmitir, "Emitir a mano la facturación de un período", []string{modelos.RolAdmin}},
+	{modelos.PermisoCuentasVer, "Ver la cuenta corriente de cualquier cliente y el listado de deudores", []string{modelos.RolAdmin}},
+	{modelos.PermisoCuentasGestionar, "Registrar pagos y notas de crédito y correr la revisión de cuentas", []string{modelos.RolAdmin}},
+	{modelos.PermisoPlanesEditar, "Crear, modificar y borrar planes", []string{modelos.RolAdmin}},
+	{modelos.PermisoPreciosGestionar, "Ajustar precios de planes, ver su historial y administrar promociones", []string{modelos.RolAdmin}},
+	{modelos.PermisoCatalogosEditar, "Crear, modificar y borrar tipos de empresa, condiciones de IVA, estados, cargos y vínculos", []string{modelos.RolAdmin}},
+	{modelos.PermisoDireccionesGestionar, "Administrar el padrón de direcciones", []string{modelos.RolAdmin}},
+	{modelos.PermisoNotificacionesPlantillas, "Administrar las plantillas de notificación", []string{modelos.RolAdmin}},
+	{modelos.PermisoOutboxAdministrar, "Ver los eventos fallidos del outbox y reintentarlos", []string{modelos.RolAdmin}},
+	{modelos.PermisoLoginVerIntentos, "Ver los intentos de login y los bloqueos vigentes", []string{modelos.RolAdmin}},
+	{modelos.PermisoLoginLevantarBloqueos, "Levantar el bloqueo de login de una cuenta o IP", []string{modelos.RolAdmin}},
+	{modelos.PermisoRolesEditar, "Administrar los roles y el catálogo de permisos", []string{modelos.RolAdmin}},
+	{modelos.PermisoRolesPermisos, "Asignar y quitar permisos a los roles", []string{modelos.RolAdmin}},
+}
+
+// CatalogoService crea y verifica las filas de catálogo requeridas
+type CatalogoService struct {
+	db *sql.DB
+}
+
+func NewCatalogoService(db *sql.DB) *CatalogoService {
+	return &CatalogoService{db: db}
+}
+
+// Sembrar crea (o reactiva si estaban borradas) las filas requeridas que falten,
+// incluida la empresa operadora. Es idempotente: devuelve sólo lo que cambió.
+func (s *CatalogoService) Sembrar(ctx context.Context) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var cambios []string
+
+	// 1. Estados, roles, vínculos y catálogos
+	for _, f := range catalogosRequeridos {
+		cambio, err := sembrarFila(ctx, tx, f)
+		if err != nil {
+			return nil, fmt.Errorf("error sembrando %s: %w", f, err)
+		}
+		if cambio != "" {
+			cambios = append(cambios, cambio)
+		}
+	}
+
+	// 2. Permisos (dependen de los roles): los nuevos reciben su asignación inicial
+	for _, p := range permisosRequeridos {
+		cambio, err := sembrarFila(ctx, tx, p.fila())
+		if err != nil {
+			return nil, fmt.Errorf("error sembrando %s: %w", p.fila(), err)
+		}
+		if strings.HasPrefix(cambio, "creado") {
+			if err := repositorios.NewRolPermisoRepo(tx).AsignarPorNombre(ctx, p.nombre, p.roles); err != nil {
+				return nil, err
+			}
+			cambio += fmt.Sprintf(" para %s", strings.Join(p.roles, ", "))
+		}
+		if cambio != "" {
+			cambios = append(cambios, cambio)
+		}
+	}
+
+	// 3. Empresa operadora (depende de tipo_empresa y tipo_iva)
+	cambio, err := sembrarEmpresaOperadora(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error sembrando empresa operadora: %w", err)
+	}
+	if cambio != "" {
+		cambios = append(cambios, cambio)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return cambios, nil
+}
+
+// VerificarRequeridos devuelve las filas requeridas que no existen (o están borradas)
+func (s *CatalogoService) VerificarRequeridos(ctx context.Context) ([]string, error) {
+	filas := append([]filaCatalogo{}, catalogosRequeridos...)
+	for _, p := range permisosRequeridos {
+		filas = append(filas, p.fila())
+	}
+
+	var faltantes []string
+	for _, f := range filas {
+		var cnt int
+		query := `SELECT COUNT(1) FROM ` + f.tabla + ` WHERE ` + f.columna + ` = ? AND borrado IS NULL`
+		if err := s.db.QueryRowContext(ctx, query, f.nombre).Scan(&cnt); err != nil {
+			return nil, fmt.Errorf("error verificando %s: %w", f, err)
+		}
+		if cnt == 0 {
+			faltantes = append(faltantes, f.String())
+		}
+	}
+
+	_, err := repositorios.NewPersonaVinculoEmpresaRepo(s.db).ObtenerIDEmpresaPorNombre(ctx, modelos.EmpresaOperadoraNombre)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if !errors.As(err, &noEncontrado) {
+			return nil, err
+		}
+		faltantes = append(faltantes, fmt.Sprintf("empresa '%s'", modelos.EmpresaOperadoraNombre))
+	}
+	return faltantes, nil
+}
+
+// sembrarFila inserta la fila si no existe o la reactiva si está borrada
+func sembrarFila(ctx context.Context, tx *sql.Tx, f filaCatalogo) (string, error) {
+	var id int
+	var borrado sql.NullTime
+	query := `SELECT ` + f.columnaID + `, borrado FROM ` + f.tabla + ` WHERE ` + f.columna + ` = ? LIMIT 1`
+	err := tx.QueryRowContext(ctx, query, f.nombre).Scan(&id, &borrado)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if f.descripcion == "" {
+			_, err = tx.ExecContext(ctx, `INSERT INTO `+f.tabla+` (`+f.columna+`) VALUES (?)`, f.nombre)
+		} else {
+			_, err = tx.ExecContext(ctx, `INSERT INTO `+f.tabla+` (`+f.columna+`, descripcion) VALUES (?, ?)`, f.nombre, f.descripcion)
+		}
+		if err != nil {
+			return "", err
+		}
+		return "creado " + f.String(), nil
+	case err != nil:
+		return "", err
+	case borrado.Valid:
+		if _, err := tx.ExecContext(ctx, `UPDATE `+f.tabla+` SET borrado = NULL WHERE `+f.columnaID+` = ?`, id); err != nil {
+			return "", err
+		}
+		return "reactivado " + f.String(), nil
+	}
+	return "", nil
+}
+
+// sembrarEmpresaOperadora crea la empresa con la que se celebran los contratos de particulares
+func sembrarEmpresaOperadora(ctx context.Context, tx *sql.Tx) (string, error) {
+	var id int
+	err := tx.QueryRowContext(ctx,
+		`SELECT id_empresa FROM empresa WHERE nombre_comercial = ? AND borrado IS NULL LIMIT 1`,
+		modelos.EmpresaOperadoraNombre).Scan(&id)
+	if err == nil {
+		return "", nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO empresa (nombre_comercial, razon_social, cuit, id_tipo_empresa, id_tipo_iva,
+			provincia_nombre, departamento_nombre, telefono)
+		SELECT ?, ?, ?, te.id_tipo_empresa, ti.id_tipo_iva, 'Mendoza', 'Rivadavia', '2634 445162'
+		FROM tipo_empresa te, tipo_iva ti
+		WHERE te.nombre = ? AND ti.tipo_iva = ?
+		LIMIT 1
+	`
+	res, err := tx.ExecContext(ctx, query,
+		modelos.EmpresaOperadoraNombre, modelos.EmpresaOperadoraRazonSocial, modelos.EmpresaOperadoraCUIT,
+		modelos.TipoEmpresaSociedadAnonima, modelos.TipoIVAResponsableInscripto)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", errors.New("no se encontraron el tipo de empresa y la condición de IVA de la operadora")
+	}
+	return fmt.Sprintf("creado empresa '%s'", modelos.EmpresaOperadoraNombre), nil
+}
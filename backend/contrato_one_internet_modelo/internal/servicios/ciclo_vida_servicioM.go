@@ -0,0 +1,541 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// Ciclo de vida de conexiones y contratos.
+//
+// Conexión: En verificacion → Factible → Por configurar → Activa ⇄ Suspendida → Baja
+// Contrato: En verificacion → Pendiente de pago → Vigente ⇄ Suspendido → Baja
+//
+// Al pasar a "Por configurar" se crea la orden de instalación; completarla pasa la conexión
+// a "Activa" y la baja cancela las órdenes abiertas. La revisión de deuda de la cuenta
+// corriente suspende y restablece conexiones con las mismas transiciones.
+//
+// Cada cambio de estado pasa por CicloVida: valida que la transición esté declarada,
+// evalúa su guarda, actualiza el estado, lo registra en el historial y aplica los efectos
+// (que pueden mover la otra entidad), todo en la transacción del llamador.
+
+// ActorTransicion identifica al usuario que provoca un cambio de estado. Sin usuario el
+// cambio lo hace el sistema (webhooks, tareas programadas).
+type ActorTransicion struct {
+	IDUsuario *int
+}
+
+// ActorSistema es el actor de los cambios que no provoca ningún usuario
+var ActorSistema = ActorTransicion{}
+
+// ActorUsuario crea el actor para un usuario conocido
+func ActorUsuario(idUsuario int) ActorTransicion {
+	if idUsuario <= 0 {
+		return ActorSistema
+	}
+	return ActorTransicion{IDUsuario: &idUsuario}
+}
+
+// ActorPersona resuelve el usuario de una persona. Una persona sin usuario (o id 0, cuando
+// el pedido no trae X-ID-Persona) queda registrada como el sistema.
+func ActorPersona(ctx context.Context, db repositorios.Execer, idPersona int) (ActorTransicion, error) {
+	if idPersona <= 0 {
+		return ActorSistema, nil
+	}
+	idUsuario, err := repositorios.NewUsuarioRepo(db).ObtenerIDUsuarioPorPersona(ctx, idPersona)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return ActorSistema, nil
+		}
+		return ActorSistema, err
+	}
+	return ActorUsuario(idUsuario), nil
+}
+
+// guardaTransicion devuelve un motivo no vacío si la transición no puede hacerse
+type guardaTransicion func(ctx context.Context, c *CicloVida, id int) (string, error)
+
+// efectoTransicion se ejecuta después de actualizar el estado, en la misma transacción
+type efectoTransicion func(ctx context.Context, c *CicloVida, id int, actor ActorTransicion) error
+
+type transicion struct {
+	desde  []string
+	hacia  string
+	guarda guardaTransicion
+	efecto efectoTransicion
+}
+
+type maquinaEstados struct {
+	entidad      repositorios.EntidadConEstado
+	transiciones []transicion
+}
+
+// buscar devuelve la transición declarada entre dos estados, o nil
+func (m *maquinaEstados) buscar(desde, hacia string) *transicion {
+	for i := range m.transiciones {
+		t := &m.transiciones[i]
+		if t.hacia != hacia {
+			continue
+		}
+		for _, d := range t.desde {
+			if d == desde {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// conoce indica si el estado es destino de alguna transición
+func (m *maquinaEstados) conoce(estado string) bool {
+	for _, t := range m.transiciones {
+		if t.hacia == estado {
+			return true
+		}
+	}
+	return false
+}
+
+// Las máquinas se arman en init: sus efectos llaman a CicloVida, que a su vez las usa
+var maquinaConexion, maquinaContrato *maquinaEstados
+
+func init() {
+	maquinaConexion = &maquinaEstados{
+		entidad: repositorios.EntidadConexion,
+		transiciones: []transicion{
+			{
+				desde: []string{modelos.EstadoConexionEnVerificacion},
+				hacia: modelos.EstadoConexionPendienteVerificacionTecnica,
+			},
+			{
+				desde:  []string{modelos.EstadoConexionEnVerificacion, modelos.EstadoConexionPendienteVerificacionTecnica},
+				hacia:  modelos.EstadoConexionFactible,
+				efecto: contratosDeConexionA([]string{modelos.EstadoContratoEnVerificacion}, modelos.EstadoContratoPendientePago),
+			},
+			{
+				desde:  []string{modelos.EstadoConexionEnVerificacion, modelos.EstadoConexionPendienteVerificacionTecnica},
+				hacia:  modelos.EstadoConexionNoFactible,
+				efecto: contratosDeConexionA([]string{modelos.EstadoContratoEnVerificacion}, modelos.EstadoContratoNoFactible),
+			},
+			{
+				desde:  []string{modelos.EstadoConexionFactible},
+				hacia:  modelos.EstadoConexionPorConfigurar,
+				guarda: conexionConContratoVigente,
+				efecto: crearOrdenInstalacion,
+			},
+			{
+				desde:  []string{modelos.EstadoConexionPorConfigurar},
+				hacia:  modelos.EstadoConexionActiva,
+				guarda: conexionConDatosTecnicos,
+				efecto: func(ctx context.Context, c *CicloVida, id int, _ ActorTransicion) error {
+					return c.repo.MarcarInstalada(ctx, id)
+				},
+			},
+			{
+				desde: []string{modelos.EstadoConexionActiva},
+				hacia: modelos.EstadoConexionSuspendida,
+			},
+			{
+				desde:  []string{modelos.EstadoConexionSuspendida},
+				hacia:  modelos.EstadoConexionActiva,
+				guarda: conexionSinContratoSuspendido,
+				efecto: func(ctx context.Context, c *CicloVida, id int, _ ActorTransicion) error {
+					return c.repo.LimpiarSuspensionPorDeuda(ctx, id)
+				},
+			},
+			{
+				desde: []string{modelos.EstadoConexionFactible, modelos.EstadoConexionPorConfigurar,
+					modelos.EstadoConexionActiva, modelos.EstadoConexionSuspendida},
+				hacia: modelos.EstadoConexionBaja,
+				efecto: func(ctx context.Context, c *CicloVida, id int, actor ActorTransicion) error {
+					if err := c.repo.MarcarBajaConexion(ctx, id); err != nil {
+						return err
+					}
+					if _, err := c.ordenes.CancelarAbiertas(ctx, id, "Baja de la conexión"); err != nil {
+						return err
+					}
+					return contratosDeConexionA([]string{modelos.EstadoContratoPendientePago,
+						modelos.EstadoContratoVigente, modelos.EstadoContratoSuspendido}, modelos.EstadoContratoBaja)(ctx, c, id, actor)
+				},
+			},
+		},
+	}
+
+	maquinaContrato = &maquinaEstados{
+		entidad: repositorios.EntidadContrato,
+		transiciones: []transicion{
+			{
+				desde: []string{modelos.EstadoContratoEnVerificacion},
+				hacia: modelos.EstadoContratoPendientePago,
+			},
+			{
+				desde: []string{modelos.EstadoContratoEnVerificacion},
+				hacia: modelos.EstadoContratoNoFactible,
+			},
+			{
+				desde:  []string{modelos.EstadoContratoPendientePago},
+				hacia:  modelos.EstadoContratoVigente,
+				guarda: contratoFirmado,
+				efecto: conexionDeContratoA([]string{modelos.EstadoConexionFactible}, modelos.EstadoConexionPorConfigurar),
+			},
+			{
+				desde:  []string{modelos.EstadoContratoVigente},
+				hacia:  modelos.EstadoContratoSuspendido,
+				efecto: conexionDeContratoA([]string{modelos.EstadoConexionActiva}, modelos.EstadoConexionSuspendida),
+			},
+			{
+				desde:  []string{modelos.EstadoContratoSuspendido},
+				hacia:  modelos.EstadoContratoVigente,
+				efecto: conexionDeContratoA([]string{modelos.EstadoConexionSuspendida}, modelos.EstadoConexionActiva),
+			},
+			{
+				desde: []string{modelos.EstadoContratoPendientePago, modelos.EstadoContratoVigente, modelos.EstadoContratoSuspendido},
+				hacia: modelos.EstadoContratoBaja,
+				efecto: func(ctx context.Context, c *CicloVida, id int, actor ActorTransicion) error {
+					if err := c.repo.MarcarFinContrato(ctx, id); err != nil {
+						return err
+					}
+					return conexionDeContratoA([]string{modelos.EstadoConexionFactible, modelos.EstadoConexionPorConfigurar,
+						modelos.EstadoConexionActiva, modelos.EstadoConexionSuspendida}, modelos.EstadoConexionBaja)(ctx, c, id, actor)
+				},
+			},
+		},
+	}
+}
+
+// === Guardas ===
+
+func conexionConContratoVigente(ctx context.Context, c *CicloVida, id int) (string, error) {
+	ok, err := c.repo.ConexionConContratoEnEstado(ctx, id, modelos.EstadoContratoVigente)
+	if err != nil || ok {
+		return "", err
+	}
+	return "el contrato de la conexión no está vigente", nil
+}
+
+func conexionConDatosTecnicos(ctx context.Context, c *CicloVida, id int) (string, error) {
+	ok, err := c.repo.ConexionConDatosTecnicos(ctx, id)
+	if err != nil || ok {
+		return "", err
+	}
+	return "la conexión no tiene NAP y VLAN asignados", nil
+}
+
+func conexionSinContratoSuspendido(ctx context.Context, c *CicloVida, id int) (string, error) {
+	suspendido, err := c.repo.ConexionConContratoEnEstado(ctx, id, modelos.EstadoContratoSuspendido)
+	if err != nil || !suspendido {
+		return "", err
+	}
+	return "el contrato de la conexión está suspendido; se reactiva desde el contrato", nil
+}
+
+func contratoFirmado(ctx context.Context, c *CicloVida, id int) (string, error) {
+	ok, err := c.repo.ContratoFirmado(ctx, id)
+	if err != nil || ok {
+		return "", err
+	}
+	return "el contrato no está firmado", nil
+}
+
+// === Efectos en cascada ===
+
+// crearOrdenInstalacion deja la orden pendiente para que un técnico instale la conexión
+func crearOrdenInstalacion(ctx context.Context, c *CicloVida, idConexion int, _ ActorTransicion) error {
+	idOrden, creada, err := c.ordenes.CrearPendiente(ctx, idConexion)
+	if err != nil {
+		return fmt.Errorf("error creando orden de instalación de la conexión %d: %w", idConexion, err)
+	}
+	if creada {
+		logger.Info.Printf("Orden de instalación %d creada para la conexión %d", idOrden, idConexion)
+	}
+	return nil
+}
+
+// contratosDeConexionA mueve a hacia los contratos de la conexión que estén en alguno de los estados desde
+func contratosDeConexionA(desde []string, hacia string) efectoTransicion {
+	return func(ctx context.Context, c *CicloVida, idConexion int, actor ActorTransicion) error {
+		ids, err := c.repo.ObtenerIDsContratosPorConexion(ctx, idConexion)
+		if err != nil {
+			return err
+		}
+		obs := fmt.Sprintf("Por cambio de estado de la conexión %d", idConexion)
+		for _, idContrato := range ids {
+			if _, err := c.transicionarSiEsta(ctx, maquinaContrato, idContrato, desde, hacia, actor, &obs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// conexionDeContratoA mueve a hacia la conexión del contrato si está en alguno de los estados desde
+func conexionDeContratoA(desde []string, hacia string) efectoTransicion {
+	return func(ctx context.Context, c *CicloVida, idContrato int, actor ActorTransicion) error {
+		idConexion, err := c.contratos.ObtenerIDConexionPorContrato(ctx, idContrato)
+		if err != nil {
+			return err
+		}
+		obs := fmt.Sprintf("Por cambio de estado del contrato %d", idContrato)
+		_, err = c.transicionarSiEsta(ctx, maquinaConexion, idConexion, desde, hacia, actor, &obs)
+		return err
+	}
+}
+
+// CicloVida aplica transiciones dentro de una transacción abierta por el llamador
+type CicloVida struct {
+	repo      *repositorios.EstadoHistorialRepo
+	contratos *repositorios.ContratoRepo
+	ordenes   *repositorios.OrdenInstalacionRepo
+}
+
+// NewCicloVida recibe la transacción en la que se harán los cambios de estado
+func NewCicloVida(tx repositorios.Execer) *CicloVida {
+	return &CicloVida{
+		repo:      repositorios.NewEstadoHistorialRepo(tx),
+		contratos: repositorios.NewContratoRepo(tx),
+		ordenes:   repositorios.NewOrdenInstalacionRepo(tx),
+	}
+}
+
+// TransicionarConexion mueve la conexión a hacia y devuelve el estado que tenía
+func (c *CicloVida) TransicionarConexion(ctx context.Context, id int, hacia string, actor ActorTransicion, observacion *string) (string, error) {
+	return c.transicionar(ctx, maquinaConexion, id, hacia, actor, observacion)
+}
+
+// TransicionarContrato mueve el contrato a hacia y devuelve el estado que tenía
+func (c *CicloVida) TransicionarContrato(ctx context.Context, id int, hacia string, actor ActorTransicion, observacion *string) (string, error) {
+	return c.transicionar(ctx, maquinaContrato, id, hacia, actor, observacion)
+}
+
+// RegistrarInicialConexion anota en el historial el estado con el que se creó la conexión
+func (c *CicloVida) RegistrarInicialConexion(ctx context.Context, id int, actor ActorTransicion, observacion *string) error {
+	return c.registrarInicial(ctx, maquinaConexion, id, actor, observacion)
+}
+
+// RegistrarInicialContrato anota en el historial el estado con el que se creó el contrato
+func (c *CicloVida) RegistrarInicialContrato(ctx context.Context, id int, actor ActorTransicion, observacion *string) error {
+	return c.registrarInicial(ctx, maquinaContrato, id, actor, observacion)
+}
+
+func (c *CicloVida) transicionar(
+	ctx context.Context,
+	m *maquinaEstados,
+	id int,
+	hacia string,
+	actor ActorTransicion,
+	observacion *string,
+) (string, error) {
+	if !m.conoce(hacia) {
+		return "", utilidades.ErrValidation{
+			Campo:   "estado",
+			Mensaje: fmt.Sprintf("'%s' no es un estado de %s al que se pueda pasar", hacia, m.entidad.Nombre),
+		}
+	}
+
+	// 1. Estado actual (bloquea la fila hasta el fin de la transacción)
+	idDesde, desde, err := c.repo.ObtenerEstadoParaActualizar(ctx, m.entidad, id)
+	if err != nil {
+		return "", err
+	}
+
+	// 2. La transición tiene que estar declarada
+	t := m.buscar(desde, hacia)
+	if t == nil {
+		return desde, utilidades.ErrTransicionInvalida{Entidad: m.entidad.Nombre, ID: id, Desde: desde, Hacia: hacia}
+	}
+
+	// 3. Guarda
+	if t.guarda != nil {
+		motivo, err := t.guarda(ctx, c, id)
+		if err != nil {
+			return desde, err
+		}
+		if motivo != "" {
+			return desde, utilidades.ErrTransicionInvalida{Entidad: m.entidad.Nombre, ID: id, Desde: desde, Hacia: hacia, Motivo: motivo}
+		}
+	}
+
+	// 4. Nuevo estado
+	idHacia, err := c.repo.ObtenerIDEstado(ctx, m.entidad, hacia)
+	if err != nil {
+		return desde, err
+	}
+	if err := c.repo.ActualizarEstado(ctx, m.entidad, id, idHacia); err != nil {
+		return desde, err
+	}
+
+	// 5. Historial
+	cambio := &modelos.CambioEstado{
+		IDEntidad:        id,
+		IDEstadoAnterior: &idDesde,
+		IDEstadoNuevo:    idHacia,
+		IDUsuario:        actor.IDUsuario,
+		Observacion:      observacion,
+	}
+	if err := c.repo.Registrar(ctx, m.entidad, cambio); err != nil {
+		return desde, fmt.Errorf("error registrando historial de %s %d: %w", m.entidad.Nombre, id, err)
+	}
+
+	// 6. Efectos
+	if t.efecto != nil {
+		if err := t.efecto(ctx, c, id, actor); err != nil {
+			return desde, err
+		}
+	}
+
+	logger.Debug.Printf("%s %d: '%s' → '%s'", m.entidad.Nombre, id, desde, hacia)
+	return desde, nil
+}
+
+// transicionarSiEsta aplica la transición sólo si la entidad está en alguno de los estados
+// desde e indica si la aplicó
+func (c *CicloVida) transicionarSiEsta(
+	ctx context.Context,
+	m *maquinaEstados,
+	id int,
+	desde []string,
+	hacia string,
+	actor ActorTransicion,
+	observacion *string,
+) (bool, error) {
+	_, actual, err := c.repo.ObtenerEstadoParaActualizar(ctx, m.entidad, id)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range desde {
+		if d == actual {
+			if _, err := c.transicionar(ctx, m, id, hacia, actor, observacion); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *CicloVida) registrarInicial(ctx context.Context, m *maquinaEstados, id int, actor ActorTransicion, observacion *string) error {
+	idEstado, _, err := c.repo.ObtenerEstadoParaActualizar(ctx, m.entidad, id)
+	if err != nil {
+		return err
+	}
+	cambio := &modelos.CambioEstado{
+		IDEntidad:     id,
+		IDEstadoNuevo: idEstado,
+		IDUsuario:     actor.IDUsuario,
+		Observacion:   observacion,
+	}
+	if err := c.repo.Registrar(ctx, m.entidad, cambio); err != nil {
+		return fmt.Errorf("error registrando historial de %s %d: %w", m.entidad.Nombre, id, err)
+	}
+	return nil
+}
+
+// CicloVidaService expone los cambios de estado manuales de los operadores
+type CicloVidaService struct {
+	db *sql.DB
+}
+
+func NewCicloVidaService(db *sql.DB) *CicloVidaService {
+	return &CicloVidaService{db: db}
+}
+
+// CambiarEstadoConexion aplica una transición pedida por un operador
+func (s *CicloVidaService) CambiarEstadoConexion(ctx context.Context, id int, req modelos.CambiarEstadoRequest, idPersona int) (*modelos.CambiarEstadoResponse, error) {
+	return s.cambiarEstado(ctx, maquinaConexion, id, req, idPersona)
+}
+
+// CambiarEstadoContrato aplica una transición pedida por un operador
+func (s *CicloVidaService) CambiarEstadoContrato(ctx context.Context, id int, req modelos.CambiarEstadoRequest, idPersona int) (*modelos.CambiarEstadoResponse, error) {
+	return s.cambiarEstado(ctx, maquinaContrato, id, req, idPersona)
+}
+
+func (s *CicloVidaService) cambiarEstado(
+	ctx context.Context,
+	m *maquinaEstados,
+	id int,
+	req modelos.CambiarEstadoRequest,
+	idPersona int,
+) (*modelos.CambiarEstadoResponse, error) {
+	req.Estado = strings.TrimSpace(req.Estado)
+	if req.Estado == "" {
+		return nil, utilidades.ErrValidation{Campo: "estado", Mensaje: "es requerido"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Responsable del cambio
+	actor, err := ActorPersona(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Transición (con sus efectos en cascada)
+	anterior, err := NewCicloVida(tx).transicionar(ctx, m, id, req.Estado, actor, req.Observacion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("%s %d pasó de '%s' a '%s' (persona %d)", m.entidad.Nombre, id, anterior, req.Estado, idPersona)
+
+	return &modelos.CambiarEstadoResponse{
+		Mensaje:        "Estado actualizado",
+		ID:             id,
+		EstadoAnterior: anterior,
+		Estado:         req.Estado,
+	}, nil
+}
+
+// HistorialConexion devuelve la línea de tiempo completa de una conexión (vista del personal)
+func (s *CicloVidaService) HistorialConexion(ctx context.Context, idConexion int) (*modelos.HistorialConexionResponse, error) {
+	repo := repositorios.NewEstadoHistorialRepo(s.db)
+
+	historial, _, err := repo.ObtenerCabeceraConexion(ctx, idConexion)
+	if err != nil {
+		return nil, err
+	}
+	if historial.Eventos, err = repo.ListarHistorialConexion(ctx, idConexion); err != nil {
+		return nil, err
+	}
+	return historial, nil
+}
+
+// HistorialConexionDeCliente devuelve la línea de tiempo de una conexión de la persona. Una
+// conexión ajena se informa como inexistente; del responsable sólo se muestra el nombre.
+func (s *CicloVidaService) HistorialConexionDeCliente(ctx context.Context, idConexion, idPersona int) (*modelos.HistorialConexionResponse, error) {
+	repo := repositorios.NewEstadoHistorialRepo(s.db)
+
+	historial, idTitular, err := repo.ObtenerCabeceraConexion(ctx, idConexion)
+	if err != nil {
+		return nil, err
+	}
+	if idTitular != idPersona {
+		logger.Warn.Printf("Persona %d pidió el historial de la conexión %d, que no le pertenece", idPersona, idConexion)
+		return nil, utilidades.ErrNotFound{Entity: "conexion", Campo: "id_conexion", Valor: fmt.Sprintf("%d", idConexion)}
+	}
+
+	if historial.Eventos, err = repo.ListarHistorialConexion(ctx, idConexion); err != nil {
+		return nil, err
+	}
+	for i := range historial.Eventos {
+		if r := historial.Eventos[i].Responsable; r != nil {
+			historial.Eventos[i].Responsable = &modelos.ResponsableCambio{Nombre: r.Nombre}
+		}
+	}
+	return historial, nil
+}
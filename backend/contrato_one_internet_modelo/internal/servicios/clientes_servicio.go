@@ -1,150 +1,196 @@
 package servicios
-/*
+
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
 	"contrato_one_internet_modelo/internal/modelos"
 	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
 )
 
+// ClientesService gestiona el alta de clientes empresa.
+// Los particulares se registran con UsuarioService.CrearPersonaYUsuario.
 type ClientesService struct {
-	DB              *sql.DB
-	RepoDireccion   repositorios.DireccionRepo
-	RepoPersona     repositorios.PersonaRepo
-	RepoEmpresa     repositorios.EmpresaRepo
-	RepoVinculo     repositorios.VinculoRepo
+	db *sql.DB
 }
 
-func NewClientesService(db *sql.DB,
-	dirRepo *repositorios.DireccionRepo,
-	perRepo *repositorios.PersonaRepo,
-	empRepo *repositorios.EmpresaRepo,
-	vincRepo *repositorios.VinculoRepo,
-) *ClientesService {
-	return &ClientesService{
-		DB:            db,
-		RepoDireccion: *dirRepo,
-		RepoPersona:   *perRepo,
-		RepoEmpresa:   *empRepo,
-		RepoVinculo:   *vincRepo,
-	}
+// NewClientesService crea una nueva instancia
+func NewClientesService(db *sql.DB) *ClientesService {
+	return &ClientesService{db: db}
 }
 
-// Para el handler: para particulates
-type CrearParticularRequest struct {
-	Persona   modelos.Persona
-	Direccion modelos.Direccion
+// CrearClienteEmpresaRequest representa el alta de una empresa cliente y su persona de contacto.
+// Si IDPersonaContacto es nil, el contacto es la persona del usuario que hace el alta.
+type CrearClienteEmpresaRequest struct {
+	Empresa           modelos.Empresa    `json:"empresa"`
+	IDDireccion       *int               `json:"id_direccion,omitempty"`
+	Direccion         *modelos.Direccion `json:"direccion,omitempty"`
+	IDPersonaContacto *int               `json:"id_persona_contacto,omitempty"`
+	IDVinculo         int                `json:"id_vinculo"`
+	IDCargo           *int               `json:"id_cargo,omitempty"`
 }
 
-func (s *ClientesService) CrearClienteParticular(ctx context.Context, req CrearParticularRequest) (int64, error) {
-	tx, err := s.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return 0, err
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		}
-	}()
+// CrearClienteEmpresaResponse representa la respuesta del alta de empresa
+type CrearClienteEmpresaResponse struct {
+	Mensaje           string `json:"mensaje"`
+	IDEmpresa         int64  `json:"id_empresa"`
+	IDDireccion       int64  `json:"id_direccion"`
+	IDPersonaContacto int    `json:"id_persona_contacto"`
+	IDVinculo         int    `json:"id_vinculo"`
+	IDCargo           *int   `json:"id_cargo,omitempty"`
+}
 
-	idDir, err := s.RepoDireccion.CrearDireccion(ctx, tx, &req.Direccion)
-	if err != nil {
-		return 0, err
+// CrearClienteEmpresa crea la empresa (validando CUIT), su dirección y el vínculo
+// persona_vinculo_empresa con el contacto, todo en una transacción.
+func (s *ClientesService) CrearClienteEmpresa(ctx context.Context, req CrearClienteEmpresaRequest, idUsuarioCreador int) (*CrearClienteEmpresaResponse, error) {
+	// 1. Validaciones de datos
+	req.Empresa.Cuit = utilidades.NormalizarCUIT(req.Empresa.Cuit)
+	if err := utilidades.ValidarCUIT(req.Empresa.Cuit); err != nil {
+		return nil, err
 	}
-
-	req.Persona.IDDireccion = int(idDir)
-
-	idPersona, err := s.RepoPersona.CrearPersona(ctx, tx, &req.Persona)
-	if err != nil {
-		return 0, err
+	if strings.TrimSpace(req.Empresa.RazonSocial) == "" {
+		return nil, utilidades.ErrValidation{Campo: "razon_social", Mensaje: "es requerido"}
 	}
-
-	err = tx.Commit()
-	if err != nil {
-		return 0, err
+	if strings.TrimSpace(req.Empresa.NombreComercial) == "" {
+		req.Empresa.NombreComercial = req.Empresa.RazonSocial
+	}
+	if req.Empresa.IDTipoEmpresa <= 0 {
+		return nil, utilidades.ErrValidation{Campo: "id_tipo_empresa", Mensaje: "es requerido y debe ser mayor a cero"}
+	}
+	if req.IDVinculo <= 0 {
+		return nil, utilidades.ErrValidation{Campo: "id_vinculo", Mensaje: "es requerido y debe ser mayor a cero"}
+	}
+	if req.IDDireccion == nil && req.Direccion == nil {
+		return nil, utilidades.ErrValidation{Campo: "direccion", Mensaje: "debe proporcionar id_direccion o el objeto direccion"}
+	}
+	if req.IDDireccion != nil && req.Direccion != nil {
+		return nil, utilidades.ErrValidation{Campo: "direccion", Mensaje: "no puede proporcionar id_direccion y direccion al mismo tiempo"}
 	}
 
-	return idPersona, nil
-}
-
-// Para el handler: para empresas
-type CrearEmpresaRequest struct {
-	Apoderado          modelos.Persona
-	DireccionApoderado modelos.Direccion
-	Empresa            modelos.Empresa
-	DireccionEmpresa   modelos.Direccion
-}
-
-type CrearEmpresaResponse struct {
-	IDEmpresa   int64
-	IDApoderado int64
-}
+	// 2. Resolver persona de contacto
+	var idPersonaContacto int
+	if req.IDPersonaContacto != nil && *req.IDPersonaContacto > 0 {
+		idPersonaContacto = *req.IDPersonaContacto
+		var count int
+		err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM persona WHERE id_persona = ? AND borrado IS NULL`, idPersonaContacto).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("error validando persona de contacto: %w", err)
+		}
+		if count == 0 {
+			return nil, utilidades.ErrNotFound{Entity: "persona", Campo: "id_persona", Valor: fmt.Sprintf("%d", idPersonaContacto)}
+		}
+	} else {
+		err := s.db.QueryRowContext(ctx, `SELECT id_persona FROM usuario WHERE id_usuario = ? AND borrado IS NULL`, idUsuarioCreador).Scan(&idPersonaContacto)
+		if err != nil {
+			return nil, fmt.Errorf("error resolviendo persona del usuario creador: %w", err)
+		}
+	}
 
-func (s *ClientesService) CrearClienteEmpresa(ctx context.Context, req CrearEmpresaRequest) (*CrearEmpresaResponse, error) {
-	tx, err := s.DB.BeginTx(ctx, nil)
-	if err != nil {
+	// 3. Validar vínculo y cargo elegidos
+	if _, err := repositorios.NewVinculoRepo(s.db).ObtenerPorID(ctx, req.IDVinculo); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utilidades.ErrNotFound{Entity: "vinculo", Campo: "id_vinculo", Valor: fmt.Sprintf("%d", req.IDVinculo)}
+		}
 		return nil, err
 	}
-
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
+	if req.IDCargo != nil {
+		if _, err := repositorios.NewCargoRepo(s.db).ObtenerPorID(ctx, *req.IDCargo); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, utilidades.ErrNotFound{Entity: "cargo", Campo: "id_cargo", Valor: fmt.Sprintf("%d", *req.IDCargo)}
+			}
+			return nil, err
 		}
-	}()
+	}
 
-	idDirApoderado, err := s.RepoDireccion.CrearDireccion(ctx, tx, &req.DireccionApoderado)
+	// 4. Iniciar transacción
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Apoderado.IDDireccion = int(idDirApoderado)
+	defer tx.Rollback()
 
-	idApoderado, err := s.RepoPersona.CrearPersona(ctx, tx, &req.Apoderado)
-	if err != nil {
-		return nil, err
+	if _, err := tx.ExecContext(ctx, "SET @session_user_id = ?", idUsuarioCreador); err != nil {
+		return nil, fmt.Errorf("error seteando variable de sesión: %w", err)
 	}
 
-	idDirEmpresa, err := s.RepoDireccion.CrearDireccion(ctx, tx, &req.DireccionEmpresa)
-	if err != nil {
-		return nil, err
+	empresaRepo := repositorios.NewEmpresaRepo()
+	direccionRepo := repositorios.NewDireccionRepo(tx)
+	pveRepo := repositorios.NewPersonaVinculoEmpresaRepo(tx)
+
+	// 5. CUIT único
+	if _, err := empresaRepo.ObtenerIDPorCUIT(ctx, tx, req.Empresa.Cuit); err == nil {
+		return nil, utilidades.ErrCuitDuplicado
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error verificando CUIT: %w", err)
 	}
-	req.Empresa.IDDireccion = int(idDirEmpresa)
 
-	idEmpresa, err := s.RepoEmpresa.CrearEmpresa(ctx, tx, &req.Empresa)
+	// 6. Dirección de la empresa
+	var idDireccion int64
+	var idDistrito int
+	if req.Direccion != nil {
+		idDireccion, err = direccionRepo.EncontrarOCrearDireccion(ctx, req.Direccion)
+		if err != nil {
+			return nil, err
+		}
+		idDistrito = req.Direccion.IDDistrito
+	} else {
+		dir, err := direccionRepo.ObtenerDireccionPorID(ctx, *req.IDDireccion)
+		if err != nil {
+			return nil, utilidades.ErrNotFound{Entity: "direccion", Campo: "id_direccion", Valor: fmt.Sprintf("%d", *req.IDDireccion)}
+		}
+		idDireccion = int64(*req.IDDireccion)
+		idDistrito = dir.IDDistrito
+	}
+	distrito, departamento, provincia, err := direccionRepo.ObtenerJerarquiaGeografica(ctx, idDistrito)
 	if err != nil {
 		return nil, err
 	}
 
-	const VinculoApoderado = "Apoderado"
+	// 7. Crear empresa
+	empresa := req.Empresa
+	empresa.IDDireccion = int(idDireccion)
+	empresa.DistritoNombre = distrito
+	empresa.DepartamentoNombre = departamento
+	empresa.ProvinciaNombre = provincia
+	empresa.IDUsuarioCreador = &idUsuarioCreador
 
-	idVinculoApoderado, err := s.RepoVinculo.ObtenerIDPorNombre(ctx, s.DB, VinculoApoderado)
+	idEmpresa, err := empresaRepo.CrearEmpresa(ctx, tx, &empresa)
 	if err != nil {
-		return nil, err
-	}
-
-	vinculo := &modelos.PersonaVinculoEmpresa{
-		IDPersona: int(idApoderado),
-		IDVinculo: idVinculoApoderado,
-		IDEmpresa: int(idEmpresa),
+		return nil, utilidades.TraducirErrorBD(err)
 	}
 
-	if err := s.RepoVinculo.CrearPersonaVinculoEmpresa(ctx, tx, vinculo); err != nil {
+	// 8. Vincular persona de contacto
+	if err := pveRepo.CrearOActualizarVinculoConCargo(ctx, idPersonaContacto, req.IDVinculo, int(idEmpresa), req.IDCargo); err != nil {
 		return nil, err
 	}
 
-	err = tx.Commit()
-	if err != nil {
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	return &CrearEmpresaResponse{
-		IDEmpresa:   idEmpresa,
-		IDApoderado: idApoderado,
+	logger.Info.Printf("Empresa %d (CUIT %s) creada con contacto persona %d", idEmpresa, empresa.Cuit, idPersonaContacto)
+
+	return &CrearClienteEmpresaResponse{
+		Mensaje:           "Empresa registrada exitosamente.",
+		IDEmpresa:         idEmpresa,
+		IDDireccion:       idDireccion,
+		IDPersonaContacto: idPersonaContacto,
+		IDVinculo:         req.IDVinculo,
+		IDCargo:           req.IDCargo,
 	}, nil
-}*/
\ No newline at end of file
+}
+
+// ObtenerEmpresa devuelve los datos de una empresa
+func (s *ClientesService) ObtenerEmpresa(ctx context.Context, idEmpresa int) (*modelos.Empresa, error) {
+	return repositorios.NewEmpresaRepo().ObtenerPorID(ctx, s.db, idEmpresa)
+}
+
+// ListarEmpresasDePersona devuelve las empresas vinculadas a una persona
+func (s *ClientesService) ListarEmpresasDePersona(ctx context.Context, idPersona int) ([]modelos.EmpresaVinculada, error) {
+	return repositorios.NewEmpresaRepo().ListarPorPersona(ctx, s.db, idPersona)
+}
@@ -29,6 +29,7 @@ type SolicitudConexionRequest struct {
 	Direccion   *modelos.Direccion `json:"direccion,omitempty"`
 	Latitud     float64            `json:"latitud"`
 	Longitud    float64            `json:"longitud"`
+	IDPromocion *int               `json:"id_promocion,omitempty"` // Promoción vigente elegida al contratar
 
 	// --- Nuevos campos para flujo de Atención al Público ---
 	IDPersonaCliente      *int    `json:"id_persona_cliente,omitempty"`     // Si un empleado lo pide para otro
@@ -47,9 +48,34 @@ type SolicitudConexionResponse struct {
 	IDContrato   int64  `json:"id_contrato"`
 }
 
+// SolicitudConexionEmpresaRequest representa la solicitud de conexión a nombre de una empresa.
+// La persona cliente (o id_persona_cliente) debe estar vinculada a la empresa.
+type SolicitudConexionEmpresaRequest struct {
+	SolicitudConexionRequest
+	IDEmpresa int `json:"id_empresa"`
+}
+
 // SolicitarConexionParticular gestiona la solicitud completa de conexión en una transacción
 func (s *ConexionService) SolicitarConexionParticular(ctx context.Context, req SolicitudConexionRequest, IdUsuarioCreador int) (*SolicitudConexionResponse, error) {
-	logger.Debug.Printf("Iniciando solicitud. Creador: %d, FactibilidadInmediata: %v", IdUsuarioCreador, req.FactibilidadInmediata)
+	return s.solicitarConexion(ctx, req, IdUsuarioCreador, nil)
+}
+
+// SolicitarConexionEmpresa gestiona la solicitud de conexión de una empresa cliente.
+// El contrato queda a nombre de la empresa, firmado por la persona con su vínculo en ella.
+func (s *ConexionService) SolicitarConexionEmpresa(ctx context.Context, req SolicitudConexionEmpresaRequest, IdUsuarioCreador int) (*SolicitudConexionResponse, error) {
+	if req.IDEmpresa <= 0 {
+		return nil, utilidades.ErrValidation{
+			Campo:   "id_empresa",
+			Mensaje: "es requerido y debe ser mayor a cero",
+		}
+	}
+	return s.solicitarConexion(ctx, req.SolicitudConexionRequest, IdUsuarioCreador, &req.IDEmpresa)
+}
+
+// solicitarConexion contiene el flujo común de solicitud de conexión.
+// Si idEmpresaCliente es nil, el contrato es particular (empresa operadora + vínculo "cliente").
+func (s *ConexionService) solicitarConexion(ctx context.Context, req SolicitudConexionRequest, IdUsuarioCreador int, idEmpresaCliente *int) (*SolicitudConexionResponse, error) {
+	logger.Debug.Printf("Iniciando solicitud. Creador: %d, FactibilidadInmediata: %v, Empresa: %v", IdUsuarioCreador, idEmpresaCliente != nil, req.FactibilidadInmediata)
 
 	// 1. Determinar quién es la persona cliente (dueño de la conexión)
 	var idPersonaCliente int
@@ -173,8 +199,8 @@ func (s *ConexionService) SolicitarConexionParticular(ctx context.Context, req S
     
     if req.FactibilidadInmediata {
         // Factibilidad inmediata = true: Conexión creada por empleado con verificación técnica ya realizada
-        nombreEstadoConexion = "Factible"
-        nombreEstadoContrato = "Pendiente de pago"
+        nombreEstadoConexion = modelos.EstadoConexionFactible
+        nombreEstadoContrato = modelos.EstadoContratoPendientePago
     } else {
         // Factibilidad inmediata = false: Requiere verificación técnica
         // Diferenciar según el origen:
@@ -182,12 +208,12 @@ func (s *ConexionService) SolicitarConexionParticular(ctx context.Context, req S
         // - Empleado derivando a verificador → "Pendiente verificación técnica"
         if req.IDPersonaCliente != nil && IdUsuarioCreador != *req.IDPersonaCliente {
             // Empleado creando para otra persona → derivar a verificador técnico
-            nombreEstadoConexion = "Pendiente verificación técnica"
+            nombreEstadoConexion = modelos.EstadoConexionPendienteVerificacionTecnica
         } else {
             // Cliente creando para sí mismo → flujo normal
-            nombreEstadoConexion = "En verificacion"
+            nombreEstadoConexion = modelos.EstadoConexionEnVerificacion
         }
-        nombreEstadoContrato = "En verificacion"
+        nombreEstadoContrato = modelos.EstadoContratoEnVerificacion
     }
 
     idEstadoConexion, err := conexionRepo.ObtenerIDEstadoPorNombre(ctx, nombreEstadoConexion)
@@ -235,24 +261,36 @@ func (s *ConexionService) SolicitarConexionParticular(ctx context.Context, req S
 		return nil, err
 	}
 
-	// 5. Obtener IDs de empresa "ONE Internet" y vínculo "cliente"
-	idEmpresa, err := pveRepo.ObtenerIDEmpresaPorNombre(ctx, "ONE Internet")
-	if err != nil {
-		logger.Error.Printf("Error obteniendo empresa: %v", err)
-		return nil, err
-	}
+	// 5-6. Resolver empresa y vínculo del contrato
+	var idEmpresa, idVinculo int
+	if idEmpresaCliente != nil {
+		// Empresa cliente: la persona debe tener un vínculo activo con ella
+		idEmpresa = *idEmpresaCliente
+		idVinculo, err = pveRepo.ObtenerIDVinculoActivo(ctx, idPersonaCliente, idEmpresa)
+		if err != nil {
+			logger.Error.Printf("Persona %d sin vínculo con empresa %d: %v", idPersonaCliente, idEmpresa, err)
+			return nil, err
+		}
+	} else {
+		// Particular: empresa "ONE Internet" y vínculo "cliente"
+		idEmpresa, err = pveRepo.ObtenerIDEmpresaPorNombre(ctx, modelos.EmpresaOperadoraNombre)
+		if err != nil {
+			logger.Error.Printf("Error obteniendo empresa: %v", err)
+			return nil, err
+		}
 
-	idVinculo, err := pveRepo.ObtenerIDVinculoPorNombre(ctx, "cliente")
-	if err != nil {
-		logger.Error.Printf("Error obteniendo vínculo: %v", err)
-		return nil, err
-	}
+		idVinculo, err = pveRepo.ObtenerIDVinculoPorNombre(ctx, modelos.VinculoCliente)
+		if err != nil {
+			logger.Error.Printf("Error obteniendo vínculo: %v", err)
+			return nil, err
+		}
 
-	// 6. Crear o actualizar vínculo persona-empresa
-	err = pveRepo.CrearOActualizarVinculo(ctx, idPersonaCliente, idVinculo, idEmpresa)
-	if err != nil {
-		logger.Error.Printf("Error creando vínculo persona-empresa: %v", err)
-		return nil, err
+		// Crear o actualizar vínculo persona-empresa
+		err = pveRepo.CrearOActualizarVinculo(ctx, idPersonaCliente, idVinculo, idEmpresa)
+		if err != nil {
+			logger.Error.Printf("Error creando vínculo persona-empresa: %v", err)
+			return nil, err
+		}
 	}
 
 	// 7. Obtener estado "En verificacion" para contrato
@@ -276,12 +314,49 @@ func (s *ConexionService) SolicitarConexionParticular(ctx context.Context, req S
 		IDUsuarioCreador: &IdUsuarioCreador,
 	}
 
+	// El contrato guarda el abono vigente del plan y la promoción elegida
+	if err := aplicarCondicionesContrato(ctx, repositorios.NewPrecioPlanRepo(tx), contrato, req.IDPromocion); err != nil {
+		logger.Error.Printf("Error fijando precio y promoción del contrato: %v", err)
+		return nil, err
+	}
+
 	idContrato, err := contratoRepo.CrearContrato(ctx, contrato)
 	if err != nil {
 		logger.Error.Printf("Error creando contrato: %v", err)
 		return nil, err
 	}
 
+	// 9. Estado inicial de ambos en el historial
+	cicloVida := NewCicloVida(tx)
+	creador := ActorUsuario(IdUsuarioCreador)
+	if err := cicloVida.RegistrarInicialConexion(ctx, int(idConexion), creador, nil); err != nil {
+		logger.Error.Printf("Error registrando historial de conexión: %v", err)
+		return nil, err
+	}
+	if err := cicloVida.RegistrarInicialContrato(ctx, int(idContrato), creador, nil); err != nil {
+		logger.Error.Printf("Error registrando historial de contrato: %v", err)
+		return nil, err
+	}
+
+	// 10. Registrar el evento para notificar a verificadores y cliente (outbox, misma transacción)
+	_, err = repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoConexionSolicitada, modelos.PayloadConexionSolicitada{
+		IDPersona:             idPersonaCliente,
+		IDConexion:            int(idConexion),
+		IDContrato:            int(idContrato),
+		IDPlan:                req.IDPlan,
+		NroConexion:           nroConexion,
+		FactibilidadInmediata: req.FactibilidadInmediata,
+		Distrito:              distrito,
+		Departamento:          departamento,
+		Provincia:             provincia,
+		Observaciones:         req.Observaciones,
+		Fecha:                 time.Now().Format("02/01/2006"),
+	})
+	if err != nil {
+		logger.Error.Printf("Error registrando evento de solicitud: %v", err)
+		return nil, err
+	}
+
 	// Commit
 	if err := tx.Commit(); err != nil {
 		logger.Error.Printf("Error haciendo commit: %v", err)
@@ -290,79 +365,7 @@ func (s *ConexionService) SolicitarConexionParticular(ctx context.Context, req S
 
 	logger.Info.Printf("Solicitud de conexión creada exitosamente: conexion=%d, contrato=%d", idConexion, idContrato)
 
-	// === ENVIAR NOTIFICACIONES ===
-	// Después del commit exitoso, enviar notificaciones de forma asíncrona para no bloquear la respuesta
-	go func() {
-		// Obtener datos del cliente para las notificaciones
-		var nombreCliente, apellidoCliente, nombrePlan string
-		queryCliente := `
-			SELECT p.nombre, p.apellido, pl.nombre
-			FROM persona p
-			INNER JOIN plan pl ON pl.id_plan = ?
-			WHERE p.id_persona = ?
-		`
-		err := s.db.QueryRow(queryCliente, req.IDPlan, idPersonaCliente).Scan(&nombreCliente, &apellidoCliente, &nombrePlan)
-		if err != nil {
-			logger.Error.Printf("Error obteniendo datos para notificación: %v", err)
-			return
-		}
-
-		notifService := NewNotificacionEnvioService(s.db)
-
-		if req.FactibilidadInmediata {
-			// Notificar aprobación de factibilidad inmediata al cliente
-			// A. Si se aprobó directo: Notificar al cliente "Factible" (saltar "Recibida")
-			err = notifService.EnviarNotificacionSolicitudFactible(
-				context.Background(),
-				idPersonaCliente,
-				int(idConexion),
-				int(idContrato),
-				fmt.Sprintf("%d", nroConexion),
-			)
-			if err != nil {
-				logger.Error.Printf("Error enviando notificación de factibilidad aprobada al cliente: %v", err)
-			}
-			return
-		} else {
-			// // B. Flujo normal: Notificar Revisador y Cliente "Recibida"
-
-			// 1. Notificación a verificadores (nueva solicitud pendiente)
-			var observacionPtr *string
-			if req.Observaciones != "" {
-				observacionPtr = &req.Observaciones
-			}
-			
-			err = notifService.EnviarNotificacionNuevaSolicitudVerificador(
-				context.Background(),
-				int(idConexion),
-				int(idContrato),
-				fmt.Sprintf("%d", nroConexion),
-				nombreCliente,
-				apellidoCliente,
-				distrito,
-				departamento,
-				provincia,
-				nombrePlan,
-				time.Now().Format("02/01/2006"),
-				observacionPtr,
-			)
-			if err != nil {
-				logger.Error.Printf("Error enviando notificación a verificadores: %v", err)
-			}
-
-			// 2. Notificación al cliente (solicitud recibida)
-			err = notifService.EnviarNotificacionSolicitudRecibidaCliente(
-				context.Background(),
-				idPersonaCliente,
-				int(idConexion),
-				int(idContrato),
-				fmt.Sprintf("%d", nroConexion),
-			)
-			if err != nil {
-				logger.Error.Printf("Error enviando notificación al cliente: %v", err)
-			}
-		}
-	}()
+	// Las notificaciones las crea el despachador del outbox a partir del evento registrado
 
 	mensajeFinal := "Solicitud creada exitosamente."
     if req.FactibilidadInmediata {
@@ -496,10 +499,12 @@ func (s *ConexionService) ObtenerSolicitudesPendientesConFiltros(
 	}, nil
 }
 
-// ConfirmarFactibilidad confirma la factibilidad de una conexión y actualiza estados
+// ConfirmarFactibilidad confirma la factibilidad de una conexión y actualiza estados.
+// idPersona es el verificador que la confirma (0 si el pedido no lo informa).
 func (s *ConexionService) ConfirmarFactibilidad(
 	ctx context.Context,
 	req modelos.ConfirmarFactibilidadRequest,
+	idPersona int,
 ) (*modelos.ConfirmarFactibilidadResponse, error) {
 	logger.Debug.Printf("Confirmando factibilidad para conexión ID: %d", req.IDConexion)
 
@@ -542,48 +547,38 @@ func (s *ConexionService) ConfirmarFactibilidad(
 	defer tx.Rollback()
 
 	conexionRepo := repositorios.NewConexionRepo(tx)
-	contratoRepo := repositorios.NewContratoRepo(tx)
 
-	// 1. Verificar que la conexión exista y esté en estado "En verificacion" o "Pendiente verificación técnica"
-	err = conexionRepo.VerificarEstadoConexionMultiple(ctx, req.IDConexion, []string{"En verificacion", "Pendiente verificación técnica"})
+	// 1. Responsable del cambio
+	actor, err := ActorPersona(ctx, tx, idPersona)
 	if err != nil {
-		logger.Error.Printf("Error verificando estado de conexión %d: %v", req.IDConexion, err)
+		logger.Error.Printf("Error resolviendo usuario de la persona %d: %v", idPersona, err)
 		return nil, err
 	}
 
-	// 2. Obtener ID del estado "Factible" para conexión
-	idEstadoFactible, err := conexionRepo.ObtenerIDEstadoPorNombre(ctx, "Factible")
-	if err != nil {
-		logger.Error.Printf("Error obteniendo estado 'Factible': %v", err)
-		return nil, fmt.Errorf("estado 'Factible' no encontrado en el sistema")
+	// 2. Pasar la conexión a "Factible" (la máquina de estados valida el estado actual
+	// y pasa el contrato asociado a "Pendiente de pago")
+	if _, err = NewCicloVida(tx).TransicionarConexion(ctx, req.IDConexion, modelos.EstadoConexionFactible, actor, req.Observaciones); err != nil {
+		logger.Error.Printf("Error confirmando factibilidad de conexión %d: %v", req.IDConexion, err)
+		return nil, err
 	}
 
-	// 3. Actualizar conexión con datos técnicos y nuevo estado
-	err = conexionRepo.ActualizarFactibilidadConexion(
+	// 3. Guardar los datos técnicos
+	err = conexionRepo.ActualizarDatosTecnicos(
 		ctx,
 		req.IDConexion,
 		req.NAP,
 		req.VLAN,
 		req.Puerto,
 		req.Observaciones,
-		idEstadoFactible,
 	)
 	if err != nil {
 		logger.Error.Printf("Error actualizando conexión %d: %v", req.IDConexion, err)
 		return nil, err
 	}
 
-	// 4. Obtener ID del estado "Pendiente de pago" para contrato
-	idEstadoPendientePago, err := contratoRepo.ObtenerIDEstadoContratoPorNombre(ctx, "Pendiente de pago")
-	if err != nil {
-		logger.Error.Printf("Error obteniendo estado 'Pendiente de pago': %v", err)
-		return nil, fmt.Errorf("estado 'Pendiente de pago' no encontrado en el sistema")
-	}
-
-	// 5. Actualizar estado del contrato asociado
-	err = contratoRepo.ActualizarEstadoContratoPorConexion(ctx, req.IDConexion, idEstadoPendientePago)
-	if err != nil {
-		logger.Error.Printf("Error actualizando contrato de conexión %d: %v", req.IDConexion, err)
+	// 4. Registrar el evento para notificar al cliente (outbox, misma transacción)
+	if _, err = repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoConexionFactible, modelos.PayloadConexion{IDConexion: req.IDConexion}); err != nil {
+		logger.Error.Printf("Error registrando evento de factibilidad: %v", err)
 		return nil, err
 	}
 
@@ -595,45 +590,18 @@ func (s *ConexionService) ConfirmarFactibilidad(
 
 	logger.Info.Printf("Factibilidad confirmada para conexión %d", req.IDConexion)
 
-	// === ENVIAR NOTIFICACIÓN AL CLIENTE ===
-	go func() {
-		// Obtener datos necesarios para la notificación
-		var idPersona, idContrato, nroConexion int
-		queryDatos := `
-			SELECT c.id_persona, con.id_contrato, c.nro_conexion
-			FROM conexion c
-			INNER JOIN contrato con ON con.id_conexion = c.id_conexion
-			WHERE c.id_conexion = ?
-		`
-		err := s.db.QueryRow(queryDatos, req.IDConexion).Scan(&idPersona, &idContrato, &nroConexion)
-		if err != nil {
-			logger.Error.Printf("Error obteniendo datos para notificación de factibilidad: %v", err)
-			return
-		}
-
-		notifService := NewNotificacionEnvioService(s.db)
-		err = notifService.EnviarNotificacionSolicitudFactible(
-			context.Background(),
-			idPersona,
-			req.IDConexion,
-			idContrato,
-			fmt.Sprintf("%d", nroConexion),
-		)
-		if err != nil {
-			logger.Error.Printf("Error enviando notificación de factibilidad al cliente: %v", err)
-		}
-	}()
-
 	return &modelos.ConfirmarFactibilidadResponse{
 		Mensaje:    "Factibilidad confirmada",
 		IDConexion: req.IDConexion,
 	}, nil
 }
 
-// RechazarFactibilidad rechaza la factibilidad de una conexión
+// RechazarFactibilidad rechaza la factibilidad de una conexión.
+// idPersona es el verificador que la rechaza (0 si el pedido no lo informa).
 func (s *ConexionService) RechazarFactibilidad(
 	ctx context.Context,
 	req modelos.RechazarFactibilidadRequest,
+	idPersona int,
 ) (*modelos.RechazarFactibilidadResponse, error) {
 	logger.Debug.Printf("Rechazando factibilidad para conexión ID: %d", req.IDConexion)
 
@@ -653,46 +621,31 @@ func (s *ConexionService) RechazarFactibilidad(
 	}
 	defer tx.Rollback()
 
-	conexionRepo := repositorios.NewConexionRepo(tx)
-	contratoRepo := repositorios.NewContratoRepo(tx)
-
-	// 1. Verificar que la conexión exista y esté en estado "En verificacion" o "Pendiente verificación técnica"
-	err = conexionRepo.VerificarEstadoConexionMultiple(ctx, req.IDConexion, []string{"En verificacion", "Pendiente verificación técnica"})
+	// 1. Responsable del cambio
+	actor, err := ActorPersona(ctx, tx, idPersona)
 	if err != nil {
-		logger.Error.Printf("Error verificando estado de conexión %d: %v", req.IDConexion, err)
+		logger.Error.Printf("Error resolviendo usuario de la persona %d: %v", idPersona, err)
 		return nil, err
 	}
 
-	// 2. Obtener ID del estado "No factible" para conexión
-	idEstadoNoFactible, err := conexionRepo.ObtenerIDEstadoPorNombre(ctx, "No factible")
-	if err != nil {
-		logger.Error.Printf("Error obteniendo estado 'No factible': %v", err)
-		return nil, fmt.Errorf("estado 'No factible' no encontrado en el sistema")
-	}
-
-	// 3. Actualizar conexión con motivo de rechazo y nuevo estado
-	err = conexionRepo.RechazarFactibilidadConexion(
-		ctx,
-		req.IDConexion,
-		req.Motivo,
-		idEstadoNoFactible,
-	)
-	if err != nil {
-		logger.Error.Printf("Error actualizando conexión %d: %v", req.IDConexion, err)
+	// 2. Pasar la conexión a "No factible" (la máquina de estados valida el estado actual
+	// y pasa el contrato asociado a "No factible")
+	if _, err = NewCicloVida(tx).TransicionarConexion(ctx, req.IDConexion, modelos.EstadoConexionNoFactible, actor, req.Motivo); err != nil {
+		logger.Error.Printf("Error rechazando factibilidad de conexión %d: %v", req.IDConexion, err)
 		return nil, err
 	}
 
-	// 4. Obtener ID del estado "No factible" para contrato
-	idEstadoRechazado, err := contratoRepo.ObtenerIDEstadoContratoPorNombre(ctx, "No factible")
-	if err != nil {
-		logger.Error.Printf("Error obteniendo estado 'No factible': %v", err)
-		return nil, fmt.Errorf("estado 'No factible' no encontrado en el sistema")
+	// 3. Guardar el motivo de rechazo
+	if req.Motivo != nil {
+		if err = repositorios.NewConexionRepo(tx).RegistrarMotivoRechazo(ctx, req.IDConexion, *req.Motivo); err != nil {
+			logger.Error.Printf("Error actualizando conexión %d: %v", req.IDConexion, err)
+			return nil, err
+		}
 	}
 
-	// 5. Actualizar estado del contrato asociado
-	err = contratoRepo.ActualizarEstadoContratoPorConexion(ctx, req.IDConexion, idEstadoRechazado)
-	if err != nil {
-		logger.Error.Printf("Error actualizando contrato de conexión %d: %v", req.IDConexion, err)
+	// 4. Registrar el evento para notificar al cliente (outbox, misma transacción)
+	if _, err = repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoConexionNoFactible, modelos.PayloadConexion{IDConexion: req.IDConexion}); err != nil {
+		logger.Error.Printf("Error registrando evento de rechazo: %v", err)
 		return nil, err
 	}
 
@@ -704,35 +657,6 @@ func (s *ConexionService) RechazarFactibilidad(
 
 	logger.Info.Printf("Factibilidad rechazada para conexión %d", req.IDConexion)
 
-	// === ENVIAR NOTIFICACIÓN AL CLIENTE ===
-	go func() {
-		// Obtener datos necesarios para la notificación
-		var idPersona, idContrato, nroConexion int
-		queryDatos := `
-			SELECT c.id_persona, con.id_contrato, c.nro_conexion
-			FROM conexion c
-			INNER JOIN contrato con ON con.id_conexion = c.id_conexion
-			WHERE c.id_conexion = ?
-		`
-		err := s.db.QueryRow(queryDatos, req.IDConexion).Scan(&idPersona, &idContrato, &nroConexion)
-		if err != nil {
-			logger.Error.Printf("Error obteniendo datos para notificación de rechazo: %v", err)
-			return
-		}
-
-		notifService := NewNotificacionEnvioService(s.db)
-		err = notifService.EnviarNotificacionSolicitudNoFactible(
-			context.Background(),
-			idPersona,
-			req.IDConexion,
-			idContrato,
-			fmt.Sprintf("%d", nroConexion),
-		)
-		if err != nil {
-			logger.Error.Printf("Error enviando notificación de rechazo al cliente: %v", err)
-		}
-	}()
-
 	return &modelos.RechazarFactibilidadResponse{
 		Mensaje:    "Solicitud marcada como no factible",
 		IDConexion: req.IDConexion,
@@ -0,0 +1,628 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/database"
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// IntervaloRevisionCuentas es cada cuánto se buscan cuentas a suspender o a restablecer
+const IntervaloRevisionCuentas = time.Hour
+
+// conceptosMedioPago son los medios de pago que el personal puede registrar
+var conceptosMedioPago = map[string]string{
+	modelos.MedioPagoEfectivo:      "Pago en efectivo",
+	modelos.MedioPagoTransferencia: "Pago por transferencia",
+	modelos.MedioPagoDebito:        "Pago con tarjeta de débito",
+	modelos.MedioPagoCheque:        "Pago con cheque",
+}
+
+// CuentaService lleva la cuenta corriente de los clientes. Las facturas emitidas son cargos
+// y los pagos de la pasarela se acreditan solos; el personal registra los pagos recibidos por
+// otros medios y las notas de crédito.
+//
+// Los pagos y notas de crédito se imputan a los cargos por orden de vencimiento. Cuando el
+// cargo impago más antiguo lleva más de diasGracia días vencido, la revisión periódica
+// suspende las conexiones activas del cliente (el contrato sigue vigente y se sigue
+// facturando); cuando se pone al día, las restablece.
+type CuentaService struct {
+	db         *sql.DB
+	diasGracia int
+}
+
+func NewCuentaService(db *sql.DB, diasGracia int) *CuentaService {
+	return &CuentaService{db: db, diasGracia: diasGracia}
+}
+
+// IniciarRevision revisa las cuentas cada IntervaloRevisionCuentas hasta que se cancele el
+// contexto. Se ejecuta en su propia goroutine.
+func (s *CuentaService) IniciarRevision(ctx context.Context) {
+	logger.Info.Printf("Revisión de cuentas iniciada (intervalo %s, %d días de gracia)", IntervaloRevisionCuentas, s.diasGracia)
+	ticker := time.NewTicker(IntervaloRevisionCuentas)
+	defer ticker.Stop()
+
+	for {
+		resumen, err := s.RevisarCuentas(ctx)
+		if err != nil {
+			logger.Error.Printf("Error revisando cuentas: %v", err)
+		} else if len(resumen.Suspendidas) > 0 || len(resumen.Restablecidas) > 0 || resumen.Errores > 0 {
+			logger.Info.Printf("Revisión de cuentas: %d conexión(es) suspendida(s), %d restablecida(s), %d error(es)",
+				len(resumen.Suspendidas), len(resumen.Restablecidas), resumen.Errores)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info.Println("Revisión de cuentas detenida")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RevisarCuentas suspende las conexiones de los clientes con deuda fuera del período de
+// gracia y restablece las de quienes se pusieron al día. Un error en una cuenta no frena al
+// resto; se cuenta en el resumen.
+func (s *CuentaService) RevisarCuentas(ctx context.Context) (*modelos.ResumenRevisionCuentas, error) {
+	repo := repositorios.NewCuentaRepo(s.db)
+	resumen := &modelos.ResumenRevisionCuentas{Suspendidas: []int{}, Restablecidas: []int{}}
+	hoy := hoyArgentina()
+
+	// 1. Suspensiones
+	deudores, err := repo.ListarDeudores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deudores {
+		movimientos, err := repo.ListarMovimientos(ctx, d.IDPersona)
+		if err != nil {
+			logger.Error.Printf("Error leyendo la cuenta de la persona %d: %v", d.IDPersona, err)
+			resumen.Errores++
+			continue
+		}
+		cuenta := resumirCuenta(movimientos, hoy)
+		if !s.fueraDeGracia(cuenta) {
+			continue
+		}
+		ids, err := s.suspender(ctx, d.IDPersona, cuenta)
+		if err != nil {
+			logger.Error.Printf("Error suspendiendo las conexiones de la persona %d: %v", d.IDPersona, err)
+			resumen.Errores++
+			continue
+		}
+		resumen.Suspendidas = append(resumen.Suspendidas, ids...)
+	}
+
+	// 2. Restablecimientos
+	personas, err := repo.ListarPersonasConSuspendidasPorDeuda(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, idPersona := range personas {
+		ids, err := s.restablecer(ctx, idPersona)
+		if err != nil {
+			logger.Error.Printf("Error restableciendo las conexiones de la persona %d: %v", idPersona, err)
+			resumen.Errores++
+			continue
+		}
+		resumen.Restablecidas = append(resumen.Restablecidas, ids...)
+	}
+	return resumen, nil
+}
+
+// RevisarCuentasManual corre la revisión sin esperar al próximo ciclo (cuentas.gestionar)
+func (s *CuentaService) RevisarCuentasManual(ctx context.Context, idPersona int) (*modelos.ResumenRevisionCuentas, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoCuentasGestionar); err != nil {
+		return nil, err
+	}
+	resumen, err := s.RevisarCuentas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Revisión de cuentas pedida por la persona %d: %d suspendida(s), %d restablecida(s), %d error(es)",
+		idPersona, len(resumen.Suspendidas), len(resumen.Restablecidas), resumen.Errores)
+	return resumen, nil
+}
+
+// fueraDeGracia indica si la deuda vencida más antigua superó el período de gracia
+func (s *CuentaService) fueraDeGracia(cuenta modelos.ResumenCuenta) bool {
+	return cuenta.SaldoVencido > 0.009 && cuenta.DiasAtraso > s.diasGracia
+}
+
+// suspender pasa a "Suspendida" las conexiones activas de la persona y las marca como
+// suspendidas por deuda
+func (s *CuentaService) suspender(ctx context.Context, idPersona int, cuenta modelos.ResumenCuenta) ([]int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewCuentaRepo(tx)
+	ids, err := repo.ListarConexionesEnEstado(ctx, idPersona, modelos.EstadoConexionActiva)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	ciclo := NewCicloVida(tx)
+	outboxRepo := repositorios.NewOutboxRepo(tx)
+	obs := fmt.Sprintf("Suspensión por deuda: $%.2f vencidos, %d días de atraso", cuenta.SaldoVencido, cuenta.DiasAtraso)
+	suspendidas := []int{}
+	for _, id := range ids {
+		aplicada, err := ciclo.transicionarSiEsta(ctx, maquinaConexion, id,
+			[]string{modelos.EstadoConexionActiva}, modelos.EstadoConexionSuspendida, ActorSistema, &obs)
+		if err != nil {
+			return nil, err
+		}
+		if !aplicada {
+			continue
+		}
+		if err := repo.MarcarSuspendidaPorDeuda(ctx, id, database.NowInArgentina()); err != nil {
+			return nil, err
+		}
+		if _, err := outboxRepo.Registrar(ctx, modelos.EventoCuentaConexionSuspendida,
+			modelos.PayloadConexionCuenta{IDConexion: id, SaldoVencido: cuenta.SaldoVencido}); err != nil {
+			return nil, fmt.Errorf("error registrando evento: %w", err)
+		}
+		suspendidas = append(suspendidas, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+	if len(suspendidas) > 0 {
+		logger.Info.Printf("Conexión(es) %v de la persona %d suspendida(s) por deuda ($%.2f vencidos, %d días de atraso)",
+			suspendidas, idPersona, cuenta.SaldoVencido, cuenta.DiasAtraso)
+	}
+	return suspendidas, nil
+}
+
+// restablecer reactiva las conexiones que suspendió la revisión de deuda si la persona ya no
+// tiene deuda fuera del período de gracia
+func (s *CuentaService) restablecer(ctx context.Context, idPersona int) ([]int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	restablecidas, err := s.RestablecerEnTransaccion(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+	if len(restablecidas) > 0 {
+		logger.Info.Printf("Conexión(es) %v de la persona %d restablecida(s) tras ponerse al día", restablecidas, idPersona)
+	}
+	return restablecidas, nil
+}
+
+// RestablecerEnTransaccion es restablecer dentro de la transacción que registra un crédito,
+// para que la cuenta se lea con el crédito incluido; quien llama confirma la transacción.
+// Una conexión cuyo contrato está suspendido se deja como está: se reactiva desde el contrato.
+func (s *CuentaService) RestablecerEnTransaccion(ctx context.Context, tx *sql.Tx, idPersona int) ([]int, error) {
+	repo := repositorios.NewCuentaRepo(tx)
+	movimientos, err := repo.ListarMovimientos(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	if s.fueraDeGracia(resumirCuenta(movimientos, hoyArgentina())) {
+		return []int{}, nil
+	}
+
+	ids, err := repo.ListarSuspendidasPorDeuda(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	ciclo := NewCicloVida(tx)
+	outboxRepo := repositorios.NewOutboxRepo(tx)
+	obs := "Restablecida: la cuenta no tiene deuda vencida fuera del período de gracia"
+	restablecidas := []int{}
+	for _, id := range ids {
+		aplicada, err := ciclo.transicionarSiEsta(ctx, maquinaConexion, id,
+			[]string{modelos.EstadoConexionSuspendida}, modelos.EstadoConexionActiva, ActorSistema, &obs)
+		var invalida utilidades.ErrTransicionInvalida
+		if errors.As(err, &invalida) {
+			logger.Debug.Printf("La conexión %d sigue suspendida: %v", id, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !aplicada {
+			continue
+		}
+		if _, err := outboxRepo.Registrar(ctx, modelos.EventoCuentaConexionRestablecida, modelos.PayloadConexionCuenta{IDConexion: id}); err != nil {
+			return nil, fmt.Errorf("error registrando evento: %w", err)
+		}
+		restablecidas = append(restablecidas, id)
+	}
+	return restablecidas, nil
+}
+
+// MiCuenta devuelve la cuenta del titular con sus movimientos paginados
+func (s *CuentaService) MiCuenta(ctx context.Context, idPersona, page, limit int) (*modelos.CuentaResponse, error) {
+	return s.cuenta(ctx, idPersona, page, limit)
+}
+
+// CuentaDeCliente devuelve la cuenta de cualquier cliente (cuentas.ver)
+func (s *CuentaService) CuentaDeCliente(ctx context.Context, idCliente, page, limit, idPersona int) (*modelos.CuentaResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoCuentasVer); err != nil {
+		return nil, err
+	}
+	if err := s.verificarCliente(ctx, idCliente); err != nil {
+		return nil, err
+	}
+	return s.cuenta(ctx, idCliente, page, limit)
+}
+
+func (s *CuentaService) cuenta(ctx context.Context, idPersona, page, limit int) (*modelos.CuentaResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	resumen, movimientos, err := s.resumen(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	// Del más reciente al más antiguo
+	total := len(movimientos)
+	pagina := []modelos.MovimientoCuenta{}
+	for i := total - 1 - (page-1)*limit; i >= 0 && len(pagina) < limit; i-- {
+		pagina = append(pagina, movimientos[i])
+	}
+
+	totalPages := (total + limit - 1) / limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &modelos.CuentaResponse{
+		IDPersona:   idPersona,
+		Resumen:     *resumen,
+		Movimientos: pagina,
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+	}, nil
+}
+
+// resumen calcula el estado de la cuenta y devuelve los movimientos con el saldo de cada uno
+func (s *CuentaService) resumen(ctx context.Context, idPersona int) (*modelos.ResumenCuenta, []modelos.MovimientoCuenta, error) {
+	repo := repositorios.NewCuentaRepo(s.db)
+	movimientos, err := repo.ListarMovimientos(ctx, idPersona)
+	if err != nil {
+		return nil, nil, err
+	}
+	resumen := resumirCuenta(movimientos, hoyArgentina())
+	if resumen.ConexionesSuspendidas, err = repo.ListarSuspendidasPorDeuda(ctx, idPersona); err != nil {
+		return nil, nil, err
+	}
+	return &resumen, movimientos, nil
+}
+
+// Deudores lista los clientes con saldo a favor de la empresa, de mayor a menor deuda, con
+// los totales por antigüedad (cuentas.ver)
+func (s *CuentaService) Deudores(ctx context.Context, idPersona int) (*modelos.DeudoresResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoCuentasVer); err != nil {
+		return nil, err
+	}
+
+	deudores, err := repositorios.NewCuentaRepo(s.db).ListarDeudores(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &modelos.DeudoresResponse{Deudores: deudores, DiasGracia: s.diasGracia}
+	for i := range resp.Deudores {
+		d := &resp.Deudores[i]
+		resumen, _, err := s.resumen(ctx, d.IDPersona)
+		if err != nil {
+			return nil, err
+		}
+		d.ResumenCuenta = *resumen
+
+		resp.Total += d.Saldo
+		resp.TotalVencido += d.SaldoVencido
+		resp.Antiguedad.PorVencer += d.Antiguedad.PorVencer
+		resp.Antiguedad.Dias1a30 += d.Antiguedad.Dias1a30
+		resp.Antiguedad.Dias31a60 += d.Antiguedad.Dias31a60
+		resp.Antiguedad.Dias61a90 += d.Antiguedad.Dias61a90
+		resp.Antiguedad.MasDe90 += d.Antiguedad.MasDe90
+	}
+	resp.Total = redondearImporte(resp.Total)
+	resp.TotalVencido = redondearImporte(resp.TotalVencido)
+	redondearAntiguedad(&resp.Antiguedad)
+	return resp, nil
+}
+
+// RegistrarPago acredita un pago recibido fuera de la pasarela (cuentas.gestionar). Si con el pago el
+// cliente se pone al día, se restablecen en el momento las conexiones suspendidas por deuda.
+func (s *CuentaService) RegistrarPago(ctx context.Context, idCliente int, req modelos.RegistrarPagoCuentaRequest, idPersona int) (*modelos.MovimientoRegistradoResponse, error) {
+	idUsuario, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoCuentasGestionar)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validar
+	importe, err := validarImporteCuenta(req.Importe)
+	if err != nil {
+		return nil, err
+	}
+	req.MedioPago = strings.ToLower(strings.TrimSpace(req.MedioPago))
+	concepto, ok := conceptosMedioPago[req.MedioPago]
+	if !ok {
+		return nil, utilidades.ErrValidation{Campo: "medio_pago", Mensaje: "debe ser 'efectivo', 'transferencia', 'debito' o 'cheque'"}
+	}
+	fecha, err := validarFechaPago(req.Fecha)
+	if err != nil {
+		return nil, err
+	}
+	if req.Referencia != nil {
+		ref := strings.TrimSpace(*req.Referencia)
+		if len(ref) > 100 {
+			return nil, utilidades.ErrValidation{Campo: "referencia", Mensaje: "no puede superar los 100 caracteres"}
+		}
+		req.Referencia = &ref
+		if ref == "" {
+			req.Referencia = nil
+		}
+	}
+	if err := s.verificarCliente(ctx, idCliente); err != nil {
+		return nil, err
+	}
+
+	// 2. Registrar
+	m := &modelos.MovimientoCuenta{
+		IDPersona:  idCliente,
+		Tipo:       modelos.MovimientoPago,
+		Concepto:   concepto,
+		Importe:    importe,
+		Fecha:      fecha,
+		MedioPago:  &req.MedioPago,
+		Referencia: req.Referencia,
+		IDUsuario:  &idUsuario,
+	}
+	resp, err := s.registrarMovimiento(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Pago de $%.2f (%s) registrado en la cuenta de la persona %d por la persona %d", importe, req.MedioPago, idCliente, idPersona)
+	return resp, nil
+}
+
+// RegistrarNotaCredito acredita un importe en la cuenta del cliente (cuentas.gestionar). Si indica
+// una factura, tiene que ser del cliente y no puede superar su total.
+func (s *CuentaService) RegistrarNotaCredito(ctx context.Context, idCliente int, req modelos.RegistrarNotaCreditoRequest, idPersona int) (*modelos.MovimientoRegistradoResponse, error) {
+	idUsuario, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoCuentasGestionar)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validar
+	importe, err := validarImporteCuenta(req.Importe)
+	if err != nil {
+		return nil, err
+	}
+	concepto := strings.TrimSpace(req.Concepto)
+	if concepto == "" {
+		return nil, utilidades.ErrValidation{Campo: "concepto", Mensaje: "es requerido"}
+	}
+	if err := s.verificarCliente(ctx, idCliente); err != nil {
+		return nil, err
+	}
+	if req.IDFactura != nil {
+		f, err := repositorios.NewFacturaRepo(s.db).ObtenerPorID(ctx, *req.IDFactura)
+		var noEncontrada utilidades.ErrNotFound
+		if errors.As(err, &noEncontrada) || (err == nil && f.IDPersona != idCliente) {
+			return nil, utilidades.ErrValidation{Campo: "id_factura", Mensaje: "no es una factura del cliente"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.NumeroComprobante == "" {
+			return nil, utilidades.ErrValidation{Campo: "id_factura", Mensaje: "la factura todavía no fue emitida"}
+		}
+		if importe > f.Total {
+			return nil, utilidades.ErrValidation{Campo: "importe", Mensaje: fmt.Sprintf("no puede superar el total de la factura ($%.2f)", f.Total)}
+		}
+		concepto = fmt.Sprintf("Nota de crédito s/ factura %s %s: %s", f.TipoComprobante, f.NumeroComprobante, concepto)
+	} else {
+		concepto = "Nota de crédito: " + concepto
+	}
+	if len(concepto) > 255 {
+		return nil, utilidades.ErrValidation{Campo: "concepto", Mensaje: "es demasiado largo"}
+	}
+
+	// 2. Registrar
+	m := &modelos.MovimientoCuenta{
+		IDPersona: idCliente,
+		Tipo:      modelos.MovimientoNotaCredito,
+		Concepto:  concepto,
+		Importe:   importe,
+		Fecha:     hoyArgentina().Format("2006-01-02"),
+		IDFactura: req.IDFactura,
+		IDUsuario: &idUsuario,
+	}
+	resp, err := s.registrarMovimiento(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Nota de crédito de $%.2f registrada en la cuenta de la persona %d por la persona %d", importe, idCliente, idPersona)
+	return resp, nil
+}
+
+// registrarMovimiento guarda el crédito, restablece lo que corresponda y devuelve la cuenta
+func (s *CuentaService) registrarMovimiento(ctx context.Context, m *modelos.MovimientoCuenta) (*modelos.MovimientoRegistradoResponse, error) {
+	id, err := repositorios.NewCuentaRepo(s.db).Registrar(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &modelos.MovimientoRegistradoResponse{Restablecidas: []int{}}
+	if ids, err := s.restablecer(ctx, m.IDPersona); err != nil {
+		// El crédito ya quedó registrado: la próxima revisión vuelve a intentarlo
+		logger.Error.Printf("Error restableciendo las conexiones de la persona %d: %v", m.IDPersona, err)
+	} else {
+		resp.Restablecidas = ids
+	}
+
+	resumen, movimientos, err := s.resumen(ctx, m.IDPersona)
+	if err != nil {
+		return nil, err
+	}
+	resp.Resumen = *resumen
+	for _, mov := range movimientos {
+		if mov.IDMovimiento == id {
+			resp.Movimiento = mov
+		}
+	}
+	return resp, nil
+}
+
+// resumirCuenta completa el saldo de cada movimiento y calcula la deuda vencida y su
+// antigüedad a la fecha hoy. Los créditos se imputan a los cargos por orden de vencimiento.
+func resumirCuenta(movimientos []modelos.MovimientoCuenta, hoy time.Time) modelos.ResumenCuenta {
+	r := modelos.ResumenCuenta{ConexionesSuspendidas: []int{}}
+
+	// 1. Saldo corrido y total de créditos
+	saldo, creditos := 0.0, 0.0
+	cargos := []modelos.MovimientoCuenta{}
+	for i := range movimientos {
+		if movimientos[i].Tipo == modelos.MovimientoCargo {
+			saldo += movimientos[i].Importe
+			cargos = append(cargos, movimientos[i])
+		} else {
+			saldo -= movimientos[i].Importe
+			creditos += movimientos[i].Importe
+		}
+		movimientos[i].Saldo = redondearImporte(saldo)
+	}
+	r.Saldo = redondearImporte(saldo)
+
+	// 2. Imputar los créditos y repartir lo pendiente de cada cargo según su atraso
+	sort.SliceStable(cargos, func(i, j int) bool { return vencimientoCargo(cargos[i]) < vencimientoCargo(cargos[j]) })
+	for _, c := range cargos {
+		pendiente := c.Importe
+		imputado := math.Min(creditos, pendiente)
+		creditos -= imputado
+		pendiente = redondearImporte(pendiente - imputado)
+		if pendiente <= 0 {
+			continue
+		}
+
+		vence := vencimientoCargo(c)
+		dias := 0
+		if t, err := time.ParseInLocation("2006-01-02", vence, hoy.Location()); err == nil {
+			dias = int(math.Round(hoy.Sub(t).Hours() / 24))
+		}
+		if r.VencimientoMasAntiguo == nil {
+			r.VencimientoMasAntiguo = &vence
+			if dias > 0 {
+				r.DiasAtraso = dias
+			}
+		}
+		if dias > 0 {
+			r.SaldoVencido += pendiente
+		}
+		switch {
+		case dias <= 0:
+			r.Antiguedad.PorVencer += pendiente
+		case dias <= 30:
+			r.Antiguedad.Dias1a30 += pendiente
+		case dias <= 60:
+			r.Antiguedad.Dias31a60 += pendiente
+		case dias <= 90:
+			r.Antiguedad.Dias61a90 += pendiente
+		default:
+			r.Antiguedad.MasDe90 += pendiente
+		}
+	}
+	r.SaldoVencido = redondearImporte(r.SaldoVencido)
+	redondearAntiguedad(&r.Antiguedad)
+	return r
+}
+
+// vencimientoCargo es la fecha de vencimiento del cargo (AAAA-MM-DD), o la del cargo si no tiene
+func vencimientoCargo(m modelos.MovimientoCuenta) string {
+	if m.FechaVencimiento != nil {
+		return *m.FechaVencimiento
+	}
+	return m.Fecha
+}
+
+func redondearAntiguedad(a *modelos.AntiguedadDeuda) {
+	a.PorVencer = redondearImporte(a.PorVencer)
+	a.Dias1a30 = redondearImporte(a.Dias1a30)
+	a.Dias31a60 = redondearImporte(a.Dias31a60)
+	a.Dias61a90 = redondearImporte(a.Dias61a90)
+	a.MasDe90 = redondearImporte(a.MasDe90)
+}
+
+// hoyArgentina es la fecha de hoy, sin hora, en la zona horaria de Argentina
+func hoyArgentina() time.Time {
+	ahora := database.NowInArgentina()
+	return soloFecha(ahora, ahora.Location())
+}
+
+func validarImporteCuenta(importe float64) (float64, error) {
+	importe = redondearImporte(importe)
+	if importe <= 0 {
+		return 0, utilidades.ErrValidation{Campo: "importe", Mensaje: "debe ser mayor a 0"}
+	}
+	if importe >= 1e10 {
+		return 0, utilidades.ErrValidation{Campo: "importe", Mensaje: "es demasiado grande"}
+	}
+	return importe, nil
+}
+
+// validarFechaPago acepta una fecha AAAA-MM-DD que no sea futura; vacía es hoy
+func validarFechaPago(fecha string) (string, error) {
+	hoy := hoyArgentina()
+	fecha = strings.TrimSpace(fecha)
+	if fecha == "" {
+		return hoy.Format("2006-01-02"), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", fecha, hoy.Location())
+	if err != nil {
+		return "", utilidades.ErrValidation{Campo: "fecha", Mensaje: "debe tener formato AAAA-MM-DD"}
+	}
+	if t.After(hoy) {
+		return "", utilidades.ErrValidation{Campo: "fecha", Mensaje: "no puede ser una fecha futura"}
+	}
+	return fecha, nil
+}
+
+// verificarCliente exige que la persona de la cuenta exista
+func (s *CuentaService) verificarCliente(ctx context.Context, idCliente int) error {
+	existe, err := repositorios.NewCuentaRepo(s.db).ExistePersona(ctx, idCliente)
+	if err != nil {
+		return err
+	}
+	if !existe {
+		return utilidades.ErrNotFound{Entity: "persona", Campo: "id_persona", Valor: fmt.Sprintf("%d", idCliente)}
+	}
+	return nil
+}
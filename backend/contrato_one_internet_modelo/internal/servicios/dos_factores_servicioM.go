@@ -0,0 +1,428 @@
+package servicios
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/config"
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+	"contrato_one_internet_modelo/internal/utilidades/qr"
+)
+
+const (
+	cantidadCodigosRecuperacion = 10
+	digitosCodigoEmail          = 6
+)
+
+// DosFactoresService administra la autenticación en dos pasos: el alta del TOTP con sus
+// códigos de recuperación, el código por email de respaldo y la validación del segundo
+// factor durante el login.
+//
+// El secreto TOTP se guarda cifrado y los códigos de un solo uso como HMAC, ambos con
+// DOS_FACTORES_CLAVE. Qué roles están obligados a usarla lo decide el controlador.
+type DosFactoresService struct {
+	db  *sql.DB
+	cfg config.DosFactoresConfig
+}
+
+func NewDosFactoresService(db *sql.DB, cfg config.DosFactoresConfig) *DosFactoresService {
+	return &DosFactoresService{db: db, cfg: cfg}
+}
+
+// Metodos devuelve los segundos factores que acepta el login de un usuario con TOTP activo
+func (s *DosFactoresService) Metodos() []string {
+	return []string{modelos.MetodoDosFactoresTOTP, modelos.MetodoDosFactoresEmail, modelos.MetodoDosFactoresRecuperacion}
+}
+
+// Activa indica si el usuario tiene la autenticación en dos pasos activa
+func (s *DosFactoresService) Activa(ctx context.Context, idUsuario int) (bool, error) {
+	d, err := repositorios.NewDosFactoresRepo(s.db).Obtener(ctx, idUsuario)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return false, nil
+		}
+		return false, err
+	}
+	return d.Activo, nil
+}
+
+// Estado devuelve la autenticación en dos pasos de la persona que hace el pedido
+func (s *DosFactoresService) Estado(ctx context.Context, idPersona int) (*modelos.EstadoDosFactores, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := repositorios.NewDosFactoresRepo(s.db)
+	estado := &modelos.EstadoDosFactores{}
+	d, err := repo.Obtener(ctx, idUsuario)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return estado, nil
+		}
+		return nil, err
+	}
+
+	estado.Activo = d.Activo
+	estado.Pendiente = !d.Activo
+	if d.Activo {
+		estado.Metodos = s.Metodos()
+		if estado.CodigosRecuperacionLibres, err = repo.ContarCodigosRecuperacionLibres(ctx, idUsuario); err != nil {
+			return nil, err
+		}
+	}
+	return estado, nil
+}
+
+// IniciarTOTP genera un secreto nuevo para que el usuario lo cargue en su app. Queda
+// pendiente hasta que ActivarTOTP confirme un código generado con él.
+func (s *DosFactoresService) IniciarTOTP(ctx context.Context, idPersona int) (*modelos.InicioTOTPResponse, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	activa, err := s.Activa(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if activa {
+		return nil, utilidades.ErrDosFactoresYaActivo
+	}
+
+	email, err := repositorios.NewUsuarioRepo(s.db).ObtenerEmail(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+
+	secreto, err := utilidades.GenerarSecretoTOTP()
+	if err != nil {
+		return nil, fmt.Errorf("error generando secreto TOTP: %w", err)
+	}
+	cifrado, err := utilidades.Cifrar(s.cfg.ClaveCifrado, secreto)
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando secreto TOTP: %w", err)
+	}
+	if err := repositorios.NewDosFactoresRepo(s.db).GuardarPendiente(ctx, idUsuario, cifrado); err != nil {
+		return nil, err
+	}
+
+	uri := utilidades.URIProvisionTOTP(s.cfg.Emisor, email, secreto)
+	imagenQR, err := qrComoDataURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.InicioTOTPResponse{Secreto: secreto, URI: uri, QR: imagenQR}, nil
+}
+
+// ActivarTOTP confirma el secreto pendiente con un código de la app y devuelve los códigos de
+// recuperación. Las sesiones abiertas sin segundo factor se cierran: hay que volver a entrar.
+func (s *DosFactoresService) ActivarTOTP(ctx context.Context, idPersona int, codigo string) (*modelos.CodigosRecuperacionResponse, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := repositorios.NewDosFactoresRepo(s.db).Obtener(ctx, idUsuario)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return nil, utilidades.ErrValidation{Campo: "codigo", Mensaje: "primero hay que iniciar la configuración del autenticador"}
+		}
+		return nil, err
+	}
+	if d.Activo {
+		return nil, utilidades.ErrDosFactoresYaActivo
+	}
+	paso, ok, err := s.validarTOTP(d, codigo)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, utilidades.ErrCodigoDosFactoresInvalido
+	}
+
+	codigos, hashes, err := s.generarCodigosRecuperacion()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewDosFactoresRepo(tx)
+	if err := repo.Activar(ctx, idUsuario, paso); err != nil {
+		return nil, err
+	}
+	if err := repo.ReemplazarCodigosRecuperacion(ctx, idUsuario, hashes); err != nil {
+		return nil, err
+	}
+	if _, err := repositorios.NewSesionRepo(tx).RevocarTodas(ctx, idUsuario, 0, modelos.MotivoSesionDosFactores); err != nil {
+		return nil, fmt.Errorf("error cerrando sesiones del usuario %d: %w", idUsuario, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Autenticación en dos pasos activada para el usuario %d", idUsuario)
+	return &modelos.CodigosRecuperacionResponse{CodigosRecuperacion: codigos}, nil
+}
+
+// RegenerarCodigosRecuperacion reemplaza los códigos de recuperación, previa confirmación con
+// un código de la app
+func (s *DosFactoresService) RegenerarCodigosRecuperacion(ctx context.Context, idPersona int, codigo string) (*modelos.CodigosRecuperacionResponse, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.confirmarTOTP(ctx, idUsuario, codigo); err != nil {
+		return nil, err
+	}
+
+	codigos, hashes, err := s.generarCodigosRecuperacion()
+	if err != nil {
+		return nil, err
+	}
+	if err := repositorios.NewDosFactoresRepo(s.db).ReemplazarCodigosRecuperacion(ctx, idUsuario, hashes); err != nil {
+		return nil, err
+	}
+	return &modelos.CodigosRecuperacionResponse{CodigosRecuperacion: codigos}, nil
+}
+
+// Desactivar quita la autenticación en dos pasos del usuario, previa confirmación con un
+// código de la app o de recuperación
+func (s *DosFactoresService) Desactivar(ctx context.Context, idPersona int, codigo string) error {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return err
+	}
+	activa, err := s.Activa(ctx, idUsuario)
+	if err != nil {
+		return err
+	}
+	if !activa {
+		return utilidades.ErrDosFactoresInactivo
+	}
+
+	ok, err := s.VerificarCodigo(ctx, idUsuario, modelos.MetodoDosFactoresTOTP, codigo)
+	if err == nil && !ok {
+		ok, err = s.VerificarCodigo(ctx, idUsuario, modelos.MetodoDosFactoresRecuperacion, codigo)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return utilidades.ErrCodigoDosFactoresInvalido
+	}
+
+	if err := repositorios.NewDosFactoresRepo(s.db).Eliminar(ctx, idUsuario); err != nil {
+		return err
+	}
+	logger.Info.Printf("Autenticación en dos pasos desactivada por el usuario %d", idUsuario)
+	return nil
+}
+
+// Restablecer quita la autenticación en dos pasos de otro usuario que perdió su dispositivo
+// y cierra sus sesiones (usuarios.restablecer_2fa). En el próximo login tendrá que configurarla de nuevo.
+func (s *DosFactoresService) Restablecer(ctx context.Context, idUsuario, idPersona int) error {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoUsuariosRestablecer2FA); err != nil {
+		return err
+	}
+	if _, err := repositorios.NewUsuarioRepo(s.db).ObtenerEmail(ctx, idUsuario); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := repositorios.NewDosFactoresRepo(tx).Eliminar(ctx, idUsuario); err != nil {
+		return err
+	}
+	if _, err := repositorios.NewSesionRepo(tx).RevocarTodas(ctx, idUsuario, 0, modelos.MotivoSesionDosFactores); err != nil {
+		return fmt.Errorf("error cerrando sesiones del usuario %d: %w", idUsuario, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Autenticación en dos pasos del usuario %d restablecida por la persona %d", idUsuario, idPersona)
+	return nil
+}
+
+// GenerarCodigoEmail crea el código de respaldo que el controlador envía por email. Sólo
+// existe como alternativa al TOTP: sin TOTP activo no hay segundo factor que reemplazar.
+func (s *DosFactoresService) GenerarCodigoEmail(ctx context.Context, idUsuario int) (*modelos.CodigoEmailResponse, error) {
+	activa, err := s.Activa(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if !activa {
+		return nil, utilidades.ErrDosFactoresInactivo
+	}
+	email, err := repositorios.NewUsuarioRepo(s.db).ObtenerEmail(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+
+	codigo, err := utilidades.GenerarCodigoNumerico(digitosCodigoEmail)
+	if err != nil {
+		return nil, fmt.Errorf("error generando código por email: %w", err)
+	}
+	expira, err := repositorios.NewDosFactoresRepo(s.db).CrearCodigoEmail(ctx, idUsuario,
+		utilidades.HashCodigo(s.cfg.ClaveCifrado, codigo), s.cfg.VigenciaCodigoEmail)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.CodigoEmailResponse{Email: email, Codigo: codigo, ExpiresAt: expira.Format(time.RFC3339)}, nil
+}
+
+// VerificarCodigo valida y consume un código del método indicado. Devuelve false si el
+// código no corresponde; los errores son sólo de validación o de base de datos.
+func (s *DosFactoresService) VerificarCodigo(ctx context.Context, idUsuario int, metodo, codigo string) (bool, error) {
+	repo := repositorios.NewDosFactoresRepo(s.db)
+	codigo = strings.TrimSpace(codigo)
+	if codigo == "" {
+		return false, utilidades.ErrValidation{Campo: "codigo", Mensaje: "es obligatorio"}
+	}
+
+	switch metodo {
+	case modelos.MetodoDosFactoresTOTP:
+		d, err := repo.Obtener(ctx, idUsuario)
+		if err != nil {
+			var noEncontrado utilidades.ErrNotFound
+			if errors.As(err, &noEncontrado) {
+				return false, nil
+			}
+			return false, err
+		}
+		if !d.Activo {
+			return false, nil
+		}
+		paso, ok, err := s.validarTOTP(d, codigo)
+		if err != nil || !ok {
+			return false, err
+		}
+		return repo.UsarPasoTOTP(ctx, idUsuario, paso)
+
+	case modelos.MetodoDosFactoresRecuperacion:
+		hash := utilidades.HashCodigo(s.cfg.ClaveCifrado, utilidades.NormalizarCodigoRecuperacion(codigo))
+		ok, err := repo.UsarCodigoRecuperacion(ctx, idUsuario, hash)
+		if err != nil || !ok {
+			return false, err
+		}
+		if libres, err := repo.ContarCodigosRecuperacionLibres(ctx, idUsuario); err == nil && libres <= 2 {
+			logger.Info.Printf("Al usuario %d le quedan %d códigos de recuperación", idUsuario, libres)
+		}
+		return true, nil
+
+	case modelos.MetodoDosFactoresEmail:
+		idCodigo, hash, err := repo.CodigoEmailVigente(ctx, idUsuario)
+		if err != nil {
+			var noEncontrado utilidades.ErrNotFound
+			if errors.As(err, &noEncontrado) {
+				return false, nil
+			}
+			return false, err
+		}
+		if !hmac.Equal([]byte(hash), []byte(utilidades.HashCodigo(s.cfg.ClaveCifrado, codigo))) {
+			if err := repo.SumarIntentoCodigoEmail(ctx, idCodigo, s.cfg.MaxIntentosCodigo); err != nil {
+				logger.Error.Printf("Error contando intento del código por email del usuario %d: %v", idUsuario, err)
+			}
+			return false, nil
+		}
+		return repo.UsarCodigoEmail(ctx, idCodigo)
+
+	default:
+		return false, utilidades.ErrValidation{Campo: "metodo", Mensaje: "debe ser 'totp', 'email' o 'recuperacion'"}
+	}
+}
+
+// confirmarTOTP exige un código válido de la app de un usuario con TOTP activo
+func (s *DosFactoresService) confirmarTOTP(ctx context.Context, idUsuario int, codigo string) error {
+	activa, err := s.Activa(ctx, idUsuario)
+	if err != nil {
+		return err
+	}
+	if !activa {
+		return utilidades.ErrDosFactoresInactivo
+	}
+	ok, err := s.VerificarCodigo(ctx, idUsuario, modelos.MetodoDosFactoresTOTP, codigo)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return utilidades.ErrCodigoDosFactoresInvalido
+	}
+	return nil
+}
+
+// validarTOTP descifra el secreto y valida el código sin consumirlo
+func (s *DosFactoresService) validarTOTP(d *modelos.DosFactoresUsuario, codigo string) (int64, bool, error) {
+	secreto, err := utilidades.Descifrar(s.cfg.ClaveCifrado, d.SecretoCifrado)
+	if err != nil {
+		return 0, false, fmt.Errorf("error descifrando secreto TOTP del usuario %d: %w", d.IDUsuario, err)
+	}
+	return utilidades.ValidarTOTP(secreto, codigo, time.Now(), d.UltimoPasoTOTP)
+}
+
+// generarCodigosRecuperacion devuelve los códigos para mostrar y sus hashes para guardar
+func (s *DosFactoresService) generarCodigosRecuperacion() ([]string, []string, error) {
+	codigos := make([]string, 0, cantidadCodigosRecuperacion)
+	hashes := make([]string, 0, cantidadCodigosRecuperacion)
+	for len(codigos) < cantidadCodigosRecuperacion {
+		c, err := utilidades.GenerarCodigoRecuperacion()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error generando códigos de recuperación: %w", err)
+		}
+		codigos = append(codigos, c)
+		hashes = append(hashes, utilidades.HashCodigo(s.cfg.ClaveCifrado, utilidades.NormalizarCodigoRecuperacion(c)))
+	}
+	return codigos, hashes, nil
+}
+
+// usuarioDePersona resuelve el usuario de la persona que hace el pedido
+func (s *DosFactoresService) usuarioDePersona(ctx context.Context, idPersona int) (int, error) {
+	if idPersona <= 0 {
+		return 0, utilidades.ErrAccesoDenegado
+	}
+	idUsuario, err := repositorios.NewUsuarioRepo(s.db).ObtenerIDUsuarioPorPersona(ctx, idPersona)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return 0, utilidades.ErrAccesoDenegado
+		}
+		return 0, err
+	}
+	return idUsuario, nil
+}
+
+// qrComoDataURI dibuja el QR del texto y lo devuelve como data URI PNG
+func qrComoDataURI(texto string) (string, error) {
+	codigo, err := qr.Codificar(texto)
+	if err != nil {
+		return "", fmt.Errorf("error generando QR: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, codigo.Imagen(6)); err != nil {
+		return "", fmt.Errorf("error codificando QR: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,393 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/config"
+	"contrato_one_internet_modelo/internal/database"
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/afip"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+	"contrato_one_internet_modelo/internal/utilidades/pdf"
+)
+
+const (
+	// TimeoutAFIP limita cada llamada a los web services de AFIP
+	TimeoutAFIP = 30 * time.Second
+
+	// TimeoutAutorizacionAFIP limita el total de las llamadas a AFIP de una factura, que se
+	// hacen con la numeración bloqueada. Es menor que innodb_lock_wait_timeout (50 s por
+	// defecto), así que otra corrida que espere el bloqueo no falla por eso.
+	TimeoutAutorizacionAFIP = 20 * time.Second
+
+	// LoteAutorizacionAFIP es la cantidad máxima de facturas que se autorizan por corrida
+	LoteAutorizacionAFIP = 500
+
+	// largoMaxObservacionesAFIP es el largo de la columna factura.afip_observaciones
+	largoMaxObservacionesAFIP = 1000
+)
+
+// codigosComprobanteAFIP traduce la letra de la factura al tipo de comprobante de AFIP
+var codigosComprobanteAFIP = map[string]int{
+	modelos.ComprobanteFacturaA: afip.CbteFacturaA,
+	modelos.ComprobanteFacturaB: afip.CbteFacturaB,
+	modelos.ComprobanteFacturaC: afip.CbteFacturaC,
+}
+
+// AutorizadorComprobantes obtiene el CAE de los comprobantes. Lo implementa *afip.WSFE.
+type AutorizadorComprobantes interface {
+	// CUIT es el CUIT emisor con el que se autorizan los comprobantes
+	CUIT() int64
+	// UltimoAutorizado devuelve el último número autorizado del tipo y punto de venta
+	UltimoAutorizado(ctx context.Context, puntoVenta, tipo int) (int, error)
+	// SolicitarCAE pide la autorización de un comprobante
+	SolicitarCAE(ctx context.Context, c afip.Comprobante) (*afip.Autorizacion, error)
+	// ConsultarComprobante devuelve un comprobante ya autorizado
+	ConsultarComprobante(ctx context.Context, puntoVenta, tipo, numero int) (*afip.ComprobanteAutorizado, error)
+}
+
+// NewAutorizadorAFIP arma el cliente del WSFEv1 según AFIP_MODO. Con la factura electrónica
+// deshabilitada devuelve nil.
+func NewAutorizadorAFIP(db *sql.DB, cfg config.AfipConfig) (AutorizadorComprobantes, error) {
+	if cfg.Modo == "deshabilitado" {
+		return nil, nil
+	}
+
+	// 1. CUIT emisor
+	cuitTexto := cfg.CUIT
+	if cuitTexto == "" {
+		cuitTexto = modelos.EmpresaOperadoraCUIT
+	}
+	cuitTexto = utilidades.NormalizarCUIT(cuitTexto)
+	if err := utilidades.ValidarCUIT(cuitTexto); err != nil {
+		return nil, fmt.Errorf("AFIP_CUIT inválido: %w", err)
+	}
+	cuit, _ := strconv.ParseInt(cuitTexto, 10, 64)
+
+	// 2. Transporte, firma del TRA y URLs según el modo
+	var transporte afip.Transporte = afip.NuevoTransporteHTTP(TimeoutAFIP)
+	var firmador afip.Firmador
+	urlWSAA, urlWSFE := cfg.WSAAURL, cfg.WSFEURL
+	switch cfg.Modo {
+	case "fake":
+		firmador = afip.FirmadorFake{}
+		if urlWSAA == "" {
+			// Servidor fake en el mismo proceso, arrancando desde la numeración local
+			fake := afip.NuevoServidorFake()
+			numeraciones, err := repositorios.NewFacturaRepo(db).Numeraciones(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range numeraciones {
+				fake.FijarUltimo(n.PuntoVenta, codigosComprobanteAFIP[n.TipoComprobante], n.UltimoNumero)
+			}
+			transporte = afip.TransporteLocal{Handler: fake}
+			urlWSAA, urlWSFE = "http://afip-fake/ws/services/LoginCms", "http://afip-fake/wsfev1/service.asmx"
+		}
+	case "homologacion", "produccion":
+		firmante, err := pdf.CargarFirmantePKCS12(cfg.PKCS12Path, cfg.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo cargar el certificado de AFIP: %w", err)
+		}
+		firmador = firmante
+		if urlWSAA == "" && cfg.Modo == "homologacion" {
+			urlWSAA, urlWSFE = afip.URLWSAAHomologacion, afip.URLWSFEHomologacion
+		} else if urlWSAA == "" {
+			urlWSAA, urlWSFE = afip.URLWSAAProduccion, afip.URLWSFEProduccion
+		}
+	default:
+		return nil, fmt.Errorf("AFIP_MODO desconocido: %q", cfg.Modo)
+	}
+
+	wsaa := afip.NuevoWSAA(transporte, urlWSAA, firmador, repositorios.NewAfipTicketRepo(db, cfg.Modo))
+	logger.Info.Printf("Factura electrónica AFIP en modo %s (CUIT %d, WSFEv1 %s)", cfg.Modo, cuit, urlWSFE)
+	return afip.NuevoWSFE(transporte, urlWSFE, wsaa, cuit), nil
+}
+
+// AutorizarPendientes pide, en orden de emisión, el CAE de las facturas pendientes. Un
+// rechazo de AFIP marca la factura como rechazada con los motivos; cualquier otro error
+// (red, autenticación, numeración) la deja pendiente para la próxima corrida y corta la
+// corrida, porque el resto de las facturas fallaría igual.
+func (s *FacturacionService) AutorizarPendientes(ctx context.Context) (autorizadas, rechazadas int) {
+	repo := repositorios.NewFacturaRepo(s.db)
+	ids, err := repo.ListarPendientesAFIP(ctx, LoteAutorizacionAFIP)
+	if err != nil {
+		logger.Error.Printf("Error listando facturas pendientes de CAE: %v", err)
+		return 0, 0
+	}
+
+	for _, idFactura := range ids {
+		estado, err := s.autorizarFactura(ctx, idFactura)
+		if err != nil {
+			logger.Error.Printf("Error autorizando la factura %d en AFIP: %v", idFactura, err)
+			if errReg := repo.RegistrarResultadoAFIP(ctx, idFactura, false, recortarObservaciones(err.Error())); errReg != nil {
+				logger.Error.Printf("Error registrando el intento de autorización de la factura %d: %v", idFactura, errReg)
+			}
+			break
+		}
+
+		switch estado {
+		case modelos.EstadoAfipAutorizada:
+			autorizadas++
+			if _, err := s.generarPDF(ctx, idFactura); err != nil {
+				logger.Error.Printf("Error generando el PDF de la factura %d: %v", idFactura, err)
+			}
+		case modelos.EstadoAfipRechazada:
+			rechazadas++
+		}
+	}
+	return autorizadas, rechazadas
+}
+
+// autorizarFactura obtiene el número y el CAE de una factura pendiente y devuelve su estado
+// final. La fila de numeración del tipo y punto de venta queda bloqueada durante la consulta
+// a AFIP, así que dos procesos no pueden pedir el mismo número; para no retener el bloqueo,
+// las llamadas a AFIP se cortan a los TimeoutAutorizacionAFIP. Si la respuesta de AFIP se
+// pierde (corte, caída del proceso), la corrida siguiente encuentra el número autorizado en
+// AFIP y, si es esta factura, guarda ese CAE en lugar de pedir otro.
+func (s *FacturacionService) autorizarFactura(ctx context.Context, idFactura int) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewFacturaRepo(tx)
+
+	// 1. Bloquear la numeración y la factura; otra corrida pudo haberla resuelto
+	f, err := repo.ObtenerPorID(ctx, idFactura)
+	if err != nil {
+		return "", err
+	}
+	ultimoLocal, err := repo.BloquearNumeracion(ctx, f.TipoComprobante, f.PuntoVenta)
+	if err != nil {
+		return "", err
+	}
+	estado, err := repo.BloquearEstadoAFIP(ctx, idFactura)
+	if err != nil {
+		return "", err
+	}
+	if estado != modelos.EstadoAfipPendiente {
+		return estado, nil
+	}
+
+	ctxAFIP, cancelar := context.WithTimeout(ctx, TimeoutAutorizacionAFIP)
+	defer cancelar()
+
+	// 2. Próximo número según AFIP, que no puede quedar detrás de la numeración local
+	tipo := codigosComprobanteAFIP[f.TipoComprobante]
+	ultimo, err := s.autorizador.UltimoAutorizado(ctxAFIP, f.PuntoVenta, tipo)
+	if err != nil {
+		return "", err
+	}
+	if ultimo < ultimoLocal {
+		return "", fmt.Errorf("AFIP informa el %d como último comprobante %s autorizado del punto de venta %04d, pero ya se usó el %d",
+			ultimo, f.TipoComprobante, f.PuntoVenta, ultimoLocal)
+	}
+	cbte, err := comprobanteAFIP(f, tipo, ultimo+1)
+	if err != nil {
+		return "", err
+	}
+
+	// 3. Un número autorizado en AFIP que no está registrado puede ser esta misma factura, si
+	// se perdió la respuesta de un pedido anterior: en ese caso se guarda su CAE
+	if ultimo > ultimoLocal {
+		autorizado, err := s.autorizador.ConsultarComprobante(ctxAFIP, f.PuntoVenta, tipo, ultimo)
+		if err != nil {
+			return "", fmt.Errorf("error consultando en AFIP el comprobante %s %04d-%08d: %w", f.TipoComprobante, f.PuntoVenta, ultimo, err)
+		}
+		if coincideComprobanteAFIP(cbte, autorizado) {
+			logger.Warn.Printf("La factura %d ya estaba autorizada en AFIP como %s %04d-%08d: se recupera su CAE",
+				idFactura, f.TipoComprobante, f.PuntoVenta, ultimo)
+			if err := s.registrarAutorizacion(ctx, tx, f, ultimo, autorizado.CAE, autorizado.CAEVencimiento, nil); err != nil {
+				return "", err
+			}
+			return modelos.EstadoAfipAutorizada, nil
+		}
+		logger.Warn.Printf("AFIP tiene autorizados comprobantes %s del punto de venta %04d hasta el %d que no están registrados (último local: %d)",
+			f.TipoComprobante, f.PuntoVenta, ultimo, ultimoLocal)
+	}
+
+	// 4. Pedir el CAE
+	aut, err := s.autorizador.SolicitarCAE(ctxAFIP, cbte)
+	if err != nil {
+		return "", err
+	}
+	observaciones := textoObservacionesAFIP(aut.Observaciones)
+
+	if aut.Resultado != afip.ResultadoAprobado {
+		if err := repo.RegistrarResultadoAFIP(ctx, idFactura, true, observaciones); err != nil {
+			return "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", fmt.Errorf("error confirmando transacción: %w", err)
+		}
+		logger.Error.Printf("AFIP rechazó la factura %d (%s %04d-%08d): %s", idFactura, f.TipoComprobante, f.PuntoVenta, cbte.Numero, observaciones)
+		return modelos.EstadoAfipRechazada, nil
+	}
+
+	var obs *string
+	if observaciones != "" {
+		obs = &observaciones
+	}
+	if err := s.registrarAutorizacion(ctx, tx, f, cbte.Numero, aut.CAE, aut.CAEVencimiento, obs); err != nil {
+		return "", err
+	}
+	return modelos.EstadoAfipAutorizada, nil
+}
+
+// registrarAutorizacion guarda el número y el CAE de la factura, la carga en la cuenta
+// corriente, avisa al cliente y confirma la transacción
+func (s *FacturacionService) registrarAutorizacion(ctx context.Context, tx *sql.Tx, f *modelos.Factura, numero int, cae string, vencimiento time.Time, observaciones *string) error {
+	repo := repositorios.NewFacturaRepo(tx)
+	if err := repo.RegistrarCAE(ctx, f.IDFactura, numero, cae, vencimiento, observaciones, database.NowInArgentina()); err != nil {
+		return err
+	}
+	if err := repo.ActualizarNumeracion(ctx, f.TipoComprobante, f.PuntoVenta, numero); err != nil {
+		return err
+	}
+	if err := repositorios.NewCuentaRepo(tx).RegistrarCargoFactura(ctx, f.IDFactura); err != nil {
+		return err
+	}
+	if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoFacturaEmitida, modelos.PayloadFactura{IDFactura: f.IDFactura}); err != nil {
+		return fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Factura %s %04d-%08d autorizada por AFIP (CAE %s, vence %s)",
+		f.TipoComprobante, f.PuntoVenta, numero, cae, vencimiento.Format("02/01/2006"))
+	return nil
+}
+
+// coincideComprobanteAFIP indica si el comprobante autorizado en AFIP es el que se iba a
+// pedir: mismo receptor, fecha, período de servicio e importe total
+func coincideComprobanteAFIP(c afip.Comprobante, a *afip.ComprobanteAutorizado) bool {
+	mismoDia := func(x, y time.Time) bool { return x.Format("20060102") == y.Format("20060102") }
+	return a.DocTipo == c.DocTipo && a.DocNro == c.DocNro &&
+		mismoDia(a.Fecha, c.Fecha) && mismoDia(a.ServicioDesde, c.ServicioDesde) && mismoDia(a.ServicioHasta, c.ServicioHasta) &&
+		math.Abs(a.ImporteTotal-c.ImporteTotal) < 0.005
+}
+
+// comprobanteAFIP arma el pedido de CAE de la factura con el número dado. Los abonos se
+// informan como servicios, con el período facturado y el vencimiento del pago.
+func comprobanteAFIP(f *modelos.Factura, tipo, numero int) (afip.Comprobante, error) {
+	loc := database.NowInArgentina().Location()
+	var fechas [4]time.Time
+	for i, texto := range []string{f.FechaEmision, f.PeriodoDesde, f.PeriodoHasta, f.FechaVencimiento} {
+		t, err := time.ParseInLocation("2006-01-02", texto, loc)
+		if err != nil {
+			return afip.Comprobante{}, fmt.Errorf("fecha inválida %q en la factura %d", texto, f.IDFactura)
+		}
+		fechas[i] = t
+	}
+
+	docTipo, docNro := documentoReceptorAFIP(f.ClienteDocumento, f.ClienteCondicionIVA)
+	c := afip.Comprobante{
+		PuntoVenta:           f.PuntoVenta,
+		Tipo:                 tipo,
+		Numero:               numero,
+		Concepto:             afip.ConceptoServicios,
+		DocTipo:              docTipo,
+		DocNro:               docNro,
+		CondicionIVAReceptor: condicionIVAReceptorAFIP(f.ClienteCondicionIVA),
+		Fecha:                fechas[0],
+		ServicioDesde:        fechas[1],
+		ServicioHasta:        fechas[2],
+		VencimientoPago:      fechas[3],
+		ImporteTotal:         f.Total,
+		ImporteNeto:          f.Neto,
+		ImporteIVA:           f.IVA,
+	}
+	// La factura C no discrimina IVA: el neto es el total
+	if tipo == afip.CbteFacturaC {
+		c.ImporteNeto, c.ImporteIVA = f.Total, 0
+		return c, nil
+	}
+
+	alicuota := afip.IVA21
+	if f.AlicuotaIVA == AlicuotaIVAResponsableInscripto {
+		alicuota = afip.IVA27
+	}
+	c.Alicuotas = []afip.AlicuotaIVA{{ID: alicuota, BaseImponible: f.Neto, Importe: f.IVA}}
+	return c, nil
+}
+
+// documentoReceptorAFIP elige el documento del receptor: CUIT para los inscriptos (la
+// factura A lo exige), CUIL o DNI para el consumidor final y, sin documento utilizable,
+// consumidor final sin identificar
+func documentoReceptorAFIP(documento, condicionIVA string) (int, int64) {
+	doc := utilidades.NormalizarCUIT(documento)
+	nro, err := strconv.ParseInt(doc, 10, 64)
+	switch {
+	case err != nil || nro <= 0:
+		return afip.DocSinIdentificar, 0
+	case len(doc) == 11 && condicionIVA != modelos.TipoIVAConsumidorFinal:
+		return afip.DocCUIT, nro
+	case len(doc) == 11:
+		return afip.DocCUIL, nro
+	case len(doc) == 7 || len(doc) == 8:
+		return afip.DocDNI, nro
+	default:
+		return afip.DocSinIdentificar, 0
+	}
+}
+
+// condicionIVAReceptorAFIP traduce la condición frente al IVA del cliente a la tabla de AFIP
+func condicionIVAReceptorAFIP(condicionIVA string) int {
+	switch condicionIVA {
+	case modelos.TipoIVAResponsableInscripto:
+		return afip.CondicionIVAResponsableInscripto
+	case modelos.TipoIVAMonotributista:
+		return afip.CondicionIVAMonotributo
+	case modelos.TipoIVAExento:
+		return afip.CondicionIVAExento
+	default:
+		return afip.CondicionIVAConsumidorFinal
+	}
+}
+
+// urlQRFactura arma la URL del QR de la RG 4892 de una factura autorizada
+func (s *FacturacionService) urlQRFactura(f *modelos.Factura) (string, error) {
+	emision, err := time.Parse("2006-01-02", f.FechaEmision)
+	if err != nil {
+		return "", fmt.Errorf("fecha de emisión inválida %q en la factura %d", f.FechaEmision, f.IDFactura)
+	}
+	docTipo, docNro := documentoReceptorAFIP(f.ClienteDocumento, f.ClienteCondicionIVA)
+	return afip.URLQR(afip.DatosQR{
+		Fecha:      emision,
+		CUIT:       s.cuitEmisor,
+		PuntoVenta: f.PuntoVenta,
+		Tipo:       codigosComprobanteAFIP[f.TipoComprobante],
+		Numero:     f.Numero,
+		Importe:    f.Total,
+		DocTipo:    docTipo,
+		DocNro:     docNro,
+		CAE:        *f.CAE,
+	})
+}
+
+func textoObservacionesAFIP(mensajes []afip.Mensaje) string {
+	partes := make([]string, len(mensajes))
+	for i, m := range mensajes {
+		partes[i] = m.String()
+	}
+	return recortarObservaciones(strings.Join(partes, "; "))
+}
+
+func recortarObservaciones(texto string) string {
+	if r := []rune(texto); len(r) > largoMaxObservacionesAFIP {
+		return string(r[:largoMaxObservacionesAFIP])
+	}
+	return texto
+}
@@ -0,0 +1,205 @@
+package servicios
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades/afip"
+)
+
+const cuitEmisorPrueba = 30716547841
+
+// transporteInterrumpido reenvía los sobres al servidor fake pero, mientras perder sea
+// verdadero, descarta la respuesta de FECAESolicitar: AFIP autoriza y el cliente no se entera
+type transporteInterrumpido struct {
+	afip.TransporteLocal
+	perder bool
+}
+
+func (t *transporteInterrumpido) Enviar(ctx context.Context, url, accion string, sobre []byte) ([]byte, error) {
+	resp, err := t.TransporteLocal.Enviar(ctx, url, accion, sobre)
+	if err == nil && t.perder && strings.HasSuffix(accion, "FECAESolicitar") {
+		return nil, errors.New("se cortó la conexión esperando la respuesta")
+	}
+	return resp, err
+}
+
+// escenarioAFIP arma el servicio contra el WSAA y WSFEv1 fake, con la factura 40 (B, punto de
+// venta 2) pendiente de CAE y la numeración local en ultimoLocal
+func escenarioAFIP(t *testing.T, ultimoLocal int, documento string) (*FacturacionService, *bdFake, *afip.ServidorFake, *transporteInterrumpido) {
+	t.Helper()
+	fake := afip.NuevoServidorFake()
+	fake.FijarUltimo(2, afip.CbteFacturaB, ultimoLocal)
+	transporte := &transporteInterrumpido{TransporteLocal: afip.TransporteLocal{Handler: fake}}
+	wsaa := afip.NuevoWSAA(transporte, "http://afip-fake/ws/services/LoginCms", afip.FirmadorFake{}, nil)
+	wsfe := afip.NuevoWSFE(transporte, "http://afip-fake/wsfev1/service.asmx", wsaa, cuitEmisorPrueba)
+
+	columnas := strings.Split("id_factura,tipo_comprobante,punto_venta,numero,id_contrato,id_conexion,nro_conexion,id_persona,periodo,"+
+		"periodo_desde,periodo_hasta,fecha_emision,fecha_vencimiento,cliente_nombre,cliente_documento,cliente_condicion_iva,"+
+		"cliente_domicilio,alicuota_iva,neto,iva,total,estado,pdf_path,pdf_hash,creado,afip_estado,cae,cae_vencimiento,afip_observaciones", ",")
+	db, bd := nuevaBDFake(t,
+		respuestaFake{
+			contiene: "JOIN conexion cn ON cn.id_conexion = f.id_conexion",
+			columnas: columnas,
+			filas: [][]driver.Value{{
+				int64(40), modelos.ComprobanteFacturaB, int64(2), nil, int64(11), int64(12), int64(1012), int64(5), "2026-10",
+				"2026-10-01", "2026-10-31", "2026-10-01", "2026-10-11", "Juana Pérez", documento, modelos.TipoIVAConsumidorFinal,
+				"San Martín 100, Ciudad, Capital, Mendoza", 21.0, 10000.0, 2100.0, 12100.0, "emitida", nil, nil, time.Now(),
+				modelos.EstadoAfipPendiente, nil, nil, nil,
+			}},
+		},
+		respuestaFake{contiene: "FROM factura_numeracion", columnas: []string{"ultimo_numero"}, filas: [][]driver.Value{{int64(ultimoLocal)}}},
+		respuestaFake{contiene: "SELECT afip_estado FROM factura", columnas: []string{"afip_estado"}, filas: [][]driver.Value{{modelos.EstadoAfipPendiente}}},
+	)
+	s := NewFacturacionService(db, t.TempDir(), 2, 10, modelos.TipoIVAResponsableInscripto, wsfe)
+	return s, bd, fake, transporte
+}
+
+// ultimoFake devuelve el último número autorizado en el servidor fake
+func ultimoFake(t *testing.T, s *FacturacionService) int {
+	t.Helper()
+	ultimo, err := s.autorizador.UltimoAutorizado(context.Background(), 2, afip.CbteFacturaB)
+	if err != nil {
+		t.Fatalf("UltimoAutorizado: %v", err)
+	}
+	return ultimo
+}
+
+func TestAutorizarFacturaAprobada(t *testing.T) {
+	s, bd, _, _ := escenarioAFIP(t, 7, "27123456")
+
+	estado, err := s.autorizarFactura(context.Background(), 40)
+	if err != nil {
+		t.Fatalf("autorizarFactura: %v", err)
+	}
+	if estado != modelos.EstadoAfipAutorizada {
+		t.Fatalf("estado = %q, se esperaba autorizada", estado)
+	}
+	args := bd.argumentosDe("SET numero = ?, cae = ?")
+	if args == nil || args[0] != int64(8) || len(args[1].(string)) != 14 {
+		t.Fatalf("RegistrarCAE con %v, se esperaba el número 8 y un CAE", args)
+	}
+	if args := bd.argumentosDe("UPDATE factura_numeracion"); args == nil || args[0] != int64(8) {
+		t.Errorf("numeración actualizada con %v, se esperaba 8", args)
+	}
+	if bd.contarEjecutadas("INSERT INTO cuenta_movimiento") != 1 || bd.contarEjecutadas("INSERT INTO outbox_evento") != 1 {
+		t.Error("la factura autorizada no se cargó en la cuenta o no se avisó al cliente")
+	}
+	if bd.commits != 1 || ultimoFake(t, s) != 8 {
+		t.Errorf("commits = %d, último en AFIP = %d", bd.commits, ultimoFake(t, s))
+	}
+}
+
+func TestAutorizarFacturaRechazada(t *testing.T) {
+	s, bd, _, _ := escenarioAFIP(t, 7, "27123456")
+	s.autorizador = autorizadorDocumentoInvalido{s.autorizador}
+
+	estado, err := s.autorizarFactura(context.Background(), 40)
+	if err != nil {
+		t.Fatalf("autorizarFactura: %v", err)
+	}
+	if estado != modelos.EstadoAfipRechazada {
+		t.Fatalf("estado = %q, se esperaba rechazada", estado)
+	}
+	args := bd.argumentosDe("SET afip_estado = ?, afip_observaciones = ?")
+	if args == nil || args[0] != modelos.EstadoAfipRechazada || !strings.Contains(args[1].(string), "10015") {
+		t.Fatalf("resultado registrado %v, se esperaba rechazada con la observación 10015", args)
+	}
+	if bd.contarEjecutadas("SET numero = ?") != 0 || bd.contarEjecutadas("INSERT INTO cuenta_movimiento") != 0 {
+		t.Error("una factura rechazada no debe numerarse ni cargarse en la cuenta")
+	}
+	if ultimoFake(t, s) != 7 {
+		t.Error("el rechazo no debe consumir un número en AFIP")
+	}
+}
+
+// autorizadorDocumentoInvalido informa el receptor como sin identificar pero con número,
+// combinación que AFIP rechaza
+type autorizadorDocumentoInvalido struct {
+	AutorizadorComprobantes
+}
+
+func (a autorizadorDocumentoInvalido) SolicitarCAE(ctx context.Context, c afip.Comprobante) (*afip.Autorizacion, error) {
+	c.DocTipo, c.DocNro = afip.DocSinIdentificar, 123456789
+	return a.AutorizadorComprobantes.SolicitarCAE(ctx, c)
+}
+
+func TestAutorizarFacturaRespuestaPerdida(t *testing.T) {
+	ctx := context.Background()
+	s, bd, _, transporte := escenarioAFIP(t, 7, "27123456")
+
+	// 1. AFIP autoriza el 8 pero la respuesta no llega: la factura sigue pendiente
+	transporte.perder = true
+	if _, err := s.autorizarFactura(ctx, 40); err == nil {
+		t.Fatal("se esperaba error con la respuesta perdida")
+	}
+	if bd.commits != 0 || bd.contarEjecutadas("SET numero = ?") != 0 {
+		t.Fatal("sin respuesta no se debe registrar nada")
+	}
+	if ultimoFake(t, s) != 8 {
+		t.Fatal("el fake debería haber autorizado el 8")
+	}
+	caeAutorizado, err := s.autorizador.ConsultarComprobante(ctx, 2, afip.CbteFacturaB, 8)
+	if err != nil {
+		t.Fatalf("ConsultarComprobante: %v", err)
+	}
+
+	// 2. La corrida siguiente encuentra el 8 en AFIP, ve que es esta factura y guarda su CAE
+	transporte.perder = false
+	estado, err := s.autorizarFactura(ctx, 40)
+	if err != nil {
+		t.Fatalf("reintento: %v", err)
+	}
+	if estado != modelos.EstadoAfipAutorizada {
+		t.Fatalf("estado = %q, se esperaba autorizada", estado)
+	}
+	args := bd.argumentosDe("SET numero = ?, cae = ?")
+	if args == nil || args[0] != int64(8) || args[1] != caeAutorizado.CAE {
+		t.Fatalf("RegistrarCAE con %v, se esperaba el 8 con el CAE %s", args, caeAutorizado.CAE)
+	}
+	if ultimoFake(t, s) != 8 {
+		t.Error("no se debe pedir un segundo CAE para la misma factura")
+	}
+}
+
+func TestAutorizarFacturaSaltoDeNumeracion(t *testing.T) {
+	ctx := context.Background()
+	s, bd, fake, _ := escenarioAFIP(t, 7, "27123456")
+
+	// Otro comprobante, de otro receptor, autorizado en AFIP por fuera del sistema
+	otro := afip.Comprobante{
+		PuntoVenta: 2, Tipo: afip.CbteFacturaB, Numero: 8, Concepto: afip.ConceptoServicios,
+		DocTipo: afip.DocDNI, DocNro: 30111222, CondicionIVAReceptor: afip.CondicionIVAConsumidorFinal,
+		Fecha: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), ServicioDesde: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		ServicioHasta: time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC), VencimientoPago: time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC),
+		ImporteTotal: 12100, ImporteNeto: 10000, ImporteIVA: 2100,
+		Alicuotas: []afip.AlicuotaIVA{{ID: afip.IVA21, BaseImponible: 10000, Importe: 2100}},
+	}
+	if aut, err := s.autorizador.SolicitarCAE(ctx, otro); err != nil || aut.Resultado != afip.ResultadoAprobado {
+		t.Fatalf("autorizando el comprobante externo: %v %+v", err, aut)
+	}
+
+	// El 8 no es esta factura: se pide el 9
+	estado, err := s.autorizarFactura(ctx, 40)
+	if err != nil {
+		t.Fatalf("autorizarFactura: %v", err)
+	}
+	if estado != modelos.EstadoAfipAutorizada {
+		t.Fatalf("estado = %q, se esperaba autorizada", estado)
+	}
+	if args := bd.argumentosDe("SET numero = ?, cae = ?"); args == nil || args[0] != int64(9) {
+		t.Fatalf("RegistrarCAE con %v, se esperaba el número 9", args)
+	}
+
+	// Un número autorizado en AFIP que no se puede consultar deja la factura pendiente
+	fake.FijarUltimo(2, afip.CbteFacturaB, 12)
+	bd.responder(respuestaFake{contiene: "FROM factura_numeracion", columnas: []string{"ultimo_numero"}, filas: [][]driver.Value{{int64(9)}}})
+	if _, err := s.autorizarFactura(ctx, 40); err == nil || !strings.Contains(err.Error(), "602") {
+		t.Fatalf("error = %v, se esperaba el 602 de FECompConsultar", err)
+	}
+}
@@ -0,0 +1,229 @@
+package servicios
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades/pdf"
+	"contrato_one_internet_modelo/internal/utilidades/qr"
+)
+
+// Columnas de la tabla de ítems (posición x del borde derecho de cada importe)
+const (
+	anchoConceptoFactura = 300.0
+	colCantidadFactura   = 390.0
+	colPrecioFactura     = 470.0
+)
+
+// leyendasCondicionEmisor es la condición frente al IVA impresa en el encabezado
+var leyendasCondicionEmisor = map[string]string{
+	modelos.TipoIVAResponsableInscripto: "IVA Responsable Inscripto",
+	modelos.TipoIVAMonotributista:       "Responsable Monotributo",
+	modelos.TipoIVAExento:               "IVA Sujeto Exento",
+}
+
+// generarPDF renderiza la factura, la guarda en FacturasPath/AAAA-MM y registra la ruta y el
+// hash del archivo. Devuelve la ruta.
+func (s *FacturacionService) generarPDF(ctx context.Context, idFactura int) (string, error) {
+	repo := repositorios.NewFacturaRepo(s.db)
+	f, err := repo.ObtenerPorID(ctx, idFactura)
+	if err != nil {
+		return "", err
+	}
+
+	urlQR := ""
+	if f.CAE != nil {
+		if urlQR, err = s.urlQRFactura(f); err != nil {
+			return "", err
+		}
+	}
+	doc, err := construirFacturaPDF(f, s.condicionIVAEmisor, urlQR)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Escribir(&buf); err != nil {
+		return "", fmt.Errorf("error escribiendo PDF: %w", err)
+	}
+
+	dir := filepath.Join(s.facturasPath, f.Periodo)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creando directorio %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, nombrePDFFactura(f))
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("error guardando %s: %w", path, err)
+	}
+
+	hash := sha256.Sum256(buf.Bytes())
+	if err := repo.ActualizarPDF(ctx, idFactura, path, hex.EncodeToString(hash[:])); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func nombrePDFFactura(f *modelos.Factura) string {
+	return fmt.Sprintf("factura_%s_%s.pdf", f.TipoComprobante, f.NumeroComprobante)
+}
+
+// construirFacturaPDF arma el comprobante con el mismo maquetador que la solicitud de
+// servicio. En la factura A se discrimina el IVA; en la B los importes lo incluyen y la C no
+// lleva IVA. Con urlQR (factura electrónica autorizada) agrega el CAE y el QR de la RG 4892.
+func construirFacturaPDF(f *modelos.Factura, condicionIVAEmisor, urlQR string) (*pdf.Documento, error) {
+	m := &maquetador{doc: pdf.NuevoDocumento()}
+	m.doc.NuevaPagina()
+	m.y = margenPDF
+	derecha := m.doc.Ancho - margenPDF
+	discriminaIVA := f.TipoComprobante == modelos.ComprobanteFacturaA
+
+	// 1. Encabezado: prestador, letra del comprobante y numeración
+	m.doc.Texto(margenPDF, m.y+16, pdf.HelveticaNegrita, 20, "ONE Internet")
+	centro := m.doc.Ancho / 2
+	m.doc.Rectangulo(centro-20, m.y, 40, 40, 1)
+	m.doc.TextoCentrado(centro, m.y+30, pdf.HelveticaNegrita, 28, f.TipoComprobante)
+	m.doc.TextoCentrado(centro, m.y+52, pdf.Helvetica, 7, fmt.Sprintf("Cód. %02d", codigosComprobanteAFIP[f.TipoComprobante]))
+	m.doc.TextoDerecha(derecha, m.y+14, pdf.HelveticaNegrita, 14, "FACTURA")
+	m.doc.TextoDerecha(derecha, m.y+30, pdf.HelveticaNegrita, tamTextoPDF, "Nº "+f.NumeroComprobante)
+	m.doc.TextoDerecha(derecha, m.y+42, pdf.Helvetica, tamTextoPDF, "Fecha de emisión: "+fechaFactura(f.FechaEmision))
+	m.doc.TextoDerecha(derecha, m.y+54, pdf.Helvetica, tamTextoPDF, leyendasCondicionEmisor[condicionIVAEmisor])
+	m.y += 30
+	for _, l := range encabezadoPrestador {
+		m.doc.Texto(margenPDF, m.y, pdf.Helvetica, 8, l)
+		m.y += 10
+	}
+	m.y += 4
+	m.doc.Linea(margenPDF, m.y, derecha, m.y, 0.8)
+	m.y += 8
+
+	// 2. Cliente y período
+	m.seccion("Cliente")
+	m.campo("Apellido y Nombres - Razón Social:", f.ClienteNombre)
+	m.campo("CUIT / CUIL / DNI:", f.ClienteDocumento)
+	m.campo("Condición ante el IVA:", f.ClienteCondicionIVA)
+	m.campo("Domicilio del servicio:", f.ClienteDomicilio)
+	m.campo("Nº de Conexión:", fmt.Sprintf("%d", f.NroConexion))
+
+	m.seccion("Período facturado")
+	m.campo("Período:", fmt.Sprintf("%s (del %s al %s)", f.Periodo, fechaFactura(f.PeriodoDesde), fechaFactura(f.PeriodoHasta)))
+	m.campo("Vencimiento:", fechaFactura(f.FechaVencimiento))
+
+	// 3. Ítems
+	m.seccion("Detalle")
+	m.doc.Texto(margenPDF, m.y+tamTextoPDF, pdf.HelveticaNegrita, tamTextoPDF, "Concepto")
+	m.doc.TextoDerecha(colCantidadFactura, m.y+tamTextoPDF, pdf.HelveticaNegrita, tamTextoPDF, "Cantidad")
+	m.doc.TextoDerecha(colPrecioFactura, m.y+tamTextoPDF, pdf.HelveticaNegrita, tamTextoPDF, "P. unitario")
+	m.doc.TextoDerecha(derecha, m.y+tamTextoPDF, pdf.HelveticaNegrita, tamTextoPDF, "Importe")
+	m.y += interlineadoPDF + 2
+	for _, it := range f.Items {
+		precio, importe := it.PrecioUnitario, it.Neto
+		if !discriminaIVA {
+			precio, importe = redondearImporte(it.PrecioUnitario*(1+f.AlicuotaIVA/100)), it.Total
+		}
+		lineas := pdf.DividirLineas(pdf.Helvetica, tamTextoPDF, it.Concepto, anchoConceptoFactura)
+		m.asegurar(float64(len(lineas)) * interlineadoPDF)
+		cantidad := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", it.Cantidad), "0"), ".")
+		m.doc.TextoDerecha(colCantidadFactura, m.y+tamTextoPDF, pdf.Helvetica, tamTextoPDF, strings.Replace(cantidad, ".", ",", 1))
+		m.doc.TextoDerecha(colPrecioFactura, m.y+tamTextoPDF, pdf.Helvetica, tamTextoPDF, importeFactura(precio))
+		m.doc.TextoDerecha(derecha, m.y+tamTextoPDF, pdf.Helvetica, tamTextoPDF, importeFactura(importe))
+		for _, l := range lineas {
+			m.doc.Texto(margenPDF, m.y+tamTextoPDF, pdf.Helvetica, tamTextoPDF, l)
+			m.y += interlineadoPDF
+		}
+		m.y += 2
+	}
+	m.doc.Linea(margenPDF, m.y, derecha, m.y, 0.6)
+	m.y += 6
+
+	// 4. Totales
+	total := func(etiqueta, valor string, fuente pdf.Fuente) {
+		m.asegurar(interlineadoPDF)
+		m.doc.TextoDerecha(colPrecioFactura, m.y+tamTextoPDF, fuente, tamTextoPDF, etiqueta)
+		m.doc.TextoDerecha(derecha, m.y+tamTextoPDF, fuente, tamTextoPDF, valor)
+		m.y += interlineadoPDF
+	}
+	if discriminaIVA {
+		total("Importe neto gravado:", importeFactura(f.Neto), pdf.Helvetica)
+		total(fmt.Sprintf("IVA %g%%:", f.AlicuotaIVA), importeFactura(f.IVA), pdf.Helvetica)
+	}
+	total("Importe total:", importeFactura(f.Total), pdf.HelveticaNegrita)
+	m.y += 10
+
+	// 5. Autorización de AFIP
+	if urlQR != "" {
+		if err := m.autorizacionAFIP(f, urlQR); err != nil {
+			return nil, err
+		}
+		m.y += 10
+	}
+
+	// 6. Leyendas
+	if f.TipoComprobante == modelos.ComprobanteFacturaB {
+		m.parrafo(fmt.Sprintf("Régimen de Transparencia Fiscal al Consumidor (Ley 27.743) - IVA contenido: %s", importeFactura(f.IVA)))
+	}
+	if f.Estado == modelos.EstadoFacturaAnulada {
+		m.parrafo("COMPROBANTE ANULADO")
+	}
+	m.parrafo("Podés consultar y descargar tus facturas desde la sección Facturas de tu perfil en www.oneinternet.com.ar.")
+	return m.doc, nil
+}
+
+// autorizacionAFIP dibuja el QR de la RG 4892 junto al CAE y su vencimiento
+func (m *maquetador) autorizacionAFIP(f *modelos.Factura, urlQR string) error {
+	const ladoQR = 80.0
+	codigo, err := qr.Codificar(urlQR)
+	if err != nil {
+		return fmt.Errorf("error generando QR de la factura: %w", err)
+	}
+	idx, err := m.doc.AgregarImagen(codigo.Imagen(4))
+	if err != nil {
+		return err
+	}
+
+	m.asegurar(ladoQR)
+	m.doc.Imagen(idx, margenPDF, m.y, ladoQR, ladoQR)
+	x := margenPDF + ladoQR + 10
+	y := m.y + 24
+	m.doc.Texto(x, y, pdf.HelveticaNegrita, 10, "Comprobante Autorizado")
+	m.doc.Texto(x, y+14, pdf.Helvetica, tamTextoPDF, "CAE Nº: "+*f.CAE)
+	if f.CAEVencimiento != nil {
+		m.doc.Texto(x, y+26, pdf.Helvetica, tamTextoPDF, "Fecha de Vto. de CAE: "+fechaFactura(*f.CAEVencimiento))
+	}
+	m.y += ladoQR
+	return nil
+}
+
+// fechaFactura pasa una fecha AAAA-MM-DD a DD/MM/AAAA
+func fechaFactura(fecha string) string {
+	p := strings.Split(fecha, "-")
+	if len(p) != 3 {
+		return fecha
+	}
+	return p[2] + "/" + p[1] + "/" + p[0]
+}
+
+// importeFactura formatea un importe en pesos con separador de miles: $ 12.345,67
+func importeFactura(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	signo := ""
+	if strings.HasPrefix(s, "-") {
+		signo, s = "-", s[1:]
+	}
+	entero, decimales := s[:len(s)-3], s[len(s)-2:]
+	var b strings.Builder
+	for i, c := range entero {
+		if i > 0 && (len(entero)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return "$ " + signo + b.String() + "," + decimales
+}
@@ -0,0 +1,397 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"contrato_one_internet_modelo/internal/database"
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+const (
+	// IntervaloFacturacion es cada cuánto se buscan contratos sin la factura del mes en curso
+	IntervaloFacturacion = time.Hour
+
+	// Alícuotas de IVA de servicios de telecomunicaciones (ley 23.349, art. 28): 27% cuando
+	// el cliente es Responsable Inscripto o Monotributista, 21% en el resto de los casos
+	AlicuotaIVAGeneral              = 21.0
+	AlicuotaIVAResponsableInscripto = 27.0
+)
+
+// FacturacionService genera las facturas mensuales de los contratos vigentes y, con la
+// factura electrónica habilitada, obtiene su CAE
+type FacturacionService struct {
+	db                 *sql.DB
+	facturasPath       string
+	puntoVenta         int
+	diasVencimiento    int
+	condicionIVAEmisor string
+	autorizador        AutorizadorComprobantes // nil: sin factura electrónica
+	cuitEmisor         int64
+}
+
+func NewFacturacionService(db *sql.DB, facturasPath string, puntoVenta, diasVencimiento int, condicionIVAEmisor string, autorizador AutorizadorComprobantes) *FacturacionService {
+	s := &FacturacionService{
+		db:                 db,
+		facturasPath:       facturasPath,
+		puntoVenta:         puntoVenta,
+		diasVencimiento:    diasVencimiento,
+		condicionIVAEmisor: condicionIVAEmisor,
+		autorizador:        autorizador,
+	}
+	if autorizador != nil {
+		s.cuitEmisor = autorizador.CUIT()
+	} else {
+		s.cuitEmisor, _ = strconv.ParseInt(modelos.EmpresaOperadoraCUIT, 10, 64)
+	}
+	return s
+}
+
+// IniciarFacturacion genera cada IntervaloFacturacion las facturas del mes en curso que
+// falten hasta que se cancele el contexto. Se ejecuta en su propia goroutine.
+func (s *FacturacionService) IniciarFacturacion(ctx context.Context) {
+	logger.Info.Printf("Facturación mensual iniciada (intervalo %s, punto de venta %04d)", IntervaloFacturacion, s.puntoVenta)
+	ticker := time.NewTicker(IntervaloFacturacion)
+	defer ticker.Stop()
+
+	for {
+		resumen, err := s.GenerarPeriodo(ctx, database.NowInArgentina())
+		if err != nil {
+			logger.Error.Printf("Error generando facturas: %v", err)
+		} else if len(resumen.Generadas) > 0 || resumen.Errores > 0 || resumen.Autorizadas > 0 || resumen.Rechazadas > 0 {
+			logger.Info.Printf("Facturación %s: %d factura(s) emitida(s), %d error(es), %d autorizada(s) y %d rechazada(s) por AFIP",
+				resumen.Periodo, len(resumen.Generadas), resumen.Errores, resumen.Autorizadas, resumen.Rechazadas)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info.Println("Facturación mensual detenida")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GenerarPeriodo emite las facturas que falten del mes que contiene a fecha. Es idempotente:
+// un contrato nunca tiene dos facturas vigentes del mismo período. Un error en un contrato no
+// frena al resto; se cuenta en el resumen. Con factura electrónica, después pide el CAE de
+// todas las facturas pendientes, incluidas las de corridas anteriores.
+func (s *FacturacionService) GenerarPeriodo(ctx context.Context, fecha time.Time) (*modelos.ResumenFacturacion, error) {
+	return s.generarPeriodo(ctx, fecha, false)
+}
+
+// generarPeriodo implementa GenerarPeriodo. Con reemitirRechazadas también vuelve a facturar
+// los contratos cuya factura del período rechazó AFIP, con los datos actuales del contrato y
+// del cliente.
+func (s *FacturacionService) generarPeriodo(ctx context.Context, fecha time.Time, reemitirRechazadas bool) (*modelos.ResumenFacturacion, error) {
+	hoy := database.NowInArgentina()
+	desde := time.Date(fecha.Year(), fecha.Month(), 1, 0, 0, 0, 0, hoy.Location())
+	hasta := desde.AddDate(0, 1, -1)
+	periodo := desde.Format("2006-01")
+	resumen := &modelos.ResumenFacturacion{Periodo: periodo, Generadas: []int{}}
+
+	ids, err := repositorios.NewFacturaRepo(s.db).ListarContratosAFacturar(ctx, periodo, desde, hasta, reemitirRechazadas)
+	if err != nil {
+		return nil, err
+	}
+
+	emision := time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, hoy.Location())
+	for _, idContrato := range ids {
+		idFactura, err := s.emitirFactura(ctx, idContrato, periodo, desde, hasta, emision)
+		if errors.Is(err, utilidades.ErrDuplicado) {
+			// Otra corrida emitió la factura del período entre el listado y el insert
+			continue
+		}
+		if err != nil {
+			logger.Error.Printf("Error facturando el contrato %d (período %s): %v", idContrato, periodo, err)
+			resumen.Errores++
+			continue
+		}
+		resumen.Generadas = append(resumen.Generadas, idFactura)
+
+		// El PDF se genera fuera de la transacción; si falla se vuelve a intentar al descargarlo.
+		// Con factura electrónica se genera al obtener el CAE.
+		if s.autorizador != nil {
+			continue
+		}
+		if _, err := s.generarPDF(ctx, idFactura); err != nil {
+			logger.Error.Printf("Error generando el PDF de la factura %d: %v", idFactura, err)
+		}
+	}
+
+	if s.autorizador != nil {
+		resumen.Autorizadas, resumen.Rechazadas = s.AutorizarPendientes(ctx)
+	}
+	return resumen, nil
+}
+
+// GenerarPeriodoManual emite las facturas de un período (AAAA-MM) sin esperar al próximo
+// ciclo (facturacion.emitir). Reemite las facturas que AFIP rechazó: la corrida automática no
+// lo hace, porque volvería a rechazarlas hasta que se corrijan los datos del cliente.
+func (s *FacturacionService) GenerarPeriodoManual(ctx context.Context, req modelos.GenerarFacturacionRequest, idPersona int) (*modelos.ResumenFacturacion, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoFacturacionEmitir); err != nil {
+		return nil, err
+	}
+
+	hoy := database.NowInArgentina()
+	fecha, err := time.ParseInLocation("2006-01", req.Periodo, hoy.Location())
+	if err != nil {
+		return nil, utilidades.ErrValidation{Campo: "periodo", Mensaje: "debe tener formato AAAA-MM"}
+	}
+	if fecha.After(hoy) {
+		return nil, utilidades.ErrValidation{Campo: "periodo", Mensaje: "no puede ser un período futuro"}
+	}
+
+	resumen, err := s.generarPeriodo(ctx, fecha, true)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Facturación %s generada por la persona %d: %d factura(s), %d error(es)", resumen.Periodo, idPersona, len(resumen.Generadas), resumen.Errores)
+	return resumen, nil
+}
+
+// emitirFactura numera y guarda la factura del contrato junto con su cargo en la cuenta
+// corriente y su evento en el outbox. Con factura electrónica la guarda pendiente, sin número:
+// el número, el cargo y el aviso al cliente llegan con el CAE.
+func (s *FacturacionService) emitirFactura(ctx context.Context, idContrato int, periodo string, desde, hasta, emision time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewFacturaRepo(tx)
+
+	// 1. Armar la factura con los datos actuales del contrato y el abono que rige en el período
+	datos, err := repo.ObtenerDatosFacturacion(ctx, idContrato, desde)
+	if err != nil {
+		return 0, err
+	}
+	factura := armarFactura(datos, s.condicionIVAEmisor, periodo, desde, hasta)
+	factura.PuntoVenta = s.puntoVenta
+	factura.FechaEmision = emision.Format("2006-01-02")
+	factura.FechaVencimiento = emision.AddDate(0, 0, s.diasVencimiento).Format("2006-01-02")
+
+	// 2. Numerar y guardar
+	if s.autorizador != nil {
+		pendiente := modelos.EstadoAfipPendiente
+		factura.AfipEstado = &pendiente
+	} else if factura.Numero, err = repo.SiguienteNumero(ctx, factura.TipoComprobante, factura.PuntoVenta); err != nil {
+		return 0, err
+	}
+	idFactura, err := repo.Crear(ctx, factura)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.autorizador != nil {
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("error confirmando transacción: %w", err)
+		}
+		logger.Info.Printf("Factura %s del contrato %d (período %s, total %.2f) pendiente de CAE",
+			factura.TipoComprobante, idContrato, periodo, factura.Total)
+		return idFactura, nil
+	}
+
+	// 3. Cargo en la cuenta corriente y aviso al cliente
+	if err := repositorios.NewCuentaRepo(tx).RegistrarCargoFactura(ctx, idFactura); err != nil {
+		return 0, err
+	}
+	if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoFacturaEmitida, modelos.PayloadFactura{IDFactura: idFactura}); err != nil {
+		return 0, fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Factura %s %04d-%08d emitida para el contrato %d (período %s, total %.2f)",
+		factura.TipoComprobante, factura.PuntoVenta, factura.Numero, idContrato, periodo, factura.Total)
+	return idFactura, nil
+}
+
+// armarFactura calcula los ítems y los importes. El abono y el costo de instalación son netos
+// de IVA. El primer mes se prorratea desde la fecha de instalación y el último hasta la fecha
+// de fin del contrato; la promoción bonifica el abono de los primeros meses de servicio
+// (el de la instalación es el primero) y el costo de instalación va en la primera factura.
+func armarFactura(d *modelos.DatosFacturacionContrato, condicionIVAEmisor, periodo string, desde, hasta time.Time) *modelos.Factura {
+	f := &modelos.Factura{
+		IDContrato:          d.IDContrato,
+		IDConexion:          d.IDConexion,
+		NroConexion:         d.NroConexion,
+		IDPersona:           d.IDPersona,
+		Periodo:             periodo,
+		ClienteNombre:       d.ClienteNombre,
+		ClienteDocumento:    d.ClienteDocumento,
+		ClienteCondicionIVA: d.ClienteCondicionIVA,
+		ClienteDomicilio:    d.ClienteDomicilio,
+	}
+	f.TipoComprobante, f.AlicuotaIVA = letraComprobante(condicionIVAEmisor, d.ClienteCondicionIVA)
+
+	// 1. Días del período con servicio
+	inicio, fin := desde, hasta
+	if instalacion := soloFecha(d.FechaInstalacion, desde.Location()); instalacion.After(inicio) {
+		inicio = instalacion
+	}
+	if d.FechaFin != nil {
+		if baja := soloFecha(*d.FechaFin, desde.Location()); baja.Before(fin) {
+			fin = baja
+		}
+	}
+	f.PeriodoDesde = inicio.Format("2006-01-02")
+	f.PeriodoHasta = fin.Format("2006-01-02")
+	diasMes := hasta.Day()
+	dias := int(math.Round(fin.Sub(inicio).Hours()/24)) + 1
+
+	// 2. Abono mensual
+	concepto := fmt.Sprintf("Abono %s %d Mbps - %s", d.PlanNombre, d.PlanVelocidad, periodo)
+	cantidad := 1.0
+	if dias < diasMes {
+		concepto += fmt.Sprintf(" (%d de %d días, del %s al %s)", dias, diasMes, inicio.Format("02/01"), fin.Format("02/01"))
+		cantidad = math.Round(float64(dias)/float64(diasMes)*10000) / 10000
+	}
+	abono := redondearImporte(d.PlanPrecio * float64(dias) / float64(diasMes))
+	f.Items = append(f.Items, itemFactura(concepto, cantidad, d.PlanPrecio, abono, f.AlicuotaIVA))
+
+	// 3. Bonificación de la promoción
+	if mes := mesesEntre(d.FechaInstalacion, desde) + 1; d.PromocionMeses > 0 && d.PromocionDescuento > 0 && mes <= d.PromocionMeses {
+		descuento := redondearImporte(abono * d.PromocionDescuento / 100)
+		bonificacion := fmt.Sprintf("Bonificación %s %s%% (mes %d de %d)", d.PromocionNombre,
+			strconv.FormatFloat(d.PromocionDescuento, 'f', -1, 64), mes, d.PromocionMeses)
+		f.Items = append(f.Items, itemFactura(bonificacion, 1, -descuento, -descuento, f.AlicuotaIVA))
+	}
+
+	// 4. Costo de instalación
+	if !d.TieneFacturas && d.CostoInstalacion > 0 {
+		f.Items = append(f.Items, itemFactura(fmt.Sprintf("Instalación conexión Nº %d", d.NroConexion), 1, d.CostoInstalacion, d.CostoInstalacion, f.AlicuotaIVA))
+	}
+
+	for _, it := range f.Items {
+		f.Neto += it.Neto
+		f.IVA += it.IVA
+	}
+	f.Neto = redondearImporte(f.Neto)
+	f.IVA = redondearImporte(f.IVA)
+	f.Total = redondearImporte(f.Neto + f.IVA)
+	return f
+}
+
+// letraComprobante elige la letra y la alícuota de IVA. Un emisor Monotributista o Exento
+// emite C, sin IVA. Un Responsable Inscripto emite A a Responsables Inscriptos y a
+// Monotributistas (RG 5003 sólo define la letra) con el 27% del art. 28, que alcanza a
+// ambos, y B al resto con el 21%.
+func letraComprobante(condicionIVAEmisor, condicionIVACliente string) (string, float64) {
+	switch {
+	case condicionIVAEmisor != modelos.TipoIVAResponsableInscripto:
+		return modelos.ComprobanteFacturaC, 0
+	case condicionIVACliente == modelos.TipoIVAResponsableInscripto,
+		condicionIVACliente == modelos.TipoIVAMonotributista:
+		return modelos.ComprobanteFacturaA, AlicuotaIVAResponsableInscripto
+	default:
+		return modelos.ComprobanteFacturaB, AlicuotaIVAGeneral
+	}
+}
+
+func itemFactura(concepto string, cantidad, precioUnitario, neto, alicuota float64) modelos.ItemFactura {
+	iva := redondearImporte(neto * alicuota / 100)
+	return modelos.ItemFactura{
+		Concepto:       concepto,
+		Cantidad:       cantidad,
+		PrecioUnitario: precioUnitario,
+		Neto:           neto,
+		IVA:            iva,
+		Total:          redondearImporte(neto + iva),
+	}
+}
+
+// mesesEntre cuenta los meses calendario que van del mes de desde al de hasta
+func mesesEntre(desde, hasta time.Time) int {
+	return (hasta.Year()-desde.Year())*12 + int(hasta.Month()) - int(desde.Month())
+}
+
+func redondearImporte(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// soloFecha descarta la hora de t en la zona horaria loc
+func soloFecha(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// ListarDeCliente devuelve las facturas del titular paginadas
+func (s *FacturacionService) ListarDeCliente(ctx context.Context, idPersona, page, limit int) (*modelos.FacturasResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	facturas, total, err := repositorios.NewFacturaRepo(s.db).ListarPorPersona(ctx, idPersona, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := (total + limit - 1) / limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &modelos.FacturasResponse{
+		Facturas:   facturas,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// DetalleDeCliente devuelve la factura con sus ítems si pertenece al titular. Una factura
+// ajena se informa como inexistente.
+func (s *FacturacionService) DetalleDeCliente(ctx context.Context, idFactura, idPersona int) (*modelos.Factura, error) {
+	f, err := repositorios.NewFacturaRepo(s.db).ObtenerPorID(ctx, idFactura)
+	if err != nil {
+		return nil, err
+	}
+	if f.IDPersona != idPersona {
+		return nil, utilidades.ErrNotFound{Entity: "factura", Campo: "id_factura", Valor: fmt.Sprintf("%d", idFactura)}
+	}
+	return f, nil
+}
+
+// PDFDeCliente devuelve la ruta del PDF de la factura del titular y el nombre con el que se
+// descarga. Si el PDF no se generó o ya no está en disco, lo vuelve a generar. Una factura
+// electrónica sin CAE no tiene PDF.
+func (s *FacturacionService) PDFDeCliente(ctx context.Context, idFactura, idPersona int) (string, string, error) {
+	f, err := s.DetalleDeCliente(ctx, idFactura, idPersona)
+	if err != nil {
+		return "", "", err
+	}
+	if f.AfipEstado != nil && *f.AfipEstado != modelos.EstadoAfipAutorizada {
+		return "", "", utilidades.ErrFacturaSinCAE
+	}
+
+	path := ""
+	if f.PDFPath != nil {
+		path = *f.PDFPath
+	}
+	if _, statErr := os.Stat(path); path == "" || statErr != nil {
+		if path, err = s.generarPDF(ctx, idFactura); err != nil {
+			return "", "", err
+		}
+	}
+	return path, nombrePDFFactura(f), nil
+}
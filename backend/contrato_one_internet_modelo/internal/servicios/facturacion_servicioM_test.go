@@ -0,0 +1,64 @@
+package servicios
+
+import (
+	"testing"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+func TestLetraComprobante(t *testing.T) {
+	ri, mono, exento, cf := modelos.TipoIVAResponsableInscripto, modelos.TipoIVAMonotributista,
+		modelos.TipoIVAExento, modelos.TipoIVAConsumidorFinal
+
+	casos := []struct {
+		emisor, cliente string
+		letra           string
+		alicuota        float64
+	}{
+		// Emisor Responsable Inscripto: el 27% alcanza a Responsables Inscriptos y Monotributistas
+		{ri, ri, modelos.ComprobanteFacturaA, 27},
+		{ri, mono, modelos.ComprobanteFacturaA, 27},
+		{ri, exento, modelos.ComprobanteFacturaB, 21},
+		{ri, cf, modelos.ComprobanteFacturaB, 21},
+		{ri, "", modelos.ComprobanteFacturaB, 21},
+		// Emisor Monotributista o Exento: factura C sin IVA para cualquier cliente
+		{mono, ri, modelos.ComprobanteFacturaC, 0},
+		{mono, mono, modelos.ComprobanteFacturaC, 0},
+		{mono, exento, modelos.ComprobanteFacturaC, 0},
+		{mono, cf, modelos.ComprobanteFacturaC, 0},
+		{exento, ri, modelos.ComprobanteFacturaC, 0},
+		{exento, mono, modelos.ComprobanteFacturaC, 0},
+		{exento, exento, modelos.ComprobanteFacturaC, 0},
+		{exento, cf, modelos.ComprobanteFacturaC, 0},
+	}
+	for _, c := range casos {
+		letra, alicuota := letraComprobante(c.emisor, c.cliente)
+		if letra != c.letra || alicuota != c.alicuota {
+			t.Errorf("emisor %q, cliente %q: %s %g%%, se esperaba %s %g%%", c.emisor, c.cliente, letra, alicuota, c.letra, c.alicuota)
+		}
+	}
+}
+
+func TestArmarFacturaMonotributista(t *testing.T) {
+	loc := time.UTC
+	d := &modelos.DatosFacturacionContrato{
+		IDContrato:          1,
+		ClienteCondicionIVA: modelos.TipoIVAMonotributista,
+		PlanNombre:          "Hogar",
+		PlanVelocidad:       100,
+		PlanPrecio:          10000,
+		FechaInstalacion:    time.Date(2026, 1, 10, 0, 0, 0, 0, loc),
+		TieneFacturas:       true,
+	}
+	desde := time.Date(2026, 3, 1, 0, 0, 0, 0, loc)
+	hasta := time.Date(2026, 3, 31, 0, 0, 0, 0, loc)
+
+	f := armarFactura(d, modelos.TipoIVAResponsableInscripto, "2026-03", desde, hasta)
+	if f.TipoComprobante != modelos.ComprobanteFacturaA || f.AlicuotaIVA != 27 {
+		t.Fatalf("comprobante %s %g%%, se esperaba A 27%%", f.TipoComprobante, f.AlicuotaIVA)
+	}
+	if f.Neto != 10000 || f.IVA != 2700 || f.Total != 12700 {
+		t.Errorf("neto %.2f, IVA %.2f, total %.2f; se esperaba 10000 + 2700 = 12700", f.Neto, f.IVA, f.Total)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"contrato_one_internet_modelo/internal/repositorios"
 	"contrato_one_internet_modelo/internal/utilidades"
 	"contrato_one_internet_modelo/internal/utilidades/logger"
+	"contrato_one_internet_modelo/internal/utilidades/pdf"
 )
 
 const (
@@ -38,48 +39,91 @@ func NewFirmaDigitalService(db *sql.DB, pdfService *PDFService, contractBasePath
 	}
 }
 
-// IniciarProcesoFirma crea el registro de contrato_firma y genera PDF
-// Retorna el contrato_firma con el token para que el controlador envíe el email
-func (s *FirmaDigitalService) IniciarProcesoFirma(ctx context.Context, idContrato int) (*modelos.ContratoFirma, error) {
-	// 1. Validar que no exista un proceso activo
-	cfRepo := repositorios.NewContratoFirmaRepo(s.db)
-	existente, err := cfRepo.ObtenerPorIDContrato(ctx, idContrato)
+// PDFOriginal es el contrato renderizado para firmar que todavía no se registró en
+// contrato_firma
+type PDFOriginal struct {
+	Path               string
+	Hash               string
+	CodigoVerificacion string
+}
+
+// PrepararPDFOriginal genera el código de verificación y renderiza el PDF original fuera de
+// cualquier transacción. Devuelve nil si el contrato ya tiene un proceso de firma. Cada
+// intento escribe su propio archivo: si no llega a registrarse se borra con
+// DescartarPDFOriginal.
+func (s *FirmaDigitalService) PrepararPDFOriginal(ctx context.Context, idContrato int) (*PDFOriginal, error) {
+	// 1. Si ya existe un proceso (activo o firmado), no se genera otro PDF
+	existente, err := repositorios.NewContratoFirmaRepo(s.db).ObtenerPorIDContrato(ctx, idContrato)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("error verificando contrato_firma existente: %w", err)
 	}
+	if existente != nil {
+		return nil, nil
+	}
 
-	if existente != nil && !existente.Firmado {
-		logger.Warn.Printf("Ya existe un proceso de firma activo para contrato %d", idContrato)
-		return nil, utilidades.ErrDuplicado
+	// 2. Código público de verificación y PDF original (lo imprime junto al QR)
+	codigoVerificacion, err := utilidades.GenerarCodigoVerificacion()
+	if err != nil {
+		return nil, fmt.Errorf("error generando código de verificación: %w", err)
 	}
 
-	// 2. Generar PDF original
-	pdfPath, hashOriginal, err := s.pdfService.GenerarPDFOriginal(ctx, idContrato)
+	pdfPath, hashOriginal, err := s.pdfService.GenerarPDFOriginal(ctx, idContrato, codigoVerificacion)
 	if err != nil {
 		return nil, fmt.Errorf("error generando PDF original: %w", err)
 	}
+	return &PDFOriginal{Path: pdfPath, Hash: hashOriginal, CodigoVerificacion: codigoVerificacion}, nil
+}
+
+// DescartarPDFOriginal borra el archivo de un PDF preparado que no se registró
+func (s *FirmaDigitalService) DescartarPDFOriginal(pdf *PDFOriginal) {
+	if pdf == nil {
+		return
+	}
+	if err := os.Remove(pdf.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error.Printf("Error borrando PDF original descartado %s: %v", pdf.Path, err)
+	}
+}
 
-	// 3. Generar token de firma
-	token := generarToken(TokenLength)
-	tokenExpira := time.Now().Add(TokenExpiracionHrs * time.Hour)
+// RegistrarProcesoFirma crea el registro de contrato_firma con el PDF ya preparado, usando db
+// (la transacción del evento de pago aprobado) para que el proceso se confirme junto con el
+// evento. Es idempotente: si el contrato ya tiene un proceso de firma lo devuelve sin cambios
+// y descarta el PDF preparado. El token se emite recién cuando el cliente guarda su firma
+// (ver GuardarFirmaCanvas).
+func (s *FirmaDigitalService) RegistrarProcesoFirma(ctx context.Context, db repositorios.Execer, idContrato int, pdf *PDFOriginal) (*modelos.ContratoFirma, error) {
+	// 1. Si ya existe un proceso (activo o firmado), no se inicia otro
+	cfRepo := repositorios.NewContratoFirmaRepo(db)
+	existente, err := cfRepo.ObtenerPorIDContrato(ctx, idContrato)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error verificando contrato_firma existente: %w", err)
+	}
 
-	// 4. Obtener id_persona del contrato para la notificación
+	if existente != nil {
+		logger.Info.Printf("El contrato %d ya tiene un proceso de firma (contrato_firma %d)", idContrato, existente.IDContratoFirma)
+		s.DescartarPDFOriginal(pdf)
+		return existente, nil
+	}
+	if pdf == nil {
+		return nil, fmt.Errorf("el contrato %d no tiene un PDF original preparado", idContrato)
+	}
+
+	// 2. Verificar que el contrato exista y tenga titular
 	var idPersona int
-	err = s.db.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ?", idContrato).Scan(&idPersona)
+	err = db.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ?", idContrato).Scan(&idPersona)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo persona del contrato: %w", err)
 	}
 
-	// 5. Crear registro contrato_firma
+	// 3. Crear registro contrato_firma sin token (vence en el acto hasta que se emita)
 	ahora := time.Now()
 	cf := &modelos.ContratoFirma{
 		IDContrato:      idContrato,
-		PdfOriginalPath: pdfPath,
-		HashOriginal:    hashOriginal,
+		PdfOriginalPath: pdf.Path,
+		HashOriginal:    pdf.Hash,
 		MetodoFirma:     "canvas+token",
-		TokenFirma:      token,
-		TokenExpira:     tokenExpira,
+		TokenExpira:     ahora,
 		PdfGenerado:     &ahora,
+
+		CodigoVerificacion: &pdf.CodigoVerificacion,
 	}
 
 	idContratoFirma, err := cfRepo.Crear(ctx, cf)
@@ -88,46 +132,48 @@ func (s *FirmaDigitalService) IniciarProcesoFirma(ctx context.Context, idContrat
 	}
 	cf.IDContratoFirma = int(idContratoFirma)
 
-	// 6. Crear notificación para el cliente
-	notifRepo := repositorios.NewNotificacionRepo(s.db)
-	rolCliente := "CLIENTE"
-	err = notifRepo.CrearNotificacion(
-		ctx,
-		idPersona,
-		"CONTRATO",
-		"Token de firma enviado",
-		fmt.Sprintf("Se ha generado tu contrato. Revisá tu correo electrónico para obtener el token de firma digital. El token expira en %d horas.", TokenExpiracionHrs),
-		&rolCliente,
-		nil,
-		&idContrato,
-		nil,
-		nil,
-	)
-	if err != nil {
-		logger.Error.Printf("Error creando notificación de token enviado: %v", err)
-		// No retornar error, continuar el flujo
+	// 4. Registrar el evento para notificar al cliente, en la misma transacción
+	if _, err := repositorios.NewOutboxRepo(db).Registrar(ctx, modelos.EventoFirmaIniciada, modelos.PayloadContrato{IDContrato: idContrato}); err != nil {
+		return nil, fmt.Errorf("error registrando evento de firma iniciada: %w", err)
 	}
 
 	logger.Info.Printf("Proceso de firma iniciado para contrato %d (contrato_firma %d)", idContrato, cf.IDContratoFirma)
 	return cf, nil
 }
 
-// GuardarFirmaCanvas guarda la imagen de la firma en formato base64
-func (s *FirmaDigitalService) GuardarFirmaCanvas(ctx context.Context, idContratoFirma int, firmaBase64, ip, userAgent string) error {
+// IniciarProcesoFirma prepara el PDF original y registra el proceso de firma fuera de una
+// transacción. Si el registro falla, el PDF se descarta.
+func (s *FirmaDigitalService) IniciarProcesoFirma(ctx context.Context, idContrato int) (*modelos.ContratoFirma, error) {
+	pdf, err := s.PrepararPDFOriginal(ctx, idContrato)
+	if err != nil {
+		return nil, err
+	}
+	cf, err := s.RegistrarProcesoFirma(ctx, s.db, idContrato, pdf)
+	if err != nil {
+		s.DescartarPDFOriginal(pdf)
+		return nil, err
+	}
+	return cf, nil
+}
+
+// GuardarFirmaCanvas guarda la imagen de la firma en formato base64. Si todavía no se emitió
+// el token de firma lo emite y devuelve el contrato_firma con el token en claro para que el
+// controlador lo envíe por email; si ya se había emitido, devuelve nil.
+func (s *FirmaDigitalService) GuardarFirmaCanvas(ctx context.Context, idContratoFirma int, firmaBase64, ip, userAgent string) (*modelos.ContratoFirma, error) {
 	cfRepo := repositorios.NewContratoFirmaRepo(s.db)
 
 	// 1. Validar que existe
 	cf, err := cfRepo.ObtenerPorID(ctx, idContratoFirma)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return utilidades.ErrNoEncontrado
+			return nil, utilidades.ErrNoEncontrado
 		}
-		return err
+		return nil, err
 	}
 
 	// 2. Validar que no esté firmado
 	if cf.Firmado {
-		return errors.New("el contrato ya fue firmado")
+		return nil, errors.New("el contrato ya fue firmado")
 	}
 
 	// 3. Limpiar base64 (remover prefijo data:image/png;base64,)
@@ -150,48 +196,36 @@ func (s *FirmaDigitalService) GuardarFirmaCanvas(ctx context.Context, idContrato
 	// 4. Decodificar y guardar imagen
 	firmaBytes, err := base64.StdEncoding.DecodeString(firmaBase64)
 	if err != nil {
-		return fmt.Errorf("firma base64 inválida: %w", err)
+		return nil, fmt.Errorf("firma base64 inválida: %w", err)
 	}
 
 	firmaPath := filepath.Join(s.contractBasePath, "firma_img", fmt.Sprintf("%d.png", idContratoFirma))
 	if err := os.MkdirAll(filepath.Dir(firmaPath), 0755); err != nil {
-		return fmt.Errorf("error creando directorio de firma: %w", err)
+		return nil, fmt.Errorf("error creando directorio de firma: %w", err)
 	}
 
 	if err := os.WriteFile(firmaPath, firmaBytes, 0644); err != nil {
-		return fmt.Errorf("error guardando imagen de firma: %w", err)
+		return nil, fmt.Errorf("error guardando imagen de firma: %w", err)
 	}
 
 	// 5. Actualizar registro
 	if err := cfRepo.ActualizarFirmaCanvas(ctx, idContratoFirma, firmaPath, ip, userAgent); err != nil {
-		return fmt.Errorf("error actualizando contrato_firma: %w", err)
+		return nil, fmt.Errorf("error actualizando contrato_firma: %w", err)
 	}
 
-	// 6. Obtener id_persona del contrato para la notificación
-	var idPersona int
-	err = s.db.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ?", cf.IDContrato).Scan(&idPersona)
-	if err == nil {
-		notifRepo := repositorios.NewNotificacionRepo(s.db)
-		rolCliente := "CLIENTE"
-		err = notifRepo.CrearNotificacion(
-			ctx,
-			idPersona,
-			"CONTRATO",
-			"Firma guardada",
-			"Tu firma digital ha sido guardada correctamente. Ahora ingresá el token que recibiste por email para completar el proceso.",
-			&rolCliente,
-			nil,
-			&cf.IDContrato,
-			nil,
-			nil,
-		)
-		if err != nil {
-			logger.Error.Printf("Error creando notificación de firma guardada: %v", err)
-		}
-	}
+	// 6. Registrar el evento para notificar al cliente (outbox)
+	s.registrarEventoFirma(ctx, modelos.EventoFirmaGuardada, cf.IDContrato)
 
 	logger.Info.Printf("Firma canvas guardada para contrato_firma %d", idContratoFirma)
-	return nil
+
+	// 7. Primera firma guardada: emitir el token
+	if cf.TokenFirmaHash != "" {
+		return nil, nil
+	}
+	if err := s.emitirToken(ctx, cf); err != nil {
+		return nil, err
+	}
+	return cf, nil
 }
 
 // ValidarTokenYFirmar valida el token y genera el PDF firmado
@@ -216,12 +250,16 @@ func (s *FirmaDigitalService) ValidarTokenYFirmar(ctx context.Context, idContrat
 		return errors.New("debe cargar la firma antes de validar el token")
 	}
 
+	if cf.TokenFirmaHash == "" {
+		return errors.New("todavía no se emitió el token de firma. Solicitá uno desde la opción 'Reenviar token'")
+	}
+
 	if cf.IntentosToken >= MaxIntentosToken {
 		return errors.New("excediste el máximo de intentos. Solicitá un nuevo token desde la opción 'Reenviar token'")
 	}
 
-	// 3. Validar token (primero verificar si es correcto)
-	if cf.TokenFirma != tokenIngresado {
+	// 3. Validar token (primero verificar si es correcto; se compara el hash en tiempo constante)
+	if !utilidades.TokenCoincide(tokenIngresado, cf.TokenFirmaHash) {
 		// Incrementar intentos
 		if err := cfRepo.IncrementarIntentos(ctx, idContratoFirma); err != nil {
 			logger.Error.Printf("Error incrementando intentos: %v", err)
@@ -234,15 +272,8 @@ func (s *FirmaDigitalService) ValidarTokenYFirmar(ctx context.Context, idContrat
 		return utilidades.ErrTokenExpirado
 	}
 
-	// 4. Leer firma para embeber en PDF
-	firmaBytes, err := os.ReadFile(*cf.FirmaPath)
-	if err != nil {
-		return fmt.Errorf("error leyendo firma: %w", err)
-	}
-	firmaBase64 := base64.StdEncoding.EncodeToString(firmaBytes)
-
-	// 5. Generar PDF firmado
-	pdfFirmadoPath, hashFirmado, err := s.pdfService.GenerarPDFConFirma(ctx, idContratoFirma, "data:image/png;base64,"+firmaBase64)
+	// 5. Generar PDF firmado (firma del cliente, anexo de auditoría y firma digital de la empresa)
+	pdfFirmadoPath, hashFirmado, err := s.pdfService.GenerarPDFConFirma(ctx, idContratoFirma, time.Now())
 	if err != nil {
 		return fmt.Errorf("error generando PDF firmado: %w", err)
 	}
@@ -252,66 +283,50 @@ func (s *FirmaDigitalService) ValidarTokenYFirmar(ctx context.Context, idContrat
 		return fmt.Errorf("error marcando contrato como firmado: %w", err)
 	}
 
-	// 7. Actualizar estado del contrato a "Vigente" (id_estado_contrato = 3)
-	contratoRepo := repositorios.NewContratoRepo(s.db)
-	if err := contratoRepo.ActualizarEstado(ctx, cf.IDContrato, 3); err != nil {
-		logger.Error.Printf("Error actualizando estado de contrato a Vigente: %v", err)
-		// No retornamos error, el contrato ya se firmó
+	// 7-8. Pasar el contrato a "Vigente" (y la conexión a "Por configurar").
+	// No retornamos error: el contrato ya se firmó
+	if err := s.activarContratoFirmado(ctx, cf.IDContrato); err != nil {
+		logger.Error.Printf("Error pasando el contrato %d a %s: %v", cf.IDContrato, modelos.EstadoContratoVigente, err)
 	}
 
-	// 8. Obtener id_conexion y actualizar estado a "Por configurar" (id_estado_conexion = 7)
-	idConexion, err := contratoRepo.ObtenerIDConexionPorContrato(ctx, cf.IDContrato)
+	// 9. Registrar el evento para notificar al cliente y a los técnicos (outbox)
+	s.registrarEventoFirma(ctx, modelos.EventoContratoFirmado, cf.IDContrato)
+
+	logger.Info.Printf("Contrato %d firmado exitosamente (contrato_firma %d)", cf.IDContrato, idContratoFirma)
+	return nil
+}
+
+// activarContratoFirmado aplica la transición a "Vigente" en nombre del titular; la máquina de
+// estados verifica la firma y pasa la conexión a "Por configurar"
+func (s *FirmaDigitalService) activarContratoFirmado(ctx context.Context, idContrato int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		logger.Error.Printf("Error obteniendo id_conexion del contrato: %v", err)
-	} else {
-		conexionRepo := repositorios.NewConexionRepo(s.db)
-		if err := conexionRepo.ActualizarEstado(ctx, idConexion, 7); err != nil {
-			logger.Error.Printf("Error actualizando estado de conexión a Por configurar: %v", err)
-		}
+		return err
 	}
+	defer tx.Rollback()
 
-	// 9. Crear notificación de contrato firmado (para cliente)
-	var idPersona int
-	err = s.db.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ?", cf.IDContrato).Scan(&idPersona)
-	if err == nil {
-		notifRepo := repositorios.NewNotificacionRepo(s.db)
-		rolCliente := "CLIENTE"
-		err = notifRepo.CrearNotificacion(
-			ctx,
-			idPersona,
-			"CONTRATO",
-			"¡Contrato firmado exitosamente!",
-			"Tu contrato ha sido firmado digitalmente y ahora está vigente. En breve un técnico se pondrá en contacto para configurar tu conexión.",
-			&rolCliente,
-			nil,
-			&cf.IDContrato,
-			nil,
-			nil,
-		)
-		if err != nil {
-			logger.Error.Printf("Error creando notificación de contrato firmado: %v", err)
-		}
+	idPersona, err := repositorios.NewContratoRepo(tx).ObtenerIDPersonaPorContrato(ctx, idContrato)
+	if err != nil {
+		return err
 	}
-
-	// 10. Crear notificación para técnicos (rol 'TECNICO')
-	notifRepo := repositorios.NewNotificacionRepo(s.db)
-	err = notifRepo.CrearNotificacionParaRol(
-		ctx,
-		"TECNICO",
-		"INSTALACION",
-		"Nuevo contrato firmado - Configuración pendiente",
-		fmt.Sprintf("El contrato #%d ha sido firmado y está listo para configuración de conexión.", cf.IDContrato),
-		&idConexion,
-		&cf.IDContrato,
-		nil,
-		nil,
-	)
+	titular, err := ActorPersona(ctx, tx, idPersona)
 	if err != nil {
-		logger.Error.Printf("Error creando notificación para técnicos: %v", err)
+		return err
+	}
+	obs := "Firma del contrato"
+	if _, err := NewCicloVida(tx).TransicionarContrato(ctx, idContrato, modelos.EstadoContratoVigente, titular, &obs); err != nil {
+		return err
 	}
+	return tx.Commit()
+}
 
-	logger.Info.Printf("Contrato %d firmado exitosamente (contrato_firma %d)", cf.IDContrato, idContratoFirma)
-	return nil
+// registrarEventoFirma deja en el outbox el evento del proceso de firma. La firma ya quedó
+// guardada, así que un error sólo se registra en el log.
+func (s *FirmaDigitalService) registrarEventoFirma(ctx context.Context, tipo string, idContrato int) {
+	payload := modelos.PayloadContrato{IDContrato: idContrato}
+	if _, err := repositorios.NewOutboxRepo(s.db).Registrar(ctx, tipo, payload); err != nil {
+		logger.Error.Printf("Error registrando evento %s del contrato %d: %v", tipo, idContrato, err)
+	}
 }
 
 // ObtenerContratoFirma obtiene un contrato_firma por ID
@@ -362,56 +377,206 @@ func (s *FirmaDigitalService) ReenviarToken(ctx context.Context, idContratoFirma
 		logger.Info.Printf("Regenerando token para contrato_firma %d: intentos excedidos (%d/%d)", idContratoFirma, cf.IntentosToken, MaxIntentosToken)
 	}
 
-	// 4. Generar nuevo token y nueva fecha de expiración
+	// 4. Generar el nuevo token (el primero, si nunca se emitió)
+	yaEmitido := cf.TokenFirmaHash != ""
+	if err := s.emitirToken(ctx, cf); err != nil {
+		return nil, err
+	}
+
+	// 5. Registrar el evento para notificar al cliente (outbox)
+	if yaEmitido {
+		s.registrarEventoFirma(ctx, modelos.EventoFirmaTokenRegenerado, cf.IDContrato)
+	}
+
+	logger.Info.Printf("Token regenerado para contrato_firma %d (contrato %d)", idContratoFirma, cf.IDContrato)
+	return cf, nil
+}
+
+// emitirToken genera un token de firma nuevo, guarda su hash con la nueva expiración y deja
+// el token en claro en cf para que el controlador lo envíe por email
+func (s *FirmaDigitalService) emitirToken(ctx context.Context, cf *modelos.ContratoFirma) error {
 	nuevoToken := generarToken(TokenLength)
 	nuevaExpiracion := time.Now().Add(TokenExpiracionHrs * time.Hour)
 
-	// 5. Actualizar en base de datos
 	query := `
 		UPDATE contrato_firma
-		SET token_firma = ?,
+		SET token_firma_hash = ?,
 		    token_expira = ?,
 		    intentos_token = 0,
 		    token_enviado = NULL
 		WHERE id_contrato_firma = ?
 	`
 
-	_, err = s.db.ExecContext(ctx, query, nuevoToken, nuevaExpiracion, idContratoFirma)
+	_, err := s.db.ExecContext(ctx, query, utilidades.HashToken(nuevoToken), nuevaExpiracion, cf.IDContratoFirma)
 	if err != nil {
-		return nil, fmt.Errorf("error actualizando token: %w", err)
+		return fmt.Errorf("error actualizando token: %w", err)
 	}
 
-	// 6. Actualizar objeto y retornar
 	cf.TokenFirma = nuevoToken
+	cf.TokenFirmaHash = utilidades.HashToken(nuevoToken)
 	cf.TokenExpira = nuevaExpiracion
 	cf.IntentosToken = 0
 	cf.TokenEnviado = nil
+	return nil
+}
 
-	// 7. Crear notificación de token reenviado
-	var idPersona int
-	err = s.db.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ?", cf.IDContrato).Scan(&idPersona)
-	if err == nil {
-		notifRepo := repositorios.NewNotificacionRepo(s.db)
-		rolCliente := "CLIENTE"
-		err = notifRepo.CrearNotificacion(
-			ctx,
-			idPersona,
-			"CONTRATO",
-			"Nuevo token de firma enviado",
-			fmt.Sprintf("Se ha generado un nuevo token de firma para tu contrato. Revisá tu correo electrónico. El token expira en %d horas.", TokenExpiracionHrs),
-			&rolCliente,
-			nil,
-			&cf.IDContrato,
-			nil,
-			nil,
-		)
-		if err != nil {
-			logger.Error.Printf("Error creando notificación de token reenviado: %v", err)
+// VerificacionFirma es el resultado de revalidar la firma digital embebida en un contrato
+type VerificacionFirma struct {
+	IDContratoFirma    int        `json:"id_contrato_firma,omitempty"`
+	IDContrato         int        `json:"id_contrato,omitempty"`
+	Valida             bool       `json:"valida"`
+	Motivo             string     `json:"motivo,omitempty"`
+	Firmante           string     `json:"firmante,omitempty"`
+	Emisor             string     `json:"emisor,omitempty"`
+	NumeroSerie        string     `json:"numero_serie,omitempty"`
+	FechaFirma         *time.Time `json:"fecha_firma,omitempty"`
+	Razon              string     `json:"razon,omitempty"`
+	Ubicacion          string     `json:"ubicacion,omitempty"`
+	CubreDocumento     bool       `json:"cubre_documento"`
+	CertificadoVigente bool       `json:"certificado_vigente"`
+	CertificadoEmpresa bool       `json:"certificado_empresa"`
+	HashCoincide       bool       `json:"hash_coincide"`
+}
+
+// VerificarFirma revalida la firma digital del PDF firmado: la firma CMS, que cubra
+// todo el archivo, el certificado de la empresa y el hash registrado al firmar.
+func (s *FirmaDigitalService) VerificarFirma(ctx context.Context, idContratoFirma int) (*VerificacionFirma, error) {
+	// 1. Obtener contrato_firma
+	cf, err := s.ObtenerContratoFirma(ctx, idContratoFirma)
+	if err != nil {
+		return nil, err
+	}
+	if !cf.Firmado || cf.PdfFirmadoPath == nil || *cf.PdfFirmadoPath == "" {
+		return nil, utilidades.ErrContratoSinFirmar
+	}
+
+	res := &VerificacionFirma{IDContratoFirma: cf.IDContratoFirma, IDContrato: cf.IDContrato}
+
+	// 2. El archivo debe ser el mismo que se registró al firmar
+	hashActual, err := s.pdfService.calcularHashArchivo(*cf.PdfFirmadoPath)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo PDF firmado: %w", err)
+	}
+	res.HashCoincide = cf.HashFirmado != nil && *cf.HashFirmado == hashActual
+
+	// 3. Validar la firma CMS embebida
+	firma, err := s.pdfService.VerificarFirmaDigital(*cf.PdfFirmadoPath)
+	if err != nil {
+		if errors.Is(err, pdf.ErrSinFirma) || errors.Is(err, pdf.ErrFirmaInvalida) || errors.Is(err, pdf.ErrCMSNoSoportado) {
+			res.Motivo = err.Error()
+			return res, nil
 		}
+		return nil, fmt.Errorf("error verificando firma digital: %w", err)
+	}
+
+	res.Firmante = firma.Firmante
+	res.Emisor = firma.Emisor
+	res.NumeroSerie = firma.NumeroSerie
+	res.FechaFirma = firma.Fecha
+	res.Razon = firma.Razon
+	res.Ubicacion = firma.Ubicacion
+	res.CubreDocumento = firma.CubreDocumento
+	res.CertificadoVigente = firma.CertificadoVigente
+	res.CertificadoEmpresa = s.pdfService.EsCertificadoEmpresa(firma.Certificado.Raw)
+
+	// 4. Resultado final
+	switch {
+	case !res.CubreDocumento:
+		res.Motivo = "el documento tiene modificaciones posteriores a la firma"
+	case !res.HashCoincide:
+		res.Motivo = "el archivo no coincide con el hash registrado al firmar"
+	case !res.CertificadoVigente:
+		res.Motivo = "el certificado no estaba vigente en la fecha de firma"
+	case s.pdfService.CertificadoEmpresaHabilitado() && !res.CertificadoEmpresa:
+		res.Motivo = "el certificado no corresponde al de la empresa"
+	default:
+		res.Valida = true
+	}
+
+	return res, nil
+}
+
+// VerificacionContrato es la información pública de un contrato consultada por su código
+// de verificación. No expone identificadores internos ni datos personales completos.
+type VerificacionContrato struct {
+	CodigoVerificacion string             `json:"codigo_verificacion"`
+	Titular            string             `json:"titular"`
+	Estado             string             `json:"estado"` // firmado | pendiente_firma
+	FechaEmision       *time.Time         `json:"fecha_emision,omitempty"`
+	FechaFirma         *time.Time         `json:"fecha_firma,omitempty"`
+	HashOriginal       string             `json:"hash_original"`
+	HashFirmado        *string            `json:"hash_firmado,omitempty"`
+	FirmaDigital       *VerificacionFirma `json:"firma_digital,omitempty"`
+}
+
+// VerificarPorCodigo devuelve el estado de firma, fechas y hashes del contrato que tiene
+// impreso el código de verificación indicado (consulta pública desde el QR del PDF).
+func (s *FirmaDigitalService) VerificarPorCodigo(ctx context.Context, codigo string) (*VerificacionContrato, error) {
+	// 1. Normalizar el código ingresado
+	codigoNormalizado, err := utilidades.NormalizarCodigoVerificacion(codigo)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Info.Printf("Token regenerado para contrato_firma %d (contrato %d)", idContratoFirma, cf.IDContrato)
-	return cf, nil
+	// 2. Buscar el contrato_firma
+	cf, err := repositorios.NewContratoFirmaRepo(s.db).ObtenerPorCodigoVerificacion(ctx, codigoNormalizado)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utilidades.ErrNotFound{Entity: "contrato", Campo: "código de verificación", Valor: codigoNormalizado}
+		}
+		return nil, err
+	}
+
+	// 3. Titular enmascarado (nombre e inicial del apellido)
+	var nombre, apellido string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT p.nombre, p.apellido
+		FROM contrato c
+		INNER JOIN persona p ON p.id_persona = c.id_persona
+		WHERE c.id_contrato = ?
+	`, cf.IDContrato).Scan(&nombre, &apellido)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error obteniendo titular del contrato: %w", err)
+	}
+
+	res := &VerificacionContrato{
+		CodigoVerificacion: codigoNormalizado,
+		Titular:            enmascararTitular(nombre, apellido),
+		Estado:             "pendiente_firma",
+		FechaEmision:       cf.PdfGenerado,
+		HashOriginal:       cf.HashOriginal,
+	}
+	if !cf.Firmado {
+		return res, nil
+	}
+
+	// 4. Contrato firmado: agregar fecha, hash y revalidar la firma digital
+	res.Estado = "firmado"
+	res.FechaFirma = cf.FechaFirma
+	res.HashFirmado = cf.HashFirmado
+
+	firma, err := s.VerificarFirma(ctx, cf.IDContratoFirma)
+	if err != nil {
+		if errors.Is(err, utilidades.ErrContratoSinFirmar) {
+			return res, nil
+		}
+		logger.Warn.Printf("No se pudo revalidar la firma del contrato_firma %d: %v", cf.IDContratoFirma, err)
+		return res, nil
+	}
+	firma.IDContratoFirma, firma.IDContrato = 0, 0
+	res.FirmaDigital = firma
+
+	return res, nil
+}
+
+// enmascararTitular devuelve "Nombre A." para no exponer el nombre completo
+func enmascararTitular(nombre, apellido string) string {
+	nombre = strings.TrimSpace(nombre)
+	apellido = strings.TrimSpace(apellido)
+	if apellido == "" {
+		return nombre
+	}
+	return strings.TrimSpace(nombre + " " + strings.ToUpper(string([]rune(apellido)[:1])) + ".")
 }
 
 // generarToken genera un token aleatorio alfanumérico
@@ -0,0 +1,50 @@
+package servicios
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIniciarProcesoFirmaEsIdempotente(t *testing.T) {
+	ahora := time.Now()
+	db, bd := nuevaBDFake(t, respuestaFake{
+		contiene: "FROM contrato_firma",
+		columnas: strings.Split("id_contrato_firma,id_contrato,pdf_original_path,pdf_firmado_path,firma_path,hash_original,hash_firmado,metodo_firma,token_firma_hash,token_expira,intentos_token,pdf_generado,token_enviado,firmado,fecha_firma,ip_firma,user_agent,codigo_verificacion", ","),
+		filas: [][]driver.Value{{
+			int64(4), int64(11), "/contratos/original/11.pdf", nil, nil, "abc", nil, "canvas+token", nil,
+			ahora, int64(0), ahora, nil, int64(0), nil, nil, nil, "ABCD-EFGH",
+		}},
+	})
+	// Sin servicio de PDF: si intentara generar el contrato otra vez, el test entra en pánico
+	s := NewFirmaDigitalService(db, nil, t.TempDir())
+
+	cf, err := s.IniciarProcesoFirma(context.Background(), 11)
+	if err != nil {
+		t.Fatalf("IniciarProcesoFirma: %v", err)
+	}
+	if cf.IDContratoFirma != 4 {
+		t.Errorf("id_contrato_firma = %d, se esperaba el existente (4)", cf.IDContratoFirma)
+	}
+	if n := len(bd.ejecutadas); n != 0 {
+		t.Errorf("se ejecutaron %d sentencias de escritura, se esperaba ninguna", n)
+	}
+
+	// Un PDF preparado por un intento concurrente no se registra y se borra
+	preparado := &PDFOriginal{Path: filepath.Join(t.TempDir(), "11-WXYZ-2345.pdf"), Hash: "def", CodigoVerificacion: "WXYZ-2345"}
+	if err := os.WriteFile(preparado.Path, []byte("%PDF-1.7"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cf, err = s.RegistrarProcesoFirma(context.Background(), db, 11, preparado)
+	if err != nil || cf.IDContratoFirma != 4 {
+		t.Fatalf("RegistrarProcesoFirma: %+v, %v", cf, err)
+	}
+	if _, err := os.Stat(preparado.Path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("el PDF preparado que no se registró sigue en disco (%v)", err)
+	}
+}
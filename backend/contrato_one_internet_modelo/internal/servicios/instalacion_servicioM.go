@@ -0,0 +1,561 @@
+package servicios
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// Órdenes de instalación.
+//
+// La máquina de estados crea una orden pendiente cuando la conexión pasa a "Por configurar".
+// Un técnico la toma (o quien las gestiona se la asigna), la programa en un turno y, al terminar en el
+// domicilio, la completa con el serial de la ONU, las potencias medidas, los materiales y las
+// fotos; eso pasa la conexión a "Activa".
+
+const (
+	duracionMaximaTurno = 8 * time.Hour
+	maxFotosOrden       = 10
+	maxTamanoFoto       = 5 << 20 // bytes, ya decodificada
+
+	// Rangos aceptados de potencia óptica en la ONU (dBm)
+	potenciaRxMinima = -40.0
+	potenciaRxMaxima = 0.0
+	potenciaTxMinima = -10.0
+	potenciaTxMaxima = 10.0
+)
+
+// extensionesFoto son los formatos de foto aceptados, por tipo de contenido detectado
+var extensionesFoto = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+// InstalacionService maneja las órdenes de instalación
+type InstalacionService struct {
+	db        *sql.DB
+	fotosPath string
+}
+
+func NewInstalacionService(db *sql.DB, fotosPath string) *InstalacionService {
+	return &InstalacionService{db: db, fotosPath: fotosPath}
+}
+
+// operadorInstalacion es el usuario que opera las órdenes. Con instalacion.gestionar trabaja
+// cualquiera; con instalacion.operar (el técnico) sólo las suyas.
+type operadorInstalacion struct {
+	idUsuario int
+	gestiona  bool
+}
+
+// puedeOperar indica si el operador puede trabajar la orden: quien gestiona cualquiera, el técnico las suyas
+func (o *operadorInstalacion) puedeOperar(orden *modelos.OrdenInstalacion) bool {
+	return o.gestiona || (orden.IDTecnico != nil && *orden.IDTecnico == o.idUsuario)
+}
+
+// puedeVer agrega, para el técnico, las órdenes pendientes que todavía nadie tomó
+func (o *operadorInstalacion) puedeVer(orden *modelos.OrdenInstalacion) bool {
+	return o.puedeOperar(orden) || (orden.IDTecnico == nil && orden.Estado == modelos.EstadoOrdenPendiente)
+}
+
+// operador resuelve el usuario y los permisos de la persona que hace el pedido (X-ID-Persona)
+func (s *InstalacionService) operador(ctx context.Context, db repositorios.Execer, idPersona int) (*operadorInstalacion, error) {
+	idUsuario, permisos, err := permisosDePersona(ctx, db, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	op := &operadorInstalacion{idUsuario: idUsuario, gestiona: tienePermiso(permisos, modelos.PermisoInstalacionGestionar)}
+	if !op.gestiona && !tienePermiso(permisos, modelos.PermisoInstalacionOperar) {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+	return op, nil
+}
+
+func tieneRol(roles []string, rol string) bool {
+	for _, r := range roles {
+		if r == rol {
+			return true
+		}
+	}
+	return false
+}
+
+func ordenAbierta(orden *modelos.OrdenInstalacion) bool {
+	return orden.Estado == modelos.EstadoOrdenPendiente ||
+		orden.Estado == modelos.EstadoOrdenAsignada ||
+		orden.Estado == modelos.EstadoOrdenProgramada
+}
+
+// Listar devuelve las órdenes paginadas. El técnico ve las suyas y las pendientes sin asignar.
+func (s *InstalacionService) Listar(
+	ctx context.Context,
+	filtro modelos.FiltroOrdenesInstalacion,
+	page, limit int,
+	idPersona int,
+) (*modelos.OrdenesInstalacionResponse, error) {
+	op, err := s.operador(ctx, s.db, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filtro.Estado {
+	case "", modelos.EstadoOrdenPendiente, modelos.EstadoOrdenAsignada, modelos.EstadoOrdenProgramada,
+		modelos.EstadoOrdenCompletada, modelos.EstadoOrdenCancelada:
+	default:
+		return nil, utilidades.ErrValidation{Campo: "estado", Mensaje: fmt.Sprintf("'%s' no es un estado de orden", filtro.Estado)}
+	}
+	if filtro.Desde != nil && filtro.Hasta != nil && !filtro.Hasta.After(*filtro.Desde) {
+		return nil, utilidades.ErrValidation{Campo: "hasta", Mensaje: "debe ser posterior a desde"}
+	}
+	if !op.gestiona {
+		filtro.ParaTecnico = &op.idUsuario
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	ordenes, total, err := repositorios.NewOrdenInstalacionRepo(s.db).Listar(ctx, filtro, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := (total + limit - 1) / limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &modelos.OrdenesInstalacionResponse{
+		Ordenes:    ordenes,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Detalle devuelve la orden con sus materiales y fotos
+func (s *InstalacionService) Detalle(ctx context.Context, idOrden, idPersona int) (*modelos.OrdenInstalacion, error) {
+	op, err := s.operador(ctx, s.db, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	orden, err := s.detalle(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	// Para el técnico, las órdenes ajenas no existen
+	if !op.puedeVer(orden) {
+		return nil, utilidades.ErrNotFound{Entity: "orden_instalacion", Campo: "id_orden", Valor: fmt.Sprintf("%d", idOrden)}
+	}
+	return orden, nil
+}
+
+func (s *InstalacionService) detalle(ctx context.Context, idOrden int) (*modelos.OrdenInstalacion, error) {
+	repo := repositorios.NewOrdenInstalacionRepo(s.db)
+
+	orden, err := repo.ObtenerPorID(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	if orden.Materiales, err = repo.ListarMateriales(ctx, idOrden); err != nil {
+		return nil, err
+	}
+	if orden.Fotos, err = repo.ListarFotos(ctx, idOrden); err != nil {
+		return nil, err
+	}
+	return orden, nil
+}
+
+// Asignar fija el técnico de la orden. El admin asigna a cualquier técnico; el técnico sólo
+// puede tomar para sí una orden sin asignar (o que ya es suya).
+func (s *InstalacionService) Asignar(
+	ctx context.Context,
+	idOrden int,
+	req modelos.AsignarOrdenRequest,
+	idPersona int,
+) (*modelos.OrdenInstalacionResponse, error) {
+	if req.IDTecnico != nil && *req.IDTecnico <= 0 {
+		return nil, utilidades.ErrValidation{Campo: "id_tecnico", Mensaje: "debe ser mayor que 0"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Operador y orden (bloqueada)
+	op, err := s.operador(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	repo := repositorios.NewOrdenInstalacionRepo(tx)
+	orden, err := repo.ObtenerParaActualizar(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	if !ordenAbierta(orden) {
+		return nil, utilidades.ErrOrdenCerrada
+	}
+
+	// 2. Técnico: el indicado por quien gestiona las órdenes o quien hace el pedido
+	idTecnico := op.idUsuario
+	if req.IDTecnico != nil {
+		idTecnico = *req.IDTecnico
+	}
+	if !op.gestiona && (idTecnico != op.idUsuario || (orden.IDTecnico != nil && *orden.IDTecnico != op.idUsuario)) {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+	roles, err := repositorios.NewUsuarioRolRepo(tx).ObtenerRolesPorUsuario(ctx, idTecnico)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo roles del usuario %d: %w", idTecnico, err)
+	}
+	if !tieneRol(roles, modelos.RolTecnico) {
+		return nil, utilidades.ErrValidation{Campo: "id_tecnico", Mensaje: "no corresponde a un usuario con rol técnico"}
+	}
+
+	// 3. Asignación (si cambia el técnico se descarta el turno)
+	if err := repo.Asignar(ctx, idOrden, idTecnico); err != nil {
+		return nil, err
+	}
+
+	// 4. Aviso al técnico, sólo si es una asignación nueva
+	if orden.IDTecnico == nil || *orden.IDTecnico != idTecnico {
+		if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoInstalacionAsignada, modelos.PayloadOrdenInstalacion{IDOrden: idOrden}); err != nil {
+			return nil, fmt.Errorf("error registrando evento: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Orden de instalación %d asignada al usuario %d", idOrden, idTecnico)
+	return s.respuesta(ctx, idOrden, "Orden asignada correctamente")
+}
+
+// Programar fija el turno de la visita. El técnico de la orden no puede tener otra orden
+// abierta con un turno que se superponga.
+func (s *InstalacionService) Programar(
+	ctx context.Context,
+	idOrden int,
+	req modelos.ProgramarOrdenRequest,
+	idPersona int,
+) (*modelos.OrdenInstalacionResponse, error) {
+	// Validaciones de entrada
+	if req.TurnoInicio.IsZero() {
+		return nil, utilidades.ErrValidation{Campo: "turno_inicio", Mensaje: "es requerido"}
+	}
+	if req.TurnoFin.IsZero() {
+		return nil, utilidades.ErrValidation{Campo: "turno_fin", Mensaje: "es requerido"}
+	}
+	if !req.TurnoFin.After(req.TurnoInicio) {
+		return nil, utilidades.ErrValidation{Campo: "turno_fin", Mensaje: "debe ser posterior a turno_inicio"}
+	}
+	if req.TurnoFin.Sub(req.TurnoInicio) > duracionMaximaTurno {
+		return nil, utilidades.ErrValidation{Campo: "turno_fin", Mensaje: fmt.Sprintf("el turno no puede durar más de %s", duracionMaximaTurno)}
+	}
+	if !req.TurnoInicio.After(time.Now()) {
+		return nil, utilidades.ErrValidation{Campo: "turno_inicio", Mensaje: "debe ser una fecha futura"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Operador y orden (bloqueada)
+	op, err := s.operador(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	repo := repositorios.NewOrdenInstalacionRepo(tx)
+	orden, err := repo.ObtenerParaActualizar(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	if !ordenAbierta(orden) {
+		return nil, utilidades.ErrOrdenCerrada
+	}
+	if orden.IDTecnico == nil {
+		return nil, utilidades.ErrOrdenSinTecnico
+	}
+	if !op.puedeOperar(orden) {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+
+	// 2. Superposición: se bloquea al técnico para que dos turnos simultáneos no pasen juntos
+	if err := repo.BloquearTecnico(ctx, *orden.IDTecnico); err != nil {
+		return nil, err
+	}
+	idOtra, err := repo.BuscarTurnoSuperpuesto(ctx, *orden.IDTecnico, idOrden, req.TurnoInicio, req.TurnoFin)
+	if err != nil {
+		return nil, err
+	}
+	if idOtra != 0 {
+		return nil, fmt.Errorf("%w (orden %d)", utilidades.ErrTurnoSuperpuesto, idOtra)
+	}
+
+	// 3. Turno y aviso al cliente
+	if err := repo.Programar(ctx, idOrden, req.TurnoInicio, req.TurnoFin); err != nil {
+		return nil, err
+	}
+	if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoInstalacionProgramada, modelos.PayloadOrdenInstalacion{IDOrden: idOrden}); err != nil {
+		return nil, fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Orden de instalación %d programada de %s a %s", idOrden,
+		req.TurnoInicio.Format("2006-01-02 15:04"), req.TurnoFin.Format("2006-01-02 15:04"))
+	return s.respuesta(ctx, idOrden, "Turno programado correctamente")
+}
+
+// fotoInstalacion es una foto ya decodificada y validada
+type fotoInstalacion struct {
+	datos         []byte
+	tipoContenido string
+}
+
+// validarCompletar valida lo relevado en el domicilio y decodifica las fotos
+func validarCompletar(req *modelos.CompletarOrdenRequest) ([]fotoInstalacion, error) {
+	req.SerialONU = strings.TrimSpace(req.SerialONU)
+	if req.SerialONU == "" {
+		return nil, utilidades.ErrValidation{Campo: "serial_onu", Mensaje: "es requerido"}
+	}
+	if len(req.SerialONU) > 64 {
+		return nil, utilidades.ErrValidation{Campo: "serial_onu", Mensaje: "no puede superar 64 caracteres"}
+	}
+	if req.PotenciaRxDBm == nil {
+		return nil, utilidades.ErrValidation{Campo: "potencia_rx_dbm", Mensaje: "es requerida"}
+	}
+	if *req.PotenciaRxDBm < potenciaRxMinima || *req.PotenciaRxDBm > potenciaRxMaxima {
+		return nil, utilidades.ErrValidation{Campo: "potencia_rx_dbm", Mensaje: fmt.Sprintf("debe estar entre %.0f y %.0f dBm", potenciaRxMinima, potenciaRxMaxima)}
+	}
+	if req.PotenciaTxDBm != nil && (*req.PotenciaTxDBm < potenciaTxMinima || *req.PotenciaTxDBm > potenciaTxMaxima) {
+		return nil, utilidades.ErrValidation{Campo: "potencia_tx_dbm", Mensaje: fmt.Sprintf("debe estar entre %.0f y %.0f dBm", potenciaTxMinima, potenciaTxMaxima)}
+	}
+
+	for i, m := range req.Materiales {
+		if strings.TrimSpace(m.Descripcion) == "" || len(m.Descripcion) > 150 {
+			return nil, utilidades.ErrValidation{Campo: fmt.Sprintf("materiales[%d].descripcion", i), Mensaje: "es requerida y no puede superar 150 caracteres"}
+		}
+		if m.Cantidad <= 0 {
+			return nil, utilidades.ErrValidation{Campo: fmt.Sprintf("materiales[%d].cantidad", i), Mensaje: "debe ser mayor que 0"}
+		}
+	}
+
+	if len(req.Fotos) > maxFotosOrden {
+		return nil, utilidades.ErrValidation{Campo: "fotos", Mensaje: fmt.Sprintf("se admiten hasta %d fotos", maxFotosOrden)}
+	}
+	fotos := make([]fotoInstalacion, 0, len(req.Fotos))
+	for i, f := range req.Fotos {
+		// Aceptar tanto base64 puro como data URL (data:image/jpeg;base64,...)
+		if strings.HasPrefix(f, "data:") {
+			if coma := strings.Index(f, ","); coma >= 0 {
+				f = f[coma+1:]
+			}
+		}
+		if base64.StdEncoding.DecodedLen(len(f)) > maxTamanoFoto+3 {
+			return nil, utilidades.ErrValidation{Campo: fmt.Sprintf("fotos[%d]", i), Mensaje: fmt.Sprintf("supera %d MB", maxTamanoFoto>>20)}
+		}
+		datos, err := base64.StdEncoding.DecodeString(f)
+		if err != nil {
+			return nil, utilidades.ErrValidation{Campo: fmt.Sprintf("fotos[%d]", i), Mensaje: "no es base64 válido"}
+		}
+		if len(datos) > maxTamanoFoto {
+			return nil, utilidades.ErrValidation{Campo: fmt.Sprintf("fotos[%d]", i), Mensaje: fmt.Sprintf("supera %d MB", maxTamanoFoto>>20)}
+		}
+		tipo := http.DetectContentType(datos)
+		if _, ok := extensionesFoto[tipo]; !ok {
+			return nil, utilidades.ErrValidation{Campo: fmt.Sprintf("fotos[%d]", i), Mensaje: "debe ser una imagen JPEG o PNG"}
+		}
+		fotos = append(fotos, fotoInstalacion{datos: datos, tipoContenido: tipo})
+	}
+	return fotos, nil
+}
+
+// Completar cierra la orden con lo relevado en el domicilio, registra al técnico como
+// instalador y pasa la conexión a "Activa", todo en una transacción
+func (s *InstalacionService) Completar(
+	ctx context.Context,
+	idOrden int,
+	req modelos.CompletarOrdenRequest,
+	idPersona int,
+) (*modelos.OrdenInstalacionResponse, error) {
+	fotos, err := validarCompletar(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Operador y orden (bloqueada)
+	op, err := s.operador(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	repo := repositorios.NewOrdenInstalacionRepo(tx)
+	orden, err := repo.ObtenerParaActualizar(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	if !ordenAbierta(orden) {
+		return nil, utilidades.ErrOrdenCerrada
+	}
+	if orden.IDTecnico == nil {
+		return nil, utilidades.ErrOrdenSinTecnico
+	}
+	if !op.puedeOperar(orden) {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+
+	// 2. Datos relevados y materiales
+	if err := repo.Completar(ctx, idOrden, req); err != nil {
+		return nil, err
+	}
+	for _, m := range req.Materiales {
+		m.Descripcion = strings.TrimSpace(m.Descripcion)
+		if err := repo.AgregarMaterial(ctx, idOrden, m); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Fotos: si la transacción no se confirma, se borran los archivos escritos
+	var escritos []string
+	confirmada := false
+	defer func() {
+		if confirmada {
+			return
+		}
+		for _, path := range escritos {
+			if err := os.Remove(path); err != nil {
+				logger.Warn.Printf("No se pudo borrar la foto %s: %v", path, err)
+			}
+		}
+	}()
+	dir := filepath.Join(s.fotosPath, fmt.Sprintf("orden_%d", idOrden))
+	if len(fotos) > 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("error creando directorio de fotos: %w", err)
+		}
+	}
+	for i, f := range fotos {
+		suma := sha256.Sum256(f.datos)
+		hash := hex.EncodeToString(suma[:])
+		path := filepath.Join(dir, fmt.Sprintf("foto_%d_%s.%s", i+1, hash[:12], extensionesFoto[f.tipoContenido]))
+		if err := os.WriteFile(path, f.datos, 0644); err != nil {
+			return nil, fmt.Errorf("error guardando foto: %w", err)
+		}
+		escritos = append(escritos, path)
+		if err := repo.AgregarFoto(ctx, idOrden, path, f.tipoContenido, len(f.datos), hash); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Instalador y activación de la conexión
+	if err := repositorios.NewConexionRepo(tx).AsignarInstalador(ctx, orden.IDConexion, *orden.IDTecnico); err != nil {
+		return nil, err
+	}
+	obs := fmt.Sprintf("Instalación completada (orden %d, ONU %s)", idOrden, req.SerialONU)
+	if _, err := NewCicloVida(tx).TransicionarConexion(ctx, orden.IDConexion, modelos.EstadoConexionActiva, ActorUsuario(op.idUsuario), &obs); err != nil {
+		return nil, err
+	}
+
+	// 5. Aviso al cliente
+	if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoInstalacionCompletada, modelos.PayloadOrdenInstalacion{IDOrden: idOrden}); err != nil {
+		return nil, fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+	confirmada = true
+
+	logger.Info.Printf("Orden de instalación %d completada: conexión %d activa", idOrden, orden.IDConexion)
+	return s.respuesta(ctx, idOrden, "Instalación completada correctamente")
+}
+
+// Cancelar cierra una orden abierta sin instalación (instalacion.gestionar)
+func (s *InstalacionService) Cancelar(
+	ctx context.Context,
+	idOrden int,
+	req modelos.CancelarOrdenRequest,
+	idPersona int,
+) (*modelos.OrdenInstalacionResponse, error) {
+	req.Motivo = strings.TrimSpace(req.Motivo)
+	if req.Motivo == "" {
+		return nil, utilidades.ErrValidation{Campo: "motivo", Mensaje: "es requerido"}
+	}
+	if len(req.Motivo) > 255 {
+		return nil, utilidades.ErrValidation{Campo: "motivo", Mensaje: "no puede superar 255 caracteres"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	op, err := s.operador(ctx, tx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	if !op.gestiona {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+	repo := repositorios.NewOrdenInstalacionRepo(tx)
+	orden, err := repo.ObtenerParaActualizar(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	if !ordenAbierta(orden) {
+		return nil, utilidades.ErrOrdenCerrada
+	}
+	if err := repo.Cancelar(ctx, idOrden, req.Motivo); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Orden de instalación %d cancelada: %s", idOrden, req.Motivo)
+	return s.respuesta(ctx, idOrden, "Orden cancelada correctamente")
+}
+
+func (s *InstalacionService) respuesta(ctx context.Context, idOrden int, mensaje string) (*modelos.OrdenInstalacionResponse, error) {
+	orden, err := s.detalle(ctx, idOrden)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.OrdenInstalacionResponse{Mensaje: mensaje, Orden: orden}, nil
+}
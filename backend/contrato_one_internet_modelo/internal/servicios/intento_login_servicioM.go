@@ -0,0 +1,229 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"contrato_one_internet_modelo/internal/config"
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// Motivos con los que se registran los intentos de login
+const (
+	motivoLoginExitoso          = "login exitoso"
+	motivoLoginUsuarioInexiste  = "usuario inexistente"
+	motivoLoginPasswordInvalida = "contraseña incorrecta"
+	motivoLoginEmailNoVerif     = "email no verificado"
+	motivoLoginBloqueado        = "bloqueado por intentos fallidos"
+	motivoLoginSegundoFactor    = "segundo factor incorrecto"
+)
+
+// IntentoLoginService registra los intentos de login y bloquea las cuentas y las IPs que
+// acumulan fallos.
+//
+// Los fallos se cuentan por cuenta (el email, exista o no) y por IP dentro de una ventana.
+// Al llegar al umbral se bloquea por BloqueoInicial, y cada bloqueo siguiente dura el doble
+// hasta BloqueoMaximo. Un login exitoso reinicia el contador de la cuenta; el de la IP no,
+// para que probar muchas cuentas desde una IP no se compense con la propia.
+type IntentoLoginService struct {
+	db  *sql.DB
+	cfg config.LoginConfig
+}
+
+func NewIntentoLoginService(db *sql.DB, cfg config.LoginConfig) *IntentoLoginService {
+	return &IntentoLoginService{db: db, cfg: cfg}
+}
+
+// VerificarBloqueo devuelve ErrLoginBloqueado si la cuenta o la IP están bloqueadas
+func (s *IntentoLoginService) VerificarBloqueo(ctx context.Context, email, ip string) error {
+	repo := repositorios.NewIntentoLoginRepo(s.db)
+
+	segundos := 0
+	for _, b := range clavesBloqueo(email, ip) {
+		restante, err := repo.SegundosBloqueo(ctx, b.tipo, b.clave)
+		if err != nil {
+			return err
+		}
+		if restante > segundos {
+			segundos = restante
+		}
+	}
+	if segundos > 0 {
+		return utilidades.ErrLoginBloqueado{Segundos: segundos}
+	}
+	return nil
+}
+
+// RegistrarFallo guarda el intento fallido y, si contar es true, lo suma a los fallos de la
+// cuenta y de la IP, bloqueándolas al llegar al umbral
+func (s *IntentoLoginService) RegistrarFallo(ctx context.Context, idUsuario *int, email, ip, userAgent, motivo string, contar bool) {
+	email, ip, userAgent = normalizarDatosLogin(email, ip, userAgent)
+
+	if err := repositorios.NewUsuarioRepo(s.db).RegistrarLogLogin(ctx, idUsuario, email, ip, userAgent, false, motivo); err != nil {
+		logger.Error.Printf("Error registrando intento de login fallido de %s: %v", email, err)
+	}
+	if !contar {
+		return
+	}
+
+	if err := s.sumarFallos(ctx, email, ip); err != nil {
+		logger.Error.Printf("Error contando intento de login fallido de %s (IP %s): %v", email, ip, err)
+	}
+}
+
+func (s *IntentoLoginService) sumarFallos(ctx context.Context, email, ip string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewIntentoLoginRepo(tx)
+	for _, b := range clavesBloqueo(email, ip) {
+		fallos, nivel, err := repo.SumarFallo(ctx, b.tipo, b.clave, s.cfg.VentanaFallos)
+		if err != nil {
+			return err
+		}
+
+		umbral := s.cfg.MaxFallosCuenta
+		if b.tipo == modelos.BloqueoLoginIP {
+			umbral = s.cfg.MaxFallosIP
+		}
+		if fallos < umbral {
+			continue
+		}
+
+		duracion := s.duracionBloqueo(nivel)
+		if err := repo.Bloquear(ctx, b.tipo, b.clave, duracion); err != nil {
+			return err
+		}
+		logger.Info.Printf("Login bloqueado para %s %s por %s tras %d intentos fallidos (bloqueo n.º %d)",
+			b.tipo, b.clave, duracion, fallos, nivel+1)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error confirmando transacción: %w", err)
+	}
+	return nil
+}
+
+// RegistrarExito guarda el login exitoso, reinicia los fallos de la cuenta e informa si es
+// el primer login del usuario desde esa IP con ese user agent (sin contar el primero de todos)
+func (s *IntentoLoginService) RegistrarExito(ctx context.Context, idUsuario int, email, ip, userAgent string) bool {
+	email, ip, userAgent = normalizarDatosLogin(email, ip, userAgent)
+	repo := repositorios.NewIntentoLoginRepo(s.db)
+
+	dispositivoNuevo := false
+	total, desdeDispositivo, err := repo.ContarLoginsExitosos(ctx, idUsuario, ip, userAgent)
+	if err != nil {
+		logger.Error.Printf("Error consultando logins previos del usuario %d: %v", idUsuario, err)
+	} else {
+		dispositivoNuevo = total > 0 && desdeDispositivo == 0
+	}
+
+	if err := repositorios.NewUsuarioRepo(s.db).RegistrarLogLogin(ctx, &idUsuario, email, ip, userAgent, true, motivoLoginExitoso); err != nil {
+		logger.Error.Printf("Error registrando log de login para usuario %d: %v", idUsuario, err)
+	}
+	if err := repo.Reiniciar(ctx, modelos.BloqueoLoginCuenta, email); err != nil {
+		logger.Error.Printf("Error reiniciando fallos de login de %s: %v", email, err)
+	}
+	return dispositivoNuevo
+}
+
+// ListarBloqueos devuelve las cuentas e IPs bloqueadas en este momento (login.ver_intentos)
+func (s *IntentoLoginService) ListarBloqueos(ctx context.Context, idPersona int) (*modelos.BloqueosLoginResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoLoginVerIntentos); err != nil {
+		return nil, err
+	}
+	bloqueos, err := repositorios.NewIntentoLoginRepo(s.db).ListarBloqueosVigentes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.BloqueosLoginResponse{Bloqueos: bloqueos}, nil
+}
+
+// LevantarBloqueo quita el bloqueo y los fallos de una cuenta o IP (login.levantar_bloqueos)
+func (s *IntentoLoginService) LevantarBloqueo(ctx context.Context, idLoginBloqueo, idPersona int) error {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoLoginLevantarBloqueos); err != nil {
+		return err
+	}
+	b, err := repositorios.NewIntentoLoginRepo(s.db).Levantar(ctx, idLoginBloqueo)
+	if err != nil {
+		return err
+	}
+	logger.Info.Printf("Bloqueo de login de %s %s levantado por la persona %d", b.Tipo, b.Clave, idPersona)
+	return nil
+}
+
+// ListarIntentos devuelve los últimos intentos de login, opcionalmente de un email o una IP (login.ver_intentos)
+func (s *IntentoLoginService) ListarIntentos(ctx context.Context, email, ip string, limite, idPersona int) (*modelos.IntentosLoginResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoLoginVerIntentos); err != nil {
+		return nil, err
+	}
+	if limite <= 0 || limite > 500 {
+		return nil, utilidades.ErrValidation{Campo: "limit", Mensaje: "debe estar entre 1 y 500"}
+	}
+	email, ip, _ = normalizarDatosLogin(email, ip, "")
+
+	intentos, err := repositorios.NewIntentoLoginRepo(s.db).ListarIntentos(ctx, email, ip, limite)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.IntentosLoginResponse{Intentos: intentos}, nil
+}
+
+// duracionBloqueo duplica el bloqueo inicial por cada bloqueo anterior, hasta el máximo
+func (s *IntentoLoginService) duracionBloqueo(nivel int) time.Duration {
+	duracion := s.cfg.BloqueoInicial
+	for i := 0; i < nivel && duracion < s.cfg.BloqueoMaximo; i++ {
+		duracion *= 2
+	}
+	if duracion > s.cfg.BloqueoMaximo {
+		duracion = s.cfg.BloqueoMaximo
+	}
+	return duracion
+}
+
+type claveBloqueo struct {
+	tipo  string
+	clave string
+}
+
+// clavesBloqueo arma las claves de la cuenta y de la IP; las vacías no se cuentan
+func clavesBloqueo(email, ip string) []claveBloqueo {
+	email, ip, _ = normalizarDatosLogin(email, ip, "")
+
+	var claves []claveBloqueo
+	if email != "" {
+		claves = append(claves, claveBloqueo{tipo: modelos.BloqueoLoginCuenta, clave: email})
+	}
+	if ip != "" {
+		claves = append(claves, claveBloqueo{tipo: modelos.BloqueoLoginIP, clave: ip})
+	}
+	return claves
+}
+
+// normalizarDatosLogin pasa el email a minúsculas y recorta los datos al largo de sus columnas
+func normalizarDatosLogin(email, ip, userAgent string) (string, string, string) {
+	return recortar(strings.ToLower(strings.TrimSpace(email)), 150),
+		recortar(strings.TrimSpace(ip), 45),
+		recortar(userAgent, 255)
+}
+
+// recortar corta s a max bytes sin partir un carácter
+func recortar(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -3,6 +3,7 @@ package servicios
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"contrato_one_internet_modelo/internal/config"
@@ -17,15 +18,27 @@ import (
 type LoginService struct {
 	usuarioRepo    *repositorios.UsuarioRepo
 	usuarioRolRepo *repositorios.UsuarioRolRepo
+	rolPermisoRepo *repositorios.RolPermisoRepo
 	refreshRepo    *repositorios.RefreshTokenRepo
+	sesionRepo     *repositorios.SesionRepo
+	intentos       *IntentoLoginService
+	dosFactores    *DosFactoresService
 	cfg            *config.AppConfig
 }
 
-func NewLoginService(uRepo *repositorios.UsuarioRepo, urRepo *repositorios.UsuarioRolRepo, rtRepo *repositorios.RefreshTokenRepo, cfg *config.AppConfig) *LoginService {
+// graciaReusoRefresh es el margen en el que un refresh token recién rotado puede volver a
+// llegar sin que se lo tome como robado: dos pestañas que refrescan a la vez
+const graciaReusoRefresh = 10 * time.Second
+
+func NewLoginService(uRepo *repositorios.UsuarioRepo, urRepo *repositorios.UsuarioRolRepo, rpRepo *repositorios.RolPermisoRepo, rtRepo *repositorios.RefreshTokenRepo, sRepo *repositorios.SesionRepo, intentos *IntentoLoginService, dosFactores *DosFactoresService, cfg *config.AppConfig) *LoginService {
 	return &LoginService{
 		usuarioRepo:    uRepo,
 		usuarioRolRepo: urRepo,
+		rolPermisoRepo: rpRepo,
 		refreshRepo:    rtRepo,
+		sesionRepo:     sRepo,
+		intentos:       intentos,
+		dosFactores:    dosFactores,
 		cfg:            cfg,
 	}
 }
@@ -37,79 +50,202 @@ func NewLoginService(uRepo *repositorios.UsuarioRepo, urRepo *repositorios.Usuar
 func (s *LoginService) ValidarCredenciales(ctx context.Context, email, password, clientIP, userAgent string) (*modelos.ModeloLoginResponse, error) {
 	// 1. Buscar usuario (Usando el Repo refactorizado del paso anterior)
 	u, err := s.usuarioRepo.BuscarPorEmailParaLogin(ctx, email)
+	var idUsuario *int
+	if err == nil {
+		idUsuario = &u.IDUsuario
+	}
+
+	// 2. Rechazar si la cuenta o la IP están bloqueadas por intentos fallidos
+	if errBloqueo := s.intentos.VerificarBloqueo(ctx, email, clientIP); errBloqueo != nil {
+		var bloqueo utilidades.ErrLoginBloqueado
+		if errors.As(errBloqueo, &bloqueo) {
+			s.intentos.RegistrarFallo(ctx, idUsuario, email, clientIP, userAgent, motivoLoginBloqueado, false)
+			return nil, bloqueo
+		}
+		// Sin poder consultar los bloqueos se sigue: el login no depende de esta tabla
+		logger.Error.Printf("Error verificando bloqueo de login de %s: %v", email, errBloqueo)
+	}
 	if err != nil {
+		s.intentos.RegistrarFallo(ctx, nil, email, clientIP, userAgent, motivoLoginUsuarioInexiste, true)
 		return nil, errors.New("credenciales inválidas")
 	}
 
-	// 2. Validar pass y estado
+	// 3. Validar pass y estado (la contraseña primero, para no revelar qué cuentas existen)
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		s.intentos.RegistrarFallo(ctx, idUsuario, email, clientIP, userAgent, motivoLoginPasswordInvalida, true)
+		return nil, errors.New("credenciales inválidas")
+	}
 	if u.RequiereVerificacion && !u.EmailVerificado {
+		s.intentos.RegistrarFallo(ctx, idUsuario, email, clientIP, userAgent, motivoLoginEmailNoVerif, false)
 		return nil, errors.New("verificación de email pendiente")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
-		return nil, errors.New("credenciales inválidas")
+
+	// 4. Con la autenticación en dos pasos activa todavía no hay sesión: falta el segundo factor
+	activa, err := s.dosFactores.Activa(ctx, u.IDUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if activa {
+		return &modelos.ModeloLoginResponse{
+			IDUsuario:   u.IDUsuario,
+			IDPersona:   u.IDPersona,
+			Requiere2FA: true,
+			Metodos2FA:  s.dosFactores.Metodos(),
+		}, nil
 	}
 
-	// 3. Auditoría
-	if err := s.usuarioRepo.ActualizarAuditoriaLogin(ctx, u.IDUsuario, clientIP, userAgent); err != nil {
-		logger.Error.Printf("Error actualizando auditoría de login para usuario %d: %v", u.IDUsuario, err)
+	// 5. Auditoría y sesión
+	return s.completarLogin(ctx, u.IDUsuario, u.IDPersona, email, clientIP, userAgent, []string{modelos.AMRPassword})
+}
+
+// VerificarSegundoFactor completa el login de un usuario con autenticación en dos pasos. El
+// controlador ya validó la contraseña (su token de desafío lo prueba) y manda el código.
+func (s *LoginService) VerificarSegundoFactor(ctx context.Context, req modelos.VerificarDosFactoresRequest) (*modelos.ModeloLoginResponse, error) {
+	// 1. El usuario tiene que seguir activo
+	email, err := s.usuarioRepo.ObtenerEmail(ctx, req.IDUsuario)
+	if err != nil {
+		return nil, err
+	}
+	idPersona, err := s.usuarioRepo.ObtenerIDPersona(ctx, req.IDUsuario)
+	if err != nil {
+		return nil, err
 	}
 
-	// Registrar log de login exitoso. Para aplicar más adelante.
-	/*
-	go func(ctx context.Context) {
-		if err := s.usuarioRepo.RegistrarLogLogin(context.Background(), u.IDUsuario, clientIP, userAgent, true, "login exitoso"); err != nil {
-			logger.Error.Printf("Error registrando log de login para usuario %d: %v", u.IDUsuario, err)
+	// 2. Los códigos fallidos cuentan para el mismo bloqueo que las contraseñas
+	if err := s.intentos.VerificarBloqueo(ctx, email, req.ClientIP); err != nil {
+		var bloqueo utilidades.ErrLoginBloqueado
+		if errors.As(err, &bloqueo) {
+			s.intentos.RegistrarFallo(ctx, &req.IDUsuario, email, req.ClientIP, req.UserAgent, motivoLoginBloqueado, false)
+			return nil, bloqueo
 		}
-	}(ctx)*/
+		logger.Error.Printf("Error verificando bloqueo de login de %s: %v", email, err)
+	}
 
-	// 4. Generar Token y Respuesta (Lógica centralizada)
-	return s.generarSesionYRespuesta(ctx, u.IDUsuario, u.IDPersona)
+	ok, err := s.dosFactores.VerificarCodigo(ctx, req.IDUsuario, req.Metodo, req.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.intentos.RegistrarFallo(ctx, &req.IDUsuario, email, req.ClientIP, req.UserAgent, motivoLoginSegundoFactor+" ("+req.Metodo+")", true)
+		return nil, utilidades.ErrCodigoDosFactoresInvalido
+	}
+
+	// 3. Auditoría y sesión
+	return s.completarLogin(ctx, req.IDUsuario, idPersona, email, req.ClientIP, req.UserAgent,
+		[]string{modelos.AMRPassword, modelos.AMROTP, modelos.AMRMFA})
 }
 
-func (s *LoginService) RefreshConToken(ctx context.Context, oldToken string) (*modelos.ModeloLoginResponse, error) {
-	// 1. Validar token opaco en BD
-	idUsuario, err := s.refreshRepo.ValidarRefreshToken(ctx, oldToken)
+// completarLogin registra el login exitoso y abre la sesión con los métodos usados
+func (s *LoginService) completarLogin(ctx context.Context, idUsuario, idPersona int, email, clientIP, userAgent string, amr []string) (*modelos.ModeloLoginResponse, error) {
+	if err := s.usuarioRepo.ActualizarAuditoriaLogin(ctx, idUsuario, clientIP, userAgent); err != nil {
+		logger.Error.Printf("Error actualizando auditoría de login para usuario %d: %v", idUsuario, err)
+	}
+	dispositivoNuevo := s.intentos.RegistrarExito(ctx, idUsuario, email, clientIP, userAgent)
+
+	resp, err := s.generarSesionYRespuesta(ctx, idUsuario, idPersona, 0, clientIP, userAgent, amr)
 	if err != nil {
 		return nil, err
 	}
+	resp.DispositivoNuevo = dispositivoNuevo
+	resp.Email = email
+	return resp, nil
+}
 
-	// 2. Revocar el anterior (Rotación de tokens para detectar robo)
-	if err := s.refreshRepo.RevocarRefreshToken(ctx, oldToken); err != nil {
+// RefreshConToken rota el refresh token dentro de su sesión. Si llega un token que ya se
+// rotó, alguien más lo tiene (el robado o el legítimo, no se sabe cuál): se cierra la sesión
+// entera y ambos tienen que volver a iniciar sesión.
+func (s *LoginService) RefreshConToken(ctx context.Context, oldToken, clientIP, userAgent string) (*modelos.ModeloLoginResponse, error) {
+	// 1. Buscar el token opaco en BD
+	rt, err := s.refreshRepo.ObtenerRefreshToken(ctx, oldToken)
+	if err != nil {
 		return nil, err
 	}
 
-	// 3. Obtener datos FRESCOS del usuario (Importante por seguridad)
-	idPersona, err := s.usuarioRepo.ObtenerIDPersona(ctx, idUsuario)
+	// 2. Detectar el reuso de un token rotado
+	if rt.Revocado {
+		if rt.Rotado != nil && rt.IDSesion != nil && time.Since(*rt.Rotado) > graciaReusoRefresh {
+			logger.Warn.Printf("Reuso del refresh token %d de la sesión %d (usuario %d): se revoca la sesión",
+				rt.ID, *rt.IDSesion, rt.IDUsuario)
+			if _, err := s.sesionRepo.Revocar(ctx, rt.IDUsuario, *rt.IDSesion, modelos.MotivoSesionReuso); err != nil {
+				logger.Error.Printf("Error revocando la sesión %d por reuso: %v", *rt.IDSesion, err)
+			}
+		}
+		return nil, utilidades.ErrTokenInvalido
+	}
+	if time.Now().After(rt.Expiracion) {
+		return nil, utilidades.ErrTokenInvalido
+	}
+
+	// 3. Revocar el anterior (Rotación de tokens para detectar robo). Si otro pedido lo rotó
+	// primero, éste llega tarde: se rechaza
+	rotado, err := s.refreshRepo.RotarRefreshToken(ctx, rt.ID)
 	if err != nil {
 		return nil, err
 	}
+	if !rotado {
+		return nil, utilidades.ErrTokenInvalido
+	}
 
-	// 4. Generar Token y Respuesta (Reutilizamos la lógica)
-	return s.generarSesionYRespuesta(ctx, idUsuario, idPersona)
+	// 4. Obtener datos FRESCOS del usuario (Importante por seguridad)
+	idPersona, err := s.usuarioRepo.ObtenerIDPersona(ctx, rt.IDUsuario)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. Generar Token y Respuesta (Reutilizamos la lógica; la sesión conserva sus métodos de
+	// autenticación). Un token anterior a las sesiones abre una y queda en su familia.
+	idSesion := 0
+	if rt.IDSesion != nil {
+		idSesion = *rt.IDSesion
+	}
+	resp, err := s.generarSesionYRespuesta(ctx, rt.IDUsuario, idPersona, idSesion, clientIP, userAgent, strings.Fields(rt.AMR))
+	if err != nil {
+		return nil, err
+	}
+	if rt.IDSesion == nil {
+		if err := s.refreshRepo.AsignarSesion(ctx, rt.ID, resp.IDSesion); err != nil {
+			logger.Error.Printf("Error asociando el refresh token %d a la sesión %d: %v", rt.ID, resp.IDSesion, err)
+		}
+	}
+	return resp, nil
 }
 
-// LogoutConToken revoca el refresh token proporcionado.
+// LogoutConToken revoca el refresh token proporcionado y cierra su sesión.
 func (s *LoginService) LogoutConToken(ctx context.Context, token string) error {
 	if token == "" {
 		return nil
 	}
 
-	if err := s.refreshRepo.RevocarRefreshToken(ctx, token); err != nil {
+	rt, err := s.refreshRepo.ObtenerRefreshToken(ctx, token)
+	if errors.Is(err, utilidades.ErrTokenInvalido) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
-	return nil
+	if rt.IDSesion != nil {
+		_, err := s.sesionRepo.Revocar(ctx, rt.IDUsuario, *rt.IDSesion, modelos.MotivoSesionLogout)
+		return err
+	}
+	return s.refreshRepo.RevocarRefreshToken(ctx, token)
 }
 
 // =============================================================================
 // MÉTODO PRIVADO AUXILIAR
 // =============================================================================
 
-func (s *LoginService) generarSesionYRespuesta(ctx context.Context, idUsuario, idPersona int) (*modelos.ModeloLoginResponse, error) {
-	// A. Obtener roles actuales
+// generarSesionYRespuesta emite un refresh token en la sesión indicada, o abre una nueva si
+// idSesion es 0
+func (s *LoginService) generarSesionYRespuesta(ctx context.Context, idUsuario, idPersona, idSesion int, clientIP, userAgent string, amr []string) (*modelos.ModeloLoginResponse, error) {
+	// A. Obtener roles y permisos actuales
 	roles, err := s.usuarioRolRepo.ObtenerRolesPorUsuario(ctx, idUsuario)
 	if err != nil {
 		return nil, err
 	}
+	permisos, err := s.rolPermisoRepo.PermisosDeUsuario(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
 
 	// B. Generar el Refresh Token Opaco
 	refreshToken, err := utilidades.GenerarTokenSeguro(utilidades.TokenSize64)
@@ -120,10 +256,23 @@ func (s *LoginService) generarSesionYRespuesta(ctx context.Context, idUsuario, i
 	// C. Calcular expiración desde configuración
 	refreshExp := time.Now().Add(s.cfg.RefreshTokenDuration)
 
-	// D. Guardar en BD
+	// D. Abrir la sesión o registrar su uso
+	ip, ua := recortar(strings.TrimSpace(clientIP), 45), recortar(userAgent, 255)
+	if idSesion == 0 {
+		idSesion, err = s.sesionRepo.Crear(ctx, idUsuario, ip, ua, refreshExp)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := s.sesionRepo.RegistrarUso(ctx, idSesion, ip, ua, refreshExp); err != nil {
+		return nil, err
+	}
+
+	// E. Guardar en BD
 	rt := &modelos.RefreshToken{
 		IDUsuario:  idUsuario,
+		IDSesion:   &idSesion,
 		Token:      refreshToken,
+		AMR:        strings.Join(amr, " "),
 		Expiracion: refreshExp,
 		Creado:     time.Now(),
 	}
@@ -131,12 +280,15 @@ func (s *LoginService) generarSesionYRespuesta(ctx context.Context, idUsuario, i
 		return nil, err
 	}
 
-	// E. Retornar estructura unificada
+	// F. Retornar estructura unificada
 	return &modelos.ModeloLoginResponse{
 		IDUsuario:        idUsuario,
 		IDPersona:        idPersona,
+		IDSesion:         idSesion,
 		Roles:            roles,
+		Permisos:         permisos,
 		RefreshToken:     refreshToken,
 		RefreshExpiresAt: refreshExp.Format(time.RFC3339),
+		AMR:              amr,
 	}, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,333 @@
+package servicios
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+const (
+	MaxIntentosEntrega     = 5
+	DuracionReservaEntrega = 2 * time.Minute
+)
+
+// NotificacionEntregaService maneja la entrega de notificaciones por canales externos
+// (email, SMS, WhatsApp, Web Push): preferencias, plantillas y estado de cada envío.
+// El envío en sí lo hace el despachador del controlador, que reserva entregas
+// pendientes y luego informa el resultado.
+type NotificacionEntregaService struct {
+	db *sql.DB
+}
+
+func NewNotificacionEntregaService(db *sql.DB) *NotificacionEntregaService {
+	return &NotificacionEntregaService{db: db}
+}
+
+// ObtenerPreferencias devuelve las preferencias guardadas y el estado general de cada canal
+func (s *NotificacionEntregaService) ObtenerPreferencias(ctx context.Context, idPersona int) (*modelos.PreferenciasNotificacionResponse, error) {
+	prefs, err := repositorios.NewNotificacionPreferenciaRepo(s.db).Listar(ctx, idPersona)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo preferencias: %w", err)
+	}
+
+	canales := make(map[string]bool, len(modelos.CanalesNotificacion))
+	for _, canal := range modelos.CanalesNotificacion {
+		canales[canal] = repositorios.CanalHabilitado(prefs, canal, modelos.TipoNotificacionTodos)
+	}
+
+	return &modelos.PreferenciasNotificacionResponse{Preferencias: prefs, Canales: canales}, nil
+}
+
+// ActualizarPreferencias guarda las preferencias recibidas (las no enviadas no se modifican)
+func (s *NotificacionEntregaService) ActualizarPreferencias(ctx context.Context, idPersona int, prefs []modelos.PreferenciaNotificacion) (*modelos.PreferenciasNotificacionResponse, error) {
+	// 1. Validar
+	if len(prefs) == 0 {
+		return nil, utilidades.ErrValidation{Campo: "preferencias", Mensaje: "debe indicar al menos una preferencia"}
+	}
+	for i := range prefs {
+		if err := validarCanal(prefs[i].Canal); err != nil {
+			return nil, err
+		}
+		prefs[i].Tipo = strings.ToUpper(strings.TrimSpace(prefs[i].Tipo))
+		if prefs[i].Tipo == "" {
+			prefs[i].Tipo = modelos.TipoNotificacionTodos
+		}
+	}
+
+	// 2. Guardar en una transacción
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewNotificacionPreferenciaRepo(tx)
+	for _, p := range prefs {
+		if err := repo.Guardar(ctx, idPersona, p); err != nil {
+			return nil, fmt.Errorf("error guardando preferencia %s/%s: %w", p.Canal, p.Tipo, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.ObtenerPreferencias(ctx, idPersona)
+}
+
+// RegistrarSuscripcionPush guarda la suscripción Web Push del navegador de la persona
+func (s *NotificacionEntregaService) RegistrarSuscripcionPush(ctx context.Context, idPersona int, sub modelos.SuscripcionPush) error {
+	if !strings.HasPrefix(sub.Endpoint, "https://") {
+		return utilidades.ErrValidation{Campo: "endpoint", Mensaje: "debe ser una URL https"}
+	}
+	if sub.P256dh == "" || sub.Auth == "" {
+		return utilidades.ErrValidation{Campo: "keys", Mensaje: "p256dh y auth son obligatorios"}
+	}
+	return repositorios.NewNotificacionPreferenciaRepo(s.db).GuardarSuscripcionPush(ctx, idPersona, sub)
+}
+
+// EliminarSuscripcionPush da de baja la suscripción push de un navegador
+func (s *NotificacionEntregaService) EliminarSuscripcionPush(ctx context.Context, idPersona int, endpoint string) error {
+	err := repositorios.NewNotificacionPreferenciaRepo(s.db).EliminarSuscripcionPush(ctx, idPersona, endpoint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utilidades.ErrNotFound{Entity: "suscripción push", Campo: "endpoint", Valor: endpoint}
+	}
+	return err
+}
+
+// ListarEntregas devuelve el estado de envío por canal de una notificación de la persona
+func (s *NotificacionEntregaService) ListarEntregas(ctx context.Context, idNotificacion, idPersona int) ([]modelos.EntregaNotificacion, error) {
+	return repositorios.NewNotificacionEntregaRepo(s.db).ListarPorNotificacion(ctx, idNotificacion, idPersona)
+}
+
+// ReservarEntregas toma hasta limite entregas pendientes para el despachador, con el
+// destino resuelto y el mensaje renderizado. Las entregas sin destino se marcan omitidas.
+func (s *NotificacionEntregaService) ReservarEntregas(ctx context.Context, limite int) ([]modelos.EntregaPendiente, error) {
+	if limite <= 0 || limite > 100 {
+		limite = 20
+	}
+
+	// 1. Reservar
+	reserva, err := utilidades.GenerarTokenSeguro(16)
+	if err != nil {
+		return nil, err
+	}
+	entregaRepo := repositorios.NewNotificacionEntregaRepo(s.db)
+	entregas, err := entregaRepo.Reservar(ctx, reserva, limite, DuracionReservaEntrega)
+	if err != nil {
+		return nil, fmt.Errorf("error reservando entregas: %w", err)
+	}
+
+	// 2. Preparar cada mensaje
+	pendientes := []modelos.EntregaPendiente{}
+	for _, e := range entregas {
+		p, motivo, err := s.prepararEntrega(ctx, e)
+		if err != nil {
+			logger.Error.Printf("Error preparando entrega %d: %v", e.IDEntrega, err)
+			continue // la reserva vence sola y se reintenta
+		}
+		if motivo != "" {
+			if err := entregaRepo.MarcarIntentoFallido(ctx, e.IDEntrega, modelos.EstadoEntregaOmitida, motivo, nil); err != nil {
+				logger.Error.Printf("Error marcando entrega %d como omitida: %v", e.IDEntrega, err)
+			}
+			continue
+		}
+		pendientes = append(pendientes, *p)
+	}
+
+	return pendientes, nil
+}
+
+// prepararEntrega resuelve el destino y renderiza la plantilla. Si no hay destino para el
+// canal devuelve el motivo para marcar la entrega como omitida.
+func (s *NotificacionEntregaService) prepararEntrega(ctx context.Context, e modelos.EntregaNotificacion) (*modelos.EntregaPendiente, string, error) {
+	// 1. Notificación y datos de contacto
+	var tipo, titulo, mensaje, nombre, apellido, email string
+	var telefono sql.NullString
+	query := `
+		SELECT n.tipo, n.titulo, n.mensaje, p.nombre, p.apellido, p.email, p.telefono
+		FROM notificacion n
+		INNER JOIN persona p ON p.id_persona = ?
+		WHERE n.id_notificacion = ?
+	`
+	// La persona sale de la entrega: una notificación difundida a un rol tiene una entrega por usuario
+	err := s.db.QueryRowContext(ctx, query, e.IDPersona, e.IDNotificacion).Scan(&tipo, &titulo, &mensaje, &nombre, &apellido, &email, &telefono)
+	if err != nil {
+		return nil, "", fmt.Errorf("error obteniendo notificación %d: %w", e.IDNotificacion, err)
+	}
+
+	p := &modelos.EntregaPendiente{
+		IDEntrega:      e.IDEntrega,
+		IDNotificacion: e.IDNotificacion,
+		Tipo:           tipo,
+		Canal:          e.Canal,
+		Nombre:         strings.TrimSpace(nombre + " " + apellido),
+		Intentos:       e.Intentos,
+	}
+
+	// 2. Destino según el canal
+	switch e.Canal {
+	case modelos.CanalEmail:
+		if email == "" {
+			return nil, "la persona no tiene email", nil
+		}
+		p.Destino = email
+	case modelos.CanalSMS, modelos.CanalWhatsApp:
+		if !telefono.Valid || strings.TrimSpace(telefono.String) == "" {
+			return nil, "la persona no tiene teléfono", nil
+		}
+		p.Destino = strings.TrimSpace(telefono.String)
+	case modelos.CanalPush:
+		subs, err := repositorios.NewNotificacionPreferenciaRepo(s.db).ListarSuscripcionesPush(ctx, e.IDPersona)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(subs) == 0 {
+			return nil, "la persona no tiene suscripciones push", nil
+		}
+		p.Suscripciones = subs
+	default:
+		return nil, "canal desconocido: " + e.Canal, nil
+	}
+
+	// 3. Plantilla del tipo/canal (si no hay, se usa el título y el mensaje tal cual)
+	p.Asunto, p.Cuerpo = titulo, mensaje
+	plantilla, err := repositorios.NewNotificacionPlantillaRepo(s.db).Obtener(ctx, tipo, e.Canal)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, "", fmt.Errorf("error obteniendo plantilla %s/%s: %w", tipo, e.Canal, err)
+	}
+	if plantilla != nil {
+		datos := map[string]string{"Nombre": nombre, "Titulo": titulo, "Mensaje": mensaje, "Tipo": tipo}
+		if p.Asunto, err = renderizarPlantillaNotificacion(plantilla.Asunto, datos); err != nil {
+			return nil, "", err
+		}
+		if p.Cuerpo, err = renderizarPlantillaNotificacion(plantilla.Cuerpo, datos); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return p, "", nil
+}
+
+// RegistrarResultado guarda el resultado de un envío informado por el despachador.
+// Los errores se reintentan con espera creciente hasta MaxIntentosEntrega.
+func (s *NotificacionEntregaService) RegistrarResultado(ctx context.Context, idEntrega int, res modelos.ResultadoEntrega) error {
+	// 1. Validar que la entrega exista y siga pendiente
+	repo := repositorios.NewNotificacionEntregaRepo(s.db)
+	e, err := repo.ObtenerPorID(ctx, idEntrega)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utilidades.ErrNotFound{Entity: "entrega", Campo: "id_entrega", Valor: fmt.Sprintf("%d", idEntrega)}
+		}
+		return err
+	}
+	if e.Estado != modelos.EstadoEntregaPendiente {
+		return utilidades.ErrValidation{Campo: "id_entrega", Mensaje: "la entrega ya no está pendiente (" + e.Estado + ")"}
+	}
+
+	// 2. Suscripciones push dadas de baja por el navegador
+	if len(res.SuscripcionesVencidas) > 0 {
+		if err := repositorios.NewNotificacionPreferenciaRepo(s.db).EliminarSuscripcionesPushVencidas(ctx, res.SuscripcionesVencidas); err != nil {
+			logger.Error.Printf("Error eliminando suscripciones push vencidas: %v", err)
+		}
+	}
+
+	// 3. Éxito
+	if res.Enviada {
+		var idProveedor *string
+		if res.IDProveedor != "" {
+			idProveedor = &res.IDProveedor
+		}
+		return repo.MarcarEnviada(ctx, idEntrega, idProveedor)
+	}
+
+	// 4. Error: reintentar o dar por fallida
+	motivo := res.Error
+	if motivo == "" {
+		motivo = "error desconocido"
+	}
+	if e.Intentos+1 >= MaxIntentosEntrega {
+		logger.Warn.Printf("Entrega %d (%s) fallida tras %d intentos: %s", idEntrega, e.Canal, e.Intentos+1, motivo)
+		return repo.MarcarIntentoFallido(ctx, idEntrega, modelos.EstadoEntregaFallida, motivo, nil)
+	}
+	espera := time.Duration(1<<e.Intentos) * time.Minute
+	return repo.MarcarIntentoFallido(ctx, idEntrega, modelos.EstadoEntregaPendiente, motivo, &espera)
+}
+
+// ListarPlantillas devuelve todas las plantillas de notificación
+func (s *NotificacionEntregaService) ListarPlantillas(ctx context.Context) ([]modelos.PlantillaNotificacion, error) {
+	return repositorios.NewNotificacionPlantillaRepo(s.db).Listar(ctx)
+}
+
+// GuardarPlantilla crea o reemplaza la plantilla de un tipo y canal
+func (s *NotificacionEntregaService) GuardarPlantilla(ctx context.Context, p *modelos.PlantillaNotificacion) (*modelos.PlantillaNotificacion, error) {
+	// 1. Validar
+	if err := validarCanal(p.Canal); err != nil {
+		return nil, err
+	}
+	p.Tipo = strings.ToUpper(strings.TrimSpace(p.Tipo))
+	if p.Tipo == "" {
+		return nil, utilidades.ErrValidation{Campo: "tipo", Mensaje: "es obligatorio (use \"*\" para todos)"}
+	}
+	if strings.TrimSpace(p.Cuerpo) == "" {
+		return nil, utilidades.ErrValidation{Campo: "cuerpo", Mensaje: "es obligatorio"}
+	}
+	// La plantilla debe compilar y ejecutarse con los campos disponibles
+	muestra := map[string]string{"Nombre": "Nombre", "Titulo": "Título", "Mensaje": "Mensaje", "Tipo": p.Tipo}
+	if _, err := renderizarPlantillaNotificacion(p.Asunto, muestra); err != nil {
+		return nil, utilidades.ErrValidation{Campo: "asunto", Mensaje: err.Error()}
+	}
+	if _, err := renderizarPlantillaNotificacion(p.Cuerpo, muestra); err != nil {
+		return nil, utilidades.ErrValidation{Campo: "cuerpo", Mensaje: err.Error()}
+	}
+
+	// 2. Guardar
+	id, err := repositorios.NewNotificacionPlantillaRepo(s.db).Guardar(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("error guardando plantilla: %w", err)
+	}
+	p.IDPlantilla = id
+	p.UltimoCambio = time.Now()
+	return p, nil
+}
+
+// EliminarPlantilla borra una plantilla (el tipo vuelve a usar la general o el texto de la notificación)
+func (s *NotificacionEntregaService) EliminarPlantilla(ctx context.Context, idPlantilla int) error {
+	err := repositorios.NewNotificacionPlantillaRepo(s.db).Eliminar(ctx, idPlantilla)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utilidades.ErrNotFound{Entity: "plantilla", Campo: "id_plantilla", Valor: fmt.Sprintf("%d", idPlantilla)}
+	}
+	return err
+}
+
+func validarCanal(canal string) error {
+	for _, c := range modelos.CanalesNotificacion {
+		if c == canal {
+			return nil
+		}
+	}
+	return utilidades.ErrValidation{Campo: "canal", Mensaje: "debe ser uno de: " + strings.Join(modelos.CanalesNotificacion, ", ")}
+}
+
+// renderizarPlantillaNotificacion ejecuta una plantilla text/template; las claves
+// inexistentes son error para detectar plantillas mal escritas
+func renderizarPlantillaNotificacion(texto string, datos map[string]string) (string, error) {
+	tmpl, err := template.New("notificacion").Option("missingkey=error").Parse(texto)
+	if err != nil {
+		return "", fmt.Errorf("plantilla inválida: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, datos); err != nil {
+		return "", fmt.Errorf("error ejecutando plantilla: %w", err)
+	}
+	return buf.String(), nil
+}
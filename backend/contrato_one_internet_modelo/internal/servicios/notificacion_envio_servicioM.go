@@ -2,17 +2,20 @@ package servicios
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"time"
 
+	"contrato_one_internet_modelo/internal/modelos"
 	"contrato_one_internet_modelo/internal/repositorios"
 )
 
+// NotificacionEnvioService arma las notificaciones de cada evento de dominio. Recibe un
+// Execer para que el despachador del outbox las cree en la misma transacción del evento.
 type NotificacionEnvioService struct {
-	db *sql.DB
+	db repositorios.Execer
 }
 
-func NewNotificacionEnvioService(db *sql.DB) *NotificacionEnvioService {
+func NewNotificacionEnvioService(db repositorios.Execer) *NotificacionEnvioService {
 	return &NotificacionEnvioService{db: db}
 }
 
@@ -138,3 +141,230 @@ func (s *NotificacionEnvioService) EnviarNotificacionSolicitudNoFactible(
 		nil,
 	)
 }
+
+// EnviarNotificacionPagoAcreditado avisa al cliente que su pago fue acreditado
+func (s *NotificacionEnvioService) EnviarNotificacionPagoAcreditado(
+	ctx context.Context,
+	idPersonaCliente, idContrato, idPago int,
+	monto float64,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaCliente,
+		"PAGO",
+		"Pago acreditado",
+		fmt.Sprintf("Recibimos tu pago de $%.2f. Ya podés firmar tu contrato.", monto),
+		&rolCliente,
+		nil,
+		&idContrato,
+		&idPago,
+		nil,
+	)
+}
+
+// EnviarNotificacionPagoNoAcreditado avisa al cliente que su pago fue rechazado o cancelado
+func (s *NotificacionEnvioService) EnviarNotificacionPagoNoAcreditado(
+	ctx context.Context,
+	idPersonaCliente, idContrato, idPago int,
+	detalle *string,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaCliente,
+		"PAGO",
+		"Pago no acreditado",
+		"Tu pago no pudo ser acreditado. Podés intentarlo nuevamente desde tu panel.",
+		&rolCliente,
+		nil,
+		&idContrato,
+		&idPago,
+		detalle,
+	)
+}
+
+// EnviarNotificacionContratoCliente crea una notificación de tipo CONTRATO para el cliente
+func (s *NotificacionEnvioService) EnviarNotificacionContratoCliente(
+	ctx context.Context,
+	idPersonaCliente, idContrato int,
+	titulo, mensaje string,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaCliente,
+		"CONTRATO",
+		titulo,
+		mensaje,
+		&rolCliente,
+		nil,
+		&idContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionContratoFirmadoTecnicos avisa a los técnicos que hay una conexión para configurar
+func (s *NotificacionEnvioService) EnviarNotificacionContratoFirmadoTecnicos(
+	ctx context.Context,
+	idConexion, idContrato int,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+
+	return repo.CrearNotificacionParaRol(
+		ctx,
+		"TECNICO",
+		"INSTALACION",
+		"Nuevo contrato firmado - Configuración pendiente",
+		fmt.Sprintf("El contrato #%d ha sido firmado y su orden de instalación está pendiente de asignación.", idContrato),
+		&idConexion,
+		&idContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionInstalacionTecnico avisa al técnico que se le asignó una orden de instalación
+func (s *NotificacionEnvioService) EnviarNotificacionInstalacionTecnico(
+	ctx context.Context,
+	idPersonaTecnico, idOrden, idConexion int,
+	idContrato *int,
+	nroConexion int,
+	direccion string,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolTecnico := "TECNICO"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaTecnico,
+		"INSTALACION",
+		"Nueva instalación asignada",
+		fmt.Sprintf("Se te asignó la orden de instalación #%d de la conexión Nº %d (%s).", idOrden, nroConexion, direccion),
+		&rolTecnico,
+		&idConexion,
+		idContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionTurnoCanceladoTecnico avisa al técnico que el cliente canceló el turno
+func (s *NotificacionEnvioService) EnviarNotificacionTurnoCanceladoTecnico(
+	ctx context.Context,
+	idPersonaTecnico, idConexion int,
+	idContrato *int,
+	mensaje string,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolTecnico := "TECNICO"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaTecnico,
+		"INSTALACION",
+		"Turno cancelado por el cliente",
+		mensaje,
+		&rolTecnico,
+		&idConexion,
+		idContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionInstalacionCliente crea una notificación de tipo INSTALACION para el cliente
+func (s *NotificacionEnvioService) EnviarNotificacionInstalacionCliente(
+	ctx context.Context,
+	idPersonaCliente, idConexion int,
+	idContrato *int,
+	titulo, mensaje string,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaCliente,
+		"INSTALACION",
+		titulo,
+		mensaje,
+		&rolCliente,
+		&idConexion,
+		idContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionFacturaEmitida avisa al titular que tiene una nueva factura disponible
+func (s *NotificacionEnvioService) EnviarNotificacionFacturaEmitida(ctx context.Context, f *modelos.Factura) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		f.IDPersona,
+		"FACTURA",
+		"Nueva factura disponible",
+		fmt.Sprintf("Ya está disponible tu factura %s Nº %s del período %s por $%.2f, con vencimiento el %s. Podés descargarla desde la sección Facturas de tu perfil.",
+			f.TipoComprobante, f.NumeroComprobante, f.Periodo, f.Total, fechaFactura(f.FechaVencimiento)),
+		&rolCliente,
+		&f.IDConexion,
+		&f.IDContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionCuentaCliente crea una notificación de tipo CUENTA (suspensión o
+// restablecimiento por deuda) para el titular de la conexión
+func (s *NotificacionEnvioService) EnviarNotificacionCuentaCliente(
+	ctx context.Context,
+	idPersonaCliente, idConexion, idContrato int,
+	titulo, mensaje string,
+) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		idPersonaCliente,
+		"CUENTA",
+		titulo,
+		mensaje,
+		&rolCliente,
+		&idConexion,
+		&idContrato,
+		nil,
+		nil,
+	)
+}
+
+// EnviarNotificacionPrecioPlan avisa al titular del contrato el abono nuevo de su plan y desde
+// cuándo rige
+func (s *NotificacionEnvioService) EnviarNotificacionPrecioPlan(ctx context.Context, c modelos.ContratoAvisoPrecio, precioNuevo float64, desde time.Time) error {
+	repo := repositorios.NewNotificacionRepo(s.db)
+	rolCliente := "CLIENTE"
+
+	return repo.CrearNotificacion(
+		ctx,
+		c.IDPersona,
+		"PLAN",
+		"Cambio en el precio de tu plan",
+		fmt.Sprintf("Desde el %s el abono mensual de tu plan %s (conexión Nº %d) pasa de $%.2f a $%.2f (importes sin IVA). Si no estás de acuerdo, podés dar de baja el servicio sin cargo antes de esa fecha.",
+			desde.Format("02/01/2006"), c.PlanNombre, c.NroConexion, c.PrecioActual, precioNuevo),
+		&rolCliente,
+		&c.IDConexion,
+		&c.IDContrato,
+		nil,
+		nil,
+	)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	"contrato_one_internet_modelo/internal/modelos"
 	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
 )
 
 type NotificacionService struct {
@@ -21,7 +23,7 @@ func NewNotificacionService(db *sql.DB) *NotificacionService {
 func (s *NotificacionService) ObtenerNotificaciones(
 	ctx context.Context,
 	idPersona int,
-	leido *int,
+	filtro modelos.FiltroNotificaciones,
 	page, limit int,
 	sortBy, sortDirection string,
 ) (*modelos.NotificacionesResponse, error) {
@@ -31,7 +33,7 @@ func (s *NotificacionService) ObtenerNotificaciones(
 	}
 
 	// Validar leído (debe ser 0 o 1 si viene)
-	if leido != nil && (*leido != 0 && *leido != 1) {
+	if filtro.Leido != nil && (*filtro.Leido != 0 && *filtro.Leido != 1) {
 		return nil, fmt.Errorf("leido debe ser 0 o 1")
 	}
 
@@ -65,13 +67,13 @@ func (s *NotificacionService) ObtenerNotificaciones(
 	repo := repositorios.NewNotificacionRepo(s.db)
 
 	// Contar total
-	total, err := repo.ContarNotificaciones(ctx, idPersona, leido)
+	total, err := repo.ContarNotificaciones(ctx, idPersona, filtro)
 	if err != nil {
 		return nil, fmt.Errorf("error al contar notificaciones: %w", err)
 	}
 
 	// Obtener notificaciones
-	notificaciones, err := repo.ObtenerNotificaciones(ctx, idPersona, leido, page, limit, sortBy, sortDirection)
+	notificaciones, err := repo.ObtenerNotificaciones(ctx, idPersona, filtro, page, limit, sortBy, sortDirection)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener notificaciones: %w", err)
 	}
@@ -126,3 +128,107 @@ func (s *NotificacionService) MarcarComoLeida(
 
 	return nil
 }
+
+// maxNotificacionesPorLote limita las operaciones masivas por lista de ids
+const maxNotificacionesPorLote = 500
+
+// ContarNoLeidas devuelve el contador de notificaciones sin leer de la bandeja
+func (s *NotificacionService) ContarNoLeidas(ctx context.Context, idPersona int) (*modelos.NotificacionesNoLeidasResponse, error) {
+	if idPersona <= 0 {
+		return nil, fmt.Errorf("id_persona inválido")
+	}
+
+	total, err := repositorios.NewNotificacionRepo(s.db).ContarNoLeidas(ctx, idPersona)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar notificaciones no leídas: %w", err)
+	}
+	return &modelos.NotificacionesNoLeidasResponse{NoLeidas: total}, nil
+}
+
+// MarcarComoLeidas marca como leídas las notificaciones indicadas. Con ids vacío marca
+// toda la bandeja de la persona (incluidas las difundidas a sus roles).
+// Los ids que no pertenecen a la persona se ignoran.
+func (s *NotificacionService) MarcarComoLeidas(ctx context.Context, idPersona int, ids []int) (*modelos.NotificacionesActualizadasResponse, error) {
+	if idPersona <= 0 {
+		return nil, fmt.Errorf("id_persona inválido")
+	}
+	if len(ids) > maxNotificacionesPorLote {
+		return nil, utilidades.ErrValidation{Campo: "ids", Mensaje: fmt.Sprintf("se admiten hasta %d notificaciones por operación", maxNotificacionesPorLote)}
+	}
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, utilidades.ErrValidation{Campo: "ids", Mensaje: "todos los ids deben ser mayores a 0"}
+		}
+	}
+
+	actualizadas, err := repositorios.NewNotificacionRepo(s.db).MarcarComoLeidas(ctx, idPersona, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error al marcar notificaciones como leídas: %w", err)
+	}
+	return &modelos.NotificacionesActualizadasResponse{Actualizadas: actualizadas}, nil
+}
+
+// Archivar saca la notificación de la bandeja de la persona (archivar=true) o la devuelve
+func (s *NotificacionService) Archivar(ctx context.Context, idNotificacion, idPersona int, archivar bool) error {
+	return s.actualizarEstadoPersona(ctx, idNotificacion, idPersona, "archivado", archivar)
+}
+
+// Eliminar oculta la notificación sólo para la persona; si es difundida a un rol,
+// el resto de los usuarios la sigue viendo
+func (s *NotificacionService) Eliminar(ctx context.Context, idNotificacion, idPersona int) error {
+	return s.actualizarEstadoPersona(ctx, idNotificacion, idPersona, "borrado", true)
+}
+
+func (s *NotificacionService) actualizarEstadoPersona(ctx context.Context, idNotificacion, idPersona int, campo string, marcar bool) error {
+	if idNotificacion <= 0 {
+		return utilidades.ErrValidation{Campo: "id_notificacion", Mensaje: "debe ser mayor a 0"}
+	}
+	if idPersona <= 0 {
+		return fmt.Errorf("id_persona inválido")
+	}
+
+	repo := repositorios.NewNotificacionRepo(s.db)
+	if err := repo.ExisteEnBandeja(ctx, idNotificacion, idPersona); err != nil {
+		if err == sql.ErrNoRows {
+			return utilidades.ErrNotFound{Entity: "notificación", Campo: "id_notificacion", Valor: strconv.Itoa(idNotificacion)}
+		}
+		return fmt.Errorf("error al obtener notificación: %w", err)
+	}
+	if err := repo.ActualizarEstadoPersona(ctx, idNotificacion, idPersona, campo, marcar); err != nil {
+		return fmt.Errorf("error al actualizar notificación: %w", err)
+	}
+	return nil
+}
+
+// ObtenerFeed devuelve las notificaciones posteriores a desdeID para el streaming en tiempo
+// real. idPersona nil = todas (feed global). Con limite 0 sólo informa el último id.
+func (s *NotificacionService) ObtenerFeed(ctx context.Context, desdeID int, idPersona *int, limite int) (*modelos.NotificacionesFeedResponse, error) {
+	if desdeID < 0 {
+		return nil, utilidades.ErrValidation{Campo: "desde_id", Mensaje: "no puede ser negativo"}
+	}
+	if limite < 0 || limite > 500 {
+		return nil, utilidades.ErrValidation{Campo: "limite", Mensaje: "debe estar entre 0 y 500"}
+	}
+
+	repo := repositorios.NewNotificacionRepo(s.db)
+	resp := &modelos.NotificacionesFeedResponse{Notificaciones: []modelos.Notificacion{}, UltimoID: desdeID}
+
+	if limite > 0 {
+		notificaciones, err := repo.ObtenerFeed(ctx, desdeID, idPersona, limite)
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener feed de notificaciones: %w", err)
+		}
+		resp.Notificaciones = notificaciones
+		if n := len(notificaciones); n > 0 {
+			resp.UltimoID = notificaciones[n-1].IDNotificacion
+		}
+		return resp, nil
+	}
+
+	ultimo, err := repo.UltimoID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener último id de notificación: %w", err)
+	}
+	resp.UltimoID = ultimo
+	return resp, nil
+}
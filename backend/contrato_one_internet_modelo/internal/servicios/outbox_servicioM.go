@@ -0,0 +1,590 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+const (
+	MaxIntentosOutbox     = 8
+	DuracionReservaOutbox = 2 * time.Minute
+	IntervaloOutbox       = 10 * time.Second
+	LoteOutbox            = 50
+	esperaMaximaOutbox    = time.Hour
+	esperaInicialOutbox   = 30 * time.Second
+	limiteListadoFallidos = 100
+)
+
+// ManejadorEvento aplica los efectos de un evento (hoy: crear notificaciones) dentro de la
+// transacción que lo marca como procesado. Un error deja el evento para reintentar.
+type ManejadorEvento func(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error
+
+// PreparadorEvento hace antes de abrir la transacción lo que un evento no debe hacer con filas
+// bloqueadas (generar archivos). Devuelve el manejador que registra el resultado en la
+// transacción y la función que lo descarta si la transacción no se confirma.
+type PreparadorEvento func(ctx context.Context, payload json.RawMessage) (ManejadorEvento, func(), error)
+
+// iniciadorFirma inicia el proceso de firma de un contrato: el PDF se prepara antes de la
+// transacción del evento y el proceso se registra dentro de ella
+type iniciadorFirma interface {
+	PrepararPDFOriginal(ctx context.Context, idContrato int) (*PDFOriginal, error)
+	RegistrarProcesoFirma(ctx context.Context, db repositorios.Execer, idContrato int, pdf *PDFOriginal) (*modelos.ContratoFirma, error)
+	DescartarPDFOriginal(pdf *PDFOriginal)
+}
+
+// OutboxService despacha los eventos registrados en outbox_evento. Cada evento se procesa
+// al menos una vez: si el proceso se cae, la reserva vence y otro despachador lo retoma.
+type OutboxService struct {
+	db           *sql.DB
+	manejadores  map[string]ManejadorEvento
+	preparadores map[string]PreparadorEvento
+}
+
+// NewOutboxService crea el despachador con los manejadores de los eventos de dominio.
+// El pago aprobado inicia el proceso de firma con firma.
+func NewOutboxService(db *sql.DB, firma iniciadorFirma) *OutboxService {
+	s := &OutboxService{db: db, manejadores: map[string]ManejadorEvento{}, preparadores: map[string]PreparadorEvento{}}
+
+	s.Registrar(modelos.EventoConexionSolicitada, manejarConexionSolicitada)
+	s.Registrar(modelos.EventoConexionFactible, manejarConexionFactible)
+	s.Registrar(modelos.EventoConexionNoFactible, manejarConexionNoFactible)
+	s.RegistrarPreparado(modelos.EventoPagoAprobado, preparadorPagoAprobado(db, firma))
+	s.Registrar(modelos.EventoPagoRechazado, manejarPagoRechazado)
+	s.Registrar(modelos.EventoFirmaIniciada, manejarFirmaIniciada)
+	s.Registrar(modelos.EventoFirmaGuardada, manejarFirmaGuardada)
+	s.Registrar(modelos.EventoFirmaTokenRegenerado, manejarFirmaTokenRegenerado)
+	s.Registrar(modelos.EventoContratoFirmado, manejarContratoFirmado)
+	s.Registrar(modelos.EventoInstalacionAsignada, manejarInstalacionAsignada)
+	s.Registrar(modelos.EventoInstalacionProgramada, manejarInstalacionProgramada)
+	s.Registrar(modelos.EventoInstalacionCompletada, manejarInstalacionCompletada)
+	s.Registrar(modelos.EventoTurnoCancelado, manejarTurnoCancelado)
+	s.Registrar(modelos.EventoTurnoRecordatorio, manejarTurnoRecordatorio)
+	s.Registrar(modelos.EventoFacturaEmitida, manejarFacturaEmitida)
+	s.Registrar(modelos.EventoCuentaConexionSuspendida, manejarCuentaConexionSuspendida)
+	s.Registrar(modelos.EventoCuentaConexionRestablecida, manejarCuentaConexionRestablecida)
+	s.Registrar(modelos.EventoPlanPrecioProgramado, manejarPlanPrecioProgramado)
+	return s
+}
+
+// Registrar asocia un manejador a un tipo de evento (reemplaza al anterior si existía)
+func (s *OutboxService) Registrar(tipo string, manejador ManejadorEvento) {
+	delete(s.preparadores, tipo)
+	s.manejadores[tipo] = manejador
+}
+
+// RegistrarPreparado asocia un preparador a un tipo de evento (reemplaza al anterior si existía)
+func (s *OutboxService) RegistrarPreparado(tipo string, preparador PreparadorEvento) {
+	delete(s.manejadores, tipo)
+	s.preparadores[tipo] = preparador
+}
+
+// Iniciar procesa eventos cada IntervaloOutbox hasta que se cancele el contexto.
+// Se ejecuta en su propia goroutine.
+func (s *OutboxService) Iniciar(ctx context.Context) {
+	logger.Info.Printf("Despachador de outbox iniciado (intervalo %s)", IntervaloOutbox)
+	ticker := time.NewTicker(IntervaloOutbox)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.ProcesarPendientes(ctx)
+			if err != nil {
+				logger.Error.Printf("Error procesando outbox: %v", err)
+				break
+			}
+			if n < LoteOutbox {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info.Println("Despachador de outbox detenido")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcesarPendientes reserva un lote de eventos, los procesa y devuelve cuántos tomó
+func (s *OutboxService) ProcesarPendientes(ctx context.Context) (int, error) {
+	reserva, err := utilidades.GenerarTokenSeguro(16)
+	if err != nil {
+		return 0, err
+	}
+
+	eventos, err := repositorios.NewOutboxRepo(s.db).Reservar(ctx, reserva, LoteOutbox, DuracionReservaOutbox)
+	if err != nil {
+		return 0, fmt.Errorf("error reservando eventos: %w", err)
+	}
+
+	for _, e := range eventos {
+		if err := s.procesar(ctx, e); err != nil {
+			s.registrarFallo(ctx, e, err)
+		}
+	}
+	return len(eventos), nil
+}
+
+// procesar ejecuta el manejador y marca el evento en una única transacción, de modo que
+// las notificaciones no se dupliquen si el evento se reintenta. Si el evento tiene
+// preparador, éste corre antes de abrir la transacción y lo preparado se descarta cuando la
+// transacción se revierte.
+func (s *OutboxService) procesar(ctx context.Context, e modelos.EventoOutbox) error {
+	manejador, ok := s.manejadores[e.Tipo]
+	if ok {
+		return s.confirmar(ctx, e, manejador, nil)
+	}
+
+	preparador, ok := s.preparadores[e.Tipo]
+	if !ok {
+		return fmt.Errorf("no hay manejador para el evento %s", e.Tipo)
+	}
+	manejador, descartar, err := preparador(ctx, e.Payload)
+	if err != nil {
+		return err
+	}
+	revertido := true
+	defer func() {
+		if revertido {
+			descartar()
+		}
+	}()
+	return s.confirmar(ctx, e, manejador, &revertido)
+}
+
+// confirmar aplica el manejador y marca el evento procesado en una transacción. revertido, si
+// no es nil, pasa a false al intentar el commit: desde ahí no se puede asegurar que la
+// transacción se revirtió y lo preparado se conserva antes que borrar algo registrado.
+func (s *OutboxService) confirmar(ctx context.Context, e modelos.EventoOutbox, manejador ManejadorEvento, revertido *bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := manejador(ctx, tx, e.Payload); err != nil {
+		return err
+	}
+	if err := repositorios.NewOutboxRepo(tx).MarcarProcesado(ctx, e.IDEvento); err != nil {
+		return err
+	}
+	if revertido != nil {
+		*revertido = false
+	}
+	return tx.Commit()
+}
+
+// registrarFallo reprograma el evento con espera exponencial o lo pasa a dead-letter
+func (s *OutboxService) registrarFallo(ctx context.Context, e modelos.EventoOutbox, causa error) {
+	repo := repositorios.NewOutboxRepo(s.db)
+	motivo := causa.Error()
+
+	if e.Intentos+1 >= MaxIntentosOutbox {
+		logger.Error.Printf("Evento %d (%s) pasa a dead-letter tras %d intentos: %s", e.IDEvento, e.Tipo, e.Intentos+1, motivo)
+		if err := repo.MarcarIntentoFallido(ctx, e.IDEvento, modelos.EstadoOutboxFallido, motivo, nil); err != nil {
+			logger.Error.Printf("Error marcando evento %d como fallido: %v", e.IDEvento, err)
+		}
+		return
+	}
+
+	espera := esperaOutbox(e.Intentos)
+	logger.Warn.Printf("Evento %d (%s) falló (intento %d), se reintenta en %s: %s",
+		e.IDEvento, e.Tipo, e.Intentos+1, espera, motivo)
+	if err := repo.MarcarIntentoFallido(ctx, e.IDEvento, modelos.EstadoOutboxPendiente, motivo, &espera); err != nil {
+		logger.Error.Printf("Error reprogramando evento %d: %v", e.IDEvento, err)
+	}
+}
+
+// esperaOutbox duplica la espera en cada intento: 30s, 1m, 2m, ... hasta 1h
+func esperaOutbox(intentos int) time.Duration {
+	espera := esperaInicialOutbox << intentos
+	if espera <= 0 || espera > esperaMaximaOutbox {
+		return esperaMaximaOutbox
+	}
+	return espera
+}
+
+// ListarFallidos devuelve los eventos en dead-letter
+func (s *OutboxService) ListarFallidos(ctx context.Context) ([]modelos.EventoOutbox, error) {
+	return repositorios.NewOutboxRepo(s.db).ListarFallidos(ctx, limiteListadoFallidos)
+}
+
+// Reintentar vuelve a encolar un evento en dead-letter
+func (s *OutboxService) Reintentar(ctx context.Context, idEvento int) error {
+	if idEvento <= 0 {
+		return utilidades.ErrValidation{Campo: "id_evento", Mensaje: "debe ser mayor que 0"}
+	}
+	if err := repositorios.NewOutboxRepo(s.db).Reencolar(ctx, idEvento); err != nil {
+		return err
+	}
+	logger.Info.Printf("Evento %d reencolado manualmente", idEvento)
+	return nil
+}
+
+// =============================================================================
+// MANEJADORES DE EVENTOS
+// =============================================================================
+
+func manejarConexionSolicitada(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	var p modelos.PayloadConexionSolicitada
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+
+	notifService := NewNotificacionEnvioService(tx)
+	nroConexion := fmt.Sprintf("%d", p.NroConexion)
+
+	// A. Factibilidad inmediata: se avisa directamente "Factible" (sin "Recibida")
+	if p.FactibilidadInmediata {
+		return notifService.EnviarNotificacionSolicitudFactible(ctx, p.IDPersona, p.IDConexion, p.IDContrato, nroConexion)
+	}
+
+	// B. Flujo normal: verificadores y cliente "Recibida"
+	var nombreCliente, apellidoCliente, nombrePlan string
+	queryCliente := `
+		SELECT p.nombre, p.apellido, pl.nombre
+		FROM persona p
+		INNER JOIN plan pl ON pl.id_plan = ?
+		WHERE p.id_persona = ?
+	`
+	if err := tx.QueryRowContext(ctx, queryCliente, p.IDPlan, p.IDPersona).Scan(&nombreCliente, &apellidoCliente, &nombrePlan); err != nil {
+		return fmt.Errorf("error obteniendo datos para notificación: %w", err)
+	}
+
+	var observacionPtr *string
+	if p.Observaciones != "" {
+		observacionPtr = &p.Observaciones
+	}
+
+	if err := notifService.EnviarNotificacionNuevaSolicitudVerificador(
+		ctx,
+		p.IDConexion,
+		p.IDContrato,
+		nroConexion,
+		nombreCliente,
+		apellidoCliente,
+		p.Distrito,
+		p.Departamento,
+		p.Provincia,
+		nombrePlan,
+		p.Fecha,
+		observacionPtr,
+	); err != nil {
+		return fmt.Errorf("error notificando a verificadores: %w", err)
+	}
+
+	return notifService.EnviarNotificacionSolicitudRecibidaCliente(ctx, p.IDPersona, p.IDConexion, p.IDContrato, nroConexion)
+}
+
+func manejarConexionFactible(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	idPersona, idConexion, idContrato, nroConexion, err := datosNotificacionConexion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionSolicitudFactible(ctx, idPersona, idConexion, idContrato, nroConexion)
+}
+
+func manejarConexionNoFactible(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	idPersona, idConexion, idContrato, nroConexion, err := datosNotificacionConexion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionSolicitudNoFactible(ctx, idPersona, idConexion, idContrato, nroConexion)
+}
+
+// datosNotificacionConexion obtiene cliente, contrato y número de la conexión del evento
+func datosNotificacionConexion(ctx context.Context, tx *sql.Tx, payload json.RawMessage) (idPersona, idConexion, idContrato int, nroConexion string, err error) {
+	var p modelos.PayloadConexion
+	if err = json.Unmarshal(payload, &p); err != nil {
+		return 0, 0, 0, "", fmt.Errorf("payload inválido: %w", err)
+	}
+
+	var nro int
+	queryDatos := `
+		SELECT c.id_persona, con.id_contrato, c.nro_conexion
+		FROM conexion c
+		INNER JOIN contrato con ON con.id_conexion = c.id_conexion
+		WHERE c.id_conexion = ?
+	`
+	if err = tx.QueryRowContext(ctx, queryDatos, p.IDConexion).Scan(&idPersona, &idContrato, &nro); err != nil {
+		return 0, 0, 0, "", fmt.Errorf("error obteniendo datos de la conexión %d: %w", p.IDConexion, err)
+	}
+	return idPersona, p.IDConexion, idContrato, fmt.Sprintf("%d", nro), nil
+}
+
+// preparadorPagoAprobado genera el PDF original del contrato antes de la transacción; el
+// manejador registra el proceso de firma y avisa al cliente. Si algo falla, el PDF se borra
+// y el evento se reintenta; un proceso ya iniciado no se duplica.
+func preparadorPagoAprobado(db *sql.DB, firma iniciadorFirma) PreparadorEvento {
+	return func(ctx context.Context, payload json.RawMessage) (ManejadorEvento, func(), error) {
+		var p modelos.PayloadPago
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, nil, fmt.Errorf("payload inválido: %w", err)
+		}
+		pago, err := repositorios.NewPagoRepo(db).ObtenerPorID(ctx, p.IDPago)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error obteniendo pago %d: %w", p.IDPago, err)
+		}
+		pdf, err := firma.PrepararPDFOriginal(ctx, pago.IDContrato)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error iniciando la firma del contrato %d: %w", pago.IDContrato, err)
+		}
+
+		manejador := func(ctx context.Context, tx *sql.Tx, _ json.RawMessage) error {
+			if _, err := firma.RegistrarProcesoFirma(ctx, tx, pago.IDContrato, pdf); err != nil {
+				return fmt.Errorf("error iniciando la firma del contrato %d: %w", pago.IDContrato, err)
+			}
+			return NewNotificacionEnvioService(tx).EnviarNotificacionPagoAcreditado(ctx, pago.IDPersona, pago.IDContrato, pago.IDPago, pago.Monto)
+		}
+		return manejador, func() { firma.DescartarPDFOriginal(pdf) }, nil
+	}
+}
+
+func manejarPagoRechazado(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	var p modelos.PayloadPago
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+	pago, err := repositorios.NewPagoRepo(tx).ObtenerPorID(ctx, p.IDPago)
+	if err != nil {
+		return fmt.Errorf("error obteniendo pago %d: %w", p.IDPago, err)
+	}
+	var detalle *string
+	if p.Detalle != "" {
+		detalle = &p.Detalle
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionPagoNoAcreditado(ctx, pago.IDPersona, pago.IDContrato, pago.IDPago, detalle)
+}
+
+func manejarFirmaIniciada(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	return notificarContratoCliente(ctx, tx, payload,
+		"Contrato listo para firmar",
+		fmt.Sprintf("Se ha generado tu contrato. Al guardar tu firma te enviaremos por email el token para confirmarla; el token expira en %d horas.", TokenExpiracionHrs),
+	)
+}
+
+func manejarFirmaGuardada(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	return notificarContratoCliente(ctx, tx, payload,
+		"Firma guardada",
+		"Tu firma digital ha sido guardada correctamente. Ahora ingresá el token que recibiste por email para completar el proceso.",
+	)
+}
+
+func manejarFirmaTokenRegenerado(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	return notificarContratoCliente(ctx, tx, payload,
+		"Nuevo token de firma enviado",
+		fmt.Sprintf("Se ha generado un nuevo token de firma para tu contrato. Revisá tu correo electrónico. El token expira en %d horas.", TokenExpiracionHrs),
+	)
+}
+
+func manejarContratoFirmado(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	// 1. Cliente
+	if err := notificarContratoCliente(ctx, tx, payload,
+		"¡Contrato firmado exitosamente!",
+		"Tu contrato ha sido firmado digitalmente y ahora está vigente. Ya podés elegir el día y horario de la instalación desde la sección de tus conexiones.",
+	); err != nil {
+		return err
+	}
+
+	// 2. Técnicos (configuración de la conexión)
+	var p modelos.PayloadContrato
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+	idConexion, err := repositorios.NewContratoRepo(tx).ObtenerIDConexionPorContrato(ctx, p.IDContrato)
+	if err != nil {
+		return fmt.Errorf("error obteniendo conexión del contrato %d: %w", p.IDContrato, err)
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionContratoFirmadoTecnicos(ctx, idConexion, p.IDContrato)
+}
+
+// notificarContratoCliente crea una notificación CONTRATO para el titular del contrato del evento
+func notificarContratoCliente(ctx context.Context, tx *sql.Tx, payload json.RawMessage, titulo, mensaje string) error {
+	var p modelos.PayloadContrato
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+
+	var idPersona int
+	if err := tx.QueryRowContext(ctx, "SELECT id_persona FROM contrato WHERE id_contrato = ?", p.IDContrato).Scan(&idPersona); err != nil {
+		return fmt.Errorf("error obteniendo titular del contrato %d: %w", p.IDContrato, err)
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionContratoCliente(ctx, idPersona, p.IDContrato, titulo, mensaje)
+}
+
+func manejarInstalacionAsignada(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	orden, _, err := datosOrdenInstalacion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	if orden.IDTecnico == nil {
+		return nil
+	}
+	idPersonaTecnico, err := repositorios.NewUsuarioRepo(tx).ObtenerIDPersona(ctx, *orden.IDTecnico)
+	if err != nil {
+		return fmt.Errorf("error obteniendo persona del técnico %d: %w", *orden.IDTecnico, err)
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionInstalacionTecnico(ctx,
+		idPersonaTecnico, orden.IDOrden, orden.IDConexion, orden.IDContrato, orden.NroConexion, orden.Direccion)
+}
+
+func manejarInstalacionProgramada(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	orden, idPersonaCliente, err := datosOrdenInstalacion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	if orden.TurnoInicio == nil || orden.TurnoFin == nil {
+		return nil
+	}
+	mensaje := fmt.Sprintf("La instalación de tu conexión Nº %d quedó programada para el %s entre las %s y las %s.",
+		orden.NroConexion, orden.TurnoInicio.Format("02/01/2006"), orden.TurnoInicio.Format("15:04"), orden.TurnoFin.Format("15:04"))
+	return NewNotificacionEnvioService(tx).EnviarNotificacionInstalacionCliente(ctx,
+		idPersonaCliente, orden.IDConexion, orden.IDContrato, "Instalación programada", mensaje)
+}
+
+func manejarInstalacionCompletada(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	orden, idPersonaCliente, err := datosOrdenInstalacion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	mensaje := fmt.Sprintf("La instalación de tu conexión Nº %d fue completada y el servicio ya está activo.", orden.NroConexion)
+	return NewNotificacionEnvioService(tx).EnviarNotificacionInstalacionCliente(ctx,
+		idPersonaCliente, orden.IDConexion, orden.IDContrato, "¡Tu conexión está activa!", mensaje)
+}
+
+func manejarTurnoCancelado(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	var p modelos.PayloadTurnoCancelado
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+	orden, err := repositorios.NewOrdenInstalacionRepo(tx).ObtenerPorID(ctx, p.IDOrden)
+	if err != nil {
+		return err
+	}
+	if orden.IDTecnico == nil {
+		return nil
+	}
+	idPersonaTecnico, err := repositorios.NewUsuarioRepo(tx).ObtenerIDPersona(ctx, *orden.IDTecnico)
+	if err != nil {
+		return fmt.Errorf("error obteniendo persona del técnico %d: %w", *orden.IDTecnico, err)
+	}
+	mensaje := fmt.Sprintf("El cliente canceló el turno del %s de %s a %s de la orden #%d (conexión Nº %d).",
+		p.Inicio.Format("02/01/2006"), p.Inicio.Format("15:04"), p.Fin.Format("15:04"), orden.IDOrden, orden.NroConexion)
+	return NewNotificacionEnvioService(tx).EnviarNotificacionTurnoCanceladoTecnico(ctx,
+		idPersonaTecnico, orden.IDConexion, orden.IDContrato, mensaje)
+}
+
+func manejarTurnoRecordatorio(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	orden, idPersonaCliente, err := datosOrdenInstalacion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	// El turno pudo cambiar o cancelarse después de registrar el recordatorio
+	if orden.Estado != modelos.EstadoOrdenProgramada || orden.TurnoInicio == nil || orden.TurnoFin == nil {
+		return nil
+	}
+	mensaje := fmt.Sprintf("Te recordamos que mañana %s, entre las %s y las %s, un técnico visitará tu domicilio para instalar la conexión Nº %d. Es necesario que haya un adulto presente.",
+		orden.TurnoInicio.Format("02/01/2006"), orden.TurnoInicio.Format("15:04"), orden.TurnoFin.Format("15:04"), orden.NroConexion)
+	return NewNotificacionEnvioService(tx).EnviarNotificacionInstalacionCliente(ctx,
+		idPersonaCliente, orden.IDConexion, orden.IDContrato, "Recordatorio de instalación", mensaje)
+}
+
+// datosOrdenInstalacion obtiene la orden del evento y el titular de su conexión
+func datosOrdenInstalacion(ctx context.Context, tx *sql.Tx, payload json.RawMessage) (*modelos.OrdenInstalacion, int, error) {
+	var p modelos.PayloadOrdenInstalacion
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, 0, fmt.Errorf("payload inválido: %w", err)
+	}
+	orden, err := repositorios.NewOrdenInstalacionRepo(tx).ObtenerPorID(ctx, p.IDOrden)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var idPersona int
+	if err := tx.QueryRowContext(ctx, "SELECT id_persona FROM conexion WHERE id_conexion = ?", orden.IDConexion).Scan(&idPersona); err != nil {
+		return nil, 0, fmt.Errorf("error obteniendo titular de la conexión %d: %w", orden.IDConexion, err)
+	}
+	return orden, idPersona, nil
+}
+
+func manejarFacturaEmitida(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	var p modelos.PayloadFactura
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+	f, err := repositorios.NewFacturaRepo(tx).ObtenerPorID(ctx, p.IDFactura)
+	if err != nil {
+		return err
+	}
+	if f.Estado != modelos.EstadoFacturaEmitida {
+		return nil
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionFacturaEmitida(ctx, f)
+}
+
+func manejarCuentaConexionSuspendida(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	var p modelos.PayloadConexionCuenta
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+	idPersona, idConexion, idContrato, nroConexion, err := datosNotificacionConexion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionCuentaCliente(ctx, idPersona, idConexion, idContrato,
+		"Servicio suspendido por falta de pago",
+		fmt.Sprintf("Suspendimos el servicio de tu conexión Nº %s por un saldo vencido de $%.2f. Se restablece automáticamente al registrarse el pago; podés ver el detalle en la sección Cuenta de tu perfil.",
+			nroConexion, p.SaldoVencido))
+}
+
+func manejarCuentaConexionRestablecida(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	idPersona, idConexion, idContrato, nroConexion, err := datosNotificacionConexion(ctx, tx, payload)
+	if err != nil {
+		return err
+	}
+	return NewNotificacionEnvioService(tx).EnviarNotificacionCuentaCliente(ctx, idPersona, idConexion, idContrato,
+		"Servicio restablecido",
+		fmt.Sprintf("Registramos tu pago y restablecimos el servicio de tu conexión Nº %s.", nroConexion))
+}
+
+// manejarPlanPrecioProgramado avisa el precio nuevo a los titulares de los contratos en curso
+// del plan. Los que ya pagan ese precio no reciben aviso.
+func manejarPlanPrecioProgramado(ctx context.Context, tx *sql.Tx, payload json.RawMessage) error {
+	var p modelos.PayloadPlanPrecio
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+	repo := repositorios.NewPrecioPlanRepo(tx)
+	precio, err := repo.ObtenerPorID(ctx, p.IDPlanPrecio)
+	if err != nil {
+		return err
+	}
+	desde, err := time.Parse("2006-01-02", precio.FechaInicio)
+	if err != nil {
+		return fmt.Errorf("fecha de inicio inválida en el precio %d: %w", precio.IDPlanPrecio, err)
+	}
+	contratos, err := repo.ListarContratosAlcanzados(ctx, precio.IDPlan, precio.FechaInicio, desde.AddDate(0, 0, -1).Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+
+	notifService := NewNotificacionEnvioService(tx)
+	for _, c := range contratos {
+		if redondearImporte(c.PrecioActual) == precio.Precio {
+			continue
+		}
+		if err := notifService.EnviarNotificacionPrecioPlan(ctx, c, precio.Precio, desde); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package servicios
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+)
+
+// iniciadorFirmaFake falla las primeras `fallas` preparaciones del PDF y cuenta los
+// registrados y descartados
+type iniciadorFirmaFake struct {
+	fallas      int
+	llamadas    int
+	registrados int
+	descartados int
+}
+
+func (f *iniciadorFirmaFake) PrepararPDFOriginal(ctx context.Context, idContrato int) (*PDFOriginal, error) {
+	f.llamadas++
+	if f.llamadas <= f.fallas {
+		return nil, errors.New("error generando PDF original")
+	}
+	return &PDFOriginal{Path: "original.pdf", Hash: "abc", CodigoVerificacion: "ABCD-EFGH"}, nil
+}
+
+func (f *iniciadorFirmaFake) RegistrarProcesoFirma(ctx context.Context, db repositorios.Execer, idContrato int, pdf *PDFOriginal) (*modelos.ContratoFirma, error) {
+	f.registrados++
+	return &modelos.ContratoFirma{IDContratoFirma: 1, IDContrato: idContrato, PdfOriginalPath: pdf.Path}, nil
+}
+
+func (f *iniciadorFirmaFake) DescartarPDFOriginal(pdf *PDFOriginal) {
+	f.descartados++
+}
+
+// filaPagoAprobado responde a la lectura del pago del evento
+func filaPagoAprobado(idPago, idContrato, idPersona int64) respuestaFake {
+	ahora := time.Now()
+	return respuestaFake{
+		contiene: "FROM pago WHERE id_pago",
+		columnas: strings.Split("id_pago,id_contrato,id_persona,monto,moneda,concepto,proveedor,id_preferencia,id_transaccion,url_checkout,estado,detalle_estado,fecha_aprobacion,creado,ultimo_cambio", ","),
+		filas: [][]driver.Value{{
+			idPago, idContrato, idPersona, 15000.0, MonedaPago, "Costo de instalación", "fake",
+			nil, "tx-1", nil, modelos.EstadoPagoAprobado, nil, ahora, ahora, ahora,
+		}},
+	}
+}
+
+func TestPagoAprobadoReintentaInicioDeFirma(t *testing.T) {
+	ctx := context.Background()
+	db, bd := nuevaBDFake(t, filaPagoAprobado(3, 11, 5))
+	firma := &iniciadorFirmaFake{fallas: 1}
+	s := NewOutboxService(db, firma)
+	evento := modelos.EventoOutbox{IDEvento: 7, Tipo: modelos.EventoPagoAprobado, Payload: json.RawMessage(`{"id_pago":3}`)}
+
+	// 1. Primer intento: falla el inicio de la firma y no se confirma nada
+	if err := s.procesar(ctx, evento); err == nil {
+		t.Fatal("se esperaba error en el primer intento")
+	}
+	if bd.commits != 0 {
+		t.Fatalf("commits = %d, se esperaba 0", bd.commits)
+	}
+
+	// 2. Reintento del evento: la firma se inicia, se avisa al cliente y se marca procesado
+	if err := s.procesar(ctx, evento); err != nil {
+		t.Fatalf("reintento: %v", err)
+	}
+	if firma.llamadas != 2 || firma.registrados != 1 || firma.descartados != 0 {
+		t.Errorf("preparados %d, registrados %d, descartados %d; se esperaban 2, 1 y 0", firma.llamadas, firma.registrados, firma.descartados)
+	}
+	if bd.commits != 1 {
+		t.Errorf("commits = %d, se esperaba 1", bd.commits)
+	}
+	if n := bd.contarEjecutadas("UPDATE outbox_evento"); n != 1 {
+		t.Errorf("eventos marcados como procesados = %d, se esperaba 1", n)
+	}
+}
+
+// TestRegistrarFalloCalculaReintentoEnMySQL verifica que la espera se pasa en segundos para
+// que MySQL calcule proximo_intento con su propio reloj, el mismo con el que lo compara
+func TestRegistrarFalloCalculaReintentoEnMySQL(t *testing.T) {
+	ctx := context.Background()
+	db, bd := nuevaBDFake(t)
+	s := NewOutboxService(db, &iniciadorFirmaFake{})
+
+	s.registrarFallo(ctx, modelos.EventoOutbox{IDEvento: 7, Tipo: modelos.EventoPagoAprobado, Intentos: 1}, errors.New("falla"))
+	if bd.contarEjecutadas("proximo_intento = COALESCE(NOW() + INTERVAL ? SECOND") != 1 {
+		t.Fatal("proximo_intento no se calcula con NOW() de MySQL")
+	}
+	args := bd.argumentosDe("UPDATE outbox_evento")
+	if args[0] != modelos.EstadoOutboxPendiente || args[2] != int64(60) {
+		t.Errorf("argumentos %v, se esperaba pendiente con 60 s de espera", args)
+	}
+
+	// En dead-letter no se reprograma
+	s.registrarFallo(ctx, modelos.EventoOutbox{IDEvento: 7, Tipo: modelos.EventoPagoAprobado, Intentos: MaxIntentosOutbox - 1}, errors.New("falla"))
+	args = bd.argumentosDe("UPDATE outbox_evento")
+	if args[0] != modelos.EstadoOutboxFallido || args[2] != nil {
+		t.Errorf("argumentos %v, se esperaba fallido sin próximo intento", args)
+	}
+}
+
+// TestPagoAprobadoDescartaPDFSiSeRevierte verifica que el PDF preparado fuera de la
+// transacción se borra cuando la transacción del evento no se confirma
+func TestPagoAprobadoDescartaPDFSiSeRevierte(t *testing.T) {
+	ctx := context.Background()
+	db, bd := nuevaBDFake(t,
+		respuestaFake{contiene: "UPDATE outbox_evento", err: errors.New("lock wait timeout")},
+		filaPagoAprobado(3, 11, 5),
+	)
+	firma := &iniciadorFirmaFake{}
+	s := NewOutboxService(db, firma)
+	evento := modelos.EventoOutbox{IDEvento: 7, Tipo: modelos.EventoPagoAprobado, Payload: json.RawMessage(`{"id_pago":3}`)}
+
+	if err := s.procesar(ctx, evento); err == nil {
+		t.Fatal("se esperaba error al marcar el evento")
+	}
+	if bd.commits != 0 || firma.registrados != 1 || firma.descartados != 1 {
+		t.Errorf("commits %d, registrados %d, descartados %d; se esperaban 0, 1 y 1", bd.commits, firma.registrados, firma.descartados)
+	}
+}
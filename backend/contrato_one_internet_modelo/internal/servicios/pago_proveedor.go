@@ -0,0 +1,117 @@
+package servicios
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// ProveedorPago abstrae la pasarela de pagos (Mercado Pago, fake local, etc.).
+type ProveedorPago interface {
+	// Nombre identifica al proveedor (se persiste en pago.proveedor)
+	Nombre() string
+	// CrearCheckout crea la preferencia de pago y devuelve la URL a la que redirigir al cliente
+	CrearCheckout(ctx context.Context, solicitud SolicitudCheckout) (*CheckoutCreado, error)
+	// ConsultarPago obtiene el estado real de un pago a partir del ID recibido por webhook
+	ConsultarPago(ctx context.Context, idTransaccion string) (*PagoProveedor, error)
+	// ValidarNotificacion verifica la firma del webhook
+	ValidarNotificacion(n NotificacionPago) error
+}
+
+// SolicitudCheckout contiene los datos necesarios para crear un checkout
+type SolicitudCheckout struct {
+	Referencia   string // id_pago local, vuelve en el webhook como external_reference
+	Titulo       string
+	Monto        float64
+	Moneda       string
+	EmailPagador string
+}
+
+// CheckoutCreado es la respuesta del proveedor al crear el checkout
+type CheckoutCreado struct {
+	IDPreferencia string
+	URL           string
+}
+
+// PagoProveedor es el estado de un pago según el proveedor, ya normalizado
+// a los estados de modelos.Pago (pendiente, aprobado, rechazado, cancelado).
+type PagoProveedor struct {
+	IDTransaccion string
+	Referencia    string
+	Estado        string
+	DetalleEstado string
+	Monto         float64
+}
+
+// NotificacionPago representa un webhook recibido del proveedor
+type NotificacionPago struct {
+	Tipo      string // "payment", "merchant_order", etc.
+	IDRecurso string // data.id
+	Firma     string // cabecera x-signature ("ts=...,v1=...")
+	IDRequest string // cabecera x-request-id
+}
+
+// firmarNotificacion calcula la firma HMAC-SHA256 con el formato de Mercado Pago:
+// manifest "id:{data.id};request-id:{x-request-id};ts:{ts};"
+func firmarNotificacion(secreto, idRecurso, idRequest, ts string) string {
+	manifest := fmt.Sprintf("id:%s;request-id:%s;ts:%s;", strings.ToLower(idRecurso), idRequest, ts)
+	mac := hmac.New(sha256.New, []byte(secreto))
+	mac.Write([]byte(manifest))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ToleranciaNotificacionPago es la antigüedad (o adelanto de reloj) máxima aceptada para el
+// ts de un webhook. Acota la ventana en la que una notificación capturada puede reenviarse.
+const ToleranciaNotificacionPago = 5 * time.Minute
+
+// validarFirmaNotificacion verifica la cabecera x-signature de un webhook y que su ts esté
+// dentro de ToleranciaNotificacionPago respecto de ahora. La comparación se hace en tiempo
+// constante.
+func validarFirmaNotificacion(secreto string, n NotificacionPago, ahora time.Time) error {
+	if secreto == "" {
+		return fmt.Errorf("%w: secreto de webhook no configurado", utilidades.ErrFirmaWebhookInvalida)
+	}
+
+	var ts, v1 string
+	for _, parte := range strings.Split(n.Firma, ",") {
+		kv := strings.SplitN(strings.TrimSpace(parte), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "ts":
+			ts = kv[1]
+		case "v1":
+			v1 = kv[1]
+		}
+	}
+	if ts == "" || v1 == "" || n.IDRecurso == "" {
+		return utilidades.ErrFirmaWebhookInvalida
+	}
+
+	esperada := firmarNotificacion(secreto, n.IDRecurso, n.IDRequest, ts)
+	if !hmac.Equal([]byte(esperada), []byte(strings.ToLower(v1))) {
+		return utilidades.ErrFirmaWebhookInvalida
+	}
+
+	// ts viene en segundos; algunos envíos de Mercado Pago lo mandan en milisegundos
+	segundos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return utilidades.ErrFirmaWebhookInvalida
+	}
+	if segundos > 1e12 {
+		segundos /= 1000
+	}
+	desfase := ahora.Sub(time.Unix(segundos, 0))
+	if desfase > ToleranciaNotificacionPago || desfase < -ToleranciaNotificacionPago {
+		return fmt.Errorf("%w: notificación fuera de la ventana de tiempo permitida", utilidades.ErrFirmaWebhookInvalida)
+	}
+	return nil
+}
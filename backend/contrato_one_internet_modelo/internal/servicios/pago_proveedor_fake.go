@@ -0,0 +1,84 @@
+package servicios
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+// ProveedorPagoFake es un proveedor local para desarrollo y pruebas.
+// No guarda estado: el ID de transacción codifica la referencia, el estado y el monto
+// ("fake-{referencia}-{estado}-{monto}"), por lo que un webhook firmado con el secreto
+// configurado alcanza para simular cualquier resultado.
+type ProveedorPagoFake struct {
+	webhookSecret string
+	retornoURL    string
+}
+
+// NewProveedorPagoFake crea el proveedor fake
+func NewProveedorPagoFake(webhookSecret, retornoURL string) *ProveedorPagoFake {
+	return &ProveedorPagoFake{webhookSecret: webhookSecret, retornoURL: retornoURL}
+}
+
+func (p *ProveedorPagoFake) Nombre() string {
+	return "fake"
+}
+
+func (p *ProveedorPagoFake) CrearCheckout(ctx context.Context, s SolicitudCheckout) (*CheckoutCreado, error) {
+	destino := p.retornoURL
+	if destino == "" {
+		destino = "http://localhost/pago-fake"
+	}
+	q := url.Values{}
+	q.Set("referencia", s.Referencia)
+	q.Set("monto", strconv.FormatFloat(s.Monto, 'f', 2, 64))
+
+	return &CheckoutCreado{
+		IDPreferencia: "fake-pref-" + s.Referencia,
+		URL:           destino + "?" + q.Encode(),
+	}, nil
+}
+
+func (p *ProveedorPagoFake) ConsultarPago(ctx context.Context, idTransaccion string) (*PagoProveedor, error) {
+	partes := strings.Split(idTransaccion, "-")
+	if len(partes) != 4 || partes[0] != "fake" {
+		return nil, fmt.Errorf("id de transacción fake inválido: %s", idTransaccion)
+	}
+
+	monto, err := strconv.ParseFloat(partes[3], 64)
+	if err != nil {
+		return nil, fmt.Errorf("monto inválido en transacción fake: %w", err)
+	}
+
+	return &PagoProveedor{
+		IDTransaccion: idTransaccion,
+		Referencia:    partes[1],
+		Estado:        normalizarEstadoMercadoPago(partes[2]),
+		DetalleEstado: "fake_" + partes[2],
+		Monto:         monto,
+	}, nil
+}
+
+func (p *ProveedorPagoFake) ValidarNotificacion(n NotificacionPago) error {
+	return validarFirmaNotificacion(p.webhookSecret, n, time.Now())
+}
+
+// NotificacionAprobada arma un webhook firmado que aprueba el pago indicado.
+// Se usa para simular el pago en entornos de desarrollo.
+func (p *ProveedorPagoFake) NotificacionAprobada(pago *modelos.Pago) NotificacionPago {
+	idTransaccion := fmt.Sprintf("fake-%d-approved-%s", pago.IDPago, strconv.FormatFloat(pago.Monto, 'f', 2, 64))
+	idRequest := fmt.Sprintf("fake-req-%d", time.Now().UnixNano())
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	return NotificacionPago{
+		Tipo:      "payment",
+		IDRecurso: idTransaccion,
+		Firma:     "ts=" + ts + ",v1=" + firmarNotificacion(p.webhookSecret, idTransaccion, idRequest, ts),
+		IDRequest: idRequest,
+	}
+}
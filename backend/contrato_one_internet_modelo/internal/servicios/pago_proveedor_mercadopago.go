@@ -0,0 +1,161 @@
+package servicios
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+// MercadoPagoProveedor implementa ProveedorPago usando Checkout Pro de Mercado Pago
+type MercadoPagoProveedor struct {
+	accessToken     string
+	webhookSecret   string
+	apiURL          string
+	notificacionURL string
+	retornoURL      string
+	httpClient      *http.Client
+}
+
+// NewMercadoPagoProveedor crea el proveedor de Mercado Pago.
+// notificacionURL es la URL pública del webhook (controlador) y retornoURL la del frontend.
+func NewMercadoPagoProveedor(accessToken, webhookSecret, apiURL, notificacionURL, retornoURL string) *MercadoPagoProveedor {
+	return &MercadoPagoProveedor{
+		accessToken:     accessToken,
+		webhookSecret:   webhookSecret,
+		apiURL:          strings.TrimRight(apiURL, "/"),
+		notificacionURL: notificacionURL,
+		retornoURL:      retornoURL,
+		httpClient:      &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *MercadoPagoProveedor) Nombre() string {
+	return "mercadopago"
+}
+
+// CrearCheckout crea una preferencia (POST /checkout/preferences)
+func (p *MercadoPagoProveedor) CrearCheckout(ctx context.Context, s SolicitudCheckout) (*CheckoutCreado, error) {
+	body := map[string]interface{}{
+		"items": []map[string]interface{}{
+			{
+				"title":       s.Titulo,
+				"quantity":    1,
+				"unit_price":  s.Monto,
+				"currency_id": s.Moneda,
+			},
+		},
+		"external_reference": s.Referencia,
+		"payer":              map[string]string{"email": s.EmailPagador},
+	}
+	if p.notificacionURL != "" {
+		body["notification_url"] = p.notificacionURL
+	}
+	if p.retornoURL != "" {
+		body["back_urls"] = map[string]string{
+			"success": p.retornoURL + "?resultado=aprobado",
+			"failure": p.retornoURL + "?resultado=rechazado",
+			"pending": p.retornoURL + "?resultado=pendiente",
+		}
+		body["auto_return"] = "approved"
+	}
+
+	var resp struct {
+		ID        string `json:"id"`
+		InitPoint string `json:"init_point"`
+	}
+	if err := p.hacerRequest(ctx, http.MethodPost, "/checkout/preferences", body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ID == "" || resp.InitPoint == "" {
+		return nil, fmt.Errorf("respuesta de mercadopago sin id o init_point")
+	}
+
+	return &CheckoutCreado{IDPreferencia: resp.ID, URL: resp.InitPoint}, nil
+}
+
+// ConsultarPago obtiene el pago (GET /v1/payments/{id}) para no confiar en el contenido del webhook
+func (p *MercadoPagoProveedor) ConsultarPago(ctx context.Context, idTransaccion string) (*PagoProveedor, error) {
+	var resp struct {
+		ID                json.Number `json:"id"`
+		Status            string      `json:"status"`
+		StatusDetail      string      `json:"status_detail"`
+		ExternalReference string      `json:"external_reference"`
+		TransactionAmount float64     `json:"transaction_amount"`
+	}
+	path := "/v1/payments/" + idTransaccion
+	if err := p.hacerRequest(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return &PagoProveedor{
+		IDTransaccion: resp.ID.String(),
+		Referencia:    resp.ExternalReference,
+		Estado:        normalizarEstadoMercadoPago(resp.Status),
+		DetalleEstado: resp.StatusDetail,
+		Monto:         resp.TransactionAmount,
+	}, nil
+}
+
+func (p *MercadoPagoProveedor) ValidarNotificacion(n NotificacionPago) error {
+	return validarFirmaNotificacion(p.webhookSecret, n, time.Now())
+}
+
+func (p *MercadoPagoProveedor) hacerRequest(ctx context.Context, method, path string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error serializando request a mercadopago: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creando request a mercadopago: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error llamando a mercadopago: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error leyendo respuesta de mercadopago: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mercadopago devolvió %d: %s", resp.StatusCode, string(respBytes))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBytes, result); err != nil {
+			return fmt.Errorf("error parseando respuesta de mercadopago: %w", err)
+		}
+	}
+	return nil
+}
+
+// normalizarEstadoMercadoPago traduce los status de Mercado Pago a los estados locales
+func normalizarEstadoMercadoPago(status string) string {
+	switch status {
+	case "approved":
+		return modelos.EstadoPagoAprobado
+	case "rejected":
+		return modelos.EstadoPagoRechazado
+	case "cancelled", "refunded", "charged_back":
+		return modelos.EstadoPagoCancelado
+	default: // pending, in_process, authorized, in_mediation
+		return modelos.EstadoPagoPendiente
+	}
+}
@@ -0,0 +1,55 @@
+package servicios
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+func TestValidarFirmaNotificacionVentanaDeTiempo(t *testing.T) {
+	const secreto = "secreto-webhook"
+	ahora := time.Now()
+	notificacion := func(ts string) NotificacionPago {
+		return NotificacionPago{
+			Tipo:      "payment",
+			IDRecurso: "123",
+			IDRequest: "req-1",
+			Firma:     "ts=" + ts + ",v1=" + firmarNotificacion(secreto, "123", "req-1", ts),
+		}
+	}
+	segundos := func(d time.Duration) string { return strconv.FormatInt(ahora.Add(d).Unix(), 10) }
+
+	casos := []struct {
+		nombre string
+		n      NotificacionPago
+		valida bool
+	}{
+		{"actual", notificacion(segundos(0)), true},
+		{"dentro de la tolerancia", notificacion(segundos(-4 * time.Minute)), true},
+		{"en milisegundos", notificacion(strconv.FormatInt(ahora.UnixMilli(), 10)), true},
+		{"reenviada tarde", notificacion(segundos(-ToleranciaNotificacionPago - time.Minute)), false},
+		{"del futuro", notificacion(segundos(ToleranciaNotificacionPago + time.Minute)), false},
+		{"ts no numérico", notificacion("ayer"), false},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			err := validarFirmaNotificacion(secreto, c.n, ahora)
+			if c.valida && err != nil {
+				t.Errorf("se rechazó una notificación válida: %v", err)
+			}
+			if !c.valida && !errors.Is(err, utilidades.ErrFirmaWebhookInvalida) {
+				t.Errorf("error %v, se esperaba ErrFirmaWebhookInvalida", err)
+			}
+		})
+	}
+
+	// Cambiar el ts de una notificación capturada invalida la firma
+	capturada := notificacion(segundos(-time.Hour))
+	capturada.Firma = "ts=" + segundos(0) + capturada.Firma[len("ts=")+len(segundos(-time.Hour)):]
+	if err := validarFirmaNotificacion(secreto, capturada, ahora); !errors.Is(err, utilidades.ErrFirmaWebhookInvalida) {
+		t.Errorf("ts reescrito: error %v, se esperaba ErrFirmaWebhookInvalida", err)
+	}
+}
@@ -0,0 +1,287 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+const MonedaPago = "ARS"
+
+// TimeoutCheckoutPago limita la creación del checkout en el proveedor, que se hace con el
+// contrato bloqueado. Es menor que innodb_lock_wait_timeout (50 s por defecto) para que un
+// segundo intento sobre el mismo contrato espere y reutilice el pago en vez de fallar.
+const TimeoutCheckoutPago = 20 * time.Second
+
+// PagoService gestiona los pagos de contratos. El proceso de firma lo inicia el evento de
+// pago aprobado (ver manejadorPagoAprobado).
+type PagoService struct {
+	db                  *sql.DB
+	proveedor           ProveedorPago
+	firmaDigitalService *FirmaDigitalService
+	cuentaService       *CuentaService
+}
+
+func NewPagoService(db *sql.DB, proveedor ProveedorPago, firmaDigitalService *FirmaDigitalService, cuentaService *CuentaService) *PagoService {
+	return &PagoService{
+		db:                  db,
+		proveedor:           proveedor,
+		firmaDigitalService: firmaDigitalService,
+		cuentaService:       cuentaService,
+	}
+}
+
+// ResultadoPago agrupa el pago y, si ya existe, el proceso de firma de su contrato
+type ResultadoPago struct {
+	Pago          *modelos.Pago
+	ContratoFirma *modelos.ContratoFirma
+}
+
+// IniciarPagoContrato crea (o reutiliza) el pago pendiente del costo de instalación
+// de un contrato y devuelve la URL de checkout del proveedor. Todo se hace con la fila del
+// contrato bloqueada: dos pedidos simultáneos no pueden crear dos pagos (ni dos checkouts)
+// para el mismo contrato, el segundo espera y reutiliza el del primero.
+func (s *PagoService) IniciarPagoContrato(ctx context.Context, idPersona, idContrato int) (*modelos.Pago, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pagoRepo := repositorios.NewPagoRepo(tx)
+
+	// 1. Validar contrato y pertenencia, bloqueando el contrato hasta el commit
+	var idPersonaContrato int
+	var costoInstalacion sql.NullFloat64
+	err = tx.QueryRowContext(ctx,
+		`SELECT id_persona, costo_instalacion FROM contrato WHERE id_contrato = ? AND borrado IS NULL FOR UPDATE`,
+		idContrato,
+	).Scan(&idPersonaContrato, &costoInstalacion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utilidades.ErrNotFound{Entity: "contrato", Campo: "id_contrato", Valor: strconv.Itoa(idContrato)}
+		}
+		return nil, fmt.Errorf("error consultando contrato: %w", err)
+	}
+	if idPersonaContrato != idPersona {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+	if !costoInstalacion.Valid || costoInstalacion.Float64 <= 0 {
+		return nil, utilidades.ErrValidation{Campo: "costo_instalacion", Mensaje: "el contrato no tiene un costo de instalación a pagar"}
+	}
+
+	// 2. Revisar pagos previos: no cobrar dos veces y reutilizar un checkout pendiente
+	ultimo, err := pagoRepo.ObtenerUltimoPorContrato(ctx, idContrato)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error consultando pagos del contrato: %w", err)
+	}
+	if ultimo != nil {
+		switch {
+		case ultimo.Estado == modelos.EstadoPagoAprobado:
+			return nil, utilidades.ErrPagoYaAcreditado
+		case ultimo.Estado == modelos.EstadoPagoPendiente && ultimo.URLCheckout != nil && ultimo.Proveedor == s.proveedor.Nombre():
+			logger.Info.Printf("Reutilizando pago pendiente %d para contrato %d", ultimo.IDPago, idContrato)
+			return ultimo, nil
+		}
+	}
+
+	// 3. Email del pagador
+	var email string
+	if err := tx.QueryRowContext(ctx, "SELECT email FROM persona WHERE id_persona = ?", idPersona).Scan(&email); err != nil {
+		return nil, fmt.Errorf("error consultando persona: %w", err)
+	}
+
+	// 4. Registrar el pago pendiente
+	pago := &modelos.Pago{
+		IDContrato: idContrato,
+		IDPersona:  idPersona,
+		Monto:      costoInstalacion.Float64,
+		Moneda:     MonedaPago,
+		Concepto:   fmt.Sprintf("Instalación - contrato %d", idContrato),
+		Proveedor:  s.proveedor.Nombre(),
+		Estado:     modelos.EstadoPagoPendiente,
+	}
+	idPago, err := pagoRepo.Crear(ctx, pago)
+	if err != nil {
+		return nil, err
+	}
+	pago.IDPago = int(idPago)
+
+	// 5. Crear el checkout en el proveedor. Es una llamada de red con el contrato bloqueado,
+	// por eso se acota a TimeoutCheckoutPago.
+	ctxCheckout, cancelar := context.WithTimeout(ctx, TimeoutCheckoutPago)
+	defer cancelar()
+	checkout, err := s.proveedor.CrearCheckout(ctxCheckout, SolicitudCheckout{
+		Referencia:   strconv.Itoa(pago.IDPago),
+		Titulo:       "ONE Internet - " + pago.Concepto,
+		Monto:        pago.Monto,
+		Moneda:       pago.Moneda,
+		EmailPagador: email,
+	})
+	if err != nil {
+		detalle := "error creando checkout"
+		if errEstado := pagoRepo.ActualizarEstado(ctx, pago.IDPago, modelos.EstadoPagoCancelado, "", &detalle); errEstado != nil {
+			logger.Error.Printf("Error cancelando pago %d tras fallo de checkout: %v", pago.IDPago, errEstado)
+		} else if errCommit := tx.Commit(); errCommit != nil {
+			logger.Error.Printf("Error registrando la cancelación del pago %d: %v", pago.IDPago, errCommit)
+		}
+		return nil, fmt.Errorf("error creando checkout en %s: %w", s.proveedor.Nombre(), err)
+	}
+
+	if err := pagoRepo.ActualizarCheckout(ctx, pago.IDPago, checkout.IDPreferencia, checkout.URL); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando el pago: %w", err)
+	}
+	pago.IDPreferencia = &checkout.IDPreferencia
+	pago.URLCheckout = &checkout.URL
+
+	logger.Info.Printf("Pago %d creado para contrato %d (proveedor %s)", pago.IDPago, idContrato, pago.Proveedor)
+	return pago, nil
+}
+
+// ObtenerPago devuelve un pago validando que pertenezca a la persona
+func (s *PagoService) ObtenerPago(ctx context.Context, idPago, idPersona int) (*modelos.Pago, error) {
+	pago, err := repositorios.NewPagoRepo(s.db).ObtenerPorID(ctx, idPago)
+	if err != nil {
+		return nil, err
+	}
+	if pago.IDPersona != idPersona {
+		return nil, utilidades.ErrAccesoDenegado
+	}
+	return pago, nil
+}
+
+// ProcesarNotificacion procesa un webhook del proveedor. Valida la firma, consulta el
+// estado real del pago y actualiza el registro local. Es idempotente: un pago ya
+// aprobado no vuelve a iniciar el proceso de firma.
+// Devuelve nil, nil si la notificación no corresponde a un pago (se ignora).
+func (s *PagoService) ProcesarNotificacion(ctx context.Context, n NotificacionPago) (*ResultadoPago, error) {
+	// 1. Verificar firma
+	if err := s.proveedor.ValidarNotificacion(n); err != nil {
+		logger.Warn.Printf("Webhook de pago con firma inválida (recurso %s): %v", n.IDRecurso, err)
+		return nil, err
+	}
+
+	if n.Tipo != "payment" {
+		logger.Debug.Printf("Webhook de pago ignorado (tipo %s)", n.Tipo)
+		return nil, nil
+	}
+
+	// 2. Consultar el pago en el proveedor (no confiamos en el contenido del webhook)
+	pp, err := s.proveedor.ConsultarPago(ctx, n.IDRecurso)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando pago %s en %s: %w", n.IDRecurso, s.proveedor.Nombre(), err)
+	}
+
+	idPago, err := strconv.Atoi(pp.Referencia)
+	if err != nil {
+		return nil, utilidades.ErrValidation{Campo: "external_reference", Mensaje: "referencia de pago inválida"}
+	}
+
+	// 3. Actualizar el pago en una transacción con la fila bloqueada
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pagoRepo := repositorios.NewPagoRepo(tx)
+	pago, err := pagoRepo.ObtenerPorIDParaActualizar(ctx, idPago)
+	if err != nil {
+		return nil, err
+	}
+
+	if pago.Estado == modelos.EstadoPagoAprobado {
+		logger.Info.Printf("Webhook duplicado para pago %d ya aprobado", pago.IDPago)
+		return &ResultadoPago{Pago: pago}, nil
+	}
+
+	if pp.Estado == modelos.EstadoPagoAprobado && math.Abs(pp.Monto-pago.Monto) > 0.009 {
+		logger.Error.Printf("Monto inconsistente en pago %d: esperado %.2f, recibido %.2f", pago.IDPago, pago.Monto, pp.Monto)
+		return nil, utilidades.ErrValidation{Campo: "monto", Mensaje: "el monto acreditado no coincide con el pago"}
+	}
+
+	if pp.Estado == pago.Estado {
+		return &ResultadoPago{Pago: pago}, nil
+	}
+
+	detalle := pp.DetalleEstado
+	if err := pagoRepo.ActualizarEstado(ctx, pago.IDPago, pp.Estado, pp.IDTransaccion, &detalle); err != nil {
+		return nil, err
+	}
+
+	// El aviso al cliente (y, si se aprobó, el inicio de la firma) se registra en el outbox
+	// dentro de la misma transacción, y el pago aprobado se acredita en la cuenta corriente:
+	// si con él el cliente se pone al día, sus conexiones suspendidas por deuda se restablecen
+	// en el momento
+	outboxRepo := repositorios.NewOutboxRepo(tx)
+	var restablecidas []int
+	switch pp.Estado {
+	case modelos.EstadoPagoAprobado:
+		if err := repositorios.NewCuentaRepo(tx).RegistrarPagoAprobado(ctx, pago.IDPago); err != nil {
+			return nil, err
+		}
+		if restablecidas, err = s.cuentaService.RestablecerEnTransaccion(ctx, tx, pago.IDPersona); err != nil {
+			return nil, err
+		}
+		if _, err := outboxRepo.Registrar(ctx, modelos.EventoPagoAprobado, modelos.PayloadPago{IDPago: pago.IDPago}); err != nil {
+			return nil, err
+		}
+	case modelos.EstadoPagoRechazado, modelos.EstadoPagoCancelado:
+		if _, err := outboxRepo.Registrar(ctx, modelos.EventoPagoRechazado, modelos.PayloadPago{IDPago: pago.IDPago, Detalle: detalle}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	pago, err = repositorios.NewPagoRepo(s.db).ObtenerPorID(ctx, idPago)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Pago %d actualizado a estado %s", pago.IDPago, pago.Estado)
+	if len(restablecidas) > 0 {
+		logger.Info.Printf("Conexión(es) %v de la persona %d restablecida(s) por el pago %d", restablecidas, pago.IDPersona, pago.IDPago)
+	}
+
+	return &ResultadoPago{Pago: pago}, nil
+}
+
+// SimularPagoAprobado recorre el flujo completo (checkout + webhook aprobado) con el
+// proveedor fake. Inicia la firma sin esperar al despachador del outbox para devolver el
+// contrato_firma; el evento de pago aprobado la encuentra iniciada y no la duplica.
+func (s *PagoService) SimularPagoAprobado(ctx context.Context, idPersona, idContrato int) (*ResultadoPago, error) {
+	fake, ok := s.proveedor.(*ProveedorPagoFake)
+	if !ok {
+		return nil, utilidades.ErrSimulacionPagoDeshabilitada
+	}
+
+	pago, err := s.IniciarPagoContrato(ctx, idPersona, idContrato)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado, err := s.ProcesarNotificacion(ctx, fake.NotificacionAprobada(pago))
+	if err != nil {
+		return nil, err
+	}
+
+	resultado.ContratoFirma, err = s.firmaDigitalService.IniciarProcesoFirma(ctx, idContrato)
+	if err != nil {
+		return nil, fmt.Errorf("pago %d aprobado pero falló el inicio de firma: %w", pago.IDPago, err)
+	}
+	return resultado, nil
+}
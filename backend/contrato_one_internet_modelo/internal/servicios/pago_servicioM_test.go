@@ -0,0 +1,114 @@
+package servicios
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+// escenarioPagoConSuspension arma la base de un cliente con la conexión 21 suspendida por
+// una factura de $12100 vencida hace 50 días, que paga montoPago por la pasarela
+func escenarioPagoConSuspension(t *testing.T, montoPago float64) (*PagoService, *bdFake, NotificacionPago) {
+	t.Helper()
+	ahora := time.Now()
+	vencimiento := ahora.AddDate(0, 0, -50).Format("2006-01-02")
+	pendiente := filaPagoAprobado(3, 11, 5)
+	pendiente.contiene = "FROM pago WHERE id_pago = ? FOR UPDATE"
+	pendiente.filas[0][3], pendiente.filas[0][8], pendiente.filas[0][10] = montoPago, nil, modelos.EstadoPagoPendiente
+
+	db, bd := nuevaBDFake(t,
+		pendiente,
+		filaPagoAprobado(3, 11, 5),
+		respuestaFake{
+			contiene: "FROM cuenta_movimiento m",
+			columnas: strings.Split("id_movimiento,id_persona,tipo,concepto,importe,fecha,fecha_vencimiento,id_factura,id_pago,medio_pago,referencia,id_usuario,creado", ","),
+			filas: [][]driver.Value{
+				{int64(1), int64(5), modelos.MovimientoCargo, "Factura B 0001-00000008", 12100.0, ahora.AddDate(0, 0, -60).Format("2006-01-02"), vencimiento, int64(40), nil, nil, nil, nil, ahora},
+				{int64(2), int64(5), modelos.MovimientoPago, "Costo de instalación", montoPago, ahora.Format("2006-01-02"), nil, nil, int64(3), "fake", nil, nil, ahora},
+			},
+		},
+		respuestaFake{contiene: "c.suspendida_por_deuda IS NOT NULL AND ec.nombre", columnas: []string{"id_conexion"}, filas: [][]driver.Value{{int64(21)}}},
+		respuestaFake{contiene: "FROM conexion t", columnas: []string{"id_estado_conexion", "nombre"}, filas: [][]driver.Value{{int64(6), modelos.EstadoConexionSuspendida}}},
+		respuestaFake{contiene: "FROM estado_conexion WHERE nombre", columnas: []string{"id_estado_conexion"}, filas: [][]driver.Value{{int64(5)}}},
+		respuestaFake{contiene: "SELECT COUNT(1)", columnas: []string{"cnt"}, filas: [][]driver.Value{{int64(0)}}},
+	)
+	proveedor := NewProveedorPagoFake("secreto-webhook", "")
+	s := NewPagoService(db, proveedor, nil, NewCuentaService(db, 15))
+	return s, bd, proveedor.NotificacionAprobada(&modelos.Pago{IDPago: 3, Monto: montoPago})
+}
+
+func TestPagoAprobadoRestableceConexionSuspendida(t *testing.T) {
+	s, bd, notificacion := escenarioPagoConSuspension(t, 12100)
+
+	if _, err := s.ProcesarNotificacion(context.Background(), notificacion); err != nil {
+		t.Fatalf("ProcesarNotificacion: %v", err)
+	}
+	if bd.commits != 1 {
+		t.Fatalf("commits = %d, se esperaba una única transacción", bd.commits)
+	}
+	if bd.contarEjecutadas("INSERT INTO cuenta_movimiento") != 1 {
+		t.Error("el pago no se acreditó en la cuenta")
+	}
+	if bd.contarEjecutadas("UPDATE conexion SET id_estado_conexion") != 1 || bd.contarEjecutadas("suspendida_por_deuda = NULL") != 1 {
+		t.Error("la conexión suspendida por deuda no se restableció con el pago")
+	}
+	if n := bd.contarEjecutadas("INSERT INTO outbox_evento"); n != 2 {
+		t.Errorf("%d evento(s) registrados, se esperaban el de pago aprobado y el de conexión restablecida", n)
+	}
+}
+
+func TestPagoParcialNoRestablece(t *testing.T) {
+	s, bd, notificacion := escenarioPagoConSuspension(t, 5000)
+
+	if _, err := s.ProcesarNotificacion(context.Background(), notificacion); err != nil {
+		t.Fatalf("ProcesarNotificacion: %v", err)
+	}
+	if bd.contarEjecutadas("INSERT INTO cuenta_movimiento") != 1 {
+		t.Error("el pago no se acreditó en la cuenta")
+	}
+	if bd.contarEjecutadas("UPDATE conexion") != 0 {
+		t.Error("con deuda vencida fuera de gracia la conexión debe seguir suspendida")
+	}
+}
+
+// TestIniciarPagoContratoBloqueaElContrato verifica que el pago se crea con el contrato
+// bloqueado (la respuesta del contrato sólo aplica a la consulta con FOR UPDATE) y que un
+// segundo pedido, ya con el checkout creado, reutiliza el pago en vez de crear otro
+func TestIniciarPagoContratoBloqueaElContrato(t *testing.T) {
+	ctx := context.Background()
+	db, bd := nuevaBDFake(t,
+		respuestaFake{contiene: "FROM contrato WHERE id_contrato = ? AND borrado IS NULL FOR UPDATE", columnas: []string{"id_persona", "costo_instalacion"}, filas: [][]driver.Value{{int64(5), 15000.0}}},
+		respuestaFake{contiene: "SELECT email FROM persona", columnas: []string{"email"}, filas: [][]driver.Value{{"cliente@example.com"}}},
+	)
+	s := NewPagoService(db, NewProveedorPagoFake("secreto-webhook", ""), nil, nil)
+
+	// 1. Primer pedido: crea el pago y el checkout en una sola transacción
+	pago, err := s.IniciarPagoContrato(ctx, 5, 11)
+	if err != nil {
+		t.Fatalf("IniciarPagoContrato: %v", err)
+	}
+	if pago.URLCheckout == nil || bd.contarEjecutadas("INSERT INTO pago") != 1 || bd.contarEjecutadas("SET id_preferencia") != 1 {
+		t.Fatalf("no se registró el pago con su checkout: %+v", pago)
+	}
+	if bd.commits != 1 {
+		t.Fatalf("commits = %d, se esperaba uno", bd.commits)
+	}
+
+	// 2. Segundo pedido: al obtener el bloqueo ve el pago pendiente y lo reutiliza
+	pendiente := filaPagoAprobado(int64(pago.IDPago), 11, 5)
+	pendiente.contiene = "FROM pago WHERE id_contrato = ? ORDER BY id_pago DESC LIMIT 1"
+	pendiente.filas[0][9], pendiente.filas[0][10] = *pago.URLCheckout, modelos.EstadoPagoPendiente
+	bd.responder(pendiente)
+
+	otro, err := s.IniciarPagoContrato(ctx, 5, 11)
+	if err != nil {
+		t.Fatalf("segundo IniciarPagoContrato: %v", err)
+	}
+	if otro.IDPago != pago.IDPago || bd.contarEjecutadas("INSERT INTO pago") != 1 {
+		t.Errorf("se creó un segundo pago (%d) para el mismo contrato", otro.IDPago)
+	}
+}
@@ -0,0 +1,10 @@
+package servicios
+
+// RenderizadorPDF convierte los datos de un contrato en un archivo PDF.
+// Implementaciones: nativo (Go puro, por defecto) y wkhtmltopdf (plantilla HTML + binario externo).
+type RenderizadorPDF interface {
+	Nombre() string
+	// Renderizar escribe el PDF en outputPath. Si datos.MostrarFirma es true,
+	// datos.FirmaImagenPath apunta al PNG de la firma.
+	Renderizar(datos *DatosContrato, outputPath string) error
+}
@@ -0,0 +1,294 @@
+package servicios
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+	"contrato_one_internet_modelo/internal/utilidades/pdf"
+	"contrato_one_internet_modelo/internal/utilidades/qr"
+)
+
+// Datos fijos del prestador que encabezan la solicitud (mismos que la plantilla HTML)
+var encabezadoPrestador = []string{
+	"CREA Servicios Tecnológicos S.A.P.E.M.",
+	"CUIT: 30-71646883-2",
+	"Sarmiento 514 Rivadavia - Mendoza",
+	"Teléfono: 2634 445162 / 445163",
+	"WhatsApp: 2634 217506",
+	"www.oneinternet.com.ar",
+}
+
+const (
+	margenPDF       = 42.5 // 15 mm
+	tamTextoPDF     = 9.0
+	interlineadoPDF = 12.0
+	anchoEtiqueta   = 165.0
+)
+
+// RenderizadorNativo genera el PDF del contrato en Go puro, sin binarios externos.
+// La salida es determinística, por lo que puede usarse en tests.
+type RenderizadorNativo struct{}
+
+func NewRenderizadorNativo() *RenderizadorNativo {
+	return &RenderizadorNativo{}
+}
+
+func (r *RenderizadorNativo) Nombre() string { return "nativo" }
+
+func (r *RenderizadorNativo) Renderizar(datos *DatosContrato, outputPath string) error {
+	doc, err := r.ConstruirDocumento(datos)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creando directorio %s: %w", dir, err)
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("error creando %s: %w", outputPath, err)
+	}
+	if err := doc.Escribir(f); err != nil {
+		f.Close()
+		return fmt.Errorf("error escribiendo PDF: %w", err)
+	}
+	return f.Close()
+}
+
+// ConstruirDocumento arma el documento en memoria con la solicitud de servicio
+func (r *RenderizadorNativo) ConstruirDocumento(datos *DatosContrato) (*pdf.Documento, error) {
+	m := &maquetador{doc: pdf.NuevoDocumento()}
+	m.doc.NuevaPagina()
+	m.y = margenPDF
+
+	// 1. Encabezado
+	m.doc.Texto(margenPDF, m.y+16, pdf.HelveticaNegrita, 20, "ONE Internet")
+	derecha := m.doc.Ancho - margenPDF
+	m.doc.TextoDerecha(derecha, m.y+14, pdf.HelveticaNegrita, 14, "Solicitud de Servicio")
+	m.doc.TextoDerecha(derecha, m.y+28, pdf.Helvetica, tamTextoPDF, datos.FechaGeneracion)
+	m.doc.TextoDerecha(derecha, m.y+42, pdf.HelveticaNegrita, tamTextoPDF, "Nº de Cuenta: "+datos.NumeroCuenta)
+	m.y += 30
+	for _, l := range encabezadoPrestador {
+		m.doc.Texto(margenPDF, m.y, pdf.Helvetica, 8, l)
+		m.y += 10
+	}
+	m.y += 4
+	m.doc.Linea(margenPDF, m.y, derecha, m.y, 0.8)
+	m.y += 14
+
+	// 2. Texto introductorio
+	m.parrafo(`La presente "Solicitud de Servicio" de Conexión Acceso a Internet, se regirá por los "Términos y Condiciones" de Prestación del Servicio, "Equipos en comodato" y "Condiciones Particulares" del servicio seleccionado, según se detalla en posteriores anexos.`)
+	m.parrafo("La firma de la presente Solicitud de Servicio, constituirá prueba de la voluntad de las partes, de obligarse legalmente de acuerdo a lo dispuesto en el/los anexos antes referenciados, y los demás anexos que pudieran incluirse a la presente.")
+
+	// 3. Datos del solicitante
+	m.seccion("Datos del Solicitante")
+	m.campo("Apellido y Nombres - Razón Social:", datos.NombreCompleto)
+	m.campo("Otra denominación:", datos.OtraDenominacion)
+	m.campo("DNI:", datos.DNI)
+	m.campo("Fecha de Nacimiento:", datos.FechaNacimiento)
+	m.campo("CUIL:", datos.CUIL)
+	m.campo("Condición ante el IVA:", datos.CondicionIVA)
+	m.campo("Email:", datos.Email)
+	m.campo("Teléfono:", datos.Telefono)
+
+	// 4. Domicilios
+	m.seccion("Domicilio Legal")
+	m.campo("Calle:", datos.DomLegalCalle)
+	m.campo("Número:", datos.DomLegalNumero)
+	m.campo("Local/ Depto/ Piso:", datos.DomLegalPiso)
+	m.campo("Localidad:", datos.DomLegalLocalidad+" - Mendoza")
+	m.campo("Código Postal:", datos.DomLegalCP)
+
+	m.seccion("Domicilio de Prestación del Servicio")
+	m.campo("Calle:", datos.DomInstalacionCalle)
+	m.campo("Número:", datos.DomInstalacionNumero)
+	m.campo("Local/ Depto/ Piso:", datos.DomInstalacionPiso)
+	m.campo("Localidad:", datos.DomInstalacionLocalidad+" - Mendoza")
+	m.campo("Código Postal:", datos.DomInstalacionCP)
+	m.campo("Email:", datos.Email)
+	m.campo("Teléfono:", datos.Telefono)
+
+	// 5. Condiciones particulares
+	m.seccion("Condiciones Particulares")
+	m.campo("Denominación Comercial:", datos.PlanNombre)
+	m.campo("Velocidad de descarga:", datos.PlanVelocidad)
+	m.campo("Tipo de Servicio:", "Ancho de banda compartido")
+	m.campo("Costo abono mensual:", "$ "+datos.PrecioMensual+" (Pago mes adelantado).")
+	m.campo("Soporte Técnico:", "Ante una indisponibilidad del Servicio, ONE internet pone a disposición su Centro de Atención al Cliente, que opera de Lunes a Viernes de 08:00 a 13:00 y de 17:00 a 20:00; Sábados de 08:00 a 12:00.\nPodrá acceder al Centro de Atención al Cliente llamando al 2634445163. WhatsApp 2634217506.")
+	m.campo("Costos de alta de servicios:", "$ "+datos.CostoAlta+" (pagaderos en 1 cuotas iguales y consecutivas)")
+	m.campo("Plazo de instalación:", "15 días hábiles a partir del pago de 1º cuota de alta de servicio. Este periodo podrá extenderse, en casos especiales, sin que ello genere derecho a reclamo alguno por parte del Cliente.")
+
+	// 6. Firma
+	if err := m.firma(datos); err != nil {
+		return nil, err
+	}
+
+	// 7. Código de verificación pública con QR
+	if datos.CodigoVerificacion != "" {
+		if err := m.verificacion(datos); err != nil {
+			return nil, err
+		}
+	}
+
+	// 8. Anexo de auditoría (sólo en el PDF firmado)
+	if datos.Auditoria != nil {
+		m.anexoAuditoria(datos)
+	}
+
+	return m.doc, nil
+}
+
+// maquetador lleva la posición vertical y agrega páginas cuando no hay espacio
+type maquetador struct {
+	doc *pdf.Documento
+	y   float64
+}
+
+func (m *maquetador) anchoUtil() float64 {
+	return m.doc.Ancho - 2*margenPDF
+}
+
+// asegurar pasa a una página nueva si no entran alto puntos más
+func (m *maquetador) asegurar(alto float64) {
+	if m.y+alto > m.doc.Alto-margenPDF {
+		m.doc.NuevaPagina()
+		m.y = margenPDF
+	}
+}
+
+func (m *maquetador) parrafo(texto string) {
+	for _, l := range pdf.DividirLineas(pdf.Helvetica, tamTextoPDF, texto, m.anchoUtil()) {
+		m.asegurar(interlineadoPDF)
+		m.doc.Texto(margenPDF, m.y+tamTextoPDF, pdf.Helvetica, tamTextoPDF, l)
+		m.y += interlineadoPDF
+	}
+	m.y += 6
+}
+
+func (m *maquetador) seccion(titulo string) {
+	m.asegurar(22 + interlineadoPDF)
+	m.y += 6
+	m.doc.RectanguloRelleno(margenPDF, m.y, m.anchoUtil(), 16, 0.88)
+	m.doc.Texto(margenPDF+4, m.y+11.5, pdf.HelveticaNegrita, 10, titulo)
+	m.y += 22
+}
+
+// campo escribe una fila etiqueta / valor; el valor se ajusta al ancho disponible
+func (m *maquetador) campo(etiqueta, valor string) {
+	anchoValor := m.anchoUtil() - anchoEtiqueta
+	lineas := pdf.DividirLineas(pdf.Helvetica, tamTextoPDF, valor, anchoValor)
+	m.asegurar(interlineadoPDF)
+	m.doc.Texto(margenPDF, m.y+tamTextoPDF, pdf.HelveticaNegrita, tamTextoPDF, etiqueta)
+	for _, l := range lineas {
+		m.asegurar(interlineadoPDF)
+		m.doc.Texto(margenPDF+anchoEtiqueta, m.y+tamTextoPDF, pdf.Helvetica, tamTextoPDF, l)
+		m.y += interlineadoPDF
+	}
+	m.y += 2
+}
+
+// firma dibuja el recuadro de firma; con MostrarFirma embebe el PNG (máx. 280x70 pt)
+func (m *maquetador) firma(datos *DatosContrato) error {
+	const anchoMax, altoMax = 280.0, 70.0
+	m.asegurar(altoMax + 50)
+	m.y += 16
+	centro := m.doc.Ancho / 2
+
+	if datos.MostrarFirma && datos.FirmaImagenPath != "" {
+		f, err := os.Open(datos.FirmaImagenPath)
+		if err != nil {
+			return fmt.Errorf("error abriendo imagen de firma: %w", err)
+		}
+		idx, err := m.doc.AgregarImagenPNG(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		w, h := m.doc.TamanoImagen(idx)
+		escala := anchoMax / float64(w)
+		if altoMax/float64(h) < escala {
+			escala = altoMax / float64(h)
+		}
+		ancho, alto := float64(w)*escala, float64(h)*escala
+		m.doc.Imagen(idx, centro-ancho/2, m.y+altoMax-alto, ancho, alto)
+	} else if datos.MostrarFirma {
+		logger.Warn.Printf("Contrato %s marcado para firma sin imagen de firma", datos.NumeroContrato)
+	}
+
+	m.y += altoMax + 4
+	m.doc.Linea(centro-anchoMax/2, m.y, centro+anchoMax/2, m.y, 0.6)
+	m.y += 12
+	m.doc.TextoCentrado(centro, m.y, pdf.HelveticaNegrita, tamTextoPDF, "Firma del Solicitante")
+	if datos.FechaFirma != "" {
+		m.y += interlineadoPDF
+		m.doc.TextoCentrado(centro, m.y, pdf.Helvetica, 8, "Firmado electrónicamente el "+datos.FechaFirma)
+	}
+	m.y += interlineadoPDF
+	return nil
+}
+
+// verificacion dibuja el QR con la URL pública de verificación junto al código impreso
+func (m *maquetador) verificacion(datos *DatosContrato) error {
+	const ladoQR = 72.0
+	codigo, err := qr.Codificar(datos.URLVerificacion)
+	if err != nil {
+		return fmt.Errorf("error generando QR de verificación: %w", err)
+	}
+	idx, err := m.doc.AgregarImagen(codigo.Imagen(4))
+	if err != nil {
+		return err
+	}
+
+	m.asegurar(ladoQR + 8)
+	m.y += 8
+	m.doc.Imagen(idx, margenPDF, m.y, ladoQR, ladoQR)
+
+	x := margenPDF + ladoQR + 10
+	anchoTexto := m.anchoUtil() - ladoQR - 10
+	y := m.y + 24
+	m.doc.Texto(x, y, pdf.HelveticaNegrita, 10, "Código de verificación: "+datos.CodigoVerificacion)
+	for _, l := range pdf.DividirLineas(pdf.Helvetica, 8, "Verificá la autenticidad de este contrato escaneando el código QR o ingresando a "+datos.URLVerificacion, anchoTexto) {
+		y += 11
+		m.doc.Texto(x, y, pdf.Helvetica, 8, l)
+	}
+	m.y += ladoQR + 4
+	return nil
+}
+
+// anexoAuditoria agrega una página con la evidencia del proceso de firma electrónica
+func (m *maquetador) anexoAuditoria(datos *DatosContrato) {
+	a := datos.Auditoria
+	m.doc.NuevaPagina()
+	m.y = margenPDF
+
+	m.doc.Texto(margenPDF, m.y+14, pdf.HelveticaNegrita, 14, "Anexo de auditoría de firma")
+	m.y += 24
+	m.doc.Linea(margenPDF, m.y, m.doc.Ancho-margenPDF, m.y, 0.8)
+	m.y += 14
+	m.parrafo("El presente anexo forma parte de la Solicitud de Servicio Nº de Cuenta " + datos.NumeroCuenta + " y registra las evidencias del proceso de firma electrónica realizado por el Solicitante.")
+
+	m.seccion("Proceso de firma")
+	m.campo("Contrato:", datos.NumeroContrato)
+	m.campo("Proceso de firma:", fmt.Sprintf("%d", a.IDContratoFirma))
+	m.campo("Firmante:", datos.NombreCompleto)
+	m.campo("Método de firma:", a.MetodoFirma)
+	m.campo("Token validado el:", a.FechaValidacionToken)
+
+	m.seccion("Dispositivo del firmante")
+	m.campo("Dirección IP:", a.IP)
+	m.campo("User agent:", a.UserAgent)
+
+	m.seccion("Integridad del documento")
+	m.campo("Hash SHA-256 original:", a.HashOriginal)
+	if datos.CodigoVerificacion != "" {
+		m.campo("Código de verificación:", datos.CodigoVerificacion)
+	}
+	if a.FirmaDigitalEmpresa != "" {
+		m.campo("Firma digital:", "Documento firmado digitalmente por "+a.FirmaDigitalEmpresa+" (PAdES). La firma puede verificarse con cualquier lector de PDF compatible.")
+	}
+}
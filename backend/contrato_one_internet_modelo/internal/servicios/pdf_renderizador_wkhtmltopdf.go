@@ -0,0 +1,118 @@
+package servicios
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"contrato_one_internet_modelo/internal/utilidades/qr"
+)
+
+// RenderizadorWkhtmltopdf genera el PDF a partir de la plantilla HTML usando el binario wkhtmltopdf
+type RenderizadorWkhtmltopdf struct {
+	templatePath string
+	binario      string
+}
+
+// NewRenderizadorWkhtmltopdf verifica que el binario esté instalado
+func NewRenderizadorWkhtmltopdf(templatePath string) (*RenderizadorWkhtmltopdf, error) {
+	binario, err := exec.LookPath("wkhtmltopdf")
+	if err != nil {
+		return nil, fmt.Errorf("wkhtmltopdf no está instalado: %w", err)
+	}
+	if _, err := os.Stat(templatePath); err != nil {
+		return nil, fmt.Errorf("plantilla de contrato %s no disponible: %w", templatePath, err)
+	}
+	return &RenderizadorWkhtmltopdf{templatePath: templatePath, binario: binario}, nil
+}
+
+func (r *RenderizadorWkhtmltopdf) Nombre() string { return "wkhtmltopdf" }
+
+func (r *RenderizadorWkhtmltopdf) Renderizar(datos *DatosContrato, outputPath string) error {
+	if datos.MostrarFirma && datos.FirmaImagenPath != "" {
+		// wkhtmltopdf lee el HTML desde un temporal: la firma necesita ruta absoluta
+		if abs, err := filepath.Abs(datos.FirmaImagenPath); err == nil {
+			datos.FirmaSrcURL = template.URL(abs)
+		}
+	}
+	if datos.CodigoVerificacion != "" {
+		// El QR viaja embebido como data URI para no depender de archivos temporales
+		codigo, err := qr.Codificar(datos.URLVerificacion)
+		if err != nil {
+			return fmt.Errorf("error generando QR de verificación: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, codigo.Imagen(4)); err != nil {
+			return fmt.Errorf("error codificando QR de verificación: %w", err)
+		}
+		datos.QRSrcURL = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+
+	htmlContent, err := r.renderizarPlantilla(datos)
+	if err != nil {
+		return err
+	}
+	return r.htmlToPDF(htmlContent, outputPath)
+}
+
+// renderizarPlantilla procesa la plantilla HTML con los datos
+func (r *RenderizadorWkhtmltopdf) renderizarPlantilla(datos *DatosContrato) (string, error) {
+	tmpl, err := template.ParseFiles(r.templatePath)
+	if err != nil {
+		return "", fmt.Errorf("error parseando plantilla %s: %w", r.templatePath, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, datos); err != nil {
+		return "", fmt.Errorf("error ejecutando plantilla: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// htmlToPDF convierte HTML a PDF usando wkhtmltopdf
+func (r *RenderizadorWkhtmltopdf) htmlToPDF(htmlContent, outputPath string) error {
+	// Crear directorio si no existe
+	dir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creando directorio %s: %w", dir, err)
+	}
+
+	// Crear archivo temporal para el HTML
+	tmpHTML, err := os.CreateTemp("", "contract-*.html")
+	if err != nil {
+		return fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	defer os.Remove(tmpHTML.Name())
+
+	if _, err := tmpHTML.WriteString(htmlContent); err != nil {
+		return fmt.Errorf("error escribiendo HTML temporal: %w", err)
+	}
+	tmpHTML.Close()
+
+	// Ejecutar wkhtmltopdf
+	cmd := exec.Command(r.binario,
+		"--enable-local-file-access",
+		"--page-size", "A4",
+		"--margin-top", "10mm",
+		"--margin-bottom", "10mm",
+		"--margin-left", "15mm",
+		"--margin-right", "15mm",
+		tmpHTML.Name(),
+		outputPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error ejecutando wkhtmltopdf: %w (stderr: %s)", err, stderr.String())
+	}
+
+	return nil
+}
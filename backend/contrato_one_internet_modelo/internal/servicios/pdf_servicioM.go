@@ -10,12 +10,14 @@ import (
 	"html/template"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"contrato_one_internet_modelo/internal/modelos"
 	"contrato_one_internet_modelo/internal/repositorios"
 	"contrato_one_internet_modelo/internal/utilidades/logger"
+	"contrato_one_internet_modelo/internal/utilidades/pdf"
 )
 
 func min(a, b int) int {
@@ -26,19 +28,50 @@ func min(a, b int) int {
 }
 
 type PDFService struct {
-	db               *sql.DB
-	templatePath     string
+	fuente           FuenteDatosContrato
+	renderizador     RenderizadorPDF
+	firmaEmpresa     *FirmaEmpresa
 	contractBasePath string
+	urlVerificacion  string // URL pública base de verificación; se le agrega el código
 }
 
-func NewPDFService(db *sql.DB, templatePath, contractBasePath string) *PDFService {
+// FirmaEmpresa es el certificado con el que la empresa firma digitalmente (PAdES) los
+// contratos ya firmados por el cliente. Con nil los PDF se generan sin firma digital.
+type FirmaEmpresa struct {
+	Firmante  *pdf.Firmante
+	Razon     string
+	Ubicacion string
+}
+
+// FuenteDatosContrato provee los datos con los que se arma el PDF de un contrato
+type FuenteDatosContrato interface {
+	// DatosContrato devuelve los datos del titular, los domicilios, el plan y la empresa
+	DatosContrato(ctx context.Context, idContrato int) (*DatosContrato, error)
+	// ContratoFirma devuelve el proceso de firma del que sale el PDF firmado
+	ContratoFirma(ctx context.Context, idContratoFirma int) (*modelos.ContratoFirma, error)
+}
+
+func NewPDFService(fuente FuenteDatosContrato, renderizador RenderizadorPDF, firmaEmpresa *FirmaEmpresa, contractBasePath, urlVerificacion string) *PDFService {
 	return &PDFService{
-		db:               db,
-		templatePath:     templatePath,
+		fuente:           fuente,
+		renderizador:     renderizador,
+		firmaEmpresa:     firmaEmpresa,
 		contractBasePath: contractBasePath,
+		urlVerificacion:  strings.TrimRight(urlVerificacion, "/"),
 	}
 }
 
+// AuditoriaFirma son los datos del proceso de firma que se listan en el anexo de auditoría
+type AuditoriaFirma struct {
+	IDContratoFirma      int
+	MetodoFirma          string
+	IP                   string
+	UserAgent            string
+	FechaValidacionToken string
+	HashOriginal         string
+	FirmaDigitalEmpresa  string // Titular del certificado de la firma digital, vacío si no se firma
+}
+
 // DatosContrato estructura para pasar datos a la plantilla
 type DatosContrato struct {
 	// Persona
@@ -97,114 +130,180 @@ type DatosContrato struct {
 	FirmaImagenPath string
 	MostrarFirma   bool
 	FechaFirma     string
+	Auditoria      *AuditoriaFirma // Anexo de auditoría (sólo PDF firmado)
+
+	// Verificación pública (código y QR impresos en el contrato)
+	CodigoVerificacion string
+	URLVerificacion    string
+	QRSrcURL           template.URL // data URI del QR (sólo wkhtmltopdf)
 	
 	// Fecha de generación del contrato
 	FechaGeneracion string
 }
 
-// GenerarPDFOriginal genera el PDF original del contrato sin firma
-func (s *PDFService) GenerarPDFOriginal(ctx context.Context, idContrato int) (pdfPath, hashSHA256 string, err error) {
+// GenerarPDFOriginal genera el PDF original del contrato sin firma. El nombre del archivo
+// lleva el código de verificación: un intento que no llega a registrarse no pisa el PDF de
+// otro.
+func (s *PDFService) GenerarPDFOriginal(ctx context.Context, idContrato int, codigoVerificacion string) (pdfPath, hashSHA256 string, err error) {
 	// 1. Obtener datos del contrato
-	datos, err := s.obtenerDatosContrato(ctx, idContrato)
+	datos, err := s.fuente.DatosContrato(ctx, idContrato)
 	if err != nil {
 		logger.Error.Printf("Error obteniendo datos del contrato %d: %v", idContrato, err)
 		return "", "", fmt.Errorf("error obteniendo datos del contrato: %w", err)
 	}
 
 	datos.MostrarFirma = false
+	s.agregarVerificacion(datos, codigoVerificacion)
 
-	// 2. Generar HTML desde plantilla
-	htmlContent, err := s.renderizarPlantilla(datos)
+	// 2. Renderizar PDF y calcular hash SHA-256
+	pdfPath = filepath.Join(s.contractBasePath, "original", fmt.Sprintf("%d-%s.pdf", idContrato, codigoVerificacion))
+	hashSHA256, err = s.generarArchivo(datos, pdfPath)
 	if err != nil {
-		logger.Error.Printf("Error renderizando plantilla: %v", err)
-		return "", "", fmt.Errorf("error renderizando plantilla: %w", err)
-	}
-
-	// 3. Convertir HTML a PDF
-	pdfPath = filepath.Join(s.contractBasePath, "original", fmt.Sprintf("%d.pdf", idContrato))
-	if err := s.htmlToPDF(htmlContent, pdfPath); err != nil {
 		logger.Error.Printf("Error generando PDF: %v", err)
-		return "", "", fmt.Errorf("error generando PDF: %w", err)
-	}
-
-	// 4. Calcular hash SHA-256
-	hashSHA256, err = s.calcularHashArchivo(pdfPath)
-	if err != nil {
-		logger.Error.Printf("Error calculando hash: %v", err)
-		return "", "", fmt.Errorf("error calculando hash: %w", err)
+		return "", "", err
 	}
 
 	logger.Info.Printf("PDF original generado: %s (hash: %s)", pdfPath, hashSHA256)
 	return pdfPath, hashSHA256, nil
 }
 
-// GenerarPDFConFirma genera el PDF final con la firma embebida
-func (s *PDFService) GenerarPDFConFirma(ctx context.Context, idContratoFirma int, firmaBase64 string) (pdfPath, hashSHA256 string, err error) {
+// GenerarPDFConFirma genera el PDF final con la firma del cliente, el anexo de auditoría
+// y, si hay certificado configurado, la firma digital PAdES de la empresa.
+func (s *PDFService) GenerarPDFConFirma(ctx context.Context, idContratoFirma int, validacionToken time.Time) (pdfPath, hashSHA256 string, err error) {
 	// 1. Obtener contrato_firma
-	cfRepo := repositorios.NewContratoFirmaRepo(s.db)
-	cf, err := cfRepo.ObtenerPorID(ctx, idContratoFirma)
+	cf, err := s.fuente.ContratoFirma(ctx, idContratoFirma)
 	if err != nil {
 		return "", "", fmt.Errorf("error obteniendo contrato_firma: %w", err)
 	}
 
 	// 2. Obtener datos del contrato
-	datos, err := s.obtenerDatosContrato(ctx, cf.IDContrato)
+	datos, err := s.fuente.DatosContrato(ctx, cf.IDContrato)
 	if err != nil {
 		return "", "", fmt.Errorf("error obteniendo datos del contrato: %w", err)
 	}
 
 	// 3. Agregar firma y fecha
 	datos.MostrarFirma = true
-	// Copiar firma a ubicación accesible para wkhtmltopdf
 	if cf.FirmaPath != nil && *cf.FirmaPath != "" {
-		// Copiar firma al directorio firmado con nombre temporal
-		firmaTempPath := filepath.Join(s.contractBasePath, "firmado", fmt.Sprintf("%d_firma.png", idContratoFirma))
-		firmaBytes, err := os.ReadFile(*cf.FirmaPath)
-		if err == nil {
-			if err := os.WriteFile(firmaTempPath, firmaBytes, 0644); err == nil {
-				// Usar ruta absoluta para wkhtmltopdf
-				datos.FirmaSrcURL = template.URL(firmaTempPath)
-			} else {
-				logger.Error.Printf("ERROR: No se pudo copiar la firma: %v", err)
-			}
-		} else {
-			logger.Error.Printf("ERROR: No se pudo leer la firma: %v", err)
-		}
+		datos.FirmaImagenPath = *cf.FirmaPath
+	} else {
+		logger.Error.Printf("ERROR: contrato_firma %d sin imagen de firma", idContratoFirma)
 	}
-	if cf.FechaFirma != nil {
-		datos.FechaFirma = cf.FechaFirma.Format("02/01/2006 15:04")
+	datos.FechaFirma = validacionToken.Format("02/01/2006 15:04")
+	if cf.CodigoVerificacion != nil {
+		s.agregarVerificacion(datos, *cf.CodigoVerificacion)
 	}
 
-	// 4. Generar HTML con firma
-	htmlContent, err := s.renderizarPlantilla(datos)
-	if err != nil {
-		return "", "", fmt.Errorf("error renderizando plantilla con firma: %w", err)
+	// 4. Anexo de auditoría
+	auditoria := &AuditoriaFirma{
+		IDContratoFirma:      idContratoFirma,
+		MetodoFirma:          cf.MetodoFirma,
+		FechaValidacionToken: validacionToken.Format("02/01/2006 15:04:05 -07:00"),
+		HashOriginal:         cf.HashOriginal,
 	}
+	if cf.IpFirma != nil {
+		auditoria.IP = *cf.IpFirma
+	}
+	if cf.UserAgent != nil {
+		auditoria.UserAgent = *cf.UserAgent
+	}
+	if s.firmaEmpresa != nil {
+		auditoria.FirmaDigitalEmpresa = s.firmaEmpresa.Firmante.Certificado().Subject.CommonName
+	}
+	datos.Auditoria = auditoria
 
-	// 5. Convertir HTML a PDF
+	// 5. Renderizar PDF firmado
 	pdfPath = filepath.Join(s.contractBasePath, "firmado", fmt.Sprintf("%d.pdf", idContratoFirma))
-	if err := s.htmlToPDF(htmlContent, pdfPath); err != nil {
-		return "", "", fmt.Errorf("error generando PDF firmado: %w", err)
+	if err := s.renderizador.Renderizar(datos, pdfPath); err != nil {
+		return "", "", fmt.Errorf("error generando PDF firmado (%s): %w", s.renderizador.Nombre(), err)
 	}
 
-	// Limpiar archivos temporales
-	firmaTempPath := filepath.Join(s.contractBasePath, "firmado", fmt.Sprintf("%d_firma.png", idContratoFirma))
-	os.Remove(firmaTempPath)
-	htmlTempPath := filepath.Join(s.contractBasePath, "firmado", fmt.Sprintf("%d.html", idContratoFirma))
-	os.Remove(htmlTempPath)
+	// 6. Firma digital de la empresa sobre el documento final
+	if s.firmaEmpresa != nil {
+		if err := s.firmarDigitalmente(pdfPath, validacionToken); err != nil {
+			return "", "", fmt.Errorf("error firmando digitalmente el PDF: %w", err)
+		}
+	}
 
-	// 6. Calcular hash
+	// 7. Hash del archivo tal como queda guardado
 	hashSHA256, err = s.calcularHashArchivo(pdfPath)
 	if err != nil {
-		return "", "", fmt.Errorf("error calculando hash del PDF firmado: %w", err)
+		return "", "", fmt.Errorf("error calculando hash: %w", err)
 	}
 
 	logger.Info.Printf("PDF firmado generado: %s (hash: %s)", pdfPath, hashSHA256)
 	return pdfPath, hashSHA256, nil
 }
 
-// obtenerDatosContrato consulta todos los datos necesarios para el contrato
-func (s *PDFService) obtenerDatosContrato(ctx context.Context, idContrato int) (*DatosContrato, error) {
+// agregarVerificacion completa el código y la URL pública de verificación del contrato
+func (s *PDFService) agregarVerificacion(datos *DatosContrato, codigo string) {
+	if codigo == "" {
+		return
+	}
+	datos.CodigoVerificacion = codigo
+	datos.URLVerificacion = s.urlVerificacion + "/" + codigo
+}
+
+// firmarDigitalmente reemplaza el PDF por su versión con firma CMS embebida
+func (s *PDFService) firmarDigitalmente(pdfPath string, fecha time.Time) error {
+	original, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return err
+	}
+
+	firmado, err := s.firmaEmpresa.Firmante.FirmarPDF(original, pdf.DatosFirma{
+		Nombre:    s.firmaEmpresa.Firmante.Certificado().Subject.CommonName,
+		Razon:     s.firmaEmpresa.Razon,
+		Ubicacion: s.firmaEmpresa.Ubicacion,
+		Fecha:     fecha,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Escribir en un temporal y renombrar para no dejar un PDF a medio firmar
+	tmp := pdfPath + ".tmp"
+	if err := os.WriteFile(tmp, firmado, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, pdfPath)
+}
+
+// VerificarFirmaDigital valida la firma digital embebida en el PDF indicado
+func (s *PDFService) VerificarFirmaDigital(pdfPath string) (*pdf.FirmaVerificada, error) {
+	datos, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo PDF: %w", err)
+	}
+	return pdf.VerificarPDF(datos)
+}
+
+// CertificadoEmpresaHabilitado indica si hay un certificado configurado para firmar
+func (s *PDFService) CertificadoEmpresaHabilitado() bool {
+	return s.firmaEmpresa != nil
+}
+
+// EsCertificadoEmpresa indica si raw es el certificado configurado para firmar
+func (s *PDFService) EsCertificadoEmpresa(raw []byte) bool {
+	return s.firmaEmpresa != nil && bytes.Equal(s.firmaEmpresa.Firmante.Certificado().Raw, raw)
+}
+
+// FuenteDatosContratoBD lee los datos del contrato de la base
+type FuenteDatosContratoBD struct {
+	db *sql.DB
+}
+
+func NewFuenteDatosContratoBD(db *sql.DB) *FuenteDatosContratoBD {
+	return &FuenteDatosContratoBD{db: db}
+}
+
+// ContratoFirma obtiene el contrato_firma por ID
+func (f *FuenteDatosContratoBD) ContratoFirma(ctx context.Context, idContratoFirma int) (*modelos.ContratoFirma, error) {
+	return repositorios.NewContratoFirmaRepo(f.db).ObtenerPorID(ctx, idContratoFirma)
+}
+
+// DatosContrato consulta todos los datos necesarios para el contrato
+func (f *FuenteDatosContratoBD) DatosContrato(ctx context.Context, idContrato int) (*DatosContrato, error) {
 	query := `
 		SELECT 
 			p.nombre, p.apellido, p.dni, p.cuil, p.email, p.telefono, p.fecha_nacimiento,
@@ -221,7 +320,7 @@ func (s *PDFService) obtenerDatosContrato(ctx context.Context, idContrato int) (
 			dept_conexion.nombre AS conexion_departamento,
 			prov_conexion.nombre AS conexion_provincia,
 			c.id_contrato, c.fecha_inicio, c.costo_instalacion,
-			pl.nombre AS plan_nombre, pl.velocidad_mbps, pl.precio,
+			pl.nombre AS plan_nombre, pl.velocidad_mbps, COALESCE(c.precio_plan, pl.precio),
 			e.razon_social AS empresa_nombre, e.cuit AS empresa_cuit
 		FROM contrato c
 		INNER JOIN persona p ON c.id_persona = p.id_persona
@@ -249,7 +348,7 @@ func (s *PDFService) obtenerDatosContrato(ctx context.Context, idContrato int) (
 	var costoInstalacion sql.NullFloat64
 	var conexionCalle, conexionNumero, conexionCP, conexionDistrito, conexionDepartamento, conexionProvincia string
 
-	err := s.db.QueryRowContext(ctx, query, idContrato).Scan(
+	err := f.db.QueryRowContext(ctx, query, idContrato).Scan(
 		&nombre, &apellido, &datos.DNI, &cuil, &datos.Email, &datos.Telefono, &fechaNacimiento,
 		&personaCalle, &personaNumero, &personaPiso, &personaDepto, &personaCP,
 		&personaDistrito, &personaDepartamento, &personaProvincia,
@@ -355,19 +454,17 @@ func (s *PDFService) obtenerDatosContrato(ctx context.Context, idContrato int) (
 	return datos, nil
 }
 
-// renderizarPlantilla procesa la plantilla HTML con los datos
-func (s *PDFService) renderizarPlantilla(datos *DatosContrato) (string, error) {
-	tmpl, err := template.ParseFiles(s.templatePath)
-	if err != nil {
-		return "", fmt.Errorf("error parseando plantilla %s: %w", s.templatePath, err)
+// generarArchivo renderiza el contrato en pdfPath y devuelve el SHA-256 del archivo generado
+func (s *PDFService) generarArchivo(datos *DatosContrato, pdfPath string) (string, error) {
+	if err := s.renderizador.Renderizar(datos, pdfPath); err != nil {
+		return "", fmt.Errorf("error generando PDF (%s): %w", s.renderizador.Nombre(), err)
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, datos); err != nil {
-		return "", fmt.Errorf("error ejecutando plantilla: %w", err)
+	hashSHA256, err := s.calcularHashArchivo(pdfPath)
+	if err != nil {
+		return "", fmt.Errorf("error calculando hash: %w", err)
 	}
-
-	return buf.String(), nil
+	return hashSHA256, nil
 }
 
 // generarFechaEspanol convierte una fecha a formato español legible
@@ -384,48 +481,6 @@ func generarFechaEspanol(t time.Time) string {
 	return fmt.Sprintf("%d de %s de %d", dia, mes, anio)
 }
 
-// htmlToPDF convierte HTML a PDF usando wkhtmltopdf
-func (s *PDFService) htmlToPDF(htmlContent, outputPath string) error {
-	// Crear directorio si no existe
-	dir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("error creando directorio %s: %w", dir, err)
-	}
-
-	// Crear archivo temporal para el HTML
-	tmpHTML, err := os.CreateTemp("", "contract-*.html")
-	if err != nil {
-		return fmt.Errorf("error creando archivo temporal: %w", err)
-	}
-	defer os.Remove(tmpHTML.Name())
-
-	if _, err := tmpHTML.WriteString(htmlContent); err != nil {
-		return fmt.Errorf("error escribiendo HTML temporal: %w", err)
-	}
-	tmpHTML.Close()
-
-	// Ejecutar wkhtmltopdf
-	cmd := exec.Command("wkhtmltopdf",
-		"--enable-local-file-access",
-		"--page-size", "A4",
-		"--margin-top", "10mm",
-		"--margin-bottom", "10mm",
-		"--margin-left", "15mm",
-		"--margin-right", "15mm",
-		tmpHTML.Name(),
-		outputPath,
-	)
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error ejecutando wkhtmltopdf: %w (stderr: %s)", err, stderr.String())
-	}
-
-	return nil
-}
-
 // calcularHashArchivo calcula el SHA-256 de un archivo
 func (s *PDFService) calcularHashArchivo(filePath string) (string, error) {
 	file, err := os.Open(filePath)
@@ -0,0 +1,178 @@
+package servicios
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"contrato_one_internet_modelo/internal/modelos"
+)
+
+// fuenteDatosFija devuelve siempre los mismos datos de contrato
+type fuenteDatosFija struct {
+	datos DatosContrato
+}
+
+func (f fuenteDatosFija) DatosContrato(ctx context.Context, idContrato int) (*DatosContrato, error) {
+	d := f.datos
+	return &d, nil
+}
+
+func (f fuenteDatosFija) ContratoFirma(ctx context.Context, idContratoFirma int) (*modelos.ContratoFirma, error) {
+	return &modelos.ContratoFirma{IDContratoFirma: idContratoFirma, IDContrato: 55, MetodoFirma: "canvas+token"}, nil
+}
+
+func datosContratoPrueba() DatosContrato {
+	return DatosContrato{
+		NombreCompleto:          "María Gómez",
+		DNI:                     "30111222",
+		CUIL:                    "27301112224",
+		Email:                   "maria@example.com",
+		Telefono:                "2634000000",
+		FechaNacimiento:         "01/02/1985",
+		CondicionIVA:            "Consumidor Final",
+		DomLegalCalle:           "San Martín",
+		DomLegalNumero:          "120",
+		DomLegalPiso:            "0",
+		DomLegalCP:              "5570",
+		DomLegalLocalidad:       "San Martín",
+		DomInstalacionCalle:     "Belgrano",
+		DomInstalacionNumero:    "45",
+		DomInstalacionPiso:      "0",
+		DomInstalacionCP:        "5570",
+		DomInstalacionLocalidad: "Palmira",
+		NumeroContrato:          "55",
+		PlanNombre:              "Hogar 100",
+		PlanVelocidad:           "100 Mbps",
+		PrecioMensual:           "15000.00",
+		CostoAlta:               "30000.00",
+		NumeroCuenta:            "2026-55",
+		FechaGeneracion:         "18 de octubre de 2026",
+	}
+}
+
+// textosPDF devuelve, en orden, los strings escritos con Tj en los content streams
+func textosPDF(t *testing.T, data []byte) []string {
+	t.Helper()
+	var textos []string
+	for {
+		i := bytes.Index(data, []byte("stream\n"))
+		if i < 0 {
+			return textos
+		}
+		data = data[i+len("stream\n"):]
+		fin := bytes.Index(data, []byte("\nendstream"))
+		if fin < 0 {
+			t.Fatal("stream sin endstream")
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[:fin]))
+		if err != nil {
+			t.Fatalf("stream no comprimido con zlib: %v", err)
+		}
+		contenido, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("error descomprimiendo stream: %v", err)
+		}
+		textos = append(textos, extraerTj(string(contenido))...)
+		data = data[fin+len("\nendstream"):]
+	}
+}
+
+// extraerTj lee los literales "(...) Tj" de un content stream (WinAnsi, sin escapes octales)
+func extraerTj(contenido string) []string {
+	var textos []string
+	for {
+		i := strings.Index(contenido, "(")
+		if i < 0 {
+			return textos
+		}
+		var sb strings.Builder
+		j := i + 1
+		for ; j < len(contenido) && contenido[j] != ')'; j++ {
+			if contenido[j] == '\\' {
+				j++
+			}
+			sb.WriteRune(rune(contenido[j])) // WinAnsi coincide con Latin-1 en los acentos
+		}
+		contenido = contenido[j+1:]
+		if strings.HasPrefix(contenido, " Tj") {
+			textos = append(textos, sb.String())
+		}
+	}
+}
+
+// valorCampo devuelve el texto que sigue a la etiqueta en el orden de escritura
+func valorCampo(textos []string, etiqueta string, desde int) (string, int) {
+	for i := desde; i < len(textos)-1; i++ {
+		if textos[i] == etiqueta {
+			return textos[i+1], i + 1
+		}
+	}
+	return "", -1
+}
+
+func TestGenerarPDFOriginal(t *testing.T) {
+	dir := t.TempDir()
+	s := NewPDFService(fuenteDatosFija{datos: datosContratoPrueba()}, NewRenderizadorNativo(), nil, dir, "https://oneinternet.example/verificar/")
+
+	ruta, hash, err := s.GenerarPDFOriginal(context.Background(), 55, "ABCD-EFGH")
+	if err != nil {
+		t.Fatalf("GenerarPDFOriginal: %v", err)
+	}
+	if len(hash) != 64 {
+		t.Errorf("hash = %q, se esperaba SHA-256 en hex", hash)
+	}
+	data, err := os.ReadFile(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) {
+		t.Fatalf("el archivo no es un PDF")
+	}
+
+	textos := textosPDF(t, data)
+	todo := strings.Join(textos, "\n")
+	for _, esperado := range []string{
+		"Solicitud de Servicio",
+		"18 de octubre de 2026",
+		"Nº de Cuenta: 2026-55",
+		"Código de verificación: ABCD-EFGH",
+		"Firma del Solicitante",
+	} {
+		if !strings.Contains(todo, esperado) {
+			t.Errorf("falta el texto %q en el PDF", esperado)
+		}
+	}
+	if strings.Contains(todo, "Firmado electrónicamente") {
+		t.Error("el PDF original no debe indicar fecha de firma")
+	}
+
+	// Los campos aparecen en orden de sección: la segunda "Calle:" es la de instalación
+	campos := []struct{ etiqueta, valor string }{
+		{"Apellido y Nombres - Razón Social:", "María Gómez"},
+		{"DNI:", "30111222"},
+		{"CUIL:", "27301112224"},
+		{"Calle:", "San Martín"},
+		{"Localidad:", "San Martín - Mendoza"},
+		{"Calle:", "Belgrano"},
+		{"Localidad:", "Palmira - Mendoza"},
+		{"Denominación Comercial:", "Hogar 100"},
+		{"Velocidad de descarga:", "100 Mbps"},
+		{"Costo abono mensual:", "$ 15000.00 (Pago mes adelantado)."},
+	}
+	pos := 0
+	for _, c := range campos {
+		valor, p := valorCampo(textos, c.etiqueta, pos)
+		if p < 0 {
+			t.Fatalf("no se encontró el campo %q después de la posición %d", c.etiqueta, pos)
+		}
+		if valor != c.valor {
+			t.Errorf("%s = %q, se esperaba %q", c.etiqueta, valor, c.valor)
+		}
+		pos = p
+	}
+}
@@ -12,7 +12,8 @@ import (
 	"contrato_one_internet_modelo/internal/utilidades/logger"
 )
 
-var permisoNombreRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+// Los nombres pueden agruparse por recurso con puntos: "conexion.confirmar_factibilidad"
+var permisoNombreRegexp = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
 
 type PermisoService struct {
 	repo *repositorios.PermisoRepo
@@ -121,7 +121,7 @@ func (s *UsuarioService) CrearPersonaYUsuario(ctx context.Context, persona model
 
 	// 4.1 Asignar rol "cliente"
 	usuarioRolRepo := repositorios.NewUsuarioRolRepo(tx)
-	if err := usuarioRolRepo.AsignarRol(ctx, idUsuario, "cliente"); err != nil {
+	if err := usuarioRolRepo.AsignarRol(ctx, idUsuario, modelos.RolCliente); err != nil {
 		logger.Error.Printf("Error al asignar rol cliente: %v", err)
 		return nil, fmt.Errorf("no se pudo asignar el rol cliente: %w", err)
 	}
@@ -744,6 +744,8 @@ func (s *UsuarioService) VerificarTokenEmail(ctx context.Context, token string)
 	}()
 
 	var (
+		idToken         int
+		tokenHash       string
 		idUsuario       int
 		expiracion      time.Time
 		usado           bool
@@ -751,13 +753,16 @@ func (s *UsuarioService) VerificarTokenEmail(ctx context.Context, token string)
 		emailVerificado bool
 	)
 
-	// Obtener token + datos necesarios del usuario
+	// Obtener token + datos necesarios del usuario (la tabla guarda el hash del token)
 	err = tx.QueryRowContext(ctx, `
-		SELECT evt.id_usuario, evt.expiracion, evt.usado, u.requiere_verificacion, u.email_verificado
+		SELECT evt.id, evt.token_hash, evt.id_usuario, evt.expiracion, evt.usado, u.requiere_verificacion, u.email_verificado
 		FROM email_verificacion_token evt
 		JOIN usuario u ON evt.id_usuario = u.id_usuario
-		WHERE evt.token = ?
-	`, token).Scan(&idUsuario, &expiracion, &usado, &requiereVerif, &emailVerificado)
+		WHERE evt.token_hash = ?
+	`, utilidades.HashToken(token)).Scan(&idToken, &tokenHash, &idUsuario, &expiracion, &usado, &requiereVerif, &emailVerificado)
+	if err == nil && !utilidades.TokenCoincide(token, tokenHash) {
+		err = sql.ErrNoRows
+	}
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -776,13 +781,13 @@ func (s *UsuarioService) VerificarTokenEmail(ctx context.Context, token string)
 
 	// 2 - Token expirado
 	if time.Now().After(expiracion) {
-		logger.Warn.Printf("Token expirado: %s", token)
+		logger.Warn.Printf("Token de verificación %d expirado", idToken)
 		return utilidades.ErrTokenExpirado
 	}
 
 	// 3 - Token usado (solo importa si el usuario no está verificado)
 	if usado {
-		logger.Warn.Printf("Token ya fue utilizado: %s", token)
+		logger.Warn.Printf("Token de verificación %d ya fue utilizado", idToken)
 		return utilidades.ErrTokenUsado
 	}
 
@@ -807,8 +812,8 @@ func (s *UsuarioService) VerificarTokenEmail(ctx context.Context, token string)
 	_, err = tx.ExecContext(ctx, `
 		UPDATE email_verificacion_token
 		SET usado = 1
-		WHERE token = ?
-	`, token)
+		WHERE id = ?
+	`, idToken)
 	if err != nil {
 		logger.Error.Printf("Error al marcar token como usado: %v", err)
 		return utilidades.TraducirErrorBD(err)
@@ -886,7 +891,7 @@ func (s *UsuarioService) ResendVerificationToken(ctx context.Context, email stri
 		return "", time.Time{}, err
 	}
 
-	logger.Debug.Printf("Token generado para usuario %d: %s, expiración: %v", usuario.IDUsuario, tokenStr, expiracion)
+	logger.Debug.Printf("Token generado para usuario %d, expiración: %v", usuario.IDUsuario, expiracion)
 	return tokenStr, expiracion, nil
 }
 
@@ -933,7 +938,7 @@ func (s *UsuarioService) SolicitarResetPassword(ctx context.Context, email strin
 
 	expiracion := time.Now().Add(1 * time.Hour)
 	// Insertar token
-	_, err = tx.ExecContext(ctx, `INSERT INTO reset_password_token (id_usuario, token, expiracion, creado, usado) VALUES (?, ?, ?, ?, 0)`, idUsuario, tokenStr, expiracion, time.Now())
+	_, err = tx.ExecContext(ctx, `INSERT INTO reset_password_token (id_usuario, token_hash, expiracion, creado, usado) VALUES (?, ?, ?, ?, 0)`, idUsuario, utilidades.HashToken(tokenStr), expiracion, time.Now())
 	if err != nil {
 		logger.Error.Printf("Error al insertar token en SolicitarResetPassword: %v", err)
 		return "", time.Time{}, err
@@ -968,7 +973,7 @@ func (s *UsuarioService) EmailDisponible(ctx context.Context, email string) (boo
 
 // CambiarPasswordConToken verifica el token de reseteo y actualiza la contraseña.
 func (s *UsuarioService) CambiarPasswordConToken(ctx context.Context, token, nuevaPassword string) error {
-	logger.Debug.Printf("Entrando a CambiarPasswordConToken con token: %s", token)
+	logger.Debug.Printf("Entrando a CambiarPasswordConToken")
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -977,20 +982,25 @@ func (s *UsuarioService) CambiarPasswordConToken(ctx context.Context, token, nue
 	}
 	defer tx.Rollback()
 
-	var idUsuario int
+	var idToken, idUsuario int
+	var tokenHash string
 	var expiracion time.Time
 	var usado bool
-	err = tx.QueryRowContext(ctx, `SELECT id_usuario, expiracion, usado FROM reset_password_token WHERE token = ?`, token).Scan(&idUsuario, &expiracion, &usado)
+	err = tx.QueryRowContext(ctx, `SELECT id, token_hash, id_usuario, expiracion, usado FROM reset_password_token WHERE token_hash = ?`,
+		utilidades.HashToken(token)).Scan(&idToken, &tokenHash, &idUsuario, &expiracion, &usado)
+	if err == nil && !utilidades.TokenCoincide(token, tokenHash) {
+		err = sql.ErrNoRows
+	}
 	if err != nil {
 		logger.Error.Printf("Error al buscar token en CambiarPasswordConToken: %v", err)
 		return errors.New("token inválido o no encontrado")
 	}
 	if usado {
-		logger.Warn.Printf("Token ya fue usado en CambiarPasswordConToken: %s", token)
+		logger.Warn.Printf("Token de reseteo %d ya fue usado en CambiarPasswordConToken", idToken)
 		return errors.New("token ya fue usado")
 	}
 	if time.Now().After(expiracion) {
-		logger.Warn.Printf("Token expirado en CambiarPasswordConToken: %s", token)
+		logger.Warn.Printf("Token de reseteo %d expirado en CambiarPasswordConToken", idToken)
 		return errors.New("token expirado")
 	}
 
@@ -1020,7 +1030,7 @@ func (s *UsuarioService) CambiarPasswordConToken(ctx context.Context, token, nue
 	}
 
 	// Marcar token como usado y actualizar password
-	if _, err := tx.ExecContext(ctx, `UPDATE reset_password_token SET usado = 1 WHERE token = ?`, token); err != nil {
+	if _, err := tx.ExecContext(ctx, `UPDATE reset_password_token SET usado = 1 WHERE id = ?`, idToken); err != nil {
 		logger.Error.Printf("Error al marcar token como usado en CambiarPasswordConToken: %v", err)
 		return err
 	}
@@ -7,9 +7,14 @@ import (
     "strings"
 
     "contrato_one_internet_modelo/internal/modelos"
+    "contrato_one_internet_modelo/internal/repositorios"
     "contrato_one_internet_modelo/internal/utilidades"
 )
 
+// precioVigentePlan es el precio del plan que rige hoy (plan_precio); plan.precio es el de lista
+// inicial y queda como respaldo
+const precioVigentePlan = `COALESCE((SELECT pp.precio FROM plan_precio pp WHERE pp.id_plan = plan.id_plan AND pp.fecha_inicio <= CURDATE() ORDER BY pp.fecha_inicio DESC LIMIT 1), plan.precio)`
+
 // PlanService encapsula consultas a tipo_plan y plan.
 type PlanService struct {
     db *sql.DB
@@ -43,8 +48,8 @@ func (s *PlanService) ObtenerTipoPlanes(ctx context.Context) ([]modelos.TipoPlan
 
 // Filtros soportados: id_tipo_plan (int), min_velocidad (int), max_precio (decimal)
 func (s *PlanService) ObtenerPlanes(ctx context.Context, idTipoPlan *int, minVel *int, maxPrecio *float64) ([]modelos.Plan, error) {
-    // Base query: active plans
-    q := `SELECT id_plan, id_tipo_plan, nombre, velocidad_mbps, precio, descripcion FROM plan WHERE (borrado IS NULL) AND (fecha_fin IS NULL OR fecha_fin >= CURDATE())` 
+    // Base query: active plans, con el precio que rige hoy
+    q := `SELECT id_plan, id_tipo_plan, nombre, velocidad_mbps, ` + precioVigentePlan + `, descripcion FROM plan WHERE (borrado IS NULL) AND (fecha_inicio IS NULL OR fecha_inicio <= CURDATE()) AND (fecha_fin IS NULL OR fecha_fin >= CURDATE())`
     args := []interface{}{}
 
     if idTipoPlan != nil {
@@ -56,7 +61,7 @@ func (s *PlanService) ObtenerPlanes(ctx context.Context, idTipoPlan *int, minVel
         args = append(args, *minVel)
     }
     if maxPrecio != nil {
-        q += " AND precio <= ?"
+        q += " AND " + precioVigentePlan + " <= ?"
         args = append(args, *maxPrecio)
     }
 
@@ -84,17 +89,28 @@ func (s *PlanService) ObtenerPlanes(ctx context.Context, idTipoPlan *int, minVel
 
 func (s *PlanService) ObtenerPlanPorID(ctx context.Context, id int) (*modelos.Plan, error) {
     var p modelos.Plan
-    err := s.db.QueryRowContext(ctx, `SELECT id_plan, id_tipo_plan, nombre, velocidad_mbps, precio, descripcion FROM plan WHERE id_plan = ? AND (borrado IS NULL) LIMIT 1`, id).Scan(&p.IDPlan, &p.IDTipoPlan, &p.Nombre, &p.VelocidadMbps, &p.Precio, &p.Descripcion)
+    err := s.db.QueryRowContext(ctx, `SELECT id_plan, id_tipo_plan, nombre, velocidad_mbps, `+precioVigentePlan+`, descripcion FROM plan WHERE id_plan = ? AND (borrado IS NULL) LIMIT 1`, id).Scan(&p.IDPlan, &p.IDTipoPlan, &p.Nombre, &p.VelocidadMbps, &p.Precio, &p.Descripcion)
     if err != nil {
         if err == sql.ErrNoRows {
             return nil, utilidades.ErrNoEncontrado
         }
         return nil, utilidades.TraducirErrorBD(err)
     }
+
+    // Precio ya anunciado que todavía no rige
+    manana := hoyArgentina().AddDate(0, 0, 1).Format("2006-01-02")
+    proximo, err := repositorios.NewPrecioPlanRepo(s.db).ProximoPrecio(ctx, id, manana)
+    if err != nil {
+        return nil, err
+    }
+    if proximo != nil {
+        p.PrecioProximo = &proximo.Precio
+        p.PrecioProximoDesde = &proximo.FechaInicio
+    }
     return &p, nil
 }
 
-// CrearPlan inserta un nuevo plan y retorna su id.
+// CrearPlan inserta un nuevo plan con su primer precio y retorna su id.
 func (s *PlanService) CrearPlan(ctx context.Context, p modelos.Plan) (int64, error) {
     // Validar id_tipo_plan existe
     var cnt int
@@ -105,8 +121,19 @@ func (s *PlanService) CrearPlan(ctx context.Context, p modelos.Plan) (int64, err
         return 0, utilidades.ErrNoEncontrado
     }
 
+    fechaInicio := hoyArgentina().Format("2006-01-02")
+    if p.FechaInicio != nil {
+        fechaInicio = *p.FechaInicio
+    }
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, fmt.Errorf("error iniciando transacción: %w", err)
+    }
+    defer tx.Rollback()
+
     // Insertar
-    res, err := s.db.ExecContext(ctx, `INSERT INTO plan (id_tipo_plan, nombre, velocidad_mbps, precio, descripcion, id_usuario_creador, borrado, fecha_inicio) VALUES (?, ?, ?, ?, ?, ?, NULL, NOW())`, p.IDTipoPlan, p.Nombre, p.VelocidadMbps, p.Precio, p.Descripcion, p.IDUsuarioCreador)
+    res, err := tx.ExecContext(ctx, `INSERT INTO plan (id_tipo_plan, nombre, velocidad_mbps, precio, descripcion, id_usuario_creador, borrado, fecha_inicio, fecha_fin) VALUES (?, ?, ?, ?, ?, ?, NULL, ?, ?)`, p.IDTipoPlan, p.Nombre, p.VelocidadMbps, p.Precio, p.Descripcion, p.IDUsuarioCreador, fechaInicio, p.FechaFin)
     if err != nil {
         return 0, utilidades.TraducirErrorBD(err)
     }
@@ -114,10 +141,19 @@ func (s *PlanService) CrearPlan(ctx context.Context, p modelos.Plan) (int64, err
     if err != nil {
         return 0, err
     }
+
+    // Primer precio, vigente desde el inicio del plan
+    if _, err := repositorios.NewPrecioPlanRepo(tx).Crear(ctx, &modelos.PrecioPlan{IDPlan: int(id), Precio: p.Precio, FechaInicio: fechaInicio}); err != nil {
+        return 0, err
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("error confirmando transacción: %w", err)
+    }
     return id, nil
 }
 
-// ActualizarPlan aplica actualizaciones parciales.
+// ActualizarPlan aplica actualizaciones parciales. El precio sólo se corrige así mientras el
+// plan no tenga contratos; después se cambia con un ajuste de precios, que avisa a los clientes.
 func (s *PlanService) ActualizarPlan(ctx context.Context, id int, updates map[string]interface{}) error {
     // Verificar existe y no borrado
     var borrado sql.NullTime
@@ -154,6 +190,25 @@ func (s *PlanService) ActualizarPlan(ctx context.Context, id int, updates map[st
         }
     }
 
+    // El precio no se pisa: es el que firmaron los clientes del plan
+    if v, ok := updates["precio"]; ok {
+        precio, ok := v.(float64)
+        if !ok {
+            return utilidades.ErrValidation{Campo: "precio", Mensaje: "debe ser numérico"}
+        }
+        repo := repositorios.NewPrecioPlanRepo(s.db)
+        tieneContratos, err := repo.TieneContratos(ctx, id)
+        if err != nil {
+            return err
+        }
+        if tieneContratos {
+            return utilidades.ErrValidation{Campo: "precio", Mensaje: "el plan tiene contratos: el precio se cambia con un ajuste de precios programado"}
+        }
+        if err := repo.ReemplazarPrecioInicial(ctx, id, precio, hoyArgentina().Format("2006-01-02")); err != nil {
+            return err
+        }
+    }
+
     // Construir SET dinámico
     sets := []string{}
     args := []interface{}{}
@@ -163,9 +218,6 @@ func (s *PlanService) ActualizarPlan(ctx context.Context, id int, updates map[st
     if v, ok := updates["velocidad_mbps"]; ok {
         sets = append(sets, "velocidad_mbps = ?"); args = append(args, v)
     }
-    if v, ok := updates["precio"]; ok {
-        sets = append(sets, "precio = ?"); args = append(args, v)
-    }
     if v, ok := updates["descripcion"]; ok {
         sets = append(sets, "descripcion = ?"); args = append(args, v)
     }
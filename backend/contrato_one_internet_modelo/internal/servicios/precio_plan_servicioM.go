@@ -0,0 +1,361 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// PrecioPlanService gestiona los precios versionados de los planes y las promociones.
+//
+// Cada precio rige desde su fecha de inicio hasta el siguiente. El contrato guarda el precio
+// con el que se firmó y sólo le llegan los precios que empiezan a regir después de la firma.
+// Por eso un ajuste se programa con al menos diasAviso días de anticipación y se avisa a los
+// titulares de los contratos alcanzados, que pueden dar de baja el servicio antes de que rija.
+type PrecioPlanService struct {
+	db        *sql.DB
+	diasAviso int
+}
+
+func NewPrecioPlanService(db *sql.DB, diasAviso int) *PrecioPlanService {
+	return &PrecioPlanService{db: db, diasAviso: diasAviso}
+}
+
+// Historial devuelve los precios de un plan (precios.gestionar)
+func (s *PrecioPlanService) Historial(ctx context.Context, idPlan, idPersona int) (*modelos.HistorialPreciosResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoPreciosGestionar); err != nil {
+		return nil, err
+	}
+
+	repo := repositorios.NewPrecioPlanRepo(s.db)
+	plan, err := repo.ObtenerPlan(ctx, idPlan)
+	if err != nil {
+		return nil, err
+	}
+	precios, err := repo.Historial(ctx, idPlan)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.HistorialPreciosResponse{IDPlan: plan.IDPlan, Nombre: plan.Nombre, Precios: precios}, nil
+}
+
+// AjustarPrecios programa precios nuevos para los planes desde una fecha (precios.gestionar). Los
+// titulares de los contratos alcanzados reciben el aviso por el outbox.
+func (s *PrecioPlanService) AjustarPrecios(ctx context.Context, req modelos.AjustePreciosRequest, idPersona int) (*modelos.AjustePreciosResponse, error) {
+	idUsuario, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoPreciosGestionar)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validar la fecha: el aviso tiene que llegar con la anticipación exigida
+	hoy := hoyArgentina()
+	desde, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.VigenteDesde), hoy.Location())
+	if err != nil {
+		return nil, utilidades.ErrValidation{Campo: "vigente_desde", Mensaje: "debe tener formato AAAA-MM-DD"}
+	}
+	if minimo := hoy.AddDate(0, 0, s.diasAviso); desde.Before(minimo) {
+		return nil, utilidades.ErrValidation{
+			Campo:   "vigente_desde",
+			Mensaje: fmt.Sprintf("los clientes deben ser avisados con %d días de anticipación: la fecha mínima es %s", s.diasAviso, minimo.Format("2006-01-02")),
+		}
+	}
+	vigenteDesde := desde.Format("2006-01-02")
+	diaAnterior := desde.AddDate(0, 0, -1).Format("2006-01-02")
+
+	motivo, err := validarMotivoAjuste(req.Motivo)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewPrecioPlanRepo(tx)
+	outbox := repositorios.NewOutboxRepo(tx)
+
+	// 2. Calcular el precio nuevo de cada plan
+	ajustes, err := preciosAjustados(ctx, repo, req, vigenteDesde, diaAnterior)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Cerrar el precio vigente, guardar el nuevo y registrar el aviso
+	resp := &modelos.AjustePreciosResponse{VigenteDesde: vigenteDesde, Planes: ajustes}
+	for i := range ajustes {
+		a := &ajustes[i]
+		if err := repo.CerrarVigencia(ctx, a.IDPlan, diaAnterior); err != nil {
+			return nil, err
+		}
+		a.IDPlanPrecio, err = repo.Crear(ctx, &modelos.PrecioPlan{
+			IDPlan:      a.IDPlan,
+			Precio:      a.PrecioNuevo,
+			FechaInicio: vigenteDesde,
+			Motivo:      motivo,
+			IDUsuario:   &idUsuario,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		contratos, err := repo.ListarContratosAlcanzados(ctx, a.IDPlan, vigenteDesde, diaAnterior)
+		if err != nil {
+			return nil, err
+		}
+		a.ContratosAvisados = len(contratos)
+		resp.ContratosAvisados += len(contratos)
+
+		if _, err := outbox.Registrar(ctx, modelos.EventoPlanPrecioProgramado, modelos.PayloadPlanPrecio{IDPlanPrecio: a.IDPlanPrecio}); err != nil {
+			return nil, fmt.Errorf("error registrando evento: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Ajuste de precios desde %s por la persona %d: %d plan(es), %d contrato(s) avisado(s)",
+		vigenteDesde, idPersona, len(ajustes), resp.ContratosAvisados)
+	return resp, nil
+}
+
+// preciosAjustados arma el ajuste de cada plan: por porcentaje sobre el precio que rige el día
+// anterior o con el precio indicado
+func preciosAjustados(ctx context.Context, repo *repositorios.PrecioPlanRepo, req modelos.AjustePreciosRequest, vigenteDesde, diaAnterior string) ([]modelos.PrecioAjustado, error) {
+	// 1. Planes y precios pedidos
+	nuevos := map[int]float64{}
+	var idPlanes []int
+	switch {
+	case req.Porcentaje != nil && len(req.Precios) > 0, req.Porcentaje == nil && len(req.Precios) == 0:
+		return nil, utilidades.ErrValidation{Campo: "precios", Mensaje: "indicar porcentaje o precios (uno de los dos)"}
+
+	case req.Porcentaje != nil:
+		if *req.Porcentaje == 0 || *req.Porcentaje <= -100 {
+			return nil, utilidades.ErrValidation{Campo: "porcentaje", Mensaje: "debe ser distinto de 0 y mayor a -100"}
+		}
+		idPlanes = req.IDPlanes
+		if len(idPlanes) == 0 {
+			var err error
+			if idPlanes, err = repo.ListarPlanesVigentes(ctx, vigenteDesde); err != nil {
+				return nil, err
+			}
+			if len(idPlanes) == 0 {
+				return nil, utilidades.ErrValidation{Campo: "id_planes", Mensaje: "no hay planes vigentes para ajustar"}
+			}
+		}
+
+	default:
+		if len(req.IDPlanes) > 0 {
+			return nil, utilidades.ErrValidation{Campo: "id_planes", Mensaje: "sólo se usa con porcentaje"}
+		}
+		for _, p := range req.Precios {
+			precio, err := validarPrecioPlan(p.Precio)
+			if err != nil {
+				return nil, err
+			}
+			if _, repetido := nuevos[p.IDPlan]; repetido {
+				return nil, utilidades.ErrValidation{Campo: "precios", Mensaje: fmt.Sprintf("el plan %d está repetido", p.IDPlan)}
+			}
+			nuevos[p.IDPlan] = precio
+			idPlanes = append(idPlanes, p.IDPlan)
+		}
+	}
+
+	// 2. Validar cada plan y calcular su precio nuevo
+	ajustes := []modelos.PrecioAjustado{}
+	vistos := map[int]bool{}
+	for _, idPlan := range idPlanes {
+		if vistos[idPlan] {
+			return nil, utilidades.ErrValidation{Campo: "id_planes", Mensaje: fmt.Sprintf("el plan %d está repetido", idPlan)}
+		}
+		vistos[idPlan] = true
+
+		plan, err := repo.ObtenerPlan(ctx, idPlan)
+		if err != nil {
+			return nil, err
+		}
+		if plan.FechaFin != nil && *plan.FechaFin < vigenteDesde {
+			return nil, utilidades.ErrValidation{Campo: "id_plan", Mensaje: fmt.Sprintf("el plan %s deja de ofrecerse el %s", plan.Nombre, *plan.FechaFin)}
+		}
+		programado, err := repo.ProximoPrecio(ctx, idPlan, vigenteDesde)
+		if err != nil {
+			return nil, err
+		}
+		if programado != nil {
+			return nil, utilidades.ErrValidation{Campo: "vigente_desde", Mensaje: fmt.Sprintf("el plan %s ya tiene un precio programado desde el %s", plan.Nombre, programado.FechaInicio)}
+		}
+		anterior, err := repo.PrecioVigente(ctx, idPlan, diaAnterior)
+		if err != nil {
+			return nil, err
+		}
+
+		precio, ok := nuevos[idPlan]
+		if !ok {
+			if precio, err = validarPrecioPlan(anterior.Precio * (1 + *req.Porcentaje/100)); err != nil {
+				return nil, err
+			}
+		}
+		ajustes = append(ajustes, modelos.PrecioAjustado{
+			IDPlan:         idPlan,
+			Nombre:         plan.Nombre,
+			PrecioAnterior: anterior.Precio,
+			PrecioNuevo:    precio,
+		})
+	}
+	return ajustes, nil
+}
+
+// ListarPromocionesVigentes devuelve las promociones que se pueden contratar hoy, opcionalmente
+// sólo las de un plan
+func (s *PrecioPlanService) ListarPromocionesVigentes(ctx context.Context, idPlan *int) ([]modelos.Promocion, error) {
+	hoy := hoyArgentina().Format("2006-01-02")
+	return repositorios.NewPrecioPlanRepo(s.db).ListarPromociones(ctx, &hoy, idPlan)
+}
+
+// ListarPromociones devuelve todas las promociones no borradas, también las vencidas (precios.gestionar)
+func (s *PrecioPlanService) ListarPromociones(ctx context.Context, idPersona int) ([]modelos.Promocion, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoPreciosGestionar); err != nil {
+		return nil, err
+	}
+	return repositorios.NewPrecioPlanRepo(s.db).ListarPromociones(ctx, nil, nil)
+}
+
+// CrearPromocion da de alta una promoción (precios.gestionar)
+func (s *PrecioPlanService) CrearPromocion(ctx context.Context, req modelos.CrearPromocionRequest, idPersona int) (*modelos.Promocion, error) {
+	idUsuario, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoPreciosGestionar)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validar los datos
+	p := &modelos.Promocion{
+		Nombre:              strings.TrimSpace(req.Nombre),
+		Descripcion:         req.Descripcion,
+		DescuentoPorcentaje: req.DescuentoPorcentaje,
+		DuracionMeses:       req.DuracionMeses,
+		IDPlan:              req.IDPlan,
+		IDUsuarioCreador:    &idUsuario,
+	}
+	if p.Nombre == "" || len(p.Nombre) > 100 {
+		return nil, utilidades.ErrValidation{Campo: "nombre", Mensaje: "es requerido y no puede superar los 100 caracteres"}
+	}
+	if p.Descripcion != nil && len(*p.Descripcion) > 255 {
+		return nil, utilidades.ErrValidation{Campo: "descripcion", Mensaje: "no puede superar los 255 caracteres"}
+	}
+	if p.DescuentoPorcentaje <= 0 || p.DescuentoPorcentaje > 100 {
+		return nil, utilidades.ErrValidation{Campo: "descuento_porcentaje", Mensaje: "debe ser mayor a 0 y hasta 100"}
+	}
+	p.DescuentoPorcentaje = redondearImporte(p.DescuentoPorcentaje)
+	if p.DuracionMeses < 1 || p.DuracionMeses > 36 {
+		return nil, utilidades.ErrValidation{Campo: "duracion_meses", Mensaje: "debe estar entre 1 y 36"}
+	}
+
+	hoy := hoyArgentina()
+	p.FechaInicio = hoy.Format("2006-01-02")
+	if fecha := strings.TrimSpace(req.FechaInicio); fecha != "" {
+		if _, err := time.Parse("2006-01-02", fecha); err != nil {
+			return nil, utilidades.ErrValidation{Campo: "fecha_inicio", Mensaje: "debe tener formato AAAA-MM-DD"}
+		}
+		p.FechaInicio = fecha
+	}
+	if req.FechaFin != nil {
+		if _, err := time.Parse("2006-01-02", *req.FechaFin); err != nil {
+			return nil, utilidades.ErrValidation{Campo: "fecha_fin", Mensaje: "debe tener formato AAAA-MM-DD"}
+		}
+		if *req.FechaFin < p.FechaInicio || *req.FechaFin < hoy.Format("2006-01-02") {
+			return nil, utilidades.ErrValidation{Campo: "fecha_fin", Mensaje: "no puede ser anterior a fecha_inicio ni a hoy"}
+		}
+		p.FechaFin = req.FechaFin
+	}
+
+	// 2. Guardar
+	repo := repositorios.NewPrecioPlanRepo(s.db)
+	if p.IDPlan != nil {
+		if _, err := repo.ObtenerPlan(ctx, *p.IDPlan); err != nil {
+			return nil, err
+		}
+	}
+	if p.IDPromocion, err = repo.CrearPromocion(ctx, p); err != nil {
+		return nil, err
+	}
+
+	logger.Info.Printf("Promoción %d (%s, %.2f%% por %d meses) creada por la persona %d", p.IDPromocion, p.Nombre, p.DescuentoPorcentaje, p.DuracionMeses, idPersona)
+	return p, nil
+}
+
+// BorrarPromocion da de baja una promoción (precios.gestionar). Los contratos que ya la tienen la
+// conservan hasta cumplir sus meses.
+func (s *PrecioPlanService) BorrarPromocion(ctx context.Context, idPromocion, idPersona int) error {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoPreciosGestionar); err != nil {
+		return err
+	}
+	if err := repositorios.NewPrecioPlanRepo(s.db).BorrarPromocion(ctx, idPromocion); err != nil {
+		return err
+	}
+	logger.Info.Printf("Promoción %d dada de baja por la persona %d", idPromocion, idPersona)
+	return nil
+}
+
+// aplicarCondicionesContrato fija en el contrato el abono vigente de su plan y, si se pidió,
+// la promoción, que tiene que estar vigente y valer para ese plan
+func aplicarCondicionesContrato(ctx context.Context, repo *repositorios.PrecioPlanRepo, c *modelos.Contrato, idPromocion *int) error {
+	hoy := hoyArgentina().Format("2006-01-02")
+
+	precio, err := repo.PrecioVigente(ctx, c.IDPlan, hoy)
+	if err != nil {
+		return err
+	}
+	c.PrecioPlan = &precio.Precio
+
+	if idPromocion == nil {
+		return nil
+	}
+	promo, err := repo.ObtenerPromocion(ctx, *idPromocion)
+	if err != nil {
+		return err
+	}
+	if promo.FechaInicio > hoy || (promo.FechaFin != nil && *promo.FechaFin < hoy) {
+		return utilidades.ErrValidation{Campo: "id_promocion", Mensaje: "la promoción no está vigente"}
+	}
+	if promo.IDPlan != nil && *promo.IDPlan != c.IDPlan {
+		return utilidades.ErrValidation{Campo: "id_promocion", Mensaje: "la promoción no es válida para el plan elegido"}
+	}
+	c.IDPromocion = &promo.IDPromocion
+	c.PromocionDescuento = &promo.DescuentoPorcentaje
+	c.PromocionMeses = &promo.DuracionMeses
+	return nil
+}
+
+func validarPrecioPlan(precio float64) (float64, error) {
+	precio = redondearImporte(precio)
+	if precio <= 0 {
+		return 0, utilidades.ErrValidation{Campo: "precio", Mensaje: "debe ser mayor a 0"}
+	}
+	if precio >= 1e10 {
+		return 0, utilidades.ErrValidation{Campo: "precio", Mensaje: "es demasiado grande"}
+	}
+	return precio, nil
+}
+
+func validarMotivoAjuste(motivo *string) (*string, error) {
+	if motivo == nil {
+		return nil, nil
+	}
+	m := strings.TrimSpace(*motivo)
+	if m == "" {
+		return nil, nil
+	}
+	if len(m) > 255 {
+		return nil, utilidades.ErrValidation{Campo: "motivo", Mensaje: "no puede superar los 255 caracteres"}
+	}
+	return &m, nil
+}
@@ -0,0 +1,268 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// RolPermisoService administra qué permisos tiene cada rol y calcula los permisos efectivos
+// de un usuario. El controlador los lleva en el JWT: un cambio alcanza a las sesiones
+// abiertas cuando renuevan el token de acceso.
+type RolPermisoService struct {
+	db *sql.DB
+}
+
+// errPermisoAdminProtegido evita que el rol admin pierda roles.permisos: nadie podría volver a
+// asignarlo desde la API
+var errPermisoAdminProtegido = utilidades.ErrValidation{
+	Campo:   "permisos",
+	Mensaje: "el rol admin no puede quedarse sin el permiso " + modelos.PermisoRolesPermisos,
+}
+
+func NewRolPermisoService(db *sql.DB) *RolPermisoService {
+	return &RolPermisoService{db: db}
+}
+
+// ListarDeRol devuelve los permisos asignados al rol
+func (s *RolPermisoService) ListarDeRol(ctx context.Context, idPersona, idRol int) (*modelos.RolPermisosResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoRolesPermisos); err != nil {
+		return nil, err
+	}
+	rol, err := s.obtenerRol(ctx, idRol)
+	if err != nil {
+		return nil, err
+	}
+	return s.respuestaRol(ctx, s.db, rol)
+}
+
+// Reemplazar deja al rol exactamente con los permisos indicados. El rol admin no puede
+// quedarse sin roles.permisos: nadie podría volver a asignarlo.
+func (s *RolPermisoService) Reemplazar(ctx context.Context, idPersona, idRol int, idsPermiso []int) (*modelos.RolPermisosResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoRolesPermisos); err != nil {
+		return nil, err
+	}
+	rol, err := s.obtenerRol(ctx, idRol)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validar los permisos y descartar repetidos
+	vistos := make(map[int]bool)
+	var ids []int
+	conservaAdministracion := false
+	for _, id := range idsPermiso {
+		if vistos[id] {
+			continue
+		}
+		permiso, err := s.verificarPermiso(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		vistos[id] = true
+		ids = append(ids, id)
+		conservaAdministracion = conservaAdministracion || permiso.Nombre == modelos.PermisoRolesPermisos
+	}
+	if rol.Nombre == modelos.RolAdmin && !conservaAdministracion {
+		return nil, errPermisoAdminProtegido
+	}
+
+	// 2. Reemplazar la asignación en una transacción
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repo := repositorios.NewRolPermisoRepo(tx)
+	if err := repo.QuitarTodos(ctx, idRol); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, err := repo.Asignar(ctx, idRol, id); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := s.respuestaRol(ctx, tx, rol)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	logger.Info.Printf("Persona %d dejó al rol %s con %d permiso(s)", idPersona, rol.Nombre, len(ids))
+	return resp, nil
+}
+
+// Asignar le da un permiso al rol. Asignar uno que ya tiene no es un error.
+func (s *RolPermisoService) Asignar(ctx context.Context, idPersona, idRol, idPermiso int) error {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoRolesPermisos); err != nil {
+		return err
+	}
+	rol, err := s.obtenerRol(ctx, idRol)
+	if err != nil {
+		return err
+	}
+	if _, err := s.verificarPermiso(ctx, idPermiso); err != nil {
+		return err
+	}
+
+	asignado, err := repositorios.NewRolPermisoRepo(s.db).Asignar(ctx, idRol, idPermiso)
+	if err != nil {
+		return err
+	}
+	if asignado {
+		logger.Info.Printf("Persona %d asignó el permiso %d al rol %s", idPersona, idPermiso, rol.Nombre)
+	}
+	return nil
+}
+
+// Quitar le saca un permiso al rol. Al rol admin no se le puede quitar roles.permisos.
+func (s *RolPermisoService) Quitar(ctx context.Context, idPersona, idRol, idPermiso int) error {
+	if _, err := exigirPermiso(ctx, s.db, idPersona, modelos.PermisoRolesPermisos); err != nil {
+		return err
+	}
+	rol, err := s.obtenerRol(ctx, idRol)
+	if err != nil {
+		return err
+	}
+	if rol.Nombre == modelos.RolAdmin {
+		permiso, err := repositorios.NewPermisoRepo(s.db).ObtenerPorID(ctx, idPermiso)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && permiso.Nombre == modelos.PermisoRolesPermisos {
+			return errPermisoAdminProtegido
+		}
+	}
+
+	quitado, err := repositorios.NewRolPermisoRepo(s.db).Quitar(ctx, idRol, idPermiso)
+	if err != nil {
+		return err
+	}
+	if !quitado {
+		return utilidades.ErrNotFound{Entity: "permiso del rol", Campo: "id_permiso", Valor: fmt.Sprintf("%d", idPermiso)}
+	}
+	logger.Info.Printf("Persona %d quitó el permiso %d al rol %s", idPersona, idPermiso, rol.Nombre)
+	return nil
+}
+
+// PermisosEfectivos devuelve los roles y permisos de la persona que hace el pedido
+func (s *RolPermisoService) PermisosEfectivos(ctx context.Context, idPersona int) (*modelos.PermisosEfectivosResponse, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := repositorios.NewUsuarioRolRepo(s.db).ObtenerRolesPorUsuario(ctx, idUsuario)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo roles del usuario %d: %w", idUsuario, err)
+	}
+	permisos, err := repositorios.NewRolPermisoRepo(s.db).PermisosDeUsuario(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []string{}
+	}
+	return &modelos.PermisosEfectivosResponse{Roles: roles, Permisos: permisos}, nil
+}
+
+// respuestaRol arma la respuesta con los permisos actuales del rol
+func (s *RolPermisoService) respuestaRol(ctx context.Context, db repositorios.Execer, rol *modelos.Rol) (*modelos.RolPermisosResponse, error) {
+	permisos, err := repositorios.NewRolPermisoRepo(db).ListarPorRol(ctx, rol.IDRol)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.RolPermisosResponse{IDRol: rol.IDRol, Rol: rol.Nombre, Permisos: permisos}, nil
+}
+
+// obtenerRol busca un rol activo
+func (s *RolPermisoService) obtenerRol(ctx context.Context, idRol int) (*modelos.Rol, error) {
+	rol, err := repositorios.NewRolRepo(s.db).ObtenerPorID(ctx, idRol)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utilidades.ErrNotFound{Entity: "rol", Campo: "id_rol", Valor: fmt.Sprintf("%d", idRol)}
+		}
+		return nil, err
+	}
+	return rol, nil
+}
+
+// verificarPermiso exige que el permiso exista y esté activo
+func (s *RolPermisoService) verificarPermiso(ctx context.Context, idPermiso int) (*modelos.Permiso, error) {
+	permiso, err := repositorios.NewPermisoRepo(s.db).ObtenerPorID(ctx, idPermiso)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utilidades.ErrNotFound{Entity: "permiso", Campo: "id_permiso", Valor: fmt.Sprintf("%d", idPermiso)}
+		}
+		return nil, err
+	}
+	return permiso, nil
+}
+
+// usuarioDePersona resuelve el usuario de la persona que hace el pedido
+func (s *RolPermisoService) usuarioDePersona(ctx context.Context, idPersona int) (int, error) {
+	if idPersona <= 0 {
+		return 0, utilidades.ErrAccesoDenegado
+	}
+	idUsuario, err := repositorios.NewUsuarioRepo(s.db).ObtenerIDUsuarioPorPersona(ctx, idPersona)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return 0, utilidades.ErrAccesoDenegado
+		}
+		return 0, err
+	}
+	return idUsuario, nil
+}
+
+// exigirPermiso resuelve el usuario de la persona que hace el pedido (X-ID-Persona) y exige que
+// alguno de sus roles tenga el permiso. Es la misma regla que aplica RequirePermission en el
+// controlador, pero contra rol_permiso: un permiso quitado deja de valer antes de que venza el JWT.
+func exigirPermiso(ctx context.Context, db repositorios.Execer, idPersona int, permiso string) (int, error) {
+	idUsuario, permisos, err := permisosDePersona(ctx, db, idPersona)
+	if err != nil {
+		return 0, err
+	}
+	if !tienePermiso(permisos, permiso) {
+		return 0, utilidades.ErrAccesoDenegado
+	}
+	return idUsuario, nil
+}
+
+// permisosDePersona devuelve el usuario de la persona y los permisos que suman sus roles
+func permisosDePersona(ctx context.Context, db repositorios.Execer, idPersona int) (int, []string, error) {
+	if idPersona <= 0 {
+		return 0, nil, utilidades.ErrAccesoDenegado
+	}
+	idUsuario, err := repositorios.NewUsuarioRepo(db).ObtenerIDUsuarioPorPersona(ctx, idPersona)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return 0, nil, utilidades.ErrAccesoDenegado
+		}
+		return 0, nil, err
+	}
+	permisos, err := repositorios.NewRolPermisoRepo(db).PermisosDeUsuario(ctx, idUsuario)
+	if err != nil {
+		return 0, nil, err
+	}
+	return idUsuario, permisos, nil
+}
+
+func tienePermiso(permisos []string, permiso string) bool {
+	for _, p := range permisos {
+		if p == permiso {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package servicios
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/utilidades"
+)
+
+// respuestasPersonaConPermisos resuelve la persona 3 al usuario 7 con los permisos indicados
+func respuestasPersonaConPermisos(permisos ...string) []respuestaFake {
+	filas := make([][]driver.Value, len(permisos))
+	for i, p := range permisos {
+		filas[i] = []driver.Value{p}
+	}
+	return []respuestaFake{
+		{contiene: "FROM usuario WHERE id_persona", columnas: []string{"id_usuario"}, filas: [][]driver.Value{{int64(7)}}},
+		{contiene: "FROM usuario_rol ur", columnas: []string{"nombre"}, filas: filas},
+	}
+}
+
+func TestExigirPermisoConsultaLosPermisosDelUsuario(t *testing.T) {
+	db, _ := nuevaBDFake(t, respuestasPersonaConPermisos(modelos.PermisoCuentasVer)...)
+
+	idUsuario, err := exigirPermiso(context.Background(), db, 3, modelos.PermisoCuentasVer)
+	if err != nil || idUsuario != 7 {
+		t.Fatalf("exigirPermiso(cuentas.ver) = %d, %v; se esperaba 7, nil", idUsuario, err)
+	}
+	if _, err := exigirPermiso(context.Background(), db, 3, modelos.PermisoCuentasGestionar); !errors.Is(err, utilidades.ErrAccesoDenegado) {
+		t.Errorf("exigirPermiso(cuentas.gestionar) = %v; se esperaba acceso denegado", err)
+	}
+	if _, err := exigirPermiso(context.Background(), db, 0, modelos.PermisoCuentasVer); !errors.Is(err, utilidades.ErrAccesoDenegado) {
+		t.Errorf("exigirPermiso sin persona = %v; se esperaba acceso denegado", err)
+	}
+}
+
+func TestRolAdminConservaRolesPermisos(t *testing.T) {
+	respuestas := append(respuestasPersonaConPermisos(modelos.PermisoRolesPermisos),
+		respuestaFake{contiene: "FROM rol WHERE", columnas: []string{"id_rol", "nombre", "descripcion"}, filas: [][]driver.Value{{int64(1), modelos.RolAdmin, nil}}},
+		respuestaFake{contiene: "FROM permiso WHERE id_permiso", columnas: []string{"id_permiso", "nombre", "descripcion"}, filas: [][]driver.Value{{int64(9), modelos.PermisoRolesPermisos, nil}}},
+	)
+	db, bd := nuevaBDFake(t, respuestas...)
+	s := NewRolPermisoService(db)
+
+	var validacion utilidades.ErrValidation
+	if err := s.Quitar(context.Background(), 3, 1, 9); !errors.As(err, &validacion) {
+		t.Errorf("Quitar(roles.permisos del admin) = %v; se esperaba un error de validación", err)
+	}
+
+	// Reemplazar con un conjunto que no lo incluye tampoco se acepta
+	bd.responder(respuestaFake{contiene: "FROM permiso WHERE id_permiso", columnas: []string{"id_permiso", "nombre", "descripcion"}, filas: [][]driver.Value{{int64(4), modelos.PermisoCuentasVer, nil}}})
+	if _, err := s.Reemplazar(context.Background(), 3, 1, []int{4}); !errors.As(err, &validacion) {
+		t.Errorf("Reemplazar(admin sin roles.permisos) = %v; se esperaba un error de validación", err)
+	}
+	if n := bd.contarEjecutadas("rol_permiso"); n != 0 {
+		t.Errorf("se ejecutaron %d sentencias sobre rol_permiso, se esperaba ninguna", n)
+	}
+}
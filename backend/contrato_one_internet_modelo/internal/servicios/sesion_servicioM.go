@@ -0,0 +1,107 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// SesionService lista y cierra las sesiones de login. Cerrar una sesión revoca sus refresh
+// tokens: los JWT de acceso ya emitidos siguen valiendo hasta que vencen.
+type SesionService struct {
+	db *sql.DB
+}
+
+func NewSesionService(db *sql.DB) *SesionService {
+	return &SesionService{db: db}
+}
+
+// Listar devuelve las sesiones vigentes de la persona, marcando la actual
+func (s *SesionService) Listar(ctx context.Context, idPersona, idSesionActual int) (*modelos.SesionesResponse, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	sesiones, err := repositorios.NewSesionRepo(s.db).ListarVigentes(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sesiones {
+		sesiones[i].Actual = sesiones[i].IDSesion == idSesionActual
+	}
+	return &modelos.SesionesResponse{Sesiones: sesiones}, nil
+}
+
+// Revocar cierra una sesión de la persona
+func (s *SesionService) Revocar(ctx context.Context, idPersona, idSesion int) error {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return err
+	}
+
+	ok, err := repositorios.NewSesionRepo(s.db).Revocar(ctx, idUsuario, idSesion, modelos.MotivoSesionUsuario)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return utilidades.ErrNotFound{Entity: "sesión", Campo: "id_sesion", Valor: fmt.Sprintf("%d", idSesion)}
+	}
+	return nil
+}
+
+// RevocarOtras cierra todas las sesiones de la persona salvo la indicada (0 las cierra todas)
+func (s *SesionService) RevocarOtras(ctx context.Context, idPersona, excepto int) (*modelos.SesionesRevocadasResponse, error) {
+	idUsuario, err := s.usuarioDePersona(ctx, idPersona)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := repositorios.NewSesionRepo(s.db).RevocarTodas(ctx, idUsuario, excepto, modelos.MotivoSesionUsuario)
+	if err != nil {
+		return nil, err
+	}
+	return &modelos.SesionesRevocadasResponse{Revocadas: n}, nil
+}
+
+// CerrarSesionesUsuario cierra todas las sesiones de otro usuario (usuarios.cerrar_sesiones)
+func (s *SesionService) CerrarSesionesUsuario(ctx context.Context, idUsuario, idPersonaAdmin int) (*modelos.SesionesRevocadasResponse, error) {
+	if _, err := exigirPermiso(ctx, s.db, idPersonaAdmin, modelos.PermisoUsuariosCerrarSesiones); err != nil {
+		return nil, err
+	}
+	if _, err := repositorios.NewUsuarioRepo(s.db).ObtenerIDPersona(ctx, idUsuario); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utilidades.ErrNotFound{Entity: "usuario", Campo: "id_usuario", Valor: fmt.Sprintf("%d", idUsuario)}
+		}
+		return nil, err
+	}
+
+	n, err := repositorios.NewSesionRepo(s.db).RevocarTodas(ctx, idUsuario, 0, modelos.MotivoSesionAdmin)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Persona %d cerró %d sesiones del usuario %d", idPersonaAdmin, n, idUsuario)
+	return &modelos.SesionesRevocadasResponse{Revocadas: n}, nil
+}
+
+// usuarioDePersona resuelve el usuario de la persona que hace el pedido
+func (s *SesionService) usuarioDePersona(ctx context.Context, idPersona int) (int, error) {
+	if idPersona <= 0 {
+		return 0, utilidades.ErrAccesoDenegado
+	}
+	idUsuario, err := repositorios.NewUsuarioRepo(s.db).ObtenerIDUsuarioPorPersona(ctx, idPersona)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return 0, utilidades.ErrAccesoDenegado
+		}
+		return 0, err
+	}
+	return idUsuario, nil
+}
@@ -0,0 +1,468 @@
+package servicios
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"contrato_one_internet_modelo/internal/database"
+	"contrato_one_internet_modelo/internal/modelos"
+	"contrato_one_internet_modelo/internal/repositorios"
+	"contrato_one_internet_modelo/internal/utilidades"
+	"contrato_one_internet_modelo/internal/utilidades/logger"
+)
+
+// Turnos de instalación reservados por el cliente.
+//
+// Los horarios de la agenda son de Argentina, como las fechas de la base.
+// Las ventanas se arman con la agenda semanal de los técnicos que cubren el distrito de la
+// conexión, descontando los turnos que ya tienen tomados. Al reservar, la orden queda
+// asignada a un técnico libre en esa ventana (se prefiere el que ya tenía) y programada.
+// El cliente puede cambiar o cancelar el turno hasta 24 horas antes y como máximo
+// MaxReprogramacionesTurno veces. El día anterior a la visita se le envía un recordatorio.
+
+const (
+	DuracionVentanaTurno     = 2 * time.Hour
+	DiasHorizonteTurnos      = 14
+	AnticipacionMinimaTurno  = 24 * time.Hour // para reservar y para cambiar o cancelar
+	MaxReprogramacionesTurno = 3
+	IntervaloRecordatorios   = 15 * time.Minute
+)
+
+// ventanaLibre es una ventana reservable con los técnicos que la tienen libre
+type ventanaLibre struct {
+	modelos.VentanaTurno
+	tecnicos []int
+}
+
+// TurnoService maneja la reserva de turnos de instalación por parte del cliente
+type TurnoService struct {
+	db *sql.DB
+}
+
+func NewTurnoService(db *sql.DB) *TurnoService {
+	return &TurnoService{db: db}
+}
+
+// diaSemana numera los días como tecnico_agenda: 1 = lunes ... 7 = domingo
+func diaSemana(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// horaDelDia combina la fecha del día con una hora "HH:MM" de la agenda
+func horaDelDia(dia time.Time, hora string) (time.Time, error) {
+	h, err := time.Parse("15:04", hora)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(dia.Year(), dia.Month(), dia.Day(), h.Hour(), h.Minute(), 0, 0, dia.Location()), nil
+}
+
+// conexionDelCliente obtiene los datos de la conexión. Una conexión ajena se informa como
+// inexistente.
+func conexionDelCliente(ctx context.Context, db repositorios.Execer, idConexion, idPersona int) (*modelos.DatosConexionTurno, error) {
+	datos, err := repositorios.NewAgendaTecnicoRepo(db).ObtenerDatosConexion(ctx, idConexion)
+	if err != nil {
+		return nil, err
+	}
+	if datos.IDPersona != idPersona {
+		logger.Warn.Printf("Persona %d pidió los turnos de la conexión %d, que no le pertenece", idPersona, idConexion)
+		return nil, utilidades.ErrNotFound{Entity: "conexion", Campo: "id_conexion", Valor: fmt.Sprintf("%d", idConexion)}
+	}
+	return datos, nil
+}
+
+// ordenPendiente obtiene (y bloquea, dentro de una transacción) la orden abierta de la conexión
+func ordenPendiente(ctx context.Context, db repositorios.Execer, idConexion int) (*modelos.OrdenInstalacion, error) {
+	orden, err := repositorios.NewOrdenInstalacionRepo(db).ObtenerAbiertaPorConexion(ctx, idConexion)
+	if err != nil {
+		var noEncontrado utilidades.ErrNotFound
+		if errors.As(err, &noEncontrado) {
+			return nil, utilidades.ErrInstalacionNoPendiente
+		}
+		return nil, err
+	}
+	return orden, nil
+}
+
+// verificarModificable aplica las reglas para cambiar o cancelar un turno ya reservado
+func verificarModificable(orden *modelos.OrdenInstalacion, ahora time.Time) error {
+	if orden.Reprogramaciones >= MaxReprogramacionesTurno {
+		return fmt.Errorf("%w: se alcanzó el máximo de %d cambios, comunicate con atención al cliente",
+			utilidades.ErrTurnoNoModificable, MaxReprogramacionesTurno)
+	}
+	if orden.TurnoInicio.Sub(ahora) < AnticipacionMinimaTurno {
+		return fmt.Errorf("%w: faltan menos de %.0f horas para la visita",
+			utilidades.ErrTurnoNoModificable, AnticipacionMinimaTurno.Hours())
+	}
+	return nil
+}
+
+// ventanasLibres arma las ventanas reservables de los próximos DiasHorizonteTurnos días para
+// la zona de la conexión. Los turnos de la orden excluida no cuentan como ocupados.
+func ventanasLibres(
+	ctx context.Context,
+	db repositorios.Execer,
+	datos *modelos.DatosConexionTurno,
+	excluirOrden int,
+	ahora time.Time,
+) ([]ventanaLibre, error) {
+	franjas, err := repositorios.NewAgendaTecnicoRepo(db).ListarFranjasPorZona(ctx, datos.Distrito, datos.Departamento)
+	if err != nil {
+		return nil, err
+	}
+	if len(franjas) == 0 {
+		return nil, nil
+	}
+
+	hoy := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, ahora.Location())
+	desde := ahora.Add(AnticipacionMinimaTurno)
+	hasta := hoy.AddDate(0, 0, DiasHorizonteTurnos+1)
+
+	// 1. Turnos ya tomados por los técnicos de la zona
+	var idsTecnicos []int
+	vistos := map[int]bool{}
+	for _, f := range franjas {
+		if !vistos[f.IDTecnico] {
+			vistos[f.IDTecnico] = true
+			idsTecnicos = append(idsTecnicos, f.IDTecnico)
+		}
+	}
+	ocupados, err := repositorios.NewOrdenInstalacionRepo(db).ListarTurnosOcupados(ctx, idsTecnicos, desde, hasta, excluirOrden)
+	if err != nil {
+		return nil, err
+	}
+	libre := func(idTecnico int, inicio, fin time.Time) bool {
+		for _, o := range ocupados {
+			if o.IDTecnico == idTecnico && o.Inicio.Before(fin) && o.Fin.After(inicio) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// 2. Ventanas de cada franja, agrupando los técnicos libres por horario
+	porInicio := map[int64]*ventanaLibre{}
+	for dia := hoy; dia.Before(hasta); dia = dia.AddDate(0, 0, 1) {
+		for _, f := range franjas {
+			if f.DiaSemana != diaSemana(dia) {
+				continue
+			}
+			inicioFranja, err := horaDelDia(dia, f.HoraInicio)
+			if err != nil {
+				return nil, fmt.Errorf("hora de agenda inválida %q: %w", f.HoraInicio, err)
+			}
+			finFranja, err := horaDelDia(dia, f.HoraFin)
+			if err != nil {
+				return nil, fmt.Errorf("hora de agenda inválida %q: %w", f.HoraFin, err)
+			}
+
+			for inicio := inicioFranja; !inicio.Add(DuracionVentanaTurno).After(finFranja); inicio = inicio.Add(DuracionVentanaTurno) {
+				fin := inicio.Add(DuracionVentanaTurno)
+				if inicio.Before(desde) || !libre(f.IDTecnico, inicio, fin) {
+					continue
+				}
+				v, ok := porInicio[inicio.Unix()]
+				if !ok {
+					v = &ventanaLibre{VentanaTurno: modelos.VentanaTurno{Inicio: inicio, Fin: fin}}
+					porInicio[inicio.Unix()] = v
+				}
+				v.tecnicos = append(v.tecnicos, f.IDTecnico)
+			}
+		}
+	}
+
+	ventanas := make([]ventanaLibre, 0, len(porInicio))
+	for _, v := range porInicio {
+		ventanas = append(ventanas, *v)
+	}
+	sort.Slice(ventanas, func(i, j int) bool { return ventanas[i].Inicio.Before(ventanas[j].Inicio) })
+	return ventanas, nil
+}
+
+// Disponibilidad devuelve el turno actual de la conexión y las ventanas que puede reservar
+func (s *TurnoService) Disponibilidad(ctx context.Context, idConexion, idPersona int) (*modelos.DisponibilidadTurnosResponse, error) {
+	datos, err := conexionDelCliente(ctx, s.db, idConexion, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	orden, err := ordenPendiente(ctx, s.db, idConexion)
+	if err != nil {
+		return nil, err
+	}
+
+	ahora := database.NowInArgentina()
+	ventanas, err := ventanasLibres(ctx, s.db, datos, orden.IDOrden, ahora)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &modelos.DisponibilidadTurnosResponse{
+		IDConexion:          idConexion,
+		IDOrden:             orden.IDOrden,
+		Reprogramaciones:    orden.Reprogramaciones,
+		MaxReprogramaciones: MaxReprogramacionesTurno,
+		PuedeModificar:      true,
+		Ventanas:            make([]modelos.VentanaTurno, 0, len(ventanas)),
+	}
+	if orden.TurnoInicio != nil && orden.TurnoFin != nil {
+		response.TurnoActual = &modelos.VentanaTurno{Inicio: *orden.TurnoInicio, Fin: *orden.TurnoFin}
+		response.PuedeModificar = verificarModificable(orden, ahora) == nil
+	}
+	for _, v := range ventanas {
+		response.Ventanas = append(response.Ventanas, v.VentanaTurno)
+	}
+	return response, nil
+}
+
+// Reservar toma una de las ventanas ofrecidas. Si la orden ya tenía turno, es un cambio y
+// se aplican las reglas de reprogramación.
+func (s *TurnoService) Reservar(
+	ctx context.Context,
+	idConexion int,
+	req modelos.ReservarTurnoRequest,
+	idPersona int,
+) (*modelos.TurnoResponse, error) {
+	if req.Inicio.IsZero() {
+		return nil, utilidades.ErrValidation{Campo: "inicio", Mensaje: "es requerido"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Conexión del cliente y orden abierta (bloqueada)
+	datos, err := conexionDelCliente(ctx, tx, idConexion, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	orden, err := ordenPendiente(ctx, tx, idConexion)
+	if err != nil {
+		return nil, err
+	}
+	ahora := database.NowInArgentina()
+	cambio := orden.TurnoInicio != nil
+	if cambio {
+		if err := verificarModificable(orden, ahora); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. La ventana tiene que seguir libre; se prefiere al técnico que ya tenía la orden
+	ventanas, err := ventanasLibres(ctx, tx, datos, orden.IDOrden, ahora)
+	if err != nil {
+		return nil, err
+	}
+	var elegida *ventanaLibre
+	for i := range ventanas {
+		if ventanas[i].Inicio.Equal(req.Inicio) {
+			elegida = &ventanas[i]
+			break
+		}
+	}
+	if elegida == nil {
+		return nil, utilidades.ErrTurnoNoDisponible
+	}
+	idTecnico := elegida.tecnicos[0]
+	for _, id := range elegida.tecnicos {
+		if orden.IDTecnico != nil && id == *orden.IDTecnico {
+			idTecnico = id
+		}
+	}
+
+	// 3. Se bloquea al técnico y se vuelve a mirar la superposición, por si otro cliente
+	// reservó la misma ventana mientras tanto
+	repo := repositorios.NewOrdenInstalacionRepo(tx)
+	if err := repo.BloquearTecnico(ctx, idTecnico); err != nil {
+		return nil, err
+	}
+	idOtra, err := repo.BuscarTurnoSuperpuesto(ctx, idTecnico, orden.IDOrden, elegida.Inicio, elegida.Fin)
+	if err != nil {
+		return nil, err
+	}
+	if idOtra != 0 {
+		return nil, utilidades.ErrTurnoNoDisponible
+	}
+
+	// 4. Asignación, turno y avisos
+	outbox := repositorios.NewOutboxRepo(tx)
+	payload := modelos.PayloadOrdenInstalacion{IDOrden: orden.IDOrden}
+	if orden.IDTecnico == nil || *orden.IDTecnico != idTecnico {
+		if err := repo.Asignar(ctx, orden.IDOrden, idTecnico); err != nil {
+			return nil, err
+		}
+		if _, err := outbox.Registrar(ctx, modelos.EventoInstalacionAsignada, payload); err != nil {
+			return nil, fmt.Errorf("error registrando evento: %w", err)
+		}
+	}
+	if err := repo.Programar(ctx, orden.IDOrden, elegida.Inicio, elegida.Fin); err != nil {
+		return nil, err
+	}
+	if cambio {
+		if err := repo.SumarReprogramacion(ctx, orden.IDOrden); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := outbox.Registrar(ctx, modelos.EventoInstalacionProgramada, payload); err != nil {
+		return nil, fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Cliente %d reservó el turno %s de la orden %d (técnico %d)",
+		idPersona, elegida.Inicio.Format("2006-01-02 15:04"), orden.IDOrden, idTecnico)
+
+	mensaje := "Turno reservado correctamente"
+	if cambio {
+		mensaje = "Turno modificado correctamente"
+	}
+	return &modelos.TurnoResponse{
+		Mensaje:     mensaje,
+		IDConexion:  idConexion,
+		NroConexion: datos.NroConexion,
+		IDOrden:     orden.IDOrden,
+		Turno:       &elegida.VentanaTurno,
+		Correo:      correoTurno(datos),
+	}, nil
+}
+
+// Cancelar libera el turno reservado. La orden sigue abierta para reservar otro.
+func (s *TurnoService) Cancelar(ctx context.Context, idConexion, idPersona int) (*modelos.TurnoResponse, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Conexión del cliente y orden abierta con turno
+	datos, err := conexionDelCliente(ctx, tx, idConexion, idPersona)
+	if err != nil {
+		return nil, err
+	}
+	orden, err := ordenPendiente(ctx, tx, idConexion)
+	if err != nil {
+		return nil, err
+	}
+	if orden.TurnoInicio == nil || orden.TurnoFin == nil {
+		return nil, utilidades.ErrNotFound{Entity: "turno", Campo: "id_conexion", Valor: fmt.Sprintf("%d", idConexion)}
+	}
+	if err := verificarModificable(orden, database.NowInArgentina()); err != nil {
+		return nil, err
+	}
+
+	// 2. Liberar el turno; la cancelación cuenta como un cambio
+	repo := repositorios.NewOrdenInstalacionRepo(tx)
+	if err := repo.QuitarTurno(ctx, orden.IDOrden); err != nil {
+		return nil, err
+	}
+	if err := repo.SumarReprogramacion(ctx, orden.IDOrden); err != nil {
+		return nil, err
+	}
+
+	// 3. Aviso al técnico
+	payload := modelos.PayloadTurnoCancelado{IDOrden: orden.IDOrden, Inicio: *orden.TurnoInicio, Fin: *orden.TurnoFin}
+	if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoTurnoCancelado, payload); err != nil {
+		return nil, fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+
+	logger.Info.Printf("Cliente %d canceló el turno %s de la orden %d",
+		idPersona, orden.TurnoInicio.Format("2006-01-02 15:04"), orden.IDOrden)
+	return &modelos.TurnoResponse{
+		Mensaje:     "Turno cancelado correctamente",
+		IDConexion:  idConexion,
+		NroConexion: datos.NroConexion,
+		IDOrden:     orden.IDOrden,
+		Turno:       &modelos.VentanaTurno{Inicio: *orden.TurnoInicio, Fin: *orden.TurnoFin},
+		Correo:      correoTurno(datos),
+	}, nil
+}
+
+// correoTurno devuelve el destinatario del correo de confirmación, si la persona tiene email
+func correoTurno(datos *modelos.DatosConexionTurno) *modelos.CorreoTurno {
+	if datos.Email == "" {
+		return nil
+	}
+	return &modelos.CorreoTurno{Email: datos.Email, Nombre: datos.Nombre}
+}
+
+// IniciarRecordatorios registra cada IntervaloRecordatorios los recordatorios de los turnos
+// del día siguiente hasta que se cancele el contexto. Se ejecuta en su propia goroutine.
+func (s *TurnoService) IniciarRecordatorios(ctx context.Context) {
+	logger.Info.Printf("Recordatorios de turnos iniciados (intervalo %s)", IntervaloRecordatorios)
+	ticker := time.NewTicker(IntervaloRecordatorios)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.RegistrarRecordatorios(ctx, database.NowInArgentina()); err != nil {
+			logger.Error.Printf("Error registrando recordatorios de turnos: %v", err)
+		} else if n > 0 {
+			logger.Info.Printf("Recordatorios de turnos registrados: %d", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info.Println("Recordatorios de turnos detenidos")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RegistrarRecordatorios registra en el outbox un recordatorio por cada orden programada para
+// el día siguiente a ahora que todavía no lo tuvo. Devuelve cuántos registró.
+func (s *TurnoService) RegistrarRecordatorios(ctx context.Context, ahora time.Time) (int, error) {
+	manana := time.Date(ahora.Year(), ahora.Month(), ahora.Day()+1, 0, 0, 0, 0, ahora.Location())
+	ids, err := repositorios.NewOrdenInstalacionRepo(s.db).ListarSinRecordatorio(ctx, manana, manana.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	registrados := 0
+	for _, idOrden := range ids {
+		ok, err := s.registrarRecordatorio(ctx, idOrden)
+		if err != nil {
+			return registrados, fmt.Errorf("orden %d: %w", idOrden, err)
+		}
+		if ok {
+			registrados++
+		}
+	}
+	return registrados, nil
+}
+
+// registrarRecordatorio marca la orden y registra el evento en una misma transacción, para
+// que otra instancia del servicio no lo registre dos veces
+func (s *TurnoService) registrarRecordatorio(ctx context.Context, idOrden int) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	marcada, err := repositorios.NewOrdenInstalacionRepo(tx).MarcarRecordatorio(ctx, idOrden)
+	if err != nil || !marcada {
+		return false, err
+	}
+	if _, err := repositorios.NewOutboxRepo(tx).Registrar(ctx, modelos.EventoTurnoRecordatorio, modelos.PayloadOrdenInstalacion{IDOrden: idOrden}); err != nil {
+		return false, fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error confirmando transacción: %w", err)
+	}
+	return true, nil
+}
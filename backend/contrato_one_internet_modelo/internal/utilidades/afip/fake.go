@@ -0,0 +1,417 @@
+package afip
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ServidorFake simula el WSAA y el WSFEv1 para desarrollo y pruebas: entrega tickets sin
+// verificar la firma del TRA, lleva la numeración por punto de venta y tipo en memoria y
+// autoriza con un CAE inventado los comprobantes que pasan las validaciones básicas
+// (número correlativo, totales, documento del receptor), que después se pueden consultar.
+// Atiende cualquier ruta y despacha
+// por el elemento del Body, así que sirve como WSAA y como WSFEv1 a la vez.
+type ServidorFake struct {
+	mu       sync.Mutex
+	tokens   map[string]time.Time
+	ultimos  map[[2]int]int
+	emitidos map[[3]int]autorizadoFake
+	proxCAE  int64
+	vigencia time.Duration
+}
+
+// NuevoServidorFake crea el servidor con la numeración en cero
+func NuevoServidorFake() *ServidorFake {
+	return &ServidorFake{
+		tokens:   map[string]time.Time{},
+		ultimos:  map[[2]int]int{},
+		emitidos: map[[3]int]autorizadoFake{},
+		proxCAE:  time.Now().Unix() % 1e9,
+		vigencia: vigenciaTRA,
+	}
+}
+
+// FijarUltimo fija el último número autorizado del tipo de comprobante en el punto de venta,
+// para arrancar el fake alineado con una numeración existente
+func (s *ServidorFake) FijarUltimo(puntoVenta, tipo, numero int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ultimos[[2]int{puntoVenta, tipo}] = numero
+}
+
+func (s *ServidorFake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	cuerpo, err := io.ReadAll(io.LimitReader(r.Body, tamMaxRespuesta))
+	if err != nil {
+		responderFalla(w, "soap:Client", "no se pudo leer el pedido")
+		return
+	}
+
+	var sobre sobreEntrada
+	if err := xml.Unmarshal(cuerpo, &sobre); err != nil {
+		responderFalla(w, "soap:Client", "sobre SOAP inválido")
+		return
+	}
+	operacion := primerElemento(sobre.Cuerpo.Contenido)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var respuesta interface{}
+	switch operacion {
+	case "loginCms":
+		respuesta, err = s.loginCms(sobre.Cuerpo.Contenido)
+	case "FECompUltimoAutorizado":
+		respuesta, err = s.ultimoAutorizado(sobre.Cuerpo.Contenido)
+	case "FECAESolicitar":
+		respuesta, err = s.solicitarCAE(sobre.Cuerpo.Contenido)
+	case "FECompConsultar":
+		respuesta, err = s.consultar(sobre.Cuerpo.Contenido)
+	default:
+		err = fmt.Errorf("operación no soportada: %q", operacion)
+	}
+	if err != nil {
+		responderFalla(w, "soap:Client", err.Error())
+		return
+	}
+
+	out, err := armarSobre(respuesta)
+	if err != nil {
+		responderFalla(w, "soap:Server", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write(out)
+}
+
+// primerElemento devuelve el nombre local del primer elemento del XML
+func primerElemento(contenido []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(contenido))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if inicio, ok := tok.(xml.StartElement); ok {
+			return inicio.Name.Local
+		}
+	}
+}
+
+func responderFalla(w http.ResponseWriter, codigo, mensaje string) {
+	var falla struct {
+		XMLName xml.Name `xml:"soap:Fault"`
+		Codigo  string   `xml:"faultcode"`
+		Mensaje string   `xml:"faultstring"`
+	}
+	falla.Codigo, falla.Mensaje = codigo, mensaje
+	out, _ := armarSobre(falla)
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(out)
+}
+
+// === WSAA ===
+
+type loginCmsResponseFake struct {
+	XMLName xml.Name `xml:"http://wsaa.view.sua.dvadac.desein.afip.gov loginCmsResponse"`
+	Return  string   `xml:"loginCmsReturn"`
+}
+
+type loginTicketResponseFake struct {
+	XMLName xml.Name `xml:"loginTicketResponse"`
+	Version string   `xml:"version,attr"`
+	Header  struct {
+		Source         string `xml:"source"`
+		Destination    string `xml:"destination"`
+		UniqueID       int64  `xml:"uniqueId"`
+		GenerationTime string `xml:"generationTime"`
+		ExpirationTime string `xml:"expirationTime"`
+	} `xml:"header"`
+	Credentials struct {
+		Token string `xml:"token"`
+		Sign  string `xml:"sign"`
+	} `xml:"credentials"`
+}
+
+func (s *ServidorFake) loginCms(contenido []byte) (interface{}, error) {
+	var pedido loginCms
+	if err := xml.Unmarshal(contenido, &pedido); err != nil {
+		return nil, err
+	}
+	if _, err := base64.StdEncoding.DecodeString(pedido.In0); err != nil || pedido.In0 == "" {
+		return nil, fmt.Errorf("cms.bad: el CMS no está en base64")
+	}
+
+	ahora := time.Now()
+	ta := loginTicketResponseFake{Version: "1.0"}
+	ta.Header.Source = "CN=wsaahomo, O=AFIP, C=AR (fake)"
+	ta.Header.UniqueID = ahora.UnixNano()
+	ta.Header.GenerationTime = ahora.Format(time.RFC3339)
+	ta.Header.ExpirationTime = ahora.Add(s.vigencia).Format(time.RFC3339)
+	ta.Credentials.Token = aleatorio(32)
+	ta.Credentials.Sign = aleatorio(16)
+	s.tokens[ta.Credentials.Token] = ahora.Add(s.vigencia)
+
+	out, err := xml.Marshal(ta)
+	if err != nil {
+		return nil, err
+	}
+	return loginCmsResponseFake{Return: xml.Header + string(out)}, nil
+}
+
+// FirmadorFake no firma: devuelve el TRA tal cual. Sólo sirve contra ServidorFake, que no
+// verifica el CMS, para desarrollar sin el certificado de AFIP.
+type FirmadorFake struct{}
+
+func (FirmadorFake) FirmarCMSAdjunto(contenido []byte) ([]byte, error) {
+	return contenido, nil
+}
+
+func aleatorio(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// === WSFEv1 ===
+
+// validarToken devuelve el error 600 de AFIP si el token no fue emitido por este servidor o venció
+func (s *ServidorFake) validarToken(auth autenticacion) []Mensaje {
+	vence, ok := s.tokens[auth.Token]
+	if !ok || time.Now().After(vence) {
+		return []Mensaje{{Codigo: 600, Mensaje: "ValidacionDeToken: No validaron las credenciales"}}
+	}
+	return nil
+}
+
+type feCompUltimoAutorizadoFake struct {
+	XMLName   xml.Name `xml:"http://ar.gov.afip.dif.FEV1/ FECompUltimoAutorizadoResponse"`
+	Resultado struct {
+		PtoVta   int       `xml:"PtoVta"`
+		CbteTipo int       `xml:"CbteTipo"`
+		CbteNro  int       `xml:"CbteNro"`
+		Errores  []Mensaje `xml:"Errors>Err,omitempty"`
+	} `xml:"FECompUltimoAutorizadoResult"`
+}
+
+func (s *ServidorFake) ultimoAutorizado(contenido []byte) (interface{}, error) {
+	var pedido feCompUltimoAutorizado
+	if err := xml.Unmarshal(contenido, &pedido); err != nil {
+		return nil, err
+	}
+	var resp feCompUltimoAutorizadoFake
+	resp.Resultado.PtoVta, resp.Resultado.CbteTipo = pedido.PtoVta, pedido.CbteTipo
+	if resp.Resultado.Errores = s.validarToken(pedido.Auth); resp.Resultado.Errores == nil {
+		resp.Resultado.CbteNro = s.ultimos[[2]int{pedido.PtoVta, pedido.CbteTipo}]
+	}
+	return resp, nil
+}
+
+// detalleCAEFake lee de detalleCAE los campos que valida y devuelve el servidor fake
+type detalleCAEFake struct {
+	DocTipo      int     `xml:"DocTipo"`
+	DocNro       int64   `xml:"DocNro"`
+	CbteDesde    int     `xml:"CbteDesde"`
+	CbteHasta    int     `xml:"CbteHasta"`
+	CbteFch      string  `xml:"CbteFch"`
+	ImpTotal     float64 `xml:"ImpTotal"`
+	ImpTotConc   float64 `xml:"ImpTotConc"`
+	ImpNeto      float64 `xml:"ImpNeto"`
+	ImpOpEx      float64 `xml:"ImpOpEx"`
+	ImpTrib      float64 `xml:"ImpTrib"`
+	ImpIVA       float64 `xml:"ImpIVA"`
+	FchServDesde string  `xml:"FchServDesde"`
+	FchServHasta string  `xml:"FchServHasta"`
+	Iva          []struct {
+		BaseImp float64 `xml:"BaseImp"`
+		Importe float64 `xml:"Importe"`
+	} `xml:"Iva>AlicIva"`
+}
+
+// autorizadoFake es un comprobante autorizado por el servidor fake, para FECompConsultar
+type autorizadoFake struct {
+	detalle   detalleCAEFake
+	cae       string
+	caeFchVto string
+}
+
+type feCAESolicitarFake struct {
+	Auth   autenticacion `xml:"Auth"`
+	Pedido struct {
+		Cabecera struct {
+			CantReg  int `xml:"CantReg"`
+			PtoVta   int `xml:"PtoVta"`
+			CbteTipo int `xml:"CbteTipo"`
+		} `xml:"FeCabReq"`
+		Detalle []detalleCAEFake `xml:"FeDetReq>FECAEDetRequest"`
+	} `xml:"FeCAEReq"`
+}
+
+type detalleRespuestaFake struct {
+	DocTipo       int       `xml:"DocTipo"`
+	DocNro        int64     `xml:"DocNro"`
+	CbteDesde     int       `xml:"CbteDesde"`
+	CbteHasta     int       `xml:"CbteHasta"`
+	CbteFch       string    `xml:"CbteFch"`
+	Resultado     string    `xml:"Resultado"`
+	Observaciones []Mensaje `xml:"Observaciones>Obs,omitempty"`
+	CAE           string    `xml:"CAE"`
+	CAEFchVto     string    `xml:"CAEFchVto"`
+}
+
+type feCAESolicitarResponseFake struct {
+	XMLName   xml.Name `xml:"http://ar.gov.afip.dif.FEV1/ FECAESolicitarResponse"`
+	Resultado struct {
+		Cabecera struct {
+			PtoVta    int    `xml:"PtoVta"`
+			CbteTipo  int    `xml:"CbteTipo"`
+			Resultado string `xml:"Resultado"`
+		} `xml:"FeCabResp"`
+		Detalle []detalleRespuestaFake `xml:"FeDetResp>FECAEDetResponse,omitempty"`
+		Errores []Mensaje              `xml:"Errors>Err,omitempty"`
+	} `xml:"FECAESolicitarResult"`
+}
+
+func (s *ServidorFake) solicitarCAE(contenido []byte) (interface{}, error) {
+	var pedido feCAESolicitarFake
+	if err := xml.Unmarshal(contenido, &pedido); err != nil {
+		return nil, err
+	}
+	cab := pedido.Pedido.Cabecera
+	var resp feCAESolicitarResponseFake
+	resp.Resultado.Cabecera.PtoVta, resp.Resultado.Cabecera.CbteTipo = cab.PtoVta, cab.CbteTipo
+	resp.Resultado.Cabecera.Resultado = ResultadoRechazado
+
+	// 1. Errores del pedido entero
+	if resp.Resultado.Errores = s.validarToken(pedido.Auth); resp.Resultado.Errores != nil {
+		return resp, nil
+	}
+	if cab.CantReg != 1 || len(pedido.Pedido.Detalle) != 1 {
+		resp.Resultado.Errores = []Mensaje{{Codigo: 10001, Mensaje: "El servidor fake sólo autoriza de a un comprobante"}}
+		return resp, nil
+	}
+
+	// 2. Validaciones del comprobante
+	d := pedido.Pedido.Detalle[0]
+	clave := [2]int{cab.PtoVta, cab.CbteTipo}
+	obs := validarComprobanteFake(cab.CbteTipo, d, s.ultimos[clave]+1)
+
+	det := detalleRespuestaFake{
+		DocTipo: d.DocTipo, DocNro: d.DocNro, CbteDesde: d.CbteDesde, CbteHasta: d.CbteHasta,
+		CbteFch: d.CbteFch, Resultado: ResultadoRechazado, Observaciones: obs,
+	}
+	if len(obs) == 0 {
+		emision, err := time.Parse("20060102", d.CbteFch)
+		if err != nil {
+			return nil, fmt.Errorf("CbteFch inválida %q", d.CbteFch)
+		}
+		s.proxCAE++
+		s.ultimos[clave] = d.CbteDesde
+		det.Resultado = ResultadoAprobado
+		det.CAE = fmt.Sprintf("7%013d", s.proxCAE)
+		det.CAEFchVto = emision.AddDate(0, 0, 10).Format("20060102")
+		resp.Resultado.Cabecera.Resultado = ResultadoAprobado
+		s.emitidos[[3]int{cab.PtoVta, cab.CbteTipo, d.CbteDesde}] = autorizadoFake{detalle: d, cae: det.CAE, caeFchVto: det.CAEFchVto}
+	}
+	resp.Resultado.Detalle = []detalleRespuestaFake{det}
+	return resp, nil
+}
+
+// validarComprobanteFake aplica un subconjunto de las validaciones del WSFEv1
+func validarComprobanteFake(tipo int, d detalleCAEFake, siguiente int) []Mensaje {
+	var obs []Mensaje
+	distinto := func(a, b float64) bool { return math.Abs(a-b) > 0.01 }
+
+	if d.CbteDesde != siguiente || d.CbteHasta != d.CbteDesde {
+		obs = append(obs, Mensaje{Codigo: 10016, Mensaje: fmt.Sprintf("El numero de comprobante debe ser el proximo a autorizar (%d)", siguiente)})
+	}
+	if distinto(d.ImpTotal, d.ImpTotConc+d.ImpNeto+d.ImpOpEx+d.ImpTrib+d.ImpIVA) {
+		obs = append(obs, Mensaje{Codigo: 10048, Mensaje: "ImpTotal debe ser la suma de ImpTotConc, ImpNeto, ImpOpEx, ImpTrib e ImpIVA"})
+	}
+
+	var base, iva float64
+	for _, a := range d.Iva {
+		base += a.BaseImp
+		iva += a.Importe
+	}
+	switch {
+	case tipo == CbteFacturaC && (len(d.Iva) > 0 || d.ImpIVA != 0):
+		obs = append(obs, Mensaje{Codigo: 10071, Mensaje: "Los comprobantes C no deben informar IVA"})
+	case tipo != CbteFacturaC && (distinto(iva, d.ImpIVA) || distinto(base, d.ImpNeto)):
+		obs = append(obs, Mensaje{Codigo: 10051, Mensaje: "La suma de las alicuotas de IVA no coincide con ImpNeto e ImpIVA"})
+	}
+
+	switch {
+	case tipo == CbteFacturaA && d.DocTipo != DocCUIT:
+		obs = append(obs, Mensaje{Codigo: 10013, Mensaje: "Los comprobantes A requieren CUIT del receptor (DocTipo 80)"})
+	case d.DocTipo == DocSinIdentificar && d.DocNro != 0:
+		obs = append(obs, Mensaje{Codigo: 10015, Mensaje: "Con DocTipo 99 DocNro debe ser 0"})
+	}
+	return obs
+}
+
+// comprobanteConsultadoFake es el ResultGet de FECompConsultar
+type comprobanteConsultadoFake struct {
+	DocTipo         int     `xml:"DocTipo"`
+	DocNro          int64   `xml:"DocNro"`
+	CbteDesde       int     `xml:"CbteDesde"`
+	CbteHasta       int     `xml:"CbteHasta"`
+	CbteFch         string  `xml:"CbteFch"`
+	ImpTotal        float64 `xml:"ImpTotal"`
+	FchServDesde    string  `xml:"FchServDesde"`
+	FchServHasta    string  `xml:"FchServHasta"`
+	Resultado       string  `xml:"Resultado"`
+	CodAutorizacion string  `xml:"CodAutorizacion"`
+	EmisionTipo     string  `xml:"EmisionTipo"`
+	FchVto          string  `xml:"FchVto"`
+	PtoVta          int     `xml:"PtoVta"`
+	CbteTipo        int     `xml:"CbteTipo"`
+}
+
+type feCompConsultarResponseFake struct {
+	XMLName   xml.Name `xml:"http://ar.gov.afip.dif.FEV1/ FECompConsultarResponse"`
+	Resultado struct {
+		Comprobante *comprobanteConsultadoFake `xml:"ResultGet,omitempty"`
+		Errores     []Mensaje                  `xml:"Errors>Err,omitempty"`
+	} `xml:"FECompConsultarResult"`
+}
+
+// consultar devuelve un comprobante autorizado por este servidor, o el error 602 de AFIP si
+// no lo autorizó (los números fijados con FijarUltimo no se pueden consultar)
+func (s *ServidorFake) consultar(contenido []byte) (interface{}, error) {
+	var pedido feCompConsultar
+	if err := xml.Unmarshal(contenido, &pedido); err != nil {
+		return nil, err
+	}
+	var resp feCompConsultarResponseFake
+	if resp.Resultado.Errores = s.validarToken(pedido.Auth); resp.Resultado.Errores != nil {
+		return resp, nil
+	}
+	p := pedido.Pedido
+	a, ok := s.emitidos[[3]int{p.PtoVta, p.CbteTipo, p.CbteNro}]
+	if !ok {
+		resp.Resultado.Errores = []Mensaje{{Codigo: 602, Mensaje: "No existen datos en nuestros registros para los parametros ingresados."}}
+		return resp, nil
+	}
+
+	d := a.detalle
+	resp.Resultado.Comprobante = &comprobanteConsultadoFake{
+		DocTipo: d.DocTipo, DocNro: d.DocNro, CbteDesde: d.CbteDesde, CbteHasta: d.CbteHasta,
+		CbteFch: d.CbteFch, ImpTotal: d.ImpTotal, FchServDesde: d.FchServDesde, FchServHasta: d.FchServHasta,
+		Resultado: ResultadoAprobado, CodAutorizacion: a.cae, EmisionTipo: "CAE", FchVto: a.caeFchVto,
+		PtoVta: p.PtoVta, CbteTipo: p.CbteTipo,
+	}
+	return resp, nil
+}
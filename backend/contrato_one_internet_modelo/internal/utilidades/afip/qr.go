@@ -0,0 +1,71 @@
+package afip
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// URLBaseQR es la URL de verificación de comprobantes de la RG 4892
+const URLBaseQR = "https://www.afip.gob.ar/fe/qr/"
+
+// DatosQR son los datos del comprobante autorizado que se codifican en el QR
+type DatosQR struct {
+	Fecha      time.Time
+	CUIT       int64
+	PuntoVenta int
+	Tipo       int
+	Numero     int
+	Importe    float64
+	DocTipo    int
+	DocNro     int64
+	CAE        string
+}
+
+// qrRG4892 respeta los nombres y el orden de campos de la especificación
+type qrRG4892 struct {
+	Ver        int         `json:"ver"`
+	Fecha      string      `json:"fecha"`
+	CUIT       int64       `json:"cuit"`
+	PtoVta     int         `json:"ptoVta"`
+	TipoCmp    int         `json:"tipoCmp"`
+	NroCmp     int         `json:"nroCmp"`
+	Importe    json.Number `json:"importe"`
+	Moneda     string      `json:"moneda"`
+	Ctz        int         `json:"ctz"`
+	TipoDocRec int         `json:"tipoDocRec,omitempty"`
+	NroDocRec  int64       `json:"nroDocRec,omitempty"`
+	TipoCodAut string      `json:"tipoCodAut"`
+	CodAut     json.Number `json:"codAut"`
+}
+
+// URLQR arma la URL que se imprime como QR en el comprobante (RG 4892): la URL base con
+// los datos del comprobante en JSON codificado en base64
+func URLQR(d DatosQR) (string, error) {
+	if _, err := strconv.ParseUint(d.CAE, 10, 64); err != nil || len(d.CAE) != 14 {
+		return "", fmt.Errorf("CAE inválido %q", d.CAE)
+	}
+	datos := qrRG4892{
+		Ver:        1,
+		Fecha:      d.Fecha.Format("2006-01-02"),
+		CUIT:       d.CUIT,
+		PtoVta:     d.PuntoVenta,
+		TipoCmp:    d.Tipo,
+		NroCmp:     d.Numero,
+		Importe:    json.Number(strconv.FormatFloat(d.Importe, 'f', 2, 64)),
+		Moneda:     "PES",
+		Ctz:        1,
+		TipoCodAut: "E", // CAE
+		CodAut:     json.Number(d.CAE),
+	}
+	if d.DocTipo != DocSinIdentificar {
+		datos.TipoDocRec, datos.NroDocRec = d.DocTipo, d.DocNro
+	}
+	j, err := json.Marshal(datos)
+	if err != nil {
+		return "", fmt.Errorf("error armando datos del QR: %w", err)
+	}
+	return URLBaseQR + "?p=" + base64.StdEncoding.EncodeToString(j), nil
+}
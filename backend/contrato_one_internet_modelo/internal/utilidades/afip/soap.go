@@ -0,0 +1,78 @@
+package afip
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const nsSOAP = "http://schemas.xmlsoap.org/soap/envelope/"
+
+// ErrorSOAP es un Fault devuelto por el web service
+type ErrorSOAP struct {
+	Codigo  string
+	Mensaje string
+}
+
+func (e *ErrorSOAP) Error() string {
+	return fmt.Sprintf("SOAP fault %s: %s", e.Codigo, e.Mensaje)
+}
+
+type sobreSalida struct {
+	XMLName xml.Name `xml:"soap:Envelope"`
+	NS      string   `xml:"xmlns:soap,attr"`
+	Cuerpo  struct {
+		Contenido interface{}
+	} `xml:"soap:Body"`
+}
+
+type sobreEntrada struct {
+	Cuerpo struct {
+		Falla *struct {
+			Codigo  string `xml:"faultcode"`
+			Mensaje string `xml:"faultstring"`
+		} `xml:"Fault"`
+		Contenido []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// armarSobre envuelve contenido (un struct con XMLName) en un sobre SOAP 1.1
+func armarSobre(contenido interface{}) ([]byte, error) {
+	var s sobreSalida
+	s.NS = nsSOAP
+	s.Cuerpo.Contenido = contenido
+	out, err := xml.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("error armando sobre SOAP: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// leerSobre extrae el contenido del Body de la respuesta en destino, o devuelve el Fault
+func leerSobre(respuesta []byte, destino interface{}) error {
+	var s sobreEntrada
+	if err := xml.Unmarshal(respuesta, &s); err != nil {
+		return fmt.Errorf("respuesta SOAP inválida: %w", err)
+	}
+	if f := s.Cuerpo.Falla; f != nil {
+		return &ErrorSOAP{Codigo: strings.TrimSpace(f.Codigo), Mensaje: strings.TrimSpace(f.Mensaje)}
+	}
+	if err := xml.Unmarshal(s.Cuerpo.Contenido, destino); err != nil {
+		return fmt.Errorf("contenido SOAP inválido: %w", err)
+	}
+	return nil
+}
+
+// llamar arma el sobre, lo envía por el transporte y decodifica la respuesta
+func llamar(ctx context.Context, t Transporte, url, accion string, pedido, respuesta interface{}) error {
+	sobre, err := armarSobre(pedido)
+	if err != nil {
+		return err
+	}
+	cuerpo, err := t.Enviar(ctx, url, accion, sobre)
+	if err != nil {
+		return err
+	}
+	return leerSobre(cuerpo, respuesta)
+}
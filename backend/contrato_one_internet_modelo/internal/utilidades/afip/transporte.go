@@ -0,0 +1,81 @@
+// Package afip implementa los clientes SOAP de los web services de AFIP que usa la
+// facturación electrónica: WSAA (autenticación) y WSFEv1 (autorización de comprobantes con
+// CAE), además de la URL del código QR de la RG 4892 y un servidor fake para desarrollo.
+//
+// Los clientes no abren conexiones por su cuenta: envían cada sobre SOAP a través de un
+// Transporte, que puede ser HTTP (homologación/producción) o local, atendido en el mismo
+// proceso por un http.Handler como ServidorFake.
+package afip
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// tamMaxRespuesta limita lo que se lee de una respuesta SOAP
+const tamMaxRespuesta = 4 << 20
+
+// Transporte envía un sobre SOAP a url con la cabecera SOAPAction accion y devuelve el
+// sobre de la respuesta. Las respuestas con Fault se devuelven igual: las interpreta el cliente.
+type Transporte interface {
+	Enviar(ctx context.Context, url, accion string, sobre []byte) ([]byte, error)
+}
+
+// TransporteHTTP envía los sobres a los web services por HTTP(S)
+type TransporteHTTP struct {
+	cliente *http.Client
+}
+
+// NuevoTransporteHTTP crea un transporte HTTP con el timeout dado para cada llamada
+func NuevoTransporteHTTP(timeout time.Duration) *TransporteHTTP {
+	return &TransporteHTTP{cliente: &http.Client{Timeout: timeout}}
+}
+
+func (t *TransporteHTTP) Enviar(ctx context.Context, url, accion string, sobre []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(sobre))
+	if err != nil {
+		return nil, fmt.Errorf("error creando request SOAP: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `"`+accion+`"`)
+
+	resp, err := t.cliente.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error llamando a %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	cuerpo, err := io.ReadAll(io.LimitReader(resp.Body, tamMaxRespuesta))
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo respuesta de %s: %w", url, err)
+	}
+	// Los Fault llegan con 500 y se interpretan en el cliente; otro estado es un error de transporte
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+		return nil, fmt.Errorf("%s respondió %d", url, resp.StatusCode)
+	}
+	return cuerpo, nil
+}
+
+// TransporteLocal atiende los sobres con un http.Handler en el mismo proceso, sin red.
+// Se usa con ServidorFake en desarrollo.
+type TransporteLocal struct {
+	Handler http.Handler
+}
+
+func (t TransporteLocal) Enviar(ctx context.Context, url, accion string, sobre []byte) ([]byte, error) {
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(sobre)).WithContext(ctx)
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `"`+accion+`"`)
+
+	rec := httptest.NewRecorder()
+	t.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK && rec.Code != http.StatusInternalServerError {
+		return nil, fmt.Errorf("%s respondió %d", url, rec.Code)
+	}
+	return rec.Body.Bytes(), nil
+}
@@ -0,0 +1,193 @@
+package afip
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URLs del WSAA
+const (
+	URLWSAAHomologacion = "https://wsaahomo.afip.gov.ar/ws/services/LoginCms"
+	URLWSAAProduccion   = "https://wsaa.afip.gov.ar/ws/services/LoginCms"
+)
+
+const (
+	// vigenciaTRA es la vigencia pedida para el ticket de acceso (AFIP acepta hasta 12 h)
+	vigenciaTRA = 12 * time.Hour
+	// margenRenovacion adelanta la renovación del ticket para no usarlo a punto de vencer
+	margenRenovacion = 10 * time.Minute
+)
+
+// Firmador firma el TRA en un CMS con el contenido adjunto. Lo implementa *pdf.Firmante
+// con el certificado que AFIP asoció al CUIT emisor.
+type Firmador interface {
+	FirmarCMSAdjunto(contenido []byte) ([]byte, error)
+}
+
+// Ticket es el ticket de acceso (TA) que devuelve el WSAA para un servicio
+type Ticket struct {
+	Token string
+	Sign  string
+	Vence time.Time
+}
+
+// vigente indica si el ticket todavía puede usarse en el instante dado
+func (t *Ticket) vigente(ahora time.Time) bool {
+	return t != nil && ahora.Add(margenRenovacion).Before(t.Vence)
+}
+
+// CacheTickets guarda los tickets de acceso. AFIP rechaza un nuevo login mientras el ticket
+// anterior siga vigente, así que conviene que sobreviva a reinicios y se comparta entre
+// procesos (por ejemplo, en la base de datos).
+type CacheTickets interface {
+	Obtener(ctx context.Context, servicio string) (*Ticket, error)
+	Guardar(ctx context.Context, servicio string, t *Ticket) error
+	Borrar(ctx context.Context, servicio string) error
+}
+
+// WSAA obtiene y reutiliza los tickets de acceso a los web services de negocio
+type WSAA struct {
+	transporte Transporte
+	url        string
+	firmador   Firmador
+	cache      CacheTickets // opcional
+
+	mu      sync.Mutex
+	tickets map[string]*Ticket
+	ahora   func() time.Time
+}
+
+// NuevoWSAA crea el cliente del WSAA. cache puede ser nil: en ese caso los tickets sólo se
+// guardan en memoria.
+func NuevoWSAA(transporte Transporte, url string, firmador Firmador, cache CacheTickets) *WSAA {
+	return &WSAA{
+		transporte: transporte,
+		url:        url,
+		firmador:   firmador,
+		cache:      cache,
+		tickets:    map[string]*Ticket{},
+		ahora:      time.Now,
+	}
+}
+
+// Ticket devuelve un ticket de acceso vigente para el servicio (por ejemplo "wsfe"),
+// pidiéndolo al WSAA sólo si no hay uno guardado
+func (a *WSAA) Ticket(ctx context.Context, servicio string) (*Ticket, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ahora := a.ahora()
+	if t := a.tickets[servicio]; t.vigente(ahora) {
+		return t, nil
+	}
+	if a.cache != nil {
+		t, err := a.cache.Obtener(ctx, servicio)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo ticket de acceso guardado: %w", err)
+		}
+		if t.vigente(ahora) {
+			a.tickets[servicio] = t
+			return t, nil
+		}
+	}
+
+	t, err := a.login(ctx, servicio, ahora)
+	if err != nil {
+		return nil, err
+	}
+	a.tickets[servicio] = t
+	if a.cache != nil {
+		if err := a.cache.Guardar(ctx, servicio, t); err != nil {
+			return nil, fmt.Errorf("error guardando ticket de acceso: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// Invalidar descarta el ticket del servicio para que el próximo pedido haga un nuevo login.
+// Se usa cuando el web service de negocio rechaza las credenciales.
+func (a *WSAA) Invalidar(ctx context.Context, servicio string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.tickets, servicio)
+	if a.cache != nil {
+		return a.cache.Borrar(ctx, servicio)
+	}
+	return nil
+}
+
+// loginTicketRequest es el TRA (ticket de requerimiento de acceso)
+type loginTicketRequest struct {
+	XMLName xml.Name `xml:"loginTicketRequest"`
+	Version string   `xml:"version,attr"`
+	Header  struct {
+		UniqueID       int64  `xml:"uniqueId"`
+		GenerationTime string `xml:"generationTime"`
+		ExpirationTime string `xml:"expirationTime"`
+	} `xml:"header"`
+	Service string `xml:"service"`
+}
+
+type loginCms struct {
+	XMLName xml.Name `xml:"http://wsaa.view.sua.dvadac.desein.afip.gov loginCms"`
+	In0     string   `xml:"in0"`
+}
+
+type loginCmsResponse struct {
+	Return string `xml:"loginCmsReturn"`
+}
+
+// loginTicketResponse es el TA, que llega como XML escapado dentro de loginCmsReturn
+type loginTicketResponse struct {
+	Header struct {
+		ExpirationTime string `xml:"expirationTime"`
+	} `xml:"header"`
+	Credentials struct {
+		Token string `xml:"token"`
+		Sign  string `xml:"sign"`
+	} `xml:"credentials"`
+}
+
+// login arma el TRA, lo firma y lo canjea por un ticket de acceso
+func (a *WSAA) login(ctx context.Context, servicio string, ahora time.Time) (*Ticket, error) {
+	// 1. TRA con una ventana que tolera relojes desfasados
+	tra := loginTicketRequest{Version: "1.0", Service: servicio}
+	tra.Header.UniqueID = ahora.Unix()
+	tra.Header.GenerationTime = ahora.Add(-10 * time.Minute).Format(time.RFC3339)
+	tra.Header.ExpirationTime = ahora.Add(vigenciaTRA).Format(time.RFC3339)
+	xmlTRA, err := xml.Marshal(tra)
+	if err != nil {
+		return nil, fmt.Errorf("error armando TRA: %w", err)
+	}
+
+	// 2. Firma CMS con el contenido adjunto
+	cms, err := a.firmador.FirmarCMSAdjunto(append([]byte(xml.Header), xmlTRA...))
+	if err != nil {
+		return nil, fmt.Errorf("error firmando TRA: %w", err)
+	}
+
+	// 3. loginCms
+	var resp loginCmsResponse
+	if err := llamar(ctx, a.transporte, a.url, "", loginCms{In0: base64.StdEncoding.EncodeToString(cms)}, &resp); err != nil {
+		return nil, fmt.Errorf("error en WSAA loginCms: %w", err)
+	}
+
+	var ta loginTicketResponse
+	if err := xml.Unmarshal([]byte(resp.Return), &ta); err != nil {
+		return nil, fmt.Errorf("ticket de acceso inválido: %w", err)
+	}
+	vence, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(ta.Header.ExpirationTime))
+	if err != nil {
+		return nil, fmt.Errorf("vencimiento del ticket de acceso inválido %q: %w", ta.Header.ExpirationTime, err)
+	}
+	if ta.Credentials.Token == "" || ta.Credentials.Sign == "" {
+		return nil, fmt.Errorf("el ticket de acceso no trae token y sign")
+	}
+	return &Ticket{Token: ta.Credentials.Token, Sign: ta.Credentials.Sign, Vence: vence}, nil
+}
@@ -0,0 +1,438 @@
+package afip
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLs del WSFEv1
+const (
+	URLWSFEHomologacion = "https://wswhomo.afip.gov.ar/wsfev1/service.asmx"
+	URLWSFEProduccion   = "https://servicios1.afip.gov.ar/wsfev1/service.asmx"
+)
+
+const (
+	nsWSFE       = "http://ar.gov.afip.dif.FEV1/"
+	servicioWSFE = "wsfe"
+)
+
+// Tipos de comprobante (tabla FEParamGetTiposCbte)
+const (
+	CbteFacturaA = 1
+	CbteFacturaB = 6
+	CbteFacturaC = 11
+)
+
+// Tipos de documento del receptor (tabla FEParamGetTiposDoc)
+const (
+	DocCUIT           = 80
+	DocCUIL           = 86
+	DocDNI            = 96
+	DocSinIdentificar = 99
+)
+
+// Alícuotas de IVA (tabla FEParamGetTiposIva)
+const (
+	IVA21 = 5
+	IVA27 = 6
+)
+
+// Condición frente al IVA del receptor (RG 5616, tabla FEParamGetCondicionIvaReceptor)
+const (
+	CondicionIVAResponsableInscripto = 1
+	CondicionIVAExento               = 4
+	CondicionIVAConsumidorFinal      = 5
+	CondicionIVAMonotributo          = 6
+)
+
+// ConceptoServicios es el concepto de los comprobantes de servicios: exige las fechas del
+// período facturado y del vencimiento del pago
+const ConceptoServicios = 2
+
+// Resultados de FECAESolicitar
+const (
+	ResultadoAprobado  = "A"
+	ResultadoRechazado = "R"
+)
+
+// Comprobante es un comprobante a autorizar, en pesos
+type Comprobante struct {
+	PuntoVenta           int
+	Tipo                 int
+	Numero               int
+	Concepto             int
+	DocTipo              int
+	DocNro               int64
+	CondicionIVAReceptor int
+	Fecha                time.Time
+	ServicioDesde        time.Time
+	ServicioHasta        time.Time
+	VencimientoPago      time.Time
+	ImporteTotal         float64
+	ImporteNeto          float64 // en la factura C, igual al total
+	ImporteIVA           float64
+	Alicuotas            []AlicuotaIVA // vacío en la factura C
+}
+
+// AlicuotaIVA es la base imponible y el IVA de una alícuota del comprobante
+type AlicuotaIVA struct {
+	ID            int
+	BaseImponible float64
+	Importe       float64
+}
+
+// Mensaje es un error u observación devuelto por el WSFEv1
+type Mensaje struct {
+	Codigo  int    `xml:"Code"`
+	Mensaje string `xml:"Msg"`
+}
+
+func (m Mensaje) String() string {
+	return fmt.Sprintf("%d: %s", m.Codigo, m.Mensaje)
+}
+
+// Autorizacion es la respuesta de FECAESolicitar para el comprobante
+type Autorizacion struct {
+	Resultado      string // ResultadoAprobado o ResultadoRechazado
+	CAE            string
+	CAEVencimiento time.Time
+	Observaciones  []Mensaje
+}
+
+// ErrorWSFE agrupa los errores (Errors/Err) con los que el WSFEv1 rechaza un pedido entero
+type ErrorWSFE struct {
+	Errores []Mensaje
+}
+
+func (e *ErrorWSFE) Error() string {
+	partes := make([]string, len(e.Errores))
+	for i, m := range e.Errores {
+		partes[i] = m.String()
+	}
+	return "WSFEv1: " + strings.Join(partes, "; ")
+}
+
+// credencialesRechazadas indica si el error es el 600 (token o sign inválidos)
+func (e *ErrorWSFE) credencialesRechazadas() bool {
+	for _, m := range e.Errores {
+		if m.Codigo == 600 {
+			return true
+		}
+	}
+	return false
+}
+
+// WSFE es el cliente del web service de factura electrónica (WSFEv1)
+type WSFE struct {
+	transporte Transporte
+	url        string
+	wsaa       *WSAA
+	cuit       int64
+}
+
+// NuevoWSFE crea el cliente del WSFEv1 para el CUIT emisor
+func NuevoWSFE(transporte Transporte, url string, wsaa *WSAA, cuit int64) *WSFE {
+	return &WSFE{transporte: transporte, url: url, wsaa: wsaa, cuit: cuit}
+}
+
+// CUIT devuelve el CUIT emisor
+func (c *WSFE) CUIT() int64 {
+	return c.cuit
+}
+
+type autenticacion struct {
+	Token string `xml:"Token"`
+	Sign  string `xml:"Sign"`
+	Cuit  int64  `xml:"Cuit"`
+}
+
+// importe se serializa con dos decimales, como lo espera el WSFEv1
+type importe float64
+
+func (i importe) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(i), 'f', 2, 64)), nil
+}
+
+type fecha time.Time
+
+func (f fecha) MarshalText() ([]byte, error) {
+	return []byte(time.Time(f).Format("20060102")), nil
+}
+
+// errorDeRespuesta arma el ErrorWSFE y, si AFIP rechazó las credenciales, descarta el ticket
+// para que el próximo pedido haga un nuevo login
+func (c *WSFE) errorDeRespuesta(ctx context.Context, errores []Mensaje) error {
+	e := &ErrorWSFE{Errores: errores}
+	if e.credencialesRechazadas() {
+		if err := c.wsaa.Invalidar(ctx, servicioWSFE); err != nil {
+			return fmt.Errorf("%v (y no se pudo descartar el ticket: %w)", e, err)
+		}
+	}
+	return e
+}
+
+func (c *WSFE) autenticacion(ctx context.Context) (autenticacion, error) {
+	t, err := c.wsaa.Ticket(ctx, servicioWSFE)
+	if err != nil {
+		return autenticacion{}, err
+	}
+	return autenticacion{Token: t.Token, Sign: t.Sign, Cuit: c.cuit}, nil
+}
+
+type feCompUltimoAutorizado struct {
+	XMLName  xml.Name      `xml:"http://ar.gov.afip.dif.FEV1/ FECompUltimoAutorizado"`
+	Auth     autenticacion `xml:"Auth"`
+	PtoVta   int           `xml:"PtoVta"`
+	CbteTipo int           `xml:"CbteTipo"`
+}
+
+type feCompUltimoAutorizadoResponse struct {
+	Resultado struct {
+		CbteNro int       `xml:"CbteNro"`
+		Errores []Mensaje `xml:"Errors>Err"`
+	} `xml:"FECompUltimoAutorizadoResult"`
+}
+
+// UltimoAutorizado devuelve el último número autorizado del tipo de comprobante en el punto
+// de venta (0 si todavía no hay ninguno)
+func (c *WSFE) UltimoAutorizado(ctx context.Context, puntoVenta, tipo int) (int, error) {
+	auth, err := c.autenticacion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp feCompUltimoAutorizadoResponse
+	pedido := feCompUltimoAutorizado{Auth: auth, PtoVta: puntoVenta, CbteTipo: tipo}
+	if err := llamar(ctx, c.transporte, c.url, nsWSFE+"FECompUltimoAutorizado", pedido, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Resultado.Errores) > 0 {
+		return 0, c.errorDeRespuesta(ctx, resp.Resultado.Errores)
+	}
+	return resp.Resultado.CbteNro, nil
+}
+
+type feCAESolicitar struct {
+	XMLName xml.Name      `xml:"http://ar.gov.afip.dif.FEV1/ FECAESolicitar"`
+	Auth    autenticacion `xml:"Auth"`
+	Pedido  struct {
+		Cabecera struct {
+			CantReg  int `xml:"CantReg"`
+			PtoVta   int `xml:"PtoVta"`
+			CbteTipo int `xml:"CbteTipo"`
+		} `xml:"FeCabReq"`
+		Detalle []detalleCAE `xml:"FeDetReq>FECAEDetRequest"`
+	} `xml:"FeCAEReq"`
+}
+
+// detalleCAE respeta el orden de elementos del WSDL
+type detalleCAE struct {
+	Concepto               int     `xml:"Concepto"`
+	DocTipo                int     `xml:"DocTipo"`
+	DocNro                 int64   `xml:"DocNro"`
+	CbteDesde              int     `xml:"CbteDesde"`
+	CbteHasta              int     `xml:"CbteHasta"`
+	CbteFch                fecha   `xml:"CbteFch"`
+	ImpTotal               importe `xml:"ImpTotal"`
+	ImpTotConc             importe `xml:"ImpTotConc"`
+	ImpNeto                importe `xml:"ImpNeto"`
+	ImpOpEx                importe `xml:"ImpOpEx"`
+	ImpTrib                importe `xml:"ImpTrib"`
+	ImpIVA                 importe `xml:"ImpIVA"`
+	FchServDesde           fecha   `xml:"FchServDesde"`
+	FchServHasta           fecha   `xml:"FchServHasta"`
+	FchVtoPago             fecha   `xml:"FchVtoPago"`
+	MonID                  string  `xml:"MonId"`
+	MonCotiz               int     `xml:"MonCotiz"`
+	CondicionIVAReceptorID int     `xml:"CondicionIVAReceptorId"`
+	Iva                    *ivaXML `xml:"Iva,omitempty"`
+}
+
+type ivaXML struct {
+	Alicuotas []alicuotaXML `xml:"AlicIva"`
+}
+
+type alicuotaXML struct {
+	ID      int     `xml:"Id"`
+	BaseImp importe `xml:"BaseImp"`
+	Importe importe `xml:"Importe"`
+}
+
+type feCAESolicitarResponse struct {
+	Resultado struct {
+		Cabecera struct {
+			Resultado string `xml:"Resultado"`
+		} `xml:"FeCabResp"`
+		Detalle []struct {
+			Resultado     string    `xml:"Resultado"`
+			CAE           string    `xml:"CAE"`
+			CAEFchVto     string    `xml:"CAEFchVto"`
+			Observaciones []Mensaje `xml:"Observaciones>Obs"`
+		} `xml:"FeDetResp>FECAEDetResponse"`
+		Errores []Mensaje `xml:"Errors>Err"`
+	} `xml:"FECAESolicitarResult"`
+}
+
+// SolicitarCAE pide la autorización del comprobante. Un rechazo de AFIP no es un error:
+// vuelve como Autorizacion con ResultadoRechazado y los motivos en Observaciones.
+func (c *WSFE) SolicitarCAE(ctx context.Context, cbte Comprobante) (*Autorizacion, error) {
+	auth, err := c.autenticacion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Pedido de un único comprobante
+	pedido := feCAESolicitar{Auth: auth}
+	pedido.Pedido.Cabecera.CantReg = 1
+	pedido.Pedido.Cabecera.PtoVta = cbte.PuntoVenta
+	pedido.Pedido.Cabecera.CbteTipo = cbte.Tipo
+	det := detalleCAE{
+		Concepto:               cbte.Concepto,
+		DocTipo:                cbte.DocTipo,
+		DocNro:                 cbte.DocNro,
+		CbteDesde:              cbte.Numero,
+		CbteHasta:              cbte.Numero,
+		CbteFch:                fecha(cbte.Fecha),
+		ImpTotal:               importe(cbte.ImporteTotal),
+		ImpNeto:                importe(cbte.ImporteNeto),
+		ImpIVA:                 importe(cbte.ImporteIVA),
+		FchServDesde:           fecha(cbte.ServicioDesde),
+		FchServHasta:           fecha(cbte.ServicioHasta),
+		FchVtoPago:             fecha(cbte.VencimientoPago),
+		MonID:                  "PES",
+		MonCotiz:               1,
+		CondicionIVAReceptorID: cbte.CondicionIVAReceptor,
+	}
+	if len(cbte.Alicuotas) > 0 {
+		det.Iva = &ivaXML{}
+		for _, a := range cbte.Alicuotas {
+			det.Iva.Alicuotas = append(det.Iva.Alicuotas, alicuotaXML{ID: a.ID, BaseImp: importe(a.BaseImponible), Importe: importe(a.Importe)})
+		}
+	}
+	pedido.Pedido.Detalle = []detalleCAE{det}
+
+	// 2. Llamada
+	var resp feCAESolicitarResponse
+	if err := llamar(ctx, c.transporte, c.url, nsWSFE+"FECAESolicitar", pedido, &resp); err != nil {
+		return nil, err
+	}
+
+	// 3. Sin detalle, AFIP rechazó el pedido entero (autenticación, cabecera, etc.)
+	r := resp.Resultado
+	if len(r.Detalle) == 0 {
+		if len(r.Errores) == 0 {
+			return nil, fmt.Errorf("WSFEv1: respuesta sin detalle ni errores")
+		}
+		return nil, c.errorDeRespuesta(ctx, r.Errores)
+	}
+
+	d := r.Detalle[0]
+	aut := &Autorizacion{Resultado: d.Resultado, Observaciones: append(d.Observaciones, r.Errores...)}
+	if d.Resultado != ResultadoAprobado {
+		aut.Resultado = ResultadoRechazado
+		return aut, nil
+	}
+	if len(d.CAE) != 14 {
+		return nil, fmt.Errorf("WSFEv1: CAE inválido %q", d.CAE)
+	}
+	if aut.CAEVencimiento, err = time.ParseInLocation("20060102", d.CAEFchVto, cbte.Fecha.Location()); err != nil {
+		return nil, fmt.Errorf("WSFEv1: vencimiento de CAE inválido %q", d.CAEFchVto)
+	}
+	aut.CAE = d.CAE
+	return aut, nil
+}
+
+// ComprobanteAutorizado es un comprobante que AFIP ya autorizó, tal como lo devuelve
+// FECompConsultar
+type ComprobanteAutorizado struct {
+	PuntoVenta     int
+	Tipo           int
+	Numero         int
+	DocTipo        int
+	DocNro         int64
+	Fecha          time.Time
+	ServicioDesde  time.Time
+	ServicioHasta  time.Time
+	ImporteTotal   float64
+	CAE            string
+	CAEVencimiento time.Time
+}
+
+type feCompConsultar struct {
+	XMLName xml.Name      `xml:"http://ar.gov.afip.dif.FEV1/ FECompConsultar"`
+	Auth    autenticacion `xml:"Auth"`
+	Pedido  struct {
+		CbteTipo int `xml:"CbteTipo"`
+		CbteNro  int `xml:"CbteNro"`
+		PtoVta   int `xml:"PtoVta"`
+	} `xml:"FeCompConsReq"`
+}
+
+type feCompConsultarResponse struct {
+	Resultado struct {
+		Comprobante struct {
+			DocTipo         int     `xml:"DocTipo"`
+			DocNro          int64   `xml:"DocNro"`
+			CbteDesde       int     `xml:"CbteDesde"`
+			CbteFch         string  `xml:"CbteFch"`
+			ImpTotal        float64 `xml:"ImpTotal"`
+			FchServDesde    string  `xml:"FchServDesde"`
+			FchServHasta    string  `xml:"FchServHasta"`
+			Resultado       string  `xml:"Resultado"`
+			CodAutorizacion string  `xml:"CodAutorizacion"`
+			FchVto          string  `xml:"FchVto"`
+		} `xml:"ResultGet"`
+		Errores []Mensaje `xml:"Errors>Err"`
+	} `xml:"FECompConsultarResult"`
+}
+
+// ConsultarComprobante devuelve un comprobante autorizado del tipo y punto de venta. Sirve
+// para recuperar el CAE de un pedido cuya respuesta se perdió: AFIP lo autorizó aunque el
+// cliente no se haya enterado.
+func (c *WSFE) ConsultarComprobante(ctx context.Context, puntoVenta, tipo, numero int) (*ComprobanteAutorizado, error) {
+	auth, err := c.autenticacion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pedido := feCompConsultar{Auth: auth}
+	pedido.Pedido.CbteTipo, pedido.Pedido.CbteNro, pedido.Pedido.PtoVta = tipo, numero, puntoVenta
+	var resp feCompConsultarResponse
+	if err := llamar(ctx, c.transporte, c.url, nsWSFE+"FECompConsultar", pedido, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Resultado.Errores) > 0 {
+		return nil, c.errorDeRespuesta(ctx, resp.Resultado.Errores)
+	}
+
+	r := resp.Resultado.Comprobante
+	if r.Resultado != ResultadoAprobado || len(r.CodAutorizacion) != 14 {
+		return nil, fmt.Errorf("WSFEv1: el comprobante %04d-%08d no está autorizado", puntoVenta, numero)
+	}
+	cbte := &ComprobanteAutorizado{
+		PuntoVenta: puntoVenta, Tipo: tipo, Numero: r.CbteDesde, DocTipo: r.DocTipo, DocNro: r.DocNro,
+		ImporteTotal: r.ImpTotal, CAE: r.CodAutorizacion,
+	}
+	// Son fechas sin hora: se leen en UTC y sólo importa el día
+	for _, f := range []struct {
+		texto   string
+		destino *time.Time
+	}{
+		{r.CbteFch, &cbte.Fecha},
+		{r.FchServDesde, &cbte.ServicioDesde},
+		{r.FchServHasta, &cbte.ServicioHasta},
+		{r.FchVto, &cbte.CAEVencimiento},
+	} {
+		if f.texto == "" {
+			continue
+		}
+		if *f.destino, err = time.Parse("20060102", f.texto); err != nil {
+			return nil, fmt.Errorf("WSFEv1: fecha inválida %q en el comprobante %04d-%08d", f.texto, puntoVenta, numero)
+		}
+	}
+	return cbte, nil
+}
@@ -0,0 +1,69 @@
+package utilidades
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// claveAES deriva una clave AES-256 del secreto configurado
+func claveAES(secreto string) []byte {
+	sum := sha256.Sum256([]byte(secreto))
+	return sum[:]
+}
+
+// Cifrar cifra texto con AES-256-GCM y devuelve nonce+cifrado en base64. Se usa para los
+// secretos que hay que poder recuperar (por ejemplo el secreto TOTP), no para contraseñas.
+func Cifrar(secreto, texto string) (string, error) {
+	bloque, err := aes.NewCipher(claveAES(secreto))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(bloque)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(texto), nil)), nil
+}
+
+// Descifrar revierte Cifrar; falla si el dato fue alterado o se cifró con otro secreto
+func Descifrar(secreto, cifrado string) (string, error) {
+	datos, err := base64.StdEncoding.DecodeString(cifrado)
+	if err != nil {
+		return "", fmt.Errorf("dato cifrado inválido: %w", err)
+	}
+	bloque, err := aes.NewCipher(claveAES(secreto))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(bloque)
+	if err != nil {
+		return "", err
+	}
+	if len(datos) < gcm.NonceSize() {
+		return "", errors.New("dato cifrado inválido: demasiado corto")
+	}
+	texto, err := gcm.Open(nil, datos[:gcm.NonceSize()], datos[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo descifrar: %w", err)
+	}
+	return string(texto), nil
+}
+
+// HashCodigo calcula el HMAC-SHA256 (hex) de un código de un solo uso. Con la clave del
+// servidor, una copia de la base no alcanza para probar los códigos cortos por fuerza bruta.
+func HashCodigo(secreto, codigo string) string {
+	mac := hmac.New(sha256.New, []byte(secreto))
+	mac.Write([]byte(codigo))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package utilidades
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Alfabeto Base32 de Crockford: sin I, L, O ni U para evitar confusiones al tipearlo
+const alfabetoCodigoVerificacion = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const largoCodigoVerificacion = 12
+
+// GenerarCodigoVerificacion genera un código público de verificación de contrato
+// con el formato XXXX-XXXX-XXXX (60 bits aleatorios).
+func GenerarCodigoVerificacion() (string, error) {
+	b := make([]byte, largoCodigoVerificacion)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = alfabetoCodigoVerificacion[int(b[i])%len(alfabetoCodigoVerificacion)]
+	}
+	return agruparCodigo(string(b)), nil
+}
+
+// NormalizarCodigoVerificacion acepta el código con o sin guiones, en minúsculas y con
+// las letras ambiguas (O, I, L) y lo devuelve en el formato almacenado.
+func NormalizarCodigoVerificacion(codigo string) (string, error) {
+	limpio := strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1").
+		Replace(strings.ToUpper(strings.TrimSpace(codigo)))
+
+	if len(limpio) != largoCodigoVerificacion {
+		return "", ErrValidation{Campo: "codigo", Mensaje: "debe tener 12 caracteres"}
+	}
+	for _, c := range limpio {
+		if !strings.ContainsRune(alfabetoCodigoVerificacion, c) {
+			return "", ErrValidation{Campo: "codigo", Mensaje: "contiene caracteres inválidos"}
+		}
+	}
+	return agruparCodigo(limpio), nil
+}
+
+func agruparCodigo(s string) string {
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12]
+}
+
+const largoCodigoRecuperacion = 10
+
+// GenerarCodigoRecuperacion genera un código de recuperación de la autenticación en dos
+// pasos con el formato XXXXX-XXXXX (50 bits aleatorios)
+func GenerarCodigoRecuperacion() (string, error) {
+	b := make([]byte, largoCodigoRecuperacion)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = alfabetoCodigoVerificacion[int(b[i])%len(alfabetoCodigoVerificacion)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// NormalizarCodigoRecuperacion quita guiones y espacios y corrige las letras ambiguas, para
+// comparar el código tal como lo tipee el usuario
+func NormalizarCodigoRecuperacion(codigo string) string {
+	return strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1").
+		Replace(strings.ToUpper(strings.TrimSpace(codigo)))
+}
+
+// GenerarCodigoNumerico genera un código de digitos cifras decimales, uniforme
+func GenerarCodigoNumerico(digitos int) (string, error) {
+	maximo := big.NewInt(1)
+	for i := 0; i < digitos; i++ {
+		maximo.Mul(maximo, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, maximo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digitos, n), nil
+}
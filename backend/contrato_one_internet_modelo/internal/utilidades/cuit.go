@@ -0,0 +1,47 @@
+package utilidades
+
+import "strings"
+
+// NormalizarCUIT quita guiones y espacios de un CUIT/CUIL
+func NormalizarCUIT(cuit string) string {
+	return strings.NewReplacer("-", "", " ", "", ".", "").Replace(strings.TrimSpace(cuit))
+}
+
+// ValidarCUIT verifica formato (11 dígitos), prefijo y dígito verificador (módulo 11) de un CUIT/CUIL.
+// Recibe el CUIT ya normalizado.
+func ValidarCUIT(cuit string) error {
+	if len(cuit) != 11 {
+		return ErrValidation{Campo: "cuit", Mensaje: "debe contener 11 dígitos"}
+	}
+	digitos := make([]int, 11)
+	for i, c := range cuit {
+		if c < '0' || c > '9' {
+			return ErrValidation{Campo: "cuit", Mensaje: "debe contener sólo dígitos"}
+		}
+		digitos[i] = int(c - '0')
+	}
+
+	switch cuit[:2] {
+	case "20", "23", "24", "27", "30", "33", "34":
+	default:
+		return ErrValidation{Campo: "cuit", Mensaje: "prefijo inválido"}
+	}
+
+	pesos := [10]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}
+	suma := 0
+	for i, p := range pesos {
+		suma += digitos[i] * p
+	}
+	verificador := 11 - suma%11
+	switch verificador {
+	case 11:
+		verificador = 0
+	case 10:
+		// Con resto 1 el CUIT debería haberse emitido con otro prefijo
+		return ErrValidation{Campo: "cuit", Mensaje: "dígito verificador inválido"}
+	}
+	if verificador != digitos[10] {
+		return ErrValidation{Campo: "cuit", Mensaje: "dígito verificador inválido"}
+	}
+	return nil
+}
@@ -30,6 +30,35 @@ func (e ErrNotFound) Error() string {
 	return fmt.Sprintf("%s no encontrado", e.Entity)
 }
 
+// ErrTransicionInvalida para cambios de estado que el ciclo de vida no permite
+type ErrTransicionInvalida struct {
+	Entidad string // conexion, contrato
+	ID      int
+	Desde   string // estado actual
+	Hacia   string // estado pedido
+	Motivo  string // vacío si la transición no existe; si no, la condición que no se cumple
+}
+
+func (e ErrTransicionInvalida) Error() string {
+	if e.Motivo != "" {
+		return fmt.Sprintf("%s %d no puede pasar de '%s' a '%s': %s", e.Entidad, e.ID, e.Desde, e.Hacia, e.Motivo)
+	}
+	return fmt.Sprintf("%s %d no puede pasar de '%s' a '%s'", e.Entidad, e.ID, e.Desde, e.Hacia)
+}
+
+// ErrLoginBloqueado cuando la cuenta o la IP superó los intentos fallidos permitidos
+type ErrLoginBloqueado struct {
+	Segundos int // tiempo restante del bloqueo
+}
+
+func (e ErrLoginBloqueado) Error() string {
+	minutos := (e.Segundos + 59) / 60
+	if minutos < 1 {
+		minutos = 1
+	}
+	return fmt.Sprintf("demasiados intentos fallidos: reintentá en %d minuto(s)", minutos)
+}
+
 
 var (
 	// === Errores de base de datos ===
@@ -68,10 +97,41 @@ var (
 	ErrTokenRequerido    = errors.New("token requerido")
 	ErrTokenGeneracion   = errors.New("error al generar token de verificación")
 
+	// === Errores de autenticación en dos pasos ===
+	ErrCodigoDosFactoresInvalido = errors.New("código de verificación inválido")
+	ErrDosFactoresYaActivo       = errors.New("la autenticación en dos pasos ya está activa")
+	ErrDosFactoresInactivo       = errors.New("la autenticación en dos pasos no está activa")
+
+	// === Errores de autenticación entre servicios ===
+	ErrCredencialesServicio = errors.New("credenciales de servicio inválidas")
+	ErrFirmaServicioVencida = errors.New("firma de servicio fuera de la ventana de tiempo permitida")
+
 	// === Errores de proceso ===
 	ErrRolAsignacion = errors.New("no se pudo asignar el rol")
 
+	// === Errores de pagos ===
+	ErrFirmaWebhookInvalida        = errors.New("firma de webhook inválida")
+	ErrPagoYaAcreditado            = errors.New("el pago del contrato ya fue acreditado")
+	ErrSimulacionPagoDeshabilitada = errors.New("la simulación de pagos sólo está disponible con el proveedor fake")
+
+	// === Errores de firma de contratos ===
+	ErrContratoSinFirmar = errors.New("el contrato todavía no fue firmado")
+
+	// === Errores de instalaciones ===
+	ErrTurnoSuperpuesto = errors.New("el técnico ya tiene un turno en ese horario")
+	ErrOrdenCerrada     = errors.New("la orden de instalación ya está cerrada")
+	ErrOrdenSinTecnico  = errors.New("la orden de instalación no tiene técnico asignado")
+
+	// === Errores de turnos de instalación ===
+	ErrInstalacionNoPendiente = errors.New("la conexión no tiene una instalación pendiente")
+	ErrTurnoNoDisponible      = errors.New("el turno elegido ya no está disponible")
+	ErrTurnoNoModificable     = errors.New("el turno ya no puede modificarse")
+
+	// === Errores de facturación ===
+	ErrFacturaSinCAE = errors.New("la factura todavía no fue autorizada por AFIP")
+
 	// === Errores generales ===
 	ErrNoEncontrado = errors.New("registro no encontrado")
+	ErrAccesoDenegado = errors.New("acceso denegado")
 	ErrInterno      = errors.New("error interno del servidor")
 )
\ No newline at end of file
@@ -0,0 +1,45 @@
+package utilidades
+
+import (
+	"crypto/hmac"
+	"strconv"
+	"time"
+
+	"contrato_one_internet_comun/autenticacion"
+)
+
+// Cabeceras de la solicitud firmada con la que un servicio pide su token interno
+const (
+	HeaderClienteServicio   = autenticacion.HeaderClienteServicio
+	HeaderTimestampServicio = autenticacion.HeaderTimestampServicio
+	HeaderFirmaServicio     = autenticacion.HeaderFirmaServicio
+)
+
+// ToleranciaFirmaServicio es el desfase de reloj máximo aceptado entre servicios.
+// Acota además la ventana en la que una firma capturada puede reutilizarse.
+const ToleranciaFirmaServicio = 5 * time.Minute
+
+// FirmarSolicitudServicio calcula el HMAC-SHA256 (hex) de "clientID\ntimestamp\nMETODO\nruta".
+// El controlador firma con la misma función (autenticacion.FirmarSolicitud).
+func FirmarSolicitudServicio(secreto, clientID, timestamp, metodo, ruta string) string {
+	return autenticacion.FirmarSolicitud(secreto, clientID, timestamp, metodo, ruta)
+}
+
+// ValidarFirmaServicio verifica la firma en tiempo constante y que el timestamp (unix, segundos)
+// esté dentro de ToleranciaFirmaServicio respecto de ahora.
+func ValidarFirmaServicio(secreto, clientID, timestamp, metodo, ruta, firma string, ahora time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrCredencialesServicio
+	}
+	desfase := ahora.Sub(time.Unix(ts, 0))
+	if desfase > ToleranciaFirmaServicio || desfase < -ToleranciaFirmaServicio {
+		return ErrFirmaServicioVencida
+	}
+
+	esperada := FirmarSolicitudServicio(secreto, clientID, timestamp, metodo, ruta)
+	if !hmac.Equal([]byte(esperada), []byte(firma)) {
+		return ErrCredencialesServicio
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package utilidades
+
+import "crypto/hmac"
+
+// claveHashTokens es TOKENS_CLAVE_HASH; main la fija al arrancar con ConfigurarClaveTokens
+var claveHashTokens string
+
+// ConfigurarClaveTokens fija la clave con la que se hashean los tokens opacos. Se llama una
+// vez al arrancar, antes de atender pedidos.
+func ConfigurarClaveTokens(clave string) {
+	claveHashTokens = clave
+}
+
+// HashToken devuelve el HMAC-SHA256 (hex) con el que se guarda un token opaco (refresh,
+// verificación de email, reseteo de contraseña, firma de contrato). En la base sólo queda el
+// hash: una copia de las tablas no sirve para usar los tokens.
+func HashToken(token string) string {
+	if claveHashTokens == "" {
+		panic("utilidades: falta ConfigurarClaveTokens antes de hashear tokens")
+	}
+	return HashCodigo(claveHashTokens, token)
+}
+
+// TokenCoincide compara en tiempo constante un token recibido con el hash guardado
+func TokenCoincide(token, hashGuardado string) bool {
+	if hashGuardado == "" {
+		return false
+	}
+	return hmac.Equal([]byte(HashToken(token)), []byte(hashGuardado))
+}
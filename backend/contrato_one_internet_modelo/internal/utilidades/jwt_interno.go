@@ -7,33 +7,54 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Genera un token JWT interno válido por 24 horas
-func GenerarTokenInterno(secretKey []byte) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": "internal-service",
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
+// Emisor y audiencia de los tokens internos; un JWT firmado con el mismo secreto
+// pero emitido para otro propósito no es aceptado en /internal.
+const (
+	EmisorTokenInterno    = "contrato_one_internet_modelo"
+	AudienciaTokenInterno = "modelo-interno"
+)
+
+// GenerarTokenInterno genera un JWT interno para el servicio clientID con la vigencia indicada.
+// Devuelve el token y su fecha de expiración.
+func GenerarTokenInterno(secretKey []byte, clientID string, vigencia time.Duration) (string, time.Time, error) {
+	ahora := time.Now()
+	exp := ahora.Add(vigencia)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    EmisorTokenInterno,
+		Subject:   clientID,
+		Audience:  jwt.ClaimStrings{AudienciaTokenInterno},
+		IssuedAt:  jwt.NewNumericDate(ahora),
+		ExpiresAt: jwt.NewNumericDate(exp),
 	})
 
-	return token.SignedString(secretKey)
+	firmado, err := token.SignedString(secretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return firmado, exp, nil
 }
 
-// Valida un token JWT firmado con clave secreta
-func ValidarTokenInterno(tokenStr string, secretKey []byte) error {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+// Valida un token JWT interno: firma HMAC, expiración, emisor y audiencia.
+// Devuelve el clientID (sub) del servicio que lo solicitó.
+func ValidarTokenInterno(tokenStr string, secretKey []byte) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("método de firma de token inesperado")
 		}
 		return secretKey, nil
-	})
+	},
+		jwt.WithIssuer(EmisorTokenInterno),
+		jwt.WithAudience(AudienciaTokenInterno),
+	)
 
 	if err != nil {
-		return errors.New("error al parsear el token")
+		return "", errors.New("error al parsear el token")
 	}
 
-	if !token.Valid {
-		return errors.New("token inválido o expirado")
+	if !token.Valid || claims.ExpiresAt == nil {
+		return "", errors.New("token inválido o expirado")
 	}
 
-	return nil
-}
\ No newline at end of file
+	return claims.Subject, nil
+}
@@ -3,6 +3,7 @@ package utilidades
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"contrato_one_internet_modelo/internal/utilidades/logger"
 )
 
@@ -11,6 +12,8 @@ func ManejarErrorHTTP(w http.ResponseWriter, err error) {
 	var (
         validationErr ErrValidation
         notFoundErr   ErrNotFound
+        transicionErr ErrTransicionInvalida
+        bloqueoErr    ErrLoginBloqueado
     )
 
 	switch {
@@ -24,6 +27,15 @@ func ManejarErrorHTTP(w http.ResponseWriter, err error) {
     case errors.As(err, &notFoundErr):
         ResponderError(w, http.StatusNotFound, notFoundErr.Error())
 
+    // Captura cambios de estado no permitidos por el ciclo de vida
+    case errors.As(err, &transicionErr):
+        ResponderError(w, http.StatusConflict, transicionErr.Error())
+
+    // Login bloqueado por intentos fallidos
+    case errors.As(err, &bloqueoErr):
+        w.Header().Set("Retry-After", strconv.Itoa(bloqueoErr.Segundos))
+        ResponderError(w, http.StatusTooManyRequests, bloqueoErr.Error())
+
 
 	// --- Errores No Estructurados ---
 	// Errores de negocio
@@ -53,6 +65,50 @@ func ManejarErrorHTTP(w http.ResponseWriter, err error) {
 		errors.Is(err, ErrViolacionTruncamientoDatos):
 		ResponderError(w, http.StatusBadRequest, err.Error())
 
+	// Errores de autenticación entre servicios
+	case errors.Is(err, ErrCredencialesServicio),
+		errors.Is(err, ErrFirmaServicioVencida):
+		ResponderError(w, http.StatusUnauthorized, err.Error())
+
+	// Errores de autenticación en dos pasos
+	case errors.Is(err, ErrCodigoDosFactoresInvalido):
+		ResponderError(w, http.StatusUnauthorized, err.Error())
+
+	case errors.Is(err, ErrDosFactoresYaActivo),
+		errors.Is(err, ErrDosFactoresInactivo):
+		ResponderError(w, http.StatusConflict, err.Error())
+
+	// Errores de pagos
+	case errors.Is(err, ErrFirmaWebhookInvalida):
+		ResponderError(w, http.StatusUnauthorized, err.Error())
+
+	case errors.Is(err, ErrPagoYaAcreditado):
+		ResponderError(w, http.StatusConflict, err.Error())
+
+	case errors.Is(err, ErrSimulacionPagoDeshabilitada),
+		errors.Is(err, ErrAccesoDenegado):
+		ResponderError(w, http.StatusForbidden, err.Error())
+
+	// Errores de firma de contratos
+	case errors.Is(err, ErrContratoSinFirmar):
+		ResponderError(w, http.StatusConflict, err.Error())
+
+	// Errores de instalaciones
+	case errors.Is(err, ErrTurnoSuperpuesto),
+		errors.Is(err, ErrOrdenCerrada),
+		errors.Is(err, ErrOrdenSinTecnico):
+		ResponderError(w, http.StatusConflict, err.Error())
+
+	// Errores de turnos de instalación
+	case errors.Is(err, ErrInstalacionNoPendiente),
+		errors.Is(err, ErrTurnoNoDisponible),
+		errors.Is(err, ErrTurnoNoModificable):
+		ResponderError(w, http.StatusConflict, err.Error())
+
+	// Errores de facturación
+	case errors.Is(err, ErrFacturaSinCAE):
+		ResponderError(w, http.StatusConflict, err.Error())
+
 	case errors.Is(err, ErrNoEncontrado):
 	ResponderError(w, http.StatusNotFound, err.Error())
 
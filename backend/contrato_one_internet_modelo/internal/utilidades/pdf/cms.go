@@ -0,0 +1,353 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Identificadores usados en la estructura CMS SignedData (RFC 5652) y CAdES
+var (
+	oidData                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAtributoContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAtributoMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAtributoSigningCertV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256                = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSA                   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256ConRSA          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidECDSAConSHA256        = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+var (
+	// ErrFirmaInvalida indica que la firma CMS no corresponde al contenido o al certificado
+	ErrFirmaInvalida = errors.New("la firma digital no es válida")
+	// ErrCMSNoSoportado indica una estructura CMS que este paquete no sabe interpretar
+	ErrCMSNoSoportado = errors.New("estructura CMS no soportada")
+)
+
+type algoritmoID struct {
+	Algoritmo  asn1.ObjectIdentifier
+	Parametros asn1.RawValue `asn1:"optional"`
+}
+
+type atributo struct {
+	Tipo    asn1.ObjectIdentifier
+	Valores asn1.RawValue // SET OF AttributeValue
+}
+
+type emisorYSerie struct {
+	Emisor asn1.RawValue
+	Serie  *big.Int
+}
+
+type signerInfo struct {
+	Version           int
+	Emisor            emisorYSerie
+	AlgoritmoDigest   algoritmoID
+	AtributosFirmados asn1.RawValue // [0] IMPLICIT SET OF Attribute
+	AlgoritmoFirma    algoritmoID
+	Firma             []byte
+}
+
+// essCertIDv2 con hashAlgorithm por defecto (SHA-256), según RFC 5035
+type essCertIDv2 struct {
+	HashCertificado []byte
+}
+
+type signingCertificateV2 struct {
+	Certificados []essCertIDv2
+}
+
+// firmarCMS genera un CMS SignedData sobre el digest SHA-256 dado, con los atributos
+// firmados contentType, messageDigest y signing-certificate-v2. Con contenido nil la firma es
+// separada (detached); si no, el contenido se incluye como eContent.
+func firmarCMS(digest []byte, clave crypto.Signer, cert *x509.Certificate, cadena []*x509.Certificate, contenido []byte) ([]byte, error) {
+	algFirma, err := algoritmoFirmaPara(clave)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Atributos firmados
+	hashCert := sha256.Sum256(cert.Raw)
+	signingCert, err := asn1.Marshal(signingCertificateV2{Certificados: []essCertIDv2{{HashCertificado: hashCert[:]}}})
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := asn1.Marshal(oidData)
+	if err != nil {
+		return nil, err
+	}
+	messageDigest, err := asn1.Marshal(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var atributos [][]byte
+	for _, a := range []struct {
+		oid   asn1.ObjectIdentifier
+		valor []byte
+	}{
+		{oidAtributoContentType, contentType},
+		{oidAtributoMessageDigest, messageDigest},
+		{oidAtributoSigningCertV2, signingCert},
+	} {
+		der, err := asn1.Marshal(atributo{
+			Tipo:    a.oid,
+			Valores: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: a.valor},
+		})
+		if err != nil {
+			return nil, err
+		}
+		atributos = append(atributos, der)
+	}
+	// DER exige los elementos de un SET OF ordenados por su codificación
+	sort.Slice(atributos, func(i, j int) bool { return bytes.Compare(atributos[i], atributos[j]) < 0 })
+	contenidoAtributos := bytes.Join(atributos, nil)
+
+	// 2. Firmar la codificación DER del SET de atributos (RFC 5652, 5.4)
+	setAtributos, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: contenidoAtributos})
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(setAtributos)
+	firma, err := clave.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("error firmando atributos: %w", err)
+	}
+
+	// 3. SignerInfo identificado por emisor y número de serie
+	si := signerInfo{
+		Version:           1,
+		Emisor:            emisorYSerie{Emisor: asn1.RawValue{FullBytes: cert.RawIssuer}, Serie: cert.SerialNumber},
+		AlgoritmoDigest:   algoritmoID{Algoritmo: oidSHA256},
+		AtributosFirmados: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: contenidoAtributos},
+		AlgoritmoFirma:    algFirma,
+		Firma:             firma,
+	}
+	siDER, err := asn1.Marshal(si)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. SignedData con el certificado y su cadena; sin eContent si la firma es separada
+	var certs []byte
+	certs = append(certs, cert.Raw...)
+	for _, c := range cadena {
+		certs = append(certs, c.Raw...)
+	}
+	digestAlgs, err := asn1.Marshal(algoritmoID{Algoritmo: oidSHA256})
+	if err != nil {
+		return nil, err
+	}
+	encap, err := asn1.Marshal(struct{ Tipo asn1.ObjectIdentifier }{oidData})
+	if err != nil {
+		return nil, err
+	}
+	if contenido != nil {
+		encap, err = asn1.Marshal(struct {
+			Tipo      asn1.ObjectIdentifier
+			Contenido []byte `asn1:"explicit,tag:0"`
+		}{oidData, contenido})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sd []byte
+	sd = append(sd, derEntero(1)...)
+	sd = append(sd, derTLV(0x31, digestAlgs)...)
+	sd = append(sd, encap...)
+	sd = append(sd, derTLV(0xA0, certs)...)
+	sd = append(sd, derTLV(0x31, siDER)...)
+
+	tipo, err := asn1.Marshal(oidSignedData)
+	if err != nil {
+		return nil, err
+	}
+	contentInfo := append(tipo, derTLV(0xA0, derTLV(0x30, sd))...)
+	return derTLV(0x30, contentInfo), nil
+}
+
+// algoritmoFirmaPara elige el AlgorithmIdentifier según el tipo de clave
+func algoritmoFirmaPara(clave crypto.Signer) (algoritmoID, error) {
+	switch clave.Public().(type) {
+	case *rsa.PublicKey:
+		return algoritmoID{Algoritmo: oidRSA, Parametros: asn1.NullRawValue}, nil
+	case *ecdsa.PublicKey:
+		return algoritmoID{Algoritmo: oidECDSAConSHA256}, nil
+	default:
+		return algoritmoID{}, fmt.Errorf("tipo de clave no soportado para firmar: %T", clave.Public())
+	}
+}
+
+// FirmaCMS resume el contenido verificado de una firma CMS
+type FirmaCMS struct {
+	Certificado *x509.Certificate
+	Cadena      []*x509.Certificate
+}
+
+// verificarCMS valida un CMS SignedData separado contra el digest SHA-256 del contenido firmado
+func verificarCMS(der []byte, digest []byte) (*FirmaCMS, error) {
+	// 1. ContentInfo
+	var ci struct {
+		Tipo      asn1.ObjectIdentifier
+		Contenido asn1.RawValue `asn1:"explicit,tag:0"`
+	}
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+	}
+	if !ci.Tipo.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: se esperaba SignedData", ErrCMSNoSoportado)
+	}
+
+	// 2. Campos de SignedData, leídos uno a uno porque certificates y crls son opcionales
+	var sd asn1.RawValue
+	if _, err := asn1.Unmarshal(ci.Contenido.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+	}
+	campos, err := elementosDER(sd.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+	}
+	if len(campos) < 4 {
+		return nil, fmt.Errorf("%w: SignedData incompleto", ErrCMSNoSoportado)
+	}
+
+	var certs []*x509.Certificate
+	var signerInfos asn1.RawValue
+	for _, c := range campos[3:] {
+		switch {
+		case c.Class == asn1.ClassContextSpecific && c.Tag == 0:
+			lista, err := elementosDER(c.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+			}
+			for _, raw := range lista {
+				cert, err := x509.ParseCertificate(raw.FullBytes)
+				if err != nil {
+					return nil, fmt.Errorf("certificado embebido inválido: %w", err)
+				}
+				certs = append(certs, cert)
+			}
+		case c.Class == asn1.ClassUniversal && c.Tag == asn1.TagSet:
+			signerInfos = c
+		}
+	}
+
+	firmantes, err := elementosDER(signerInfos.Bytes)
+	if err != nil || len(firmantes) != 1 {
+		return nil, fmt.Errorf("%w: se esperaba exactamente un firmante", ErrCMSNoSoportado)
+	}
+	var si signerInfo
+	if _, err := asn1.Unmarshal(firmantes[0].FullBytes, &si); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+	}
+	if !si.AlgoritmoDigest.Algoritmo.Equal(oidSHA256) {
+		return nil, fmt.Errorf("%w: algoritmo de digest %v", ErrCMSNoSoportado, si.AlgoritmoDigest.Algoritmo)
+	}
+	if si.AtributosFirmados.Class != asn1.ClassContextSpecific || si.AtributosFirmados.Tag != 0 {
+		return nil, fmt.Errorf("%w: la firma no tiene atributos firmados", ErrCMSNoSoportado)
+	}
+
+	// 3. Certificado del firmante
+	var firmante *x509.Certificate
+	var cadena []*x509.Certificate
+	for _, c := range certs {
+		if firmante == nil && bytes.Equal(c.RawIssuer, si.Emisor.Emisor.FullBytes) && c.SerialNumber.Cmp(si.Emisor.Serie) == 0 {
+			firmante = c
+			continue
+		}
+		cadena = append(cadena, c)
+	}
+	if firmante == nil {
+		return nil, fmt.Errorf("%w: el certificado del firmante no está embebido", ErrFirmaInvalida)
+	}
+
+	// 4. El messageDigest firmado debe coincidir con el contenido
+	atributos, err := elementosDER(si.AtributosFirmados.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+	}
+	var digestFirmado []byte
+	for _, raw := range atributos {
+		var a atributo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &a); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+		}
+		if a.Tipo.Equal(oidAtributoMessageDigest) {
+			if _, err := asn1.Unmarshal(a.Valores.Bytes, &digestFirmado); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrCMSNoSoportado, err)
+			}
+		}
+	}
+	if !bytes.Equal(digestFirmado, digest) {
+		return nil, fmt.Errorf("%w: el documento fue modificado después de firmarse", ErrFirmaInvalida)
+	}
+
+	// 5. La firma cubre el SET de atributos (re-etiquetado como SET universal)
+	setAtributos, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.AtributosFirmados.Bytes})
+	if err != nil {
+		return nil, err
+	}
+	var alg x509.SignatureAlgorithm
+	switch {
+	case si.AlgoritmoFirma.Algoritmo.Equal(oidRSA), si.AlgoritmoFirma.Algoritmo.Equal(oidSHA256ConRSA):
+		alg = x509.SHA256WithRSA
+	case si.AlgoritmoFirma.Algoritmo.Equal(oidECDSAConSHA256):
+		alg = x509.ECDSAWithSHA256
+	default:
+		return nil, fmt.Errorf("%w: algoritmo de firma %v", ErrCMSNoSoportado, si.AlgoritmoFirma.Algoritmo)
+	}
+	if err := firmante.CheckSignature(alg, setAtributos, si.Firma); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFirmaInvalida, err)
+	}
+
+	return &FirmaCMS{Certificado: firmante, Cadena: cadena}, nil
+}
+
+// elementosDER separa una secuencia de elementos DER concatenados
+func elementosDER(b []byte) ([]asn1.RawValue, error) {
+	var out []asn1.RawValue
+	for len(b) > 0 {
+		var rv asn1.RawValue
+		resto, err := asn1.Unmarshal(b, &rv)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rv)
+		b = resto
+	}
+	return out, nil
+}
+
+// derTLV codifica un elemento DER con la etiqueta y el contenido dados
+func derTLV(etiqueta byte, contenido []byte) []byte {
+	n := len(contenido)
+	out := []byte{etiqueta}
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n < 0x100:
+		out = append(out, 0x81, byte(n))
+	case n < 0x10000:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(out, contenido...)
+}
+
+func derEntero(v int) []byte {
+	b, _ := asn1.Marshal(v)
+	return b
+}
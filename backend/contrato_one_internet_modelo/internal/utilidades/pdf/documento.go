@@ -0,0 +1,294 @@
+// Package pdf implementa un generador mínimo de documentos PDF 1.4 sin dependencias externas:
+// texto con las fuentes estándar Helvetica, líneas, rectángulos e imágenes RGB, y la firma
+// digital PAdES (CMS separado) de documentos existentes mediante actualización incremental.
+// Las coordenadas se expresan en puntos con origen en la esquina superior izquierda.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Tamaño de página A4 en puntos
+const (
+	A4Ancho = 595.28
+	A4Alto  = 841.89
+)
+
+// Documento acumula páginas e imágenes y las serializa con Escribir
+type Documento struct {
+	Ancho, Alto float64
+
+	paginas  []*bytes.Buffer
+	imagenes []*imagenRGB
+	actual   *bytes.Buffer
+}
+
+type imagenRGB struct {
+	ancho, alto int
+	datos       []byte // RGB comprimido con zlib (FlateDecode)
+}
+
+// NuevoDocumento crea un documento A4 vacío
+func NuevoDocumento() *Documento {
+	return &Documento{Ancho: A4Ancho, Alto: A4Alto}
+}
+
+// NuevaPagina agrega una página y la deja como actual
+func (d *Documento) NuevaPagina() {
+	d.actual = &bytes.Buffer{}
+	d.paginas = append(d.paginas, d.actual)
+}
+
+// CantidadPaginas devuelve la cantidad de páginas creadas
+func (d *Documento) CantidadPaginas() int {
+	return len(d.paginas)
+}
+
+func (d *Documento) pagina() *bytes.Buffer {
+	if d.actual == nil {
+		d.NuevaPagina()
+	}
+	return d.actual
+}
+
+// AnchoTexto devuelve el ancho en puntos de s con la fuente y tamaño dados
+func AnchoTexto(fuente Fuente, tam float64, s string) float64 {
+	total := 0
+	for _, r := range s {
+		total += fuente.anchoRune(r)
+	}
+	return float64(total) * tam / 1000
+}
+
+// Texto escribe s con la línea base en (x, y)
+func (d *Documento) Texto(x, y float64, fuente Fuente, tam float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(d.pagina(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		fuente.recurso(), num(tam), num(x), num(d.Alto-y), escaparTexto(codificarWinAnsi(s)))
+}
+
+// TextoDerecha escribe s alineado a la derecha en x
+func (d *Documento) TextoDerecha(x, y float64, fuente Fuente, tam float64, s string) {
+	d.Texto(x-AnchoTexto(fuente, tam, s), y, fuente, tam, s)
+}
+
+// TextoCentrado escribe s centrado en x
+func (d *Documento) TextoCentrado(x, y float64, fuente Fuente, tam float64, s string) {
+	d.Texto(x-AnchoTexto(fuente, tam, s)/2, y, fuente, tam, s)
+}
+
+// DividirLineas corta s en líneas que no superan anchoMax, respetando palabras y saltos de línea
+func DividirLineas(fuente Fuente, tam float64, s string, anchoMax float64) []string {
+	var lineas []string
+	for _, parrafo := range strings.Split(s, "\n") {
+		palabras := strings.Fields(parrafo)
+		if len(palabras) == 0 {
+			lineas = append(lineas, "")
+			continue
+		}
+		linea := palabras[0]
+		for _, p := range palabras[1:] {
+			candidata := linea + " " + p
+			if AnchoTexto(fuente, tam, candidata) > anchoMax {
+				lineas = append(lineas, linea)
+				linea = p
+				continue
+			}
+			linea = candidata
+		}
+		lineas = append(lineas, linea)
+	}
+	return lineas
+}
+
+// Linea dibuja una línea de (x1, y1) a (x2, y2)
+func (d *Documento) Linea(x1, y1, x2, y2, grosor float64) {
+	fmt.Fprintf(d.pagina(), "%s w %s %s m %s %s l S\n",
+		num(grosor), num(x1), num(d.Alto-y1), num(x2), num(d.Alto-y2))
+}
+
+// Rectangulo dibuja el contorno de un rectángulo con esquina superior izquierda en (x, y)
+func (d *Documento) Rectangulo(x, y, ancho, alto, grosor float64) {
+	fmt.Fprintf(d.pagina(), "%s w %s %s %s %s re S\n",
+		num(grosor), num(x), num(d.Alto-y-alto), num(ancho), num(alto))
+}
+
+// RectanguloRelleno rellena un rectángulo con un gris (0 = negro, 1 = blanco)
+func (d *Documento) RectanguloRelleno(x, y, ancho, alto, gris float64) {
+	fmt.Fprintf(d.pagina(), "q %s g %s %s %s %s re f Q\n",
+		num(gris), num(x), num(d.Alto-y-alto), num(ancho), num(alto))
+}
+
+// AgregarImagen registra una imagen (la transparencia se compone sobre blanco)
+// y devuelve su índice para usar con Imagen.
+func (d *Documento) AgregarImagen(img image.Image) (int, error) {
+	b := img.Bounds()
+	lienzo := image.NewRGBA(b)
+	draw.Draw(lienzo, b, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(lienzo, b, img, b.Min, draw.Over)
+
+	var comprimido bytes.Buffer
+	zw := zlib.NewWriter(&comprimido)
+	fila := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		fila = fila[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := lienzo.PixOffset(x, y)
+			fila = append(fila, lienzo.Pix[i], lienzo.Pix[i+1], lienzo.Pix[i+2])
+		}
+		if _, err := zw.Write(fila); err != nil {
+			return 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	d.imagenes = append(d.imagenes, &imagenRGB{ancho: b.Dx(), alto: b.Dy(), datos: comprimido.Bytes()})
+	return len(d.imagenes) - 1, nil
+}
+
+// AgregarImagenPNG decodifica un PNG y lo registra como imagen
+func (d *Documento) AgregarImagenPNG(r io.Reader) (int, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("error decodificando PNG: %w", err)
+	}
+	return d.AgregarImagen(img)
+}
+
+// TamanoImagen devuelve ancho y alto en píxeles de una imagen registrada
+func (d *Documento) TamanoImagen(idx int) (int, int) {
+	img := d.imagenes[idx]
+	return img.ancho, img.alto
+}
+
+// Imagen dibuja la imagen idx con esquina superior izquierda en (x, y)
+func (d *Documento) Imagen(idx int, x, y, ancho, alto float64) {
+	fmt.Fprintf(d.pagina(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		num(ancho), num(alto), num(x), num(d.Alto-y-alto), idx+1)
+}
+
+// Escribir serializa el documento completo. La salida es determinística:
+// los mismos contenidos producen siempre los mismos bytes.
+func (d *Documento) Escribir(w io.Writer) error {
+	if len(d.paginas) == 0 {
+		d.NuevaPagina()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	nuevoObjeto := func() int {
+		offsets = append(offsets, buf.Len())
+		return len(offsets)
+	}
+
+	// Numeración: 1 catálogo, 2 páginas, 3-4 fuentes, luego imágenes y por cada página (página, contenido)
+	primeraImagen := 5
+	primeraPagina := primeraImagen + len(d.imagenes)
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	nuevoObjeto()
+	buf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	nuevoObjeto()
+	kids := make([]string, len(d.paginas))
+	for i := range d.paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", primeraPagina+2*i)
+	}
+	fmt.Fprintf(&buf, "2 0 obj\n<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(d.paginas))
+
+	for i, f := range []Fuente{Helvetica, HelveticaNegrita} {
+		nuevoObjeto()
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\nendobj\n",
+			3+i, f.nombreBase())
+	}
+
+	for i, img := range d.imagenes {
+		n := nuevoObjeto()
+		if n != primeraImagen+i {
+			return fmt.Errorf("numeración de objetos inconsistente")
+		}
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+			n, img.ancho, img.alto, len(img.datos))
+		buf.Write(img.datos)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	var recursosImagenes strings.Builder
+	if len(d.imagenes) > 0 {
+		recursosImagenes.WriteString(" /XObject <<")
+		for i := range d.imagenes {
+			fmt.Fprintf(&recursosImagenes, " /Im%d %d 0 R", i+1, primeraImagen+i)
+		}
+		recursosImagenes.WriteString(" >>")
+	}
+
+	for _, contenido := range d.paginas {
+		var comprimido bytes.Buffer
+		zw := zlib.NewWriter(&comprimido)
+		if _, err := zw.Write(contenido.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		nPagina := nuevoObjeto()
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >>%s >> /Contents %d 0 R >>\nendobj\n",
+			nPagina, num(d.Ancho), num(d.Alto), recursosImagenes.String(), nPagina+1)
+
+		nContenido := nuevoObjeto()
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", nContenido, comprimido.Len())
+		buf.Write(comprimido.Bytes())
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	inicioXref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, inicioXref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// escaparTexto escapa los caracteres especiales de un string literal PDF
+func escaparTexto(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\r', '\n':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// num formatea un número para el content stream (sin notación exponencial)
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
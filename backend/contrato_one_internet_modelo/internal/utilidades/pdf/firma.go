@@ -0,0 +1,631 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// capacidadFirma es el espacio (en bytes) reservado en /Contents para el CMS
+const capacidadFirma = 16384
+
+// El placeholder de /ByteRange tiene ancho fijo para poder completarlo sin mover offsets
+const placeholderByteRange = "[0 0000000000 0000000000 0000000000]"
+
+var (
+	// ErrPDFNoSoportado indica una estructura de PDF que no se puede firmar de forma incremental
+	ErrPDFNoSoportado = errors.New("estructura de PDF no soportada para firma")
+	// ErrSinFirma indica que el PDF no contiene una firma digital
+	ErrSinFirma = errors.New("el PDF no contiene una firma digital")
+)
+
+// Firmante contiene la clave privada y el certificado con los que la empresa firma los PDF
+type Firmante struct {
+	clave       crypto.Signer
+	certificado *x509.Certificate
+	cadena      []*x509.Certificate
+}
+
+// NuevoFirmante crea un firmante a partir de una clave y su certificado (más la cadena opcional)
+func NuevoFirmante(clave crypto.Signer, certificado *x509.Certificate, cadena ...*x509.Certificate) (*Firmante, error) {
+	if _, err := algoritmoFirmaPara(clave); err != nil {
+		return nil, err
+	}
+	if !clavesIguales(clave.Public(), certificado.PublicKey) {
+		return nil, errors.New("la clave privada no corresponde al certificado")
+	}
+	return &Firmante{clave: clave, certificado: certificado, cadena: cadena}, nil
+}
+
+// CargarFirmantePKCS12 lee un archivo .p12/.pfx con la clave privada, el certificado
+// de la empresa y, opcionalmente, los certificados intermedios.
+func CargarFirmantePKCS12(path, password string) (*Firmante, error) {
+	datos, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", path, err)
+	}
+
+	bloques, err := pkcs12.ToPEM(datos, password)
+	if err != nil {
+		return nil, fmt.Errorf("error decodificando PKCS#12: %w", err)
+	}
+
+	var clave crypto.Signer
+	var certs []*x509.Certificate
+	for _, b := range bloques {
+		switch b.Type {
+		case "PRIVATE KEY":
+			clave, err = parsearClavePrivada(b)
+			if err != nil {
+				return nil, err
+			}
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("certificado inválido en PKCS#12: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if clave == nil {
+		return nil, errors.New("el PKCS#12 no contiene una clave privada")
+	}
+
+	// El certificado del firmante es el que corresponde a la clave; el resto es la cadena
+	var propio *x509.Certificate
+	var cadena []*x509.Certificate
+	for _, c := range certs {
+		if propio == nil && clavesIguales(clave.Public(), c.PublicKey) {
+			propio = c
+			continue
+		}
+		cadena = append(cadena, c)
+	}
+	if propio == nil {
+		return nil, errors.New("el PKCS#12 no contiene el certificado de la clave privada")
+	}
+	return NuevoFirmante(clave, propio, cadena...)
+}
+
+// parsearClavePrivada interpreta los bloques de pkcs12.ToPEM (PKCS#1 para RSA, SEC 1 para ECDSA)
+func parsearClavePrivada(b *pem.Block) (crypto.Signer, error) {
+	if k, err := x509.ParsePKCS1PrivateKey(b.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParseECPrivateKey(b.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(b.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("clave privada no soportada en PKCS#12: %w", err)
+	}
+	signer, ok := k.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("clave privada no soportada en PKCS#12: %T", k)
+	}
+	return signer, nil
+}
+
+func clavesIguales(a, b crypto.PublicKey) bool {
+	switch k := a.(type) {
+	case *rsa.PublicKey:
+		return k.Equal(b)
+	case *ecdsa.PublicKey:
+		return k.Equal(b)
+	}
+	return false
+}
+
+// Certificado devuelve el certificado con el que se firman los documentos
+func (f *Firmante) Certificado() *x509.Certificate {
+	return f.certificado
+}
+
+// FirmarCMSAdjunto firma el contenido y devuelve un CMS SignedData (DER) que lo incluye.
+// Es el formato que exige, por ejemplo, el WSAA de AFIP para el ticket de acceso.
+func (f *Firmante) FirmarCMSAdjunto(contenido []byte) ([]byte, error) {
+	h := sha256.Sum256(contenido)
+	return firmarCMS(h[:], f.clave, f.certificado, f.cadena, contenido)
+}
+
+// DatosFirma son los metadatos visibles en el diccionario de firma
+type DatosFirma struct {
+	Nombre    string
+	Razon     string
+	Ubicacion string
+	Fecha     time.Time
+}
+
+// FirmarPDF agrega al PDF una firma CMS separada (PAdES, /ETSI.CAdES.detached) mediante
+// una actualización incremental: el contenido original no se modifica y la firma
+// cubre todo el archivo salvo el propio valor de /Contents.
+func (f *Firmante) FirmarPDF(original []byte, datos DatosFirma) ([]byte, error) {
+	// 1. Trailer y objetos a actualizar
+	tr, err := leerTrailer(original)
+	if err != nil {
+		return nil, err
+	}
+	catalogo, err := leerDiccionario(original, tr.raiz)
+	if err != nil {
+		return nil, fmt.Errorf("catálogo: %w", err)
+	}
+	if strings.Contains(catalogo, "/AcroForm") {
+		return nil, fmt.Errorf("%w: el documento ya tiene un formulario", ErrPDFNoSoportado)
+	}
+	pagina, err := primeraPagina(original, catalogo)
+	if err != nil {
+		return nil, err
+	}
+	dictPagina, err := leerDiccionario(original, pagina)
+	if err != nil {
+		return nil, fmt.Errorf("página: %w", err)
+	}
+
+	nFirma := tr.tamano
+	nCampo := tr.tamano + 1
+	campoRef := fmt.Sprintf("%d 0 R", nCampo)
+
+	dictPagina, err = agregarAnotacion(dictPagina, campoRef)
+	if err != nil {
+		return nil, err
+	}
+	catalogo = insertarEnDiccionario(catalogo, fmt.Sprintf("/AcroForm << /Fields [%s] /SigFlags 3 >>", campoRef))
+
+	// 2. Objetos nuevos al final del archivo
+	var buf bytes.Buffer
+	buf.Write(original)
+	if len(original) > 0 && original[len(original)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	offsets := map[referencia]int{}
+
+	offsets[referencia{nFirma, 0}] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached /ByteRange ", nFirma)
+	posByteRange := buf.Len()
+	buf.WriteString(placeholderByteRange)
+	buf.WriteString(" /Contents ")
+	inicioContents := buf.Len()
+	buf.WriteByte('<')
+	buf.Write(bytes.Repeat([]byte{'0'}, 2*capacidadFirma))
+	buf.WriteByte('>')
+	finContents := buf.Len()
+	fmt.Fprintf(&buf, " /M %s", fechaPDF(datos.Fecha))
+	if datos.Nombre != "" {
+		fmt.Fprintf(&buf, " /Name %s", textoPDF(datos.Nombre))
+	}
+	if datos.Razon != "" {
+		fmt.Fprintf(&buf, " /Reason %s", textoPDF(datos.Razon))
+	}
+	if datos.Ubicacion != "" {
+		fmt.Fprintf(&buf, " /Location %s", textoPDF(datos.Ubicacion))
+	}
+	buf.WriteString(" >>\nendobj\n")
+
+	// Campo de firma invisible (Rect vacío) asociado a la primera página
+	offsets[referencia{nCampo, 0}] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Annot /Subtype /Widget /FT /Sig /T (FirmaEmpresa) /V %d 0 R /F 132 /Rect [0 0 0 0] /P %d %d R >>\nendobj\n",
+		nCampo, nFirma, pagina.num, pagina.gen)
+
+	offsets[pagina] = buf.Len()
+	fmt.Fprintf(&buf, "%d %d obj\n%s\nendobj\n", pagina.num, pagina.gen, dictPagina)
+
+	offsets[tr.raiz] = buf.Len()
+	fmt.Fprintf(&buf, "%d %d obj\n%s\nendobj\n", tr.raiz.num, tr.raiz.gen, catalogo)
+
+	// 3. Sección xref incremental con /Prev
+	inicioXref := buf.Len()
+	refs := make([]referencia, 0, len(offsets))
+	for r := range offsets {
+		refs = append(refs, r)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].num < refs[j].num })
+	buf.WriteString("xref\n")
+	for _, r := range refs {
+		fmt.Fprintf(&buf, "%d 1\n%010d %05d n \n", r.num, offsets[r], r.gen)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d %d R", nCampo+1, tr.raiz.num, tr.raiz.gen)
+	if tr.extra != "" {
+		buf.WriteString(" " + tr.extra)
+	}
+	fmt.Fprintf(&buf, " /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", tr.inicioXref, inicioXref)
+
+	salida := buf.Bytes()
+
+	// 4. Completar /ByteRange con las posiciones reales
+	byteRange := fmt.Sprintf("[0 %d %d %d]", inicioContents, finContents, len(salida)-finContents)
+	if len(byteRange) > len(placeholderByteRange) {
+		return nil, fmt.Errorf("%w: documento demasiado grande", ErrPDFNoSoportado)
+	}
+	byteRange += strings.Repeat(" ", len(placeholderByteRange)-len(byteRange))
+	copy(salida[posByteRange:], byteRange)
+
+	// 5. Firmar el digest de los rangos y escribir el CMS en /Contents
+	h := sha256.New()
+	h.Write(salida[:inicioContents])
+	h.Write(salida[finContents:])
+	cms, err := firmarCMS(h.Sum(nil), f.clave, f.certificado, f.cadena, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(cms) > capacidadFirma {
+		return nil, fmt.Errorf("la firma CMS (%d bytes) excede el espacio reservado", len(cms))
+	}
+	copy(salida[inicioContents+1:], strings.ToUpper(hex.EncodeToString(cms)))
+
+	return salida, nil
+}
+
+// FirmaVerificada describe una firma embebida que fue validada criptográficamente
+type FirmaVerificada struct {
+	Firmante           string
+	Emisor             string
+	NumeroSerie        string
+	Fecha              *time.Time
+	Razon              string
+	Ubicacion          string
+	CubreDocumento     bool // false si hubo modificaciones incrementales después de firmar
+	CertificadoVigente bool // el certificado estaba vigente en la fecha de firma
+	Certificado        *x509.Certificate
+}
+
+var reByteRange = regexp.MustCompile(`^/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+
+// VerificarPDF valida la última firma embebida del PDF: recalcula el digest de los
+// rangos firmados y verifica el CMS contra el certificado que trae embebido.
+func VerificarPDF(datos []byte) (*FirmaVerificada, error) {
+	// 1. Ubicar el diccionario de firma y sus rangos
+	pos := bytes.LastIndex(datos, []byte("/ByteRange"))
+	if pos < 0 {
+		return nil, ErrSinFirma
+	}
+	m := reByteRange.FindSubmatch(datos[pos:])
+	if m == nil {
+		return nil, fmt.Errorf("%w: /ByteRange mal formado", ErrFirmaInvalida)
+	}
+	var br [4]int
+	for i := range br {
+		br[i], _ = strconv.Atoi(string(m[i+1]))
+	}
+	if br[0] != 0 || br[1] >= br[2] || br[2]+br[3] > len(datos) || datos[br[1]] != '<' || datos[br[2]-1] != '>' {
+		return nil, fmt.Errorf("%w: /ByteRange inconsistente", ErrFirmaInvalida)
+	}
+
+	// 2. Extraer el CMS (hex con relleno de ceros)
+	cms, err := hex.DecodeString(string(datos[br[1]+1 : br[2]-1]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: /Contents inválido", ErrFirmaInvalida)
+	}
+
+	// 3. Verificar contra el digest de los rangos firmados
+	h := sha256.New()
+	h.Write(datos[br[0]:br[1]])
+	h.Write(datos[br[2] : br[2]+br[3]])
+	firma, err := verificarCMS(cms, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Metadatos del diccionario de firma
+	inicio := bytes.LastIndex(datos[:pos], []byte("obj"))
+	fin := bytes.Index(datos[pos:], []byte("endobj"))
+	if inicio < 0 || fin < 0 {
+		return nil, fmt.Errorf("%w: diccionario de firma incompleto", ErrFirmaInvalida)
+	}
+	dict := string(datos[inicio : pos+fin])
+
+	cert := firma.Certificado
+	v := &FirmaVerificada{
+		Firmante:       cert.Subject.CommonName,
+		Emisor:         cert.Issuer.CommonName,
+		NumeroSerie:    strings.ToUpper(cert.SerialNumber.Text(16)),
+		Razon:          leerTextoPDF(dict, "/Reason"),
+		Ubicacion:      leerTextoPDF(dict, "/Location"),
+		CubreDocumento: br[2]+br[3] == len(datos),
+		Certificado:    cert,
+	}
+	if v.Firmante == "" {
+		v.Firmante = cert.Subject.String()
+	}
+	if v.Emisor == "" {
+		v.Emisor = cert.Issuer.String()
+	}
+	if t, ok := parsearFechaPDF(leerTextoPDF(dict, "/M")); ok {
+		v.Fecha = &t
+		v.CertificadoVigente = !t.Before(cert.NotBefore) && !t.After(cert.NotAfter)
+	}
+	return v, nil
+}
+
+// referencia identifica un objeto indirecto (número y generación)
+type referencia struct {
+	num, gen int
+}
+
+type trailer struct {
+	raiz       referencia
+	tamano     int
+	inicioXref int
+	extra      string // /Info y /ID a conservar en el nuevo trailer
+}
+
+var (
+	reStartxref = regexp.MustCompile(`startxref\s+(\d+)`)
+	reSize      = regexp.MustCompile(`/Size\s+(\d+)`)
+	reInfo      = regexp.MustCompile(`/Info\s+\d+\s+\d+\s+R`)
+	reID        = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+	reKids      = regexp.MustCompile(`/Kids\s*\[\s*(\d+)\s+(\d+)\s+R`)
+	reTipoPages = regexp.MustCompile(`/Type\s*/Pages\b`)
+)
+
+// leerTrailer interpreta el último trailer clásico; los PDF con xref comprimido no se soportan
+func leerTrailer(datos []byte) (*trailer, error) {
+	sx := reStartxref.FindAllSubmatch(datos, -1)
+	if len(sx) == 0 {
+		return nil, fmt.Errorf("%w: no se encontró startxref", ErrPDFNoSoportado)
+	}
+	inicioXref, _ := strconv.Atoi(string(sx[len(sx)-1][1]))
+
+	pos := bytes.LastIndex(datos, []byte("trailer"))
+	if pos < 0 || pos < inicioXref {
+		return nil, fmt.Errorf("%w: el PDF usa tabla xref comprimida", ErrPDFNoSoportado)
+	}
+	dict, err := extraerDiccionario(string(datos[pos+len("trailer"):]))
+	if err != nil {
+		return nil, fmt.Errorf("trailer: %w", err)
+	}
+
+	raiz, ok := buscarReferencia(dict, "/Root")
+	if !ok {
+		return nil, fmt.Errorf("%w: trailer sin /Root", ErrPDFNoSoportado)
+	}
+	ms := reSize.FindStringSubmatch(dict)
+	if ms == nil {
+		return nil, fmt.Errorf("%w: trailer sin /Size", ErrPDFNoSoportado)
+	}
+	tamano, _ := strconv.Atoi(ms[1])
+
+	var extra []string
+	if info := reInfo.FindString(dict); info != "" {
+		extra = append(extra, info)
+	}
+	if id := reID.FindString(dict); id != "" {
+		extra = append(extra, id)
+	}
+	return &trailer{raiz: raiz, tamano: tamano, inicioXref: inicioXref, extra: strings.Join(extra, " ")}, nil
+}
+
+// buscarReferencia devuelve la referencia indirecta asociada a clave en el diccionario
+func buscarReferencia(dict, clave string) (referencia, bool) {
+	re := regexp.MustCompile(regexp.QuoteMeta(clave) + `\s+(\d+)\s+(\d+)\s+R`)
+	m := re.FindStringSubmatch(dict)
+	if m == nil {
+		return referencia{}, false
+	}
+	num, _ := strconv.Atoi(m[1])
+	gen, _ := strconv.Atoi(m[2])
+	return referencia{num, gen}, true
+}
+
+// leerDiccionario devuelve el diccionario de la última definición del objeto ref
+func leerDiccionario(datos []byte, ref referencia) (string, error) {
+	re := regexp.MustCompile(fmt.Sprintf(`(?:^|\s)%d\s+%d\s+obj\b`, ref.num, ref.gen))
+	ubicaciones := re.FindAllIndex(datos, -1)
+	if len(ubicaciones) == 0 {
+		return "", fmt.Errorf("%w: objeto %d %d no encontrado", ErrPDFNoSoportado, ref.num, ref.gen)
+	}
+	inicio := ubicaciones[len(ubicaciones)-1][1]
+	fin := bytes.Index(datos[inicio:], []byte("endobj"))
+	if fin < 0 {
+		return "", fmt.Errorf("%w: objeto %d sin endobj", ErrPDFNoSoportado, ref.num)
+	}
+	return extraerDiccionario(string(datos[inicio : inicio+fin]))
+}
+
+// primeraPagina recorre el árbol de páginas hasta la primera hoja
+func primeraPagina(datos []byte, catalogo string) (referencia, error) {
+	ref, ok := buscarReferencia(catalogo, "/Pages")
+	if !ok {
+		return referencia{}, fmt.Errorf("%w: catálogo sin /Pages", ErrPDFNoSoportado)
+	}
+	for i := 0; i < 32; i++ {
+		dict, err := leerDiccionario(datos, ref)
+		if err != nil {
+			return referencia{}, err
+		}
+		if !reTipoPages.MatchString(dict) {
+			return ref, nil
+		}
+		m := reKids.FindStringSubmatch(dict)
+		if m == nil {
+			return referencia{}, fmt.Errorf("%w: nodo de páginas sin /Kids", ErrPDFNoSoportado)
+		}
+		ref.num, _ = strconv.Atoi(m[1])
+		ref.gen, _ = strconv.Atoi(m[2])
+	}
+	return referencia{}, fmt.Errorf("%w: árbol de páginas demasiado profundo", ErrPDFNoSoportado)
+}
+
+var (
+	reAnnotsArray = regexp.MustCompile(`/Annots\s*\[`)
+	reAnnotsRef   = regexp.MustCompile(`/Annots\s+\d+\s+\d+\s+R`)
+)
+
+// agregarAnotacion suma el widget de firma a las anotaciones de la página
+func agregarAnotacion(dict, ref string) (string, error) {
+	if loc := reAnnotsArray.FindStringIndex(dict); loc != nil {
+		return dict[:loc[1]] + ref + " " + dict[loc[1]:], nil
+	}
+	if reAnnotsRef.MatchString(dict) {
+		return "", fmt.Errorf("%w: /Annots indirecto en la página", ErrPDFNoSoportado)
+	}
+	return insertarEnDiccionario(dict, "/Annots ["+ref+"]"), nil
+}
+
+// insertarEnDiccionario agrega una entrada antes del cierre ">>" del diccionario
+func insertarEnDiccionario(dict, entrada string) string {
+	fin := strings.LastIndex(dict, ">>")
+	return strings.TrimRight(dict[:fin], " \r\n") + " " + entrada + " >>"
+}
+
+// extraerDiccionario devuelve el primer diccionario "<< ... >>" de s respetando anidamiento y strings
+func extraerDiccionario(s string) (string, error) {
+	inicio := strings.Index(s, "<<")
+	if inicio < 0 {
+		return "", fmt.Errorf("%w: diccionario no encontrado", ErrPDFNoSoportado)
+	}
+	nivel := 0
+	for i := inicio; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			// String literal: saltear respetando escapes y paréntesis balanceados
+			prof := 0
+			for ; i < len(s); i++ {
+				if s[i] == '\\' {
+					i++
+					continue
+				}
+				if s[i] == '(' {
+					prof++
+				} else if s[i] == ')' {
+					prof--
+					if prof == 0 {
+						break
+					}
+				}
+			}
+		case '<':
+			if i+1 < len(s) && s[i+1] == '<' {
+				nivel++
+				i++
+			}
+		case '>':
+			if i+1 < len(s) && s[i+1] == '>' {
+				nivel--
+				i++
+				if nivel == 0 {
+					return s[inicio : i+1], nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: diccionario sin cerrar", ErrPDFNoSoportado)
+}
+
+// textoPDF codifica un text string: literal si es ASCII, UTF-16BE con BOM si no
+func textoPDF(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "(" + escaparTexto([]byte(s)) + ")"
+	}
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&sb, "%04X", u)
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+// leerTextoPDF obtiene el valor de un text string (literal o hexadecimal) de un diccionario
+func leerTextoPDF(dict, clave string) string {
+	i := strings.Index(dict, clave+" ")
+	if i < 0 {
+		i = strings.Index(dict, clave+"(")
+		if i < 0 {
+			i = strings.Index(dict, clave+"<")
+		}
+		if i < 0 {
+			return ""
+		}
+	}
+	resto := strings.TrimLeft(dict[i+len(clave):], " \r\n")
+	if strings.HasPrefix(resto, "(") {
+		var sb strings.Builder
+		for j := 1; j < len(resto); j++ {
+			switch resto[j] {
+			case '\\':
+				if j+1 < len(resto) {
+					j++
+					sb.WriteByte(resto[j])
+				}
+			case ')':
+				return sb.String()
+			default:
+				sb.WriteByte(resto[j])
+			}
+		}
+		return ""
+	}
+	if strings.HasPrefix(resto, "<") {
+		fin := strings.IndexByte(resto, '>')
+		if fin < 0 {
+			return ""
+		}
+		b, err := hex.DecodeString(resto[1:fin])
+		if err != nil || len(b) < 2 || b[0] != 0xFE || b[1] != 0xFF {
+			return string(b)
+		}
+		u := make([]uint16, 0, len(b)/2)
+		for j := 2; j+1 < len(b); j += 2 {
+			u = append(u, uint16(b[j])<<8|uint16(b[j+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	return ""
+}
+
+// fechaPDF formatea t como fecha PDF: (D:AAAAMMDDHHmmSS+hh'mm')
+func fechaPDF(t time.Time) string {
+	_, offset := t.Zone()
+	signo := '+'
+	if offset < 0 {
+		signo = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("(D:%s%c%02d'%02d')", t.Format("20060102150405"), signo, offset/3600, (offset%3600)/60)
+}
+
+var reFechaPDF = regexp.MustCompile(`^D:(\d{14})(?:([+\-])(\d{2})'?(\d{2})'?|Z)?`)
+
+func parsearFechaPDF(s string) (time.Time, bool) {
+	m := reFechaPDF.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	offset := 0
+	if m[2] != "" {
+		h, _ := strconv.Atoi(m[3])
+		min, _ := strconv.Atoi(m[4])
+		offset = h*3600 + min*60
+		if m[2] == "-" {
+			offset = -offset
+		}
+	}
+	t, err := time.ParseInLocation("20060102150405", m[1], time.FixedZone("", offset))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
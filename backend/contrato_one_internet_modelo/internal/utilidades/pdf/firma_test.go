@@ -0,0 +1,266 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// certificadoPrueba genera un certificado autofirmado para la clave dada
+func certificadoPrueba(t *testing.T, clave crypto.Signer) *x509.Certificate {
+	t.Helper()
+	plantilla := &x509.Certificate{
+		SerialNumber: big.NewInt(4242),
+		Subject:      pkix.Name{CommonName: "ONE Internet Prueba", Organization: []string{"ONE Internet"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, plantilla, plantilla, clave.Public(), clave)
+	if err != nil {
+		t.Fatalf("creando certificado: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("leyendo certificado: %v", err)
+	}
+	return cert
+}
+
+// documentoPrueba genera un PDF de una página con el documento nativo del paquete
+func documentoPrueba(t *testing.T) []byte {
+	t.Helper()
+	doc := NuevoDocumento()
+	doc.NuevaPagina()
+	doc.Texto(50, 800, Helvetica, 12, "Solicitud de servicio de prueba")
+	var buf bytes.Buffer
+	if err := doc.Escribir(&buf); err != nil {
+		t.Fatalf("escribiendo PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// elementosPrueba separa los elementos DER consecutivos de un contenido
+func elementosPrueba(t *testing.T, der []byte) []asn1.RawValue {
+	t.Helper()
+	var lista []asn1.RawValue
+	for len(der) > 0 {
+		var v asn1.RawValue
+		resto, err := asn1.Unmarshal(der, &v)
+		if err != nil {
+			t.Fatalf("DER inválido: %v", err)
+		}
+		lista = append(lista, v)
+		der = resto
+	}
+	return lista
+}
+
+var reByteRangePrueba = regexp.MustCompile(`/ByteRange\s*\[(\d+) (\d+) (\d+) (\d+)\s*\]`)
+
+// TestFirmarPDFVerificable firma con un certificado de prueba y valida el resultado sin pasar
+// por VerificarPDF: los rangos, el digest firmado y la firma CMS se comprueban por separado.
+func TestFirmarPDFVerificable(t *testing.T) {
+	claveRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claveEC, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nombre    string
+		clave     crypto.Signer
+		algoritmo x509.SignatureAlgorithm
+	}{
+		{"RSA", claveRSA, x509.SHA256WithRSA},
+		{"ECDSA", claveEC, x509.ECDSAWithSHA256},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			cert := certificadoPrueba(t, c.clave)
+			firmante, err := NuevoFirmante(c.clave, cert)
+			if err != nil {
+				t.Fatalf("NuevoFirmante: %v", err)
+			}
+			original := documentoPrueba(t)
+			firmado, err := firmante.FirmarPDF(original, DatosFirma{
+				Nombre:    "ONE Internet",
+				Razon:     "Prueba",
+				Ubicacion: "Mendoza",
+				Fecha:     time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("FirmarPDF: %v", err)
+			}
+
+			// 1. Actualización incremental: el original queda intacto al principio
+			if !bytes.HasPrefix(firmado, original) {
+				t.Fatal("la firma modificó el contenido original")
+			}
+
+			// 2. /ByteRange cubre todo el archivo salvo el valor de /Contents
+			m := reByteRangePrueba.FindSubmatch(firmado)
+			if m == nil {
+				t.Fatal("no se encontró /ByteRange")
+			}
+			var br [4]int
+			for i := range br {
+				br[i], _ = strconv.Atoi(string(m[i+1]))
+			}
+			if br[0] != 0 || br[2]+br[3] != len(firmado) {
+				t.Fatalf("/ByteRange %v no cubre el archivo de %d bytes", br, len(firmado))
+			}
+			if firmado[br[1]] != '<' || firmado[br[2]-1] != '>' {
+				t.Fatalf("el hueco de /ByteRange no coincide con /Contents")
+			}
+			if !bytes.Contains(firmado[br[1]-len("/Contents "):br[1]], []byte("/Contents")) {
+				t.Fatal("el hueco de /ByteRange no está precedido por /Contents")
+			}
+
+			h := sha256.New()
+			h.Write(firmado[br[0]:br[1]])
+			h.Write(firmado[br[2] : br[2]+br[3]])
+			digest := h.Sum(nil)
+
+			der, err := hex.DecodeString(string(firmado[br[1]+1 : br[2]-1]))
+			if err != nil {
+				t.Fatalf("/Contents no es hexadecimal: %v", err)
+			}
+
+			// 3. ContentInfo -> SignedData
+			var ci struct {
+				Tipo      asn1.ObjectIdentifier
+				Contenido asn1.RawValue `asn1:"explicit,tag:0"`
+			}
+			if _, err := asn1.Unmarshal(der, &ci); err != nil {
+				t.Fatalf("ContentInfo: %v", err)
+			}
+			if !ci.Tipo.Equal(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}) {
+				t.Fatalf("tipo de contenido %v, se esperaba signedData", ci.Tipo)
+			}
+			sd := elementosPrueba(t, ci.Contenido.Bytes)
+			campos := elementosPrueba(t, sd[0].Bytes)
+
+			var certEmbebido *x509.Certificate
+			var signerInfos asn1.RawValue
+			for _, campo := range campos[3:] {
+				switch {
+				case campo.Class == asn1.ClassContextSpecific && campo.Tag == 0:
+					certEmbebido, err = x509.ParseCertificate(elementosPrueba(t, campo.Bytes)[0].FullBytes)
+					if err != nil {
+						t.Fatalf("certificado embebido: %v", err)
+					}
+				case campo.Tag == asn1.TagSet:
+					signerInfos = campo
+				}
+			}
+			if certEmbebido == nil || !certEmbebido.Equal(cert) {
+				t.Fatal("el CMS no embebe el certificado del firmante")
+			}
+
+			// 4. SignerInfo: version, sid, digestAlgorithm, [0] signedAttrs, signatureAlgorithm, signature
+			si := elementosPrueba(t, elementosPrueba(t, signerInfos.Bytes)[0].Bytes)
+			if len(si) != 6 {
+				t.Fatalf("SignerInfo con %d campos", len(si))
+			}
+			atributos := si[3]
+			if atributos.Class != asn1.ClassContextSpecific || atributos.Tag != 0 {
+				t.Fatal("el SignerInfo no tiene atributos firmados")
+			}
+			var digestFirmado []byte
+			for _, raw := range elementosPrueba(t, atributos.Bytes) {
+				var a struct {
+					Tipo    asn1.ObjectIdentifier
+					Valores asn1.RawValue
+				}
+				if _, err := asn1.Unmarshal(raw.FullBytes, &a); err != nil {
+					t.Fatalf("atributo: %v", err)
+				}
+				if a.Tipo.Equal(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}) {
+					if _, err := asn1.Unmarshal(a.Valores.Bytes, &digestFirmado); err != nil {
+						t.Fatalf("messageDigest: %v", err)
+					}
+				}
+			}
+			if !bytes.Equal(digestFirmado, digest) {
+				t.Fatalf("messageDigest %x, digest de los rangos %x", digestFirmado, digest)
+			}
+
+			// 5. La firma cubre los atributos codificados como SET (RFC 5652, 5.4)
+			var firma []byte
+			if _, err := asn1.Unmarshal(si[5].FullBytes, &firma); err != nil {
+				t.Fatalf("signature: %v", err)
+			}
+			setAtributos := append([]byte{0x31}, atributos.FullBytes[1:]...)
+			if err := cert.CheckSignature(c.algoritmo, setAtributos, firma); err != nil {
+				t.Fatalf("la firma CMS no verifica con el certificado: %v", err)
+			}
+
+			// 6. VerificarPDF coincide con la verificación independiente
+			v, err := VerificarPDF(firmado)
+			if err != nil {
+				t.Fatalf("VerificarPDF: %v", err)
+			}
+			if !v.CubreDocumento || !v.CertificadoVigente || v.Firmante != "ONE Internet Prueba" || v.Razon != "Prueba" {
+				t.Errorf("firma verificada inesperada: %+v", v)
+			}
+		})
+	}
+}
+
+func TestVerificarPDFDetectaModificaciones(t *testing.T) {
+	clave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firmante, err := NuevoFirmante(clave, certificadoPrueba(t, clave))
+	if err != nil {
+		t.Fatal(err)
+	}
+	firmado, err := firmante.FirmarPDF(documentoPrueba(t), DatosFirma{Fecha: time.Now()})
+	if err != nil {
+		t.Fatalf("FirmarPDF: %v", err)
+	}
+
+	// Un byte cambiado dentro del texto de la página
+	pos := bytes.Index(firmado, []byte("prueba"))
+	if pos < 0 {
+		t.Fatal("no se encontró el texto de la página")
+	}
+	modificado := bytes.Clone(firmado)
+	modificado[pos] = 'P'
+	if _, err := VerificarPDF(modificado); !errors.Is(err, ErrFirmaInvalida) {
+		t.Errorf("PDF modificado: error %v, se esperaba ErrFirmaInvalida", err)
+	}
+
+	// Contenido agregado después de firmar: la firma sigue siendo válida pero no cubre todo
+	agregado := append(bytes.Clone(firmado), []byte("% comentario\n")...)
+	v, err := VerificarPDF(agregado)
+	if err != nil {
+		t.Fatalf("VerificarPDF con agregado: %v", err)
+	}
+	if v.CubreDocumento {
+		t.Error("CubreDocumento debería ser false con contenido posterior a la firma")
+	}
+
+	if _, err := VerificarPDF(documentoPrueba(t)); !errors.Is(err, ErrSinFirma) {
+		t.Errorf("PDF sin firmar: error %v, se esperaba ErrSinFirma", err)
+	}
+}
@@ -0,0 +1,118 @@
+package pdf
+
+// Fuente identifica una de las fuentes estándar Type1 (no requieren embeberse)
+type Fuente int
+
+const (
+	Helvetica Fuente = iota
+	HelveticaNegrita
+)
+
+// nombreBase es el BaseFont de cada fuente estándar
+func (f Fuente) nombreBase() string {
+	if f == HelveticaNegrita {
+		return "Helvetica-Bold"
+	}
+	return "Helvetica"
+}
+
+// recurso es el nombre del recurso de fuente usado en los content streams
+func (f Fuente) recurso() string {
+	if f == HelveticaNegrita {
+		return "F2"
+	}
+	return "F1"
+}
+
+// Anchos (en milésimas de em) de los caracteres 32..126 según las métricas AFM de Adobe
+var anchosHelvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // espacio - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 - 9
+	278, 278, 584, 584, 584, 556, 1015, // : - @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A - M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N - Z
+	278, 278, 278, 469, 556, 333, // [ - `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a - m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n - z
+	334, 260, 334, 584, // { - ~
+}
+
+var anchosHelveticaNegrita = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // espacio - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 - 9
+	333, 333, 584, 584, 584, 611, 975, // : - @
+	722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, // A - M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N - Z
+	333, 278, 333, 584, 556, 333, // [ - `
+	556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, // a - m
+	611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, // n - z
+	389, 280, 389, 584, // { - ~
+}
+
+// letraBase devuelve la letra sin diacríticos para estimar el ancho de caracteres latinos
+var letraBase = map[rune]rune{
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a', 'ã': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c',
+	'Á': 'A', 'À': 'A', 'Ä': 'A', 'Â': 'A', 'Ã': 'A',
+	'É': 'E', 'È': 'E', 'Ë': 'E', 'Ê': 'E',
+	'Í': 'I', 'Ì': 'I', 'Ï': 'I', 'Î': 'I',
+	'Ó': 'O', 'Ò': 'O', 'Ö': 'O', 'Ô': 'O', 'Õ': 'O',
+	'Ú': 'U', 'Ù': 'U', 'Ü': 'U', 'Û': 'U',
+	'Ñ': 'N', 'Ç': 'C',
+	'º': 'o', 'ª': 'a',
+}
+
+// anchoRune devuelve el ancho de un carácter en milésimas de em
+func (f Fuente) anchoRune(r rune) int {
+	tabla := &anchosHelvetica
+	if f == HelveticaNegrita {
+		tabla = &anchosHelveticaNegrita
+	}
+	if b, ok := letraBase[r]; ok {
+		r = b
+	}
+	if r >= 32 && r <= 126 {
+		return tabla[r-32]
+	}
+	return 556
+}
+
+// codificarWinAnsi convierte texto UTF-8 a WinAnsiEncoding (cp1252).
+// Los caracteres sin representación se reemplazan por '?'.
+func codificarWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 128:
+			out = append(out, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			switch r {
+			case '€':
+				out = append(out, 0x80)
+			case '‘':
+				out = append(out, 0x91)
+			case '’':
+				out = append(out, 0x92)
+			case '“':
+				out = append(out, 0x93)
+			case '”':
+				out = append(out, 0x94)
+			case '•':
+				out = append(out, 0x95)
+			case '–':
+				out = append(out, 0x96)
+			case '—':
+				out = append(out, 0x97)
+			default:
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
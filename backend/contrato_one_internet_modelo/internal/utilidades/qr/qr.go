@@ -0,0 +1,461 @@
+// Package qr genera códigos QR (ISO/IEC 18004) en modo byte con corrección de errores
+// nivel M, versiones 1 a 15 (hasta 412 bytes), suficiente para URLs de verificación y el
+// código de las facturas electrónicas (RG 4892).
+package qr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// ErrDemasiadoLargo indica que el texto no entra en la versión máxima soportada
+var ErrDemasiadoLargo = errors.New("texto demasiado largo para el código QR")
+
+// bloquesM describe, por versión, los codewords de corrección por bloque y los
+// grupos de bloques de datos (cantidad, codewords de datos) para el nivel M.
+var bloquesM = [16]struct {
+	ecPorBloque int
+	grupos      [][2]int
+}{
+	1:  {10, [][2]int{{1, 16}}},
+	2:  {16, [][2]int{{1, 28}}},
+	3:  {26, [][2]int{{1, 44}}},
+	4:  {18, [][2]int{{2, 32}}},
+	5:  {24, [][2]int{{2, 43}}},
+	6:  {16, [][2]int{{4, 27}}},
+	7:  {18, [][2]int{{4, 31}}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}},
+	11: {30, [][2]int{{1, 50}, {4, 51}}},
+	12: {22, [][2]int{{6, 36}, {2, 37}}},
+	13: {22, [][2]int{{8, 37}, {1, 38}}},
+	14: {24, [][2]int{{4, 40}, {5, 41}}},
+	15: {24, [][2]int{{5, 41}, {5, 42}}},
+}
+
+// posicionesAlineacion son los centros de los patrones de alineación por versión
+var posicionesAlineacion = [16][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+	11: {6, 30, 54},
+	12: {6, 32, 58},
+	13: {6, 34, 62},
+	14: {6, 26, 46, 66},
+	15: {6, 26, 48, 70},
+}
+
+// Codigo es la matriz de módulos de un QR (true = módulo oscuro)
+type Codigo struct {
+	Version int
+	Tamano  int
+	modulos [][]bool
+	funcion [][]bool // módulos reservados (patrones, formato, versión)
+}
+
+// Oscuro indica si el módulo de la fila y columna dadas es oscuro
+func (c *Codigo) Oscuro(fila, col int) bool {
+	return c.modulos[fila][col]
+}
+
+// Codificar genera el QR más chico que contiene texto
+func Codificar(texto string) (*Codigo, error) {
+	datos := []byte(texto)
+
+	// 1. Elegir la versión mínima
+	version := 0
+	for v := 1; v <= 15; v++ {
+		bitsConteo := 8
+		if v >= 10 {
+			bitsConteo = 16
+		}
+		if 4+bitsConteo+8*len(datos) <= 8*capacidadDatos(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrDemasiadoLargo
+	}
+
+	// 2. Codewords de datos y corrección, intercalados
+	codewords := intercalar(version, codificarDatos(version, datos))
+
+	// 3. Matriz con patrones de función y datos
+	c := nuevoCodigo(version)
+	c.ubicarDatos(codewords)
+
+	// 4. Elegir la máscara con menor penalización
+	mejor, menorPenalizacion := 0, -1
+	for m := 0; m < 8; m++ {
+		c.aplicarMascara(m)
+		c.dibujarFormato(m)
+		if p := c.penalizacion(); menorPenalizacion < 0 || p < menorPenalizacion {
+			mejor, menorPenalizacion = m, p
+		}
+		c.aplicarMascara(m) // XOR: deshace la máscara
+	}
+	c.aplicarMascara(mejor)
+	c.dibujarFormato(mejor)
+	return c, nil
+}
+
+// Imagen dibuja el código con escala píxeles por módulo y la zona de silencio reglamentaria (4 módulos)
+func (c *Codigo) Imagen(escala int) image.Image {
+	const borde = 4
+	lado := (c.Tamano + 2*borde) * escala
+	img := image.NewGray(image.Rect(0, 0, lado, lado))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for f := 0; f < c.Tamano; f++ {
+		for col := 0; col < c.Tamano; col++ {
+			if !c.modulos[f][col] {
+				continue
+			}
+			for dy := 0; dy < escala; dy++ {
+				for dx := 0; dx < escala; dx++ {
+					img.SetGray((col+borde)*escala+dx, (f+borde)*escala+dy, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+	return img
+}
+
+func capacidadDatos(version int) int {
+	total := 0
+	for _, g := range bloquesM[version].grupos {
+		total += g[0] * g[1]
+	}
+	return total
+}
+
+// codificarDatos arma el flujo de bits en modo byte con terminador y relleno
+func codificarDatos(version int, datos []byte) []byte {
+	var bits []bool
+	agregar := func(valor, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (valor>>i)&1 == 1)
+		}
+	}
+
+	bitsConteo := 8
+	if version >= 10 {
+		bitsConteo = 16
+	}
+	agregar(0b0100, 4) // modo byte
+	agregar(len(datos), bitsConteo)
+	for _, b := range datos {
+		agregar(int(b), 8)
+	}
+
+	capacidad := 8 * capacidadDatos(version)
+	for i := 0; i < 4 && len(bits) < capacidad; i++ {
+		bits = append(bits, false) // terminador
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	out := make([]byte, 0, capacidad/8)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for relleno := byte(0xEC); len(out) < capacidad/8; relleno ^= 0xEC ^ 0x11 {
+		out = append(out, relleno)
+	}
+	return out
+}
+
+// intercalar divide los datos en bloques, calcula su corrección Reed-Solomon y los intercala
+func intercalar(version int, datos []byte) []byte {
+	info := bloquesM[version]
+	var bloques, correcciones [][]byte
+	pos := 0
+	for _, g := range info.grupos {
+		for i := 0; i < g[0]; i++ {
+			bloque := datos[pos : pos+g[1]]
+			pos += g[1]
+			bloques = append(bloques, bloque)
+			correcciones = append(correcciones, restoReedSolomon(bloque, info.ecPorBloque))
+		}
+	}
+
+	var out []byte
+	for i := 0; ; i++ {
+		agregado := false
+		for _, b := range bloques {
+			if i < len(b) {
+				out = append(out, b[i])
+				agregado = true
+			}
+		}
+		if !agregado {
+			break
+		}
+	}
+	for i := 0; i < info.ecPorBloque; i++ {
+		for _, ec := range correcciones {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+func nuevoCodigo(version int) *Codigo {
+	tam := 17 + 4*version
+	c := &Codigo{Version: version, Tamano: tam}
+	c.modulos = make([][]bool, tam)
+	c.funcion = make([][]bool, tam)
+	for i := range c.modulos {
+		c.modulos[i] = make([]bool, tam)
+		c.funcion[i] = make([]bool, tam)
+	}
+
+	// Patrones de sincronización
+	for i := 0; i < tam; i++ {
+		c.fijar(6, i, i%2 == 0)
+		c.fijar(i, 6, i%2 == 0)
+	}
+
+	// Patrones de posición (con separador) en tres esquinas
+	c.dibujarPosicion(3, 3)
+	c.dibujarPosicion(3, tam-4)
+	c.dibujarPosicion(tam-4, 3)
+
+	// Patrones de alineación, salvo los que se superponen con los de posición
+	pos := posicionesAlineacion[version]
+	for i, f := range pos {
+		for j, col := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == len(pos)-1) || (i == len(pos)-1 && j == 0) {
+				continue
+			}
+			c.dibujarAlineacion(f, col)
+		}
+	}
+
+	// Reservar las áreas de formato y versión
+	c.dibujarFormato(0)
+	if version >= 7 {
+		c.dibujarVersion()
+	}
+	return c
+}
+
+func (c *Codigo) fijar(fila, col int, oscuro bool) {
+	c.modulos[fila][col] = oscuro
+	c.funcion[fila][col] = true
+}
+
+func (c *Codigo) dibujarPosicion(fila, col int) {
+	for df := -4; df <= 4; df++ {
+		for dc := -4; dc <= 4; dc++ {
+			f, cc := fila+df, col+dc
+			if f < 0 || f >= c.Tamano || cc < 0 || cc >= c.Tamano {
+				continue
+			}
+			dist := max(abs(df), abs(dc))
+			c.fijar(f, cc, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Codigo) dibujarAlineacion(fila, col int) {
+	for df := -2; df <= 2; df++ {
+		for dc := -2; dc <= 2; dc++ {
+			c.fijar(fila+df, col+dc, max(abs(df), abs(dc)) != 1)
+		}
+	}
+}
+
+// dibujarFormato escribe los 15 bits de formato (nivel M y máscara) en sus dos copias
+func (c *Codigo) dibujarFormato(mascara int) {
+	const nivelM = 0b00
+	datos := nivelM<<3 | mascara
+	resto := datos
+	for i := 0; i < 10; i++ {
+		resto = (resto << 1) ^ ((resto >> 9) * 0x537)
+	}
+	bits := (datos<<10 | resto) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	// Primera copia, alrededor del patrón de posición superior izquierdo
+	for i := 0; i <= 5; i++ {
+		c.fijar(i, 8, bit(i))
+	}
+	c.fijar(7, 8, bit(6))
+	c.fijar(8, 8, bit(7))
+	c.fijar(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		c.fijar(8, 14-i, bit(i))
+	}
+
+	// Segunda copia, repartida entre las otras dos esquinas
+	for i := 0; i < 8; i++ {
+		c.fijar(8, c.Tamano-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.fijar(c.Tamano-15+i, 8, bit(i))
+	}
+	c.fijar(c.Tamano-8, 8, true) // módulo oscuro fijo
+}
+
+// dibujarVersion escribe los 18 bits de versión (versiones 7 en adelante)
+func (c *Codigo) dibujarVersion() {
+	resto := c.Version
+	for i := 0; i < 12; i++ {
+		resto = (resto << 1) ^ ((resto >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | resto
+	for i := 0; i < 18; i++ {
+		oscuro := (bits>>i)&1 == 1
+		a, b := c.Tamano-11+i%3, i/3
+		c.fijar(b, a, oscuro)
+		c.fijar(a, b, oscuro)
+	}
+}
+
+// ubicarDatos recorre la matriz en zigzag de a dos columnas, de abajo hacia arriba y viceversa
+func (c *Codigo) ubicarDatos(codewords []byte) {
+	i := 0
+	for derecha := c.Tamano - 1; derecha >= 1; derecha -= 2 {
+		if derecha == 6 {
+			derecha = 5 // saltear la columna de sincronización
+		}
+		for vert := 0; vert < c.Tamano; vert++ {
+			for j := 0; j < 2; j++ {
+				col := derecha - j
+				fila := vert
+				if (derecha+1)&2 == 0 {
+					fila = c.Tamano - 1 - vert
+				}
+				if c.funcion[fila][col] {
+					continue
+				}
+				if i < len(codewords)*8 {
+					c.modulos[fila][col] = (codewords[i/8]>>(7-i%8))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (c *Codigo) aplicarMascara(m int) {
+	for f := 0; f < c.Tamano; f++ {
+		for col := 0; col < c.Tamano; col++ {
+			if c.funcion[f][col] {
+				continue
+			}
+			var invertir bool
+			switch m {
+			case 0:
+				invertir = (f+col)%2 == 0
+			case 1:
+				invertir = f%2 == 0
+			case 2:
+				invertir = col%3 == 0
+			case 3:
+				invertir = (f+col)%3 == 0
+			case 4:
+				invertir = (f/2+col/3)%2 == 0
+			case 5:
+				invertir = (f*col)%2+(f*col)%3 == 0
+			case 6:
+				invertir = ((f*col)%2+(f*col)%3)%2 == 0
+			case 7:
+				invertir = ((f+col)%2+(f*col)%3)%2 == 0
+			}
+			if invertir {
+				c.modulos[f][col] = !c.modulos[f][col]
+			}
+		}
+	}
+}
+
+// penalizacion evalúa las cuatro reglas de la norma para elegir la máscara
+func (c *Codigo) penalizacion() int {
+	n := c.Tamano
+	total := 0
+	en := func(f, col int, fila bool) bool {
+		if fila {
+			return c.modulos[f][col]
+		}
+		return c.modulos[col][f]
+	}
+
+	for _, fila := range []bool{true, false} {
+		for a := 0; a < n; a++ {
+			// Regla 1: rachas de 5 o más módulos del mismo color
+			racha := 1
+			for b := 1; b < n; b++ {
+				if en(a, b, fila) == en(a, b-1, fila) {
+					racha++
+					continue
+				}
+				if racha >= 5 {
+					total += racha - 2
+				}
+				racha = 1
+			}
+			if racha >= 5 {
+				total += racha - 2
+			}
+
+			// Regla 3: patrones similares a los de posición (1:1:3:1:1 con 4 claros)
+			for b := 0; b+11 <= n; b++ {
+				patron := [11]bool{}
+				for k := range patron {
+					patron[k] = en(a, b+k, fila)
+				}
+				if patron == [11]bool{true, false, true, true, true, false, true, false, false, false, false} ||
+					patron == [11]bool{false, false, false, false, true, false, true, true, true, false, true} {
+					total += 40
+				}
+			}
+		}
+	}
+
+	// Regla 2: bloques de 2x2 del mismo color
+	oscuros := 0
+	for f := 0; f < n; f++ {
+		for col := 0; col < n; col++ {
+			if c.modulos[f][col] {
+				oscuros++
+			}
+			if f+1 < n && col+1 < n {
+				v := c.modulos[f][col]
+				if c.modulos[f+1][col] == v && c.modulos[f][col+1] == v && c.modulos[f+1][col+1] == v {
+					total += 3
+				}
+			}
+		}
+	}
+
+	// Regla 4: proporción de módulos oscuros alejada del 50%
+	porcentaje := oscuros * 100 / (n * n)
+	total += abs(porcentaje-50) / 5 * 10
+	return total
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,246 @@
+package qr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Bits de formato del nivel M por máscara (ISO/IEC 18004, tabla C.1)
+var formatoM = map[string]int{
+	"101010000010010": 0,
+	"101000100100101": 1,
+	"101111001111100": 2,
+	"101101101001011": 3,
+	"100010111111001": 4,
+	"100000011001110": 5,
+	"100111110010111": 6,
+	"100101010100000": 7,
+}
+
+// Estructura de bloques del nivel M y bits de versión de las versiones que se decodifican
+var estructuraPrueba = map[int]struct {
+	ecPorBloque int
+	bloques     []int
+	alineacion  []int
+	bitsVersion int
+	bitsConteo  int
+}{
+	1:  {10, []int{16}, nil, 0, 8},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}, 0x07C94, 8},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}, 0x0A4D3, 16},
+}
+
+// decodificar lee el código como lo haría un lector: formato, máscara, codewords en zigzag,
+// desintercalado, síndromes Reed-Solomon y datos en modo byte.
+func decodificar(t *testing.T, c *Codigo) string {
+	t.Helper()
+	n := c.Tamano
+	e, ok := estructuraPrueba[c.Version]
+	if !ok {
+		t.Fatalf("versión %d sin estructura de prueba", c.Version)
+	}
+	if n != 17+4*c.Version {
+		t.Fatalf("tamaño %d para la versión %d", n, c.Version)
+	}
+
+	// 1. Formato (copia superior izquierda, del bit 14 al 0) y su copia
+	var formato, copia strings.Builder
+	for _, p := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		formato.WriteByte(bitTexto(c.Oscuro(p[0], p[1])))
+	}
+	for i := 0; i < 7; i++ {
+		copia.WriteByte(bitTexto(c.Oscuro(n-1-i, 8)))
+	}
+	for i := 0; i < 8; i++ {
+		copia.WriteByte(bitTexto(c.Oscuro(8, n-8+i)))
+	}
+	mascara, ok := formatoM[formato.String()]
+	if !ok {
+		t.Fatalf("formato %s no corresponde al nivel M", formato.String())
+	}
+	if copia.String() != formato.String() {
+		t.Fatalf("copias de formato distintas: %s y %s", formato.String(), copia.String())
+	}
+	if !c.Oscuro(n-8, 8) {
+		t.Fatal("falta el módulo oscuro fijo")
+	}
+
+	// 2. Versión (bloque superior derecho) desde la versión 7
+	if e.bitsVersion != 0 {
+		bits := 0
+		for i := 17; i >= 0; i-- {
+			bits <<= 1
+			if c.Oscuro(i/3, n-11+i%3) {
+				bits |= 1
+			}
+		}
+		if bits != e.bitsVersion {
+			t.Fatalf("bits de versión %05X, se esperaba %05X", bits, e.bitsVersion)
+		}
+	}
+
+	// 3. Módulos de función
+	funcion := make([][]bool, n)
+	for i := range funcion {
+		funcion[i] = make([]bool, n)
+	}
+	marcar := func(f0, c0, alto, ancho int) {
+		for f := f0; f < f0+alto; f++ {
+			for col := c0; col < c0+ancho; col++ {
+				funcion[f][col] = true
+			}
+		}
+	}
+	marcar(0, 0, 9, 9)
+	marcar(0, n-8, 9, 8)
+	marcar(n-8, 0, 8, 9)
+	marcar(6, 0, 1, n)
+	marcar(0, 6, n, 1)
+	ultima := len(e.alineacion) - 1
+	for i, f := range e.alineacion {
+		for j, col := range e.alineacion {
+			// Los de las esquinas coincidirían con los patrones de posición
+			if (i == 0 && j == 0) || (i == 0 && j == ultima) || (i == ultima && j == 0) {
+				continue
+			}
+			marcar(f-2, col-2, 5, 5)
+		}
+	}
+	if e.bitsVersion != 0 {
+		marcar(0, n-11, 6, 3)
+		marcar(n-11, 0, 3, 6)
+	}
+
+	// 4. Codewords leídos en zigzag, quitando la máscara
+	invertida := []func(f, col int) bool{
+		func(f, col int) bool { return (f+col)%2 == 0 },
+		func(f, col int) bool { return f%2 == 0 },
+		func(f, col int) bool { return col%3 == 0 },
+		func(f, col int) bool { return (f+col)%3 == 0 },
+		func(f, col int) bool { return (f/2+col/3)%2 == 0 },
+		func(f, col int) bool { return f*col%2+f*col%3 == 0 },
+		func(f, col int) bool { return (f*col%2+f*col%3)%2 == 0 },
+		func(f, col int) bool { return ((f+col)%2+f*col%3)%2 == 0 },
+	}[mascara]
+	var leidos []byte
+	var actual byte
+	nbits := 0
+	subiendo := true
+	for derecha := n - 1; derecha > 0; derecha -= 2 {
+		if derecha == 6 {
+			derecha--
+		}
+		for k := 0; k < n; k++ {
+			f := k
+			if subiendo {
+				f = n - 1 - k
+			}
+			for _, col := range []int{derecha, derecha - 1} {
+				if funcion[f][col] {
+					continue
+				}
+				actual = actual<<1 | byte(boolInt(c.Oscuro(f, col) != invertida(f, col)))
+				if nbits++; nbits%8 == 0 {
+					leidos = append(leidos, actual)
+				}
+			}
+		}
+		subiendo = !subiendo
+	}
+
+	// 5. Desintercalar y verificar cada bloque con sus síndromes
+	bloques := make([][]byte, len(e.bloques))
+	pos := 0
+	for i := 0; i < e.bloques[len(e.bloques)-1]; i++ {
+		for b, largo := range e.bloques {
+			if i < largo {
+				bloques[b] = append(bloques[b], leidos[pos])
+				pos++
+			}
+		}
+	}
+	var datos []byte
+	for _, bloque := range bloques {
+		datos = append(datos, bloque...)
+	}
+	for i := 0; i < e.ecPorBloque; i++ {
+		for b := range bloques {
+			bloques[b] = append(bloques[b], leidos[pos])
+			pos++
+		}
+	}
+	for b, bloque := range bloques {
+		for i, s := range sindromes(bloque, e.ecPorBloque) {
+			if s != 0 {
+				t.Fatalf("versión %d, bloque %d: síndrome %d = %d", c.Version, b, i, s)
+			}
+		}
+	}
+
+	// 6. Modo byte: indicador 0100, longitud y datos
+	bit := 0
+	leer := func(cant int) int {
+		v := 0
+		for i := 0; i < cant; i++ {
+			v = v<<1 | int(datos[bit/8]>>(7-bit%8)&1)
+			bit++
+		}
+		return v
+	}
+	if modo := leer(4); modo != 0b0100 {
+		t.Fatalf("modo %04b, se esperaba byte (0100)", modo)
+	}
+	largo := leer(e.bitsConteo)
+	texto := make([]byte, largo)
+	for i := range texto {
+		texto[i] = byte(leer(8))
+	}
+	return string(texto)
+}
+
+func bitTexto(oscuro bool) byte {
+	if oscuro {
+		return '1'
+	}
+	return '0'
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestCodificarDecodifica(t *testing.T) {
+	casos := []struct {
+		texto   string
+		version int
+	}{
+		{"ONE-AB12CD", 1},
+		{"https://oneinternet.com.ar/v1/verificar-contrato?codigo=" + strings.Repeat("X7", 27), 7},
+		{"https://www.afip.gob.ar/fe/qr/?p=" + strings.Repeat("eyJ2ZXIiOjEsImZlY2hhIjoi", 7), 10},
+	}
+	for _, c := range casos {
+		qr, err := Codificar(c.texto)
+		if err != nil {
+			t.Fatalf("Codificar(%d bytes): %v", len(c.texto), err)
+		}
+		if qr.Version != c.version {
+			t.Fatalf("%d bytes: versión %d, se esperaba %d", len(c.texto), qr.Version, c.version)
+		}
+		if got := decodificar(t, qr); got != c.texto {
+			t.Errorf("versión %d: decodificado %q, se esperaba %q", c.version, got, c.texto)
+		}
+	}
+}
+
+func TestCodificarLimite(t *testing.T) {
+	if _, err := Codificar(strings.Repeat("a", 412)); err != nil {
+		t.Errorf("412 bytes: %v", err)
+	}
+	if _, err := Codificar(strings.Repeat("a", 413)); !errors.Is(err, ErrDemasiadoLargo) {
+		t.Errorf("413 bytes: error %v, se esperaba ErrDemasiadoLargo", err)
+	}
+}
@@ -0,0 +1,57 @@
+package qr
+
+// Aritmética en GF(256) con el polinomio primitivo x^8 + x^4 + x^3 + x^2 + 1 (0x11D)
+var expGF, logGF [256]int
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expGF[i] = x
+		logGF[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	expGF[255] = expGF[0]
+}
+
+func multiplicarGF(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expGF[(logGF[a]+logGF[b])%255]
+}
+
+// polinomioGenerador devuelve los coeficientes (sin el principal) de ∏ (x - α^i), i = 0..grado-1
+func polinomioGenerador(grado int) []int {
+	g := []int{1}
+	for i := 0; i < grado; i++ {
+		sig := make([]int, len(g)+1)
+		for j, coef := range g {
+			sig[j] ^= coef
+			sig[j+1] ^= multiplicarGF(coef, expGF[i])
+		}
+		g = sig
+	}
+	return g[1:]
+}
+
+// restoReedSolomon calcula los n codewords de corrección de errores de un bloque de datos
+func restoReedSolomon(datos []byte, n int) []byte {
+	gen := polinomioGenerador(n)
+	resto := make([]int, n)
+	for _, b := range datos {
+		factor := int(b) ^ resto[0]
+		copy(resto, resto[1:])
+		resto[n-1] = 0
+		for i := range resto {
+			resto[i] ^= multiplicarGF(gen[i], factor)
+		}
+	}
+	out := make([]byte, n)
+	for i, v := range resto {
+		out[i] = byte(v)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package qr
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// Vectores publicados de versión 1-M (16 codewords de datos, 10 de corrección)
+func TestRestoReedSolomonVectoresPublicados(t *testing.T) {
+	casos := []struct {
+		nombre string
+		datos  []byte
+		ec     []byte
+	}{
+		{
+			// ISO/IEC 18004, anexo I: "01234567" en modo numérico
+			nombre: "ISO 18004 anexo I",
+			datos:  []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			ec:     []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55},
+		},
+		{
+			// "HELLO WORLD" en modo alfanumérico
+			nombre: "HELLO WORLD",
+			datos:  []byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			ec:     []byte{0xC4, 0x23, 0x27, 0x77, 0xEB, 0xD7, 0xE7, 0xE2, 0x5D, 0x17},
+		},
+	}
+	for _, c := range casos {
+		if got := restoReedSolomon(c.datos, len(c.ec)); !bytes.Equal(got, c.ec) {
+			t.Errorf("%s: corrección % X, se esperaba % X", c.nombre, got, c.ec)
+		}
+	}
+}
+
+// Polinomio generador de grado 10 de la norma (anexo A), en exponentes de α
+func TestPolinomioGenerador(t *testing.T) {
+	exponentes := []int{251, 67, 46, 61, 118, 70, 64, 94, 32, 45}
+	gen := polinomioGenerador(10)
+	for i, e := range exponentes {
+		if gen[i] != expGF[e] {
+			t.Errorf("coeficiente %d = %d, se esperaba α^%d = %d", i, gen[i], e, expGF[e])
+		}
+	}
+}
+
+// sindromes evalúa el codeword (datos seguidos de la corrección) en α^0..α^(n-1)
+func sindromes(codeword []byte, n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		for _, b := range codeword {
+			s[i] = multiplicarGF(s[i], expGF[i]) ^ int(b)
+		}
+	}
+	return s
+}
+
+// Un bloque bien codificado tiene síndromes nulos y deja de tenerlos si se altera un byte
+func TestRestoReedSolomonSindromesNulos(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{10, 16, 18, 22, 24, 26, 30} {
+		datos := make([]byte, 40)
+		r.Read(datos)
+		codeword := append(append([]byte{}, datos...), restoReedSolomon(datos, n)...)
+		for i, s := range sindromes(codeword, n) {
+			if s != 0 {
+				t.Fatalf("n=%d: síndrome %d = %d", n, i, s)
+			}
+		}
+
+		codeword[3] ^= 0x40
+		nulos := true
+		for _, s := range sindromes(codeword, n) {
+			nulos = nulos && s == 0
+		}
+		if nulos {
+			t.Errorf("n=%d: un byte alterado no cambió los síndromes", n)
+		}
+	}
+}
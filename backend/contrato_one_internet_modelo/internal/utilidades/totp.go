@@ -0,0 +1,100 @@
+package utilidades
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parámetros TOTP (RFC 6238) compatibles con Google Authenticator, Authy, etc.
+const (
+	PeriodoTOTP      = 30 // segundos de validez de cada código
+	DigitosTOTP      = 6
+	toleranciaTOTP   = 1  // pasos aceptados antes y después del actual (desfase de reloj)
+	bytesSecretoTOTP = 20 // 160 bits, lo recomendado por la RFC 4226 para HMAC-SHA1
+)
+
+var base32SinRelleno = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerarSecretoTOTP genera un secreto aleatorio en Base32 sin relleno, el formato que
+// esperan las apps autenticadoras
+func GenerarSecretoTOTP() (string, error) {
+	b := make([]byte, bytesSecretoTOTP)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32SinRelleno.EncodeToString(b), nil
+}
+
+// URIProvisionTOTP arma la URI otpauth:// que las apps leen desde el QR
+func URIProvisionTOTP(emisor, cuenta, secreto string) string {
+	etiqueta := url.PathEscape(emisor + ":" + cuenta)
+	q := url.Values{}
+	q.Set("secret", secreto)
+	q.Set("issuer", emisor)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprintf("%d", DigitosTOTP))
+	q.Set("period", fmt.Sprintf("%d", PeriodoTOTP))
+	return "otpauth://totp/" + etiqueta + "?" + q.Encode()
+}
+
+// PasoTOTP devuelve el número de intervalo de PeriodoTOTP segundos al que pertenece t
+func PasoTOTP(t time.Time) int64 {
+	return t.Unix() / PeriodoTOTP
+}
+
+// CodigoTOTP calcula el código del secreto para un paso (HOTP de la RFC 4226)
+func CodigoTOTP(secreto string, paso int64) (string, error) {
+	clave, err := base32SinRelleno.DecodeString(strings.ToUpper(strings.TrimRight(secreto, "=")))
+	if err != nil {
+		return "", fmt.Errorf("secreto TOTP inválido: %w", err)
+	}
+
+	var contador [8]byte
+	binary.BigEndian.PutUint64(contador[:], uint64(paso))
+	mac := hmac.New(sha1.New, clave)
+	mac.Write(contador[:])
+	sum := mac.Sum(nil)
+
+	// Truncamiento dinámico
+	offset := sum[len(sum)-1] & 0x0f
+	valor := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < DigitosTOTP; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", DigitosTOTP, valor%modulo), nil
+}
+
+// ValidarTOTP verifica el código contra el paso actual y los vecinos. Para que un código no
+// pueda usarse dos veces sólo acepta pasos posteriores a ultimoPaso; devuelve el paso que
+// coincidió, que hay que guardar como el nuevo último paso usado.
+func ValidarTOTP(secreto, codigo string, ahora time.Time, ultimoPaso int64) (int64, bool, error) {
+	codigo = strings.ReplaceAll(strings.TrimSpace(codigo), " ", "")
+	if len(codigo) != DigitosTOTP {
+		return 0, false, nil
+	}
+
+	actual := PasoTOTP(ahora)
+	for paso := actual - toleranciaTOTP; paso <= actual+toleranciaTOTP; paso++ {
+		if paso <= ultimoPaso {
+			continue
+		}
+		esperado, err := CodigoTOTP(secreto, paso)
+		if err != nil {
+			return 0, false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(esperado), []byte(codigo)) == 1 {
+			return paso, true, nil
+		}
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,106 @@
+package utilidades
+
+import (
+	"testing"
+	"time"
+)
+
+// secretoRFC6238 es la clave SHA-1 de los vectores del Apéndice B de la RFC 6238
+// ("12345678901234567890" en ASCII) en Base32
+var secretoRFC6238 = base32SinRelleno.EncodeToString([]byte("12345678901234567890"))
+
+// TestCodigoTOTPVectoresRFC6238 usa los vectores SHA-1 del Apéndice B. La RFC los da con 8
+// dígitos; con DigitosTOTP = 6 el código son sus últimos 6 dígitos (el módulo es 10^6).
+func TestCodigoTOTPVectoresRFC6238(t *testing.T) {
+	vectores := []struct {
+		unix   int64
+		codigo string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, v := range vectores {
+		esperado := v.codigo[len(v.codigo)-DigitosTOTP:]
+		codigo, err := CodigoTOTP(secretoRFC6238, PasoTOTP(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("CodigoTOTP(%d): %v", v.unix, err)
+		}
+		if codigo != esperado {
+			t.Errorf("T = %d: código %s, se esperaba %s", v.unix, codigo, esperado)
+		}
+	}
+}
+
+func TestValidarTOTPVentana(t *testing.T) {
+	ahora := time.Unix(1234567890, 0)
+	actual := PasoTOTP(ahora)
+	codigo := func(paso int64) string {
+		c, err := CodigoTOTP(secretoRFC6238, paso)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// Se aceptan el paso actual y uno antes o después (desfase de reloj)
+	for _, desfase := range []int64{-1, 0, 1} {
+		paso, ok, err := ValidarTOTP(secretoRFC6238, codigo(actual+desfase), ahora, 0)
+		if err != nil || !ok || paso != actual+desfase {
+			t.Errorf("desfase %d: paso %d, ok %v, err %v", desfase, paso, ok, err)
+		}
+	}
+
+	// Dos pasos de distancia ya no
+	for _, desfase := range []int64{-2, 2} {
+		if _, ok, _ := ValidarTOTP(secretoRFC6238, codigo(actual+desfase), ahora, 0); ok {
+			t.Errorf("desfase %d: se aceptó un código fuera de la ventana", desfase)
+		}
+	}
+
+	// Espacios del usuario se ignoran; largo incorrecto o secreto inválido no validan
+	c := codigo(actual)
+	if _, ok, _ := ValidarTOTP(secretoRFC6238, " "+c[:3]+" "+c[3:]+" ", ahora, 0); !ok {
+		t.Error("no se aceptó el código con espacios")
+	}
+	if _, ok, _ := ValidarTOTP(secretoRFC6238, c[:5], ahora, 0); ok {
+		t.Error("se aceptó un código de 5 dígitos")
+	}
+	if _, _, err := ValidarTOTP("no es base32!", c, ahora, 0); err == nil {
+		t.Error("se esperaba error con un secreto inválido")
+	}
+}
+
+func TestValidarTOTPRechazaReuso(t *testing.T) {
+	ahora := time.Unix(1234567890, 0)
+	actual := PasoTOTP(ahora)
+	codigo, _ := CodigoTOTP(secretoRFC6238, actual)
+
+	// 1. Primer uso: se acepta y devuelve el paso a guardar
+	ultimo, ok, err := ValidarTOTP(secretoRFC6238, codigo, ahora, 0)
+	if err != nil || !ok || ultimo != actual {
+		t.Fatalf("primer uso: paso %d, ok %v, err %v", ultimo, ok, err)
+	}
+
+	// 2. El mismo código, aun dentro de su ventana, no vuelve a servir
+	for _, despues := range []time.Duration{0, 10 * time.Second, PeriodoTOTP * time.Second} {
+		if _, ok, _ := ValidarTOTP(secretoRFC6238, codigo, ahora.Add(despues), ultimo); ok {
+			t.Errorf("+%s: se aceptó un código ya usado", despues)
+		}
+	}
+
+	// 3. Un código anterior al último usado tampoco, aunque esté en la ventana
+	previo, _ := CodigoTOTP(secretoRFC6238, actual-1)
+	if _, ok, _ := ValidarTOTP(secretoRFC6238, previo, ahora, ultimo); ok {
+		t.Error("se aceptó un código anterior al último usado")
+	}
+
+	// 4. El del paso siguiente sí
+	siguiente, _ := CodigoTOTP(secretoRFC6238, actual+1)
+	if paso, ok, _ := ValidarTOTP(secretoRFC6238, siguiente, ahora.Add(PeriodoTOTP*time.Second), ultimo); !ok || paso != actual+1 {
+		t.Errorf("paso siguiente: paso %d, ok %v", paso, ok)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"log"
@@ -12,6 +13,8 @@ import (
 	"contrato_one_internet_modelo/internal/config"
 	"contrato_one_internet_modelo/internal/database"
 	"contrato_one_internet_modelo/internal/rutas"
+	"contrato_one_internet_modelo/internal/servicios"
+	"contrato_one_internet_modelo/internal/utilidades"
 	"contrato_one_internet_modelo/internal/utilidades/logger"
 )
 
@@ -43,11 +46,48 @@ func main() {
 		logger.Error.Fatalf("Error fatal al cargar configuración: %v", err)
 	}
 
+	// Los tokens opacos se guardan hasheados con esta clave
+	utilidades.ConfigurarClaveTokens(appCfg.TokensClaveHash)
+
 	// Conectar a la base de datos
 	db := database.ConnectDB(appCfg.DBConfig)
 
+	// Verificar que existan las filas de catálogo de las que depende el código
+	faltantes, err := servicios.NewCatalogoService(db).VerificarRequeridos(context.Background())
+	if err != nil {
+		logger.Error.Fatalf("Error verificando catálogos requeridos: %v", err)
+	}
+	if len(faltantes) > 0 {
+		for _, f := range faltantes {
+			logger.Error.Printf("Falta la fila requerida %s", f)
+		}
+		logger.Error.Fatalf("Faltan %d fila(s) de catálogo requeridas. Ejecute: cd internal/cmd/sembrar_catalogos && go run .", len(faltantes))
+	}
+
+	// Despachador del outbox: convierte los eventos de dominio en notificaciones e inicia la
+	// firma de los contratos pagados. Las rutas usan la misma instancia del servicio de firma.
+	firmaDigitalService := rutas.ConfigurarFirmaDigital(db, appCfg)
+	go servicios.NewOutboxService(db, firmaDigitalService).Iniciar(context.Background())
+
+	// Recordatorios del día anterior a cada turno de instalación
+	go servicios.NewTurnoService(db).IniciarRecordatorios(context.Background())
+
+	// Facturación mensual de los contratos vigentes y, si está habilitada, factura electrónica.
+	// Las rutas usan la misma instancia para compartir el ticket de acceso de AFIP.
+	autorizadorAFIP, err := servicios.NewAutorizadorAFIP(db, appCfg.Afip)
+	if err != nil {
+		logger.Error.Fatalf("Error configurando la factura electrónica: %v", err)
+	}
+	facturacionService := servicios.NewFacturacionService(db, appCfg.Facturacion.FacturasPath,
+		appCfg.Facturacion.PuntoVenta, appCfg.Facturacion.DiasVencimiento, appCfg.Facturacion.CondicionIVAEmisor, autorizadorAFIP)
+	go facturacionService.IniciarFacturacion(context.Background())
+
+	// Revisión de la cuenta corriente: suspende por deuda y restablece al ponerse al día
+	cuentaService := servicios.NewCuentaService(db, appCfg.Cuentas.DiasGraciaSuspension)
+	go cuentaService.IniciarRevision(context.Background())
+
 	// Configurar rutas
-	router := rutas.SetupRutas(db, appCfg)
+	router := rutas.SetupRutas(db, appCfg, facturacionService, cuentaService, firmaDigitalService)
 
 	// Configurar servidor
 	server := &http.Server{
@@ -58,6 +98,22 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// HTTPS / mTLS opcional
+	if appCfg.TLS.HabilitadoTLS() {
+		tlsCfg, err := appCfg.TLS.ConfigServidorTLS()
+		if err != nil {
+			logger.Error.Fatalf("Configuración TLS inválida: %v", err)
+		}
+		server.TLSConfig = tlsCfg
+
+		logger.Info.Printf("🚀 Servidor iniciado con TLS en puerto %s (mTLS: %v, entorno: %s)",
+			appCfg.ServerPort, appCfg.TLS.ClientCAFile != "", appEnv)
+		if err := server.ListenAndServeTLS(appCfg.TLS.CertFile, appCfg.TLS.KeyFile); err != nil && err != http.ErrServerClosed {
+			logger.Error.Fatalf("No se pudo iniciar el servidor: %v", err)
+		}
+		return
+	}
+
 	logger.Info.Printf("🚀 Servidor iniciado en puerto %s (entorno: %s)", appCfg.ServerPort, appEnv)
 
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {